@@ -0,0 +1,73 @@
+package analytics
+
+import (
+	"sort"
+	"time"
+)
+
+// Stats are the audience numbers of a set of viewer sessions.
+type Stats struct {
+	UniqueViewers  int     `json:"unique_viewers"`
+	Views          int     `json:"views"` // sessions
+	WatchMinutes   float64 `json:"watch_minutes"`
+	AvgViewSeconds float64 `json:"avg_view_seconds"`
+	PeakViewers    int     `json:"peak_viewers"`
+}
+
+// Rollup computes Stats over the parts of sessions that fall within
+// [from, to). Sessions entirely outside are ignored.
+func Rollup(sessions []Session, from, to time.Time) Stats {
+	type event struct {
+		at    time.Time
+		delta int
+	}
+	var (
+		st      Stats
+		seconds float64
+		events  []event
+	)
+	viewers := make(map[string]struct{})
+	for _, s := range sessions {
+		joined, left := s.Joined, s.Left
+		if joined.Before(from) {
+			joined = from
+		}
+		if left.After(to) {
+			left = to
+		}
+		if !joined.Before(to) || left.Before(joined) || s.Left.Before(from) {
+			continue
+		}
+		st.Views++
+		viewers[s.Viewer] = struct{}{}
+		seconds += left.Sub(joined).Seconds()
+		events = append(events, event{joined, 1}, event{left, -1})
+	}
+	if st.Views == 0 {
+		return st
+	}
+	st.UniqueViewers = len(viewers)
+	st.WatchMinutes = round2(seconds / 60)
+	st.AvgViewSeconds = round2(seconds / float64(st.Views))
+
+	// Joins sort before leaves at the same instant, so a session of a
+	// single heartbeat still counts as watching.
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at.Equal(events[j].at) {
+			return events[i].delta > events[j].delta
+		}
+		return events[i].at.Before(events[j].at)
+	})
+	n := 0
+	for _, e := range events {
+		n += e.delta
+		if n > st.PeakViewers {
+			st.PeakViewers = n
+		}
+	}
+	return st
+}
+
+func round2(f float64) float64 {
+	return float64(int64(f*100+0.5)) / 100
+}
@@ -0,0 +1,178 @@
+// Package analytics follows who watches which live broadcast and rolls
+// the resulting viewer sessions up into audience numbers.
+//
+// Viewers are not connected to the API while they watch; a player only
+// refetches the live playlist every few seconds. The Tracker treats each
+// fetch as a heartbeat: the first one opens a session, and a session
+// ends when its heartbeats stop for longer than the timeout or the
+// broadcast ends. Sessions live in memory until they end, so those open
+// during a restart are lost.
+package analytics
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Broadcast identifies the broadcast a stream is currently carrying.
+type Broadcast struct {
+	Channel string // channel (user) ID
+	ID      string // broadcast ID
+	Started time.Time
+}
+
+// Session is one viewer watching one broadcast from Joined to Left.
+type Session struct {
+	Broadcast
+	Viewer string // anonymized, see ViewerID
+	Joined time.Time
+	Left   time.Time
+}
+
+// ViewerID derives the anonymized ID a viewer is recorded under from a
+// stable identifier (a user ID, or a playback session for anonymous
+// viewers). The same key and id always give the same ID, so unique
+// viewers can be counted without storing who they are.
+func ViewerID(key []byte, kind, id string) string {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(kind + ":" + id))
+	return hex.EncodeToString(m.Sum(nil)[:12])
+}
+
+// Tracker keeps the open viewer sessions of live broadcasts.
+type Tracker struct {
+	timeout time.Duration
+	onLeave func([]Session)
+
+	mu   sync.Mutex
+	live map[string]*liveBroadcast // by stream name
+}
+
+type liveBroadcast struct {
+	Broadcast
+	viewers map[string]*Session // by viewer, Left is the last heartbeat
+	peak    int
+}
+
+// NewTracker returns a tracker that ends sessions without a heartbeat for
+// timeout and hands ended sessions to onLeave, which must not block for
+// long.
+func NewTracker(timeout time.Duration, onLeave func([]Session)) *Tracker {
+	return &Tracker{timeout: timeout, onLeave: onLeave, live: make(map[string]*liveBroadcast)}
+}
+
+// Run sweeps idle sessions every interval until stop is closed.
+func (t *Tracker) Run(interval time.Duration, stop <-chan struct{}) {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case now := <-tick.C:
+			t.Sweep(now)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Begin starts counting viewers of stream for broadcast b. Sessions of a
+// previous broadcast of the stream are ended first.
+func (t *Tracker) Begin(stream string, b Broadcast) {
+	t.mu.Lock()
+	lb := t.live[stream]
+	if lb != nil && lb.ID == b.ID {
+		t.mu.Unlock()
+		return
+	}
+	var ended []Session
+	if lb != nil {
+		ended = lb.endAll()
+	}
+	t.live[stream] = &liveBroadcast{Broadcast: b, viewers: make(map[string]*Session)}
+	t.mu.Unlock()
+	t.leave(ended)
+}
+
+// End ends every session of the stream's broadcast at now.
+func (t *Tracker) End(stream string, now time.Time) {
+	t.mu.Lock()
+	lb := t.live[stream]
+	delete(t.live, stream)
+	var ended []Session
+	if lb != nil {
+		for _, s := range lb.viewers {
+			if s.Left.Before(now) && now.Sub(s.Left) < t.timeout {
+				s.Left = now // was still watching
+			}
+		}
+		ended = lb.endAll()
+	}
+	t.mu.Unlock()
+	t.leave(ended)
+}
+
+// Seen records a heartbeat of viewer watching stream. It reports false
+// if no broadcast of stream is known, in which case Begin must be called
+// first.
+func (t *Tracker) Seen(stream, viewer string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lb := t.live[stream]
+	if lb == nil {
+		return false
+	}
+	if s := lb.viewers[viewer]; s != nil {
+		s.Left = now
+		return true
+	}
+	lb.viewers[viewer] = &Session{Broadcast: lb.Broadcast, Viewer: viewer, Joined: now, Left: now}
+	if n := len(lb.viewers); n > lb.peak {
+		lb.peak = n
+	}
+	return true
+}
+
+// Viewers returns how many viewers watch stream now and the most that
+// watched at once during its broadcast.
+func (t *Tracker) Viewers(stream string) (current, peak int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if lb := t.live[stream]; lb != nil {
+		return len(lb.viewers), lb.peak
+	}
+	return 0, 0
+}
+
+// Sweep ends the sessions whose last heartbeat is older than the timeout.
+func (t *Tracker) Sweep(now time.Time) {
+	var ended []Session
+	t.mu.Lock()
+	for _, lb := range t.live {
+		for v, s := range lb.viewers {
+			if now.Sub(s.Left) > t.timeout {
+				ended = append(ended, *s)
+				delete(lb.viewers, v)
+			}
+		}
+	}
+	t.mu.Unlock()
+	t.leave(ended)
+}
+
+func (t *Tracker) leave(ended []Session) {
+	if len(ended) > 0 && t.onLeave != nil {
+		t.onLeave(ended)
+	}
+}
+
+func (lb *liveBroadcast) endAll() []Session {
+	ended := make([]Session, 0, len(lb.viewers))
+	for _, s := range lb.viewers {
+		ended = append(ended, *s)
+	}
+	lb.viewers = nil
+	return ended
+}
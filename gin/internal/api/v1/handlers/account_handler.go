@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+)
+
+// AccountHandler deletes accounts.
+type AccountHandler struct {
+	svc *service.Service
+}
+
+// NewAccountHandler returns a AccountHandler using svc.
+func NewAccountHandler(svc *service.Service) *AccountHandler {
+	return &AccountHandler{svc: svc}
+}
+
+// DeleteAccountRequest is the JSON payload for DELETE /account.
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// DeleteAccount godoc
+// @Summary      Permanently delete the authenticated user's account.
+// @Description  Requires the current password. Ends a running broadcast and removes the
+// @Description  user's stream, recordings, clips, follows, chat messages and sessions.
+// @Tags         account
+// @Accept       json
+// @Param        body body DeleteAccountRequest true "Password confirmation"
+// @Success      204
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      502 {object} map[string]string
+// @Router       /account [delete]
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.svc.DeleteAccount(c.Request.Context(), userID, req.Password)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, service.ErrWrongPassword):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrIngestUnreachable):
+		c.JSON(http.StatusBadGateway, gin.H{"error": "account deleted, but the ingest server could not be reached to end its broadcast"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete account"})
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/analytics"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AnalyticsHandler serves channel analytics.
+type AnalyticsHandler struct {
+	svc *service.Service
+}
+
+// NewAnalyticsHandler returns a AnalyticsHandler using svc.
+func NewAnalyticsHandler(svc *service.Service) *AnalyticsHandler {
+	return &AnalyticsHandler{svc: svc}
+}
+
+var analyticsCSVHeader = []string{
+	"unique_viewers", "views", "watch_minutes", "avg_view_seconds", "peak_viewers",
+	"chat_messages", "new_followers",
+}
+
+// GetDailyAnalytics godoc
+// @Summary      Per-day analytics of the authenticated user's channel.
+// @Description  Unique viewers, views, watch minutes, average view duration, peak concurrent
+// @Description  viewers, chat messages and new followers for every UTC day from `from` to `to`
+// @Description  (inclusive, default the last 30 days, at most 366), with totals over the range.
+// @Description  format=csv downloads the days as CSV.
+// @Tags         analytics
+// @Produce      json,text/csv
+// @Param        from   query string false "First day, YYYY-MM-DD"
+// @Param        to     query string false "Last day, YYYY-MM-DD"
+// @Param        format query string false "json (default) or csv"
+// @Success      200 {object} service.DailyReport
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Router       /stream/analytics/daily [get]
+func (h *AnalyticsHandler) GetDailyAnalytics(c *gin.Context) {
+	userID, r, ok := analyticsRequest(c)
+	if !ok {
+		return
+	}
+	report, err := h.svc.GetDailyAnalytics(c.Request.Context(), userID, r)
+	if err != nil {
+		writeAnalyticsError(c, err)
+		return
+	}
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	rows := make([][]string, 0, len(report.Days))
+	for _, d := range report.Days {
+		rows = append(rows, append([]string{d.Day}, analyticsCSVRow(d.Stats, d.AnalyticsCounters)...))
+	}
+	writeAnalyticsCSV(c, fmt.Sprintf("analytics-daily-%s-%s.csv", r.From, r.To),
+		append([]string{"day"}, analyticsCSVHeader...), rows)
+}
+
+// ListBroadcastAnalytics godoc
+// @Summary      Per-broadcast analytics of the authenticated user's channel.
+// @Description  The numbers of each broadcast that started from `from` to `to` (inclusive,
+// @Description  default the last 30 days), newest first. format=csv downloads them as CSV.
+// @Tags         analytics
+// @Produce      json,text/csv
+// @Param        from   query string false "First day, YYYY-MM-DD"
+// @Param        to     query string false "Last day, YYYY-MM-DD"
+// @Param        format query string false "json (default) or csv"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Router       /stream/analytics/broadcasts [get]
+func (h *AnalyticsHandler) ListBroadcastAnalytics(c *gin.Context) {
+	userID, r, ok := analyticsRequest(c)
+	if !ok {
+		return
+	}
+	broadcasts, err := h.svc.ListBroadcastAnalytics(c.Request.Context(), userID, r)
+	if err != nil {
+		writeAnalyticsError(c, err)
+		return
+	}
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, gin.H{"from": r.From, "to": r.To, "broadcasts": broadcasts})
+		return
+	}
+
+	rows := make([][]string, 0, len(broadcasts))
+	for _, b := range broadcasts {
+		ended := ""
+		if b.EndedAt != nil {
+			ended = b.EndedAt.Format(time.RFC3339)
+		}
+		rows = append(rows, append([]string{b.BroadcastID.Hex(), b.Title, b.Category, b.StartedAt.Format(time.RFC3339), ended},
+			analyticsCSVRow(b.Stats, b.AnalyticsCounters)...))
+	}
+	writeAnalyticsCSV(c, fmt.Sprintf("analytics-broadcasts-%s-%s.csv", r.From, r.To),
+		append([]string{"broadcast_id", "title", "category", "started_at", "ended_at"}, analyticsCSVHeader...), rows)
+}
+
+// GetBroadcastAnalytics godoc
+// @Summary      Analytics of one of the authenticated user's broadcasts.
+// @Tags         analytics
+// @Produce      json
+// @Param        id path string true "Broadcast ID"
+// @Success      200 {object} service.BroadcastAnalytics
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /stream/analytics/broadcasts/{id} [get]
+func (h *AnalyticsHandler) GetBroadcastAnalytics(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrBroadcastNotFound.Error()})
+		return
+	}
+	b, err := h.svc.GetBroadcastAnalytics(c.Request.Context(), userID, id)
+	if err != nil {
+		writeAnalyticsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, b)
+}
+
+// analyticsRequest reads the user and date range of a report request.
+func analyticsRequest(c *gin.Context) (primitive.ObjectID, service.AnalyticsRange, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return userID, service.AnalyticsRange{}, false
+	}
+	r, err := service.ParseAnalyticsRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		writeAnalyticsError(c, err)
+		return userID, r, false
+	}
+	return userID, r, true
+}
+
+func analyticsCSVRow(s analytics.Stats, n service.AnalyticsCounters) []string {
+	return []string{
+		strconv.Itoa(s.UniqueViewers),
+		strconv.Itoa(s.Views),
+		strconv.FormatFloat(s.WatchMinutes, 'f', 2, 64),
+		strconv.FormatFloat(s.AvgViewSeconds, 'f', 2, 64),
+		strconv.Itoa(s.PeakViewers),
+		strconv.FormatInt(n.ChatMessages, 10),
+		strconv.FormatInt(n.NewFollowers, 10),
+	}
+}
+
+func writeAnalyticsCSV(c *gin.Context, filename string, header []string, rows [][]string) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(header)
+	_ = w.WriteAll(rows)
+}
+
+func writeAnalyticsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrBroadcastNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load analytics"})
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+)
+
+// BroadcastHandler lists past broadcasts.
+type BroadcastHandler struct {
+	svc *service.Service
+}
+
+// NewBroadcastHandler returns a BroadcastHandler using svc.
+func NewBroadcastHandler(svc *service.Service) *BroadcastHandler {
+	return &BroadcastHandler{svc: svc}
+}
+
+// ListBroadcasts godoc
+// @Summary      Past and current broadcasts of a channel, newest first.
+// @Description  Each broadcast keeps the title, category and tags it started with, its start
+// @Description  and end, duration, peak concurrent viewers, title and category changes made
+// @Description  while live, and video_id when its recording was kept. Unlisted and private
+// @Description  broadcasts follow the same rules as recordings.
+// @Tags         broadcasts
+// @Produce      json
+// @Param        username path  string true  "Channel username"
+// @Param        cursor   query string false "Pagination cursor"
+// @Param        limit    query int    false "Page size (default 20, at most limits.max_page_size)"
+// @Success      200 {object} service.BroadcastPage
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /users/{username}/broadcasts [get]
+func (h *BroadcastHandler) ListBroadcasts(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, err := h.svc.ListBroadcasts(c.Request.Context(), c.Param("username"), optionalUserID(c), c.Query("cursor"), limit)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, page)
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+)
+
+// CategoryHandler serves categories, browsing and trending tags.
+type CategoryHandler struct {
+	svc *service.Service
+}
+
+// NewCategoryHandler returns a CategoryHandler using svc.
+func NewCategoryHandler(svc *service.Service) *CategoryHandler {
+	return &CategoryHandler{svc: svc}
+}
+
+// CategoryRequest is the admin payload for creating or editing a category.
+// Slug is taken from the URL on update and ignored in the body.
+type CategoryRequest struct {
+	Slug      string  `json:"slug"`
+	Name      *string `json:"name" binding:"omitempty,max=40"`
+	Icon      *string `json:"icon" binding:"omitempty,max=64"`
+	SortOrder *int    `json:"sort_order"`
+}
+
+// ListCategories godoc
+// @Summary      List the category catalog with live stream counts.
+// @Tags         categories
+// @Produce      json
+// @Success      200 {array} repo.CategoryLiveCount
+// @Failure      500 {object} map[string]string
+// @Router       /categories [get]
+func (h *CategoryHandler) ListCategories(c *gin.Context) {
+	cats, err := h.svc.ListCategories(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list categories"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"categories": cats})
+}
+
+// CreateCategory godoc
+// @Summary      Add a category to the catalog (admin only).
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        payload body CategoryRequest true "Category"
+// @Success      201 {object} models.Category
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /admin/categories [post]
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cat, err := h.svc.CreateCategory(c.Request.Context(), service.CategoryInput{
+		Slug:      req.Slug,
+		Name:      req.Name,
+		Icon:      req.Icon,
+		SortOrder: req.SortOrder,
+	})
+	if err != nil {
+		writeCategoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, cat)
+}
+
+// UpdateCategory godoc
+// @Summary      Edit a category's name, icon or sort order (admin only).
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        slug    path string          true "Category slug"
+// @Param        payload body CategoryRequest true "Fields to change"
+// @Success      200 {object} models.Category
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /admin/categories/{slug} [put]
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cat, err := h.svc.UpdateCategory(c.Request.Context(), service.CategoryInput{
+		Slug:      c.Param("slug"),
+		Name:      req.Name,
+		Icon:      req.Icon,
+		SortOrder: req.SortOrder,
+	})
+	if err != nil {
+		writeCategoryError(c, err)
+		return
+	}
+	if cat == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		return
+	}
+	c.JSON(http.StatusOK, cat)
+}
+
+// DeleteCategory godoc
+// @Summary      Remove an unused category (admin only).
+// @Tags         admin
+// @Param        slug path string true "Category slug"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /admin/categories/{slug} [delete]
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	deleted, err := h.svc.DeleteCategory(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		writeCategoryError(c, err)
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "category not found"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// BrowseCategory godoc
+// @Summary      List live streams in a category.
+// @Tags         browse
+// @Produce      json
+// @Param        slug   path  string true  "Category slug"
+// @Param        cursor query string false "Pagination cursor"
+// @Param        limit  query int    false "Page size (default 20, at most limits.max_page_size)"
+// @Success      200 {object} service.SearchResult
+// @Failure      404 {object} map[string]string
+// @Router       /browse/categories/{slug}/streams [get]
+func (h *CategoryHandler) BrowseCategory(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	res, err := h.svc.BrowseCategory(c.Request.Context(), c.Param("slug"), c.Query("cursor"), limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnknownCategory):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidCursor):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to browse category"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// TrendingTags godoc
+// @Summary      Tags used by the most channels live in the last N hours.
+// @Tags         browse
+// @Produce      json
+// @Param        hours query int false "Window in hours (default 24, max 168)"
+// @Param        limit query int false "Max tags (default 20, at most limits.max_page_size)"
+// @Success      200 {object} map[string]interface{}
+// @Router       /browse/tags/trending [get]
+func (h *CategoryHandler) TrendingTags(c *gin.Context) {
+	hours, _ := strconv.Atoi(c.Query("hours"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	tags, err := h.svc.TrendingTags(c.Request.Context(), hours, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compute trending tags"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+func writeCategoryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCategory):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCategoryExists), errors.Is(err, service.ErrCategoryInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/chat"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+	"github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ChatHandler serves chat rooms and their history.
+type ChatHandler struct {
+	svc  *service.Service
+	auth *service.AuthService
+}
+
+// NewChatHandler returns a ChatHandler using svc, and auth to look up who is posting.
+func NewChatHandler(svc *service.Service, auth *service.AuthService) *ChatHandler {
+	return &ChatHandler{svc: svc, auth: auth}
+}
+
+// chatUpgrader accepts any Origin: chat authenticates with an explicit
+// session token rather than cookies, so a foreign page cannot ride on a
+// visitor's credentials.
+var chatUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(*http.Request) bool { return true },
+}
+
+// ChatSocket godoc
+// @Summary      WebSocket chat for a channel.
+// @Description  Upgrades to a WebSocket. A valid session (?session_id= for browsers) can
+// @Description  send {"type":"message","text":"..."}; without one the connection is read-only.
+// @Description  The server sends a "history" event first, then "message", "system" and "error" events.
+// @Description  The room follows the stream's visibility and subscriber-only setting; anonymous
+// @Description  invite holders pass the playback token they were given as ?token=.
+// @Tags         chat
+// @Param        username path  string true  "Channel username"
+// @Param        token    query string false "Playback token"
+// @Success      101
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /chat/{username}/ws [get]
+func (h *ChatHandler) ChatSocket(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// Logged-in users may post; everyone else only reads.
+	var userID primitive.ObjectID
+	var username, displayName string
+	if hex := c.GetString("userID"); hex != "" {
+		if id, err := primitive.ObjectIDFromHex(hex); err == nil {
+			if user, err := h.auth.GetUser(ctx, id); err == nil && user != nil {
+				userID, username, displayName = user.ID, user.Username, user.DisplayName
+			}
+		}
+	}
+
+	channel, err := h.svc.OpenChatRoom(ctx, c.Param("username"), userID, c.Query("token"))
+	if err != nil {
+		writeChatRoomError(c, err)
+		return
+	}
+
+	conn, err := chatUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // the upgrader already replied
+	}
+	client := chat.NewClient(conn, userID, username, displayName)
+	go client.WritePump()
+
+	if err := h.svc.JoinChat(ctx, channel, client); err != nil {
+		client.Close()
+		return
+	}
+	defer h.svc.LeaveChat(channel, client)
+
+	client.ReadPump(func(in chat.Inbound) {
+		h.svc.HandleChatFrame(ctx, channel, client, in)
+	})
+}
+
+// ListChatMessages godoc
+// @Summary      A channel's chat history, newest first.
+// @Tags         chat
+// @Produce      json
+// @Param        username path  string true  "Channel username"
+// @Param        cursor   query string false "Pagination cursor"
+// @Param        limit    query int    false "Page size (default 20, at most limits.max_page_size)"
+// @Param        token    query string false "Playback token, for anonymous invite holders"
+// @Success      200 {object} service.ChatPage
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /chat/{username}/messages [get]
+func (h *ChatHandler) ListChatMessages(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, err := h.svc.ListChatMessages(c.Request.Context(), c.Param("username"), optionalUserID(c), c.Query("token"), c.Query("cursor"), limit)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		writeChatRoomError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// writeChatRoomError answers a refused chat room like the playback path:
+// 404 for channels the viewer may not see, 403 for subscriber-only ones.
+func writeChatRoomError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "channel not found"})
+	case errors.Is(err, service.ErrSubscriptionRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "subscription_required"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
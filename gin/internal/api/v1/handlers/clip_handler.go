@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/playback"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ClipHandler serves clips.
+type ClipHandler struct {
+	svc *service.Service
+}
+
+// NewClipHandler returns a ClipHandler using svc.
+func NewClipHandler(svc *service.Service) *ClipHandler {
+	return &ClipHandler{svc: svc}
+}
+
+// CreateClipRequest asks for a clip of the last DurationSeconds of a live channel.
+type CreateClipRequest struct {
+	Channel         string `json:"channel" binding:"required"`
+	Title           string `json:"title"`
+	DurationSeconds int    `json:"duration_seconds"`
+}
+
+// CreateClip godoc
+// @Summary      Clip the last 10-60 seconds (default 30) of a live channel.
+// @Tags         clips
+// @Accept       json
+// @Produce      json
+// @Param        body body CreateClipRequest true "Clip details"
+// @Success      201 {object} models.Clip
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /clips [post]
+func (h *ClipHandler) CreateClip(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req CreateClipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	clip, err := h.svc.CreateClip(c.Request.Context(), userID, service.ClipInput{
+		Channel:         req.Channel,
+		Title:           req.Title,
+		DurationSeconds: req.DurationSeconds,
+	})
+	if err != nil {
+		writeClipError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, clip)
+}
+
+// ListClips godoc
+// @Summary      Clips of a channel, newest first.
+// @Tags         clips
+// @Produce      json
+// @Param        username path  string true  "Channel username"
+// @Param        cursor   query string false "Pagination cursor"
+// @Param        limit    query int    false "Page size (default 20, at most limits.max_page_size)"
+// @Success      200 {object} service.ClipPage
+// @Failure      404 {object} map[string]string
+// @Router       /users/{username}/clips [get]
+func (h *ClipHandler) ListClips(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, err := h.svc.ListClips(c.Request.Context(), c.Param("username"), optionalUserID(c), c.Query("cursor"), limit)
+	if err != nil {
+		writeClipError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetClip godoc
+// @Summary      Metadata of a clip.
+// @Description  Play it from /clips/{id}/index.m3u8.
+// @Tags         clips
+// @Produce      json
+// @Param        id path string true "Clip ID"
+// @Success      200 {object} models.Clip
+// @Failure      404 {object} map[string]string
+// @Router       /clips/{id} [get]
+func (h *ClipHandler) GetClip(c *gin.Context) {
+	id, ok := clipID(c)
+	if !ok {
+		return
+	}
+	clip, err := h.svc.GetClip(c.Request.Context(), id, optionalUserID(c))
+	if err != nil {
+		writeClipError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, clip)
+}
+
+// ServeClipFile godoc
+// @Summary      Clip playback: the HLS playlist (index.m3u8) or one of its segments.
+// @Description  Each playlist fetch counts as a view. Private clips need ?token= from
+// @Description  POST /streams/{username}/playback-token.
+// @Tags         clips
+// @Produce      application/vnd.apple.mpegurl
+// @Param        id    path  string true  "Clip ID"
+// @Param        file  path  string true  "index.m3u8 or a segment name"
+// @Param        token query string false "Playback token (private clips)"
+// @Success      200
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /clips/{id}/{file} [get]
+func (h *ClipHandler) ServeClipFile(c *gin.Context) {
+	id, ok := clipID(c)
+	if !ok {
+		return
+	}
+	path, token, err := h.svc.ClipFilePath(c.Request.Context(), id, c.Param("file"), c.Query("token"))
+	if err != nil {
+		writeClipError(c, err)
+		return
+	}
+	serveHLSFile(c, path, token, false)
+}
+
+// DeleteClip godoc
+// @Summary      Delete a clip. Allowed for its creator and the channel's broadcaster and moderators.
+// @Tags         clips
+// @Param        id path string true "Clip ID"
+// @Success      204
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /clips/{id} [delete]
+func (h *ClipHandler) DeleteClip(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := clipID(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteClip(c.Request.Context(), userID, id); err != nil {
+		writeClipError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// clipID parses the :id path parameter, answering 404 if it is malformed.
+func clipID(c *gin.Context) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrClipNotFound.Error()})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+func writeClipError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrClipNotFound), errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrPlaybackDenied),
+		errors.Is(err, service.ErrSubscriptionRequired),
+		errors.Is(err, playback.ErrInvalidToken), errors.Is(err, playback.ErrTokenExpired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrStreamOffline):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrClipRateLimited):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidClip), errors.Is(err, service.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
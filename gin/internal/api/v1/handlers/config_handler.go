@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/config"
+)
+
+// ConfigHandler shows the running configuration to admins.
+type ConfigHandler struct {
+	cfg *config.Config
+}
+
+// NewConfigHandler returns a ConfigHandler for cfg.
+func NewConfigHandler(cfg *config.Config) *ConfigHandler {
+	return &ConfigHandler{cfg: cfg}
+}
+
+// GetConfig godoc
+// @Summary      Show the running configuration
+// @Description  Returns every setting keyed like the YAML config file. Secrets are redacted and the Mongo URI password is masked. Admins only.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Router       /admin/config [get]
+func (h *ConfigHandler) GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, h.cfg.Dump())
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// currentUserID returns the ObjectID stored by middleware.SessionCheck.
+// If it is missing or malformed an error response has already been
+// written and ok is false.
+func currentUserID(c *gin.Context) (id primitive.ObjectID, ok bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return primitive.NilObjectID, false
+	}
+	objID, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid user id"})
+		return primitive.NilObjectID, false
+	}
+	return objID, true
+}
+
+// optionalUserID returns the ObjectID stored by middleware.OptionalSession,
+// or NilObjectID for anonymous visitors.
+func optionalUserID(c *gin.Context) primitive.ObjectID {
+	id, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if err != nil {
+		return primitive.NilObjectID
+	}
+	return id
+}
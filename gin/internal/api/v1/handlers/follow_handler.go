@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+)
+
+// FollowHandler serves profiles, follows and the following feed.
+type FollowHandler struct {
+	svc *service.Service
+}
+
+// NewFollowHandler returns a FollowHandler using svc.
+func NewFollowHandler(svc *service.Service) *FollowHandler {
+	return &FollowHandler{svc: svc}
+}
+
+// GetProfile godoc
+// @Summary      Public profile of a user, including follower counts.
+// @Tags         users
+// @Produce      json
+// @Param        username path string true "Username"
+// @Success      200 {object} models.PublicProfile
+// @Failure      404 {object} map[string]string
+// @Router       /users/{username} [get]
+func (h *FollowHandler) GetProfile(c *gin.Context) {
+	p, err := h.svc.GetProfile(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		writeFollowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// FollowChannel godoc
+// @Summary      Follow a channel as the authenticated user.
+// @Tags         follows
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Success      200 {object} map[string]bool
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /users/{username}/follow [post]
+func (h *FollowHandler) FollowChannel(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	created, err := h.svc.Follow(c.Request.Context(), userID, c.Param("username"))
+	if err != nil {
+		writeFollowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"following": true, "created": created})
+}
+
+// UnfollowChannel godoc
+// @Summary      Unfollow a channel as the authenticated user.
+// @Tags         follows
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Success      200 {object} map[string]bool
+// @Failure      404 {object} map[string]string
+// @Router       /users/{username}/follow [delete]
+func (h *FollowHandler) UnfollowChannel(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	deleted, err := h.svc.Unfollow(c.Request.Context(), userID, c.Param("username"))
+	if err != nil {
+		writeFollowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"following": false, "deleted": deleted})
+}
+
+// GetFollowStatus godoc
+// @Summary      Whether the authenticated user follows a channel.
+// @Tags         follows
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Success      200 {object} map[string]bool
+// @Failure      404 {object} map[string]string
+// @Router       /users/{username}/follow [get]
+func (h *FollowHandler) GetFollowStatus(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	following, err := h.svc.IsFollowing(c.Request.Context(), userID, c.Param("username"))
+	if err != nil {
+		writeFollowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"following": following})
+}
+
+// ListFollowers godoc
+// @Summary      Users following a channel, newest first.
+// @Tags         follows
+// @Produce      json
+// @Param        username path  string true  "Channel username"
+// @Param        cursor   query string false "Pagination cursor"
+// @Param        limit    query int    false "Page size (default 20, at most limits.max_page_size)"
+// @Success      200 {object} service.FollowPage
+// @Failure      404 {object} map[string]string
+// @Router       /users/{username}/followers [get]
+func (h *FollowHandler) ListFollowers(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, err := h.svc.ListFollowers(c.Request.Context(), c.Param("username"), c.Query("cursor"), limit)
+	if err != nil {
+		writeFollowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// ListFollowing godoc
+// @Summary      Channels a user follows, newest first.
+// @Tags         follows
+// @Produce      json
+// @Param        username path  string true  "Username"
+// @Param        cursor   query string false "Pagination cursor"
+// @Param        limit    query int    false "Page size (default 20, at most limits.max_page_size)"
+// @Success      200 {object} service.FollowPage
+// @Failure      404 {object} map[string]string
+// @Router       /users/{username}/following [get]
+func (h *FollowHandler) ListFollowing(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, err := h.svc.ListFollowing(c.Request.Context(), c.Param("username"), c.Query("cursor"), limit)
+	if err != nil {
+		writeFollowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetFollowingFeed godoc
+// @Summary      Followed channels that are live now, then recently ended broadcasts.
+// @Tags         feed
+// @Produce      json
+// @Param        limit query int false "Max streams per section (default 20, at most limits.max_page_size)"
+// @Success      200 {object} service.FollowingFeed
+// @Failure      401 {object} map[string]string
+// @Router       /feed/following [get]
+func (h *FollowHandler) GetFollowingFeed(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	feed, err := h.svc.GetFollowingFeed(c.Request.Context(), userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build feed"})
+		return
+	}
+	c.JSON(http.StatusOK, feed)
+}
+
+func writeFollowError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCannotFollowSelf), errors.Is(err, service.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
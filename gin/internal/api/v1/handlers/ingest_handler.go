@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+)
+
+// IngestHandler receives the RTMP server's publish callbacks.
+type IngestHandler struct {
+	svc *service.Service
+}
+
+// NewIngestHandler returns a IngestHandler using svc.
+func NewIngestHandler(svc *service.Service) *IngestHandler {
+	return &IngestHandler{svc: svc}
+}
+
+// OnPublish godoc
+// @Summary      nginx-rtmp on_publish callback.
+// @Description  Authenticates the stream key sent as the RTMP stream name. On success the
+// @Description  publish is redirected to the owner's username so that HLS output is written
+// @Description  as <username>.m3u8 and the secret key never appears in playback URLs.
+// @Description  Channels suspended by an admin takedown are refused.
+// @Tags         ingest
+// @Accept       x-www-form-urlencoded
+// @Param        name formData string true "RTMP stream name (the stream key)"
+// @Success      302
+// @Failure      403 {object} map[string]string
+// @Router       /ingest/publish [post]
+func (h *IngestHandler) OnPublish(c *gin.Context) {
+	s, err := h.svc.StartPublish(c.Request.Context(), c.PostForm("name"))
+	if errors.Is(err, service.ErrStreamSuspended) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+	if s == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid stream key"})
+		return
+	}
+
+	// nginx-rtmp treats a Location without a scheme as the new stream name.
+	c.Header("Location", s.Username)
+	c.Status(http.StatusFound)
+}
+
+// OnPublishDone godoc
+// @Summary      nginx-rtmp on_publish_done callback.
+// @Tags         ingest
+// @Accept       x-www-form-urlencoded
+// @Param        name formData string true "RTMP stream name (the username after redirect)"
+// @Success      204
+// @Router       /ingest/publish_done [post]
+func (h *IngestHandler) OnPublishDone(c *gin.Context) {
+	if _, err := h.svc.EndPublish(c.Request.Context(), c.PostForm("name")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+)
+
+// LedgerHandler serves wallets, tips and honey grants.
+type LedgerHandler struct {
+	svc *service.Service
+}
+
+// NewLedgerHandler returns a LedgerHandler using svc.
+func NewLedgerHandler(svc *service.Service) *LedgerHandler {
+	return &LedgerHandler{svc: svc}
+}
+
+// idempotencyHeader lets clients retry a transfer without repeating it.
+const idempotencyHeader = "Idempotency-Key"
+
+// TipRequest is the JSON payload for POST /channels/:username/tips.
+type TipRequest struct {
+	Amount         int64  `json:"amount" binding:"required"`
+	Message        string `json:"message"`
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+// LedgerTransferRequest is the JSON payload for admin grants and adjustments.
+type LedgerTransferRequest struct {
+	Username       string `json:"username" binding:"required"`
+	Amount         int64  `json:"amount" binding:"required"`
+	Memo           string `json:"memo"`
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+// GetWallet godoc
+// @Summary      The authenticated user's honey balance.
+// @Tags         ledger
+// @Produce      json
+// @Success      200 {object} service.Wallet
+// @Failure      401 {object} map[string]string
+// @Router       /wallet [get]
+func (h *LedgerHandler) GetWallet(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	w, err := h.svc.GetWallet(c.Request.Context(), userID)
+	if err != nil {
+		writeLedgerError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
+
+// ListLedgerEntries godoc
+// @Summary      The authenticated user's statement, newest first.
+// @Tags         ledger
+// @Produce      json
+// @Param        cursor query string false "Pagination cursor"
+// @Param        limit  query int    false "Page size (default 20, at most limits.max_page_size)"
+// @Success      200 {object} service.LedgerPage
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Router       /wallet/entries [get]
+func (h *LedgerHandler) ListLedgerEntries(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, err := h.svc.ListLedgerEntries(c.Request.Context(), userID, c.Query("cursor"), limit)
+	if err != nil {
+		writeLedgerError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// TipChannel godoc
+// @Summary      Tip a channel in honey.
+// @Description  The optional message appears in the channel's chat and on the stream alert.
+// @Description  Retrying with the same Idempotency-Key header (or idempotency_key) returns the
+// @Description  original tip instead of tipping again.
+// @Tags         ledger
+// @Accept       json
+// @Produce      json
+// @Param        username        path   string     true  "Channel username"
+// @Param        Idempotency-Key header string     false "Client-chosen key for safe retries"
+// @Param        body            body   TipRequest true  "Amount and message"
+// @Success      201 {object} service.TipResult
+// @Success      200 {object} service.TipResult "Replayed"
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Router       /channels/{username}/tips [post]
+func (h *LedgerHandler) TipChannel(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req TipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.svc.Tip(c.Request.Context(), userID, c.Param("username"), service.TipInput{
+		Amount:         req.Amount,
+		Message:        req.Message,
+		IdempotencyKey: idempotencyKey(c, req.IdempotencyKey),
+	})
+	if err != nil {
+		writeLedgerError(c, err)
+		return
+	}
+	status := http.StatusCreated
+	if res.Replayed {
+		status = http.StatusOK
+	}
+	c.JSON(status, res)
+}
+
+// GrantHoney godoc
+// @Summary      Admin: issue honey to a user.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        Idempotency-Key header string                false "Client-chosen key for safe retries"
+// @Param        body            body   LedgerTransferRequest true  "Recipient, positive amount and memo"
+// @Success      201 {object} models.LedgerTransfer
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /admin/ledger/grants [post]
+func (h *LedgerHandler) GrantHoney(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req LedgerTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	t, err := h.svc.GrantHoney(c.Request.Context(), adminID, req.Username, req.Amount, req.Memo, idempotencyKey(c, req.IdempotencyKey))
+	if err != nil {
+		writeLedgerError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, t)
+}
+
+// AdjustHoney godoc
+// @Summary      Admin: correct a user's honey balance.
+// @Description  A positive amount credits the user, a negative one debits them; a debit
+// @Description  cannot take the balance below zero. The memo is required.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        Idempotency-Key header string                false "Client-chosen key for safe retries"
+// @Param        body            body   LedgerTransferRequest true  "User, signed amount and memo"
+// @Success      201 {object} models.LedgerTransfer
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Router       /admin/ledger/adjustments [post]
+func (h *LedgerHandler) AdjustHoney(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req LedgerTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	t, err := h.svc.AdjustHoney(c.Request.Context(), adminID, req.Username, req.Amount, req.Memo, idempotencyKey(c, req.IdempotencyKey))
+	if err != nil {
+		writeLedgerError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, t)
+}
+
+// idempotencyKey prefers the header over the body field.
+func idempotencyKey(c *gin.Context, body string) string {
+	if k := c.GetHeader(idempotencyHeader); k != "" {
+		return k
+	}
+	return body
+}
+
+func writeLedgerError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTransfer), errors.Is(err, service.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrIdempotencyConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInsufficientFunds):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ledger request failed"})
+	}
+}
@@ -7,6 +7,17 @@ import (
 	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
 )
 
+// AuthHandler serves registration and login.
+type AuthHandler struct {
+	auth     *service.AuthService
+	sessions *service.SessionService
+}
+
+// NewAuthHandler returns an AuthHandler using the given services.
+func NewAuthHandler(auth *service.AuthService, sessions *service.SessionService) *AuthHandler {
+	return &AuthHandler{auth: auth, sessions: sessions}
+}
+
 // LoginRequest payload for /auth/login
 type LoginRequest struct {
 	EmailOrUsername string `json:"email_or_username" binding:"required"`
@@ -25,7 +36,7 @@ type LoginRequest struct {
 // @Failure      401  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /auth/login [post]
-func Login(c *gin.Context) {
+func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -37,7 +48,7 @@ func Login(c *gin.Context) {
 		EmailOrUsername: req.EmailOrUsername,
 		Password:        req.Password,
 	}
-	user, err := service.Authenticate(c.Request.Context(), &authReq)
+	user, err := h.auth.Authenticate(c.Request.Context(), &authReq)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
@@ -50,7 +61,7 @@ func Login(c *gin.Context) {
 
 	// ----- create a session -----
 	// Convert user.ID (primitive.ObjectID) to a hex string for the service.
-	sid, err := service.CreateSession(c.Request.Context(), user.ID.Hex())
+	sid, err := h.sessions.CreateSession(c.Request.Context(), user.ID.Hex())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
 		return
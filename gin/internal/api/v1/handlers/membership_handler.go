@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MembershipHandler serves membership tiers and memberships.
+type MembershipHandler struct {
+	svc *service.Service
+}
+
+// NewMembershipHandler returns a MembershipHandler using svc.
+func NewMembershipHandler(svc *service.Service) *MembershipHandler {
+	return &MembershipHandler{svc: svc}
+}
+
+// TierRequest is the JSON payload for POST /stream/tiers.
+type TierRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	Perks        []string `json:"perks"`
+	Price        int64    `json:"price" binding:"required"`
+	DurationDays int      `json:"duration_days"`
+}
+
+// UpdateTierRequest is the JSON payload for PATCH /stream/tiers/:id;
+// omitted fields are left as they are.
+type UpdateTierRequest struct {
+	Name         *string   `json:"name"`
+	Perks        *[]string `json:"perks"`
+	Price        *int64    `json:"price"`
+	DurationDays *int      `json:"duration_days"`
+}
+
+// SubscribeRequest is the JSON payload for POST /channels/:username/membership.
+type SubscribeRequest struct {
+	TierID string `json:"tier_id" binding:"required"`
+}
+
+// SubscribersOnlyRequest turns a subscriber-only restriction on or off.
+type SubscribersOnlyRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// ListMembershipTiers godoc
+// @Summary      The membership tiers a channel offers, cheapest first.
+// @Tags         memberships
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Success      200 {object} map[string]interface{}
+// @Failure      404 {object} map[string]string
+// @Router       /users/{username}/tiers [get]
+func (h *MembershipHandler) ListMembershipTiers(c *gin.Context) {
+	tiers, err := h.svc.ListMembershipTiers(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tiers": tiers})
+}
+
+// ListOwnMembershipTiers godoc
+// @Summary      The authenticated user's membership tiers, archived ones included.
+// @Tags         memberships
+// @Produce      json
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Router       /stream/tiers [get]
+func (h *MembershipHandler) ListOwnMembershipTiers(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	tiers, err := h.svc.ListOwnMembershipTiers(c.Request.Context(), userID)
+	if err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tiers": tiers})
+}
+
+// CreateMembershipTier godoc
+// @Summary      Offer a membership tier on the authenticated user's channel.
+// @Description  Members pay price honey every duration_days (default 30). At most 5 tiers.
+// @Tags         memberships
+// @Accept       json
+// @Produce      json
+// @Param        body body TierRequest true "Tier"
+// @Success      201 {object} models.MembershipTier
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Router       /stream/tiers [post]
+func (h *MembershipHandler) CreateMembershipTier(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req TierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	t, err := h.svc.CreateMembershipTier(c.Request.Context(), userID, service.TierInput{
+		Name:         req.Name,
+		Perks:        req.Perks,
+		Price:        req.Price,
+		DurationDays: req.DurationDays,
+	})
+	if err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, t)
+}
+
+// UpdateMembershipTier godoc
+// @Summary      Edit one of the authenticated user's membership tiers.
+// @Description  Price and duration changes apply to members from their next renewal.
+// @Tags         memberships
+// @Accept       json
+// @Produce      json
+// @Param        id   path string            true "Tier ID"
+// @Param        body body UpdateTierRequest true "Fields to change"
+// @Success      200 {object} models.MembershipTier
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /stream/tiers/{id} [patch]
+func (h *MembershipHandler) UpdateMembershipTier(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := tierID(c)
+	if !ok {
+		return
+	}
+	var req UpdateTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	t, err := h.svc.UpdateMembershipTier(c.Request.Context(), userID, id, service.TierUpdate{
+		Name:         req.Name,
+		Perks:        req.Perks,
+		Price:        req.Price,
+		DurationDays: req.DurationDays,
+	})
+	if err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// ArchiveMembershipTier godoc
+// @Summary      Stop offering one of the authenticated user's membership tiers.
+// @Description  Its members keep their perks until their period ends; it is not renewed.
+// @Tags         memberships
+// @Param        id path string true "Tier ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Router       /stream/tiers/{id} [delete]
+func (h *MembershipHandler) ArchiveMembershipTier(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := tierID(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.ArchiveMembershipTier(c.Request.Context(), userID, id); err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListChannelMembers godoc
+// @Summary      Active members of the authenticated user's channel, newest first.
+// @Tags         memberships
+// @Produce      json
+// @Param        cursor query string false "Pagination cursor"
+// @Param        limit  query int    false "Page size (default 20, at most limits.max_page_size)"
+// @Success      200 {object} service.MemberPage
+// @Failure      400 {object} map[string]string
+// @Router       /stream/members [get]
+func (h *MembershipHandler) ListChannelMembers(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, err := h.svc.ListChannelMembers(c.Request.Context(), userID, c.Query("cursor"), limit)
+	if err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// SetStreamSubscribersOnly godoc
+// @Summary      Restrict playback of the authenticated user's stream to members.
+// @Description  The stream is still listed as its visibility allows. Recordings made while
+// @Description  it is on are subscriber-only too.
+// @Tags         memberships
+// @Accept       json
+// @Produce      json
+// @Param        body body SubscribersOnlyRequest true "On or off"
+// @Success      200 {object} models.Stream
+// @Failure      400 {object} map[string]string
+// @Router       /stream/subscribers-only [put]
+func (h *MembershipHandler) SetStreamSubscribersOnly(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req SubscribersOnlyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s, err := h.svc.SetStreamSubscribersOnly(c.Request.Context(), userID, *req.Enabled)
+	if err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// SetVideoSubscribersOnly godoc
+// @Summary      Restrict playback of one of your videos to members.
+// @Tags         memberships
+// @Accept       json
+// @Produce      json
+// @Param        id   path string                 true "Video ID"
+// @Param        body body SubscribersOnlyRequest true "On or off"
+// @Success      200 {object} models.Video
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /videos/{id}/subscribers-only [put]
+func (h *MembershipHandler) SetVideoSubscribersOnly(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := videoID(c)
+	if !ok {
+		return
+	}
+	var req SubscribersOnlyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	v, err := h.svc.SetVideoSubscribersOnly(c.Request.Context(), userID, id, *req.Enabled)
+	if err != nil {
+		writeVideoError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, v)
+}
+
+// ListMyMemberships godoc
+// @Summary      The authenticated user's memberships, active ones first.
+// @Tags         memberships
+// @Produce      json
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Router       /memberships [get]
+func (h *MembershipHandler) ListMyMemberships(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	ms, err := h.svc.ListMyMemberships(c.Request.Context(), userID)
+	if err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"memberships": ms})
+}
+
+// GetMembership godoc
+// @Summary      The authenticated user's membership of a channel.
+// @Tags         memberships
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Success      200 {object} models.Membership
+// @Failure      404 {object} map[string]string
+// @Router       /channels/{username}/membership [get]
+func (h *MembershipHandler) GetMembership(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	m, err := h.svc.GetMembership(c.Request.Context(), userID, c.Param("username"))
+	if err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, m)
+}
+
+// Subscribe godoc
+// @Summary      Become a member of a channel.
+// @Description  Pays the tier's price for the first period; the membership renews
+// @Description  automatically until cancelled. Subscribing again to the tier of a running
+// @Description  membership turns renewal back on without charging.
+// @Tags         memberships
+// @Accept       json
+// @Produce      json
+// @Param        username path string           true "Channel username"
+// @Param        body     body SubscribeRequest true "Tier"
+// @Success      201 {object} models.Membership
+// @Success      200 {object} models.Membership "Already a member"
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Router       /channels/{username}/membership [post]
+func (h *MembershipHandler) Subscribe(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req SubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(req.TierID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrTierNotFound.Error()})
+		return
+	}
+	m, created, err := h.svc.Subscribe(c.Request.Context(), userID, c.Param("username"), id)
+	if err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, m)
+}
+
+// CancelMembership godoc
+// @Summary      Stop renewing the authenticated user's membership of a channel.
+// @Description  The membership stays active until the end of the paid period.
+// @Tags         memberships
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Success      200 {object} models.Membership
+// @Failure      404 {object} map[string]string
+// @Router       /channels/{username}/membership [delete]
+func (h *MembershipHandler) CancelMembership(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	m, err := h.svc.CancelMembership(c.Request.Context(), userID, c.Param("username"))
+	if err != nil {
+		writeMembershipError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, m)
+}
+
+// tierID parses the :id path parameter, answering 404 if it is malformed.
+func tierID(c *gin.Context) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrTierNotFound.Error()})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+func writeMembershipError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrTierNotFound), errors.Is(err, service.ErrMembershipNotFound),
+		errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrStreamNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTier), errors.Is(err, service.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAlreadyMember), errors.Is(err, service.ErrIdempotencyConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInsufficientFunds):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "membership request failed"})
+	}
+}
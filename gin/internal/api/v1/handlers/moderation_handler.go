@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ModerationHandler serves channel moderation.
+type ModerationHandler struct {
+	svc *service.Service
+}
+
+// NewModerationHandler returns a ModerationHandler using svc.
+func NewModerationHandler(svc *service.Service) *ModerationHandler {
+	return &ModerationHandler{svc: svc}
+}
+
+// AddModeratorRequest names the user to appoint.
+type AddModeratorRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// BanRequest bans a user; a positive DurationSeconds makes it a timeout.
+type BanRequest struct {
+	Username        string `json:"username" binding:"required"`
+	Reason          string `json:"reason"`
+	DurationSeconds int    `json:"duration_seconds"`
+}
+
+// ChatSettingsRequest is a partial update; omitted fields are unchanged.
+type ChatSettingsRequest struct {
+	SlowModeSeconds      *int                  `json:"slow_mode_seconds"`
+	FollowersOnly        *bool                 `json:"followers_only"`
+	FollowersOnlyMinutes *int                  `json:"followers_only_minutes"`
+	EmoteOnly            *bool                 `json:"emote_only"`
+	BlockedTerms         *[]models.BlockedTerm `json:"blocked_terms"`
+}
+
+// ListModerators godoc
+// @Summary      Moderators of a channel. Broadcaster and moderators only.
+// @Tags         moderation
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Success      200 {array}  models.ChannelModerator
+// @Failure      403 {object} map[string]string
+// @Router       /channels/{username}/moderators [get]
+func (h *ModerationHandler) ListModerators(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	mods, err := h.svc.ListModerators(c.Request.Context(), userID, c.Param("username"))
+	if err != nil {
+		writeModerationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, mods)
+}
+
+// AddModerator godoc
+// @Summary      Appoint a chat moderator. Broadcaster only.
+// @Tags         moderation
+// @Accept       json
+// @Produce      json
+// @Param        username path string              true "Channel username"
+// @Param        body     body AddModeratorRequest true "User to appoint"
+// @Success      200 {object} models.ChannelModerator
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /channels/{username}/moderators [post]
+func (h *ModerationHandler) AddModerator(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req AddModeratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	mod, err := h.svc.AddModerator(c.Request.Context(), userID, c.Param("username"), req.Username)
+	if err != nil {
+		writeModerationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, mod)
+}
+
+// RemoveModerator godoc
+// @Summary      Revoke a chat moderator. Broadcaster only.
+// @Tags         moderation
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Param        target   path string true "Moderator username"
+// @Success      200 {object} map[string]bool
+// @Failure      403 {object} map[string]string
+// @Router       /channels/{username}/moderators/{target} [delete]
+func (h *ModerationHandler) RemoveModerator(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	removed, err := h.svc.RemoveModerator(c.Request.Context(), userID, c.Param("username"), c.Param("target"))
+	if err != nil {
+		writeModerationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"removed": removed})
+}
+
+// ListBans godoc
+// @Summary      Active bans and timeouts in a channel's chat.
+// @Tags         moderation
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Success      200 {array}  models.ChannelBan
+// @Failure      403 {object} map[string]string
+// @Router       /channels/{username}/bans [get]
+func (h *ModerationHandler) ListBans(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	bans, err := h.svc.ListBans(c.Request.Context(), userID, c.Param("username"))
+	if err != nil {
+		writeModerationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, bans)
+}
+
+// BanUser godoc
+// @Summary      Ban a user from chat, or time them out with duration_seconds.
+// @Tags         moderation
+// @Accept       json
+// @Produce      json
+// @Param        username path string     true "Channel username"
+// @Param        body     body BanRequest true "Ban details"
+// @Success      200 {object} models.ChannelBan
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /channels/{username}/bans [post]
+func (h *ModerationHandler) BanUser(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req BanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ban, err := h.svc.BanUser(c.Request.Context(), userID, c.Param("username"), service.BanInput{
+		Username:        req.Username,
+		Reason:          req.Reason,
+		DurationSeconds: req.DurationSeconds,
+	})
+	if err != nil {
+		writeModerationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, ban)
+}
+
+// UnbanUser godoc
+// @Summary      Lift a ban or timeout.
+// @Tags         moderation
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Param        target   path string true "Banned username"
+// @Success      200 {object} map[string]bool
+// @Failure      403 {object} map[string]string
+// @Router       /channels/{username}/bans/{target} [delete]
+func (h *ModerationHandler) UnbanUser(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	lifted, err := h.svc.UnbanUser(c.Request.Context(), userID, c.Param("username"), c.Param("target"))
+	if err != nil {
+		writeModerationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unbanned": lifted})
+}
+
+// DeleteChatMessage godoc
+// @Summary      Remove a message from a channel's chat.
+// @Tags         moderation
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Param        id       path string true "Message ID"
+// @Success      204
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /channels/{username}/chat/messages/{id} [delete]
+func (h *ModerationHandler) DeleteChatMessage(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	msgID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message id"})
+		return
+	}
+	if err := h.svc.DeleteChatMessage(c.Request.Context(), userID, c.Param("username"), msgID); err != nil {
+		writeModerationError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetChatSettings godoc
+// @Summary      Chat modes and blocked terms of a channel.
+// @Tags         moderation
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Success      200 {object} models.ChatSettings
+// @Failure      403 {object} map[string]string
+// @Router       /channels/{username}/chat/settings [get]
+func (h *ModerationHandler) GetChatSettings(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	s, err := h.svc.GetChatSettings(c.Request.Context(), userID, c.Param("username"))
+	if err != nil {
+		writeModerationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// UpdateChatSettings godoc
+// @Summary      Change slow mode, followers-only, emote-only or blocked terms.
+// @Tags         moderation
+// @Accept       json
+// @Produce      json
+// @Param        username path string              true "Channel username"
+// @Param        body     body ChatSettingsRequest true "Fields to change"
+// @Success      200 {object} models.ChatSettings
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Router       /channels/{username}/chat/settings [put]
+func (h *ModerationHandler) UpdateChatSettings(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req ChatSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s, err := h.svc.UpdateChatSettings(c.Request.Context(), userID, c.Param("username"), service.ChatSettingsInput{
+		SlowModeSeconds:      req.SlowModeSeconds,
+		FollowersOnly:        req.FollowersOnly,
+		FollowersOnlyMinutes: req.FollowersOnlyMinutes,
+		EmoteOnly:            req.EmoteOnly,
+		BlockedTerms:         req.BlockedTerms,
+	})
+	if err != nil {
+		writeModerationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// ListModerationLog godoc
+// @Summary      Audit log of moderation actions, newest first. Broadcaster only.
+// @Tags         moderation
+// @Produce      json
+// @Param        username path  string true  "Channel username"
+// @Param        action   query string false "Only this action type"
+// @Param        cursor   query string false "Pagination cursor"
+// @Param        limit    query int    false "Page size (default 20, at most limits.max_page_size)"
+// @Success      200 {object} service.ModerationLogPage
+// @Failure      403 {object} map[string]string
+// @Router       /channels/{username}/moderation/log [get]
+func (h *ModerationHandler) ListModerationLog(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, err := h.svc.ListModerationLog(c.Request.Context(), userID, c.Param("username"), c.Query("action"), c.Query("cursor"), limit)
+	if err != nil {
+		writeModerationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func writeModerationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrMessageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidModeration), errors.Is(err, service.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
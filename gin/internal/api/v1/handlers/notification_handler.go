@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NotificationHandler serves the notification inbox.
+type NotificationHandler struct {
+	svc *service.Service
+}
+
+// NewNotificationHandler returns a NotificationHandler using svc.
+func NewNotificationHandler(svc *service.Service) *NotificationHandler {
+	return &NotificationHandler{svc: svc}
+}
+
+// sseKeepAlive is how often an idle SSE connection gets a comment line so
+// proxies do not time it out.
+const sseKeepAlive = 25 * time.Second
+
+// MarkReadRequest is the payload for POST /notifications/read.
+// Either list IDs or set all to true.
+type MarkReadRequest struct {
+	IDs []string `json:"ids"`
+	All bool     `json:"all"`
+}
+
+// ListNotifications godoc
+// @Summary      The authenticated user's notifications, newest first.
+// @Tags         notifications
+// @Produce      json
+// @Param        unread query bool   false "Only unread"
+// @Param        cursor query string false "Pagination cursor"
+// @Param        limit  query int    false "Page size (default 20, at most limits.max_page_size)"
+// @Success      200 {object} service.NotificationPage
+// @Failure      400 {object} map[string]string
+// @Router       /notifications [get]
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	unread, _ := strconv.ParseBool(c.Query("unread"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	page, err := h.svc.ListNotifications(c.Request.Context(), userID, unread, c.Query("cursor"), limit)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// UnreadNotificationCount godoc
+// @Summary      Number of unread notifications.
+// @Tags         notifications
+// @Produce      json
+// @Success      200 {object} map[string]int
+// @Router       /notifications/unread_count [get]
+func (h *NotificationHandler) UnreadNotificationCount(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	n, err := h.svc.UnreadNotificationCount(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread": n})
+}
+
+// MarkNotificationsRead godoc
+// @Summary      Mark notifications as read, by ID or all at once.
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Param        payload body MarkReadRequest true "IDs or all"
+// @Success      200 {object} map[string]int
+// @Failure      400 {object} map[string]string
+// @Router       /notifications/read [post]
+func (h *NotificationHandler) MarkNotificationsRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req MarkReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.All && len(req.IDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pass ids or all=true"})
+		return
+	}
+
+	var ids []primitive.ObjectID
+	if !req.All {
+		for _, hex := range req.IDs {
+			id, err := primitive.ObjectIDFromHex(hex)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification id " + hex})
+				return
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	n, err := h.svc.MarkNotificationsRead(c.Request.Context(), userID, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mark notifications read"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": n})
+}
+
+// StreamNotifications godoc
+// @Summary      Server-Sent Events stream of new notifications.
+// @Description  Sends an "unread_count" event on connect, then a "notification" event
+// @Description  for every new notification. Browsers should pass ?session_id= because
+// @Description  EventSource cannot set headers.
+// @Tags         notifications
+// @Produce      text/event-stream
+// @Success      200
+// @Router       /notifications/stream [get]
+func (h *NotificationHandler) StreamNotifications(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	// h.Subscribe before reading the count so nothing slips in between.
+	ch, cancel := h.svc.SubscribeNotifications(userID)
+	defer cancel()
+
+	unread, err := h.svc.UnreadNotificationCount(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count notifications"})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // disable proxy buffering
+	c.SSEvent("unread_count", gin.H{"unread": unread})
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case n, open := <-ch:
+			if !open {
+				return false
+			}
+			c.SSEvent("notification", n)
+			return true
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		}
+	})
+}
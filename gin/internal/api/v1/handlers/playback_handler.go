@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/hls"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/playback"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+)
+
+// PlaybackHandler issues playback tokens and serves live HLS.
+type PlaybackHandler struct {
+	svc *service.Service
+}
+
+// NewPlaybackHandler returns a PlaybackHandler using svc.
+func NewPlaybackHandler(svc *service.Service) *PlaybackHandler {
+	return &PlaybackHandler{svc: svc}
+}
+
+// IssuePlaybackToken godoc
+// @Summary      Short-lived signed token to watch a channel's live stream.
+// @Description  Anonymous visitors get a token too unless the stream is private; an invite
+// @Description  code admits them (and adds logged-in viewers to the allowlist). Play the
+// @Description  returned playlist_url; the token is carried into every segment URI.
+// @Tags         playback
+// @Produce      json
+// @Param        username path  string true  "Channel username"
+// @Param        invite   query string false "Invite code for a private stream"
+// @Success      200 {object} service.PlaybackGrant
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /streams/{username}/playback-token [post]
+func (h *PlaybackHandler) IssuePlaybackToken(c *gin.Context) {
+	grant, err := h.svc.IssuePlaybackToken(c.Request.Context(), c.Param("username"), optionalUserID(c), c.Query("invite"))
+	if err != nil {
+		writePlaybackError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, grant)
+}
+
+// VerifyPlayback godoc
+// @Summary      nginx auth_request target for live HLS requests.
+// @Description  nginx passes the original request URI in X-Original-URI; the token is read
+// @Description  from its query string. Answers 204 to allow and 403 to deny.
+// @Tags         playback
+// @Param        X-Original-URI header string true "The HLS request being authorized"
+// @Success      204
+// @Failure      403
+// @Router       /playback/verify [get]
+func (h *PlaybackHandler) VerifyPlayback(c *gin.Context) {
+	u, err := url.Parse(c.GetHeader("X-Original-URI"))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	if _, err := h.svc.AuthorizePlayback(path.Base(u.Path), u.Query().Get("token")); err != nil {
+		c.Status(http.StatusForbidden)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ServeLiveHLS godoc
+// @Summary      Token-checked live HLS origin: a stream's master playlist, or a flat file.
+// @Description  <username>.m3u8 is a generated master playlist listing the stream's renditions,
+// @Description  which point at /hls/{username}/{file}. Any other name is served like ServeLiveStreamFile.
+// @Description  Supports HEAD and byte ranges. A 404 with code "stream_not_found" means there is no
+// @Description  such channel; a 503 with code "stream_offline" means it is not broadcasting (yet).
+// @Tags         playback
+// @Produce      application/vnd.apple.mpegurl
+// @Param        stream path  string true "<username>.m3u8, or a media playlist or segment name"
+// @Param        token  query string true "Playback token"
+// @Success      200
+// @Success      206
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      503 {object} map[string]string
+// @Router       /hls/{stream} [get]
+func (h *PlaybackHandler) ServeLiveHLS(c *gin.Context) {
+	name := c.Param("stream")
+	stream := strings.TrimSuffix(name, ".m3u8")
+	if stream == name || !masterName.MatchString(stream) {
+		h.serveLiveFile(c, name)
+		return
+	}
+
+	renditions, token, err := h.svc.OpenLiveMaster(c.Request.Context(), stream, c.Query("token"))
+	if err != nil {
+		writePlaybackError(c, err)
+		return
+	}
+	var buf bytes.Buffer
+	_ = hls.WriteMaster(&buf, renditions, func(r hls.Rendition) string {
+		return stream + "/" + r.File + "?token=" + url.QueryEscape(token)
+	})
+	hls.ServeBytes(c.Writer, c.Request, name, buf.Bytes(), hls.CacheLive)
+}
+
+// ServeLiveStreamFile godoc
+// @Summary      Token-checked live HLS origin: a rendition's media playlist or one of its segments.
+// @Description  Media playlists are rewritten so every segment URI carries the (possibly renewed)
+// @Description  token. Supports HEAD and byte ranges; errors are as for the master playlist.
+// @Tags         playback
+// @Produce      application/vnd.apple.mpegurl
+// @Param        stream path  string true "Channel username"
+// @Param        file   path  string true "Media playlist or segment name"
+// @Param        token  query string true "Playback token"
+// @Success      200
+// @Success      206
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      503 {object} map[string]string
+// @Router       /hls/{stream}/{file} [get]
+func (h *PlaybackHandler) ServeLiveStreamFile(c *gin.Context) {
+	file := c.Param("file")
+	if playback.StreamOfFile(file) != c.Param("stream") {
+		writePlaybackError(c, service.ErrHLSNotFound)
+		return
+	}
+	h.serveLiveFile(c, file)
+}
+
+// masterName matches the stream part of a master playlist request. Media
+// playlists of renditions have an underscore and are served as files.
+var masterName = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// serveLiveFile serves a live media playlist, rewritten with the token, or
+// a segment.
+func (h *PlaybackHandler) serveLiveFile(c *gin.Context, file string) {
+	p, token, err := h.svc.OpenLiveHLS(c.Request.Context(), file, c.Query("token"))
+	if err != nil {
+		writePlaybackError(c, err)
+		return
+	}
+
+	if !playback.IsPlaylist(file) {
+		if err := hls.ServeFile(c.Writer, c.Request, p, hls.CacheSegment); err != nil {
+			// Rotated away between the check and the read.
+			writePlaybackError(c, service.ErrHLSNotFound)
+		}
+		return
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		writePlaybackError(c, service.ErrHLSNotFound)
+		return
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	if err := playback.RewritePlaylist(&buf, f, token); err != nil {
+		writePlaybackError(c, err)
+		return
+	}
+	hls.ServeBytes(c.Writer, c.Request, file, buf.Bytes(), hls.CacheLive)
+}
+
+// writePlaybackError answers with the error and, for HLS requests, a code
+// players can act on: stream_offline is worth retrying, the rest are not.
+func writePlaybackError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrStreamNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "stream_not_found"})
+	case errors.Is(err, service.ErrHLSNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "not_found"})
+	case errors.Is(err, service.ErrStreamOffline):
+		c.Header("Retry-After", "5")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error(), "code": "stream_offline"})
+	case errors.Is(err, service.ErrPlaybackDenied), errors.Is(err, service.ErrInvalidInvite),
+		errors.Is(err, playback.ErrInvalidToken), errors.Is(err, playback.ErrTokenExpired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "forbidden"})
+	case errors.Is(err, service.ErrSubscriptionRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "subscription_required"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
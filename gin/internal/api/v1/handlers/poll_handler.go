@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PollHandler serves polls.
+type PollHandler struct {
+	svc *service.Service
+}
+
+// NewPollHandler returns a PollHandler using svc.
+func NewPollHandler(svc *service.Service) *PollHandler {
+	return &PollHandler{svc: svc}
+}
+
+// PollRequest is the JSON payload for POST /channels/:username/polls.
+type PollRequest struct {
+	Question        string   `json:"question" binding:"required"`
+	Options         []string `json:"options" binding:"required"`
+	DurationSeconds int      `json:"duration_seconds" binding:"required"`
+}
+
+// VoteRequest is the JSON payload for POST /channels/:username/polls/:id/votes.
+type VoteRequest struct {
+	Option *int `json:"option" binding:"required"`
+}
+
+// OpenPoll godoc
+// @Summary      Put a poll to a live channel's chat.
+// @Description  Owner or moderator only. 2-5 options, open for 15 seconds to 30 minutes; one poll at a time. Tallies are pushed to chat as "poll" events.
+// @Tags         polls
+// @Accept       json
+// @Produce      json
+// @Param        username path string      true "Channel username"
+// @Param        body     body PollRequest true "Poll"
+// @Success      201 {object} models.Poll
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /channels/{username}/polls [post]
+func (h *PollHandler) OpenPoll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req PollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	p, err := h.svc.OpenPoll(c.Request.Context(), userID, c.Param("username"), service.PollInput{
+		Question:        req.Question,
+		Options:         req.Options,
+		DurationSeconds: req.DurationSeconds,
+	})
+	if err != nil {
+		writePollError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, p)
+}
+
+// EndPoll godoc
+// @Summary      Close a running poll early.
+// @Tags         polls
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Param        id       path string true "Poll ID"
+// @Success      200 {object} models.Poll
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /channels/{username}/polls/{id}/end [post]
+func (h *PollHandler) EndPoll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := pollID(c)
+	if !ok {
+		return
+	}
+	p, err := h.svc.EndPoll(c.Request.Context(), userID, c.Param("username"), id)
+	if err != nil {
+		writePollError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// VotePoll godoc
+// @Summary      Vote in a running poll; one vote per user.
+// @Tags         polls
+// @Accept       json
+// @Param        username path string      true "Channel username"
+// @Param        id       path string      true "Poll ID"
+// @Param        body     body VoteRequest true "Index of the chosen option"
+// @Success      204
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /channels/{username}/polls/{id}/votes [post]
+func (h *PollHandler) VotePoll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := pollID(c)
+	if !ok {
+		return
+	}
+	var req VoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.svc.VotePoll(c.Request.Context(), userID, c.Param("username"), id, *req.Option); err != nil {
+		writePollError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListBroadcastPolls godoc
+// @Summary      The polls of a broadcast with their results.
+// @Tags         polls
+// @Produce      json
+// @Param        id path string true "Broadcast ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      404 {object} map[string]string
+// @Router       /broadcasts/{id}/polls [get]
+func (h *PollHandler) ListBroadcastPolls(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrBroadcastNotFound.Error()})
+		return
+	}
+	polls, err := h.svc.ListBroadcastPolls(c.Request.Context(), id, optionalUserID(c))
+	if err != nil {
+		writePollError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"polls": polls})
+}
+
+// pollID parses the :id path parameter, answering 404 if it is malformed.
+func pollID(c *gin.Context) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrPollNotFound.Error()})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+func writePollError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPollNotFound), errors.Is(err, service.ErrUserNotFound),
+		errors.Is(err, service.ErrBroadcastNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidPoll):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPollClosed), errors.Is(err, service.ErrPollRunning),
+		errors.Is(err, service.ErrAlreadyVoted), errors.Is(err, service.ErrStreamOffline):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "poll request failed"})
+	}
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PredictionHandler serves predictions.
+type PredictionHandler struct {
+	svc *service.Service
+}
+
+// NewPredictionHandler returns a PredictionHandler using svc.
+func NewPredictionHandler(svc *service.Service) *PredictionHandler {
+	return &PredictionHandler{svc: svc}
+}
+
+// PredictionRequest is the JSON payload for POST /channels/:username/predictions.
+type PredictionRequest struct {
+	Title         string   `json:"title" binding:"required"`
+	Outcomes      []string `json:"outcomes" binding:"required"`
+	WindowSeconds int      `json:"window_seconds" binding:"required"`
+}
+
+// ResolvePredictionRequest is the JSON payload for
+// POST /channels/:username/predictions/:id/resolve.
+type ResolvePredictionRequest struct {
+	Outcome *int `json:"outcome" binding:"required"`
+}
+
+// StakeRequest is the JSON payload for POST /channels/:username/predictions/:id/stakes.
+type StakeRequest struct {
+	Outcome *int  `json:"outcome" binding:"required"`
+	Amount  int64 `json:"amount" binding:"required"`
+}
+
+// OpenPrediction godoc
+// @Summary      Start a prediction in a live channel.
+// @Description  Owner or moderator only. 2-5 outcomes; viewers stake honey for window_seconds (15 seconds to 30 minutes), then it locks until resolved. Updates are pushed to chat as "prediction" events.
+// @Tags         predictions
+// @Accept       json
+// @Produce      json
+// @Param        username path string            true "Channel username"
+// @Param        body     body PredictionRequest true "Prediction"
+// @Success      201 {object} models.Prediction
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /channels/{username}/predictions [post]
+func (h *PredictionHandler) OpenPrediction(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req PredictionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	p, err := h.svc.OpenPrediction(c.Request.Context(), userID, c.Param("username"), service.PredictionInput{
+		Title:         req.Title,
+		Outcomes:      req.Outcomes,
+		WindowSeconds: req.WindowSeconds,
+	})
+	if err != nil {
+		writePredictionError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, p)
+}
+
+// LockPrediction godoc
+// @Summary      Stop taking stakes before the window closes.
+// @Tags         predictions
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Param        id       path string true "Prediction ID"
+// @Success      200 {object} models.Prediction
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /channels/{username}/predictions/{id}/lock [post]
+func (h *PredictionHandler) LockPrediction(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := predictionID(c)
+	if !ok {
+		return
+	}
+	p, err := h.svc.LockPrediction(c.Request.Context(), userID, c.Param("username"), id)
+	if err != nil {
+		writePredictionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// ResolvePrediction godoc
+// @Summary      Name the winning outcome and pay out the pool.
+// @Description  Winners split all stakes in proportion to theirs; if nobody backed the winner every stake is refunded. Repeating the call with the same outcome finishes interrupted payouts.
+// @Tags         predictions
+// @Accept       json
+// @Produce      json
+// @Param        username path string                   true "Channel username"
+// @Param        id       path string                   true "Prediction ID"
+// @Param        body     body ResolvePredictionRequest true "Index of the winning outcome"
+// @Success      200 {object} models.Prediction
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /channels/{username}/predictions/{id}/resolve [post]
+func (h *PredictionHandler) ResolvePrediction(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := predictionID(c)
+	if !ok {
+		return
+	}
+	var req ResolvePredictionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	p, err := h.svc.ResolvePrediction(c.Request.Context(), userID, c.Param("username"), id, *req.Outcome)
+	if err != nil {
+		writePredictionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// CancelPrediction godoc
+// @Summary      Call off a prediction and refund every stake.
+// @Tags         predictions
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Param        id       path string true "Prediction ID"
+// @Success      200 {object} models.Prediction
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /channels/{username}/predictions/{id}/cancel [post]
+func (h *PredictionHandler) CancelPrediction(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := predictionID(c)
+	if !ok {
+		return
+	}
+	p, err := h.svc.CancelPrediction(c.Request.Context(), userID, c.Param("username"), id)
+	if err != nil {
+		writePredictionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// StakePrediction godoc
+// @Summary      Stake honey on an outcome of an open prediction; one stake per user.
+// @Description  The channel's owner and moderators cannot stake.
+// @Tags         predictions
+// @Accept       json
+// @Produce      json
+// @Param        username path string       true "Channel username"
+// @Param        id       path string       true "Prediction ID"
+// @Param        body     body StakeRequest true "Stake"
+// @Success      201 {object} models.PredictionStake
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      422 {object} map[string]string
+// @Router       /channels/{username}/predictions/{id}/stakes [post]
+func (h *PredictionHandler) StakePrediction(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := predictionID(c)
+	if !ok {
+		return
+	}
+	var req StakeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s, err := h.svc.StakePrediction(c.Request.Context(), userID, c.Param("username"), id, *req.Outcome, req.Amount)
+	if err != nil {
+		writePredictionError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, s)
+}
+
+// ListBroadcastPredictions godoc
+// @Summary      The predictions of a broadcast with their outcomes.
+// @Tags         predictions
+// @Produce      json
+// @Param        id path string true "Broadcast ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      404 {object} map[string]string
+// @Router       /broadcasts/{id}/predictions [get]
+func (h *PredictionHandler) ListBroadcastPredictions(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrBroadcastNotFound.Error()})
+		return
+	}
+	predictions, err := h.svc.ListBroadcastPredictions(c.Request.Context(), id, optionalUserID(c))
+	if err != nil {
+		writePredictionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"predictions": predictions})
+}
+
+// predictionID parses the :id path parameter, answering 404 if it is malformed.
+func predictionID(c *gin.Context) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrPredictionNotFound.Error()})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+func writePredictionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPredictionNotFound), errors.Is(err, service.ErrUserNotFound),
+		errors.Is(err, service.ErrBroadcastNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidPrediction), errors.Is(err, service.ErrInvalidTransfer):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPredictionClosed), errors.Is(err, service.ErrPredictionRunning),
+		errors.Is(err, service.ErrAlreadyStaked), errors.Is(err, service.ErrStreamOffline),
+		errors.Is(err, service.ErrIdempotencyConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInsufficientFunds):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "prediction request failed"})
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+)
+
+// RaidHandler serves raids and raid settings.
+type RaidHandler struct {
+	svc *service.Service
+}
+
+// NewRaidHandler returns a RaidHandler using svc.
+func NewRaidHandler(svc *service.Service) *RaidHandler {
+	return &RaidHandler{svc: svc}
+}
+
+// RaidRequest is the JSON payload for POST /stream/raid.
+type RaidRequest struct {
+	Target string `json:"target" binding:"required"`
+}
+
+// RaidSettingsRequest is the JSON payload for PUT /stream/raid-settings;
+// omitted fields are left as they are.
+type RaidSettingsRequest struct {
+	FollowersOnly *bool     `json:"followers_only"`
+	Blocked       *[]string `json:"blocked"`
+}
+
+// StartRaid godoc
+// @Summary      Pick a live channel to raid when the authenticated user's broadcast ends.
+// @Description  When the broadcast ends, chat clients get a "raid" event naming the target and the target is told the raid size. Picking again replaces the target.
+// @Tags         raids
+// @Accept       json
+// @Produce      json
+// @Param        body body RaidRequest true "Channel to raid"
+// @Success      200 {object} models.Raid
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /stream/raid [post]
+func (h *RaidHandler) StartRaid(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req RaidRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	r, err := h.svc.StartRaid(c.Request.Context(), userID, req.Target)
+	if err != nil {
+		writeRaidError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// GetPendingRaid godoc
+// @Summary      The authenticated user's pending raid.
+// @Tags         raids
+// @Produce      json
+// @Success      200 {object} models.Raid
+// @Failure      404 {object} map[string]string
+// @Router       /stream/raid [get]
+func (h *RaidHandler) GetPendingRaid(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	r, err := h.svc.GetPendingRaid(c.Request.Context(), userID)
+	if err != nil {
+		writeRaidError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// CancelRaid godoc
+// @Summary      Call off the authenticated user's pending raid.
+// @Tags         raids
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Router       /stream/raid [delete]
+func (h *RaidHandler) CancelRaid(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.CancelRaid(c.Request.Context(), userID); err != nil {
+		writeRaidError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetRaidSettings godoc
+// @Summary      Who may raid the authenticated user's channel.
+// @Tags         raids
+// @Produce      json
+// @Success      200 {object} models.RaidSettings
+// @Failure      401 {object} map[string]string
+// @Router       /stream/raid-settings [get]
+func (h *RaidHandler) GetRaidSettings(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	s, err := h.svc.GetRaidSettings(c.Request.Context(), userID)
+	if err != nil {
+		writeRaidError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// UpdateRaidSettings godoc
+// @Summary      Restrict who may raid the authenticated user's channel.
+// @Description  followers_only accepts raids only from channels that follow this one; blocked lists channels (by username) that may never raid it.
+// @Tags         raids
+// @Accept       json
+// @Produce      json
+// @Param        body body RaidSettingsRequest true "Fields to change"
+// @Success      200 {object} models.RaidSettings
+// @Failure      400 {object} map[string]string
+// @Router       /stream/raid-settings [put]
+func (h *RaidHandler) UpdateRaidSettings(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req RaidSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s, err := h.svc.UpdateRaidSettings(c.Request.Context(), userID, service.RaidSettingsInput{
+		FollowersOnly: req.FollowersOnly,
+		Blocked:       req.Blocked,
+	})
+	if err != nil {
+		writeRaidError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+func writeRaidError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrRaidNotFound), errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidRaid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrRaidNotAllowed):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrStreamOffline):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "raid request failed"})
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+)
+
+// RecommendationHandler serves recommendations.
+type RecommendationHandler struct {
+	svc *service.Service
+}
+
+// NewRecommendationHandler returns a RecommendationHandler using svc.
+func NewRecommendationHandler(svc *service.Service) *RecommendationHandler {
+	return &RecommendationHandler{svc: svc}
+}
+
+// GetRecommendations godoc
+// @Summary      Live streams recommended for the viewer, with the reasons why.
+// @Description  Logged-in viewers get streams scored by their follows, the categories and tags
+// @Description  they watched in the last 30 days, what other viewers of their favourite channels
+// @Description  watch, and freshness. Anonymous viewers get streams ranked by popularity and
+// @Description  freshness (personalized=false).
+// @Tags         feed
+// @Produce      json
+// @Param        limit query int false "Max streams (default 20, at most limits.max_page_size)"
+// @Success      200 {object} service.Recommendations
+// @Failure      500 {object} map[string]string
+// @Router       /recommendations [get]
+func (h *RecommendationHandler) GetRecommendations(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	recs, err := h.svc.RecommendStreams(c.Request.Context(), optionalUserID(c), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build recommendations"})
+		return
+	}
+	c.JSON(http.StatusOK, recs)
+}
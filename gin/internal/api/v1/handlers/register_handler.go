@@ -2,9 +2,9 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
 	"github.com/CSBOWMA/bigredhacks2025/gin/models"
 	"golang.org/x/crypto/bcrypt"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -12,11 +12,12 @@ import (
 
 // RegisterRequest is the JSON payload expected for the /auth/register endpoint.
 type RegisterRequest struct {
-	Username  string `json:"username" binding:"required,alphanum,min=3,max=20"`
-	FirstName string `json:"first_name" binding:"required"`
-	LastName  string `json:"last_name" binding:"required"`
-	Email     string `json:"email" binding:"required,email"`
-	Password  string `json:"password" binding:"required,min=6"`
+	Username    string `json:"username" binding:"required,alphanum,min=3,max=20"`
+	DisplayName string `json:"display_name" binding:"max=40"` // optional, defaults to Username
+	FirstName   string `json:"first_name" binding:"required"`
+	LastName    string `json:"last_name" binding:"required"`
+	Email       string `json:"email" binding:"required,email"`
+	Password    string `json:"password" binding:"required,min=6"`
 }
 // Register godoc
 // @Summary      Register a new user
@@ -29,7 +30,7 @@ type RegisterRequest struct {
 // @Failure      400  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /auth/register [post]
-func Register(c *gin.Context) {
+func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -44,8 +45,13 @@ func Register(c *gin.Context) {
 	}
 
 	// ----- build the model -----
+	displayName := strings.TrimSpace(req.DisplayName)
+	if displayName == "" {
+		displayName = req.Username
+	}
 	user := models.User{
 		Username:     req.Username,
+		DisplayName:  displayName,
 		FirstName:    req.FirstName,
 		LastName:     req.LastName,
 		Email:        req.Email,
@@ -53,7 +59,7 @@ func Register(c *gin.Context) {
 	}
 
 	// ----- persist -----
-	if err := service.CreateUser(c.Request.Context(), &user); err != nil {
+	if err := h.auth.CreateUser(c.Request.Context(), &user); err != nil {
 		// Mongo duplicate key error (code 11000) -> return 409 Conflict
 		if mongo.IsDuplicateKeyError(err) {
 			c.JSON(http.StatusConflict, gin.H{"error": "username or email already taken"})
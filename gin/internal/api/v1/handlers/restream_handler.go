@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RestreamHandler serves restream destinations.
+type RestreamHandler struct {
+	svc *service.Service
+}
+
+// NewRestreamHandler returns a RestreamHandler using svc.
+func NewRestreamHandler(svc *service.Service) *RestreamHandler {
+	return &RestreamHandler{svc: svc}
+}
+
+// CreateRestreamRequest is the JSON payload for POST /stream/restreams.
+type CreateRestreamRequest struct {
+	Name    string `json:"name" binding:"required"`
+	URL     string `json:"url" binding:"required"`
+	Key     string `json:"key" binding:"required"`
+	Enabled *bool  `json:"enabled"`
+}
+
+// UpdateRestreamRequest changes the fields that are present.
+type UpdateRestreamRequest struct {
+	Name    *string `json:"name"`
+	URL     *string `json:"url"`
+	Key     *string `json:"key"`
+	Enabled *bool   `json:"enabled"`
+}
+
+// ListRestreams godoc
+// @Summary      The authenticated user's restream destinations and, while live, their relay status.
+// @Description  status.state is connecting, live, retrying (with the last error) or failed
+// @Description  (given up until the next broadcast). Stream keys are never returned.
+// @Tags         restream
+// @Produce      json
+// @Success      200 {array} service.RestreamDestinationStatus
+// @Router       /stream/restreams [get]
+func (h *RestreamHandler) ListRestreams(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	dests, err := h.svc.ListRestreamDestinations(c.Request.Context(), userID)
+	if err != nil {
+		writeRestreamError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dests)
+}
+
+// CreateRestream godoc
+// @Summary      Add an external RTMP destination to forward broadcasts to.
+// @Description  The key is stored encrypted. If the user is live, forwarding starts at once.
+// @Tags         restream
+// @Accept       json
+// @Produce      json
+// @Param        payload body CreateRestreamRequest true "rtmp:// or rtmps:// URL and stream key"
+// @Success      201 {object} models.RestreamDestination
+// @Failure      400 {object} map[string]string
+// @Router       /stream/restreams [post]
+func (h *RestreamHandler) CreateRestream(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req CreateRestreamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	d, err := h.svc.CreateRestreamDestination(c.Request.Context(), userID, service.RestreamInput{
+		Name:    req.Name,
+		URL:     req.URL,
+		Key:     req.Key,
+		Enabled: req.Enabled,
+	})
+	if err != nil {
+		writeRestreamError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, d)
+}
+
+// UpdateRestream godoc
+// @Summary      Edit, enable or disable a restream destination.
+// @Description  While live, the relay is restarted, started or stopped to match.
+// @Tags         restream
+// @Accept       json
+// @Produce      json
+// @Param        id      path string                true "Destination ID"
+// @Param        payload body UpdateRestreamRequest true "Fields to change"
+// @Success      200 {object} models.RestreamDestination
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /stream/restreams/{id} [patch]
+func (h *RestreamHandler) UpdateRestream(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := restreamID(c)
+	if !ok {
+		return
+	}
+	var req UpdateRestreamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	d, err := h.svc.UpdateRestreamDestination(c.Request.Context(), userID, id, service.RestreamUpdate{
+		Name:    req.Name,
+		URL:     req.URL,
+		Key:     req.Key,
+		Enabled: req.Enabled,
+	})
+	if err != nil {
+		writeRestreamError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// DeleteRestream godoc
+// @Summary      Remove a restream destination, stopping its relay.
+// @Tags         restream
+// @Param        id path string true "Destination ID"
+// @Success      204
+// @Failure      404 {object} map[string]string
+// @Router       /stream/restreams/{id} [delete]
+func (h *RestreamHandler) DeleteRestream(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := restreamID(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteRestreamDestination(c.Request.Context(), userID, id); err != nil {
+		writeRestreamError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// restreamID parses the :id path parameter, answering 404 if it is malformed.
+func restreamID(c *gin.Context) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrDestinationNotFound.Error()})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+func writeRestreamError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrDestinationNotFound), errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidDestination):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
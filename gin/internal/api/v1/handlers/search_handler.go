@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+)
+
+// SearchHandler serves search.
+type SearchHandler struct {
+	svc *service.Service
+}
+
+// NewSearchHandler returns a SearchHandler using svc.
+func NewSearchHandler(svc *service.Service) *SearchHandler {
+	return &SearchHandler{svc: svc}
+}
+
+// Search godoc
+// @Summary      Search streams or channels.
+// @Description  Full-text search over stream titles, descriptions and tags (type=streams)
+// @Description  or usernames and display names (type=channels). Results are ordered by
+// @Description  relevance then recency unless sort=recent. Pass next_cursor back as cursor
+// @Description  to fetch the following page.
+// @Tags         search
+// @Produce      json
+// @Param        q        query string false "Search text"
+// @Param        type     query string false "streams (default) or channels"
+// @Param        live     query bool   false "Only live streams"
+// @Param        category query string false "Category slug"
+// @Param        tag      query string false "Tag"
+// @Param        sort     query string false "relevance (default) or recent"
+// @Param        cursor   query string false "Pagination cursor"
+// @Param        limit    query int    false "Page size (default 20, at most limits.max_page_size)"
+// @Success      200 {object} service.SearchResult
+// @Failure      400 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /search [get]
+func (h *SearchHandler) Search(c *gin.Context) {
+	req := service.SearchRequest{
+		Query:    c.Query("q"),
+		Type:     c.DefaultQuery("type", "streams"),
+		Category: c.Query("category"),
+		Tag:      c.Query("tag"),
+		Sort:     c.DefaultQuery("sort", "relevance"),
+		Cursor:   c.Query("cursor"),
+	}
+	if req.Type != "streams" && req.Type != "channels" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be streams or channels"})
+		return
+	}
+	if req.Sort != "relevance" && req.Sort != "recent" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be relevance or recent"})
+		return
+	}
+	req.LiveOnly, _ = strconv.ParseBool(c.Query("live"))
+	req.Limit, _ = strconv.Atoi(c.Query("limit"))
+
+	res, err := h.svc.Search(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "search failed"})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// Suggest godoc
+// @Summary      Prefix autocomplete for tags or channels.
+// @Tags         search
+// @Produce      json
+// @Param        q     query string true  "Prefix"
+// @Param        type  query string false "tags (default) or channels"
+// @Param        limit query int    false "Max suggestions (default 20, at most limits.max_page_size)"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /search/suggest [get]
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	prefix := c.Query("q")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	switch c.DefaultQuery("type", "tags") {
+	case "tags":
+		tags, err := h.svc.SuggestTags(c.Request.Context(), prefix, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "suggest failed"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"tags": tags})
+	case "channels":
+		channels, err := h.svc.SuggestChannels(c.Request.Context(), prefix, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "suggest failed"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"channels": channels})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be tags or channels"})
+	}
+}
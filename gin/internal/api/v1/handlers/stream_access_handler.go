@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StreamAccessHandler serves stream visibility, allowlists and invites.
+type StreamAccessHandler struct {
+	svc *service.Service
+}
+
+// NewStreamAccessHandler returns a StreamAccessHandler using svc.
+func NewStreamAccessHandler(svc *service.Service) *StreamAccessHandler {
+	return &StreamAccessHandler{svc: svc}
+}
+
+// StreamVisibilityRequest is the JSON payload for PUT /stream/visibility.
+type StreamVisibilityRequest struct {
+	Visibility string `json:"visibility" binding:"required"`
+}
+
+// AllowViewerRequest names a user to put on the allowlist.
+type AllowViewerRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// CreateInviteRequest limits a new invite link; zero means no limit.
+type CreateInviteRequest struct {
+	MaxUses        int `json:"max_uses"`
+	ExpiresInHours int `json:"expires_in_hours"`
+}
+
+// SetStreamVisibility godoc
+// @Summary      Make the authenticated user's stream public, unlisted or private.
+// @Description  Unlisted streams are hidden from discovery, search and follower alerts but
+// @Description  watchable with the link. Private streams are only watchable by the allowlist
+// @Description  and invite holders. Recordings keep the visibility they were made with.
+// @Tags         stream
+// @Accept       json
+// @Produce      json
+// @Param        payload body StreamVisibilityRequest true "public, unlisted or private"
+// @Success      200 {object} models.Stream
+// @Failure      400 {object} map[string]string
+// @Router       /stream/visibility [put]
+func (h *StreamAccessHandler) SetStreamVisibility(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req StreamVisibilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s, err := h.svc.SetStreamVisibility(c.Request.Context(), userID, req.Visibility)
+	if err != nil {
+		writeAccessError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// GetStreamAccess godoc
+// @Summary      Visibility, allowlist and invite links of the authenticated user's stream.
+// @Tags         stream
+// @Produce      json
+// @Success      200 {object} service.StreamAccess
+// @Router       /stream/access [get]
+func (h *StreamAccessHandler) GetStreamAccess(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	access, err := h.svc.GetStreamAccess(c.Request.Context(), userID)
+	if err != nil {
+		writeAccessError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, access)
+}
+
+// AllowStreamViewer godoc
+// @Summary      Add a user to the private stream allowlist.
+// @Tags         stream
+// @Accept       json
+// @Produce      json
+// @Param        payload body AllowViewerRequest true "User to allow"
+// @Success      200 {object} map[string]bool
+// @Failure      400 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /stream/access/users [post]
+func (h *StreamAccessHandler) AllowStreamViewer(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req AllowViewerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.svc.AllowStreamViewer(c.Request.Context(), userID, req.Username); err != nil {
+		writeAccessError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"allowed": true})
+}
+
+// DisallowStreamViewer godoc
+// @Summary      Remove a user from the private stream allowlist.
+// @Tags         stream
+// @Produce      json
+// @Param        username path string true "User to remove"
+// @Success      200 {object} map[string]bool
+// @Failure      404 {object} map[string]string
+// @Router       /stream/access/users/{username} [delete]
+func (h *StreamAccessHandler) DisallowStreamViewer(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.DisallowStreamViewer(c.Request.Context(), userID, c.Param("username")); err != nil {
+		writeAccessError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"allowed": false})
+}
+
+// CreateStreamInvite godoc
+// @Summary      Create an invite link to the authenticated user's private stream.
+// @Description  Share it as ?invite=<code> on the stream page; the player passes it to
+// @Description  POST /streams/{username}/playback-token.
+// @Tags         stream
+// @Accept       json
+// @Produce      json
+// @Param        payload body CreateInviteRequest false "Limits"
+// @Success      201 {object} models.StreamInvite
+// @Failure      400 {object} map[string]string
+// @Router       /stream/invites [post]
+func (h *StreamAccessHandler) CreateStreamInvite(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req CreateInviteRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	inv, err := h.svc.CreateStreamInvite(c.Request.Context(), userID, service.InviteInput{
+		MaxUses:        req.MaxUses,
+		ExpiresInHours: req.ExpiresInHours,
+	})
+	if err != nil {
+		writeAccessError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, inv)
+}
+
+// RevokeStreamInvite godoc
+// @Summary      Revoke an invite link. Anonymous viewers admitted with it lose access.
+// @Tags         stream
+// @Produce      json
+// @Param        id path string true "Invite ID"
+// @Success      200 {object} map[string]bool
+// @Router       /stream/invites/{id} [delete]
+func (h *StreamAccessHandler) RevokeStreamInvite(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invite id"})
+		return
+	}
+	revoked, err := h.svc.RevokeStreamInvite(c.Request.Context(), userID, id)
+	if err != nil {
+		writeAccessError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
+
+// RedeemStreamInvite godoc
+// @Summary      Join a private stream's allowlist with an invite code.
+// @Tags         stream
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Param        code     path string true "Invite code"
+// @Success      200 {object} map[string]bool
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /streams/{username}/invites/{code}/redeem [post]
+func (h *StreamAccessHandler) RedeemStreamInvite(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.RedeemStreamInvite(c.Request.Context(), userID, c.Param("username"), c.Param("code")); err != nil {
+		writeAccessError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"allowed": true})
+}
+
+func writeAccessError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrStreamNotFound), errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidInvite):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidVisibility), errors.Is(err, service.ErrInvalidAccess):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+)
+
+// StreamHandler serves channel streams and admin takedowns.
+type StreamHandler struct {
+	svc *service.Service
+}
+
+// NewStreamHandler returns a StreamHandler using svc.
+func NewStreamHandler(svc *service.Service) *StreamHandler {
+	return &StreamHandler{svc: svc}
+}
+
+// UpdateStreamRequest is the JSON payload for PUT /stream.
+type UpdateStreamRequest struct {
+	Title       string   `json:"title" binding:"required,max=140"`
+	Description string   `json:"description" binding:"max=2000"`
+	Category    string   `json:"category" binding:"required"`
+	Tags        []string `json:"tags"`
+}
+
+// GetMyStream godoc
+// @Summary      Get the authenticated user's stream details.
+// @Tags         stream
+// @Produce      json
+// @Success      200 {object} models.Stream
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /stream [get]
+func (h *StreamHandler) GetMyStream(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	s, err := h.svc.GetStreamForUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load stream"})
+		return
+	}
+	if s == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "stream not set up yet"})
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// EndMyStream godoc
+// @Summary      End the authenticated user's broadcast now.
+// @Description  Disconnects the encoder from the ingest server. The stream key stays valid,
+// @Description  so the encoder may reconnect; rotate the key to keep it out.
+// @Tags         stream
+// @Success      204
+// @Failure      401 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      502 {object} map[string]string
+// @Router       /stream/end [post]
+func (h *StreamHandler) EndMyStream(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	err := h.svc.EndStream(c.Request.Context(), userID)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, service.ErrStreamOffline):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrIngestUnreachable):
+		c.JSON(http.StatusBadGateway, gin.H{"error": "could not reach the ingest server to end the stream"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to end stream"})
+	}
+}
+
+// GetStreamHealth godoc
+// @Summary      Ingest health of the authenticated user's live stream.
+// @Description  Bitrate, frame rate, resolution, codecs, keyframe interval and dropped frames,
+// @Description  measured from the last few segments, with about five minutes of per-segment
+// @Description  samples and warnings for common encoder misconfigurations. Offline streams
+// @Description  report live=false and nothing else.
+// @Tags         stream
+// @Produce      json
+// @Success      200 {object} service.StreamHealth
+// @Failure      401 {object} map[string]string
+// @Router       /stream/health [get]
+func (h *StreamHandler) GetStreamHealth(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	health, err := h.svc.GetStreamHealth(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load stream health"})
+		return
+	}
+	c.JSON(http.StatusOK, health)
+}
+
+// UpdateMyStream godoc
+// @Summary      Set title, description, category and tags of the authenticated user's stream.
+// @Description  category must be a slug from GET /categories. Tags are lower-cased and
+// @Description  validated (at most 10, 2-25 letters, digits or hyphens, none banned).
+// @Tags         stream
+// @Accept       json
+// @Produce      json
+// @Param        payload body UpdateStreamRequest true "Stream details"
+// @Success      200 {object} models.Stream
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Router       /stream [put]
+func (h *StreamHandler) UpdateMyStream(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateStreamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s, err := h.svc.UpdateStreamDetails(c.Request.Context(), userID, service.StreamDetailsInput{
+		Title:       req.Title,
+		Description: req.Description,
+		Category:    req.Category,
+		Tags:        req.Tags,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrStreamNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		case errors.Is(err, service.ErrUnknownCategory), errors.Is(err, service.ErrInvalidTag):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update stream"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// GetChannelStream godoc
+// @Summary      Get a channel's stream details.
+// @Description  Private streams are only returned to their owner and allowlist.
+// @Tags         stream
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Success      200 {object} models.Stream
+// @Failure      404 {object} map[string]string
+// @Router       /streams/{username} [get]
+func (h *StreamHandler) GetChannelStream(c *gin.Context) {
+	s, err := h.svc.GetChannelStream(c.Request.Context(), c.Param("username"), optionalUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load stream"})
+		return
+	}
+	if s == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// TakedownRequest is the JSON payload for POST /admin/streams/:username/takedown.
+type TakedownRequest struct {
+	Reason         string `json:"reason"`
+	SuspendSeconds int    `json:"suspend_seconds"`
+}
+
+// TakeDownStream godoc
+// @Summary      Admin: end a channel's broadcast, optionally suspending it.
+// @Description  Drops the publisher from the ingest server. With suspend_seconds the channel
+// @Description  cannot go live again until the suspension runs out or is lifted.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        username path string true "Channel username"
+// @Param        body body TakedownRequest true "Reason and suspension"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      502 {object} map[string]string
+// @Router       /admin/streams/{username}/takedown [post]
+func (h *StreamHandler) TakeDownStream(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req TakedownRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.svc.TakeDownStream(c.Request.Context(), adminID, c.Param("username"), service.TakedownInput{
+		Reason:         req.Reason,
+		SuspendSeconds: req.SuspendSeconds,
+	})
+	if err != nil {
+		writeTakedownError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"username":        user.Username,
+		"suspended_until": user.StreamSuspendedUntil,
+	})
+}
+
+// LiftStreamSuspension godoc
+// @Summary      Admin: let a suspended channel go live again.
+// @Tags         admin
+// @Param        username path string true "Channel username"
+// @Success      204
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /admin/streams/{username}/suspension [delete]
+func (h *StreamHandler) LiftStreamSuspension(c *gin.Context) {
+	if err := h.svc.LiftStreamSuspension(c.Request.Context(), c.Param("username")); err != nil {
+		writeTakedownError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeTakedownError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTakedown):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrIngestUnreachable):
+		// A suspension is in place; the broadcast may still be running.
+		c.JSON(http.StatusBadGateway, gin.H{"error": "could not reach the ingest server to end the broadcast"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
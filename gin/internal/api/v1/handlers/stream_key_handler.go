@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -8,6 +9,16 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// StreamKeyHandler serves the authenticated user's stream key.
+type StreamKeyHandler struct {
+	keys *service.StreamKeyService
+}
+
+// NewStreamKeyHandler returns a StreamKeyHandler using keys.
+func NewStreamKeyHandler(keys *service.StreamKeyService) *StreamKeyHandler {
+	return &StreamKeyHandler{keys: keys}
+}
+
 // GetStreamKey godoc
 // @Summary      Retrieve or create a stream key for the authenticated user.
 // @Description  If the user already has a key it is returned; otherwise a new key is created.
@@ -18,7 +29,7 @@ import (
 // @Failure      401 {object} map[string]string
 // @Failure      500 {object} map[string]string
 // @Router       /stream-key [get]
-func GetStreamKey(c *gin.Context) {
+func (h *StreamKeyHandler) GetStreamKey(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
@@ -32,7 +43,7 @@ func GetStreamKey(c *gin.Context) {
 		return
 	}
 
-	key, err := service.GetOrCreateStreamKey(c.Request.Context(), objID)
+	key, err := h.keys.GetOrCreateStreamKey(c.Request.Context(), objID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get/create stream key"})
 		return
@@ -52,8 +63,9 @@ func GetStreamKey(c *gin.Context) {
 // @Success      200 {object} map[string]string
 // @Failure      401 {object} map[string]string
 // @Failure      500 {object} map[string]string
+// @Failure      502 {object} map[string]string
 // @Router       /stream-key/new [post]
-func NewStreamKey(c *gin.Context) {
+func (h *StreamKeyHandler) NewStreamKey(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
@@ -66,7 +78,11 @@ func NewStreamKey(c *gin.Context) {
 		return
 	}
 
-	key, err := service.ReplaceStreamKey(c.Request.Context(), objID)
+	key, err := h.keys.ReplaceStreamKey(c.Request.Context(), objID)
+	if errors.Is(err, service.ErrIngestUnreachable) {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "stream key replaced, but the ingest server could not be reached to end the broadcast on the old key"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to replace stream key"})
 		return
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/hls"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/playback"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// VideoHandler serves videos.
+type VideoHandler struct {
+	svc *service.Service
+}
+
+// NewVideoHandler returns a VideoHandler using svc.
+func NewVideoHandler(svc *service.Service) *VideoHandler {
+	return &VideoHandler{svc: svc}
+}
+
+// UpdateVideoRequest retitles a video.
+type UpdateVideoRequest struct {
+	Title string `json:"title" binding:"required"`
+}
+
+// ListVideos godoc
+// @Summary      Recorded broadcasts of a channel, newest first.
+// @Tags         videos
+// @Produce      json
+// @Param        username path  string true  "Channel username"
+// @Param        cursor   query string false "Pagination cursor"
+// @Param        limit    query int    false "Page size (default 20, at most limits.max_page_size)"
+// @Success      200 {object} service.VideoPage
+// @Failure      404 {object} map[string]string
+// @Router       /users/{username}/videos [get]
+func (h *VideoHandler) ListVideos(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	page, err := h.svc.ListVideos(c.Request.Context(), c.Param("username"), optionalUserID(c), c.Query("cursor"), limit)
+	if err != nil {
+		writeVideoError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetVideo godoc
+// @Summary      Metadata of a recorded broadcast.
+// @Description  Play it from /videos/{id}/index.m3u8.
+// @Tags         videos
+// @Produce      json
+// @Param        id path string true "Video ID"
+// @Success      200 {object} models.Video
+// @Failure      404 {object} map[string]string
+// @Router       /videos/{id} [get]
+func (h *VideoHandler) GetVideo(c *gin.Context) {
+	id, ok := videoID(c)
+	if !ok {
+		return
+	}
+	v, err := h.svc.GetVideo(c.Request.Context(), id, optionalUserID(c))
+	if err != nil {
+		writeVideoError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, v)
+}
+
+// ServeVideoFile godoc
+// @Summary      VOD playback: the HLS playlist (index.m3u8) or one of its segments.
+// @Description  Private videos need ?token= from POST /streams/{username}/playback-token.
+// @Tags         videos
+// @Produce      application/vnd.apple.mpegurl
+// @Param        id    path  string true  "Video ID"
+// @Param        file  path  string true  "index.m3u8 or a segment name"
+// @Param        token query string false "Playback token (private videos)"
+// @Success      200
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /videos/{id}/{file} [get]
+func (h *VideoHandler) ServeVideoFile(c *gin.Context) {
+	id, ok := videoID(c)
+	if !ok {
+		return
+	}
+	path, token, v, err := h.svc.VideoFilePath(c.Request.Context(), id, c.Param("file"), c.Query("token"))
+	if err != nil {
+		writeVideoError(c, err)
+		return
+	}
+	serveHLSFile(c, path, token, v.Status == models.VideoRecording)
+}
+
+// UpdateVideo godoc
+// @Summary      Retitle one of your videos.
+// @Tags         videos
+// @Accept       json
+// @Produce      json
+// @Param        id   path string             true "Video ID"
+// @Param        body body UpdateVideoRequest true "New title"
+// @Success      200 {object} models.Video
+// @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /videos/{id} [patch]
+func (h *VideoHandler) UpdateVideo(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := videoID(c)
+	if !ok {
+		return
+	}
+	var req UpdateVideoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	v, err := h.svc.UpdateVideoTitle(c.Request.Context(), userID, id, req.Title)
+	if err != nil {
+		writeVideoError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, v)
+}
+
+// DeleteVideo godoc
+// @Summary      Delete one of your videos and its files.
+// @Tags         videos
+// @Param        id path string true "Video ID"
+// @Success      204
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /videos/{id} [delete]
+func (h *VideoHandler) DeleteVideo(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := videoID(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteVideo(c.Request.Context(), userID, id); err != nil {
+		writeVideoError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// serveHLSFile sends a recorded playlist or segment. The playlist of a
+// recording still in progress must not be cached. A non-empty token is
+// added to every URI of the playlist, which then must not be shared.
+func serveHLSFile(c *gin.Context, path, token string, growing bool) {
+	if !playback.IsPlaylist(path) {
+		// Segment files never change once written.
+		cache := hls.CacheSegment
+		if token == "" && c.Query("token") == "" {
+			cache = "public, max-age=31536000, immutable"
+		}
+		if err := hls.ServeFile(c.Writer, c.Request, path, cache); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+		}
+		return
+	}
+
+	cache := "public, max-age=60"
+	if growing || token != "" {
+		cache = hls.CacheLive
+	}
+	if token == "" {
+		if err := hls.ServeFile(c.Writer, c.Request, path, cache); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+		}
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+		return
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	if err := playback.RewritePlaylist(&buf, f, token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+	hls.ServeBytes(c.Writer, c.Request, filepath.Base(path), buf.Bytes(), cache)
+}
+
+// videoID parses the :id path parameter, answering 404 if it is malformed.
+func videoID(c *gin.Context) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrVideoNotFound.Error()})
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+func writeVideoError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrVideoNotFound), errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrPlaybackDenied),
+		errors.Is(err, service.ErrSubscriptionRequired),
+		errors.Is(err, playback.ErrInvalidToken), errors.Is(err, playback.ErrTokenExpired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrVideoRecording):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidVideo), errors.Is(err, service.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
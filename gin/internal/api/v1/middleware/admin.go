@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AdminOnly must run after SessionCheck. It rejects users whose role, as
+// auth knows it, is not admin.
+func AdminOnly(auth *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		objID, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			c.Abort()
+			return
+		}
+
+		user, err := auth.GetUser(c.Request.Context(), objID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			c.Abort()
+			return
+		}
+		if user == nil || user.Role != models.RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IngestSecret guards the RTMP callback routes: the caller must pass want
+// as the "secret" query parameter, which nginx appends to the
+// on_publish/on_publish_done URLs. The routes sit on the public API, so
+// when want is empty (the built-in ingest needs no callbacks) they are
+// closed rather than open.
+func IngestSecret(want string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got := c.Query("secret")
+		if want == "" || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
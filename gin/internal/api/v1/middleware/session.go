@@ -14,38 +14,23 @@ import (
 //   - Authorization: Bearer <uuid>
 //   - Authorization: <uuid>               (no "Bearer" word)
 //   - X-Session-ID: <uuid>
+//   - ?session_id=<uuid>                  (only after QuerySession)
 //
-// The extracted ID is stored in the context under the key "userID".
-func SessionCheck() gin.HandlerFunc {
+// The session is looked up in sessions, and its user ID is stored in the
+// context under the key "userID".
+func SessionCheck(sessions *service.SessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var sid string
+		sid := sessionID(c)
 
-		// 1️⃣ Try the Authorization header.
-		auth := c.GetHeader("Authorization")
-		if auth != "" {
-			// If it starts with "Bearer " (case‑insensitive) strip it.
-			// Otherwise use the whole header value as the token.
-			if strings.HasPrefix(strings.ToLower(auth), "bearer ") {
-				sid = strings.TrimSpace(auth[7:])
-			} else {
-				sid = strings.TrimSpace(auth)
-			}
-		}
-
-		// 2️⃣ Fallback to the custom header.
-		if sid == "" {
-			sid = c.GetHeader("X-Session-ID")
-		}
-
-		// 3️⃣ If still empty → reject.
+		// No session ID anywhere → reject.
 		if sid == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing session id"})
 			c.Abort()
 			return
 		}
 
-		// 4️⃣ Validate the session.
-		sess, err := service.ValidateSession(c.Request.Context(), sid)
+		// Validate the session.
+		sess, err := sessions.ValidateSession(c.Request.Context(), sid)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 			c.Abort()
@@ -62,3 +47,55 @@ func SessionCheck() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// OptionalSession is SessionCheck for routes that also serve anonymous
+// visitors: a valid session sets "userID", anything else just continues
+// without it.
+func OptionalSession(sessions *service.SessionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if sid := sessionID(c); sid != "" {
+			sess, err := sessions.ValidateSession(c.Request.Context(), sid)
+			if err == nil && sess != nil {
+				c.Set("userID", sess.UserID.Hex())
+			}
+		}
+		c.Next()
+	}
+}
+
+// sessionID pulls the session ID from the request, or returns "".
+func sessionID(c *gin.Context) string {
+	var sid string
+
+	// 1️⃣ Try the Authorization header.
+	auth := c.GetHeader("Authorization")
+	if auth != "" {
+		// If it starts with "Bearer " (case‑insensitive) strip it.
+		// Otherwise use the whole header value as the token.
+		if strings.HasPrefix(strings.ToLower(auth), "bearer ") {
+			sid = strings.TrimSpace(auth[7:])
+		} else {
+			sid = strings.TrimSpace(auth)
+		}
+	}
+
+	// 2️⃣ Fallback to the custom header.
+	if sid == "" {
+		sid = c.GetHeader("X-Session-ID")
+	}
+
+	return sid
+}
+
+// QuerySession lets the session checks that follow read the session ID
+// from the "session_id" query parameter, for EventSource and WebSocket
+// clients, which cannot set headers. Use it only on those routes: query
+// strings end up in access logs, proxies and Referer headers.
+func QuerySession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if sid := c.Query("session_id"); sid != "" && sessionID(c) == "" {
+			c.Request.Header.Set("X-Session-ID", sid)
+		}
+		c.Next()
+	}
+}
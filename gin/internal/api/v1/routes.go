@@ -4,19 +4,119 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/CSBOWMA/bigredhacks2025/gin/internal/api/v1/handlers"
 	"github.com/CSBOWMA/bigredhacks2025/gin/internal/api/v1/middleware"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/config"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
 )
 
-func RegisterRoutes(rg *gin.RouterGroup) {
+// Deps are the configuration and services the v1 routes are built from.
+type Deps struct {
+	Config     *config.Config
+	Auth       *service.AuthService
+	Sessions   *service.SessionService
+	StreamKeys *service.StreamKeyService
+	Service    *service.Service
+}
+
+func RegisterRoutes(rg *gin.RouterGroup, deps Deps) {
+	authHandler := handlers.NewAuthHandler(deps.Auth, deps.Sessions)
+	streamKeyHandler := handlers.NewStreamKeyHandler(deps.StreamKeys)
+	configHandler := handlers.NewConfigHandler(deps.Config)
+	accountHandler := handlers.NewAccountHandler(deps.Service)
+	analyticsHandler := handlers.NewAnalyticsHandler(deps.Service)
+	broadcastHandler := handlers.NewBroadcastHandler(deps.Service)
+	categoryHandler := handlers.NewCategoryHandler(deps.Service)
+	chatHandler := handlers.NewChatHandler(deps.Service, deps.Auth)
+	clipHandler := handlers.NewClipHandler(deps.Service)
+	followHandler := handlers.NewFollowHandler(deps.Service)
+	ingestHandler := handlers.NewIngestHandler(deps.Service)
+	ledgerHandler := handlers.NewLedgerHandler(deps.Service)
+	membershipHandler := handlers.NewMembershipHandler(deps.Service)
+	moderationHandler := handlers.NewModerationHandler(deps.Service)
+	notificationHandler := handlers.NewNotificationHandler(deps.Service)
+	playbackHandler := handlers.NewPlaybackHandler(deps.Service)
+	pollHandler := handlers.NewPollHandler(deps.Service)
+	predictionHandler := handlers.NewPredictionHandler(deps.Service)
+	raidHandler := handlers.NewRaidHandler(deps.Service)
+	recommendationHandler := handlers.NewRecommendationHandler(deps.Service)
+	restreamHandler := handlers.NewRestreamHandler(deps.Service)
+	searchHandler := handlers.NewSearchHandler(deps.Service)
+	streamAccessHandler := handlers.NewStreamAccessHandler(deps.Service)
+	streamHandler := handlers.NewStreamHandler(deps.Service)
+	videoHandler := handlers.NewVideoHandler(deps.Service)
+
 	// Public auth routes
 	auth := rg.Group("/auth")
 	{
-		auth.POST("/register", handlers.Register)
-		auth.POST("/login", handlers.Login)
+		auth.POST("/register", authHandler.Register)
+		auth.POST("/login", authHandler.Login)
+	}
+
+	// Public discovery routes
+	rg.GET("/search", searchHandler.Search)
+	rg.GET("/search/suggest", searchHandler.Suggest)
+	rg.GET("/categories", categoryHandler.ListCategories)
+	rg.GET("/browse/categories/:slug/streams", categoryHandler.BrowseCategory)
+	rg.GET("/browse/tags/trending", categoryHandler.TrendingTags)
+	rg.GET("/users/:username", followHandler.GetProfile)
+	rg.GET("/users/:username/tiers", membershipHandler.ListMembershipTiers)
+
+	// Channel pages: private streams and recordings show up for their
+	// owner and allowlist, so a session is used when present. It also
+	// personalizes recommendations.
+	viewer := rg.Group("/")
+	viewer.Use(middleware.OptionalSession(deps.Sessions))
+	{
+		viewer.GET("/streams/:username", streamHandler.GetChannelStream)
+		viewer.GET("/recommendations", recommendationHandler.GetRecommendations)
+		viewer.GET("/users/:username/videos", videoHandler.ListVideos)
+		viewer.GET("/users/:username/broadcasts", broadcastHandler.ListBroadcasts)
+		viewer.GET("/broadcasts/:id/polls", pollHandler.ListBroadcastPolls)
+		viewer.GET("/broadcasts/:id/predictions", predictionHandler.ListBroadcastPredictions)
+		viewer.GET("/videos/:id", videoHandler.GetVideo)
+		viewer.GET("/users/:username/clips", clipHandler.ListClips)
+		viewer.GET("/clips/:id", clipHandler.GetClip)
+	}
+	// Recorded playback; private recordings are checked with playback tokens.
+	rg.GET("/videos/:id/:file", videoHandler.ServeVideoFile)
+	rg.HEAD("/videos/:id/:file", videoHandler.ServeVideoFile)
+	rg.GET("/clips/:id/:file", clipHandler.ServeClipFile)
+	rg.HEAD("/clips/:id/:file", clipHandler.ServeClipFile)
+
+	// Live playback: tokens are signed per viewer and checked on every HLS request.
+	rg.POST("/streams/:username/playback-token", middleware.OptionalSession(deps.Sessions), playbackHandler.IssuePlaybackToken)
+	rg.GET("/playback/verify", playbackHandler.VerifyPlayback)
+	// The HLS origin: /hls/<user>.m3u8 is a master playlist over the
+	// stream's renditions, served from /hls/<user>/<file>.
+	rg.GET("/hls/:stream", playbackHandler.ServeLiveHLS)
+	rg.HEAD("/hls/:stream", playbackHandler.ServeLiveHLS)
+	rg.GET("/hls/:stream/:file", playbackHandler.ServeLiveStreamFile)
+	rg.HEAD("/hls/:stream/:file", playbackHandler.ServeLiveStreamFile)
+
+	// Chat: anonymous visitors may read, logged-in users may post.
+	// WebSocket clients cannot set headers, so the socket also takes the
+	// session from the query string.
+	rg.GET("/chat/:username/ws", middleware.QuerySession(), middleware.OptionalSession(deps.Sessions), chatHandler.ChatSocket)
+	chatRoutes := rg.Group("/chat/:username")
+	chatRoutes.Use(middleware.OptionalSession(deps.Sessions))
+	{
+		chatRoutes.GET("/messages", chatHandler.ListChatMessages)
+	}
+
+	// Live notifications, for EventSource clients, which cannot set
+	// headers either.
+	rg.GET("/notifications/stream", middleware.QuerySession(), middleware.SessionCheck(deps.Sessions), notificationHandler.StreamNotifications)
+
+	// RTMP server callbacks
+	ingest := rg.Group("/ingest")
+	ingest.Use(middleware.IngestSecret(deps.Config.Auth.IngestSecret))
+	{
+		ingest.POST("/publish", ingestHandler.OnPublish)
+		ingest.POST("/publish_done", ingestHandler.OnPublishDone)
 	}
 
 	// Protected routes (session required)
 	protected := rg.Group("/")
-	protected.Use(middleware.SessionCheck())
+	protected.Use(middleware.SessionCheck(deps.Sessions))
 	{
 		// Example profile endpoint (already there)
 		protected.GET("/protected/profile", func(c *gin.Context) {
@@ -27,8 +127,129 @@ func RegisterRoutes(rg *gin.RouterGroup) {
 		// ----- Stream key routes -----
 		stream := protected.Group("/stream-key")
 		{
-			stream.GET("", handlers.GetStreamKey)
-			stream.POST("/new", handlers.NewStreamKey)
+			stream.GET("", streamKeyHandler.GetStreamKey)
+			stream.POST("/new", streamKeyHandler.NewStreamKey)
+		}
+
+		// ----- Account -----
+		protected.DELETE("/account", accountHandler.DeleteAccount)
+
+		// ----- Own stream details -----
+		protected.GET("/stream", streamHandler.GetMyStream)
+		protected.PUT("/stream", streamHandler.UpdateMyStream)
+		protected.GET("/stream/health", streamHandler.GetStreamHealth)
+		protected.POST("/stream/end", streamHandler.EndMyStream)
+
+		// ----- Raids -----
+		protected.GET("/stream/raid", raidHandler.GetPendingRaid)
+		protected.POST("/stream/raid", raidHandler.StartRaid)
+		protected.DELETE("/stream/raid", raidHandler.CancelRaid)
+		protected.GET("/stream/raid-settings", raidHandler.GetRaidSettings)
+		protected.PUT("/stream/raid-settings", raidHandler.UpdateRaidSettings)
+
+		// ----- Channel analytics -----
+		protected.GET("/stream/analytics/daily", analyticsHandler.GetDailyAnalytics)
+		protected.GET("/stream/analytics/broadcasts", analyticsHandler.ListBroadcastAnalytics)
+		protected.GET("/stream/analytics/broadcasts/:id", analyticsHandler.GetBroadcastAnalytics)
+
+		// ----- Memberships: tiers, members and subscriber-only content -----
+		protected.GET("/stream/tiers", membershipHandler.ListOwnMembershipTiers)
+		protected.POST("/stream/tiers", membershipHandler.CreateMembershipTier)
+		protected.PATCH("/stream/tiers/:id", membershipHandler.UpdateMembershipTier)
+		protected.DELETE("/stream/tiers/:id", membershipHandler.ArchiveMembershipTier)
+		protected.GET("/stream/members", membershipHandler.ListChannelMembers)
+		protected.PUT("/stream/subscribers-only", membershipHandler.SetStreamSubscribersOnly)
+		protected.PUT("/videos/:id/subscribers-only", membershipHandler.SetVideoSubscribersOnly)
+		protected.GET("/memberships", membershipHandler.ListMyMemberships)
+		protected.GET("/channels/:username/membership", membershipHandler.GetMembership)
+		protected.POST("/channels/:username/membership", membershipHandler.Subscribe)
+		protected.DELETE("/channels/:username/membership", membershipHandler.CancelMembership)
+
+		// ----- Stream visibility and access lists -----
+		protected.PUT("/stream/visibility", streamAccessHandler.SetStreamVisibility)
+		protected.GET("/stream/access", streamAccessHandler.GetStreamAccess)
+		protected.POST("/stream/access/users", streamAccessHandler.AllowStreamViewer)
+		protected.DELETE("/stream/access/users/:username", streamAccessHandler.DisallowStreamViewer)
+		protected.POST("/stream/invites", streamAccessHandler.CreateStreamInvite)
+		protected.DELETE("/stream/invites/:id", streamAccessHandler.RevokeStreamInvite)
+		protected.POST("/streams/:username/invites/:code/redeem", streamAccessHandler.RedeemStreamInvite)
+
+		// ----- Restreaming to other platforms -----
+		protected.GET("/stream/restreams", restreamHandler.ListRestreams)
+		protected.POST("/stream/restreams", restreamHandler.CreateRestream)
+		protected.PATCH("/stream/restreams/:id", restreamHandler.UpdateRestream)
+		protected.DELETE("/stream/restreams/:id", restreamHandler.DeleteRestream)
+
+		// ----- Social graph -----
+		users := protected.Group("/users/:username")
+		{
+			users.GET("/follow", followHandler.GetFollowStatus)
+			users.POST("/follow", followHandler.FollowChannel)
+			users.DELETE("/follow", followHandler.UnfollowChannel)
+			users.GET("/followers", followHandler.ListFollowers)
+			users.GET("/following", followHandler.ListFollowing)
+		}
+		protected.GET("/feed/following", followHandler.GetFollowingFeed)
+
+		// ----- Recordings -----
+		protected.PATCH("/videos/:id", videoHandler.UpdateVideo)
+		protected.DELETE("/videos/:id", videoHandler.DeleteVideo)
+		protected.POST("/clips", clipHandler.CreateClip)
+		protected.DELETE("/clips/:id", clipHandler.DeleteClip)
+
+		// ----- Channel moderation -----
+		channels := protected.Group("/channels/:username")
+		{
+			channels.GET("/moderators", moderationHandler.ListModerators)
+			channels.POST("/moderators", moderationHandler.AddModerator)
+			channels.DELETE("/moderators/:target", moderationHandler.RemoveModerator)
+			channels.GET("/bans", moderationHandler.ListBans)
+			channels.POST("/bans", moderationHandler.BanUser)
+			channels.DELETE("/bans/:target", moderationHandler.UnbanUser)
+			channels.DELETE("/chat/messages/:id", moderationHandler.DeleteChatMessage)
+			channels.GET("/chat/settings", moderationHandler.GetChatSettings)
+			channels.PUT("/chat/settings", moderationHandler.UpdateChatSettings)
+			channels.GET("/moderation/log", moderationHandler.ListModerationLog)
+		}
+
+		// ----- Honey ledger -----
+		protected.GET("/wallet", ledgerHandler.GetWallet)
+		protected.GET("/wallet/entries", ledgerHandler.ListLedgerEntries)
+		protected.POST("/channels/:username/tips", ledgerHandler.TipChannel)
+
+		// ----- Polls and predictions -----
+		interactions := protected.Group("/channels/:username")
+		{
+			interactions.POST("/polls", pollHandler.OpenPoll)
+			interactions.POST("/polls/:id/end", pollHandler.EndPoll)
+			interactions.POST("/polls/:id/votes", pollHandler.VotePoll)
+			interactions.POST("/predictions", predictionHandler.OpenPrediction)
+			interactions.POST("/predictions/:id/lock", predictionHandler.LockPrediction)
+			interactions.POST("/predictions/:id/resolve", predictionHandler.ResolvePrediction)
+			interactions.POST("/predictions/:id/cancel", predictionHandler.CancelPrediction)
+			interactions.POST("/predictions/:id/stakes", predictionHandler.StakePrediction)
+		}
+
+		// ----- Notification inbox -----
+		notifications := protected.Group("/notifications")
+		{
+			notifications.GET("", notificationHandler.ListNotifications)
+			notifications.GET("/unread_count", notificationHandler.UnreadNotificationCount)
+			notifications.POST("/read", notificationHandler.MarkNotificationsRead)
+		}
+
+		// ----- Admin routes -----
+		admin := protected.Group("/admin")
+		admin.Use(middleware.AdminOnly(deps.Auth))
+		{
+			admin.POST("/categories", categoryHandler.CreateCategory)
+			admin.PUT("/categories/:slug", categoryHandler.UpdateCategory)
+			admin.DELETE("/categories/:slug", categoryHandler.DeleteCategory)
+			admin.POST("/streams/:username/takedown", streamHandler.TakeDownStream)
+			admin.DELETE("/streams/:username/suspension", streamHandler.LiftStreamSuspension)
+			admin.POST("/ledger/grants", ledgerHandler.GrantHoney)
+			admin.POST("/ledger/adjustments", ledgerHandler.AdjustHoney)
+			admin.GET("/config", configHandler.GetConfig)
 		}
 	}
 }
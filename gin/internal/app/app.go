@@ -0,0 +1,160 @@
+// Package app wires configuration, storage, services and the HTTP router
+// into one App, so that the server and tests build the API the same way.
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/api/v1"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/api/v1/middleware"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/config"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/db"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// App owns the storage, services and router of one API instance. Apps
+// share no state, so several may run in one process.
+type App struct {
+	Config     *config.Config
+	Store      *repo.Store
+	Service    *service.Service
+	Auth       *service.AuthService
+	Sessions   *service.SessionService
+	StreamKeys *service.StreamKeyService
+
+	router *gin.Engine
+	close  func() error
+}
+
+// NewMongo connects to MongoDB and returns an App backed by it, with
+// categories seeded, interrupted recordings recovered and membership
+// renewals running. Close stops the renewals and disconnects.
+func NewMongo(cfg *config.Config) (*App, error) {
+	d, err := db.Connect(cfg.Mongo.URI, cfg.Mongo.Database)
+	if err != nil {
+		return nil, err
+	}
+	store := repo.NewMongoStore(d)
+	svc := service.New(cfg, store)
+	ctx := context.Background()
+	if err := svc.SeedDefaultCategories(ctx); err != nil {
+		log.Printf("� failed to seed categories: %v", err)
+	}
+	if err := svc.RecoverRecordings(ctx); err != nil {
+		log.Printf("� failed to recover recordings: %v", err)
+	}
+	svc.StartMembershipRenewals()
+
+	a := build(cfg, store, svc, svc.DropPublisher)
+	a.close = func() error { return db.Close(d) }
+	return a, nil
+}
+
+// NewInMemory returns an App over empty in-memory stores, for tests: serve
+// a.Handler() with httptest. Rotating a stream key does not talk to any
+// ingest.
+func NewInMemory(cfg *config.Config) *App {
+	return New(cfg, repo.NewMemoryStore(), nil)
+}
+
+// New returns an App over store. endBroadcast is called when a stream key
+// is rotated and may be nil.
+func New(cfg *config.Config, store *repo.Store, endBroadcast func(context.Context, primitive.ObjectID) error) *App {
+	return build(cfg, store, service.New(cfg, store), endBroadcast)
+}
+
+func build(cfg *config.Config, store *repo.Store, svc *service.Service, endBroadcast func(context.Context, primitive.ObjectID) error) *App {
+	a := &App{
+		Config:     cfg,
+		Store:      store,
+		Service:    svc,
+		Auth:       service.NewAuthService(store.Users),
+		Sessions:   service.NewSessionService(store.Sessions),
+		StreamKeys: service.NewStreamKeyService(store.StreamKeys, endBroadcast),
+	}
+	a.router = a.newRouter()
+	return a
+}
+
+// newRouter builds the gin engine: recovery, CORS, the body size limit,
+// the v1 routes and /health.
+func (a *App) newRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery()) // recover from panics
+
+	// ---------- CORS ----------
+	// This configuration:
+	//   • Echoes back the exact Origin (if it is in cors.origins)
+	//   • Allows credentials (cookies, Authorization header)
+	//   • Allows the methods/headers you need
+	r.Use(cors.New(cors.Config{
+		AllowOriginFunc:  a.Config.AllowOrigin,
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-Session-ID", "Idempotency-Key"},
+		ExposeHeaders:    []string{"X-Session-ID"},
+		AllowCredentials: true,
+	}))
+	r.Use(middleware.MaxBodyBytes(a.Config.Limits.MaxBodyBytes))
+
+	v1.RegisterRoutes(r.Group("/api/v1"), v1.Deps{
+		Config:     a.Config,
+		Auth:       a.Auth,
+		Sessions:   a.Sessions,
+		StreamKeys: a.StreamKeys,
+		Service:    a.Service,
+	})
+
+	r.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	return r
+}
+
+// Handler returns the App's HTTP handler.
+func (a *App) Handler() http.Handler {
+	return a.router
+}
+
+// Run starts the RTMP ingest, if configured, and serves HTTP until it
+// fails.
+func (a *App) Run() error {
+	if addr := a.Config.Ingest.RTMPAddr; addr != "" {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("RTMP ingest: %w", err)
+		}
+		ingest := a.Service.NewIngestServer()
+		log.Printf("� RTMP ingest listening on %s", addr)
+		go func() {
+			if err := ingest.Serve(l); err != nil {
+				log.Fatalf("� RTMP ingest crashed: %v", err)
+			}
+		}()
+	}
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", a.Config.Server.Port),
+		Handler:           a.router,
+		ReadHeaderTimeout: a.Config.Server.ReadHeaderTimeout,
+		IdleTimeout:       a.Config.Server.IdleTimeout,
+	}
+	log.Printf("� Server listening on %s", srv.Addr)
+	return srv.ListenAndServe()
+}
+
+// Close stops the App's background jobs and releases what it opened.
+func (a *App) Close() error {
+	a.Service.Close()
+	if a.close == nil {
+		return nil
+	}
+	return a.close()
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/config"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// caller makes a request with an optional session and JSON body, checks
+// the status and returns the decoded JSON response.
+type caller func(method, path, session string, body any, want int) map[string]any
+
+// serve serves a over httptest for the duration of the test.
+func serve(t *testing.T, a *App) caller {
+	gin.SetMode(gin.TestMode)
+	srv := httptest.NewServer(a.Handler())
+	t.Cleanup(func() {
+		srv.Close()
+		_ = a.Close()
+	})
+
+	return func(method, path, session string, body any, want int) map[string]any {
+		t.Helper()
+		var buf bytes.Buffer
+		if body != nil {
+			if err := json.NewEncoder(&buf).Encode(body); err != nil {
+				t.Fatal(err)
+			}
+		}
+		req, err := http.NewRequest(method, srv.URL+path, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if session != "" {
+			req.Header.Set("Authorization", "Bearer "+session)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		out := map[string]any{}
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		if resp.StatusCode != want {
+			t.Fatalf("%s %s: status %d, want %d (%v)", method, path, resp.StatusCode, want, out)
+		}
+		return out
+	}
+}
+
+func newUser(username string) map[string]string {
+	return map[string]string{
+		"username":   username,
+		"first_name": "Test",
+		"last_name":  "User",
+		"email":      username + "@example.com",
+		"password":   "wonderland",
+	}
+}
+
+// signUp registers username and returns the session ID of a login.
+func signUp(call caller, username string) string {
+	call("POST", "/api/v1/auth/register", "", newUser(username), http.StatusCreated)
+	login := call("POST", "/api/v1/auth/login", "", map[string]string{
+		"email_or_username": username + "@example.com", "password": "wonderland",
+	}, http.StatusOK)
+	sid, _ := login["session_id"].(string)
+	return sid
+}
+
+func TestInMemoryAuthAndStreamKey(t *testing.T) {
+	call := serve(t, NewInMemory(config.Default()))
+	call("GET", "/health", "", nil, http.StatusOK)
+	sid := signUp(call, "alice")
+	call("POST", "/api/v1/auth/register", "", newUser("alice"), http.StatusConflict)
+	call("POST", "/api/v1/auth/login", "", map[string]string{
+		"email_or_username": "alice", "password": "wrong",
+	}, http.StatusUnauthorized)
+
+	call("GET", "/api/v1/stream-key", "", nil, http.StatusUnauthorized)
+	first := call("GET", "/api/v1/stream-key", sid, nil, http.StatusOK)["stream_key"]
+	if again := call("GET", "/api/v1/stream-key", sid, nil, http.StatusOK)["stream_key"]; again != first {
+		t.Fatalf("stream key changed from %v to %v", first, again)
+	}
+	if rotated := call("POST", "/api/v1/stream-key/new", sid, nil, http.StatusOK)["stream_key"]; rotated == first {
+		t.Fatal("rotated stream key is unchanged")
+	}
+	call("GET", "/api/v1/protected/profile", sid, nil, http.StatusOK)
+	call("GET", "/api/v1/admin/config", sid, nil, http.StatusForbidden)
+
+	call("GET", "/api/v1/search?q=alice", "", nil, http.StatusOK)
+	call("GET", "/api/v1/wallet", sid, nil, http.StatusOK)
+	call("GET", "/api/v1/no-such-route", "", nil, http.StatusNotFound)
+}
+
+// Apps running side by side share no state: each sees only the users,
+// follows and notifications made through it.
+func TestInMemoryAppsAreIsolated(t *testing.T) {
+	for i := range 4 {
+		t.Run(fmt.Sprintf("app%d", i), func(t *testing.T) {
+			t.Parallel()
+			call := serve(t, NewInMemory(config.Default()))
+			alice := signUp(call, "alice")
+			bob := signUp(call, "bob")
+
+			call("POST", "/api/v1/users/alice/follow", bob, nil, http.StatusOK)
+			if n := call("GET", "/api/v1/users/alice", "", nil, http.StatusOK)["follower_count"]; n != 1.0 {
+				t.Errorf("alice has %v followers, want 1", n)
+			}
+			if n := call("GET", "/api/v1/notifications/unread_count", alice, nil, http.StatusOK)["unread"]; n != 1.0 {
+				t.Errorf("alice has %v unread notifications, want 1", n)
+			}
+			if following := call("GET", "/api/v1/users/bob/follow", alice, nil, http.StatusOK)["following"]; following != false {
+				t.Error("alice follows bob")
+			}
+		})
+	}
+}
+
+// A rotated key whose broadcast could not be cut off is not reported as
+// a clean rotation.
+func TestStreamKeyRotationWithUnreachableIngest(t *testing.T) {
+	unreachable := func(context.Context, primitive.ObjectID) error {
+		return fmt.Errorf("%w: connection refused", service.ErrIngestUnreachable)
+	}
+	call := serve(t, New(config.Default(), repo.NewMemoryStore(), unreachable))
+	sid := signUp(call, "alice")
+	first := call("GET", "/api/v1/stream-key", sid, nil, http.StatusOK)["stream_key"]
+	call("POST", "/api/v1/stream-key/new", sid, nil, http.StatusBadGateway)
+	if now := call("GET", "/api/v1/stream-key", sid, nil, http.StatusOK)["stream_key"]; now == first {
+		t.Error("the old key is still valid")
+	}
+}
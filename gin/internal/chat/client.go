@@ -0,0 +1,133 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = (pongWait * 9) / 10
+	maxFrameSize   = 4096
+	sendBufferSize = 64
+)
+
+// Client is one WebSocket connection to a chat room. A zero UserID means
+// an anonymous, read-only viewer.
+type Client struct {
+	UserID      primitive.ObjectID
+	Username    string
+	DisplayName string
+
+	conn      *websocket.Conn
+	send      chan []byte
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewClient wraps an upgraded connection.
+func NewClient(conn *websocket.Conn, userID primitive.ObjectID, username, displayName string) *Client {
+	return &Client{
+		UserID:      userID,
+		Username:    username,
+		DisplayName: displayName,
+		conn:        conn,
+		send:        make(chan []byte, sendBufferSize),
+		done:        make(chan struct{}),
+	}
+}
+
+// Anonymous reports whether the client is not logged in.
+func (c *Client) Anonymous() bool {
+	return c.UserID.IsZero()
+}
+
+// Send queues an event for this client only. It returns false if the
+// client is gone or too far behind, in which case it is disconnected.
+func (c *Client) Send(ev Event) bool {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return false
+	}
+	return c.sendRaw(b)
+}
+
+func (c *Client) sendRaw(b []byte) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+	select {
+	case c.send <- b:
+		return true
+	default:
+		// Slow consumer: drop the connection rather than block the room.
+		c.Close()
+		return false
+	}
+}
+
+// Close terminates the connection. It is safe to call more than once.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		_ = c.conn.Close()
+	})
+}
+
+// WritePump writes queued frames and keep-alive pings until the client closes.
+func (c *Client) WritePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.Close()
+	}()
+	for {
+		select {
+		case <-c.done:
+			return
+		case b := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, b); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ReadPump reads inbound frames and hands them to onFrame until the
+// connection fails or is closed. It blocks.
+func (c *Client) ReadPump(onFrame func(Inbound)) {
+	defer c.Close()
+	c.conn.SetReadLimit(maxFrameSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		var in Inbound
+		if err := c.conn.ReadJSON(&in); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				// A bad frame, not a broken connection.
+				c.Send(Event{Type: EventError, Error: "frames must be JSON chat frames"})
+				continue
+			}
+			return
+		}
+		onFrame(in)
+	}
+}
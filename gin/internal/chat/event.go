@@ -0,0 +1,48 @@
+// Package chat implements per-stream chat rooms over WebSocket.
+package chat
+
+import "github.com/CSBOWMA/bigredhacks2025/gin/models"
+
+// Event types sent to clients.
+const (
+	EventHistory = "history" // backfill sent once on join
+	EventMessage = "message" // a new chat message
+	EventSystem  = "system"  // informational text from the server
+	EventError   = "error"   // a problem with the client's last frame
+
+	// Moderation events.
+	EventDelete    = "delete"     // Data["message_id"] was removed
+	EventClearUser = "clear_user" // all messages of Data["user_id"] were removed
+	EventSettings  = "settings"   // the room's chat modes changed; Data holds them
+
+	// Audience interaction: Poll or Prediction holds its current state
+	// whenever it opens, its tallies move or it ends.
+	EventPoll       = "poll"
+	EventPrediction = "prediction"
+
+	// EventRaid tells viewers of a broadcast that ended to move on to
+	// Data["target"], the channel it raided.
+	EventRaid = "raid"
+
+	// EventAlert asks on-stream overlays to show an alert; Data["kind"]
+	// says what happened and the rest of Data describes it.
+	EventAlert = "alert"
+)
+
+// Event is the JSON frame exchanged with clients and carried over PubSub.
+type Event struct {
+	Type       string                 `json:"type"`
+	Message    *models.ChatMessage    `json:"message,omitempty"`
+	Poll       *models.Poll           `json:"poll,omitempty"`
+	Prediction *models.Prediction     `json:"prediction,omitempty"`
+	Messages   []models.ChatMessage   `json:"messages,omitempty"`
+	Text       string                 `json:"text,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+// Inbound is a frame sent by a client. Only "message" is accepted today.
+type Inbound struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
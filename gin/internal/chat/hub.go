@@ -0,0 +1,110 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// Hub tracks the local connections of every room and relays room events
+// through a PubSub, subscribing to a room only while it has local clients.
+type Hub struct {
+	ps PubSub
+
+	// subMu serialises Join/Leave so PubSub (un)subscription happens
+	// without holding mu, which deliver needs while PubSub holds its own lock.
+	subMu sync.Mutex
+	mu    sync.RWMutex
+	rooms map[string]*room
+}
+
+type room struct {
+	clients     map[*Client]struct{}
+	unsubscribe func()
+}
+
+// NewHub returns a hub on top of ps.
+func NewHub(ps PubSub) *Hub {
+	return &Hub{ps: ps, rooms: make(map[string]*room)}
+}
+
+// Join adds c to the room.
+func (h *Hub) Join(name string, c *Client) {
+	h.subMu.Lock()
+	defer h.subMu.Unlock()
+
+	h.mu.RLock()
+	r := h.rooms[name]
+	h.mu.RUnlock()
+
+	if r == nil {
+		r = &room{clients: make(map[*Client]struct{})}
+		r.unsubscribe = h.ps.Subscribe(name, func(payload []byte) { h.deliver(name, payload) })
+	}
+
+	h.mu.Lock()
+	h.rooms[name] = r
+	r.clients[c] = struct{}{}
+	h.mu.Unlock()
+}
+
+// Leave removes c from the room, dropping the subscription when the room
+// has no local clients left.
+func (h *Hub) Leave(name string, c *Client) {
+	h.subMu.Lock()
+	defer h.subMu.Unlock()
+
+	h.mu.Lock()
+	r := h.rooms[name]
+	if r == nil {
+		h.mu.Unlock()
+		return
+	}
+	delete(r.clients, c)
+	empty := len(r.clients) == 0
+	if empty {
+		delete(h.rooms, name)
+	}
+	h.mu.Unlock()
+
+	if empty {
+		r.unsubscribe()
+	}
+}
+
+// Broadcast publishes ev to everyone in the room on every instance.
+func (h *Hub) Broadcast(ctx context.Context, name string, ev Event) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return h.ps.Publish(ctx, name, b)
+}
+
+// LocalClients returns how many connections this instance holds for the room.
+func (h *Hub) LocalClients(name string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if r := h.rooms[name]; r != nil {
+		return len(r.clients)
+	}
+	return 0
+}
+
+// deliver hands a published payload to the room's local clients.
+func (h *Hub) deliver(name string, payload []byte) {
+	h.mu.RLock()
+	r := h.rooms[name]
+	var targets []*Client
+	if r != nil {
+		targets = make([]*Client, 0, len(r.clients))
+		for c := range r.clients {
+			targets = append(targets, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range targets {
+		c.sendRaw(payload)
+	}
+}
@@ -0,0 +1,66 @@
+package chat
+
+import (
+	"context"
+	"sync"
+)
+
+// PubSub carries serialised events between Hubs. With the in-memory
+// backend a single gin instance talks to itself; a shared backend (Redis,
+// NATS, ...) implementing the same interface lets several instances serve
+// the same room, each delivering to its own local connections.
+type PubSub interface {
+	// Publish sends payload to every subscriber of room, on every instance.
+	Publish(ctx context.Context, room string, payload []byte) error
+	// Subscribe registers fn for room and returns a func that removes it.
+	// fn must not block.
+	Subscribe(room string, fn func(payload []byte)) (unsubscribe func())
+	// Close releases backend resources.
+	Close() error
+}
+
+// MemoryPubSub is the default, single-process PubSub.
+type MemoryPubSub struct {
+	mu   sync.RWMutex
+	next int
+	subs map[string]map[int]func([]byte)
+}
+
+// NewMemoryPubSub returns an empty in-memory PubSub.
+func NewMemoryPubSub() *MemoryPubSub {
+	return &MemoryPubSub{subs: make(map[string]map[int]func([]byte))}
+}
+
+// Publish calls every subscriber of room synchronously.
+func (m *MemoryPubSub) Publish(_ context.Context, room string, payload []byte) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, fn := range m.subs[room] {
+		fn(payload)
+	}
+	return nil
+}
+
+// Subscribe registers fn for room.
+func (m *MemoryPubSub) Subscribe(room string, fn func([]byte)) func() {
+	m.mu.Lock()
+	id := m.next
+	m.next++
+	if m.subs[room] == nil {
+		m.subs[room] = make(map[int]func([]byte))
+	}
+	m.subs[room][id] = fn
+	m.mu.Unlock()
+
+	return func() {
+		m.mu.Lock()
+		delete(m.subs[room], id)
+		if len(m.subs[room]) == 0 {
+			delete(m.subs, room)
+		}
+		m.mu.Unlock()
+	}
+}
+
+// Close is a no-op for the in-memory backend.
+func (m *MemoryPubSub) Close() error { return nil }
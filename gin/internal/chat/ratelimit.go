@@ -0,0 +1,73 @@
+package chat
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter is a per-key token bucket: each key may burst up to burst
+// actions and then refills one token every interval.
+type RateLimiter struct {
+	interval time.Duration
+	burst    float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	sweep   time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a limiter allowing burst actions at once and one
+// more per interval afterwards.
+func NewRateLimiter(interval time.Duration, burst int) *RateLimiter {
+	return &RateLimiter{
+		interval: interval,
+		burst:    float64(burst),
+		buckets:  make(map[string]*bucket),
+		sweep:    time.Now(),
+	}
+}
+
+// Allow consumes a token for key and reports whether one was available.
+func (l *RateLimiter) Allow(key string) bool {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.evictIdle(now)
+
+	b := l.buckets[key]
+	if b == nil {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens += float64(now.Sub(b.last)) / float64(l.interval)
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// evictIdle drops buckets that have fully refilled, at most once a minute,
+// so the map does not grow with every user who ever chatted.
+func (l *RateLimiter) evictIdle(now time.Time) {
+	if now.Sub(l.sweep) < time.Minute {
+		return
+	}
+	l.sweep = now
+	full := time.Duration(l.burst) * l.interval
+	for k, b := range l.buckets {
+		if now.Sub(b.last) > full {
+			delete(l.buckets, k)
+		}
+	}
+}
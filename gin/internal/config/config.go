@@ -0,0 +1,456 @@
+// Package config loads the server configuration.
+//
+// Every setting has a default and can be set, from lowest to highest
+// precedence, in a YAML file (-config or CONFIG_FILE), an environment
+// variable (also read from ./.env) or a command-line flag. The tags on
+// each field name its YAML key, variable and flag; fields tagged redact
+// are hidden in Dump.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the whole server configuration.
+type Config struct {
+	Server     Server     `yaml:"server"`
+	Mongo      Mongo      `yaml:"mongo"`
+	Auth       Auth       `yaml:"auth"`
+	CORS       CORS       `yaml:"cors"`
+	Ingest     Ingest     `yaml:"ingest"`
+	HLS        HLS        `yaml:"hls"`
+	Limits     Limits     `yaml:"limits"`
+	Moderation Moderation `yaml:"moderation"`
+}
+
+// Server configures the HTTP server.
+type Server struct {
+	// Mode is gin's mode. Only debug, for development, runs without a
+	// restream secret.
+	Mode              string        `yaml:"mode" env:"GIN_MODE" flag:"mode" usage:"gin mode: debug (development), test or release"`
+	Port              int           `yaml:"port" env:"PORT" flag:"port" usage:"HTTP port"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"READ_HEADER_TIMEOUT" flag:"read-header-timeout" usage:"time allowed to read request headers"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"IDLE_TIMEOUT" flag:"idle-timeout" usage:"keep-alive connection idle timeout"`
+}
+
+// Mongo configures the database.
+type Mongo struct {
+	URI      string `yaml:"uri" env:"MONGODB_URI" flag:"mongo-uri" usage:"MongoDB connection URI" redact:"url"`
+	Database string `yaml:"database" env:"MONGODB_DB" flag:"mongo-db" usage:"MongoDB database name"`
+}
+
+// Auth holds the secrets. JWTSecret keys playback tokens and viewer
+// anonymization and is required, so that neither changes on a restart.
+type Auth struct {
+	JWTSecret string `yaml:"jwt_secret" env:"JWT_SECRET" flag:"jwt-secret" usage:"signing secret for playback tokens" redact:"true"`
+	// IngestSecret guards the nginx-rtmp callbacks. It is required unless
+	// the built-in ingest runs, in which case the callbacks are closed.
+	IngestSecret string `yaml:"ingest_secret" env:"INGEST_SECRET" flag:"ingest-secret" usage:"secret the RTMP callbacks must pass" redact:"true"`
+	// RestreamSecret encrypts restream keys. It is required outside debug
+	// mode; in debug mode it falls back to JWTSecret.
+	RestreamSecret string `yaml:"restream_secret" env:"RESTREAM_SECRET" flag:"restream-secret" usage:"key restream destination keys are encrypted with" redact:"true"`
+}
+
+// CORS lists the browser origins allowed to call the API with
+// credentials. An origin without a port allows every port of its host.
+type CORS struct {
+	Origins []string `yaml:"origins" env:"CORS_ORIGINS" flag:"cors-origins" usage:"comma-separated allowed origins"`
+}
+
+// Ingest configures how broadcasts come in and are relayed.
+type Ingest struct {
+	// RTMPAddr runs the built-in RTMP server instead of nginx-rtmp.
+	RTMPAddr string `yaml:"rtmp_addr" env:"RTMP_ADDR" flag:"rtmp-addr" usage:"listen address of the built-in RTMP ingest (disabled if empty)"`
+	// PlayFrom lists the addresses, besides loopback, that may play
+	// streams back from the built-in ingest, such as relays on another
+	// host. Connections through a published Docker port come from the
+	// bridge gateway, so its range does not belong here.
+	PlayFrom []string `yaml:"play_from" env:"RTMP_PLAY_FROM" flag:"rtmp-play-from" usage:"comma-separated IPs or CIDR ranges besides loopback that may play from the built-in ingest"`
+	// ControlURL is nginx-rtmp's control module, used to drop publishers.
+	ControlURL string `yaml:"control_url" env:"RTMP_CONTROL_URL" flag:"rtmp-control-url" usage:"nginx-rtmp control URL"`
+	// RestreamSource is where relays pull broadcasts back from; empty
+	// means the built-in ingest if it runs, otherwise rtmp://rtmp:1935/live.
+	RestreamSource string `yaml:"restream_source" env:"RESTREAM_SOURCE" flag:"restream-source" usage:"RTMP application relays pull broadcasts from"`
+	FFmpegPath     string `yaml:"ffmpeg_path" env:"FFMPEG_PATH" flag:"ffmpeg-path" usage:"ffmpeg binary used by relays"`
+}
+
+// HLS configures where video is kept on disk.
+type HLS struct {
+	// LiveDir is where the RTMP server writes live HLS (its hls_path).
+	LiveDir string `yaml:"live_dir" env:"HLS_DIR" flag:"hls-dir" usage:"directory of live HLS segments"`
+	VODDir  string `yaml:"vod_dir" env:"VOD_DIR" flag:"vod-dir" usage:"directory recordings are archived to"`
+}
+
+// Limits caps what a single request may ask for.
+type Limits struct {
+	MaxBodyBytes int64 `yaml:"max_body_bytes" env:"MAX_BODY_BYTES" flag:"max-body-bytes" usage:"largest request body accepted"`
+	MaxPageSize  int   `yaml:"max_page_size" env:"MAX_PAGE_SIZE" flag:"max-page-size" usage:"largest page a list endpoint returns"`
+}
+
+// Moderation configures content rules.
+type Moderation struct {
+	// BannedTags are refused in addition to the built-in list.
+	BannedTags []string `yaml:"banned_tags" env:"BANNED_TAGS" flag:"banned-tags" usage:"comma-separated extra banned tags"`
+}
+
+// Default returns the configuration used when nothing is set. It is not
+// valid on its own: the Mongo URI has no default.
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Mode:              "release",
+			Port:              8080,
+			ReadHeaderTimeout: 10 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+		},
+		Mongo:  Mongo{Database: "streaming_app"},
+		CORS:   CORS{Origins: []string{"http://localhost"}},
+		Ingest: Ingest{ControlURL: "http://rtmp:8082/control", FFmpegPath: "ffmpeg"},
+		HLS:    HLS{LiveDir: "/tmp/hls", VODDir: "/tmp/vod"},
+		Limits: Limits{MaxBodyBytes: 1 << 20, MaxPageSize: 50},
+	}
+}
+
+// Load builds the configuration from the defaults, the YAML file, the
+// environment and args (the command line without the program name), then
+// validates it. Flag -h prints the usage and returns flag.ErrHelp.
+func Load(args []string) (*Config, error) {
+	if err := loadDotEnv(); err != nil {
+		return nil, err
+	}
+	c := Default()
+	fields := c.fields()
+
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	path := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML config file (env CONFIG_FILE)")
+	flags := make(map[string]string)
+	for _, f := range fields {
+		name := f.flag
+		fs.Func(name, fmt.Sprintf("%s (env %s)", f.usage, f.env), func(s string) error {
+			flags[name] = s
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	if *path != "" {
+		if err := c.loadFile(*path); err != nil {
+			return nil, err
+		}
+	}
+	var errs []error
+	for _, f := range fields {
+		if s := os.Getenv(f.env); s != "" {
+			if err := f.set(s); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+			}
+		}
+		if s, ok := flags[f.flag]; ok {
+			if err := f.set(s); err != nil {
+				errs = append(errs, fmt.Errorf("-%s: %w", f.flag, err))
+			}
+		}
+	}
+	if err := errors.Join(append(errs, c.Validate())...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// loadFile overlays the YAML file at path. Unknown keys are errors, so
+// that a typo does not silently fall back to a default.
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	defer f.Close()
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate checks every setting and reports all problems at once.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	switch c.Server.Mode {
+	case "debug", "test", "release":
+	default:
+		fail("server.mode", "must be debug, test or release, got %q", c.Server.Mode)
+	}
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		fail("server.port", "must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	if c.Server.ReadHeaderTimeout <= 0 {
+		fail("server.read_header_timeout", "must be positive")
+	}
+	if c.Server.IdleTimeout <= 0 {
+		fail("server.idle_timeout", "must be positive")
+	}
+
+	if c.Mongo.URI == "" {
+		fail("mongo.uri", "is required")
+	} else if u, err := url.Parse(c.Mongo.URI); err != nil || (u.Scheme != "mongodb" && u.Scheme != "mongodb+srv") {
+		fail("mongo.uri", "must be a mongodb:// or mongodb+srv:// URI")
+	}
+	if c.Mongo.Database == "" {
+		fail("mongo.database", "is required")
+	}
+
+	for _, key := range []struct {
+		name, value string
+	}{
+		{"auth.jwt_secret", c.Auth.JWTSecret},
+		{"auth.ingest_secret", c.Auth.IngestSecret},
+		{"auth.restream_secret", c.Auth.RestreamSecret},
+	} {
+		if key.value != "" && len(key.value) < minSecretLength {
+			fail(key.name, "must be at least %d characters", minSecretLength)
+		}
+	}
+
+	if c.Auth.JWTSecret == "" {
+		fail("auth.jwt_secret", "is required")
+	}
+	if c.Server.Mode != "debug" && c.Auth.RestreamSecret == "" {
+		fail("auth.restream_secret", "is required outside debug mode")
+	}
+	if c.Ingest.RTMPAddr == "" && c.Auth.IngestSecret == "" {
+		fail("auth.ingest_secret", "is required when nginx-rtmp is the ingest (ingest.rtmp_addr is empty)")
+	}
+
+	if len(c.CORS.Origins) == 0 {
+		fail("cors.origins", "at least one origin is required")
+	}
+	for _, o := range c.CORS.Origins {
+		if err := checkOrigin(o); err != nil {
+			fail("cors.origins", "%q: %v", o, err)
+		}
+	}
+
+	if c.Ingest.RTMPAddr != "" {
+		if _, _, err := net.SplitHostPort(c.Ingest.RTMPAddr); err != nil {
+			fail("ingest.rtmp_addr", "must be host:port: %v", err)
+		}
+	}
+	for _, p := range c.Ingest.PlayFrom {
+		if _, err := parsePrefix(p); err != nil {
+			fail("ingest.play_from", "%q is not an IP address or CIDR range", p)
+		}
+	}
+	if u, err := url.Parse(c.Ingest.ControlURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		fail("ingest.control_url", "must be an http(s) URL")
+	}
+	if c.Ingest.RestreamSource != "" {
+		if u, err := url.Parse(c.Ingest.RestreamSource); err != nil || u.Scheme != "rtmp" || u.Host == "" {
+			fail("ingest.restream_source", "must be an rtmp:// URL")
+		}
+	}
+	if c.Ingest.FFmpegPath == "" {
+		fail("ingest.ffmpeg_path", "is required")
+	}
+
+	if c.HLS.LiveDir == "" {
+		fail("hls.live_dir", "is required")
+	}
+	if c.HLS.VODDir == "" {
+		fail("hls.vod_dir", "is required")
+	}
+
+	if c.Limits.MaxBodyBytes <= 0 {
+		fail("limits.max_body_bytes", "must be positive")
+	}
+	if c.Limits.MaxPageSize < 1 {
+		fail("limits.max_page_size", "must be at least 1")
+	}
+	return errors.Join(errs...)
+}
+
+// minSecretLength is the shortest secret accepted.
+const minSecretLength = 16
+
+// checkOrigin accepts scheme://host[:port] with nothing after it.
+func checkOrigin(o string) error {
+	u, err := url.Parse(o)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("scheme must be http or https")
+	}
+	if u.Host == "" || u.Hostname() == "*" {
+		return errors.New("host is required and cannot be a wildcard")
+	}
+	if u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return errors.New("must be scheme://host[:port] only")
+	}
+	return nil
+}
+
+// AllowOrigin reports whether the browser origin is in CORS.Origins. An
+// allowed origin without a port matches its host on any port.
+func (c *Config) AllowOrigin(origin string) bool {
+	o, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range c.CORS.Origins {
+		a, err := url.Parse(allowed)
+		if err != nil || a.Scheme != o.Scheme {
+			continue
+		}
+		if a.Host == o.Host || (a.Port() == "" && a.Hostname() == o.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowPlay reports whether a client at addr may play streams back from
+// the built-in ingest: loopback clients and those in Ingest.PlayFrom.
+func (c *Config) AllowPlay(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() {
+		return true
+	}
+	for _, p := range c.Ingest.PlayFrom {
+		if prefix, err := parsePrefix(p); err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePrefix parses a CIDR range, or an IP address as a range of one.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Dump returns the configuration keyed like the YAML file, with secrets
+// redacted, for display to admins.
+func (c *Config) Dump() map[string]map[string]any {
+	out := make(map[string]map[string]any)
+	for _, f := range c.fields() {
+		section, key, _ := strings.Cut(f.key, ".")
+		if out[section] == nil {
+			out[section] = make(map[string]any)
+		}
+		out[section][key] = f.dump()
+	}
+	return out
+}
+
+// redacted replaces a set secret.
+const redacted = "[redacted]"
+
+// field is one setting, reached through reflection.
+type field struct {
+	key, env, flag, usage, redact string
+	v                             reflect.Value
+}
+
+// fields lists the settings of c in declaration order.
+func (c *Config) fields() []field {
+	var fields []field
+	root := reflect.ValueOf(c).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Field(i)
+		sectionKey := root.Type().Field(i).Tag.Get("yaml")
+		for j := 0; j < section.NumField(); j++ {
+			sf := section.Type().Field(j)
+			fields = append(fields, field{
+				key:    sectionKey + "." + sf.Tag.Get("yaml"),
+				env:    sf.Tag.Get("env"),
+				flag:   sf.Tag.Get("flag"),
+				usage:  sf.Tag.Get("usage"),
+				redact: sf.Tag.Get("redact"),
+				v:      section.Field(j),
+			})
+		}
+	}
+	return fields
+}
+
+// set parses s into the field. Lists are comma-separated.
+func (f field) set(s string) error {
+	switch f.v.Interface().(type) {
+	case string:
+		f.v.SetString(s)
+	case time.Duration:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		f.v.SetInt(int64(d))
+	case int, int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("not an integer: %q", s)
+		}
+		f.v.SetInt(n)
+	case []string:
+		var list []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		f.v.Set(reflect.ValueOf(list))
+	default:
+		panic("config: unsupported field type " + f.v.Type().String())
+	}
+	return nil
+}
+
+// dump returns the field's value for display.
+func (f field) dump() any {
+	switch v := f.v.Interface().(type) {
+	case time.Duration:
+		return v.String()
+	case string:
+		switch {
+		case v == "" || f.redact == "":
+			return v
+		case f.redact == "url":
+			if u, err := url.Parse(v); err == nil {
+				return u.Redacted()
+			}
+		}
+		return redacted
+	default:
+		return v
+	}
+}
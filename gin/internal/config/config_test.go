@@ -0,0 +1,162 @@
+package config
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte(`
+server:
+  port: 9000
+  idle_timeout: 30s
+mongo:
+  uri: mongodb://file/
+  database: from_file
+hls:
+  live_dir: /file/hls
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("JWT_SECRET", "a-jwt-secret-for-tests")
+	t.Setenv("RESTREAM_SECRET", "a-restream-secret-for-tests")
+	t.Setenv("INGEST_SECRET", "an-ingest-secret-for-tests")
+	t.Setenv("MONGODB_DB", "from_env")
+	t.Setenv("HLS_DIR", "/env/hls")
+	t.Setenv("CORS_ORIGINS", "https://example.com, http://localhost:3000")
+
+	c, err := Load([]string{"-hls-dir", "/flag/hls"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Server.Port != 9000 || c.Server.IdleTimeout != 30*time.Second {
+		t.Errorf("file values not applied: %+v", c.Server)
+	}
+	if c.Server.ReadHeaderTimeout != Default().Server.ReadHeaderTimeout {
+		t.Errorf("default lost: %v", c.Server.ReadHeaderTimeout)
+	}
+	if c.Mongo.URI != "mongodb://file/" || c.Mongo.Database != "from_env" {
+		t.Errorf("env should override file: %+v", c.Mongo)
+	}
+	if c.HLS.LiveDir != "/flag/hls" {
+		t.Errorf("flag should override env: %q", c.HLS.LiveDir)
+	}
+	if len(c.CORS.Origins) != 2 || c.CORS.Origins[1] != "http://localhost:3000" {
+		t.Errorf("origins: %q", c.CORS.Origins)
+	}
+}
+
+func TestLoadUnknownFileKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  prot: 80\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load([]string{"-config", path}); err == nil {
+		t.Fatal("unknown key accepted")
+	}
+}
+
+func TestValidateListsAllErrors(t *testing.T) {
+	c := Default()
+	c.Server.Port = 0
+	c.Auth.JWTSecret = "short"
+	c.CORS.Origins = []string{"*"}
+	c.Limits.MaxPageSize = 0
+	c.Ingest.PlayFrom = []string{"10.0.0.0/33"}
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("invalid config accepted")
+	}
+	for _, key := range []string{"server.port", "mongo.uri", "auth.jwt_secret", "cors.origins", "ingest.play_from", "limits.max_page_size"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error does not mention %s:\n%v", key, err)
+		}
+	}
+
+	c = Default()
+	c.Mongo.URI = "mongodb://localhost:27017"
+	err = c.Validate()
+	if err == nil || !strings.Contains(err.Error(), "auth.ingest_secret") {
+		t.Fatalf("nginx-rtmp ingest without a secret accepted: %v", err)
+	}
+	for _, key := range []string{"auth.jwt_secret", "auth.restream_secret"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("missing %s accepted: %v", key, err)
+		}
+	}
+	c.Ingest.RTMPAddr = ":1935"
+	c.Auth.JWTSecret = "a-jwt-secret-for-tests"
+	c.Server.Mode = "debug"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("debug mode without a restream secret should be valid: %v", err)
+	}
+	c.Server.Mode = "release"
+	c.Auth.RestreamSecret = "a-restream-secret-for-tests"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("defaults with a URI, secrets and the built-in ingest should be valid: %v", err)
+	}
+}
+
+func TestDumpRedactsSecrets(t *testing.T) {
+	c := Default()
+	c.Mongo.URI = "mongodb://app:hunter2@db:27017/"
+	c.Auth.JWTSecret = "a-very-long-jwt-secret"
+	d := c.Dump()
+	if uri := d["mongo"]["uri"].(string); strings.Contains(uri, "hunter2") {
+		t.Errorf("password in dump: %s", uri)
+	}
+	if d["auth"]["jwt_secret"] != redacted {
+		t.Errorf("jwt secret in dump: %v", d["auth"]["jwt_secret"])
+	}
+	if d["auth"]["ingest_secret"] != "" {
+		t.Errorf("unset secret should stay empty: %v", d["auth"]["ingest_secret"])
+	}
+	if d["server"]["read_header_timeout"] != "10s" {
+		t.Errorf("duration: %v", d["server"]["read_header_timeout"])
+	}
+}
+
+func TestAllowOrigin(t *testing.T) {
+	c := Default()
+	c.CORS.Origins = []string{"http://localhost", "https://example.com:8443"}
+	for origin, want := range map[string]bool{
+		"http://localhost":          true,
+		"http://localhost:3000":     true,
+		"https://localhost":         false,
+		"http://localhost.evil.com": false,
+		"https://example.com:8443":  true,
+		"https://example.com":       false,
+	} {
+		if got := c.AllowOrigin(origin); got != want {
+			t.Errorf("AllowOrigin(%q) = %v, want %v", origin, got, want)
+		}
+	}
+}
+
+func TestAllowPlay(t *testing.T) {
+	c := Default()
+	c.Ingest.PlayFrom = []string{"10.1.0.0/16", "192.0.2.7"}
+	for addr, want := range map[string]bool{
+		"127.0.0.1":        true,
+		"::1":              true,
+		"::ffff:127.0.0.1": true,
+		"10.1.2.3":         true,
+		"::ffff:10.1.2.3":  true,
+		"10.2.0.1":         false,
+		"192.0.2.7":        true,
+		"192.0.2.8":        false,
+		"172.28.0.1":       false, // the compose gateway, i.e. a published port
+		"2001:db8::1":      false,
+	} {
+		if got := c.AllowPlay(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("AllowPlay(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -2,8 +2,8 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"log"
-	"os"
 	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
@@ -11,12 +11,16 @@ import (
     "go.mongodb.org/mongo-driver/bson"
 )
 
-var client *mongo.Client
+// DefaultDatabase is the database Connect selects when given no name.
+const DefaultDatabase = "streaming_app"
 
-// Connect creates a shared *mongo.Client using the supplied URI.
-// The URI is normally read from the env variable MONGODB_URI.
-// It also pings the server to verify the connection.
-func Connect(uri string) error {
+// Connect opens a client using the supplied URI and returns the named
+// database (DefaultDatabase if empty), with its indexes in place.
+// It also pings the server to verify the connection. Close releases it.
+func Connect(uri, name string) (*mongo.Database, error) {
+	if name == "" {
+		name = DefaultDatabase
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -27,68 +31,60 @@ func Connect(uri string) error {
 		SetMinPoolSize(10).
 		SetConnectTimeout(5 * time.Second)
 
-	var err error
-	client, err = mongo.Connect(ctx, clientOptions)
+	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	d := client.Database(name)
 
 	// Ping to be sure we really connected
 	if err = client.Ping(ctx, nil); err != nil {
-		return err
+		_ = Close(d)
+		return nil, err
 	}
-    err = ensureUserIndexes()
-		if err != nil {
-			// If we cannot create indexes we consider it a fatal error.
-			log.Printf("db: failed to create user indexes: %v", err)
-			_ = client.Disconnect(context.Background())
-			client = nil
-			return nil
+	// If we cannot create indexes we consider it a fatal error.
+	for _, idx := range []struct {
+		name   string
+		ensure func(*mongo.Database) error
+	}{
+		{"user", ensureUserIndexes},
+		{"stream key", ensureStreamKeyIndexes},
+		{"stream", ensureStreamIndexes},
+		{"category", ensureCategoryIndexes},
+		{"follow", ensureFollowIndexes},
+		{"notification", ensureNotificationIndexes},
+		{"chat", ensureChatIndexes},
+		{"moderation", ensureModerationIndexes},
+		{"video", ensureVideoIndexes},
+		{"clip", ensureClipIndexes},
+		{"stream invite", ensureStreamInviteIndexes},
+		{"restream", ensureRestreamIndexes},
+		{"analytics", ensureAnalyticsIndexes},
+		{"broadcast", ensureBroadcastIndexes},
+		{"ledger", ensureLedgerIndexes},
+		{"membership", ensureMembershipIndexes},
+		{"poll and prediction", ensureInteractionIndexes},
+		{"raid", ensureRaidIndexes},
+	} {
+		if err := idx.ensure(d); err != nil {
+			_ = Close(d)
+			return nil, fmt.Errorf("db: creating %s indexes: %w", idx.name, err)
 		}
-    err = ensureStreamKeyIndexes()
-		if err != nil {
-			// If we cannot create indexes we consider it a fatal error.
-			log.Printf("db: failed to create stream key indexes: %v", err)
-			_ = client.Disconnect(context.Background())
-			client = nil
-			return nil
-		}
-	log.Println("✅ Connected to MongoDB Atlas")
-	return nil
-}
-
-// Get returns the shared *mongo.Client.
-// Panics if Connect has not been called first.
-func Get() *mongo.Client {
-	if client == nil {
-		panic("mongo client not initialised – call db.Connect first")
-	}
-	return client
-}
-
-// DB returns the *mongo.Database that the app uses.
-// The database name can be overridden with the env var MONGODB_DB;
-// otherwise it defaults to "streaming_app".
-func DB() *mongo.Database {
-	name := os.Getenv("MONGODB_DB")
-	if name == "" {
-		name = "streaming_app"
 	}
-	return Get().Database(name)
+	log.Println("✅ Connected to MongoDB Atlas")
+	return d, nil
 }
 
-// Close disconnects the client (useful for tests or graceful shutdown).
-func Close() error {
-	if client == nil {
-		return nil
-	}
+// Close disconnects the client of a database returned by Connect.
+func Close(d *mongo.Database) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	return client.Disconnect(ctx)
-}// In internal/db/mongo.go – add after the client is connected
+	return d.Client().Disconnect(ctx)
+}
+// In internal/db/mongo.go – add after the client is connected
 
-func ensureUserIndexes() error {
-    coll := DB().Collection("users")
+func ensureUserIndexes(d *mongo.Database) error {
+    coll := d.Collection("users")
 
     // Unique index on email
     _, err := coll.Indexes().CreateOne(context.Background(),
@@ -108,11 +104,44 @@ func ensureUserIndexes() error {
             Options: options.Index().SetUnique(true),
         },
     )
+    if err != nil {
+        return err
+    }
+
+    // Text index for channel search (a collection can only have one).
+    _, err = coll.Indexes().CreateOne(context.Background(),
+        mongo.IndexModel{
+            Keys: bson.D{{Key: "username", Value: "text"}, {Key: "display_name", Value: "text"}},
+            Options: options.Index().
+                SetName("channel_search").
+                SetWeights(bson.D{{Key: "username", Value: 3}, {Key: "display_name", Value: 2}}),
+        },
+    )
+    if err != nil {
+        return err
+    }
+
+    // Case-insensitive prefix autocomplete runs on lower-cased copies of
+    // the names. Users created before the copies existed get them here.
+    _, err = coll.UpdateMany(context.Background(),
+        bson.M{"username_lower": bson.M{"$exists": false}},
+        mongo.Pipeline{{{Key: "$set", Value: bson.M{
+            "username_lower":     bson.M{"$toLower": "$username"},
+            "display_name_lower": bson.M{"$toLower": "$display_name"},
+        }}}},
+    )
+    if err != nil {
+        return err
+    }
+    _, err = coll.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+        {Keys: bson.D{{Key: "username_lower", Value: 1}}},
+        {Keys: bson.D{{Key: "display_name_lower", Value: 1}}},
+    })
     return err
 }
 
-func ensureStreamKeyIndexes() error {
-	coll := DB().Collection("stream_keys")
+func ensureStreamKeyIndexes(d *mongo.Database) error {
+	coll := d.Collection("stream_keys")
 
 	// Unique index on user_id so a user can have at most one key.
 	_, err := coll.Indexes().CreateOne(
@@ -124,3 +153,304 @@ func ensureStreamKeyIndexes() error {
 	)
 	return err
 }
+
+
+func ensureStreamIndexes(d *mongo.Database) error {
+	coll := d.Collection("streams")
+
+	_, err := coll.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		// One stream document per user.
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("user_id_unique"),
+		},
+		{
+			Keys:    bson.D{{Key: "username", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("username_unique"),
+		},
+		// Full-text search; titles matter most, then tags, then descriptions.
+		{
+			Keys: bson.D{
+				{Key: "title", Value: "text"},
+				{Key: "tags", Value: "text"},
+				{Key: "description", Value: "text"},
+			},
+			Options: options.Index().
+				SetName("stream_search").
+				SetWeights(bson.D{{Key: "title", Value: 10}, {Key: "tags", Value: 5}, {Key: "description", Value: 1}}),
+		},
+		// Filtered listings ordered by recency, and tag autocomplete.
+		{Keys: bson.D{{Key: "live", Value: 1}, {Key: "updated_at", Value: -1}}},
+		{Keys: bson.D{{Key: "category", Value: 1}, {Key: "updated_at", Value: -1}}},
+		{Keys: bson.D{{Key: "tags", Value: 1}}},
+		// Trending tags look at streams that ended recently.
+		{Keys: bson.D{{Key: "ended_at", Value: -1}}},
+	})
+	return err
+}
+
+func ensureCategoryIndexes(d *mongo.Database) error {
+	coll := d.Collection("categories")
+
+	_, err := coll.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "slug", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("slug_unique"),
+		},
+		{Keys: bson.D{{Key: "sort_order", Value: 1}, {Key: "name", Value: 1}}},
+	})
+	return err
+}
+
+func ensureFollowIndexes(d *mongo.Database) error {
+	coll := d.Collection("follows")
+
+	_, err := coll.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		// A user follows a channel at most once; this is what keeps the
+		// follower counters from drifting under concurrent requests.
+		{
+			Keys:    bson.D{{Key: "follower_id", Value: 1}, {Key: "channel_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("follower_channel_unique"),
+		},
+		// Followers / following lists, newest first.
+		{Keys: bson.D{{Key: "channel_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "follower_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		// Batched went-live fan-out walks followers by edge ID.
+		{Keys: bson.D{{Key: "channel_id", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	return err
+}
+
+func ensureNotificationIndexes(d *mongo.Database) error {
+	coll := d.Collection("notifications")
+
+	_, err := coll.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		// Inbox listing (optionally unread only), newest first.
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "read", Value: 1}, {Key: "created_at", Value: -1}}},
+		// Old notifications expire on their own.
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(90 * 24 * 60 * 60).SetName("created_at_ttl"),
+		},
+	})
+	return err
+}
+
+func ensureChatIndexes(d *mongo.Database) error {
+	coll := d.Collection("chat_messages")
+
+	_, err := coll.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		// Backfill and history pages per room, newest first.
+		{Keys: bson.D{{Key: "channel", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		// Slow mode looks up a user's latest message in a room.
+		{Keys: bson.D{{Key: "channel", Value: 1}, {Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		// Chat is kept for a week.
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(7 * 24 * 60 * 60).SetName("created_at_ttl"),
+		},
+	})
+	return err
+}
+
+func ensureModerationIndexes(d *mongo.Database) error {
+	ctx := context.Background()
+
+	_, err := d.Collection("channel_moderators").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "channel_id", Value: 1}, {Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("channel_user_unique"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = d.Collection("channel_bans").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "channel_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("channel_user_unique"),
+		},
+		// Timeouts disappear once they expire; permanent bans have no expires_at.
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("expires_at_ttl"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = d.Collection("moderation_log").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "channel_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "channel_id", Value: 1}, {Key: "action", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+func ensureVideoIndexes(d *mongo.Database) error {
+	coll := d.Collection("videos")
+
+	_, err := coll.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		// One recording per broadcast.
+		{
+			Keys:    bson.D{{Key: "broadcast_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("broadcast_id_unique"),
+		},
+		// A channel's videos, newest first.
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "started_at", Value: -1}, {Key: "_id", Value: -1}}},
+		// Recordings interrupted by a restart.
+		{Keys: bson.D{{Key: "status", Value: 1}}},
+	})
+	return err
+}
+
+func ensureClipIndexes(d *mongo.Database) error {
+	coll := d.Collection("clips")
+
+	_, err := coll.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		// A channel's clips, newest first.
+		{Keys: bson.D{{Key: "channel_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "creator_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+func ensureStreamInviteIndexes(d *mongo.Database) error {
+	coll := d.Collection("stream_invites")
+
+	_, err := coll.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "code", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("code_unique"),
+		},
+		{Keys: bson.D{{Key: "channel_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+func ensureRestreamIndexes(d *mongo.Database) error {
+	coll := d.Collection("restream_destinations")
+
+	_, err := coll.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	return err
+}
+
+func ensureAnalyticsIndexes(d *mongo.Database) error {
+	ctx := context.Background()
+
+	_, err := d.Collection("viewer_sessions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		// Date-range reports per channel.
+		{Keys: bson.D{{Key: "channel_id", Value: 1}, {Key: "joined_at", Value: 1}}},
+		{Keys: bson.D{{Key: "broadcast_id", Value: 1}}},
+		// Watch history and co-watching for recommendations.
+		{Keys: bson.D{{Key: "viewer", Value: 1}, {Key: "joined_at", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = d.Collection("channel_daily_stats").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "channel_id", Value: 1}, {Key: "day", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("channel_day_unique"),
+	})
+	return err
+}
+
+func ensureBroadcastIndexes(d *mongo.Database) error {
+	coll := d.Collection("broadcasts")
+
+	_, err := coll.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		// Channel history pages and analytics ranges, newest first.
+		Keys: bson.D{{Key: "channel_id", Value: 1}, {Key: "started_at", Value: -1}, {Key: "_id", Value: -1}},
+	})
+	return err
+}
+
+func ensureLedgerIndexes(d *mongo.Database) error {
+	ctx := context.Background()
+
+	_, err := d.Collection("ledger_transfers").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "idempotency_key", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("idempotency_key_unique"),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = d.Collection("ledger_entries").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Balances and statements per account, newest first.
+		Keys: bson.D{{Key: "account", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+	})
+	return err
+}
+
+func ensureMembershipIndexes(d *mongo.Database) error {
+	ctx := context.Background()
+
+	_, err := d.Collection("membership_tiers").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "channel_id", Value: 1}, {Key: "price", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = d.Collection("memberships").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// One membership per user and channel, reused on resubscribe.
+			Keys:    bson.D{{Key: "channel_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("channel_user_unique"),
+		},
+		{
+			// Members of a channel, newest first.
+			Keys: bson.D{{Key: "channel_id", Value: 1}, {Key: "status", Value: 1}, {Key: "started_at", Value: -1}, {Key: "_id", Value: -1}},
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{
+			// Memberships due for renewal or expiry.
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}},
+		},
+	})
+	return err
+}
+
+func ensureInteractionIndexes(d *mongo.Database) error {
+	ctx := context.Background()
+
+	for _, coll := range []string{"polls", "predictions"} {
+		_, err := d.Collection(coll).Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "channel_id", Value: 1}, {Key: "status", Value: 1}}},
+			{Keys: bson.D{{Key: "broadcast_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	// One vote per user and poll, one stake per user and prediction.
+	_, err := d.Collection("poll_votes").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "poll_id", Value: 1}, {Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("poll_user_unique"),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = d.Collection("prediction_stakes").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "prediction_id", Value: 1}, {Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("prediction_user_unique"),
+	})
+	return err
+}
+
+func ensureRaidIndexes(d *mongo.Database) error {
+	ctx := context.Background()
+
+	// At most one pending raid per channel; launched and canceled ones are
+	// kept as history.
+	_, err := d.Collection("raids").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "from_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("pending_raid_unique").
+				SetPartialFilterExpression(bson.M{"status": "pending"}),
+		},
+		{Keys: bson.D{{Key: "to_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
@@ -0,0 +1,216 @@
+// Package flv parses the audio and video tag bodies RTMP carries (H.264
+// and AAC) into what an MPEG-TS muxer needs: Annex B access units and
+// ADTS frames.
+package flv
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrUnsupported is returned for codecs other than H.264 and AAC.
+var ErrUnsupported = errors.New("flv: unsupported codec")
+
+// ErrMalformed is returned for truncated or inconsistent tag bodies.
+var ErrMalformed = errors.New("flv: malformed tag")
+
+const (
+	codecAVC      = 7
+	soundAAC      = 10
+	frameKey      = 1
+	packetHeader  = 0 // sequence header (decoder configuration)
+	packetPayload = 1
+)
+
+// VideoTag is a parsed H.264 video tag body.
+type VideoTag struct {
+	Keyframe       bool
+	SequenceHeader bool
+	// CompositionTime is PTS minus DTS, in milliseconds.
+	CompositionTime int32
+	Data            []byte
+}
+
+// ParseVideo parses a video tag body.
+func ParseVideo(body []byte) (VideoTag, error) {
+	if len(body) < 1 {
+		return VideoTag{}, ErrMalformed
+	}
+	if body[0]&0x0f != codecAVC {
+		return VideoTag{}, ErrUnsupported
+	}
+	if len(body) < 5 {
+		return VideoTag{}, ErrMalformed
+	}
+	cts := int32(uint32(body[2])<<16|uint32(body[3])<<8|uint32(body[4])) << 8 >> 8 // SI24
+	return VideoTag{
+		Keyframe:        body[0]>>4 == frameKey,
+		SequenceHeader:  body[1] == packetHeader,
+		CompositionTime: cts,
+		Data:            body[5:],
+	}, nil
+}
+
+// AudioTag is a parsed AAC audio tag body.
+type AudioTag struct {
+	SequenceHeader bool
+	Data           []byte
+}
+
+// ParseAudio parses an audio tag body.
+func ParseAudio(body []byte) (AudioTag, error) {
+	if len(body) < 1 {
+		return AudioTag{}, ErrMalformed
+	}
+	if body[0]>>4 != soundAAC {
+		return AudioTag{}, ErrUnsupported
+	}
+	if len(body) < 2 {
+		return AudioTag{}, ErrMalformed
+	}
+	return AudioTag{SequenceHeader: body[1] == packetHeader, Data: body[2:]}, nil
+}
+
+// AVCConfig is an H.264 decoder configuration record.
+type AVCConfig struct {
+	LengthSize int // bytes in each NALU length prefix
+	SPS        [][]byte
+	PPS        [][]byte
+}
+
+// ParseAVCConfig parses the AVCDecoderConfigurationRecord carried in a
+// video sequence header.
+func ParseAVCConfig(b []byte) (*AVCConfig, error) {
+	if len(b) < 6 {
+		return nil, ErrMalformed
+	}
+	c := &AVCConfig{LengthSize: int(b[4]&0x03) + 1}
+	n := int(b[5] & 0x1f)
+	b = b[6:]
+	var err error
+	if c.SPS, b, err = readParamSets(b, n); err != nil {
+		return nil, err
+	}
+	if len(b) < 1 {
+		return nil, ErrMalformed
+	}
+	n = int(b[0])
+	if c.PPS, _, err = readParamSets(b[1:], n); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func readParamSets(b []byte, n int) ([][]byte, []byte, error) {
+	sets := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		if len(b) < 2 {
+			return nil, nil, ErrMalformed
+		}
+		l := int(binary.BigEndian.Uint16(b))
+		if len(b) < 2+l {
+			return nil, nil, ErrMalformed
+		}
+		sets = append(sets, b[2:2+l])
+		b = b[2+l:]
+	}
+	return sets, b, nil
+}
+
+// H.264 NAL unit types.
+const (
+	naluIDR = 5
+	naluSPS = 7
+	naluPPS = 8
+	naluAUD = 9
+)
+
+var startCode = []byte{0, 0, 0, 1}
+
+// AnnexB converts a length-prefixed access unit to Annex B, starting it
+// with an access unit delimiter and, for keyframes, the parameter sets.
+func (c *AVCConfig) AnnexB(data []byte, keyframe bool) ([]byte, error) {
+	out := make([]byte, 0, len(data)+64)
+	out = append(out, 0, 0, 0, 1, naluAUD, 0xf0)
+	if keyframe {
+		for _, ps := range append(append([][]byte{}, c.SPS...), c.PPS...) {
+			out = append(out, startCode...)
+			out = append(out, ps...)
+		}
+	}
+	for len(data) > 0 {
+		if len(data) < c.LengthSize {
+			return nil, ErrMalformed
+		}
+		var l int
+		for _, b := range data[:c.LengthSize] {
+			l = l<<8 | int(b)
+		}
+		data = data[c.LengthSize:]
+		if l > len(data) {
+			return nil, ErrMalformed
+		}
+		nalu := data[:l]
+		data = data[l:]
+		if len(nalu) == 0 {
+			continue
+		}
+		switch nalu[0] & 0x1f {
+		case naluAUD:
+			continue // already added
+		case naluSPS, naluPPS:
+			if keyframe {
+				continue // already added from the configuration
+			}
+		}
+		out = append(out, startCode...)
+		out = append(out, nalu...)
+	}
+	return out, nil
+}
+
+// AACConfig is the AudioSpecificConfig carried in an audio sequence header.
+type AACConfig struct {
+	ObjectType int
+	RateIndex  int
+	Channels   int
+}
+
+var aacRates = [...]int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+// ParseAACConfig parses an AudioSpecificConfig.
+func ParseAACConfig(b []byte) (*AACConfig, error) {
+	if len(b) < 2 {
+		return nil, ErrMalformed
+	}
+	c := &AACConfig{
+		ObjectType: int(b[0] >> 3),
+		RateIndex:  int(b[0]&0x07)<<1 | int(b[1]>>7),
+		Channels:   int(b[1] >> 3 & 0x0f),
+	}
+	if c.ObjectType == 0 || c.RateIndex >= len(aacRates) {
+		return nil, ErrMalformed
+	}
+	return c, nil
+}
+
+// SampleRate returns the sampling frequency in Hz.
+func (c *AACConfig) SampleRate() int { return aacRates[c.RateIndex] }
+
+// ADTS prefixes a raw AAC frame with an ADTS header.
+func (c *AACConfig) ADTS(frame []byte) []byte {
+	n := len(frame) + 7
+	profile := c.ObjectType - 1
+	if c.ObjectType > 4 {
+		profile = 1 // ADTS cannot signal HE-AAC; its LC core decodes as LC
+	}
+	out := make([]byte, 7, n)
+	out[0] = 0xff
+	out[1] = 0xf1 // MPEG-4, layer 0, no CRC
+	out[2] = byte(profile<<6) | byte(c.RateIndex<<2) | byte(c.Channels>>2&0x01)
+	out[3] = byte(c.Channels&0x03)<<6 | byte(n>>11&0x03)
+	out[4] = byte(n >> 3)
+	out[5] = byte(n&0x07)<<5 | 0x1f
+	out[6] = 0xfc
+	return append(out, frame...)
+}
@@ -0,0 +1,196 @@
+// Package health measures live streams as they arrive, by probing each new
+// HLS segment the ingest writes: bitrate, frame rate, keyframe spacing,
+// codecs and gaps in the video timeline.
+package health
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/mpegts"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/vod"
+)
+
+// Sample is what one segment showed.
+type Sample struct {
+	Segment     string    `json:"segment"`
+	At          time.Time `json:"at"`
+	Duration    float64   `json:"duration"`
+	BitrateKbps int       `json:"bitrate_kbps"`
+	FPS         float64   `json:"fps"`
+	// KeyframeInterval is the longest spacing between keyframes that ended
+	// in this segment, in seconds; 0 if none did.
+	KeyframeInterval float64 `json:"keyframe_interval"`
+	DroppedFrames    int     `json:"dropped_frames"`
+}
+
+// Snapshot is the latest known state of a stream.
+type Snapshot struct {
+	VideoCodec string
+	Profile    string
+	Width      int
+	Height     int
+	AudioCodec string
+	SampleRate int
+	Channels   int
+	// Samples are the most recent segments, oldest first.
+	Samples []Sample
+}
+
+// Monitor probes the segments of the streams it was started for.
+type Monitor struct {
+	dir      string
+	interval time.Duration
+	keep     int
+
+	mu      sync.Mutex
+	streams map[string]*tracker
+}
+
+type tracker struct {
+	stop chan struct{}
+
+	mu      sync.Mutex
+	snap    Snapshot
+	seen    map[string]bool
+	lastKey int64 // DTS of the latest keyframe, -1 before the first
+}
+
+// NewMonitor returns a Monitor reading HLS from dir every interval and
+// keeping the last keep samples per stream.
+func NewMonitor(dir string, interval time.Duration, keep int) *Monitor {
+	return &Monitor{dir: dir, interval: interval, keep: keep, streams: make(map[string]*tracker)}
+}
+
+// Start begins measuring stream name. Starting a measured stream is a no-op.
+func (m *Monitor) Start(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.streams[name]; ok {
+		return
+	}
+	t := &tracker{stop: make(chan struct{}), seen: make(map[string]bool), lastKey: -1}
+	m.streams[name] = t
+	go t.run(m, name)
+}
+
+// Stop stops measuring stream name and forgets it.
+func (m *Monitor) Stop(name string) {
+	m.mu.Lock()
+	t := m.streams[name]
+	delete(m.streams, name)
+	m.mu.Unlock()
+	if t != nil {
+		close(t.stop)
+	}
+}
+
+// Snapshot returns what is known about stream name, and false if it is
+// not being measured.
+func (m *Monitor) Snapshot(name string) (Snapshot, bool) {
+	m.mu.Lock()
+	t := m.streams[name]
+	m.mu.Unlock()
+	if t == nil {
+		return Snapshot{}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	snap := t.snap
+	snap.Samples = append([]Sample(nil), t.snap.Samples...)
+	return snap, true
+}
+
+func (t *tracker) run(m *Monitor, name string) {
+	tick := time.NewTicker(m.interval)
+	defer tick.Stop()
+	for {
+		t.poll(m, name)
+		select {
+		case <-t.stop:
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// poll probes the segments of the live playlist not seen yet.
+func (t *tracker) poll(m *Monitor, name string) {
+	f, err := os.Open(filepath.Join(m.dir, name+".m3u8"))
+	if err != nil {
+		return
+	}
+	segs, err := vod.ParseMediaPlaylist(f)
+	f.Close()
+	if err != nil {
+		return
+	}
+
+	listed := make(map[string]bool, len(segs))
+	for _, seg := range segs {
+		file := filepath.Base(seg.URI)
+		listed[file] = true
+		if t.seen[file] {
+			continue
+		}
+		if s, ok := t.probe(filepath.Join(m.dir, file), seg.Duration); ok {
+			t.mu.Lock()
+			t.snap.Samples = append(t.snap.Samples, s)
+			if over := len(t.snap.Samples) - m.keep; over > 0 {
+				t.snap.Samples = t.snap.Samples[over:]
+			}
+			t.mu.Unlock()
+		}
+		t.seen[file] = true
+	}
+	for file := range t.seen {
+		if !listed[file] {
+			delete(t.seen, file)
+		}
+	}
+}
+
+func (t *tracker) probe(path string, duration float64) (Sample, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Sample{}, false
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return Sample{}, false
+	}
+	info, err := mpegts.Probe(f)
+	if err != nil || duration <= 0 {
+		return Sample{}, false
+	}
+
+	s := Sample{
+		Segment:       filepath.Base(path),
+		At:            fi.ModTime().UTC(),
+		Duration:      duration,
+		BitrateKbps:   int(float64(fi.Size()) * 8 / duration / 1000),
+		FPS:           math.Round(float64(info.VideoFrames)/duration*100) / 100,
+		DroppedFrames: info.DroppedFrames,
+	}
+	for _, k := range info.Keyframes {
+		if t.lastKey >= 0 && k > t.lastKey {
+			s.KeyframeInterval = max(s.KeyframeInterval, math.Round(float64(k-t.lastKey)/900)/100)
+		}
+		t.lastKey = k
+	}
+
+	t.mu.Lock()
+	t.snap.VideoCodec, t.snap.AudioCodec = info.VideoCodec, info.AudioCodec
+	if info.Width > 0 {
+		t.snap.Profile, t.snap.Width, t.snap.Height = info.Profile, info.Width, info.Height
+	}
+	if info.SampleRate > 0 {
+		t.snap.SampleRate, t.snap.Channels = info.SampleRate, info.Channels
+	}
+	t.mu.Unlock()
+	return s, true
+}
@@ -0,0 +1,129 @@
+package hls
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/vod"
+)
+
+// SourceRendition names the untranscoded rendition, <stream>.m3u8.
+const SourceRendition = "source"
+
+// Rendition is one variant of a live stream.
+type Rendition struct {
+	Name     string // "source", or the variant suffix such as "720p"
+	File     string // media playlist file name in the HLS directory
+	Height   int    // from a "<n>p" suffix; 0 if unknown
+	Peak     int    // bits per second, from the largest segment
+	Average  int    // bits per second over the playlist
+	Segments int
+}
+
+// variantFile matches <stream>_<variant>.m3u8.
+var variantFile = regexp.MustCompile(`^([A-Za-z0-9]+)_([A-Za-z0-9]+)\.m3u8$`)
+
+// heightSuffix matches variant names like 720p.
+var heightSuffix = regexp.MustCompile(`^([0-9]{3,4})p`)
+
+// Renditions finds the renditions of stream in dir: the source playlist
+// <stream>.m3u8 and transcoded ones <stream>_<variant>.m3u8, as written by
+// nginx-rtmp (hls_variant) or an ffmpeg ladder. Renditions without
+// segments yet are skipped, so an empty result means the stream is offline.
+// They are ordered best first.
+func Renditions(dir, stream string) ([]Rendition, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []Rendition
+	for _, e := range entries {
+		name := e.Name()
+		var r Rendition
+		switch {
+		case name == stream+".m3u8":
+			r = Rendition{Name: SourceRendition, File: name}
+		default:
+			m := variantFile.FindStringSubmatch(name)
+			if m == nil || m[1] != stream {
+				continue
+			}
+			r = Rendition{Name: m[2], File: name}
+			if h := heightSuffix.FindStringSubmatch(m[2]); h != nil {
+				r.Height, _ = strconv.Atoi(h[1])
+			}
+		}
+		if ok, err := measure(dir, &r); err != nil || !ok {
+			continue // mid-rotation or a master written by nginx itself
+		}
+		out = append(out, r)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if (out[i].Name == SourceRendition) != (out[j].Name == SourceRendition) {
+			return out[i].Name == SourceRendition
+		}
+		return out[i].Peak > out[j].Peak
+	})
+	return out, nil
+}
+
+// measure fills in the bitrates of a rendition from its media playlist
+// and segment sizes. It reports false for playlists without segments.
+func measure(dir string, r *Rendition) (bool, error) {
+	f, err := os.Open(filepath.Join(dir, r.File))
+	if err != nil {
+		return false, err
+	}
+	segs, err := vod.ParseMediaPlaylist(f)
+	f.Close()
+	if err != nil || len(segs) == 0 {
+		return false, err
+	}
+
+	var bits, seconds float64
+	for _, s := range segs {
+		fi, err := os.Stat(filepath.Join(dir, filepath.Base(s.URI)))
+		if err != nil || s.Duration <= 0 {
+			continue
+		}
+		b := float64(fi.Size()) * 8
+		bits += b
+		seconds += s.Duration
+		r.Peak = max(r.Peak, int(math.Ceil(b/s.Duration)))
+	}
+	if seconds == 0 {
+		return false, nil
+	}
+	r.Average = int(math.Ceil(bits / seconds))
+	r.Segments = len(segs)
+	return true, nil
+}
+
+// WriteMaster writes a master playlist over renditions. uri maps a
+// rendition to the URI its media playlist is requested with.
+func WriteMaster(w io.Writer, renditions []Rendition, uri func(Rendition) string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "#EXTM3U")
+	fmt.Fprintln(bw, "#EXT-X-VERSION:3")
+	fmt.Fprintln(bw, "#EXT-X-INDEPENDENT-SEGMENTS")
+	for _, r := range renditions {
+		attrs := []string{
+			fmt.Sprintf("BANDWIDTH=%d", max(r.Peak, 1)),
+			fmt.Sprintf("AVERAGE-BANDWIDTH=%d", max(r.Average, 1)),
+		}
+		if r.Height > 0 {
+			attrs = append(attrs, fmt.Sprintf("RESOLUTION=%dx%d", r.Height*16/9, r.Height))
+		}
+		fmt.Fprintf(bw, "#EXT-X-STREAM-INF:%s\n%s\n", strings.Join(attrs, ","), uri(r))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,227 @@
+package hls
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/flv"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/mpegts"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/vod"
+)
+
+// Segmenter remuxes one live stream's FLV audio and video into HLS, with
+// the layout nginx-rtmp writes: <name>.m3u8 listing <name>-<seq>.ts in dir.
+// Segments are cut on the first keyframe after fragment and the playlist
+// covers the last window of them.
+type Segmenter struct {
+	dir      string
+	name     string
+	fragment int64 // milliseconds
+	window   float64
+
+	avc *flv.AVCConfig
+	aac *flv.AACConfig
+
+	file  *os.File
+	buf   *bufio.Writer
+	mux   *mpegts.Muxer
+	seq   int   // sequence number of the open segment
+	start int64 // timestamp of its first frame, ms
+	last  int64 // latest timestamp written, ms
+
+	segs []liveSegment // closed segments still on disk, oldest first
+}
+
+type liveSegment struct {
+	seq      int
+	duration float64
+}
+
+// NewSegmenter starts the HLS output of stream name in dir. Sequence
+// numbers continue from a playlist left by an earlier broadcast, whose
+// segments are removed, so file names are never reused.
+func NewSegmenter(dir, name string, fragment, window time.Duration) (*Segmenter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &Segmenter{dir: dir, name: name, fragment: fragment.Milliseconds(), window: window.Seconds()}
+	if f, err := os.Open(s.playlistPath()); err == nil {
+		segs, _ := vod.ParseMediaPlaylist(f)
+		f.Close()
+		seqOf := regexp.MustCompile(`^` + regexp.QuoteMeta(name) + `-([0-9]+)\.ts$`)
+		for _, seg := range segs {
+			if m := seqOf.FindStringSubmatch(filepath.Base(seg.URI)); m != nil {
+				n, _ := strconv.Atoi(m[1])
+				s.seq = max(s.seq, n+1)
+				os.Remove(s.segmentPath(n))
+			}
+		}
+	}
+	return s, nil
+}
+
+func (s *Segmenter) playlistPath() string {
+	return filepath.Join(s.dir, s.name+".m3u8")
+}
+
+func (s *Segmenter) segmentPath(seq int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s-%d.ts", s.name, seq))
+}
+
+// WriteVideo adds an FLV video tag body with timestamp ts (ms).
+func (s *Segmenter) WriteVideo(ts uint32, body []byte) error {
+	tag, err := flv.ParseVideo(body)
+	if err != nil {
+		return err
+	}
+	if tag.SequenceHeader {
+		s.avc, err = flv.ParseAVCConfig(tag.Data)
+		return err
+	}
+	if s.avc == nil {
+		return nil // no decoder configuration yet
+	}
+	dts := int64(ts)
+	if tag.Keyframe && (s.file == nil || dts-s.start >= s.fragment) {
+		if err := s.cut(dts); err != nil {
+			return err
+		}
+	}
+	if s.file == nil {
+		return nil // segments must start with a keyframe
+	}
+	au, err := s.avc.AnnexB(tag.Data, tag.Keyframe)
+	if err != nil {
+		return err
+	}
+	s.last = max(s.last, dts)
+	return s.mux.WriteVideo((dts+int64(tag.CompositionTime))*90, dts*90, tag.Keyframe, au)
+}
+
+// WriteAudio adds an FLV audio tag body with timestamp ts (ms).
+func (s *Segmenter) WriteAudio(ts uint32, body []byte) error {
+	tag, err := flv.ParseAudio(body)
+	if err != nil {
+		return err
+	}
+	if tag.SequenceHeader {
+		s.aac, err = flv.ParseAACConfig(tag.Data)
+		return err
+	}
+	if s.aac == nil {
+		return nil
+	}
+	pts := int64(ts)
+	if s.avc == nil && (s.file == nil || pts-s.start >= s.fragment) {
+		// Audio-only streams are cut on any frame.
+		if err := s.cut(pts); err != nil {
+			return err
+		}
+	}
+	if s.file == nil {
+		return nil
+	}
+	s.last = max(s.last, pts)
+	return s.mux.WriteAudio(pts*90, s.aac.ADTS(tag.Data))
+}
+
+// Close finishes the open segment and marks the playlist ended.
+func (s *Segmenter) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	if err := s.closeSegment(s.last); err != nil {
+		return err
+	}
+	return s.writePlaylist(true)
+}
+
+// cut closes the open segment at ts, publishes it in the playlist and
+// opens the next one.
+func (s *Segmenter) cut(ts int64) error {
+	if s.file != nil {
+		if err := s.closeSegment(ts); err != nil {
+			return err
+		}
+		if err := s.writePlaylist(false); err != nil {
+			return err
+		}
+		s.seq++
+	}
+	f, err := os.Create(s.segmentPath(s.seq))
+	if err != nil {
+		return err
+	}
+	s.file, s.buf = f, bufio.NewWriterSize(f, 64<<10)
+	s.mux = mpegts.NewMuxer(s.buf, s.avc != nil, s.aac != nil)
+	s.start, s.last = ts, ts
+	return s.mux.WriteTables()
+}
+
+func (s *Segmenter) closeSegment(end int64) error {
+	err := s.buf.Flush()
+	if cerr := s.file.Close(); err == nil {
+		err = cerr
+	}
+	s.file, s.buf, s.mux = nil, nil, nil
+	if err != nil {
+		return err
+	}
+	d := float64(end-s.start) / 1000
+	if d <= 0 {
+		d = float64(s.fragment) / 1000
+	}
+	s.segs = append(s.segs, liveSegment{seq: s.seq, duration: d})
+	return nil
+}
+
+// writePlaylist atomically rewrites the playlist over the last window of
+// segments. Segments that left it stay on disk for another window, since
+// players and the archiver may still be fetching them.
+func (s *Segmenter) writePlaylist(ended bool) error {
+	first := len(s.segs) - 1
+	total := s.segs[first].duration
+	for first > 0 && total+s.segs[first-1].duration <= s.window {
+		first--
+		total += s.segs[first].duration
+	}
+	keep := first
+	for kept := 0.0; keep > 0 && kept < s.window; keep-- {
+		kept += s.segs[keep-1].duration
+	}
+	for _, seg := range s.segs[:keep] {
+		if err := os.Remove(s.segmentPath(seg.seq)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	listed := s.segs[first:]
+	s.segs = s.segs[keep:]
+
+	target := 0.0
+	for _, seg := range listed {
+		target = max(target, seg.duration)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-MEDIA-SEQUENCE:%d\n#EXT-X-TARGETDURATION:%d\n",
+		listed[0].seq, int(math.Ceil(target)))
+	for _, seg := range listed {
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\n%s-%d.ts\n", seg.duration, s.name, seg.seq)
+	}
+	if ended {
+		b.WriteString("#EXT-X-ENDLIST\n")
+	}
+
+	tmp := s.playlistPath() + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.playlistPath())
+}
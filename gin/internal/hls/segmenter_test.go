@@ -0,0 +1,151 @@
+package hls
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/vod"
+)
+
+// FLV tag bodies of a minimal H.264 and AAC stream.
+var (
+	avcSequenceHeader = []byte{
+		0x17, 0, 0, 0, 0,
+		1, 0x64, 0, 0x1f, 0xff, // version, profile, compatibility, level, 4-byte lengths
+		0xe1, 0, 4, 0x67, 0x64, 0, 0x1f, // one SPS
+		1, 0, 2, 0x68, 0xee, // one PPS
+	}
+	avcKeyframe    = []byte{0x17, 1, 0, 0, 0, 0, 0, 0, 2, 0x65, 0x88}
+	avcInterframe  = []byte{0x27, 1, 0, 0, 0, 0, 0, 0, 2, 0x41, 0x9a}
+	aacSequenceHdr = []byte{0xaf, 0, 0x12, 0x10} // AAC LC, 44.1 kHz, stereo
+	aacFrame       = []byte{0xaf, 1, 0x21, 0x10, 0x05}
+)
+
+// writeStream feeds seconds of 25 fps video with a keyframe every second,
+// and audio alongside.
+func writeStream(t *testing.T, s *Segmenter, seconds int) {
+	t.Helper()
+	for i := 0; i < seconds*25; i++ {
+		ts := uint32(i * 40)
+		frame := avcInterframe
+		if i%25 == 0 {
+			frame = avcKeyframe
+		}
+		if err := s.WriteVideo(ts, frame); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.WriteAudio(ts, aacFrame); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readPlaylist(t *testing.T, path string) (string, []vod.Segment) {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	segs, err := vod.ParseMediaPlaylist(strings.NewReader(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b), segs
+}
+
+func TestSegmenterPlaylist(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSegmenter(dir, "chan", time.Second, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteVideo(0, avcSequenceHeader); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteAudio(0, aacSequenceHdr); err != nil {
+		t.Fatal(err)
+	}
+	writeStream(t, s, 5)
+
+	live, segs := readPlaylist(t, filepath.Join(dir, "chan.m3u8"))
+	if len(segs) != 4 || strings.Contains(live, "#EXT-X-ENDLIST") {
+		t.Fatalf("live playlist:\n%s", live)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ended, segs := readPlaylist(t, filepath.Join(dir, "chan.m3u8"))
+	if !strings.Contains(ended, "#EXT-X-MEDIA-SEQUENCE:0\n") || !strings.HasSuffix(ended, "#EXT-X-ENDLIST\n") {
+		t.Errorf("ended playlist:\n%s", ended)
+	}
+	if len(segs) != 5 {
+		t.Fatalf("%d segments, want 5", len(segs))
+	}
+	for i, seg := range segs {
+		if want := fmt.Sprintf("chan-%d.ts", i); seg.URI != want {
+			t.Errorf("segment %d is %q, want %q", i, seg.URI, want)
+		}
+		want := 1.0
+		if i == len(segs)-1 {
+			want = 0.96 // ends with the last frame
+		}
+		if seg.Duration != want {
+			t.Errorf("segment %d lasts %v, want %v", i, seg.Duration, want)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, seg.URI))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(b) == 0 || len(b)%188 != 0 || b[0] != 0x47 {
+			t.Errorf("%s is not an MPEG-TS file (%d bytes)", seg.URI, len(b))
+		}
+	}
+
+	// The next broadcast continues the numbering and clears the old files.
+	next, err := NewSegmenter(dir, "chan", time.Second, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.seq != 5 {
+		t.Errorf("next broadcast starts at %d, want 5", next.seq)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "chan-0.ts")); !os.IsNotExist(err) {
+		t.Errorf("old segment left behind: %v", err)
+	}
+}
+
+func TestSegmenterWaitsForKeyframe(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSegmenter(dir, "chan", time.Second, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Frames before the decoder configuration, and interframes before the
+	// first keyframe, are dropped.
+	if err := s.WriteVideo(0, avcKeyframe); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteVideo(0, avcSequenceHeader); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteVideo(40, avcInterframe); err != nil {
+		t.Fatal(err)
+	}
+	if s.file != nil {
+		t.Fatal("segment opened without a keyframe")
+	}
+	if err := s.WriteVideo(80, avcKeyframe); err != nil {
+		t.Fatal(err)
+	}
+	if s.file == nil || s.start != 80 {
+		t.Fatalf("segment should start at the keyframe, got start %d", s.start)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
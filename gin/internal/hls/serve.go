@@ -0,0 +1,65 @@
+// Package hls serves HLS playlists and segments and builds master
+// playlists over the renditions of a live stream.
+package hls
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache policies for HLS responses.
+const (
+	// CacheLive is for playlists that change every segment.
+	CacheLive = "no-cache"
+	// CacheSegment is for segments, which never change once written. They
+	// are private because their URLs carry a viewer's playback token.
+	CacheSegment = "private, max-age=86400, immutable"
+)
+
+var contentTypes = map[string]string{
+	".m3u8": "application/vnd.apple.mpegurl",
+	".ts":   "video/mp2t",
+	".m4s":  "video/iso.segment",
+	".mp4":  "video/mp4",
+	".aac":  "audio/aac",
+	".vtt":  "text/vtt",
+}
+
+// ContentType returns the MIME type of an HLS file by extension.
+func ContentType(name string) string {
+	if t, ok := contentTypes[filepath.Ext(name)]; ok {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// ServeFile answers GET and HEAD for a file on disk, including byte
+// ranges and conditional requests. It returns os.ErrNotExist untouched so
+// callers can pick the right 404 (a segment may rotate out at any time).
+func ServeFile(w http.ResponseWriter, r *http.Request, path, cacheControl string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", ContentType(path))
+	w.Header().Set("Cache-Control", cacheControl)
+	http.ServeContent(w, r, filepath.Base(path), fi.ModTime(), f)
+	return nil
+}
+
+// ServeBytes answers GET and HEAD for a generated playlist, including byte
+// ranges. Generated playlists carry no modification time, so conditional
+// requests always get the full body.
+func ServeBytes(w http.ResponseWriter, r *http.Request, name string, body []byte, cacheControl string) {
+	w.Header().Set("Content-Type", ContentType(name))
+	w.Header().Set("Cache-Control", cacheControl)
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(body))
+}
@@ -0,0 +1,79 @@
+// Package ingest controls the RTMP ingest server from the API, so that a
+// broadcast can be cut off when its key is revoked, its owner deleted or
+// it is taken down. Both ingest setups are supported: nginx-rtmp through
+// its control module, and the built-in server of package rtmp.
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/rtmp"
+)
+
+// Controller disconnects publishers from the ingest server.
+type Controller interface {
+	// DropPublisher disconnects whoever publishes name to app and reports
+	// whether anyone was. The ingest server then ends the publish as
+	// usual, calling back publish_done.
+	DropPublisher(ctx context.Context, app, name string) (bool, error)
+}
+
+// NginxControl drives nginx-rtmp's control module (rtmp_control all)
+// served at URL, e.g. http://rtmp:8082/control.
+type NginxControl struct {
+	URL    string
+	Client *http.Client // http.DefaultClient with a timeout when nil
+}
+
+var defaultClient = &http.Client{Timeout: 5 * time.Second}
+
+// DropPublisher calls /drop/publisher, which answers with the number of
+// clients it disconnected.
+func (n *NginxControl) DropPublisher(ctx context.Context, app, name string) (bool, error) {
+	q := url.Values{"app": {app}, "name": {name}}
+	u := strings.TrimRight(n.URL, "/") + "/drop/publisher?" + q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return false, err
+	}
+	client := n.Client
+	if client == nil {
+		client = defaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("ingest: dropping %s/%s: %w", app, name, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64))
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		n, err := strconv.Atoi(strings.TrimSpace(string(body)))
+		if err != nil {
+			return false, fmt.Errorf("ingest: dropping %s/%s: unexpected response %q", app, name, body)
+		}
+		return n > 0, nil
+	case http.StatusNoContent, http.StatusNotFound:
+		// Older builds answer like this when nothing matched.
+		return false, nil
+	}
+	return false, fmt.Errorf("ingest: dropping %s/%s: %s", app, name, resp.Status)
+}
+
+// Local controls the built-in RTMP server, which serves a single app.
+type Local struct {
+	Server *rtmp.Server
+}
+
+// DropPublisher closes the publishing connection of name.
+func (l Local) DropPublisher(_ context.Context, _, name string) (bool, error) {
+	return l.Server.Drop(name), nil
+}
@@ -0,0 +1,199 @@
+package mpegts
+
+// scanH264 walks the NAL units of an Annex B access unit, filling in the
+// picture size and profile from the first SPS, and reports whether it
+// holds an IDR slice.
+func scanH264(au []byte, info *Info) (idr bool) {
+	for _, nalu := range splitAnnexB(au) {
+		if len(nalu) == 0 {
+			continue
+		}
+		switch nalu[0] & 0x1f {
+		case 5:
+			idr = true
+		case 7:
+			if info.Width == 0 {
+				parseSPS(nalu, info)
+			}
+		}
+	}
+	return idr
+}
+
+// splitAnnexB splits an Annex B byte stream at its start codes.
+func splitAnnexB(b []byte) [][]byte {
+	var nalus [][]byte
+	start := -1
+	for i := 0; i+2 < len(b); i++ {
+		if b[i] != 0 || b[i+1] != 0 || b[i+2] != 1 {
+			continue
+		}
+		if start >= 0 {
+			end := i
+			if end > start && b[end-1] == 0 {
+				end-- // four-byte start code
+			}
+			nalus = append(nalus, b[start:end])
+		}
+		start = i + 3
+		i += 2
+	}
+	if start >= 0 && start <= len(b) {
+		nalus = append(nalus, b[start:])
+	}
+	return nalus
+}
+
+var h264Profiles = map[int]string{
+	66: "Baseline", 77: "Main", 88: "Extended", 100: "High",
+	110: "High 10", 122: "High 4:2:2", 244: "High 4:4:4",
+}
+
+// parseSPS reads the profile and cropped picture size from an SPS NAL unit.
+func parseSPS(nalu []byte, info *Info) {
+	r := &bitReader{b: unescape(nalu[1:])}
+	profile := r.u(8)
+	r.u(16) // constraint flags, level
+	r.ue()  // seq_parameter_set_id
+
+	chroma := 1
+	switch profile {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		chroma = r.ue()
+		if chroma == 3 {
+			r.u(1) // separate_colour_plane_flag
+		}
+		r.ue() // bit_depth_luma_minus8
+		r.ue() // bit_depth_chroma_minus8
+		r.u(1) // qpprime_y_zero_transform_bypass_flag
+		if r.u(1) == 1 {
+			lists := 8
+			if chroma == 3 {
+				lists = 12
+			}
+			for i := 0; i < lists; i++ {
+				if r.u(1) == 1 {
+					size := 16
+					if i >= 6 {
+						size = 64
+					}
+					skipScalingList(r, size)
+				}
+			}
+		}
+	}
+
+	r.ue() // log2_max_frame_num_minus4
+	switch r.ue() {
+	case 0:
+		r.ue() // log2_max_pic_order_cnt_lsb_minus4
+	case 1:
+		r.u(1)
+		r.se()
+		r.se()
+		for n := r.ue(); n > 0 && !r.failed; n-- {
+			r.se()
+		}
+	}
+	r.ue() // max_num_ref_frames
+	r.u(1) // gaps_in_frame_num_value_allowed_flag
+	widthMBs := r.ue() + 1
+	heightMaps := r.ue() + 1
+	frameMBsOnly := r.u(1)
+	if frameMBsOnly == 0 {
+		r.u(1) // mb_adaptive_frame_field_flag
+	}
+	r.u(1) // direct_8x8_inference_flag
+	var cropL, cropR, cropT, cropB int
+	if r.u(1) == 1 {
+		cropL, cropR, cropT, cropB = r.ue(), r.ue(), r.ue(), r.ue()
+	}
+	if r.failed {
+		return
+	}
+
+	unitX, unitY := 1, 2-frameMBsOnly
+	switch chroma {
+	case 1:
+		unitX, unitY = 2, 2*(2-frameMBsOnly)
+	case 2:
+		unitX = 2
+	}
+	info.Width = widthMBs*16 - (cropL+cropR)*unitX
+	info.Height = (2-frameMBsOnly)*heightMaps*16 - (cropT+cropB)*unitY
+	info.Profile = h264Profiles[profile]
+}
+
+func skipScalingList(r *bitReader, size int) {
+	last, next := 8, 8
+	for j := 0; j < size && !r.failed; j++ {
+		if next != 0 {
+			next = (last + r.se() + 256) % 256
+		}
+		if next != 0 {
+			last = next
+		}
+	}
+}
+
+// unescape removes emulation prevention bytes (00 00 03 -> 00 00).
+func unescape(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	zeros := 0
+	for _, v := range b {
+		if zeros >= 2 && v == 3 {
+			zeros = 0
+			continue
+		}
+		if v == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// bitReader reads the bit fields of an RBSP. Reading past the end sets
+// failed and yields zeros.
+type bitReader struct {
+	b      []byte
+	pos    int
+	failed bool
+}
+
+func (r *bitReader) u(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		if r.pos >= len(r.b)*8 {
+			r.failed = true
+			return 0
+		}
+		v = v<<1 | int(r.b[r.pos/8]>>(7-r.pos%8)&1)
+		r.pos++
+	}
+	return v
+}
+
+// ue reads an unsigned Exp-Golomb code.
+func (r *bitReader) ue() int {
+	zeros := 0
+	for r.u(1) == 0 && !r.failed {
+		zeros++
+		if zeros > 31 {
+			r.failed = true
+			return 0
+		}
+	}
+	return 1<<zeros - 1 + r.u(zeros)
+}
+
+// se reads a signed Exp-Golomb code.
+func (r *bitReader) se() int {
+	k := r.ue()
+	if k%2 == 1 {
+		return (k + 1) / 2
+	}
+	return -k / 2
+}
@@ -0,0 +1,225 @@
+// Package mpegts writes MPEG transport streams carrying one H.264 video
+// and one AAC (ADTS) audio elementary stream, as HLS segments use.
+package mpegts
+
+import (
+	"io"
+)
+
+const (
+	packetSize = 188
+
+	pidPAT   = 0x0000
+	pidPMT   = 0x1000
+	pidVideo = 0x0100
+	pidAudio = 0x0101
+
+	streamTypeH264 = 0x1b
+	streamTypeAAC  = 0x0f
+
+	streamIDVideo = 0xe0
+	streamIDAudio = 0xc0
+)
+
+// Muxer writes PES packets into transport stream packets.
+type Muxer struct {
+	w        io.Writer
+	hasVideo bool
+	hasAudio bool
+	cc       map[uint16]byte
+	pkt      [packetSize]byte
+}
+
+// NewMuxer returns a Muxer writing to w. The streams present are fixed
+// when the program map is written.
+func NewMuxer(w io.Writer, hasVideo, hasAudio bool) *Muxer {
+	return &Muxer{w: w, hasVideo: hasVideo, hasAudio: hasAudio, cc: make(map[uint16]byte)}
+}
+
+// WriteTables writes the PAT and PMT. Each HLS segment starts with them so
+// it can be decoded on its own.
+func (m *Muxer) WriteTables() error {
+	pat := []byte{
+		0x00,       // table_id
+		0xb0, 0x0d, // section_syntax_indicator, length 13
+		0x00, 0x01, // transport_stream_id
+		0xc1,       // version 0, current
+		0x00, 0x00, // section numbers
+		0x00, 0x01, // program_number 1
+		0xe0 | pidPMT>>8, pidPMT & 0xff,
+	}
+	if err := m.writeSection(pidPAT, pat); err != nil {
+		return err
+	}
+
+	pcrPID := uint16(pidAudio)
+	if m.hasVideo {
+		pcrPID = pidVideo
+	}
+	pmt := []byte{
+		0x02,       // table_id
+		0xb0, 0x00, // length filled in below
+		0x00, 0x01, // program_number
+		0xc1,
+		0x00, 0x00,
+		0xe0 | byte(pcrPID>>8), byte(pcrPID),
+		0xf0, 0x00, // program_info_length
+	}
+	if m.hasVideo {
+		pmt = append(pmt, streamTypeH264, 0xe0|pidVideo>>8, pidVideo&0xff, 0xf0, 0x00)
+	}
+	if m.hasAudio {
+		pmt = append(pmt, streamTypeAAC, 0xe0|pidAudio>>8, pidAudio&0xff, 0xf0, 0x00)
+	}
+	pmt[2] = byte(len(pmt) - 3 + 4) // after the length field, including the CRC
+	return m.writeSection(pidPMT, pmt)
+}
+
+func (m *Muxer) writeSection(pid uint16, section []byte) error {
+	section = appendCRC(section)
+	p := m.pkt[:]
+	m.header(p, pid, true)
+	p[4] = 0 // pointer_field
+	n := copy(p[5:], section)
+	for i := 5 + n; i < packetSize; i++ {
+		p[i] = 0xff
+	}
+	_, err := m.w.Write(p)
+	return err
+}
+
+// WriteVideo writes one Annex B access unit. Timestamps are in 90 kHz units.
+func (m *Muxer) WriteVideo(pts, dts int64, keyframe bool, au []byte) error {
+	hdr := pesHeader(streamIDVideo, pts, dts, 0) // unbounded length for video
+	return m.writePES(pidVideo, hdr, au, keyframe, dts)
+}
+
+// WriteAudio writes one or more ADTS frames. pts is in 90 kHz units.
+func (m *Muxer) WriteAudio(pts int64, frames []byte) error {
+	hdr := pesHeader(streamIDAudio, pts, pts, len(frames))
+	pcr := int64(-1)
+	if !m.hasVideo {
+		pcr = pts
+	}
+	return m.writePES(pidAudio, hdr, frames, false, pcr)
+}
+
+// pesHeader builds a PES header. DTS is only written when it differs.
+func pesHeader(streamID byte, pts, dts int64, payloadLen int) []byte {
+	hdr := []byte{0x00, 0x00, 0x01, streamID, 0, 0, 0x80, 0x80, 5}
+	if dts != pts {
+		hdr[7], hdr[8] = 0xc0, 10
+		hdr = appendTimestamp(hdr, 0x3, pts)
+		hdr = appendTimestamp(hdr, 0x1, dts)
+	} else {
+		hdr = appendTimestamp(hdr, 0x2, pts)
+	}
+	if payloadLen > 0 {
+		if l := len(hdr) - 6 + payloadLen; l <= 0xffff {
+			hdr[4], hdr[5] = byte(l>>8), byte(l)
+		}
+	}
+	return hdr
+}
+
+func appendTimestamp(b []byte, marker byte, ts int64) []byte {
+	ts &= 0x1ffffffff
+	return append(b,
+		marker<<4|byte(ts>>29)&0x0e|1,
+		byte(ts>>22),
+		byte(ts>>14)&0xfe|1,
+		byte(ts>>7),
+		byte(ts<<1)&0xfe|1,
+	)
+}
+
+// writePES splits a PES packet over transport packets. The first one
+// carries the random access flag for keyframes and, if pcr >= 0, a PCR.
+func (m *Muxer) writePES(pid uint16, hdr, payload []byte, keyframe bool, pcr int64) error {
+	data := append(hdr, payload...)
+	first := true
+	for len(data) > 0 {
+		p := m.pkt[:]
+		m.header(p, pid, first)
+
+		var af []byte
+		if first && (keyframe || pcr >= 0) {
+			flags := byte(0)
+			if keyframe {
+				flags |= 0x40
+			}
+			af = []byte{0, flags}
+			if pcr >= 0 {
+				af[1] |= 0x10
+				af = append(af, byte(pcr>>25), byte(pcr>>17), byte(pcr>>9), byte(pcr>>1), byte(pcr<<7)|0x7e, 0x00)
+			}
+		}
+
+		room := packetSize - 4 - len(af)
+		if len(data) < room {
+			// Stuff the adaptation field so the payload ends the packet.
+			stuff := room - len(data)
+			if af == nil {
+				if stuff == 1 {
+					af = []byte{0}
+				} else {
+					af = []byte{0, 0}
+					stuff -= 2
+					af = append(af, fill(stuff)...)
+				}
+			} else {
+				af = append(af, fill(stuff)...)
+			}
+			room = len(data)
+		}
+		if af != nil {
+			p[3] |= 0x20
+			af[0] = byte(len(af) - 1)
+			copy(p[4:], af)
+		}
+		n := copy(p[4+len(af):], data[:room])
+		data = data[n:]
+		first = false
+		if _, err := m.w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fill(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = 0xff
+	}
+	return b
+}
+
+// header writes the 4-byte packet header and advances the PID's
+// continuity counter.
+func (m *Muxer) header(p []byte, pid uint16, start bool) {
+	p[0] = 0x47
+	p[1] = byte(pid>>8) & 0x1f
+	if start {
+		p[1] |= 0x40
+	}
+	p[2] = byte(pid)
+	p[3] = 0x10 | m.cc[pid]&0x0f // payload only; adaptation added by the caller
+	m.cc[pid] = (m.cc[pid] + 1) & 0x0f
+}
+
+// appendCRC appends the MPEG-2 CRC-32 of b.
+func appendCRC(b []byte) []byte {
+	crc := uint32(0xffffffff)
+	for _, v := range b {
+		crc ^= uint32(v) << 24
+		for i := 0; i < 8; i++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return append(b, byte(crc>>24), byte(crc>>16), byte(crc>>8), byte(crc))
+}
@@ -0,0 +1,219 @@
+package mpegts
+
+import (
+	"errors"
+	"io"
+	"sort"
+)
+
+// ErrNotTS is returned for data that is not a transport stream.
+var ErrNotTS = errors.New("mpegts: not a transport stream")
+
+// Info describes the elementary streams of a transport stream segment.
+type Info struct {
+	VideoCodec string // "h264", "hevc" or "" without video
+	Profile    string
+	Width      int
+	Height     int
+	AudioCodec string // "aac", "mp3" or "" without audio
+	SampleRate int
+	Channels   int
+
+	// VideoFrames counts video access units.
+	VideoFrames int
+	// FirstDTS and LastDTS bound the video timestamps, in 90 kHz units.
+	FirstDTS int64
+	LastDTS  int64
+	// Keyframes are the DTS of the access units with an IDR slice.
+	Keyframes []int64
+	// DroppedFrames estimates frames missing from the video timeline,
+	// from gaps well above the typical frame interval.
+	DroppedFrames int
+}
+
+type pesState struct {
+	buf []byte
+}
+
+// Probe reads a transport stream and reports what it carries.
+func Probe(r io.Reader) (*Info, error) {
+	info := &Info{}
+	var pmtPID, videoPID, audioPID int = -1, -1, -1
+	var video pesState
+	var dts []int64
+	audioDone := false
+
+	flushVideo := func() {
+		if len(video.buf) == 0 {
+			return
+		}
+		if d, payload, ok := parsePES(video.buf); ok {
+			info.VideoFrames++
+			dts = append(dts, d)
+			if info.VideoCodec == "h264" && scanH264(payload, info) {
+				info.Keyframes = append(info.Keyframes, d)
+			}
+		}
+		video.buf = video.buf[:0]
+	}
+
+	pkt := make([]byte, packetSize)
+	for {
+		if _, err := io.ReadFull(r, pkt); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, err
+		}
+		if pkt[0] != 0x47 {
+			return nil, ErrNotTS
+		}
+		pusi := pkt[1]&0x40 != 0
+		pid := int(pkt[1]&0x1f)<<8 | int(pkt[2])
+		payload := pkt[4:]
+		if pkt[3]&0x20 != 0 {
+			if int(pkt[4])+1 > len(payload) {
+				continue
+			}
+			payload = payload[1+int(pkt[4]):]
+		}
+		if pkt[3]&0x10 == 0 {
+			continue
+		}
+
+		switch {
+		case pid == pidPAT && pusi && pmtPID < 0:
+			if sec := section(payload); len(sec) >= 12 {
+				pmtPID = int(sec[10]&0x1f)<<8 | int(sec[11])
+			}
+		case pid == pmtPID && pusi && videoPID < 0 && audioPID < 0:
+			videoPID, audioPID = parsePMT(section(payload), info)
+		case pid == videoPID:
+			if pusi {
+				flushVideo()
+			}
+			video.buf = append(video.buf, payload...)
+		case pid == audioPID && pusi && !audioDone:
+			if _, es, ok := parsePES(payload); ok && info.AudioCodec == "aac" && len(es) >= 7 && es[0] == 0xff && es[1]&0xf0 == 0xf0 {
+				rate := int(es[2]>>2) & 0x0f
+				if rate < len(sampleRates) {
+					info.SampleRate = sampleRates[rate]
+				}
+				info.Channels = int(es[2]&0x01)<<2 | int(es[3]>>6)
+				audioDone = true
+			}
+		}
+	}
+	flushVideo()
+	if pmtPID < 0 {
+		return nil, ErrNotTS
+	}
+
+	if len(dts) > 0 {
+		sort.Slice(dts, func(i, j int) bool { return dts[i] < dts[j] })
+		info.FirstDTS, info.LastDTS = dts[0], dts[len(dts)-1]
+		info.DroppedFrames = droppedFrames(dts)
+	}
+	return info, nil
+}
+
+var sampleRates = [...]int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+// section returns the PSI section that starts in payload.
+func section(payload []byte) []byte {
+	if len(payload) < 1 || int(payload[0])+1 > len(payload) {
+		return nil
+	}
+	sec := payload[1+int(payload[0]):]
+	if len(sec) < 3 {
+		return nil
+	}
+	n := 3 + (int(sec[1]&0x0f)<<8 | int(sec[2]))
+	if n > len(sec) {
+		return nil
+	}
+	return sec[:n]
+}
+
+func parsePMT(sec []byte, info *Info) (videoPID, audioPID int) {
+	videoPID, audioPID = -1, -1
+	if len(sec) < 16 {
+		return
+	}
+	infoLen := int(sec[10]&0x0f)<<8 | int(sec[11])
+	if 12+infoLen > len(sec)-4 {
+		return
+	}
+	es := sec[12+infoLen : len(sec)-4] // without the CRC
+	for len(es) >= 5 {
+		typ := es[0]
+		pid := int(es[1]&0x1f)<<8 | int(es[2])
+		n := int(es[3]&0x0f)<<8 | int(es[4])
+		if 5+n > len(es) {
+			return
+		}
+		es = es[5+n:]
+		switch typ {
+		case streamTypeH264:
+			videoPID, info.VideoCodec = pid, "h264"
+		case 0x24:
+			videoPID, info.VideoCodec = pid, "hevc"
+		case streamTypeAAC:
+			audioPID, info.AudioCodec = pid, "aac"
+		case 0x03, 0x04:
+			audioPID, info.AudioCodec = pid, "mp3"
+		}
+	}
+	return
+}
+
+// parsePES returns the DTS (or PTS) of a PES packet and its payload.
+func parsePES(b []byte) (int64, []byte, bool) {
+	if len(b) < 9 || b[0] != 0 || b[1] != 0 || b[2] != 1 {
+		return 0, nil, false
+	}
+	hlen := int(b[8])
+	if len(b) < 9+hlen {
+		return 0, nil, false
+	}
+	flags := b[7] >> 6
+	var ts int64
+	switch {
+	case flags == 3 && hlen >= 10:
+		ts = readTimestamp(b[14:19])
+	case flags >= 2 && hlen >= 5:
+		ts = readTimestamp(b[9:14])
+	default:
+		return 0, nil, false
+	}
+	return ts, b[9+hlen:], true
+}
+
+func readTimestamp(b []byte) int64 {
+	return int64(b[0]>>1&0x07)<<30 | int64(b[1])<<22 | int64(b[2]>>1)<<15 | int64(b[3])<<7 | int64(b[4]>>1)
+}
+
+// droppedFrames counts frames that would fill gaps of more than 1.5
+// typical (median) frame intervals.
+func droppedFrames(dts []int64) int {
+	if len(dts) < 3 {
+		return 0
+	}
+	gaps := make([]int64, 0, len(dts)-1)
+	for i := 1; i < len(dts); i++ {
+		gaps = append(gaps, dts[i]-dts[i-1])
+	}
+	sorted := append([]int64(nil), gaps...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	typical := sorted[len(sorted)/2]
+	if typical <= 0 {
+		return 0
+	}
+	dropped := 0
+	for _, g := range gaps {
+		if g*2 > typical*3 {
+			dropped += int((g+typical/2)/typical) - 1
+		}
+	}
+	return dropped
+}
@@ -0,0 +1,64 @@
+// Package notify delivers notifications to connected clients in real time.
+package notify
+
+import (
+	"sync"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// subscriberBuffer is how many notifications may queue for a slow client
+// before new ones are dropped for it. Dropped notifications are still in
+// the inbox; the client just misses the live push.
+const subscriberBuffer = 32
+
+// Hub routes notifications to the open SSE connections of their recipient.
+// A user may have several connections (tabs, devices).
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[primitive.ObjectID]map[chan models.Notification]struct{}
+}
+
+// NewHub returns an empty hub.
+func NewHub() *Hub {
+	return &Hub{subs: make(map[primitive.ObjectID]map[chan models.Notification]struct{})}
+}
+
+// Subscribe registers a connection for userID. The returned cancel func
+// must be called when the connection closes; it closes the channel.
+func (h *Hub) Subscribe(userID primitive.ObjectID) (<-chan models.Notification, func()) {
+	ch := make(chan models.Notification, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[chan models.Notification]struct{})
+	}
+	h.subs[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs[userID], ch)
+			if len(h.subs[userID]) == 0 {
+				delete(h.subs, userID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish pushes n to every connection of its recipient without blocking.
+func (h *Hub) Publish(n models.Notification) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subs[n.UserID] {
+		select {
+		case ch <- n:
+		default:
+		}
+	}
+}
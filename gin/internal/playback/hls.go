@@ -0,0 +1,72 @@
+package playback
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// liveFile matches what the RTMP server writes for a stream:
+	// <name>.m3u8 and <name>-<seq>.ts, or <name>_<variant>.m3u8 and
+	// <name>_<variant>-<seq>.ts for transcoded renditions.
+	liveFile = regexp.MustCompile(`^([A-Za-z0-9]+)(?:_[A-Za-z0-9]+)?(?:\.m3u8|-[0-9]+\.ts)$`)
+	// uriAttr matches URI="..." attributes in playlist tags.
+	uriAttr = regexp.MustCompile(`URI="([^"]*)"`)
+)
+
+// StreamOfFile returns the stream a live HLS file belongs to, or "" if the
+// name is not one the RTMP server produces.
+func StreamOfFile(name string) string {
+	m := liveFile.FindStringSubmatch(name)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// IsPlaylist reports whether name is a playlist rather than a segment.
+func IsPlaylist(name string) bool {
+	return strings.HasSuffix(name, ".m3u8")
+}
+
+// RewritePlaylist copies an HLS playlist, adding token to the query of
+// every URI it references so that players send it with each request.
+func RewritePlaylist(w io.Writer, r io.Reader, token string) error {
+	bw := bufio.NewWriter(w)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "#"):
+			line = uriAttr.ReplaceAllStringFunc(line, func(attr string) string {
+				uri := attr[len(`URI="`) : len(attr)-1]
+				return `URI="` + withToken(uri, token) + `"`
+			})
+		default:
+			line = withToken(trimmed, token)
+		}
+		bw.WriteString(line)
+		bw.WriteByte('\n')
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// withToken sets the token query parameter on uri.
+func withToken(uri, token string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,86 @@
+package playback
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRewritePlaylist(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "media playlist",
+			in: "#EXTM3U\n#EXT-X-TARGETDURATION:3\n#EXT-X-MEDIA-SEQUENCE:7\n" +
+				"#EXTINF:3.000,\nalice-7.ts\n#EXTINF:3.000,\nalice-8.ts\n",
+			want: "#EXTM3U\n#EXT-X-TARGETDURATION:3\n#EXT-X-MEDIA-SEQUENCE:7\n" +
+				"#EXTINF:3.000,\nalice-7.ts?token=T\n#EXTINF:3.000,\nalice-8.ts?token=T\n",
+		},
+		{
+			name: "master playlist",
+			in:   "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=800000\nalice_low.m3u8\n",
+			want: "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=800000\nalice_low.m3u8?token=T\n",
+		},
+		{
+			name: "URI attributes",
+			in:   "#EXT-X-MAP:URI=\"init.mp4\"\n#EXT-X-KEY:METHOD=AES-128,URI=\"key.bin\",IV=0x1\n",
+			want: "#EXT-X-MAP:URI=\"init.mp4?token=T\"\n#EXT-X-KEY:METHOD=AES-128,URI=\"key.bin?token=T\",IV=0x1\n",
+		},
+		{
+			name: "existing query and a stale token",
+			in:   "seg.ts?_HLS_msn=3\nother.ts?token=old\n",
+			want: "seg.ts?_HLS_msn=3&token=T\nother.ts?token=T\n",
+		},
+		{
+			name: "absolute URI",
+			in:   "https://cdn.example.com/live/alice-1.ts\n",
+			want: "https://cdn.example.com/live/alice-1.ts?token=T\n",
+		},
+		{
+			name: "blank lines and padding kept apart from URIs",
+			in:   "#EXTM3U\n\n  alice-1.ts  \r\n#EXT-X-ENDLIST",
+			want: "#EXTM3U\n\nalice-1.ts?token=T\n#EXT-X-ENDLIST\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := RewritePlaylist(&out, strings.NewReader(tt.in), "T"); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("got\n%q\nwant\n%q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRewritePlaylistEscapesToken(t *testing.T) {
+	var out strings.Builder
+	if err := RewritePlaylist(&out, strings.NewReader("a.ts\n"), "a+b/c=="); err != nil {
+		t.Fatal(err)
+	}
+	if want := "a.ts?token=a%2Bb%2Fc%3D%3D\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestStreamOfFile(t *testing.T) {
+	for name, want := range map[string]string{
+		"alice.m3u8":      "alice",
+		"alice-12.ts":     "alice",
+		"alice_720p.m3u8": "alice",
+		"alice_720p-3.ts": "alice",
+		"../alice.m3u8":   "",
+		"alice.mp4":       "",
+		"alice-x.ts":      "",
+		"alice.m3u8?x=1":  "",
+		"":                "",
+	} {
+		if got := StreamOfFile(name); got != want {
+			t.Errorf("StreamOfFile(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
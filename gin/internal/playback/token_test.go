@@ -0,0 +1,57 @@
+package playback
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignerVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	signer := NewSigner([]byte("a-playback-secret"))
+	claims := Claims{Stream: "alice", Resource: ResourceLive, Viewer: "64b7f0c2e4b0a1a2b3c4d5e6", Expires: now.Add(time.Minute).Unix()}
+	token := signer.Sign(claims)
+	body, sig, _ := strings.Cut(token, ".")
+
+	// reSign forges a token with changed claims but the original signature.
+	reSign := func(change func(*Claims)) string {
+		c := claims
+		change(&c)
+		forged, _, _ := strings.Cut(signer.Sign(c), ".")
+		return forged + "." + sig
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		stream string
+		now    time.Time
+		want   error
+	}{
+		{name: "valid", token: token, stream: "alice", now: now},
+		{name: "last second", token: token, stream: "alice", now: now.Add(time.Minute - time.Second)},
+		{name: "expired", token: token, stream: "alice", now: now.Add(time.Minute), want: ErrTokenExpired},
+		{name: "wrong stream", token: token, stream: "bob", now: now, want: ErrWrongStream},
+		{name: "other key", token: NewSigner([]byte("another-secret")).Sign(claims), stream: "alice", now: now, want: ErrInvalidToken},
+		{name: "tampered stream", token: reSign(func(c *Claims) { c.Stream = "bob" }), stream: "bob", now: now, want: ErrInvalidToken},
+		{name: "tampered resource", token: reSign(func(c *Claims) { c.Resource = VideoResource("1") }), stream: "alice", now: now, want: ErrInvalidToken},
+		{name: "tampered expiry", token: reSign(func(c *Claims) { c.Expires += 3600 }), stream: "alice", now: now, want: ErrInvalidToken},
+		{name: "tampered signature", token: body + "." + base64.RawURLEncoding.EncodeToString([]byte("forged")), stream: "alice", now: now, want: ErrInvalidToken},
+		{name: "no signature", token: body, stream: "alice", now: now, want: ErrInvalidToken},
+		{name: "empty", token: "", stream: "alice", now: now, want: ErrInvalidToken},
+		{name: "no stream", token: signer.Sign(Claims{Expires: claims.Expires}), stream: "", now: now, want: ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := signer.Verify(tt.token, tt.stream, tt.now)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if err == nil && got != claims {
+				t.Errorf("got claims %+v, want %+v", got, claims)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+// Package relay forwards live RTMP streams to external destinations
+// (simulcasting) by running one ffmpeg per destination that pulls the
+// stream back from the ingest server and pushes it out unchanged.
+//
+// To try it end to end without a third-party account, run a sink on a
+// host with a public address (destinations on private networks are
+// refused)
+//
+//	ffmpeg -listen 1 -i rtmp://0.0.0.0:1940/live/test -c copy -f null -
+//
+// add rtmp://<that host>:1940/live with key "test" as a destination, and
+// go live: the destination turns "live" and the sink prints frame counts.
+// Stopping the sink shows up as "retrying" with ffmpeg's error. The tests
+// run the Manager against the built-in RTMP server instead.
+package relay
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// State is where a relay is in its lifecycle.
+type State string
+
+const (
+	StateConnecting State = "connecting"
+	StateLive       State = "live"
+	StateRetrying   State = "retrying"
+	StateFailed     State = "failed"
+)
+
+const (
+	// maxFailures is how many quick failures in a row give up on a
+	// destination until the next broadcast.
+	maxFailures = 5
+	// healthyRun is how long ffmpeg must stay up for its exit to count as
+	// a dropped connection rather than a failure to connect.
+	healthyRun = 30 * time.Second
+	minBackoff = 2 * time.Second
+	maxBackoff = 30 * time.Second
+)
+
+// Target is one destination to forward a stream to.
+type Target struct {
+	ID  string
+	URL string // rtmp:// or rtmps:// application URL
+	Key string // stream key at the destination, appended to URL
+}
+
+// publishURL is where ffmpeg pushes the stream.
+func (t Target) publishURL() string {
+	if t.Key == "" {
+		return t.URL
+	}
+	return strings.TrimRight(t.URL, "/") + "/" + t.Key
+}
+
+// redact removes the key from text, such as an ffmpeg error echoing the URL.
+func (t Target) redact(text string) string {
+	if t.Key == "" {
+		return text
+	}
+	return strings.ReplaceAll(text, t.Key, "****")
+}
+
+// Status is the current state of one relay.
+type Status struct {
+	State     State      `json:"state"`
+	Error     string     `json:"error,omitempty"`
+	LiveSince *time.Time `json:"live_since,omitempty"`
+	Restarts  int        `json:"restarts"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Manager runs the relays of every live stream.
+type Manager struct {
+	source string
+	ffmpeg string
+
+	mu     sync.Mutex
+	relays map[string]map[string]*relay // stream -> target ID
+}
+
+type relay struct {
+	target Target
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu     sync.Mutex
+	status Status
+}
+
+// NewManager returns a Manager that reads streams from source/<stream>
+// (e.g. rtmp://rtmp:1935/live) with the ffmpeg binary at ffmpeg.
+func NewManager(source, ffmpeg string) *Manager {
+	return &Manager{
+		source: strings.TrimRight(source, "/"),
+		ffmpeg: ffmpeg,
+		relays: make(map[string]map[string]*relay),
+	}
+}
+
+// Start begins forwarding stream to t. A relay already running for the
+// same target is kept, or restarted if its URL or key changed.
+func (m *Manager) Start(stream string, t Target) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	byID := m.relays[stream]
+	if byID == nil {
+		byID = make(map[string]*relay)
+		m.relays[stream] = byID
+	}
+	if r, ok := byID[t.ID]; ok {
+		if r.target == t && r.current().State != StateFailed {
+			return
+		}
+		r.stop()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &relay{
+		target: t,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		status: Status{State: StateConnecting, UpdatedAt: time.Now().UTC()},
+	}
+	byID[t.ID] = r
+	go r.run(ctx, m.ffmpeg, m.source+"/"+stream)
+}
+
+// Stop stops forwarding stream to the target with the given ID.
+func (m *Manager) Stop(stream, id string) {
+	m.mu.Lock()
+	r := m.relays[stream][id]
+	delete(m.relays[stream], id)
+	if len(m.relays[stream]) == 0 {
+		delete(m.relays, stream)
+	}
+	m.mu.Unlock()
+	if r != nil {
+		r.stop()
+	}
+}
+
+// StopAll stops every relay of stream, e.g. when its broadcast ends.
+func (m *Manager) StopAll(stream string) {
+	m.mu.Lock()
+	byID := m.relays[stream]
+	delete(m.relays, stream)
+	m.mu.Unlock()
+	for _, r := range byID {
+		r.stop()
+	}
+}
+
+// Status returns the status of each relay of stream by target ID.
+func (m *Manager) Status(stream string) map[string]Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]Status, len(m.relays[stream]))
+	for id, r := range m.relays[stream] {
+		out[id] = r.current()
+	}
+	return out
+}
+
+func (r *relay) stop() {
+	r.cancel()
+	<-r.done
+}
+
+func (r *relay) current() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+func (r *relay) update(f func(*Status)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f(&r.status)
+	r.status.UpdatedAt = time.Now().UTC()
+}
+
+// run keeps ffmpeg running until ctx is cancelled, backing off after
+// failures and giving up after maxFailures quick ones in a row.
+func (r *relay) run(ctx context.Context, ffmpeg, source string) {
+	defer close(r.done)
+	failures := 0
+	for {
+		started := time.Now()
+		err := r.forward(ctx, ffmpeg, source)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) >= healthyRun {
+			failures = 0
+		}
+		failures++
+
+		msg := "connection closed"
+		if err != nil {
+			msg = r.target.redact(err.Error())
+		}
+		if failures >= maxFailures {
+			r.update(func(s *Status) { s.State, s.Error, s.LiveSince = StateFailed, msg, nil })
+			return
+		}
+		r.update(func(s *Status) {
+			s.State, s.Error, s.LiveSince = StateRetrying, msg, nil
+			s.Restarts++
+		})
+
+		backoff := min(minBackoff<<(failures-1), maxBackoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		r.update(func(s *Status) { s.State = StateConnecting })
+	}
+}
+
+// forward runs one ffmpeg until it exits. It marks the relay live once
+// ffmpeg reports progress and returns ffmpeg's last error line.
+func (r *relay) forward(ctx context.Context, ffmpeg, source string) error {
+	cmd := exec.CommandContext(ctx, ffmpeg,
+		"-hide_banner", "-nostdin", "-loglevel", "error",
+		"-rw_timeout", "10000000", // µs; notice a stalled source or sink
+		"-i", source,
+		"-c", "copy", "-f", "flv",
+		"-progress", "pipe:1", "-nostats",
+		r.target.publishURL(),
+	)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	var lastErr string
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		r.watchProgress(stdout)
+	}()
+	go func() {
+		defer wg.Done()
+		sc := bufio.NewScanner(stderr)
+		for sc.Scan() {
+			if line := strings.TrimSpace(sc.Text()); line != "" {
+				lastErr = line
+			}
+		}
+	}()
+	wg.Wait()
+
+	err = cmd.Wait()
+	if lastErr != "" {
+		return exitError(lastErr)
+	}
+	return err
+}
+
+// watchProgress reads ffmpeg's -progress output and marks the relay live
+// on the first report.
+func (r *relay) watchProgress(out io.Reader) {
+	sc := bufio.NewScanner(out)
+	live := false
+	for sc.Scan() {
+		if live || !strings.HasPrefix(sc.Text(), "progress=") {
+			continue
+		}
+		live = true
+		now := time.Now().UTC()
+		r.update(func(s *Status) { s.State, s.Error, s.LiveSince = StateLive, "", &now })
+	}
+}
+
+// exitError is the error line ffmpeg printed before exiting.
+type exitError string
+
+func (e exitError) Error() string { return string(e) }
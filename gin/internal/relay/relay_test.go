@@ -0,0 +1,214 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/rtmp"
+)
+
+// With RELAY_TEST_FFMPEG set the test binary stands in for ffmpeg: it
+// connects to the URL it should publish to, reports progress, and exits
+// with an error once the destination hangs up.
+func TestMain(m *testing.M) {
+	if os.Getenv("RELAY_TEST_FFMPEG") != "" {
+		os.Exit(fakeFFmpeg(os.Args[len(os.Args)-1]))
+	}
+	os.Exit(m.Run())
+}
+
+func fakeFFmpeg(publish string) int {
+	u, err := url.Parse(publish)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	conn, err := net.Dial("tcp", u.Host)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: Connection refused\n", publish)
+		return 1
+	}
+	fmt.Println("progress=continue")
+	_, _ = io.Copy(io.Discard, conn)
+	fmt.Fprintf(os.Stderr, "%s: Broken pipe\n", publish)
+	return 1
+}
+
+// waitState waits for the relay of stream to target id to reach want.
+func waitState(t *testing.T, m *Manager, stream, id string, want State) Status {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		st := m.Status(stream)[id]
+		if st.State == want {
+			return st
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("relay is %s (%s), want %s", st.State, st.Error, want)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestManagerStates(t *testing.T) {
+	t.Setenv("RELAY_TEST_FFMPEG", "1")
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	m := NewManager("rtmp://127.0.0.1:1/live", os.Args[0])
+	target := Target{ID: "dest", URL: "rtmp://" + l.Addr().String() + "/live", Key: "secret-key"}
+	m.Start("alice", target)
+	defer m.StopAll("alice")
+	if st := m.Status("alice")["dest"]; st.State != StateConnecting {
+		t.Fatalf("new relay is %s, want connecting", st.State)
+	}
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := waitState(t, m, "alice", "dest", StateLive)
+	if live.LiveSince == nil {
+		t.Error("live relay without live_since")
+	}
+
+	// The destination goes away.
+	l.Close()
+	conn.Close()
+	st := waitState(t, m, "alice", "dest", StateRetrying)
+	if st.Restarts != 1 || st.LiveSince != nil {
+		t.Errorf("after one drop: %+v", st)
+	}
+	if !strings.Contains(st.Error, "Broken pipe") || strings.Contains(st.Error, target.Key) {
+		t.Errorf("error %q should be ffmpeg's, with the key redacted", st.Error)
+	}
+
+	m.StopAll("alice")
+	if got := m.Status("alice"); len(got) != 0 {
+		t.Errorf("stopped relays still listed: %v", got)
+	}
+}
+
+// acceptAll lets every key publish under its own name.
+type acceptAll struct{}
+
+func (acceptAll) Publish(_ context.Context, _, key string) (string, error) { return key, nil }
+func (acceptAll) PublishDone(string)                                     {}
+
+var errSinkGone = errors.New("sink gone")
+
+// countingSink counts the video it receives until it is told to fail.
+type countingSink struct {
+	frames atomic.Int64
+	gone   atomic.Bool
+}
+
+func (s *countingSink) WriteAudio(uint32, []byte) error {
+	if s.gone.Load() {
+		return errSinkGone
+	}
+	return nil
+}
+
+func (s *countingSink) WriteVideo(uint32, []byte) error {
+	if s.gone.Load() {
+		return errSinkGone
+	}
+	s.frames.Add(1)
+	return nil
+}
+
+func (s *countingSink) Close() error { return nil }
+
+func serveRTMP(t *testing.T, srv *rtmp.Server) net.Listener {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+// TestManagerAgainstRTMPServer relays a test pattern with the real ffmpeg
+// from one built-in RTMP server, standing in for the ingest, to another,
+// standing in for the destination.
+func TestManagerAgainstRTMPServer(t *testing.T) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		t.Skip("ffmpeg is not installed")
+	}
+	if testing.Short() {
+		t.Skip("runs ffmpeg for several seconds")
+	}
+
+	origin := &rtmp.Server{
+		Handler:   acceptAll{},
+		AllowPlay: func(net.Addr, string, string) bool { return true },
+	}
+	originAddr := serveRTMP(t, origin).Addr().String()
+	sink := &countingSink{}
+	dest := &rtmp.Server{
+		Handler: acceptAll{},
+		NewSink: func(string) (rtmp.Sink, error) { return sink, nil },
+	}
+	destListener := serveRTMP(t, dest)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pub := exec.CommandContext(ctx, ffmpeg, "-hide_banner", "-nostdin", "-loglevel", "error",
+		"-re", "-f", "lavfi", "-i", "testsrc=size=160x120:rate=25",
+		"-c:v", "libx264", "-preset", "ultrafast", "-tune", "zerolatency", "-g", "25",
+		"-f", "flv", "rtmp://"+originAddr+"/live/alice")
+	if err := pub.Start(); err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	defer func() {
+		cancel()
+		_ = pub.Wait()
+	}()
+	for deadline := time.Now().Add(15 * time.Second); !slices.Contains(origin.Publishing(), "alice"); {
+		if time.Now().After(deadline) {
+			t.Fatal("the test pattern never reached the origin")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	m := NewManager("rtmp://"+originAddr+"/live", ffmpeg)
+	m.Start("alice", Target{ID: "dest", URL: "rtmp://" + destListener.Addr().String() + "/live", Key: "relay-key"})
+	defer m.StopAll("alice")
+	if st := m.Status("alice")["dest"]; st.State != StateConnecting {
+		t.Fatalf("new relay is %s, want connecting", st.State)
+	}
+
+	waitState(t, m, "alice", "dest", StateLive)
+	for deadline := time.Now().Add(15 * time.Second); sink.frames.Load() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("live relay delivered no video")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// The destination drops the stream and stops accepting connections.
+	destListener.Close()
+	sink.gone.Store(true)
+	st := waitState(t, m, "alice", "dest", StateRetrying)
+	if st.Error == "" || strings.Contains(st.Error, "relay-key") {
+		t.Errorf("retrying with error %q", st.Error)
+	}
+}
@@ -0,0 +1,150 @@
+package repo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoAccountRepository is the AccountRepository backed by every
+// collection that holds user data.
+type MongoAccountRepository struct {
+	db *mongo.Database
+}
+
+// DeletedAccount lists what AccountRepository.Delete removed that has files on disk.
+type DeletedAccount struct {
+	VideoIDs []primitive.ObjectID
+	ClipIDs  []primitive.ObjectID
+}
+
+// Delete removes a user and everything that belongs to them: their
+// sessions, stream key, stream, restream destinations, recordings, clips
+// (of their channel and made by them), follows, chat messages, moderation
+// state, notifications, channel analytics, memberships, raids, and polls
+// and predictions with their votes and stakes. Follow counts of the other
+// side are adjusted. Ledger entries are kept: the other side of every
+// transfer still needs them to balance.
+func (r MongoAccountRepository) Delete(ctx context.Context, userID primitive.ObjectID) (*DeletedAccount, error) {
+	d := r.db
+	out := &DeletedAccount{}
+
+	var err error
+	if out.VideoIDs, err = findIDs(ctx, r.db, "videos", bson.M{"user_id": userID}); err != nil {
+		return nil, err
+	}
+	if out.ClipIDs, err = findIDs(ctx, r.db, "clips", bson.M{"$or": bson.A{
+		bson.M{"channel_id": userID}, bson.M{"creator_id": userID},
+	}}); err != nil {
+		return nil, err
+	}
+
+	if err := r.deleteFollowEdges(ctx, userID); err != nil {
+		return nil, err
+	}
+	users := d.Collection("users")
+	if _, err := d.Collection("streams").UpdateMany(ctx, bson.M{"allowed_user_ids": userID},
+		bson.M{"$pull": bson.M{"allowed_user_ids": userID}}); err != nil {
+		return nil, err
+	}
+	if _, err := d.Collection("raid_settings").UpdateMany(ctx, bson.M{"blocked.channel_id": userID},
+		bson.M{"$pull": bson.M{"blocked": bson.M{"channel_id": userID}}}); err != nil {
+		return nil, err
+	}
+
+	own := bson.M{"user_id": userID}
+	channel := bson.M{"channel_id": userID}
+	either := bson.M{"$or": bson.A{own, channel}}
+	deletes := []struct {
+		coll   string
+		filter bson.M
+	}{
+		{"sessions", own},
+		{"stream_keys", own},
+		{"streams", own},
+		{"restream_destinations", own},
+		{"stream_invites", channel},
+		{"videos", own},
+		{"clips", bson.M{"_id": bson.M{"$in": out.ClipIDs}}},
+		{"chat_messages", either},
+		{"chat_settings", bson.M{"_id": userID}},
+		{"channel_moderators", either},
+		{"channel_bans", either},
+		{"moderation_log", channel},
+		{"notifications", own},
+		{"viewer_sessions", channel},
+		{"broadcasts", channel},
+		{"channel_daily_stats", channel},
+		{"membership_tiers", channel},
+		{"memberships", either},
+		{"polls", channel},
+		{"poll_votes", either},
+		{"predictions", channel},
+		{"prediction_stakes", either},
+		{"raids", bson.M{"$or": bson.A{bson.M{"from_id": userID}, bson.M{"to_id": userID}}}},
+		{"raid_settings", bson.M{"_id": userID}},
+	}
+	for _, del := range deletes {
+		if _, err := d.Collection(del.coll).DeleteMany(ctx, del.filter); err != nil {
+			return nil, err
+		}
+	}
+	// The user goes last so a failed deletion can be retried.
+	if _, err := users.DeleteOne(ctx, bson.M{"_id": userID}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// deleteFollowEdges removes the user's follow edges and undoes the
+// counters MongoFollowRepository.Create raised on the other side, in one
+// transaction, so that a retried deletion does not lower them twice.
+func (r MongoAccountRepository) deleteFollowEdges(ctx context.Context, userID primitive.ObjectID) error {
+	return inTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		following, err := distinctIDs(sc, r.db, "follows", "channel_id", bson.M{"follower_id": userID})
+		if err != nil {
+			return err
+		}
+		followers, err := distinctIDs(sc, r.db, "follows", "follower_id", bson.M{"channel_id": userID})
+		if err != nil {
+			return err
+		}
+		users := r.db.Collection("users")
+		if len(following) > 0 {
+			if _, err := users.UpdateMany(sc, bson.M{"_id": bson.M{"$in": following}},
+				bson.M{"$inc": bson.M{"follower_count": -1}}); err != nil {
+				return err
+			}
+		}
+		if len(followers) > 0 {
+			if _, err := users.UpdateMany(sc, bson.M{"_id": bson.M{"$in": followers}},
+				bson.M{"$inc": bson.M{"following_count": -1}}); err != nil {
+				return err
+			}
+		}
+		_, err = r.db.Collection("follows").DeleteMany(sc, bson.M{"$or": bson.A{
+			bson.M{"follower_id": userID}, bson.M{"channel_id": userID},
+		}})
+		return err
+	})
+}
+
+func findIDs(ctx context.Context, d *mongo.Database, coll string, filter bson.M) ([]primitive.ObjectID, error) {
+	return distinctIDs(ctx, d, coll, "_id", filter)
+}
+
+func distinctIDs(ctx context.Context, d *mongo.Database, coll, field string, filter bson.M) ([]primitive.ObjectID, error) {
+	vals, err := d.Collection(coll).Distinct(ctx, field, filter)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(vals))
+	for _, v := range vals {
+		if id, ok := v.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
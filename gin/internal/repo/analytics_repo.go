@@ -0,0 +1,156 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoAnalyticsRepository is the AnalyticsRepository backed by the
+// "viewer_sessions" and "channel_daily_stats" collections.
+type MongoAnalyticsRepository struct {
+	db *mongo.Database
+}
+
+// Counter fields of Broadcast and DailyStats.
+const (
+	StatChatMessages = "chat_messages"
+	StatNewFollowers = "new_followers"
+)
+
+// InsertViewerSessions stores ended viewer sessions.
+func (r MongoAnalyticsRepository) InsertViewerSessions(ctx context.Context, sessions []models.ViewerSession) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(sessions))
+	for i := range sessions {
+		docs[i] = sessions[i]
+	}
+	_, err := r.db.Collection("viewer_sessions").InsertMany(ctx, docs)
+	return err
+}
+
+// ListViewerSessions returns the channel's sessions that overlap [from, to).
+func (r MongoAnalyticsRepository) ListViewerSessions(ctx context.Context, channelID primitive.ObjectID, from, to time.Time) ([]models.ViewerSession, error) {
+	return r.findViewerSessions(ctx, bson.M{
+		"channel_id": channelID,
+		"joined_at":  bson.M{"$lt": to},
+		"left_at":    bson.M{"$gte": from},
+	})
+}
+
+// ListBroadcastViewerSessions returns the sessions of the given broadcasts.
+func (r MongoAnalyticsRepository) ListBroadcastViewerSessions(ctx context.Context, broadcastIDs []primitive.ObjectID) ([]models.ViewerSession, error) {
+	if len(broadcastIDs) == 0 {
+		return []models.ViewerSession{}, nil
+	}
+	return r.findViewerSessions(ctx, bson.M{"broadcast_id": bson.M{"$in": broadcastIDs}})
+}
+
+// ListViewerHistory returns the viewer's sessions that started after
+// since, latest first.
+func (r MongoAnalyticsRepository) ListViewerHistory(ctx context.Context, viewer string, since time.Time, limit int) ([]models.ViewerSession, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "joined_at", Value: -1}}).SetLimit(int64(limit))
+	cur, err := r.db.Collection("viewer_sessions").Find(ctx,
+		bson.M{"viewer": viewer, "joined_at": bson.M{"$gte": since}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	sessions := []models.ViewerSession{}
+	if err := cur.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// ChannelViewers is a channel and a number of distinct viewers.
+type ChannelViewers struct {
+	ChannelID primitive.ObjectID `bson:"_id"`
+	Viewers   int                `bson:"viewers"`
+}
+
+// CoWatchedChannels looks at up to sample other viewers who watched the
+// channel since since and returns the channels they watched besides it,
+// by how many of them did, most first. viewer itself is left out.
+func (r MongoAnalyticsRepository) CoWatchedChannels(ctx context.Context, channelID primitive.ObjectID, viewer string, since time.Time, sample, limit int) ([]ChannelViewers, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"channel_id": channelID,
+			"joined_at":  bson.M{"$gte": since},
+			"viewer":     bson.M{"$ne": viewer},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": "$viewer"}}},
+		{{Key: "$limit", Value: sample}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "viewer_sessions",
+			"let":  bson.M{"v": "$_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{
+					"$expr": bson.M{"$and": bson.A{
+						bson.M{"$eq": bson.A{"$viewer", "$$v"}},
+						bson.M{"$gte": bson.A{"$joined_at", since}},
+						bson.M{"$ne": bson.A{"$channel_id", channelID}},
+					}},
+				}},
+				bson.M{"$group": bson.M{"_id": "$channel_id"}},
+			},
+			"as": "channels",
+		}}},
+		{{Key: "$unwind", Value: "$channels"}},
+		{{Key: "$group", Value: bson.M{"_id": "$channels._id", "viewers": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "viewers", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cur, err := r.db.Collection("viewer_sessions").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	out := []ChannelViewers{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r MongoAnalyticsRepository) findViewerSessions(ctx context.Context, filter bson.M) ([]models.ViewerSession, error) {
+	cur, err := r.db.Collection("viewer_sessions").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	sessions := []models.ViewerSession{}
+	if err := cur.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// IncDailyStat adds one to a counter of the channel's day.
+func (r MongoAnalyticsRepository) IncDailyStat(ctx context.Context, channelID primitive.ObjectID, day, field string) error {
+	_, err := r.db.Collection("channel_daily_stats").UpdateOne(ctx,
+		bson.M{"channel_id": channelID, "day": day},
+		bson.M{"$inc": bson.M{field: 1}},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// ListDailyStats returns the channel's counters for days in [from, to]
+// (YYYY-MM-DD, inclusive). Days without activity are missing.
+func (r MongoAnalyticsRepository) ListDailyStats(ctx context.Context, channelID primitive.ObjectID, from, to string) ([]models.DailyStats, error) {
+	cur, err := r.db.Collection("channel_daily_stats").Find(ctx,
+		bson.M{"channel_id": channelID, "day": bson.M{"$gte": from, "$lte": to}},
+		options.Find().SetSort(bson.D{{Key: "day", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	stats := []models.DailyStats{}
+	if err := cur.All(ctx, &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,134 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoBroadcastRepository is the BroadcastRepository backed by the
+// "broadcasts" collection.
+type MongoBroadcastRepository struct {
+	db *mongo.Database
+}
+
+// Create stores a broadcast that just started. It is a no-op if
+// one with the same ID exists.
+func (r MongoBroadcastRepository) Create(ctx context.Context, b *models.Broadcast) error {
+	coll := r.db.Collection("broadcasts")
+	if b.Tags == nil {
+		b.Tags = []string{}
+	}
+	if b.Events == nil {
+		b.Events = []models.BroadcastEvent{}
+	}
+	_, err := coll.InsertOne(ctx, b)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// End records the end, length and peak audience of a broadcast.
+// The peak only ever grows, so a partial count after a restart does not
+// lower it.
+func (r MongoBroadcastRepository) End(ctx context.Context, id primitive.ObjectID, endedAt time.Time, duration float64, peak int) error {
+	_, err := r.db.Collection("broadcasts").UpdateByID(ctx, id, bson.M{
+		"$set": bson.M{"ended_at": endedAt, "duration_seconds": duration},
+		"$max": bson.M{"peak_viewers": peak},
+	})
+	return err
+}
+
+// SetVisibility changes the visibility of a broadcast, and of its
+// video while that is still recording.
+func (r MongoBroadcastRepository) SetVisibility(ctx context.Context, id primitive.ObjectID, visibility string) error {
+	_, err := r.db.Collection("broadcasts").UpdateByID(ctx, id,
+		bson.M{"$set": bson.M{"visibility": visibility}})
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Collection("videos").UpdateOne(ctx,
+		bson.M{"broadcast_id": id, "status": models.VideoRecording},
+		bson.M{"$set": bson.M{"visibility": visibility, "updated_at": time.Now().UTC()}})
+	return err
+}
+
+// AddEvents appends events to a broadcast.
+func (r MongoBroadcastRepository) AddEvents(ctx context.Context, id primitive.ObjectID, events []models.BroadcastEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	_, err := r.db.Collection("broadcasts").UpdateByID(ctx, id,
+		bson.M{"$push": bson.M{"events": bson.M{"$each": events}}})
+	return err
+}
+
+// IncStat adds one to a counter of a broadcast.
+func (r MongoBroadcastRepository) IncStat(ctx context.Context, id primitive.ObjectID, field string) error {
+	_, err := r.db.Collection("broadcasts").UpdateByID(ctx, id,
+		bson.M{"$inc": bson.M{field: 1}})
+	return err
+}
+
+// FindByID returns a broadcast (or nil).
+func (r MongoBroadcastRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Broadcast, error) {
+	var b models.Broadcast
+	err := r.db.Collection("broadcasts").FindOne(ctx, bson.M{"_id": id}).Decode(&b)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &b, nil
+}
+
+// ListByChannel returns a page of the channel's broadcasts,
+// newest first, leaving out those with one of the hidden visibilities.
+func (r MongoBroadcastRepository) ListByChannel(ctx context.Context, channelID primitive.ObjectID, hidden []string, after *TimeCursor, limit int) ([]models.Broadcast, error) {
+	filter := bson.M{"channel_id": channelID}
+	if len(hidden) > 0 {
+		filter["visibility"] = bson.M{"$nin": hidden}
+	}
+	if after != nil {
+		filter = bson.M{"$and": bson.A{filter, after.olderThan("started_at", "_id")}}
+	}
+	return r.findBroadcasts(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit)))
+}
+
+// ListStartedBetween returns the channel's broadcasts that
+// started in [from, to), newest first.
+func (r MongoBroadcastRepository) ListStartedBetween(ctx context.Context, channelID primitive.ObjectID, from, to time.Time) ([]models.Broadcast, error) {
+	return r.findBroadcasts(ctx, bson.M{
+		"channel_id": channelID,
+		"started_at": bson.M{"$gte": from, "$lt": to},
+	}, options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}}))
+}
+
+// ListByIDs returns the given broadcasts, in no particular order.
+func (r MongoBroadcastRepository) ListByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Broadcast, error) {
+	if len(ids) == 0 {
+		return []models.Broadcast{}, nil
+	}
+	return r.findBroadcasts(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find())
+}
+
+func (r MongoBroadcastRepository) findBroadcasts(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.Broadcast, error) {
+	cur, err := r.db.Collection("broadcasts").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	broadcasts := []models.Broadcast{}
+	if err := cur.All(ctx, &broadcasts); err != nil {
+		return nil, err
+	}
+	return broadcasts, nil
+}
@@ -0,0 +1,152 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoCategoryRepository is the CategoryRepository backed by the
+// "categories" collection.
+type MongoCategoryRepository struct {
+	db *mongo.Database
+}
+
+// CategoryUpdate holds the editable fields of a category; nil means unchanged.
+type CategoryUpdate struct {
+	Name      *string
+	Icon      *string
+	SortOrder *int
+}
+
+// CategoryLiveCount is a category and the number of streams live in it.
+type CategoryLiveCount struct {
+	models.Category `bson:",inline"`
+	LiveStreams     int `json:"live_streams" bson:"live_streams"`
+}
+
+// List returns the catalog ordered by sort_order, then name.
+func (r MongoCategoryRepository) List(ctx context.Context) ([]models.Category, error) {
+	coll := r.db.Collection("categories")
+	opts := options.Find().SetSort(bson.D{{Key: "sort_order", Value: 1}, {Key: "name", Value: 1}})
+	cur, err := coll.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	cats := []models.Category{}
+	if err := cur.All(ctx, &cats); err != nil {
+		return nil, err
+	}
+	return cats, nil
+}
+
+// ListWithLiveCounts returns the catalog with a count of listed
+// live streams per entry.
+func (r MongoCategoryRepository) ListWithLiveCounts(ctx context.Context) ([]CategoryLiveCount, error) {
+	coll := r.db.Collection("categories")
+	cur, err := coll.Aggregate(ctx, mongo.Pipeline{
+		bson.D{{Key: "$lookup", Value: bson.M{
+			"from": "streams",
+			"let":  bson.M{"slug": "$slug"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$category", "$$slug"}},
+					bson.M{"$eq": bson.A{"$live", true}},
+					bson.M{"$not": bson.A{bson.M{"$in": bson.A{
+						bson.M{"$ifNull": bson.A{"$visibility", models.VisibilityPublic}},
+						bson.A{models.VisibilityUnlisted, models.VisibilityPrivate},
+					}}}},
+				}}}},
+				bson.M{"$count": "n"},
+			},
+			"as": "live",
+		}}},
+		bson.D{{Key: "$addFields", Value: bson.M{
+			"live_streams": bson.M{"$ifNull": bson.A{bson.M{"$first": "$live.n"}, 0}},
+		}}},
+		bson.D{{Key: "$project", Value: bson.M{"live": 0}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "sort_order", Value: 1}, {Key: "name", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	cats := []CategoryLiveCount{}
+	if err := cur.All(ctx, &cats); err != nil {
+		return nil, err
+	}
+	return cats, nil
+}
+
+// FindBySlug returns the category with the given slug (or nil).
+func (r MongoCategoryRepository) FindBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	coll := r.db.Collection("categories")
+	var cat models.Category
+	err := coll.FindOne(ctx, bson.M{"slug": slug}).Decode(&cat)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cat, nil
+}
+
+// Create inserts a category and fills its ID.
+func (r MongoCategoryRepository) Create(ctx context.Context, cat *models.Category) error {
+	coll := r.db.Collection("categories")
+	res, err := coll.InsertOne(ctx, cat)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		cat.ID = id
+	}
+	return nil
+}
+
+// Update applies the non-nil fields of u and returns the updated
+// category, or nil if no category has that slug.
+func (r MongoCategoryRepository) Update(ctx context.Context, slug string, u CategoryUpdate) (*models.Category, error) {
+	coll := r.db.Collection("categories")
+	set := bson.M{"updated_at": time.Now().UTC()}
+	if u.Name != nil {
+		set["name"] = *u.Name
+	}
+	if u.Icon != nil {
+		set["icon"] = *u.Icon
+	}
+	if u.SortOrder != nil {
+		set["sort_order"] = *u.SortOrder
+	}
+
+	var cat models.Category
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := coll.FindOneAndUpdate(ctx, bson.M{"slug": slug}, bson.M{"$set": set}, opts).Decode(&cat)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cat, nil
+}
+
+// Delete removes a category. It reports whether one was deleted.
+func (r MongoCategoryRepository) Delete(ctx context.Context, slug string) (bool, error) {
+	coll := r.db.Collection("categories")
+	res, err := coll.DeleteOne(ctx, bson.M{"slug": slug})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// Count returns the size of the catalog.
+func (r MongoCategoryRepository) Count(ctx context.Context) (int64, error) {
+	return r.db.Collection("categories").CountDocuments(ctx, bson.M{})
+}
@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoChatRepository is the ChatRepository backed by the "chat_messages"
+// collection.
+type MongoChatRepository struct {
+	db *mongo.Database
+}
+
+// Insert stores a chat message and fills its ID.
+func (r MongoChatRepository) Insert(ctx context.Context, m *models.ChatMessage) error {
+	coll := r.db.Collection("chat_messages")
+	res, err := coll.InsertOne(ctx, m)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		m.ID = id
+	}
+	return nil
+}
+
+// List returns a channel's messages newest first, starting
+// after the cursor if one is given.
+func (r MongoChatRepository) List(ctx context.Context, channel string, after *TimeCursor, limit int) ([]models.ChatMessage, error) {
+	coll := r.db.Collection("chat_messages")
+
+	filter := bson.M{"channel": channel, "deleted": bson.M{"$ne": true}}
+	if after != nil {
+		filter = bson.M{"$and": bson.A{filter, after.olderThan("created_at", "_id")}}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+	cur, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	msgs := []models.ChatMessage{}
+	if err := cur.All(ctx, &msgs); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// LastMessageAt returns when the user last posted in the channel (zero if never).
+func (r MongoChatRepository) LastMessageAt(ctx context.Context, channel string, userID primitive.ObjectID) (time.Time, error) {
+	coll := r.db.Collection("chat_messages")
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetProjection(bson.M{"created_at": 1})
+	var m models.ChatMessage
+	err := coll.FindOne(ctx, bson.M{"channel": channel, "user_id": userID}, opts).Decode(&m)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return m.CreatedAt, nil
+}
+
+// MarkDeleted hides a message of the channel and returns it,
+// or nil if there is no such message.
+func (r MongoChatRepository) MarkDeleted(ctx context.Context, channel string, id, by primitive.ObjectID) (*models.ChatMessage, error) {
+	coll := r.db.Collection("chat_messages")
+	var m models.ChatMessage
+	err := coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "channel": channel},
+		bson.M{"$set": bson.M{"deleted": true, "deleted_by": by}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&m)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// MarkUserMessagesDeleted hides every message of a user in the channel
+// (used when they are banned or timed out).
+func (r MongoChatRepository) MarkUserMessagesDeleted(ctx context.Context, channel string, userID, by primitive.ObjectID) error {
+	coll := r.db.Collection("chat_messages")
+	_, err := coll.UpdateMany(ctx,
+		bson.M{"channel": channel, "user_id": userID, "deleted": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"deleted": true, "deleted_by": by}},
+	)
+	return err
+}
@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoClipRepository is the ClipRepository backed by the "clips"
+// collection.
+type MongoClipRepository struct {
+	db *mongo.Database
+}
+
+// Create inserts a new clip and sets its ID.
+func (r MongoClipRepository) Create(ctx context.Context, c *models.Clip) error {
+	coll := r.db.Collection("clips")
+	res, err := coll.InsertOne(ctx, c)
+	if err != nil {
+		return err
+	}
+	c.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindByID returns the clip (or nil).
+func (r MongoClipRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Clip, error) {
+	coll := r.db.Collection("clips")
+	var c models.Clip
+	err := coll.FindOne(ctx, bson.M{"_id": id}).Decode(&c)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Delete removes a clip document.
+func (r MongoClipRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.db.Collection("clips").DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// IncViews adds one view to the clip.
+func (r MongoClipRepository) IncViews(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.db.Collection("clips").UpdateByID(ctx, id, bson.M{"$inc": bson.M{"view_count": 1}})
+	return err
+}
+
+// ListByChannel returns a page of the channel's clips, newest first,
+// leaving out those cut from broadcasts with one of the hidden visibilities.
+func (r MongoClipRepository) ListByChannel(ctx context.Context, channelID primitive.ObjectID, hidden []string, after *TimeCursor, limit int) ([]models.Clip, error) {
+	coll := r.db.Collection("clips")
+	filter := bson.M{"channel_id": channelID}
+	if len(hidden) > 0 {
+		filter["visibility"] = bson.M{"$nin": hidden}
+	}
+	if after != nil {
+		filter = bson.M{"$and": bson.A{filter, after.olderThan("created_at", "_id")}}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+	cur, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	clips := []models.Clip{}
+	if err := cur.All(ctx, &clips); err != nil {
+		return nil, err
+	}
+	return clips, nil
+}
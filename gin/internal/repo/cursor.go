@@ -0,0 +1,24 @@
+package repo
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TimeCursor paginates lists ordered newest first by a timestamp field,
+// with the document ID as tie-breaker.
+type TimeCursor struct {
+	At time.Time          `json:"t"`
+	ID primitive.ObjectID `json:"id"`
+}
+
+// olderThan returns a filter matching documents that sort after c when
+// ordering by {field: -1, idField: -1}.
+func (c *TimeCursor) olderThan(field, idField string) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{"$lt": c.At}},
+		bson.M{field: c.At, idField: bson.M{"$lt": c.ID}},
+	}}
+}
@@ -0,0 +1,188 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoFollowRepository is the FollowRepository backed by the "follows"
+// collection.
+type MongoFollowRepository struct {
+	db *mongo.Database
+}
+
+// FollowEntry is one row of a followers/following list.
+type FollowEntry struct {
+	FollowID   primitive.ObjectID   `json:"-" bson:"_id"`
+	FollowedAt time.Time            `json:"followed_at" bson:"created_at"`
+	User       models.PublicProfile `json:"user" bson:"user"`
+}
+
+// Create inserts a follow edge and, in the same transaction, adds
+// one to the follower's following_count and the channel's follower_count.
+// A duplicate-key error means the edge already exists (unique index on
+// follower_id + channel_id) and nothing was written.
+func (r MongoFollowRepository) Create(ctx context.Context, f *models.Follow) error {
+	return inTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		res, err := r.db.Collection("follows").InsertOne(sc, f)
+		if err != nil {
+			return err
+		}
+		if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+			f.ID = id
+		}
+		return r.incFollowCounts(sc, f.FollowerID, f.ChannelID, 1)
+	})
+}
+
+// Delete removes a follow edge and reports whether it existed. The
+// counters Create raised are lowered in the same transaction.
+func (r MongoFollowRepository) Delete(ctx context.Context, followerID, channelID primitive.ObjectID) (bool, error) {
+	var deleted bool
+	err := inTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		res, err := r.db.Collection("follows").DeleteOne(sc, bson.M{"follower_id": followerID, "channel_id": channelID})
+		if err != nil {
+			return err
+		}
+		if deleted = res.DeletedCount > 0; !deleted {
+			return nil
+		}
+		return r.incFollowCounts(sc, followerID, channelID, -1)
+	})
+	return deleted, err
+}
+
+// IsFollowing reports whether followerID follows channelID.
+func (r MongoFollowRepository) IsFollowing(ctx context.Context, followerID, channelID primitive.ObjectID) (bool, error) {
+	coll := r.db.Collection("follows")
+	n, err := coll.CountDocuments(ctx, bson.M{"follower_id": followerID, "channel_id": channelID}, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+// Find returns the follow edge between the two users (or nil).
+func (r MongoFollowRepository) Find(ctx context.Context, followerID, channelID primitive.ObjectID) (*models.Follow, error) {
+	coll := r.db.Collection("follows")
+	var f models.Follow
+	err := coll.FindOne(ctx, bson.M{"follower_id": followerID, "channel_id": channelID}).Decode(&f)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &f, nil
+}
+
+// incFollowCounts adjusts the follower's following_count and the channel's
+// follower_count by delta (+1 on follow, -1 on unfollow).
+func (r MongoFollowRepository) incFollowCounts(ctx context.Context, followerID, channelID primitive.ObjectID, delta int64) error {
+	coll := r.db.Collection("users")
+	if _, err := coll.UpdateByID(ctx, followerID, bson.M{"$inc": bson.M{"following_count": delta}}); err != nil {
+		return err
+	}
+	_, err := coll.UpdateByID(ctx, channelID, bson.M{"$inc": bson.M{"follower_count": delta}})
+	return err
+}
+
+// ListFollowers returns the users following channelID, newest first.
+func (r MongoFollowRepository) ListFollowers(ctx context.Context, channelID primitive.ObjectID, after *TimeCursor, limit int) ([]FollowEntry, error) {
+	return r.listFollows(ctx, "channel_id", channelID, "follower_id", after, limit)
+}
+
+// ListFollowing returns the channels followerID follows, newest first.
+func (r MongoFollowRepository) ListFollowing(ctx context.Context, followerID primitive.ObjectID, after *TimeCursor, limit int) ([]FollowEntry, error) {
+	return r.listFollows(ctx, "follower_id", followerID, "channel_id", after, limit)
+}
+
+// listFollows pages through edges where matchField == id and joins the
+// user on the other end of each edge (otherField).
+func (r MongoFollowRepository) listFollows(ctx context.Context, matchField string, id primitive.ObjectID, otherField string, after *TimeCursor, limit int) ([]FollowEntry, error) {
+	coll := r.db.Collection("follows")
+
+	match := bson.M{matchField: id}
+	if after != nil {
+		match = bson.M{"$and": bson.A{match, after.olderThan("created_at", "_id")}}
+	}
+	cur, err := coll.Aggregate(ctx, mongo.Pipeline{
+		bson.D{{Key: "$match", Value: match}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}}},
+		bson.D{{Key: "$limit", Value: limit}},
+		bson.D{{Key: "$lookup", Value: bson.M{
+			"from":         "users",
+			"localField":   otherField,
+			"foreignField": "_id",
+			"as":           "user",
+			"pipeline": bson.A{bson.M{"$project": bson.M{
+				"username": 1, "display_name": 1, "follower_count": 1, "following_count": 1,
+			}}},
+		}}},
+		bson.D{{Key: "$unwind", Value: "$user"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	entries := []FollowEntry{}
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// FollowedChannelIDs returns up to limit channel IDs that followerID follows.
+func (r MongoFollowRepository) FollowedChannelIDs(ctx context.Context, followerID primitive.ObjectID, limit int) ([]primitive.ObjectID, error) {
+	coll := r.db.Collection("follows")
+	opts := options.Find().
+		SetProjection(bson.M{"channel_id": 1}).
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(int64(limit))
+	cur, err := coll.Find(ctx, bson.M{"follower_id": followerID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var rows []models.Follow
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ChannelID
+	}
+	return ids, nil
+}
+
+// FollowerIDsAfter returns up to limit follower IDs of channelID whose
+// follow edge ID is greater than afterEdge, plus the last edge ID seen.
+// It is used to walk very large follower sets in batches.
+func (r MongoFollowRepository) FollowerIDsAfter(ctx context.Context, channelID, afterEdge primitive.ObjectID, limit int) ([]primitive.ObjectID, primitive.ObjectID, error) {
+	coll := r.db.Collection("follows")
+	filter := bson.M{"channel_id": channelID}
+	if !afterEdge.IsZero() {
+		filter["_id"] = bson.M{"$gt": afterEdge}
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"follower_id": 1}).
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	cur, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, afterEdge, err
+	}
+	var rows []models.Follow
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, afterEdge, err
+	}
+	ids := make([]primitive.ObjectID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.FollowerID
+	}
+	if len(rows) > 0 {
+		afterEdge = rows[len(rows)-1].ID
+	}
+	return ids, afterEdge, nil
+}
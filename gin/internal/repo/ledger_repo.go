@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoLedgerRepository is the LedgerRepository backed by the
+// "ledger_accounts", "ledger_transfers" and "ledger_entries" collections.
+type MongoLedgerRepository struct {
+	db *mongo.Database
+}
+
+// ErrInsufficientFunds aborts a transfer that would overdraw an account.
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// CreateTransfer records t with its two entries in one transaction, after
+// checking that the paying account can afford it (system accounts may go
+// negative). Both accounts are created on first use.
+//
+// Every transfer bumps Seq on both accounts, so two transfers spending
+// from the same account conflict and one is retried against the other's
+// result: balances cannot be overdrawn by racing requests.
+//
+// Transfers are idempotent by IdempotencyKey: if one with the same key
+// exists, it is returned as existing and nothing is written. Transactions
+// need a replica set, such as Atlas.
+func (r MongoLedgerRepository) CreateTransfer(ctx context.Context, t *models.LedgerTransfer) (existing *models.LedgerTransfer, err error) {
+	err = inTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		existing, err = r.createTransfer(sc, t)
+		return err
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent request with the same key won.
+		existing, err = r.findTransferByKey(ctx, t.IdempotencyKey)
+	}
+	return existing, err
+}
+
+// createTransfer is CreateTransfer within the caller's transaction.
+func (r MongoLedgerRepository) createTransfer(sc mongo.SessionContext, t *models.LedgerTransfer) (existing *models.LedgerTransfer, err error) {
+	if prev, err := r.findTransferByKey(sc, t.IdempotencyKey); err != nil || prev != nil {
+		return prev, err
+	}
+	for _, acc := range []string{t.From, t.To} {
+		if err := r.touchAccount(sc, acc, t.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+	if !strings.HasPrefix(t.From, models.LedgerAccountSystem+":") {
+		balance, err := r.Balance(sc, t.From)
+		if err != nil {
+			return nil, err
+		}
+		if balance < t.Amount {
+			return nil, ErrInsufficientFunds
+		}
+	}
+
+	d := r.db
+	res, err := d.Collection("ledger_transfers").InsertOne(sc, t)
+	if err != nil {
+		return nil, err
+	}
+	t.ID = res.InsertedID.(primitive.ObjectID)
+	_, err = d.Collection("ledger_entries").InsertMany(sc, []interface{}{
+		models.LedgerEntry{TransferID: t.ID, Account: t.From, Counterparty: t.To, Kind: t.Kind, Amount: -t.Amount, Memo: t.Memo, CreatedAt: t.CreatedAt},
+		models.LedgerEntry{TransferID: t.ID, Account: t.To, Counterparty: t.From, Kind: t.Kind, Amount: t.Amount, Memo: t.Memo, CreatedAt: t.CreatedAt},
+	})
+	return nil, err
+}
+
+// touchAccount creates the account if needed and bumps its Seq.
+func (r MongoLedgerRepository) touchAccount(ctx context.Context, id string, now time.Time) error {
+	kind, rest, _ := strings.Cut(id, ":")
+	insert := bson.M{"kind": kind, "created_at": now}
+	if kind == models.LedgerAccountUser {
+		if uid, err := HexToObjectID(rest); err == nil {
+			insert["user_id"] = uid
+		}
+	}
+	_, err := r.db.Collection("ledger_accounts").UpdateByID(ctx, id,
+		bson.M{"$inc": bson.M{"seq": 1}, "$setOnInsert": insert},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+func (r MongoLedgerRepository) findTransferByKey(ctx context.Context, key string) (*models.LedgerTransfer, error) {
+	var t models.LedgerTransfer
+	err := r.db.Collection("ledger_transfers").FindOne(ctx, bson.M{"idempotency_key": key}).Decode(&t)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Balance sums the account's entries.
+func (r MongoLedgerRepository) Balance(ctx context.Context, account string) (int64, error) {
+	cur, err := r.db.Collection("ledger_entries").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"account": account}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "balance": bson.M{"$sum": "$amount"}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var rows []struct {
+		Balance int64 `bson:"balance"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	return rows[0].Balance, nil
+}
+
+// ListEntries returns a page of the account's entries, newest first.
+func (r MongoLedgerRepository) ListEntries(ctx context.Context, account string, after *TimeCursor, limit int) ([]models.LedgerEntry, error) {
+	filter := bson.M{"account": account}
+	if after != nil {
+		filter = bson.M{"$and": bson.A{filter, after.olderThan("created_at", "_id")}}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+	cur, err := r.db.Collection("ledger_entries").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	entries := []models.LedgerEntry{}
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
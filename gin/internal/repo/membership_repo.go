@@ -0,0 +1,250 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoMembershipRepository is the MembershipRepository backed by the
+// "membership_tiers" and "memberships" collections.
+type MongoMembershipRepository struct {
+	db *mongo.Database
+}
+
+// CreateTier inserts a tier and sets its ID.
+func (r MongoMembershipRepository) CreateTier(ctx context.Context, t *models.MembershipTier) error {
+	res, err := r.db.Collection("membership_tiers").InsertOne(ctx, t)
+	if err != nil {
+		return err
+	}
+	t.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// MembershipTierUpdate holds the editable fields of a tier; nil means unchanged.
+type MembershipTierUpdate struct {
+	Name         *string
+	Perks        *[]string
+	Price        *int64
+	DurationDays *int
+	Archived     *bool
+}
+
+// UpdateTier applies the non-nil fields of u to one of the
+// channel's tiers and returns the result (or nil if there is no such tier).
+func (r MongoMembershipRepository) UpdateTier(ctx context.Context, channelID, id primitive.ObjectID, u MembershipTierUpdate) (*models.MembershipTier, error) {
+	set := bson.M{"updated_at": time.Now().UTC()}
+	if u.Name != nil {
+		set["name"] = *u.Name
+	}
+	if u.Perks != nil {
+		set["perks"] = *u.Perks
+	}
+	if u.Price != nil {
+		set["price"] = *u.Price
+	}
+	if u.DurationDays != nil {
+		set["duration_days"] = *u.DurationDays
+	}
+	if u.Archived != nil {
+		set["archived"] = *u.Archived
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var t models.MembershipTier
+	err := r.db.Collection("membership_tiers").FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "channel_id": channelID}, bson.M{"$set": set}, opts).Decode(&t)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// FindTier returns the tier (or nil).
+func (r MongoMembershipRepository) FindTier(ctx context.Context, id primitive.ObjectID) (*models.MembershipTier, error) {
+	var t models.MembershipTier
+	err := r.db.Collection("membership_tiers").FindOne(ctx, bson.M{"_id": id}).Decode(&t)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListTiers returns the channel's tiers, cheapest first.
+func (r MongoMembershipRepository) ListTiers(ctx context.Context, channelID primitive.ObjectID, includeArchived bool) ([]models.MembershipTier, error) {
+	filter := bson.M{"channel_id": channelID}
+	if !includeArchived {
+		filter["archived"] = bson.M{"$ne": true}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "price", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := r.db.Collection("membership_tiers").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	tiers := []models.MembershipTier{}
+	if err := cur.All(ctx, &tiers); err != nil {
+		return nil, err
+	}
+	return tiers, nil
+}
+
+// CountTiers counts the channel's tiers that are not archived.
+func (r MongoMembershipRepository) CountTiers(ctx context.Context, channelID primitive.ObjectID) (int64, error) {
+	return r.db.Collection("membership_tiers").CountDocuments(ctx,
+		bson.M{"channel_id": channelID, "archived": bson.M{"$ne": true}})
+}
+
+// Find returns the user's membership of the channel, active or
+// not (or nil).
+func (r MongoMembershipRepository) Find(ctx context.Context, channelID, userID primitive.ObjectID) (*models.Membership, error) {
+	var m models.Membership
+	err := r.db.Collection("memberships").FindOne(ctx,
+		bson.M{"channel_id": channelID, "user_id": userID}).Decode(&m)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// IsMember reports whether the user holds an active membership of the
+// channel at now.
+func (r MongoMembershipRepository) IsMember(ctx context.Context, channelID, userID primitive.ObjectID, now time.Time) (bool, error) {
+	n, err := r.db.Collection("memberships").CountDocuments(ctx, bson.M{
+		"channel_id": channelID,
+		"user_id":    userID,
+		"status":     models.MembershipActive,
+		"expires_at": bson.M{"$gt": now},
+	}, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+// Start activates m, replacing an earlier membership of the
+// user and channel unless that one is still active. It reports false if
+// it is, leaving it untouched; m then holds nothing new.
+func (r MongoMembershipRepository) Start(ctx context.Context, m *models.Membership) (bool, error) {
+	filter := bson.M{
+		"channel_id": m.ChannelID,
+		"user_id":    m.UserID,
+		"$or": bson.A{
+			bson.M{"status": bson.M{"$ne": models.MembershipActive}},
+			bson.M{"expires_at": bson.M{"$lte": m.StartedAt}},
+		},
+	}
+	set := bson.M{
+		"channel_username": m.ChannelUsername,
+		"username":         m.Username,
+		"tier_id":          m.TierID,
+		"tier_name":        m.TierName,
+		"status":           m.Status,
+		"auto_renew":       m.AutoRenew,
+		"started_at":       m.StartedAt,
+		"expires_at":       m.ExpiresAt,
+		"updated_at":       m.UpdatedAt,
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := r.db.Collection("memberships").FindOneAndUpdate(ctx, filter,
+		bson.M{"$set": set, "$unset": bson.M{"canceled_at": ""}}, opts).Decode(m)
+	if mongo.IsDuplicateKeyError(err) {
+		// The filter missed an active membership, so the upsert collided.
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SetAutoRenew turns renewal of a membership on or off and
+// returns the result.
+func (r MongoMembershipRepository) SetAutoRenew(ctx context.Context, id primitive.ObjectID, autoRenew bool, now time.Time) (*models.Membership, error) {
+	update := bson.M{"$set": bson.M{"auto_renew": true, "updated_at": now}, "$unset": bson.M{"canceled_at": ""}}
+	if !autoRenew {
+		update = bson.M{"$set": bson.M{"auto_renew": false, "canceled_at": now, "updated_at": now}}
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var m models.Membership
+	err := r.db.Collection("memberships").FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&m)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ListByUser returns the user's memberships, active ones first
+// and then by expiry, latest first.
+func (r MongoMembershipRepository) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Membership, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: -1}})
+	return r.findMemberships(ctx, bson.M{"user_id": userID}, opts)
+}
+
+// ListChannelMembers returns a page of the channel's active members,
+// newest first.
+func (r MongoMembershipRepository) ListChannelMembers(ctx context.Context, channelID primitive.ObjectID, now time.Time, after *TimeCursor, limit int) ([]models.Membership, error) {
+	filter := bson.M{"channel_id": channelID, "status": models.MembershipActive, "expires_at": bson.M{"$gt": now}}
+	if after != nil {
+		filter = bson.M{"$and": bson.A{filter, after.olderThan("started_at", "_id")}}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+	return r.findMemberships(ctx, filter, opts)
+}
+
+// ListDue returns up to limit active memberships that have
+// run out by now, the longest overdue first.
+func (r MongoMembershipRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]models.Membership, error) {
+	filter := bson.M{"status": models.MembershipActive, "expires_at": bson.M{"$lte": now}}
+	opts := options.Find().SetSort(bson.D{{Key: "expires_at", Value: 1}}).SetLimit(int64(limit))
+	return r.findMemberships(ctx, filter, opts)
+}
+
+// Renew extends a due membership to expiresAt. It reports false
+// if the membership changed since it was loaded with the given expiry,
+// for instance because another instance renewed it first.
+func (r MongoMembershipRepository) Renew(ctx context.Context, id primitive.ObjectID, prevExpiry, expiresAt time.Time, tierName string) (bool, error) {
+	res, err := r.db.Collection("memberships").UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.MembershipActive, "expires_at": prevExpiry},
+		bson.M{"$set": bson.M{"expires_at": expiresAt, "tier_name": tierName, "updated_at": time.Now().UTC()}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// Expire ends a due membership. Like Renew it reports
+// false if the membership changed since it was loaded.
+func (r MongoMembershipRepository) Expire(ctx context.Context, id primitive.ObjectID, prevExpiry time.Time) (bool, error) {
+	res, err := r.db.Collection("memberships").UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.MembershipActive, "expires_at": prevExpiry},
+		bson.M{"$set": bson.M{"status": models.MembershipExpired, "auto_renew": false, "updated_at": time.Now().UTC()}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (r MongoMembershipRepository) findMemberships(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.Membership, error) {
+	cur, err := r.db.Collection("memberships").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := []models.Membership{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,93 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryAccountRepository is the in-memory AccountRepository.
+type MemoryAccountRepository struct{ d *memoryDB }
+
+// deleteWhere removes the documents of m that drop accepts.
+func deleteWhere[K comparable, T any](m map[K]T, drop func(*T) bool) {
+	for k, v := range m {
+		if drop(&v) {
+			delete(m, k)
+		}
+	}
+}
+
+// Delete removes what the Mongo version does, all at once.
+func (m *MemoryAccountRepository) Delete(_ context.Context, userID primitive.ObjectID) (*DeletedAccount, error) {
+	d := m.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	out := &DeletedAccount{VideoIDs: []primitive.ObjectID{}, ClipIDs: []primitive.ObjectID{}}
+	for _, v := range d.videos {
+		if v.UserID == userID {
+			out.VideoIDs = append(out.VideoIDs, v.ID)
+		}
+	}
+	for _, c := range d.clips {
+		if c.ChannelID == userID || c.CreatorID == userID {
+			out.ClipIDs = append(out.ClipIDs, c.ID)
+		}
+	}
+	sortBy(out.VideoIDs, func(a, b *primitive.ObjectID) bool { return idLess(*a, *b) })
+	sortBy(out.ClipIDs, func(a, b *primitive.ObjectID) bool { return idLess(*a, *b) })
+
+	for id, f := range d.follows {
+		if f.FollowerID == userID || f.ChannelID == userID {
+			d.incFollowCounts(f.FollowerID, f.ChannelID, -1)
+			delete(d.follows, id)
+		}
+	}
+	for id, s := range d.streams {
+		if containsID(s.AllowedUserIDs, userID) {
+			s.AllowedUserIDs = pullID(s.AllowedUserIDs, userID)
+			d.streams[id] = s
+		}
+	}
+	for id, s := range d.raidSettings {
+		blocked := []models.RaidBlock{}
+		for _, b := range s.Blocked {
+			if b.ChannelID != userID {
+				blocked = append(blocked, b)
+			}
+		}
+		if len(blocked) != len(s.Blocked) {
+			s.Blocked = blocked
+			d.raidSettings[id] = s
+		}
+	}
+
+	deleteWhere(d.sessions, func(s *models.Session) bool { return s.UserID == userID })
+	deleteWhere(d.streamKeys, func(k *models.StreamKey) bool { return k.UserID == userID })
+	deleteWhere(d.streams, func(s *models.Stream) bool { return s.UserID == userID })
+	deleteWhere(d.restreams, func(r *models.RestreamDestination) bool { return r.UserID == userID })
+	deleteWhere(d.streamInvites, func(inv *models.StreamInvite) bool { return inv.ChannelID == userID })
+	deleteWhere(d.videos, func(v *models.Video) bool { return v.UserID == userID })
+	deleteWhere(d.clips, func(c *models.Clip) bool { return containsID(out.ClipIDs, c.ID) })
+	deleteWhere(d.chatMessages, func(msg *models.ChatMessage) bool { return msg.UserID == userID || msg.ChannelID == userID })
+	delete(d.chatSettings, userID)
+	deleteWhere(d.moderators, func(mod *models.ChannelModerator) bool { return mod.UserID == userID || mod.ChannelID == userID })
+	deleteWhere(d.bans, func(b *models.ChannelBan) bool { return b.UserID == userID || b.ChannelID == userID })
+	deleteWhere(d.moderationLog, func(a *models.ModerationAction) bool { return a.ChannelID == userID })
+	deleteWhere(d.notifications, func(n *models.Notification) bool { return n.UserID == userID })
+	deleteWhere(d.viewerSessions, func(s *models.ViewerSession) bool { return s.ChannelID == userID })
+	deleteWhere(d.broadcasts, func(b *models.Broadcast) bool { return b.ChannelID == userID })
+	deleteWhere(d.dailyStats, func(s *models.DailyStats) bool { return s.ChannelID == userID })
+	deleteWhere(d.tiers, func(t *models.MembershipTier) bool { return t.ChannelID == userID })
+	deleteWhere(d.memberships, func(mb *models.Membership) bool { return mb.UserID == userID || mb.ChannelID == userID })
+	deleteWhere(d.polls, func(p *models.Poll) bool { return p.ChannelID == userID })
+	deleteWhere(d.pollVotes, func(v *models.PollVote) bool { return v.UserID == userID || v.ChannelID == userID })
+	deleteWhere(d.predictions, func(p *models.Prediction) bool { return p.ChannelID == userID })
+	deleteWhere(d.stakes, func(s *models.PredictionStake) bool { return s.UserID == userID || s.ChannelID == userID })
+	deleteWhere(d.raids, func(r *models.Raid) bool { return r.FromID == userID || r.ToID == userID })
+	delete(d.raidSettings, userID)
+	delete(d.users, userID)
+	return out, nil
+}
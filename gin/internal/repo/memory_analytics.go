@@ -0,0 +1,134 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryAnalyticsRepository is the in-memory AnalyticsRepository.
+type MemoryAnalyticsRepository struct{ d *memoryDB }
+
+func (m *MemoryAnalyticsRepository) InsertViewerSessions(_ context.Context, sessions []models.ViewerSession) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	for _, s := range sessions {
+		s = clone(s)
+		if s.ID.IsZero() {
+			s.ID = primitive.NewObjectID()
+		}
+		m.d.viewerSessions[s.ID] = s
+	}
+	return nil
+}
+
+func (m *MemoryAnalyticsRepository) ListViewerSessions(_ context.Context, channelID primitive.ObjectID, from, to time.Time) ([]models.ViewerSession, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	from, to = ms(from), ms(to)
+	return m.d.sortedViewerSessions(func(s *models.ViewerSession) bool {
+		return s.ChannelID == channelID && s.JoinedAt.Before(to) && !s.LeftAt.Before(from)
+	}), nil
+}
+
+func (m *MemoryAnalyticsRepository) ListBroadcastViewerSessions(_ context.Context, broadcastIDs []primitive.ObjectID) ([]models.ViewerSession, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	return m.d.sortedViewerSessions(func(s *models.ViewerSession) bool {
+		return containsID(broadcastIDs, s.BroadcastID)
+	}), nil
+}
+
+// sortedViewerSessions returns the sessions keep accepts in insertion
+// order, the closest there is to MongoDB's natural order.
+func (d *memoryDB) sortedViewerSessions(keep func(*models.ViewerSession) bool) []models.ViewerSession {
+	sessions := where(d.viewerSessions, keep)
+	sortBy(sessions, func(a, b *models.ViewerSession) bool { return idLess(a.ID, b.ID) })
+	return sessions
+}
+
+func (m *MemoryAnalyticsRepository) ListViewerHistory(_ context.Context, viewer string, since time.Time, limit int) ([]models.ViewerSession, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	since = ms(since)
+	sessions := where(m.d.viewerSessions, func(s *models.ViewerSession) bool {
+		return s.Viewer == viewer && !s.JoinedAt.Before(since)
+	})
+	sortBy(sessions, func(a, b *models.ViewerSession) bool { return newer(a.JoinedAt, a.ID, b.JoinedAt, b.ID) })
+	return firstN(sessions, limit), nil
+}
+
+func (m *MemoryAnalyticsRepository) CoWatchedChannels(_ context.Context, channelID primitive.ObjectID, viewer string, since time.Time, sample, limit int) ([]ChannelViewers, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	since = ms(since)
+
+	seen := map[string]bool{}
+	for _, s := range m.d.viewerSessions {
+		if s.ChannelID == channelID && !s.JoinedAt.Before(since) && s.Viewer != viewer {
+			seen[s.Viewer] = true
+		}
+	}
+	viewers := make([]string, 0, len(seen))
+	for v := range seen {
+		viewers = append(viewers, v)
+	}
+	sort.Strings(viewers)
+	viewers = firstN(viewers, sample)
+
+	watched := map[primitive.ObjectID]map[string]bool{}
+	for _, s := range m.d.viewerSessions {
+		if s.ChannelID == channelID || s.JoinedAt.Before(since) || !containsString(viewers, s.Viewer) {
+			continue
+		}
+		if watched[s.ChannelID] == nil {
+			watched[s.ChannelID] = map[string]bool{}
+		}
+		watched[s.ChannelID][s.Viewer] = true
+	}
+	out := []ChannelViewers{}
+	for id, vs := range watched {
+		out = append(out, ChannelViewers{ChannelID: id, Viewers: len(vs)})
+	}
+	sortBy(out, func(a, b *ChannelViewers) bool {
+		if a.Viewers != b.Viewers {
+			return a.Viewers > b.Viewers
+		}
+		return idLess(a.ChannelID, b.ChannelID)
+	})
+	return firstN(out, limit), nil
+}
+
+func (m *MemoryAnalyticsRepository) IncDailyStat(_ context.Context, channelID primitive.ObjectID, day, field string) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	key := dailyStatsKey{channelID, day}
+	s, ok := m.d.dailyStats[key]
+	if !ok {
+		s = models.DailyStats{ChannelID: channelID, Day: day}
+	}
+	switch field {
+	case StatChatMessages:
+		s.ChatMessages++
+	case StatNewFollowers:
+		s.NewFollowers++
+	default:
+		return fmt.Errorf("repo: unknown daily stat %q", field)
+	}
+	m.d.dailyStats[key] = s
+	return nil
+}
+
+func (m *MemoryAnalyticsRepository) ListDailyStats(_ context.Context, channelID primitive.ObjectID, from, to string) ([]models.DailyStats, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	stats := where(m.d.dailyStats, func(s *models.DailyStats) bool {
+		return s.ChannelID == channelID && s.Day >= from && s.Day <= to
+	})
+	sortBy(stats, func(a, b *models.DailyStats) bool { return a.Day < b.Day })
+	return stats, nil
+}
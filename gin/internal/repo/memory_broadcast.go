@@ -0,0 +1,131 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryBroadcastRepository is the in-memory BroadcastRepository.
+type MemoryBroadcastRepository struct{ d *memoryDB }
+
+func (m *MemoryBroadcastRepository) Create(_ context.Context, b *models.Broadcast) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	if b.Tags == nil {
+		b.Tags = []string{}
+	}
+	if b.Events == nil {
+		b.Events = []models.BroadcastEvent{}
+	}
+	if _, ok := m.d.broadcasts[b.ID]; !ok {
+		m.d.broadcasts[b.ID] = clone(*b)
+	}
+	return nil
+}
+
+func (m *MemoryBroadcastRepository) End(_ context.Context, id primitive.ObjectID, endedAt time.Time, duration float64, peak int) error {
+	return m.update(id, func(b *models.Broadcast) error {
+		b.EndedAt, b.DurationSeconds = &endedAt, duration
+		if peak > b.PeakViewers {
+			b.PeakViewers = peak
+		}
+		return nil
+	})
+}
+
+func (m *MemoryBroadcastRepository) SetVisibility(_ context.Context, id primitive.ObjectID, visibility string) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	if b, ok := m.d.broadcasts[id]; ok {
+		b.Visibility = visibility
+		m.d.broadcasts[id] = clone(b)
+	}
+	for vid, v := range m.d.videos {
+		if v.BroadcastID == id && v.Status == models.VideoRecording {
+			v.Visibility, v.UpdatedAt = visibility, time.Now().UTC()
+			m.d.videos[vid] = clone(v)
+			break
+		}
+	}
+	return nil
+}
+
+func (m *MemoryBroadcastRepository) AddEvents(_ context.Context, id primitive.ObjectID, events []models.BroadcastEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return m.update(id, func(b *models.Broadcast) error {
+		b.Events = append(b.Events, events...)
+		return nil
+	})
+}
+
+func (m *MemoryBroadcastRepository) IncStat(_ context.Context, id primitive.ObjectID, field string) error {
+	return m.update(id, func(b *models.Broadcast) error {
+		switch field {
+		case StatChatMessages:
+			b.ChatMessages++
+		case StatNewFollowers:
+			b.NewFollowers++
+		default:
+			return fmt.Errorf("repo: unknown broadcast stat %q", field)
+		}
+		return nil
+	})
+}
+
+// update applies apply to the broadcast, if it exists.
+func (m *MemoryBroadcastRepository) update(id primitive.ObjectID, apply func(*models.Broadcast) error) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	b, ok := m.d.broadcasts[id]
+	if !ok {
+		return nil
+	}
+	if err := apply(&b); err != nil {
+		return err
+	}
+	m.d.broadcasts[id] = clone(b)
+	return nil
+}
+
+func (m *MemoryBroadcastRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.Broadcast, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	b, ok := m.d.broadcasts[id]
+	if !ok {
+		return nil, nil
+	}
+	return ptr(b), nil
+}
+
+func (m *MemoryBroadcastRepository) ListByChannel(_ context.Context, channelID primitive.ObjectID, hidden []string, after *TimeCursor, limit int) ([]models.Broadcast, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	broadcasts := where(m.d.broadcasts, func(b *models.Broadcast) bool {
+		return b.ChannelID == channelID && !containsString(hidden, b.Visibility) && after.after(b.StartedAt, b.ID)
+	})
+	sortBy(broadcasts, func(a, b *models.Broadcast) bool { return newer(a.StartedAt, a.ID, b.StartedAt, b.ID) })
+	return firstN(broadcasts, limit), nil
+}
+
+func (m *MemoryBroadcastRepository) ListStartedBetween(_ context.Context, channelID primitive.ObjectID, from, to time.Time) ([]models.Broadcast, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	from, to = ms(from), ms(to)
+	broadcasts := where(m.d.broadcasts, func(b *models.Broadcast) bool {
+		return b.ChannelID == channelID && !b.StartedAt.Before(from) && b.StartedAt.Before(to)
+	})
+	sortBy(broadcasts, func(a, b *models.Broadcast) bool { return newer(a.StartedAt, a.ID, b.StartedAt, b.ID) })
+	return broadcasts, nil
+}
+
+func (m *MemoryBroadcastRepository) ListByIDs(_ context.Context, ids []primitive.ObjectID) ([]models.Broadcast, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	return where(m.d.broadcasts, func(b *models.Broadcast) bool { return containsID(ids, b.ID) }), nil
+}
@@ -0,0 +1,117 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryCategoryRepository is the in-memory CategoryRepository.
+type MemoryCategoryRepository struct{ d *memoryDB }
+
+func (m *MemoryCategoryRepository) List(_ context.Context) ([]models.Category, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	return m.d.listCategories(), nil
+}
+
+func (d *memoryDB) listCategories() []models.Category {
+	cats := where(d.categories, func(*models.Category) bool { return true })
+	sortBy(cats, func(a, b *models.Category) bool {
+		if a.SortOrder != b.SortOrder {
+			return a.SortOrder < b.SortOrder
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return idLess(a.ID, b.ID)
+	})
+	return cats
+}
+
+func (m *MemoryCategoryRepository) ListWithLiveCounts(_ context.Context) ([]CategoryLiveCount, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	live := map[string]int{}
+	for _, s := range m.d.streams {
+		if s.Live && listed(s.Visibility) {
+			live[s.Category]++
+		}
+	}
+	out := []CategoryLiveCount{}
+	for _, c := range m.d.listCategories() {
+		out = append(out, CategoryLiveCount{Category: c, LiveStreams: live[c.Slug]})
+	}
+	return out, nil
+}
+
+func (m *MemoryCategoryRepository) FindBySlug(_ context.Context, slug string) (*models.Category, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	return m.d.findCategory(slug), nil
+}
+
+func (d *memoryDB) findCategory(slug string) *models.Category {
+	for _, c := range d.categories {
+		if c.Slug == slug {
+			return ptr(c)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryCategoryRepository) Create(_ context.Context, cat *models.Category) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	if m.d.findCategory(cat.Slug) != nil {
+		return duplicateKeyError("categories", "slug_unique")
+	}
+	if cat.ID.IsZero() {
+		cat.ID = primitive.NewObjectID()
+	}
+	if _, ok := m.d.categories[cat.ID]; ok {
+		return duplicateKeyError("categories", "_id")
+	}
+	m.d.categories[cat.ID] = clone(*cat)
+	return nil
+}
+
+func (m *MemoryCategoryRepository) Update(_ context.Context, slug string, u CategoryUpdate) (*models.Category, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	c := m.d.findCategory(slug)
+	if c == nil {
+		return nil, nil
+	}
+	if u.Name != nil {
+		c.Name = *u.Name
+	}
+	if u.Icon != nil {
+		c.Icon = *u.Icon
+	}
+	if u.SortOrder != nil {
+		c.SortOrder = *u.SortOrder
+	}
+	c.UpdatedAt = time.Now().UTC()
+	m.d.categories[c.ID] = clone(*c)
+	return ptr(*c), nil
+}
+
+func (m *MemoryCategoryRepository) Delete(_ context.Context, slug string) (bool, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	c := m.d.findCategory(slug)
+	if c == nil {
+		return false, nil
+	}
+	delete(m.d.categories, c.ID)
+	return true, nil
+}
+
+func (m *MemoryCategoryRepository) Count(_ context.Context) (int64, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	return int64(len(m.d.categories)), nil
+}
@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryChatRepository is the in-memory ChatRepository.
+type MemoryChatRepository struct{ d *memoryDB }
+
+func (m *MemoryChatRepository) Insert(_ context.Context, msg *models.ChatMessage) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	if msg.ID.IsZero() {
+		msg.ID = primitive.NewObjectID()
+	}
+	if _, ok := m.d.chatMessages[msg.ID]; ok {
+		return duplicateKeyError("chat_messages", "_id")
+	}
+	m.d.chatMessages[msg.ID] = clone(*msg)
+	return nil
+}
+
+func (m *MemoryChatRepository) List(_ context.Context, channel string, after *TimeCursor, limit int) ([]models.ChatMessage, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	msgs := where(m.d.chatMessages, func(msg *models.ChatMessage) bool {
+		return msg.Channel == channel && !msg.Deleted && after.after(msg.CreatedAt, msg.ID)
+	})
+	sortBy(msgs, func(a, b *models.ChatMessage) bool { return newer(a.CreatedAt, a.ID, b.CreatedAt, b.ID) })
+	return firstN(msgs, limit), nil
+}
+
+func (m *MemoryChatRepository) LastMessageAt(_ context.Context, channel string, userID primitive.ObjectID) (time.Time, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	var last time.Time
+	for _, msg := range m.d.chatMessages {
+		if msg.Channel == channel && msg.UserID == userID && msg.CreatedAt.After(last) {
+			last = msg.CreatedAt
+		}
+	}
+	return last, nil
+}
+
+func (m *MemoryChatRepository) MarkDeleted(_ context.Context, channel string, id, by primitive.ObjectID) (*models.ChatMessage, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	msg, ok := m.d.chatMessages[id]
+	if !ok || msg.Channel != channel {
+		return nil, nil
+	}
+	msg.Deleted, msg.DeletedBy = true, by
+	m.d.chatMessages[id] = clone(msg)
+	return ptr(msg), nil
+}
+
+func (m *MemoryChatRepository) MarkUserMessagesDeleted(_ context.Context, channel string, userID, by primitive.ObjectID) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	for id, msg := range m.d.chatMessages {
+		if msg.Channel == channel && msg.UserID == userID && !msg.Deleted {
+			msg.Deleted, msg.DeletedBy = true, by
+			m.d.chatMessages[id] = clone(msg)
+		}
+	}
+	return nil
+}
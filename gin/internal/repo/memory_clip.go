@@ -0,0 +1,61 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryClipRepository is the in-memory ClipRepository.
+type MemoryClipRepository struct{ d *memoryDB }
+
+func (m *MemoryClipRepository) Create(_ context.Context, c *models.Clip) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	if c.ID.IsZero() {
+		c.ID = primitive.NewObjectID()
+	}
+	if _, ok := m.d.clips[c.ID]; ok {
+		return duplicateKeyError("clips", "_id")
+	}
+	m.d.clips[c.ID] = clone(*c)
+	return nil
+}
+
+func (m *MemoryClipRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.Clip, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	c, ok := m.d.clips[id]
+	if !ok {
+		return nil, nil
+	}
+	return ptr(c), nil
+}
+
+func (m *MemoryClipRepository) Delete(_ context.Context, id primitive.ObjectID) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	delete(m.d.clips, id)
+	return nil
+}
+
+func (m *MemoryClipRepository) IncViews(_ context.Context, id primitive.ObjectID) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	if c, ok := m.d.clips[id]; ok {
+		c.ViewCount++
+		m.d.clips[id] = c
+	}
+	return nil
+}
+
+func (m *MemoryClipRepository) ListByChannel(_ context.Context, channelID primitive.ObjectID, hidden []string, after *TimeCursor, limit int) ([]models.Clip, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	clips := where(m.d.clips, func(c *models.Clip) bool {
+		return c.ChannelID == channelID && !containsString(hidden, c.Visibility) && after.after(c.CreatedAt, c.ID)
+	})
+	sortBy(clips, func(a, b *models.Clip) bool { return newer(a.CreatedAt, a.ID, b.CreatedAt, b.ID) })
+	return firstN(clips, limit), nil
+}
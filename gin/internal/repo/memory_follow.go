@@ -0,0 +1,135 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryFollowRepository is the in-memory FollowRepository.
+type MemoryFollowRepository struct{ d *memoryDB }
+
+func (m *MemoryFollowRepository) Create(_ context.Context, f *models.Follow) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	if m.d.findFollow(f.FollowerID, f.ChannelID) != nil {
+		return duplicateKeyError("follows", "follower_channel_unique")
+	}
+	if f.ID.IsZero() {
+		f.ID = primitive.NewObjectID()
+	}
+	if _, ok := m.d.follows[f.ID]; ok {
+		return duplicateKeyError("follows", "_id")
+	}
+	m.d.follows[f.ID] = clone(*f)
+	m.d.incFollowCounts(f.FollowerID, f.ChannelID, 1)
+	return nil
+}
+
+func (m *MemoryFollowRepository) Delete(_ context.Context, followerID, channelID primitive.ObjectID) (bool, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	f := m.d.findFollow(followerID, channelID)
+	if f == nil {
+		return false, nil
+	}
+	delete(m.d.follows, f.ID)
+	m.d.incFollowCounts(followerID, channelID, -1)
+	return true, nil
+}
+
+func (d *memoryDB) findFollow(followerID, channelID primitive.ObjectID) *models.Follow {
+	for _, f := range d.follows {
+		if f.FollowerID == followerID && f.ChannelID == channelID {
+			return ptr(f)
+		}
+	}
+	return nil
+}
+
+// incFollowCounts is the in-memory incFollowCounts.
+func (d *memoryDB) incFollowCounts(followerID, channelID primitive.ObjectID, delta int64) {
+	if u, ok := d.users[followerID]; ok {
+		u.FollowingCount += delta
+		d.users[followerID] = u
+	}
+	if u, ok := d.users[channelID]; ok {
+		u.FollowerCount += delta
+		d.users[channelID] = u
+	}
+}
+
+func (m *MemoryFollowRepository) IsFollowing(_ context.Context, followerID, channelID primitive.ObjectID) (bool, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	return m.d.findFollow(followerID, channelID) != nil, nil
+}
+
+func (m *MemoryFollowRepository) Find(_ context.Context, followerID, channelID primitive.ObjectID) (*models.Follow, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	return m.d.findFollow(followerID, channelID), nil
+}
+
+func (m *MemoryFollowRepository) ListFollowers(_ context.Context, channelID primitive.ObjectID, after *TimeCursor, limit int) ([]FollowEntry, error) {
+	return m.list(func(f *models.Follow) (primitive.ObjectID, primitive.ObjectID) {
+		return f.ChannelID, f.FollowerID
+	}, channelID, after, limit), nil
+}
+
+func (m *MemoryFollowRepository) ListFollowing(_ context.Context, followerID primitive.ObjectID, after *TimeCursor, limit int) ([]FollowEntry, error) {
+	return m.list(func(f *models.Follow) (primitive.ObjectID, primitive.ObjectID) {
+		return f.FollowerID, f.ChannelID
+	}, followerID, after, limit), nil
+}
+
+// list is the in-memory listFollows; ends returns the matched and the
+// other end of an edge.
+func (m *MemoryFollowRepository) list(ends func(*models.Follow) (primitive.ObjectID, primitive.ObjectID), id primitive.ObjectID, after *TimeCursor, limit int) []FollowEntry {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	edges := where(m.d.follows, func(f *models.Follow) bool {
+		matched, _ := ends(f)
+		return matched == id && after.after(f.CreatedAt, f.ID)
+	})
+	sortBy(edges, func(a, b *models.Follow) bool { return newer(a.CreatedAt, a.ID, b.CreatedAt, b.ID) })
+	entries := []FollowEntry{}
+	for _, f := range firstN(edges, limit) {
+		_, other := ends(&f)
+		if u, ok := m.d.users[other]; ok {
+			entries = append(entries, FollowEntry{FollowID: f.ID, FollowedAt: f.CreatedAt, User: u.Public()})
+		}
+	}
+	return entries
+}
+
+func (m *MemoryFollowRepository) FollowedChannelIDs(_ context.Context, followerID primitive.ObjectID, limit int) ([]primitive.ObjectID, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	edges := where(m.d.follows, func(f *models.Follow) bool { return f.FollowerID == followerID })
+	sortBy(edges, func(a, b *models.Follow) bool { return newer(a.CreatedAt, a.ID, b.CreatedAt, b.ID) })
+	ids := []primitive.ObjectID{}
+	for _, f := range firstN(edges, limit) {
+		ids = append(ids, f.ChannelID)
+	}
+	return ids, nil
+}
+
+func (m *MemoryFollowRepository) FollowerIDsAfter(_ context.Context, channelID, afterEdge primitive.ObjectID, limit int) ([]primitive.ObjectID, primitive.ObjectID, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	edges := where(m.d.follows, func(f *models.Follow) bool {
+		return f.ChannelID == channelID && (afterEdge.IsZero() || idLess(afterEdge, f.ID))
+	})
+	sortBy(edges, func(a, b *models.Follow) bool { return idLess(a.ID, b.ID) })
+	edges = firstN(edges, limit)
+	ids := make([]primitive.ObjectID, len(edges))
+	for i, f := range edges {
+		ids[i] = f.FollowerID
+	}
+	if len(edges) > 0 {
+		afterEdge = edges[len(edges)-1].ID
+	}
+	return ids, afterEdge, nil
+}
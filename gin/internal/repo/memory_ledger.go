@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryLedgerRepository is the in-memory LedgerRepository. It keeps no
+// ledger accounts: their Seq only serializes MongoDB transactions, which
+// the lock does here.
+type MemoryLedgerRepository struct{ d *memoryDB }
+
+func (m *MemoryLedgerRepository) CreateTransfer(_ context.Context, t *models.LedgerTransfer) (*models.LedgerTransfer, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	return m.d.createTransfer(t)
+}
+
+// createTransfer is CreateTransfer under the caller's write lock.
+func (d *memoryDB) createTransfer(t *models.LedgerTransfer) (*models.LedgerTransfer, error) {
+	if prev := d.findTransferByKey(t.IdempotencyKey); prev != nil {
+		return prev, nil
+	}
+	if !strings.HasPrefix(t.From, models.LedgerAccountSystem+":") && d.balance(t.From) < t.Amount {
+		return nil, ErrInsufficientFunds
+	}
+	t.ID = primitive.NewObjectID()
+	d.transfers[t.ID] = clone(*t)
+	for _, e := range []models.LedgerEntry{
+		{TransferID: t.ID, Account: t.From, Counterparty: t.To, Kind: t.Kind, Amount: -t.Amount, Memo: t.Memo, CreatedAt: t.CreatedAt},
+		{TransferID: t.ID, Account: t.To, Counterparty: t.From, Kind: t.Kind, Amount: t.Amount, Memo: t.Memo, CreatedAt: t.CreatedAt},
+	} {
+		e.ID = primitive.NewObjectID()
+		d.ledgerEntries[e.ID] = clone(e)
+	}
+	return nil, nil
+}
+
+func (d *memoryDB) findTransferByKey(key string) *models.LedgerTransfer {
+	for _, t := range d.transfers {
+		if t.IdempotencyKey == key {
+			return ptr(t)
+		}
+	}
+	return nil
+}
+
+func (d *memoryDB) balance(account string) int64 {
+	var sum int64
+	for _, e := range d.ledgerEntries {
+		if e.Account == account {
+			sum += e.Amount
+		}
+	}
+	return sum
+}
+
+func (m *MemoryLedgerRepository) Balance(_ context.Context, account string) (int64, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	return m.d.balance(account), nil
+}
+
+func (m *MemoryLedgerRepository) ListEntries(_ context.Context, account string, after *TimeCursor, limit int) ([]models.LedgerEntry, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	entries := where(m.d.ledgerEntries, func(e *models.LedgerEntry) bool {
+		return e.Account == account && after.after(e.CreatedAt, e.ID)
+	})
+	sortBy(entries, func(a, b *models.LedgerEntry) bool { return newer(a.CreatedAt, a.ID, b.CreatedAt, b.ID) })
+	return firstN(entries, limit), nil
+}
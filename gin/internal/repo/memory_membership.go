@@ -0,0 +1,210 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryMembershipRepository is the in-memory MembershipRepository.
+type MemoryMembershipRepository struct{ d *memoryDB }
+
+func (m *MemoryMembershipRepository) CreateTier(_ context.Context, t *models.MembershipTier) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	if t.ID.IsZero() {
+		t.ID = primitive.NewObjectID()
+	}
+	if _, ok := m.d.tiers[t.ID]; ok {
+		return duplicateKeyError("membership_tiers", "_id")
+	}
+	m.d.tiers[t.ID] = clone(*t)
+	return nil
+}
+
+func (m *MemoryMembershipRepository) UpdateTier(_ context.Context, channelID, id primitive.ObjectID, u MembershipTierUpdate) (*models.MembershipTier, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	t, ok := m.d.tiers[id]
+	if !ok || t.ChannelID != channelID {
+		return nil, nil
+	}
+	if u.Name != nil {
+		t.Name = *u.Name
+	}
+	if u.Perks != nil {
+		t.Perks = *u.Perks
+	}
+	if u.Price != nil {
+		t.Price = *u.Price
+	}
+	if u.DurationDays != nil {
+		t.DurationDays = *u.DurationDays
+	}
+	if u.Archived != nil {
+		t.Archived = *u.Archived
+	}
+	t.UpdatedAt = time.Now().UTC()
+	m.d.tiers[id] = clone(t)
+	return ptr(t), nil
+}
+
+func (m *MemoryMembershipRepository) FindTier(_ context.Context, id primitive.ObjectID) (*models.MembershipTier, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	t, ok := m.d.tiers[id]
+	if !ok {
+		return nil, nil
+	}
+	return ptr(t), nil
+}
+
+func (m *MemoryMembershipRepository) ListTiers(_ context.Context, channelID primitive.ObjectID, includeArchived bool) ([]models.MembershipTier, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	tiers := where(m.d.tiers, func(t *models.MembershipTier) bool {
+		return t.ChannelID == channelID && (includeArchived || !t.Archived)
+	})
+	sortBy(tiers, func(a, b *models.MembershipTier) bool {
+		if a.Price != b.Price {
+			return a.Price < b.Price
+		}
+		return idLess(a.ID, b.ID)
+	})
+	return tiers, nil
+}
+
+func (m *MemoryMembershipRepository) CountTiers(_ context.Context, channelID primitive.ObjectID) (int64, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	var n int64
+	for _, t := range m.d.tiers {
+		if t.ChannelID == channelID && !t.Archived {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (d *memoryDB) findMembership(channelID, userID primitive.ObjectID) *models.Membership {
+	for _, mb := range d.memberships {
+		if mb.ChannelID == channelID && mb.UserID == userID {
+			return ptr(mb)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryMembershipRepository) Find(_ context.Context, channelID, userID primitive.ObjectID) (*models.Membership, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	return m.d.findMembership(channelID, userID), nil
+}
+
+func (m *MemoryMembershipRepository) IsMember(_ context.Context, channelID, userID primitive.ObjectID, now time.Time) (bool, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	mb := m.d.findMembership(channelID, userID)
+	return mb != nil && mb.Status == models.MembershipActive && mb.ExpiresAt.After(ms(now)), nil
+}
+
+func (m *MemoryMembershipRepository) Start(_ context.Context, mb *models.Membership) (bool, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	stored := models.Membership{ID: primitive.NewObjectID(), ChannelID: mb.ChannelID, UserID: mb.UserID}
+	if old := m.d.findMembership(mb.ChannelID, mb.UserID); old != nil {
+		if old.Status == models.MembershipActive && old.ExpiresAt.After(ms(mb.StartedAt)) {
+			return false, nil
+		}
+		stored = *old
+	}
+	stored.ChannelUsername, stored.Username = mb.ChannelUsername, mb.Username
+	stored.TierID, stored.TierName = mb.TierID, mb.TierName
+	stored.Status, stored.AutoRenew = mb.Status, mb.AutoRenew
+	stored.StartedAt, stored.ExpiresAt, stored.UpdatedAt = mb.StartedAt, mb.ExpiresAt, mb.UpdatedAt
+	stored.CanceledAt = nil
+	stored = clone(stored)
+	m.d.memberships[stored.ID] = stored
+	*mb = clone(stored)
+	return true, nil
+}
+
+func (m *MemoryMembershipRepository) SetAutoRenew(_ context.Context, id primitive.ObjectID, autoRenew bool, now time.Time) (*models.Membership, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	mb, ok := m.d.memberships[id]
+	if !ok {
+		return nil, nil
+	}
+	mb.AutoRenew, mb.UpdatedAt, mb.CanceledAt = autoRenew, now, nil
+	if !autoRenew {
+		mb.CanceledAt = &now
+	}
+	m.d.memberships[id] = clone(mb)
+	return ptr(mb), nil
+}
+
+func (m *MemoryMembershipRepository) ListByUser(_ context.Context, userID primitive.ObjectID) ([]models.Membership, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	out := where(m.d.memberships, func(mb *models.Membership) bool { return mb.UserID == userID })
+	sortBy(out, func(a, b *models.Membership) bool {
+		if a.Status != b.Status {
+			return a.Status < b.Status
+		}
+		return a.ExpiresAt.After(b.ExpiresAt)
+	})
+	return out, nil
+}
+
+func (m *MemoryMembershipRepository) ListChannelMembers(_ context.Context, channelID primitive.ObjectID, now time.Time, after *TimeCursor, limit int) ([]models.Membership, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	now = ms(now)
+	out := where(m.d.memberships, func(mb *models.Membership) bool {
+		return mb.ChannelID == channelID && mb.Status == models.MembershipActive && mb.ExpiresAt.After(now) &&
+			after.after(mb.StartedAt, mb.ID)
+	})
+	sortBy(out, func(a, b *models.Membership) bool { return newer(a.StartedAt, a.ID, b.StartedAt, b.ID) })
+	return firstN(out, limit), nil
+}
+
+func (m *MemoryMembershipRepository) ListDue(_ context.Context, now time.Time, limit int) ([]models.Membership, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	now = ms(now)
+	out := where(m.d.memberships, func(mb *models.Membership) bool {
+		return mb.Status == models.MembershipActive && !mb.ExpiresAt.After(now)
+	})
+	sortBy(out, func(a, b *models.Membership) bool { return older(a.ExpiresAt, a.ID, b.ExpiresAt, b.ID) })
+	return firstN(out, limit), nil
+}
+
+func (m *MemoryMembershipRepository) Renew(_ context.Context, id primitive.ObjectID, prevExpiry, expiresAt time.Time, tierName string) (bool, error) {
+	return m.updateDue(id, prevExpiry, func(mb *models.Membership) {
+		mb.ExpiresAt, mb.TierName = expiresAt, tierName
+	}), nil
+}
+
+func (m *MemoryMembershipRepository) Expire(_ context.Context, id primitive.ObjectID, prevExpiry time.Time) (bool, error) {
+	return m.updateDue(id, prevExpiry, func(mb *models.Membership) {
+		mb.Status, mb.AutoRenew = models.MembershipExpired, false
+	}), nil
+}
+
+// updateDue applies apply to the membership if it is still active with
+// the expiry prevExpiry, and reports whether it did.
+func (m *MemoryMembershipRepository) updateDue(id primitive.ObjectID, prevExpiry time.Time, apply func(*models.Membership)) bool {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	mb, ok := m.d.memberships[id]
+	if !ok || mb.Status != models.MembershipActive || !mb.ExpiresAt.Equal(ms(prevExpiry)) {
+		return false
+	}
+	apply(&mb)
+	mb.UpdatedAt = time.Now().UTC()
+	m.d.memberships[id] = clone(mb)
+	return true
+}
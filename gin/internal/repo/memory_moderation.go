@@ -0,0 +1,160 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryModerationRepository is the in-memory ModerationRepository.
+type MemoryModerationRepository struct{ d *memoryDB }
+
+func (m *MemoryModerationRepository) AddModerator(_ context.Context, mod *models.ChannelModerator) (bool, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	if m.d.findModerator(mod.ChannelID, mod.UserID) != nil {
+		return false, nil
+	}
+	// Like the upsert it mimics, this leaves mod.ID alone.
+	stored := clone(*mod)
+	if stored.ID.IsZero() {
+		stored.ID = primitive.NewObjectID()
+	}
+	m.d.moderators[stored.ID] = stored
+	return true, nil
+}
+
+func (d *memoryDB) findModerator(channelID, userID primitive.ObjectID) *models.ChannelModerator {
+	for _, mod := range d.moderators {
+		if mod.ChannelID == channelID && mod.UserID == userID {
+			return ptr(mod)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryModerationRepository) RemoveModerator(_ context.Context, channelID, userID primitive.ObjectID) (bool, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	mod := m.d.findModerator(channelID, userID)
+	if mod == nil {
+		return false, nil
+	}
+	delete(m.d.moderators, mod.ID)
+	return true, nil
+}
+
+func (m *MemoryModerationRepository) IsModerator(_ context.Context, channelID, userID primitive.ObjectID) (bool, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	return m.d.findModerator(channelID, userID) != nil, nil
+}
+
+func (m *MemoryModerationRepository) ListModerators(_ context.Context, channelID primitive.ObjectID) ([]models.ChannelModerator, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	mods := where(m.d.moderators, func(mod *models.ChannelModerator) bool { return mod.ChannelID == channelID })
+	sortBy(mods, func(a, b *models.ChannelModerator) bool { return older(a.CreatedAt, a.ID, b.CreatedAt, b.ID) })
+	return mods, nil
+}
+
+func (m *MemoryModerationRepository) UpsertBan(_ context.Context, b *models.ChannelBan) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	b.ID = primitive.NilObjectID
+	stored := clone(*b)
+	if old := m.d.findBan(b.ChannelID, b.UserID); old != nil {
+		// A replacement keeps the _id of the document it replaces.
+		stored.ID = old.ID
+	} else {
+		stored.ID = primitive.NewObjectID()
+		b.ID = stored.ID
+	}
+	m.d.bans[stored.ID] = stored
+	return nil
+}
+
+func (d *memoryDB) findBan(channelID, userID primitive.ObjectID) *models.ChannelBan {
+	for _, b := range d.bans {
+		if b.ChannelID == channelID && b.UserID == userID {
+			return ptr(b)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryModerationRepository) DeleteBan(_ context.Context, channelID, userID primitive.ObjectID) (bool, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	b := m.d.findBan(channelID, userID)
+	if b == nil {
+		return false, nil
+	}
+	delete(m.d.bans, b.ID)
+	return true, nil
+}
+
+// banActive reports whether b is a ban, or a timeout that has not run out by now.
+func banActive(b *models.ChannelBan, now time.Time) bool {
+	return b.ExpiresAt == nil || b.ExpiresAt.After(ms(now))
+}
+
+func (m *MemoryModerationRepository) FindActiveBan(_ context.Context, channelID, userID primitive.ObjectID, now time.Time) (*models.ChannelBan, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	b := m.d.findBan(channelID, userID)
+	if b == nil || !banActive(b, now) {
+		return nil, nil
+	}
+	return b, nil
+}
+
+func (m *MemoryModerationRepository) ListActiveBans(_ context.Context, channelID primitive.ObjectID, now time.Time) ([]models.ChannelBan, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	bans := where(m.d.bans, func(b *models.ChannelBan) bool { return b.ChannelID == channelID && banActive(b, now) })
+	sortBy(bans, func(a, b *models.ChannelBan) bool { return newer(a.CreatedAt, a.ID, b.CreatedAt, b.ID) })
+	return bans, nil
+}
+
+func (m *MemoryModerationRepository) GetChatSettings(_ context.Context, channelID primitive.ObjectID) (*models.ChatSettings, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	s, ok := m.d.chatSettings[channelID]
+	if !ok {
+		return nil, nil
+	}
+	return ptr(s), nil
+}
+
+func (m *MemoryModerationRepository) SaveChatSettings(_ context.Context, s *models.ChatSettings) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	m.d.chatSettings[s.ChannelID] = clone(*s)
+	return nil
+}
+
+func (m *MemoryModerationRepository) InsertAction(_ context.Context, a *models.ModerationAction) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	if a.ID.IsZero() {
+		a.ID = primitive.NewObjectID()
+	}
+	if _, ok := m.d.moderationLog[a.ID]; ok {
+		return duplicateKeyError("moderation_log", "_id")
+	}
+	m.d.moderationLog[a.ID] = clone(*a)
+	return nil
+}
+
+func (m *MemoryModerationRepository) ListActions(_ context.Context, channelID primitive.ObjectID, action string, after *TimeCursor, limit int) ([]models.ModerationAction, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	actions := where(m.d.moderationLog, func(a *models.ModerationAction) bool {
+		return a.ChannelID == channelID && (action == "" || a.Action == action) && after.after(a.CreatedAt, a.ID)
+	})
+	sortBy(actions, func(a, b *models.ModerationAction) bool { return newer(a.CreatedAt, a.ID, b.CreatedAt, b.ID) })
+	return firstN(actions, limit), nil
+}
@@ -0,0 +1,66 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryNotificationRepository is the in-memory NotificationRepository.
+type MemoryNotificationRepository struct{ d *memoryDB }
+
+func (m *MemoryNotificationRepository) Insert(_ context.Context, ns []*models.Notification) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	for _, n := range ns {
+		if n.ID.IsZero() {
+			n.ID = primitive.NewObjectID()
+		}
+		if _, ok := m.d.notifications[n.ID]; ok {
+			// Unordered, like the InsertMany it mimics: the others go in.
+			continue
+		}
+		m.d.notifications[n.ID] = clone(*n)
+	}
+	return nil
+}
+
+func (m *MemoryNotificationRepository) List(_ context.Context, userID primitive.ObjectID, unreadOnly bool, after *TimeCursor, limit int) ([]models.Notification, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	ns := where(m.d.notifications, func(n *models.Notification) bool {
+		return n.UserID == userID && !(unreadOnly && n.Read) && after.after(n.CreatedAt, n.ID)
+	})
+	sortBy(ns, func(a, b *models.Notification) bool { return newer(a.CreatedAt, a.ID, b.CreatedAt, b.ID) })
+	return firstN(ns, limit), nil
+}
+
+func (m *MemoryNotificationRepository) CountUnread(_ context.Context, userID primitive.ObjectID) (int64, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	var n int64
+	for _, x := range m.d.notifications {
+		if x.UserID == userID && !x.Read {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MemoryNotificationRepository) MarkRead(_ context.Context, userID primitive.ObjectID, ids []primitive.ObjectID) (int64, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	now := time.Now().UTC()
+	var changed int64
+	for id, n := range m.d.notifications {
+		if n.UserID != userID || n.Read || (len(ids) > 0 && !containsID(ids, id)) {
+			continue
+		}
+		n.Read, n.ReadAt = true, &now
+		m.d.notifications[id] = clone(n)
+		changed++
+	}
+	return changed, nil
+}
@@ -0,0 +1,107 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryPollRepository is the in-memory PollRepository.
+type MemoryPollRepository struct{ d *memoryDB }
+
+func (m *MemoryPollRepository) Create(_ context.Context, p *models.Poll) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	if p.ID.IsZero() {
+		p.ID = primitive.NewObjectID()
+	}
+	if _, ok := m.d.polls[p.ID]; ok {
+		return duplicateKeyError("polls", "_id")
+	}
+	m.d.polls[p.ID] = clone(*p)
+	return nil
+}
+
+func (m *MemoryPollRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.Poll, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	p, ok := m.d.polls[id]
+	if !ok {
+		return nil, nil
+	}
+	return ptr(p), nil
+}
+
+func (m *MemoryPollRepository) FindOpen(_ context.Context, channelID primitive.ObjectID, now time.Time) (*models.Poll, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	now = ms(now)
+	return findFirst(m.d.polls, func(p *models.Poll) bool {
+		return p.ChannelID == channelID && p.Status == models.PollOpen && p.EndsAt.After(now)
+	}, func(a, b *models.Poll) bool { return idLess(a.ID, b.ID) }), nil
+}
+
+func (m *MemoryPollRepository) Close(_ context.Context, id primitive.ObjectID, now time.Time) (*models.Poll, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	p, ok := m.d.polls[id]
+	if !ok || p.Status != models.PollOpen {
+		return nil, nil
+	}
+	p.Status, p.ClosedAt = models.PollClosed, &now
+	m.d.polls[id] = clone(p)
+	return ptr(p), nil
+}
+
+func (m *MemoryPollRepository) ListOpenIDs(_ context.Context, channelID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	ids := []primitive.ObjectID{}
+	for _, p := range m.d.polls {
+		if p.ChannelID == channelID && p.Status == models.PollOpen {
+			ids = append(ids, p.ID)
+		}
+	}
+	sortBy(ids, func(a, b *primitive.ObjectID) bool { return idLess(*a, *b) })
+	return ids, nil
+}
+
+func (m *MemoryPollRepository) InsertVote(_ context.Context, v *models.PollVote) (bool, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	for _, other := range m.d.pollVotes {
+		if other.PollID == v.PollID && other.UserID == v.UserID {
+			return false, nil
+		}
+	}
+	v.ID = primitive.NewObjectID()
+	m.d.pollVotes[v.ID] = clone(*v)
+	return true, nil
+}
+
+func (m *MemoryPollRepository) CountVote(_ context.Context, id primitive.ObjectID, option int) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	p, ok := m.d.polls[id]
+	if !ok {
+		return nil
+	}
+	if option < 0 || option >= len(p.Options) {
+		return fmt.Errorf("repo: poll %s has no option %d", id.Hex(), option)
+	}
+	p.Options[option].Votes++
+	p.TotalVotes++
+	m.d.polls[id] = clone(p)
+	return nil
+}
+
+func (m *MemoryPollRepository) ListByBroadcast(_ context.Context, broadcastID primitive.ObjectID) ([]models.Poll, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	polls := where(m.d.polls, func(p *models.Poll) bool { return p.BroadcastID == broadcastID })
+	sortBy(polls, func(a, b *models.Poll) bool { return older(a.CreatedAt, a.ID, b.CreatedAt, b.ID) })
+	return polls, nil
+}
@@ -0,0 +1,161 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryPredictionRepository is the in-memory PredictionRepository.
+type MemoryPredictionRepository struct{ d *memoryDB }
+
+func (m *MemoryPredictionRepository) Create(_ context.Context, p *models.Prediction) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	if p.ID.IsZero() {
+		p.ID = primitive.NewObjectID()
+	}
+	if _, ok := m.d.predictions[p.ID]; ok {
+		return duplicateKeyError("predictions", "_id")
+	}
+	m.d.predictions[p.ID] = clone(*p)
+	return nil
+}
+
+func (m *MemoryPredictionRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.Prediction, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	p, ok := m.d.predictions[id]
+	if !ok {
+		return nil, nil
+	}
+	return ptr(p), nil
+}
+
+func (m *MemoryPredictionRepository) FindActive(_ context.Context, channelID primitive.ObjectID) (*models.Prediction, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	return findFirst(m.d.predictions, func(p *models.Prediction) bool {
+		return p.ChannelID == channelID && (p.Status == models.PredictionOpen || p.Status == models.PredictionLocked)
+	}, func(a, b *models.Prediction) bool { return idLess(a.ID, b.ID) }), nil
+}
+
+func (m *MemoryPredictionRepository) Lock(_ context.Context, id primitive.ObjectID, now time.Time) (*models.Prediction, error) {
+	return m.update(id, func(p *models.Prediction) bool {
+		if p.Status != models.PredictionOpen {
+			return false
+		}
+		p.Status, p.LocksAt = models.PredictionLocked, now
+		return true
+	}), nil
+}
+
+func (m *MemoryPredictionRepository) End(_ context.Context, id primitive.ObjectID, winning *int, by primitive.ObjectID, now time.Time) (*models.Prediction, error) {
+	return m.update(id, func(p *models.Prediction) bool {
+		if p.Status != models.PredictionOpen && p.Status != models.PredictionLocked {
+			return false
+		}
+		p.Status, p.EndedBy, p.EndedAt = models.PredictionCanceled, by, &now
+		if winning != nil {
+			w := *winning
+			p.Status, p.WinningOutcome = models.PredictionResolved, &w
+		}
+		return true
+	}), nil
+}
+
+func (m *MemoryPredictionRepository) MarkSettled(_ context.Context, id primitive.ObjectID) error {
+	m.update(id, func(p *models.Prediction) bool {
+		p.Settled = true
+		return true
+	})
+	return nil
+}
+
+// update applies apply to the prediction and returns the result, or nil
+// if there is no such prediction or apply declines to change it.
+func (m *MemoryPredictionRepository) update(id primitive.ObjectID, apply func(*models.Prediction) bool) *models.Prediction {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	p, ok := m.d.predictions[id]
+	if !ok || !apply(&p) {
+		return nil
+	}
+	m.d.predictions[id] = clone(p)
+	return ptr(p)
+}
+
+func (m *MemoryPredictionRepository) ListUnsettled(_ context.Context, channelID primitive.ObjectID) ([]models.Prediction, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	out := where(m.d.predictions, func(p *models.Prediction) bool {
+		return p.ChannelID == channelID && p.Ended() && !p.Settled
+	})
+	sortBy(out, func(a, b *models.Prediction) bool { return idLess(a.ID, b.ID) })
+	return out, nil
+}
+
+func (m *MemoryPredictionRepository) ListByBroadcast(_ context.Context, broadcastID primitive.ObjectID) ([]models.Prediction, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	out := where(m.d.predictions, func(p *models.Prediction) bool { return p.BroadcastID == broadcastID })
+	sortBy(out, func(a, b *models.Prediction) bool { return older(a.CreatedAt, a.ID, b.CreatedAt, b.ID) })
+	return out, nil
+}
+
+// PlaceStake checks everything that could abort the MongoDB transaction
+// before it writes anything, so a failed stake leaves no trace.
+func (m *MemoryPredictionRepository) PlaceStake(_ context.Context, s *models.PredictionStake, t *models.LedgerTransfer, now time.Time) (bool, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	p, ok := m.d.predictions[s.PredictionID]
+	if !ok || p.Status != models.PredictionOpen || !p.LocksAt.After(ms(now)) {
+		return false, ErrPredictionNotOpen
+	}
+	for _, other := range m.d.stakes {
+		if other.PredictionID == s.PredictionID && other.UserID == s.UserID {
+			return false, nil
+		}
+	}
+	if m.d.findTransferByKey(t.IdempotencyKey) != nil {
+		return false, nil
+	}
+	if !strings.HasPrefix(t.From, models.LedgerAccountSystem+":") && m.d.balance(t.From) < t.Amount {
+		return false, ErrInsufficientFunds
+	}
+	if s.Outcome < 0 || s.Outcome >= len(p.Outcomes) {
+		return false, fmt.Errorf("repo: prediction %s has no outcome %d", p.ID.Hex(), s.Outcome)
+	}
+
+	p.Outcomes[s.Outcome].Users++
+	p.Outcomes[s.Outcome].Points += s.Amount
+	m.d.predictions[p.ID] = clone(p)
+	s.ID = primitive.NewObjectID()
+	m.d.stakes[s.ID] = clone(*s)
+	if _, err := m.d.createTransfer(t); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *MemoryPredictionRepository) ListStakes(_ context.Context, predictionID primitive.ObjectID) ([]models.PredictionStake, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	stakes := where(m.d.stakes, func(s *models.PredictionStake) bool { return s.PredictionID == predictionID })
+	sortBy(stakes, func(a, b *models.PredictionStake) bool { return idLess(a.ID, b.ID) })
+	return stakes, nil
+}
+
+func (m *MemoryPredictionRepository) SetStakePayout(_ context.Context, id primitive.ObjectID, payout int64) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	if s, ok := m.d.stakes[id]; ok {
+		s.Payout = payout
+		m.d.stakes[id] = s
+	}
+	return nil
+}
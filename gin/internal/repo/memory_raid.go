@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryRaidRepository is the in-memory RaidRepository.
+type MemoryRaidRepository struct{ d *memoryDB }
+
+func (d *memoryDB) findPendingRaid(fromID primitive.ObjectID) *models.Raid {
+	for _, r := range d.raids {
+		if r.FromID == fromID && r.Status == models.RaidPending {
+			return ptr(r)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryRaidRepository) SetPending(_ context.Context, r *models.Raid) (*models.Raid, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	stored := m.d.findPendingRaid(r.FromID)
+	if stored == nil {
+		stored = &models.Raid{ID: primitive.NewObjectID(), FromID: r.FromID, Status: models.RaidPending}
+	}
+	stored.FromUsername, stored.ToID, stored.ToUsername = r.FromUsername, r.ToID, r.ToUsername
+	stored.BroadcastID, stored.CreatedAt = r.BroadcastID, r.CreatedAt
+	m.d.raids[stored.ID] = clone(*stored)
+	return ptr(*stored), nil
+}
+
+func (m *MemoryRaidRepository) FindPending(_ context.Context, fromID primitive.ObjectID) (*models.Raid, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	return m.d.findPendingRaid(fromID), nil
+}
+
+func (m *MemoryRaidRepository) CancelPending(_ context.Context, fromID primitive.ObjectID) (bool, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	r := m.d.findPendingRaid(fromID)
+	if r == nil {
+		return false, nil
+	}
+	r.Status = models.RaidCanceled
+	m.d.raids[r.ID] = *r
+	return true, nil
+}
+
+func (m *MemoryRaidRepository) Launch(_ context.Context, id primitive.ObjectID, viewers int, now time.Time) (bool, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	r, ok := m.d.raids[id]
+	if !ok || r.Status != models.RaidPending {
+		return false, nil
+	}
+	r.Status, r.Viewers, r.LaunchedAt = models.RaidCompleted, viewers, &now
+	m.d.raids[id] = clone(r)
+	return true, nil
+}
+
+func (m *MemoryRaidRepository) GetSettings(_ context.Context, channelID primitive.ObjectID) (*models.RaidSettings, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	s, ok := m.d.raidSettings[channelID]
+	if !ok {
+		return nil, nil
+	}
+	return ptr(s), nil
+}
+
+func (m *MemoryRaidRepository) SaveSettings(_ context.Context, s *models.RaidSettings) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	m.d.raidSettings[s.ChannelID] = clone(*s)
+	return nil
+}
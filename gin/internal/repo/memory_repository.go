@@ -0,0 +1,383 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryDB holds the collections of the in-memory repositories. One lock
+// guards all of them, so every method runs as if in a transaction.
+//
+// Documents are stored and handed out as copies made by a BSON round
+// trip, so they come back the way MongoDB would return them: times cut
+// to milliseconds and in UTC, bson:"-" fields dropped, empty omitempty
+// fields unset.
+type memoryDB struct {
+	mu sync.RWMutex
+
+	users          map[primitive.ObjectID]models.User
+	sessions       map[string]models.Session
+	streamKeys     map[primitive.ObjectID]models.StreamKey
+	streams        map[primitive.ObjectID]models.Stream
+	categories     map[primitive.ObjectID]models.Category
+	follows        map[primitive.ObjectID]models.Follow
+	notifications  map[primitive.ObjectID]models.Notification
+	chatMessages   map[primitive.ObjectID]models.ChatMessage
+	moderators     map[primitive.ObjectID]models.ChannelModerator
+	bans           map[primitive.ObjectID]models.ChannelBan
+	chatSettings   map[primitive.ObjectID]models.ChatSettings // by channel ID
+	moderationLog  map[primitive.ObjectID]models.ModerationAction
+	videos         map[primitive.ObjectID]models.Video
+	clips          map[primitive.ObjectID]models.Clip
+	streamInvites  map[primitive.ObjectID]models.StreamInvite
+	restreams      map[primitive.ObjectID]models.RestreamDestination
+	viewerSessions map[primitive.ObjectID]models.ViewerSession
+	dailyStats     map[dailyStatsKey]models.DailyStats
+	broadcasts     map[primitive.ObjectID]models.Broadcast
+	transfers      map[primitive.ObjectID]models.LedgerTransfer
+	ledgerEntries  map[primitive.ObjectID]models.LedgerEntry
+	tiers          map[primitive.ObjectID]models.MembershipTier
+	memberships    map[primitive.ObjectID]models.Membership
+	polls          map[primitive.ObjectID]models.Poll
+	pollVotes      map[primitive.ObjectID]models.PollVote
+	predictions    map[primitive.ObjectID]models.Prediction
+	stakes         map[primitive.ObjectID]models.PredictionStake
+	raids          map[primitive.ObjectID]models.Raid
+	raidSettings   map[primitive.ObjectID]models.RaidSettings // by channel ID
+}
+
+type dailyStatsKey struct {
+	channelID primitive.ObjectID
+	day       string
+}
+
+func newMemoryDB() *memoryDB {
+	return &memoryDB{
+		users:          make(map[primitive.ObjectID]models.User),
+		sessions:       make(map[string]models.Session),
+		streamKeys:     make(map[primitive.ObjectID]models.StreamKey),
+		streams:        make(map[primitive.ObjectID]models.Stream),
+		categories:     make(map[primitive.ObjectID]models.Category),
+		follows:        make(map[primitive.ObjectID]models.Follow),
+		notifications:  make(map[primitive.ObjectID]models.Notification),
+		chatMessages:   make(map[primitive.ObjectID]models.ChatMessage),
+		moderators:     make(map[primitive.ObjectID]models.ChannelModerator),
+		bans:           make(map[primitive.ObjectID]models.ChannelBan),
+		chatSettings:   make(map[primitive.ObjectID]models.ChatSettings),
+		moderationLog:  make(map[primitive.ObjectID]models.ModerationAction),
+		videos:         make(map[primitive.ObjectID]models.Video),
+		clips:          make(map[primitive.ObjectID]models.Clip),
+		streamInvites:  make(map[primitive.ObjectID]models.StreamInvite),
+		restreams:      make(map[primitive.ObjectID]models.RestreamDestination),
+		viewerSessions: make(map[primitive.ObjectID]models.ViewerSession),
+		dailyStats:     make(map[dailyStatsKey]models.DailyStats),
+		broadcasts:     make(map[primitive.ObjectID]models.Broadcast),
+		transfers:      make(map[primitive.ObjectID]models.LedgerTransfer),
+		ledgerEntries:  make(map[primitive.ObjectID]models.LedgerEntry),
+		tiers:          make(map[primitive.ObjectID]models.MembershipTier),
+		memberships:    make(map[primitive.ObjectID]models.Membership),
+		polls:          make(map[primitive.ObjectID]models.Poll),
+		pollVotes:      make(map[primitive.ObjectID]models.PollVote),
+		predictions:    make(map[primitive.ObjectID]models.Prediction),
+		stakes:         make(map[primitive.ObjectID]models.PredictionStake),
+		raids:          make(map[primitive.ObjectID]models.Raid),
+		raidSettings:   make(map[primitive.ObjectID]models.RaidSettings),
+	}
+}
+
+// duplicateKeyError mimics the error MongoDB returns when a write breaks
+// a unique index, so callers checking mongo.IsDuplicateKeyError work with
+// the in-memory repositories too.
+func duplicateKeyError(coll, field string) error {
+	return mongo.WriteException{WriteErrors: mongo.WriteErrors{{
+		Code:    11000,
+		Message: fmt.Sprintf("E11000 duplicate key error collection: %s index: %s", coll, field),
+	}}}
+}
+
+// clone returns a deep copy of the document v as MongoDB would store it.
+func clone[T any](v T) T {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("repo: encoding %T: %v", v, err))
+	}
+	var out T
+	if err := bson.Unmarshal(data, &out); err != nil {
+		panic(fmt.Sprintf("repo: decoding %T: %v", v, err))
+	}
+	return out
+}
+
+// ptr returns a pointer to a copy of the document v.
+func ptr[T any](v T) *T {
+	c := clone(v)
+	return &c
+}
+
+// where returns copies of the documents of m that keep accepts, in no
+// particular order. The result is never nil.
+func where[K comparable, T any](m map[K]T, keep func(*T) bool) []T {
+	out := []T{}
+	for _, v := range m {
+		if keep(&v) {
+			out = append(out, clone(v))
+		}
+	}
+	return out
+}
+
+// findFirst returns a copy of the document of m that keep accepts and sorts
+// first by first, or nil.
+func findFirst[K comparable, T any](m map[K]T, keep func(*T) bool, first func(a, b *T) bool) *T {
+	var best *T
+	for _, v := range m {
+		if keep(&v) && (best == nil || first(&v, best)) {
+			v := v
+			best = &v
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return ptr(*best)
+}
+
+// sortBy sorts s by less.
+func sortBy[T any](s []T, less func(a, b *T) bool) {
+	sort.Slice(s, func(i, j int) bool { return less(&s[i], &s[j]) })
+}
+
+// firstN cuts s to n elements; n <= 0 means no limit, as with SetLimit.
+func firstN[T any](s []T, n int) []T {
+	if n > 0 && len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// idLess orders ObjectIDs the way MongoDB does.
+func idLess(a, b primitive.ObjectID) bool {
+	return bytes.Compare(a[:], b[:]) < 0
+}
+
+// ms cuts t to the millisecond precision of BSON dates, as the driver
+// does when it sends a time in a query.
+func ms(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Millisecond)
+}
+
+// newer orders documents by {at: -1, _id: -1}.
+func newer(atA time.Time, idA primitive.ObjectID, atB time.Time, idB primitive.ObjectID) bool {
+	if !atA.Equal(atB) {
+		return atA.After(atB)
+	}
+	return idLess(idB, idA)
+}
+
+// older orders documents by {at: 1, _id: 1}.
+func older(atA time.Time, idA primitive.ObjectID, atB time.Time, idB primitive.ObjectID) bool {
+	return newer(atB, idB, atA, idA)
+}
+
+// after reports whether a document sorts after the cursor when ordering
+// by {at: -1, _id: -1}; a nil cursor admits everything. It matches
+// TimeCursor.olderThan.
+func (c *TimeCursor) after(at time.Time, id primitive.ObjectID) bool {
+	if c == nil {
+		return true
+	}
+	return newer(ms(c.At), c.ID, at, id)
+}
+
+// timeOrZero returns *t, or the zero time for nil.
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// listed reports whether a visibility may appear in discovery, like
+// listedVisibilities.
+func listed(visibility string) bool {
+	return visibility != models.VisibilityUnlisted && visibility != models.VisibilityPrivate
+}
+
+// MemoryUserRepository is the in-memory UserRepository.
+type MemoryUserRepository struct{ d *memoryDB }
+
+func (m *MemoryUserRepository) Create(_ context.Context, user *models.User) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	user.SetSearchKeys()
+	if _, ok := m.d.users[user.ID]; ok {
+		return duplicateKeyError("users", "_id")
+	}
+	for _, u := range m.d.users {
+		if u.Email == user.Email {
+			return duplicateKeyError("users", "email")
+		}
+		if u.Username == user.Username {
+			return duplicateKeyError("users", "username")
+		}
+	}
+	m.d.users[user.ID] = clone(*user)
+	return nil
+}
+
+func (m *MemoryUserRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.User, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	u, ok := m.d.users[id]
+	if !ok {
+		return nil, nil
+	}
+	return ptr(u), nil
+}
+
+func (m *MemoryUserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	if username == "" {
+		return nil, nil
+	}
+	return m.FindByEmailOrUsername(ctx, "", username)
+}
+
+func (m *MemoryUserRepository) FindByEmailOrUsername(_ context.Context, email, username string) (*models.User, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	return findFirst(m.d.users, func(u *models.User) bool {
+		return (email == "" || u.Email == email) && (username == "" || u.Username == username)
+	}, func(a, b *models.User) bool { return idLess(a.ID, b.ID) }), nil
+}
+
+func (m *MemoryUserRepository) ListPublicProfiles(_ context.Context, ids []primitive.ObjectID) ([]models.PublicProfile, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	profiles := []models.PublicProfile{}
+	for _, u := range where(m.d.users, func(u *models.User) bool { return containsID(ids, u.ID) }) {
+		profiles = append(profiles, u.Public())
+	}
+	sortBy(profiles, func(a, b *models.PublicProfile) bool { return a.Username < b.Username })
+	return profiles, nil
+}
+
+func (m *MemoryUserRepository) SetStreamSuspension(_ context.Context, id primitive.ObjectID, until *time.Time, reason string) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	u, ok := m.d.users[id]
+	if !ok {
+		return nil
+	}
+	u.StreamSuspendedUntil, u.StreamSuspendReason = nil, ""
+	if until != nil {
+		u.StreamSuspendedUntil, u.StreamSuspendReason = until, reason
+	}
+	m.d.users[id] = clone(u)
+	return nil
+}
+
+// MemorySessionRepository is the in-memory SessionRepository.
+type MemorySessionRepository struct{ d *memoryDB }
+
+func (m *MemorySessionRepository) Create(_ context.Context, session *models.Session) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	if _, ok := m.d.sessions[session.ID]; ok {
+		return duplicateKeyError("sessions", "_id")
+	}
+	m.d.sessions[session.ID] = clone(*session)
+	return nil
+}
+
+func (m *MemorySessionRepository) Get(_ context.Context, sessionID string) (*models.Session, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	s, ok := m.d.sessions[sessionID]
+	if !ok {
+		return nil, nil
+	}
+	return ptr(s), nil
+}
+
+// MemoryStreamKeyRepository is the in-memory StreamKeyRepository.
+type MemoryStreamKeyRepository struct{ d *memoryDB }
+
+func (m *MemoryStreamKeyRepository) Create(_ context.Context, key *models.StreamKey) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	for _, k := range m.d.streamKeys {
+		if k.UserID == key.UserID {
+			return duplicateKeyError("stream_keys", "user_id_unique")
+		}
+	}
+	if key.ID.IsZero() {
+		key.ID = primitive.NewObjectID()
+	}
+	if _, ok := m.d.streamKeys[key.ID]; ok {
+		return duplicateKeyError("stream_keys", "_id")
+	}
+	m.d.streamKeys[key.ID] = clone(*key)
+	return nil
+}
+
+func (m *MemoryStreamKeyRepository) FindByUserID(_ context.Context, userID primitive.ObjectID) (*models.StreamKey, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	for _, k := range m.d.streamKeys {
+		if k.UserID == userID {
+			return ptr(k), nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MemoryStreamKeyRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.StreamKey, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	k, ok := m.d.streamKeys[id]
+	if !ok {
+		return nil, nil
+	}
+	return ptr(k), nil
+}
+
+func (m *MemoryStreamKeyRepository) DeleteByUserID(_ context.Context, userID primitive.ObjectID) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	for id, k := range m.d.streamKeys {
+		if k.UserID == userID {
+			delete(m.d.streamKeys, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo/repotest"
+)
+
+func TestMemoryStore(t *testing.T) {
+	repotest.Run(t, func(*testing.T) *repo.Store { return repo.NewMemoryStore() })
+}
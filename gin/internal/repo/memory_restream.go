@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryRestreamRepository is the in-memory RestreamRepository.
+type MemoryRestreamRepository struct{ d *memoryDB }
+
+func (m *MemoryRestreamRepository) Create(_ context.Context, dest *models.RestreamDestination) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	if dest.ID.IsZero() {
+		dest.ID = primitive.NewObjectID()
+	}
+	if _, ok := m.d.restreams[dest.ID]; ok {
+		return duplicateKeyError("restream_destinations", "_id")
+	}
+	m.d.restreams[dest.ID] = clone(*dest)
+	return nil
+}
+
+func (m *MemoryRestreamRepository) List(_ context.Context, userID primitive.ObjectID, enabledOnly bool) ([]models.RestreamDestination, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	dests := where(m.d.restreams, func(dest *models.RestreamDestination) bool {
+		return dest.UserID == userID && (dest.Enabled || !enabledOnly)
+	})
+	sortBy(dests, func(a, b *models.RestreamDestination) bool { return older(a.CreatedAt, a.ID, b.CreatedAt, b.ID) })
+	return dests, nil
+}
+
+func (m *MemoryRestreamRepository) Count(_ context.Context, userID primitive.ObjectID) (int64, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	var n int64
+	for _, dest := range m.d.restreams {
+		if dest.UserID == userID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MemoryRestreamRepository) Update(_ context.Context, userID, id primitive.ObjectID, u RestreamDestinationUpdate) (*models.RestreamDestination, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	dest, ok := m.d.restreams[id]
+	if !ok || dest.UserID != userID {
+		return nil, nil
+	}
+	if u.Name != nil {
+		dest.Name = *u.Name
+	}
+	if u.URL != nil {
+		dest.URL = *u.URL
+	}
+	if u.KeyCipher != nil {
+		dest.KeyCipher = *u.KeyCipher
+	}
+	if u.KeyHint != nil {
+		dest.KeyHint = *u.KeyHint
+	}
+	if u.Enabled != nil {
+		dest.Enabled = *u.Enabled
+	}
+	dest.UpdatedAt = time.Now().UTC()
+	m.d.restreams[id] = clone(dest)
+	return ptr(dest), nil
+}
+
+func (m *MemoryRestreamRepository) Delete(_ context.Context, userID, id primitive.ObjectID) (bool, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	dest, ok := m.d.restreams[id]
+	if !ok || dest.UserID != userID {
+		return false, nil
+	}
+	delete(m.d.restreams, id)
+	return true, nil
+}
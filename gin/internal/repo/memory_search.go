@@ -0,0 +1,145 @@
+package repo
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemorySearchRepository is the in-memory SearchRepository. Its text
+// search approximates MongoDB's: the same fields and weights and a
+// similar score, but whole words only, without stemming, stop words,
+// phrases or negation.
+type MemorySearchRepository struct{ d *memoryDB }
+
+// tokenize splits s into lower-cased words.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// searchTerms returns the distinct words of a query.
+func searchTerms(text string) []string {
+	var terms []string
+	for _, t := range tokenize(text) {
+		if !containsString(terms, t) {
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+// textScore scores a field of the given weight against terms the way
+// MongoDB's text index does, leaving out its position adjustments.
+func textScore(terms []string, field string, weight float64) float64 {
+	tokens := tokenize(field)
+	score := 0.0
+	for _, term := range terms {
+		n := 0
+		for _, tok := range tokens {
+			if tok == term {
+				n++
+			}
+		}
+		if n > 0 {
+			score += weight * (0.5*float64(n)/float64(len(tokens)) + 0.5)
+		}
+	}
+	return score
+}
+
+func (m *MemorySearchRepository) Streams(_ context.Context, q StreamSearch) ([]StreamHit, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	terms := searchTerms(q.Text)
+	byRelevance := q.Text != "" && !q.SortRecent
+
+	hits := []StreamHit{}
+	for _, s := range m.d.streams {
+		if !listed(s.Visibility) || (q.LiveOnly && !s.Live) ||
+			(q.Category != "" && s.Category != q.Category) || (q.Tag != "" && !containsString(s.Tags, q.Tag)) {
+			continue
+		}
+		hit := StreamHit{Stream: clone(s)}
+		if q.Text != "" {
+			hit.Score = textScore(terms, s.Title, 10) + textScore(terms, strings.Join(s.Tags, " "), 5) +
+				textScore(terms, s.Description, 1)
+			if hit.Score == 0 {
+				continue
+			}
+		}
+		if !q.After.after(hit.Score, hit.UpdatedAt, hit.ID, byRelevance) {
+			continue
+		}
+		hits = append(hits, hit)
+	}
+	sortBy(hits, func(a, b *StreamHit) bool {
+		if byRelevance && a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return newer(a.UpdatedAt, a.ID, b.UpdatedAt, b.ID)
+	})
+	return firstN(hits, q.Limit), nil
+}
+
+// after reports whether a stream hit sorts after the cursor; a nil cursor
+// admits everything. It matches afterCursor.
+func (c *SearchCursor) after(score float64, at time.Time, id primitive.ObjectID, byRelevance bool) bool {
+	if c == nil {
+		return true
+	}
+	if byRelevance && score != c.Score {
+		return score < c.Score
+	}
+	return newer(ms(c.UpdatedAt), c.ID, at, id)
+}
+
+func (m *MemorySearchRepository) Channels(_ context.Context, text string, after *SearchCursor, limit int) ([]ChannelHit, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	terms := searchTerms(text)
+	hits := []ChannelHit{}
+	for _, u := range m.d.users {
+		score := textScore(terms, u.Username, 3) + textScore(terms, u.DisplayName, 2)
+		if score == 0 {
+			continue
+		}
+		if after != nil && !(score < after.Score || (score == after.Score && idLess(u.ID, after.ID))) {
+			continue
+		}
+		hits = append(hits, ChannelHit{ID: u.ID, Username: u.Username, DisplayName: u.DisplayName, Score: score})
+	}
+	sortBy(hits, func(a, b *ChannelHit) bool {
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return idLess(b.ID, a.ID)
+	})
+	return firstN(hits, limit), nil
+}
+
+func (m *MemorySearchRepository) SuggestTags(_ context.Context, prefix string, limit int) ([]TagCount, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	streams := where(m.d.streams, func(s *models.Stream) bool { return listed(s.Visibility) })
+	return countTags(streams, func(tag string) bool { return strings.HasPrefix(tag, prefix) }, limit), nil
+}
+
+func (m *MemorySearchRepository) SuggestChannels(_ context.Context, prefix string, limit int) ([]ChannelHit, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	prefix = strings.ToLower(prefix)
+	hits := []ChannelHit{}
+	for _, u := range m.d.users {
+		if strings.HasPrefix(u.UsernameLower, prefix) || strings.HasPrefix(u.DisplayNameLower, prefix) {
+			hits = append(hits, ChannelHit{ID: u.ID, Username: u.Username, DisplayName: u.DisplayName})
+		}
+	}
+	sortBy(hits, func(a, b *ChannelHit) bool { return a.Username < b.Username })
+	return firstN(hits, limit), nil
+}
@@ -0,0 +1,182 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryStreamRepository is the in-memory StreamRepository.
+type MemoryStreamRepository struct{ d *memoryDB }
+
+func (m *MemoryStreamRepository) FindByUserID(_ context.Context, userID primitive.ObjectID) (*models.Stream, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	return m.d.findStream(func(s *models.Stream) bool { return s.UserID == userID }), nil
+}
+
+func (m *MemoryStreamRepository) FindByUsername(_ context.Context, username string) (*models.Stream, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	return m.d.findStream(func(s *models.Stream) bool { return s.Username == username }), nil
+}
+
+func (d *memoryDB) findStream(keep func(*models.Stream) bool) *models.Stream {
+	return findFirst(d.streams, keep, func(a, b *models.Stream) bool { return idLess(a.ID, b.ID) })
+}
+
+func (m *MemoryStreamRepository) UpsertDetails(_ context.Context, userID primitive.ObjectID, username string, d StreamDetails) (*models.Stream, error) {
+	now := time.Now().UTC()
+	return m.update(userID, username, func(s *models.Stream) {
+		s.Title, s.Description, s.Category, s.Tags = d.Title, d.Description, d.Category, d.Tags
+		s.UpdatedAt = now
+	})
+}
+
+func (m *MemoryStreamRepository) SetLive(_ context.Context, userID primitive.ObjectID, username string, live bool, at time.Time) (*models.Stream, error) {
+	return m.update(userID, username, func(s *models.Stream) {
+		s.Live, s.UpdatedAt = live, at
+		if live {
+			s.StartedAt, s.BroadcastID = &at, primitive.NewObjectID()
+		} else {
+			s.EndedAt = &at
+		}
+	})
+}
+
+func (m *MemoryStreamRepository) SetVisibility(_ context.Context, userID primitive.ObjectID, username, visibility string) (*models.Stream, error) {
+	now := time.Now().UTC()
+	return m.update(userID, username, func(s *models.Stream) {
+		s.Visibility, s.UpdatedAt = visibility, now
+	})
+}
+
+func (m *MemoryStreamRepository) SetSubscribersOnly(_ context.Context, userID primitive.ObjectID, username string, on bool) (*models.Stream, error) {
+	now := time.Now().UTC()
+	return m.update(userID, username, func(s *models.Stream) {
+		s.SubscribersOnly, s.UpdatedAt = on, now
+	})
+}
+
+func (m *MemoryStreamRepository) AddViewer(_ context.Context, userID primitive.ObjectID, username string, viewerID primitive.ObjectID) (*models.Stream, error) {
+	return m.update(userID, username, func(s *models.Stream) {
+		if !containsID(s.AllowedUserIDs, viewerID) {
+			s.AllowedUserIDs = append(s.AllowedUserIDs, viewerID)
+		}
+	})
+}
+
+func (m *MemoryStreamRepository) RemoveViewer(_ context.Context, userID primitive.ObjectID, username string, viewerID primitive.ObjectID) (*models.Stream, error) {
+	return m.update(userID, username, func(s *models.Stream) {
+		s.AllowedUserIDs = pullID(s.AllowedUserIDs, viewerID)
+	})
+}
+
+// update applies apply to the user's stream, creating it with empty
+// details if needed, and returns the result, like updateStream.
+func (m *MemoryStreamRepository) update(userID primitive.ObjectID, username string, apply func(*models.Stream)) (*models.Stream, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	s := m.d.findStream(func(s *models.Stream) bool { return s.UserID == userID })
+	if s == nil {
+		s = &models.Stream{ID: primitive.NewObjectID(), UserID: userID, Tags: []string{}}
+	}
+	s.Username = username
+	apply(s)
+	for _, other := range m.d.streams {
+		if other.ID != s.ID && other.Username == s.Username {
+			return nil, duplicateKeyError("streams", "username_unique")
+		}
+	}
+	m.d.streams[s.ID] = clone(*s)
+	return ptr(*s), nil
+}
+
+// pullID returns ids without id.
+func pullID(ids []primitive.ObjectID, id primitive.ObjectID) []primitive.ObjectID {
+	out := ids[:0:0]
+	for _, x := range ids {
+		if x != id {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+func (m *MemoryStreamRepository) CountInCategory(_ context.Context, slug string) (int64, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	var n int64
+	for _, s := range m.d.streams {
+		if s.Category == slug {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MemoryStreamRepository) TrendingTags(_ context.Context, since time.Time, limit int) ([]TagCount, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	since = ms(since)
+	streams := where(m.d.streams, func(s *models.Stream) bool {
+		return listed(s.Visibility) && (s.Live || (s.EndedAt != nil && !s.EndedAt.Before(since)))
+	})
+	return countTags(streams, func(string) bool { return true }, limit), nil
+}
+
+// countTags counts the tags of streams that keep accepts, most used
+// first, then by tag.
+func countTags(streams []models.Stream, keep func(tag string) bool, limit int) []TagCount {
+	counts := map[string]int{}
+	for _, s := range streams {
+		for _, tag := range s.Tags {
+			if keep(tag) {
+				counts[tag]++
+			}
+		}
+	}
+	tags := []TagCount{}
+	for tag, n := range counts {
+		tags = append(tags, TagCount{Tag: tag, Count: n})
+	}
+	sortBy(tags, func(a, b *TagCount) bool {
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Tag < b.Tag
+	})
+	return firstN(tags, limit)
+}
+
+func (m *MemoryStreamRepository) ListLiveByUserIDs(_ context.Context, userIDs []primitive.ObjectID, limit int) ([]models.Stream, error) {
+	return m.list(func(s *models.Stream) bool {
+		return containsID(userIDs, s.UserID) && s.Live && listed(s.Visibility)
+	}, func(s *models.Stream) *time.Time { return s.StartedAt }, limit), nil
+}
+
+func (m *MemoryStreamRepository) ListEndedByUserIDs(_ context.Context, userIDs []primitive.ObjectID, since time.Time, limit int) ([]models.Stream, error) {
+	since = ms(since)
+	return m.list(func(s *models.Stream) bool {
+		return containsID(userIDs, s.UserID) && !s.Live && s.EndedAt != nil && !s.EndedAt.Before(since) && listed(s.Visibility)
+	}, func(s *models.Stream) *time.Time { return s.EndedAt }, limit), nil
+}
+
+func (m *MemoryStreamRepository) ListLive(_ context.Context, limit int) ([]models.Stream, error) {
+	return m.list(func(s *models.Stream) bool {
+		return s.Live && listed(s.Visibility)
+	}, func(s *models.Stream) *time.Time { return s.StartedAt }, limit), nil
+}
+
+// list returns the streams keep accepts, latest by the time at first.
+func (m *MemoryStreamRepository) list(keep func(*models.Stream) bool, at func(*models.Stream) *time.Time, limit int) []models.Stream {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	streams := where(m.d.streams, keep)
+	sortBy(streams, func(a, b *models.Stream) bool {
+		return newer(timeOrZero(at(a)), a.ID, timeOrZero(at(b)), b.ID)
+	})
+	return firstN(streams, limit)
+}
@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryStreamInviteRepository is the in-memory StreamInviteRepository.
+type MemoryStreamInviteRepository struct{ d *memoryDB }
+
+func (m *MemoryStreamInviteRepository) Create(_ context.Context, inv *models.StreamInvite) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	for _, other := range m.d.streamInvites {
+		if other.Code == inv.Code {
+			return duplicateKeyError("stream_invites", "code_unique")
+		}
+	}
+	if inv.ID.IsZero() {
+		inv.ID = primitive.NewObjectID()
+	}
+	if _, ok := m.d.streamInvites[inv.ID]; ok {
+		return duplicateKeyError("stream_invites", "_id")
+	}
+	m.d.streamInvites[inv.ID] = clone(*inv)
+	return nil
+}
+
+func (m *MemoryStreamInviteRepository) List(_ context.Context, channelID primitive.ObjectID) ([]models.StreamInvite, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	invites := where(m.d.streamInvites, func(inv *models.StreamInvite) bool { return inv.ChannelID == channelID })
+	sortBy(invites, func(a, b *models.StreamInvite) bool { return newer(a.CreatedAt, a.ID, b.CreatedAt, b.ID) })
+	return invites, nil
+}
+
+func (m *MemoryStreamInviteRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.StreamInvite, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	inv, ok := m.d.streamInvites[id]
+	if !ok {
+		return nil, nil
+	}
+	return ptr(inv), nil
+}
+
+func (m *MemoryStreamInviteRepository) Delete(_ context.Context, channelID, id primitive.ObjectID) (bool, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	inv, ok := m.d.streamInvites[id]
+	if !ok || inv.ChannelID != channelID {
+		return false, nil
+	}
+	delete(m.d.streamInvites, id)
+	return true, nil
+}
+
+func (m *MemoryStreamInviteRepository) Use(_ context.Context, channelID primitive.ObjectID, code string, now time.Time) (*models.StreamInvite, error) {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	now = ms(now)
+	for id, inv := range m.d.streamInvites {
+		if inv.ChannelID != channelID || inv.Code != code {
+			continue
+		}
+		if (inv.ExpiresAt != nil && !inv.ExpiresAt.After(now)) || (inv.MaxUses != 0 && inv.Uses >= inv.MaxUses) {
+			return nil, nil
+		}
+		inv.Uses++
+		m.d.streamInvites[id] = inv
+		return ptr(inv), nil
+	}
+	return nil, nil
+}
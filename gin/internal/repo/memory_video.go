@@ -0,0 +1,112 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryVideoRepository is the in-memory VideoRepository.
+type MemoryVideoRepository struct{ d *memoryDB }
+
+func (m *MemoryVideoRepository) Create(_ context.Context, v *models.Video) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	for _, other := range m.d.videos {
+		if other.BroadcastID == v.BroadcastID {
+			return duplicateKeyError("videos", "broadcast_id_unique")
+		}
+	}
+	if v.ID.IsZero() {
+		v.ID = primitive.NewObjectID()
+	}
+	if _, ok := m.d.videos[v.ID]; ok {
+		return duplicateKeyError("videos", "_id")
+	}
+	m.d.videos[v.ID] = clone(*v)
+	return nil
+}
+
+func (m *MemoryVideoRepository) FindByID(_ context.Context, id primitive.ObjectID) (*models.Video, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	v, ok := m.d.videos[id]
+	if !ok {
+		return nil, nil
+	}
+	return ptr(v), nil
+}
+
+func (m *MemoryVideoRepository) FindByBroadcastID(_ context.Context, broadcastID primitive.ObjectID) (*models.Video, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	for _, v := range m.d.videos {
+		if v.BroadcastID == broadcastID {
+			return ptr(v), nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MemoryVideoRepository) Finish(_ context.Context, id primitive.ObjectID, duration float64, segments int, endedAt time.Time) error {
+	m.update(id, func(v *models.Video) {
+		v.Status, v.DurationSeconds, v.SegmentCount, v.EndedAt = models.VideoReady, duration, segments, &endedAt
+	})
+	return nil
+}
+
+func (m *MemoryVideoRepository) UpdateTitle(_ context.Context, id primitive.ObjectID, title string) (*models.Video, error) {
+	return m.update(id, func(v *models.Video) { v.Title = title }), nil
+}
+
+func (m *MemoryVideoRepository) SetSubscribersOnly(_ context.Context, id primitive.ObjectID, on bool) (*models.Video, error) {
+	return m.update(id, func(v *models.Video) { v.SubscribersOnly = on }), nil
+}
+
+// update applies apply to the video, bumps its updated_at and returns the
+// result, or nil if there is no such video.
+func (m *MemoryVideoRepository) update(id primitive.ObjectID, apply func(*models.Video)) *models.Video {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	v, ok := m.d.videos[id]
+	if !ok {
+		return nil
+	}
+	apply(&v)
+	v.UpdatedAt = time.Now().UTC()
+	m.d.videos[id] = clone(v)
+	return ptr(v)
+}
+
+func (m *MemoryVideoRepository) Delete(_ context.Context, id primitive.ObjectID) error {
+	m.d.mu.Lock()
+	defer m.d.mu.Unlock()
+	delete(m.d.videos, id)
+	return nil
+}
+
+func (m *MemoryVideoRepository) ListByUser(_ context.Context, userID primitive.ObjectID, hidden []string, after *TimeCursor, limit int) ([]models.Video, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	videos := where(m.d.videos, func(v *models.Video) bool {
+		return v.UserID == userID && !containsString(hidden, v.Visibility) && after.after(v.StartedAt, v.ID)
+	})
+	sortBy(videos, func(a, b *models.Video) bool { return newer(a.StartedAt, a.ID, b.StartedAt, b.ID) })
+	return firstN(videos, limit), nil
+}
+
+func (m *MemoryVideoRepository) ListByStatus(_ context.Context, status string) ([]models.Video, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	videos := where(m.d.videos, func(v *models.Video) bool { return v.Status == status })
+	sortBy(videos, func(a, b *models.Video) bool { return idLess(a.ID, b.ID) })
+	return videos, nil
+}
+
+func (m *MemoryVideoRepository) ListByBroadcastIDs(_ context.Context, broadcastIDs []primitive.ObjectID) ([]models.Video, error) {
+	m.d.mu.RLock()
+	defer m.d.mu.RUnlock()
+	return where(m.d.videos, func(v *models.Video) bool { return containsID(broadcastIDs, v.BroadcastID) }), nil
+}
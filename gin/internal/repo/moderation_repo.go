@@ -0,0 +1,196 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoModerationRepository is the ModerationRepository backed by the
+// "channel_moderators", "channel_bans", "chat_settings" and
+// "moderation_log" collections.
+type MongoModerationRepository struct {
+	db *mongo.Database
+}
+
+// AddModerator appoints a moderator; appointing twice is a no-op.
+// It reports whether a new appointment was made.
+func (r MongoModerationRepository) AddModerator(ctx context.Context, m *models.ChannelModerator) (bool, error) {
+	coll := r.db.Collection("channel_moderators")
+	res, err := coll.UpdateOne(ctx,
+		bson.M{"channel_id": m.ChannelID, "user_id": m.UserID},
+		bson.M{"$setOnInsert": m},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.UpsertedCount > 0, nil
+}
+
+// RemoveModerator revokes an appointment and reports whether it existed.
+func (r MongoModerationRepository) RemoveModerator(ctx context.Context, channelID, userID primitive.ObjectID) (bool, error) {
+	coll := r.db.Collection("channel_moderators")
+	res, err := coll.DeleteOne(ctx, bson.M{"channel_id": channelID, "user_id": userID})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// IsModerator reports whether userID moderates channelID.
+func (r MongoModerationRepository) IsModerator(ctx context.Context, channelID, userID primitive.ObjectID) (bool, error) {
+	coll := r.db.Collection("channel_moderators")
+	n, err := coll.CountDocuments(ctx, bson.M{"channel_id": channelID, "user_id": userID}, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+// ListModerators returns a channel's moderators in appointment order.
+func (r MongoModerationRepository) ListModerators(ctx context.Context, channelID primitive.ObjectID) ([]models.ChannelModerator, error) {
+	coll := r.db.Collection("channel_moderators")
+	cur, err := coll.Find(ctx, bson.M{"channel_id": channelID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	mods := []models.ChannelModerator{}
+	if err := cur.All(ctx, &mods); err != nil {
+		return nil, err
+	}
+	return mods, nil
+}
+
+// UpsertBan stores a ban or timeout, replacing any existing one for the user.
+func (r MongoModerationRepository) UpsertBan(ctx context.Context, b *models.ChannelBan) error {
+	coll := r.db.Collection("channel_bans")
+	b.ID = primitive.NilObjectID
+	res, err := coll.ReplaceOne(ctx,
+		bson.M{"channel_id": b.ChannelID, "user_id": b.UserID},
+		b,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.UpsertedID.(primitive.ObjectID); ok {
+		b.ID = id
+	}
+	return nil
+}
+
+// DeleteBan lifts a ban or timeout and reports whether one existed.
+func (r MongoModerationRepository) DeleteBan(ctx context.Context, channelID, userID primitive.ObjectID) (bool, error) {
+	coll := r.db.Collection("channel_bans")
+	res, err := coll.DeleteOne(ctx, bson.M{"channel_id": channelID, "user_id": userID})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// FindActiveBan returns the user's ban or unexpired timeout in the channel (or nil).
+// The TTL monitor only runs once a minute, so expiry is also checked here.
+func (r MongoModerationRepository) FindActiveBan(ctx context.Context, channelID, userID primitive.ObjectID, now time.Time) (*models.ChannelBan, error) {
+	coll := r.db.Collection("channel_bans")
+	var b models.ChannelBan
+	err := coll.FindOne(ctx, bson.M{
+		"channel_id": channelID,
+		"user_id":    userID,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$exists": false}},
+			bson.M{"expires_at": bson.M{"$gt": now}},
+		},
+	}).Decode(&b)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &b, nil
+}
+
+// ListActiveBans returns the channel's bans and unexpired timeouts, newest first.
+func (r MongoModerationRepository) ListActiveBans(ctx context.Context, channelID primitive.ObjectID, now time.Time) ([]models.ChannelBan, error) {
+	coll := r.db.Collection("channel_bans")
+	cur, err := coll.Find(ctx, bson.M{
+		"channel_id": channelID,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$exists": false}},
+			bson.M{"expires_at": bson.M{"$gt": now}},
+		},
+	}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	bans := []models.ChannelBan{}
+	if err := cur.All(ctx, &bans); err != nil {
+		return nil, err
+	}
+	return bans, nil
+}
+
+// GetChatSettings returns the channel's chat settings, or nil if it never
+// changed the defaults.
+func (r MongoModerationRepository) GetChatSettings(ctx context.Context, channelID primitive.ObjectID) (*models.ChatSettings, error) {
+	coll := r.db.Collection("chat_settings")
+	var s models.ChatSettings
+	err := coll.FindOne(ctx, bson.M{"_id": channelID}).Decode(&s)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SaveChatSettings writes the channel's chat settings.
+func (r MongoModerationRepository) SaveChatSettings(ctx context.Context, s *models.ChatSettings) error {
+	coll := r.db.Collection("chat_settings")
+	_, err := coll.ReplaceOne(ctx, bson.M{"_id": s.ChannelID}, s, options.Replace().SetUpsert(true))
+	return err
+}
+
+// InsertAction appends to a channel's moderation log.
+func (r MongoModerationRepository) InsertAction(ctx context.Context, a *models.ModerationAction) error {
+	coll := r.db.Collection("moderation_log")
+	res, err := coll.InsertOne(ctx, a)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		a.ID = id
+	}
+	return nil
+}
+
+// ListActions returns a channel's moderation log, newest first,
+// optionally restricted to one action type.
+func (r MongoModerationRepository) ListActions(ctx context.Context, channelID primitive.ObjectID, action string, after *TimeCursor, limit int) ([]models.ModerationAction, error) {
+	coll := r.db.Collection("moderation_log")
+
+	filter := bson.M{"channel_id": channelID}
+	if action != "" {
+		filter["action"] = action
+	}
+	if after != nil {
+		filter = bson.M{"$and": bson.A{filter, after.olderThan("created_at", "_id")}}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+	cur, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	actions := []models.ModerationAction{}
+	if err := cur.All(ctx, &actions); err != nil {
+		return nil, err
+	}
+	return actions, nil
+}
@@ -0,0 +1,45 @@
+package repo_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/db"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo/repotest"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TestMongoStore runs the contract against a throwaway database on the
+// server at MONGODB_TEST_URI, and is skipped when that is not set.
+func TestMongoStore(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI not set")
+	}
+	d, err := db.Connect(uri, fmt.Sprintf("repotest_%d", time.Now().UnixNano()))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = d.Drop(context.Background())
+		_ = db.Close(d)
+	})
+
+	repotest.Run(t, func(t *testing.T) *repo.Store {
+		// Emptied rather than dropped, so the unique indexes stay.
+		colls, err := d.ListCollectionNames(context.Background(), bson.M{})
+		if err != nil {
+			t.Fatalf("list collections: %v", err)
+		}
+		for _, coll := range colls {
+			if _, err := d.Collection(coll).DeleteMany(context.Background(), bson.M{}); err != nil {
+				t.Fatalf("clear %s: %v", coll, err)
+			}
+		}
+		return repo.NewMongoStore(d)
+	})
+}
@@ -0,0 +1,82 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoNotificationRepository is the NotificationRepository backed by the
+// "notifications" collection.
+type MongoNotificationRepository struct {
+	db *mongo.Database
+}
+
+// Insert bulk-inserts notifications and fills their IDs.
+func (r MongoNotificationRepository) Insert(ctx context.Context, ns []*models.Notification) error {
+	if len(ns) == 0 {
+		return nil
+	}
+	coll := r.db.Collection("notifications")
+	docs := make([]interface{}, len(ns))
+	for i, n := range ns {
+		if n.ID.IsZero() {
+			n.ID = primitive.NewObjectID()
+		}
+		docs[i] = n
+	}
+	_, err := coll.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	return err
+}
+
+// List returns a user's notifications, newest first.
+func (r MongoNotificationRepository) List(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, after *TimeCursor, limit int) ([]models.Notification, error) {
+	coll := r.db.Collection("notifications")
+
+	filter := bson.M{"user_id": userID}
+	if unreadOnly {
+		filter["read"] = false
+	}
+	if after != nil {
+		filter = bson.M{"$and": bson.A{filter, after.olderThan("created_at", "_id")}}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+	cur, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	ns := []models.Notification{}
+	if err := cur.All(ctx, &ns); err != nil {
+		return nil, err
+	}
+	return ns, nil
+}
+
+// CountUnread returns how many unread notifications a user has.
+func (r MongoNotificationRepository) CountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	coll := r.db.Collection("notifications")
+	return coll.CountDocuments(ctx, bson.M{"user_id": userID, "read": false})
+}
+
+// MarkRead marks the given notifications of the user as read.
+// An empty ids slice marks every unread notification. It returns how many changed.
+func (r MongoNotificationRepository) MarkRead(ctx context.Context, userID primitive.ObjectID, ids []primitive.ObjectID) (int64, error) {
+	coll := r.db.Collection("notifications")
+
+	filter := bson.M{"user_id": userID, "read": false}
+	if len(ids) > 0 {
+		filter["_id"] = bson.M{"$in": ids}
+	}
+	res, err := coll.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"read": true, "read_at": time.Now().UTC()}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
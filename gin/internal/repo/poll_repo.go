@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoPollRepository is the PollRepository backed by the "polls" and
+// "poll_votes" collections.
+type MongoPollRepository struct {
+	db *mongo.Database
+}
+
+// Create inserts a poll and sets its ID.
+func (r MongoPollRepository) Create(ctx context.Context, p *models.Poll) error {
+	res, err := r.db.Collection("polls").InsertOne(ctx, p)
+	if err != nil {
+		return err
+	}
+	p.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindByID returns the poll (or nil).
+func (r MongoPollRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Poll, error) {
+	return r.findPoll(ctx, bson.M{"_id": id})
+}
+
+// FindOpen returns the channel's poll that takes votes at now (or nil).
+func (r MongoPollRepository) FindOpen(ctx context.Context, channelID primitive.ObjectID, now time.Time) (*models.Poll, error) {
+	return r.findPoll(ctx, bson.M{"channel_id": channelID, "status": models.PollOpen, "ends_at": bson.M{"$gt": now}})
+}
+
+func (r MongoPollRepository) findPoll(ctx context.Context, filter bson.M) (*models.Poll, error) {
+	var p models.Poll
+	err := r.db.Collection("polls").FindOne(ctx, filter).Decode(&p)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Close closes an open poll at now and returns it, or nil if it was
+// already closed.
+func (r MongoPollRepository) Close(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.Poll, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var p models.Poll
+	err := r.db.Collection("polls").FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": models.PollOpen},
+		bson.M{"$set": bson.M{"status": models.PollClosed, "closed_at": now}}, opts).Decode(&p)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListOpenIDs returns the IDs of the channel's polls not closed yet,
+// including those whose time ran out.
+func (r MongoPollRepository) ListOpenIDs(ctx context.Context, channelID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return findIDs(ctx, r.db, "polls", bson.M{"channel_id": channelID, "status": models.PollOpen})
+}
+
+// InsertVote records a vote. It reports false if the user already
+// voted in the poll.
+func (r MongoPollRepository) InsertVote(ctx context.Context, v *models.PollVote) (bool, error) {
+	res, err := r.db.Collection("poll_votes").InsertOne(ctx, v)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	v.ID = res.InsertedID.(primitive.ObjectID)
+	return true, nil
+}
+
+// CountVote adds a vote to the tally of the poll's option.
+func (r MongoPollRepository) CountVote(ctx context.Context, id primitive.ObjectID, option int) error {
+	_, err := r.db.Collection("polls").UpdateByID(ctx, id, bson.M{"$inc": bson.M{
+		fmt.Sprintf("options.%d.votes", option): 1,
+		"total_votes":                           1,
+	}})
+	return err
+}
+
+// ListByBroadcast returns the polls run during a broadcast, oldest first.
+func (r MongoPollRepository) ListByBroadcast(ctx context.Context, broadcastID primitive.ObjectID) ([]models.Poll, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cur, err := r.db.Collection("polls").Find(ctx, bson.M{"broadcast_id": broadcastID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	polls := []models.Poll{}
+	if err := cur.All(ctx, &polls); err != nil {
+		return nil, err
+	}
+	return polls, nil
+}
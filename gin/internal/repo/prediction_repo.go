@@ -0,0 +1,190 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoPredictionRepository is the PredictionRepository backed by the
+// "predictions" and "prediction_stakes" collections.
+type MongoPredictionRepository struct {
+	db *mongo.Database
+}
+
+var (
+	// ErrPredictionNotOpen aborts a stake on a prediction that locked or
+	// ended before it was placed.
+	ErrPredictionNotOpen = errors.New("prediction is not open")
+
+	// errStakePaid aborts a stake whose payment already exists.
+	errStakePaid = errors.New("stake already paid")
+)
+
+// Create inserts a prediction and sets its ID.
+func (r MongoPredictionRepository) Create(ctx context.Context, p *models.Prediction) error {
+	res, err := r.db.Collection("predictions").InsertOne(ctx, p)
+	if err != nil {
+		return err
+	}
+	p.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindByID returns the prediction (or nil).
+func (r MongoPredictionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Prediction, error) {
+	return r.findPrediction(ctx, bson.M{"_id": id})
+}
+
+// FindActive returns the channel's prediction that is open or
+// locked and waiting for its outcome (or nil).
+func (r MongoPredictionRepository) FindActive(ctx context.Context, channelID primitive.ObjectID) (*models.Prediction, error) {
+	return r.findPrediction(ctx, bson.M{
+		"channel_id": channelID,
+		"status":     bson.M{"$in": bson.A{models.PredictionOpen, models.PredictionLocked}},
+	})
+}
+
+func (r MongoPredictionRepository) findPrediction(ctx context.Context, filter bson.M) (*models.Prediction, error) {
+	var p models.Prediction
+	err := r.db.Collection("predictions").FindOne(ctx, filter).Decode(&p)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Lock stops an open prediction from taking stakes and returns
+// it, or nil if it was not open.
+func (r MongoPredictionRepository) Lock(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.Prediction, error) {
+	return r.updatePrediction(ctx, bson.M{"_id": id, "status": models.PredictionOpen},
+		bson.M{"$set": bson.M{"status": models.PredictionLocked, "locks_at": now}})
+}
+
+// End resolves (with the winning outcome) or cancels (with nil)
+// a prediction that is open or locked, and returns it, or nil if it had
+// already ended.
+func (r MongoPredictionRepository) End(ctx context.Context, id primitive.ObjectID, winning *int, by primitive.ObjectID, now time.Time) (*models.Prediction, error) {
+	set := bson.M{"status": models.PredictionCanceled, "ended_by": by, "ended_at": now}
+	if winning != nil {
+		set["status"], set["winning_outcome"] = models.PredictionResolved, *winning
+	}
+	return r.updatePrediction(ctx,
+		bson.M{"_id": id, "status": bson.M{"$in": bson.A{models.PredictionOpen, models.PredictionLocked}}},
+		bson.M{"$set": set})
+}
+
+// MarkSettled records that every payout of a prediction was made.
+func (r MongoPredictionRepository) MarkSettled(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.db.Collection("predictions").UpdateByID(ctx, id, bson.M{"$set": bson.M{"settled": true}})
+	return err
+}
+
+func (r MongoPredictionRepository) updatePrediction(ctx context.Context, filter, update bson.M) (*models.Prediction, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var p models.Prediction
+	err := r.db.Collection("predictions").FindOneAndUpdate(ctx, filter, update, opts).Decode(&p)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListUnsettled returns the channel's ended predictions whose
+// payouts were interrupted.
+func (r MongoPredictionRepository) ListUnsettled(ctx context.Context, channelID primitive.ObjectID) ([]models.Prediction, error) {
+	return r.findPredictions(ctx, bson.M{
+		"channel_id": channelID,
+		"status":     bson.M{"$in": bson.A{models.PredictionResolved, models.PredictionCanceled}},
+		"settled":    false,
+	}, nil)
+}
+
+// ListByBroadcast returns the predictions run during a
+// broadcast, oldest first.
+func (r MongoPredictionRepository) ListByBroadcast(ctx context.Context, broadcastID primitive.ObjectID) ([]models.Prediction, error) {
+	return r.findPredictions(ctx, bson.M{"broadcast_id": broadcastID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+}
+
+func (r MongoPredictionRepository) findPredictions(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.Prediction, error) {
+	cur, err := r.db.Collection("predictions").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := []models.Prediction{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PlaceStake records a stake, adds it to the totals of its
+// outcome and pays for it with t, in one transaction that only commits
+// while the prediction is still open at now. Counting the stake writes the
+// prediction, so a concurrent lock or end conflicts with it rather than
+// slipping in between the check and the payment. It reports false if the
+// user already staked on the prediction.
+func (r MongoPredictionRepository) PlaceStake(ctx context.Context, s *models.PredictionStake, t *models.LedgerTransfer, now time.Time) (bool, error) {
+	err := inTransaction(ctx, r.db, func(sc mongo.SessionContext) error {
+		d := r.db
+		res, err := d.Collection("predictions").UpdateOne(sc,
+			bson.M{"_id": s.PredictionID, "status": models.PredictionOpen, "locks_at": bson.M{"$gt": now}},
+			bson.M{"$inc": bson.M{
+				fmt.Sprintf("outcomes.%d.users", s.Outcome):  1,
+				fmt.Sprintf("outcomes.%d.points", s.Outcome): s.Amount,
+			}})
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			return ErrPredictionNotOpen
+		}
+		s.ID = primitive.NewObjectID()
+		if _, err := d.Collection("prediction_stakes").InsertOne(sc, s); err != nil {
+			return err
+		}
+		existing, err := MongoLedgerRepository{db: r.db}.createTransfer(sc, t)
+		if err == nil && existing != nil {
+			return errStakePaid
+		}
+		return err
+	})
+	if mongo.IsDuplicateKeyError(err) || errors.Is(err, errStakePaid) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ListStakes returns every stake of a prediction.
+func (r MongoPredictionRepository) ListStakes(ctx context.Context, predictionID primitive.ObjectID) ([]models.PredictionStake, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cur, err := r.db.Collection("prediction_stakes").Find(ctx, bson.M{"prediction_id": predictionID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	stakes := []models.PredictionStake{}
+	if err := cur.All(ctx, &stakes); err != nil {
+		return nil, err
+	}
+	return stakes, nil
+}
+
+// SetStakePayout records what a stake returned.
+func (r MongoPredictionRepository) SetStakePayout(ctx context.Context, id primitive.ObjectID, payout int64) error {
+	_, err := r.db.Collection("prediction_stakes").UpdateByID(ctx, id, bson.M{"$set": bson.M{"payout": payout}})
+	return err
+}
@@ -0,0 +1,97 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoRaidRepository is the RaidRepository backed by the "raids" and
+// "raid_settings" collections.
+type MongoRaidRepository struct {
+	db *mongo.Database
+}
+
+// SetPending makes r the pending raid of its channel, replacing the
+// target of one already pending, and returns the result.
+func (r MongoRaidRepository) SetPending(ctx context.Context, raid *models.Raid) (*models.Raid, error) {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var out models.Raid
+	err := r.db.Collection("raids").FindOneAndUpdate(ctx,
+		bson.M{"from_id": raid.FromID, "status": models.RaidPending},
+		bson.M{"$set": bson.M{
+			"from_username": raid.FromUsername,
+			"to_id":         raid.ToID,
+			"to_username":   raid.ToUsername,
+			"broadcast_id":  raid.BroadcastID,
+			"created_at":    raid.CreatedAt,
+		}}, opts).Decode(&out)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// FindPending returns the channel's pending raid (or nil).
+func (r MongoRaidRepository) FindPending(ctx context.Context, fromID primitive.ObjectID) (*models.Raid, error) {
+	var raid models.Raid
+	err := r.db.Collection("raids").FindOne(ctx,
+		bson.M{"from_id": fromID, "status": models.RaidPending}).Decode(&raid)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &raid, nil
+}
+
+// CancelPending calls off the channel's pending raid. It reports
+// false if there was none.
+func (r MongoRaidRepository) CancelPending(ctx context.Context, fromID primitive.ObjectID) (bool, error) {
+	res, err := r.db.Collection("raids").UpdateOne(ctx,
+		bson.M{"from_id": fromID, "status": models.RaidPending},
+		bson.M{"$set": bson.M{"status": models.RaidCanceled}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// Launch marks a pending raid as completed with its size. It reports
+// false if the raid was no longer pending, for instance because it was
+// canceled or another instance launched it.
+func (r MongoRaidRepository) Launch(ctx context.Context, id primitive.ObjectID, viewers int, now time.Time) (bool, error) {
+	res, err := r.db.Collection("raids").UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.RaidPending},
+		bson.M{"$set": bson.M{"status": models.RaidCompleted, "viewers": viewers, "launched_at": now}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// GetSettings returns the channel's raid settings, or nil if it never
+// changed the defaults.
+func (r MongoRaidRepository) GetSettings(ctx context.Context, channelID primitive.ObjectID) (*models.RaidSettings, error) {
+	var s models.RaidSettings
+	err := r.db.Collection("raid_settings").FindOne(ctx, bson.M{"_id": channelID}).Decode(&s)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SaveSettings writes the channel's raid settings.
+func (r MongoRaidRepository) SaveSettings(ctx context.Context, s *models.RaidSettings) error {
+	_, err := r.db.Collection("raid_settings").ReplaceOne(ctx, bson.M{"_id": s.ChannelID}, s, options.Replace().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,391 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// The interfaces below describe the stores the services use, so that
+// callers can be handed either implementation: a Mongo one that talks to
+// the collections of a *mongo.Database, or the in-memory one used in
+// tests. Both must pass the contract suite in repotest. New stores should follow the same pattern.
+//
+// Lookups return nil, nil when nothing matches. Writes that break a
+// uniqueness rule return an error for which mongo.IsDuplicateKeyError
+// reports true, whatever the implementation. Lists are never nil.
+
+// UserRepository stores user accounts. Usernames and emails are unique.
+type UserRepository interface {
+	// Create inserts the user, assigning an ID if it has none.
+	Create(ctx context.Context, user *models.User) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	// FindByUsername returns nil for an empty username.
+	FindByUsername(ctx context.Context, username string) (*models.User, error)
+	// FindByEmailOrUsername matches every non-empty argument.
+	FindByEmailOrUsername(ctx context.Context, email, username string) (*models.User, error)
+	// ListPublicProfiles returns the profiles of the given users ordered by
+	// username, skipping unknown IDs.
+	ListPublicProfiles(ctx context.Context, ids []primitive.ObjectID) ([]models.PublicProfile, error)
+	// SetStreamSuspension bars the user from going live until the given
+	// time, or lifts the suspension when until is nil.
+	SetStreamSuspension(ctx context.Context, id primitive.ObjectID, until *time.Time, reason string) error
+}
+
+// SessionRepository stores login sessions by their UUID.
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	Get(ctx context.Context, sessionID string) (*models.Session, error)
+}
+
+// StreamKeyRepository stores stream keys; a user has at most one.
+type StreamKeyRepository interface {
+	// Create inserts the key and sets its ID.
+	Create(ctx context.Context, key *models.StreamKey) error
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) (*models.StreamKey, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.StreamKey, error)
+	// DeleteByUserID removes the user's key, if any.
+	DeleteByUserID(ctx context.Context, userID primitive.ObjectID) error
+}
+
+// StreamRepository stores one stream document per user. The setters
+// create the document, with empty details, if the user has none yet.
+// Listings leave out unlisted and private streams.
+type StreamRepository interface {
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) (*models.Stream, error)
+	FindByUsername(ctx context.Context, username string) (*models.Stream, error)
+	UpsertDetails(ctx context.Context, userID primitive.ObjectID, username string, d StreamDetails) (*models.Stream, error)
+	// SetLive records the start or end of a broadcast at the given time.
+	// Going live assigns a new broadcast ID.
+	SetLive(ctx context.Context, userID primitive.ObjectID, username string, live bool, at time.Time) (*models.Stream, error)
+	SetVisibility(ctx context.Context, userID primitive.ObjectID, username, visibility string) (*models.Stream, error)
+	SetSubscribersOnly(ctx context.Context, userID primitive.ObjectID, username string, on bool) (*models.Stream, error)
+	AddViewer(ctx context.Context, userID primitive.ObjectID, username string, viewerID primitive.ObjectID) (*models.Stream, error)
+	RemoveViewer(ctx context.Context, userID primitive.ObjectID, username string, viewerID primitive.ObjectID) (*models.Stream, error)
+	CountInCategory(ctx context.Context, slug string) (int64, error)
+	// TrendingTags counts the tags of streams live now or since since.
+	TrendingTags(ctx context.Context, since time.Time, limit int) ([]TagCount, error)
+	ListLiveByUserIDs(ctx context.Context, userIDs []primitive.ObjectID, limit int) ([]models.Stream, error)
+	ListEndedByUserIDs(ctx context.Context, userIDs []primitive.ObjectID, since time.Time, limit int) ([]models.Stream, error)
+	ListLive(ctx context.Context, limit int) ([]models.Stream, error)
+}
+
+// CategoryRepository stores the category catalog. Slugs are unique.
+type CategoryRepository interface {
+	// List orders the catalog by sort order, then name.
+	List(ctx context.Context) ([]models.Category, error)
+	ListWithLiveCounts(ctx context.Context) ([]CategoryLiveCount, error)
+	FindBySlug(ctx context.Context, slug string) (*models.Category, error)
+	Create(ctx context.Context, cat *models.Category) error
+	// Update returns nil if no category has the slug.
+	Update(ctx context.Context, slug string, u CategoryUpdate) (*models.Category, error)
+	Delete(ctx context.Context, slug string) (bool, error)
+	Count(ctx context.Context) (int64, error)
+}
+
+// FollowRepository stores follow edges. An edge exists at most once, and
+// writing one adjusts the follow counters of both users with it.
+type FollowRepository interface {
+	Create(ctx context.Context, f *models.Follow) error
+	Delete(ctx context.Context, followerID, channelID primitive.ObjectID) (bool, error)
+	IsFollowing(ctx context.Context, followerID, channelID primitive.ObjectID) (bool, error)
+	Find(ctx context.Context, followerID, channelID primitive.ObjectID) (*models.Follow, error)
+	ListFollowers(ctx context.Context, channelID primitive.ObjectID, after *TimeCursor, limit int) ([]FollowEntry, error)
+	ListFollowing(ctx context.Context, followerID primitive.ObjectID, after *TimeCursor, limit int) ([]FollowEntry, error)
+	FollowedChannelIDs(ctx context.Context, followerID primitive.ObjectID, limit int) ([]primitive.ObjectID, error)
+	// FollowerIDsAfter walks a channel's followers in edge order.
+	FollowerIDsAfter(ctx context.Context, channelID, afterEdge primitive.ObjectID, limit int) ([]primitive.ObjectID, primitive.ObjectID, error)
+}
+
+// NotificationRepository stores notification inboxes.
+type NotificationRepository interface {
+	Insert(ctx context.Context, ns []*models.Notification) error
+	List(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, after *TimeCursor, limit int) ([]models.Notification, error)
+	CountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	// MarkRead marks the given notifications, or all with no IDs.
+	MarkRead(ctx context.Context, userID primitive.ObjectID, ids []primitive.ObjectID) (int64, error)
+}
+
+// ChatRepository stores chat messages by room, the channel's username.
+type ChatRepository interface {
+	Insert(ctx context.Context, m *models.ChatMessage) error
+	// List leaves out deleted messages.
+	List(ctx context.Context, channel string, after *TimeCursor, limit int) ([]models.ChatMessage, error)
+	LastMessageAt(ctx context.Context, channel string, userID primitive.ObjectID) (time.Time, error)
+	MarkDeleted(ctx context.Context, channel string, id, by primitive.ObjectID) (*models.ChatMessage, error)
+	MarkUserMessagesDeleted(ctx context.Context, channel string, userID, by primitive.ObjectID) error
+}
+
+// ModerationRepository stores channel moderators, bans and timeouts, chat
+// settings and the moderation log.
+type ModerationRepository interface {
+	AddModerator(ctx context.Context, m *models.ChannelModerator) (bool, error)
+	RemoveModerator(ctx context.Context, channelID, userID primitive.ObjectID) (bool, error)
+	IsModerator(ctx context.Context, channelID, userID primitive.ObjectID) (bool, error)
+	ListModerators(ctx context.Context, channelID primitive.ObjectID) ([]models.ChannelModerator, error)
+	// UpsertBan replaces the user's ban in the channel, if any.
+	UpsertBan(ctx context.Context, b *models.ChannelBan) error
+	DeleteBan(ctx context.Context, channelID, userID primitive.ObjectID) (bool, error)
+	// FindActiveBan and ListActiveBans skip timeouts that ran out by now.
+	FindActiveBan(ctx context.Context, channelID, userID primitive.ObjectID, now time.Time) (*models.ChannelBan, error)
+	ListActiveBans(ctx context.Context, channelID primitive.ObjectID, now time.Time) ([]models.ChannelBan, error)
+	GetChatSettings(ctx context.Context, channelID primitive.ObjectID) (*models.ChatSettings, error)
+	SaveChatSettings(ctx context.Context, s *models.ChatSettings) error
+	InsertAction(ctx context.Context, a *models.ModerationAction) error
+	// ListActions filters by action unless it is empty.
+	ListActions(ctx context.Context, channelID primitive.ObjectID, action string, after *TimeCursor, limit int) ([]models.ModerationAction, error)
+}
+
+// VideoRepository stores recordings, one per broadcast.
+type VideoRepository interface {
+	Create(ctx context.Context, v *models.Video) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Video, error)
+	FindByBroadcastID(ctx context.Context, broadcastID primitive.ObjectID) (*models.Video, error)
+	Finish(ctx context.Context, id primitive.ObjectID, duration float64, segments int, endedAt time.Time) error
+	UpdateTitle(ctx context.Context, id primitive.ObjectID, title string) (*models.Video, error)
+	SetSubscribersOnly(ctx context.Context, id primitive.ObjectID, on bool) (*models.Video, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// ListByUser leaves out videos with one of the hidden visibilities.
+	ListByUser(ctx context.Context, userID primitive.ObjectID, hidden []string, after *TimeCursor, limit int) ([]models.Video, error)
+	ListByStatus(ctx context.Context, status string) ([]models.Video, error)
+	ListByBroadcastIDs(ctx context.Context, broadcastIDs []primitive.ObjectID) ([]models.Video, error)
+}
+
+// ClipRepository stores clips.
+type ClipRepository interface {
+	Create(ctx context.Context, c *models.Clip) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Clip, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	IncViews(ctx context.Context, id primitive.ObjectID) error
+	// ListByChannel leaves out clips with one of the hidden visibilities.
+	ListByChannel(ctx context.Context, channelID primitive.ObjectID, hidden []string, after *TimeCursor, limit int) ([]models.Clip, error)
+}
+
+// StreamInviteRepository stores invite links to private streams. Codes
+// are unique.
+type StreamInviteRepository interface {
+	Create(ctx context.Context, inv *models.StreamInvite) error
+	List(ctx context.Context, channelID primitive.ObjectID) ([]models.StreamInvite, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.StreamInvite, error)
+	Delete(ctx context.Context, channelID, id primitive.ObjectID) (bool, error)
+	// Use counts one use of a valid code, or returns nil.
+	Use(ctx context.Context, channelID primitive.ObjectID, code string, now time.Time) (*models.StreamInvite, error)
+}
+
+// RestreamRepository stores restream destinations.
+type RestreamRepository interface {
+	Create(ctx context.Context, d *models.RestreamDestination) error
+	List(ctx context.Context, userID primitive.ObjectID, enabledOnly bool) ([]models.RestreamDestination, error)
+	Count(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	Update(ctx context.Context, userID, id primitive.ObjectID, u RestreamDestinationUpdate) (*models.RestreamDestination, error)
+	Delete(ctx context.Context, userID, id primitive.ObjectID) (bool, error)
+}
+
+// AnalyticsRepository stores viewer sessions and daily channel counters.
+type AnalyticsRepository interface {
+	InsertViewerSessions(ctx context.Context, sessions []models.ViewerSession) error
+	ListViewerSessions(ctx context.Context, channelID primitive.ObjectID, from, to time.Time) ([]models.ViewerSession, error)
+	ListBroadcastViewerSessions(ctx context.Context, broadcastIDs []primitive.ObjectID) ([]models.ViewerSession, error)
+	ListViewerHistory(ctx context.Context, viewer string, since time.Time, limit int) ([]models.ViewerSession, error)
+	CoWatchedChannels(ctx context.Context, channelID primitive.ObjectID, viewer string, since time.Time, sample, limit int) ([]ChannelViewers, error)
+	// IncDailyStat counts one StatChatMessages or StatNewFollowers.
+	IncDailyStat(ctx context.Context, channelID primitive.ObjectID, day, field string) error
+	ListDailyStats(ctx context.Context, channelID primitive.ObjectID, from, to string) ([]models.DailyStats, error)
+}
+
+// BroadcastRepository stores the history of broadcasts.
+type BroadcastRepository interface {
+	// Create is a no-op if the broadcast exists.
+	Create(ctx context.Context, b *models.Broadcast) error
+	End(ctx context.Context, id primitive.ObjectID, endedAt time.Time, duration float64, peak int) error
+	// SetVisibility also changes the recording while it is in progress.
+	SetVisibility(ctx context.Context, id primitive.ObjectID, visibility string) error
+	AddEvents(ctx context.Context, id primitive.ObjectID, events []models.BroadcastEvent) error
+	// IncStat counts one StatChatMessages or StatNewFollowers.
+	IncStat(ctx context.Context, id primitive.ObjectID, field string) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Broadcast, error)
+	ListByChannel(ctx context.Context, channelID primitive.ObjectID, hidden []string, after *TimeCursor, limit int) ([]models.Broadcast, error)
+	ListStartedBetween(ctx context.Context, channelID primitive.ObjectID, from, to time.Time) ([]models.Broadcast, error)
+	ListByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Broadcast, error)
+}
+
+// LedgerRepository stores honey transfers as pairs of entries.
+type LedgerRepository interface {
+	// CreateTransfer returns the transfer with the same idempotency key
+	// instead, if there is one, and fails with ErrInsufficientFunds
+	// rather than overdraw a non-system account.
+	CreateTransfer(ctx context.Context, t *models.LedgerTransfer) (existing *models.LedgerTransfer, err error)
+	Balance(ctx context.Context, account string) (int64, error)
+	ListEntries(ctx context.Context, account string, after *TimeCursor, limit int) ([]models.LedgerEntry, error)
+}
+
+// MembershipRepository stores membership tiers and one membership per
+// user and channel.
+type MembershipRepository interface {
+	CreateTier(ctx context.Context, t *models.MembershipTier) error
+	UpdateTier(ctx context.Context, channelID, id primitive.ObjectID, u MembershipTierUpdate) (*models.MembershipTier, error)
+	FindTier(ctx context.Context, id primitive.ObjectID) (*models.MembershipTier, error)
+	ListTiers(ctx context.Context, channelID primitive.ObjectID, includeArchived bool) ([]models.MembershipTier, error)
+	CountTiers(ctx context.Context, channelID primitive.ObjectID) (int64, error)
+	Find(ctx context.Context, channelID, userID primitive.ObjectID) (*models.Membership, error)
+	IsMember(ctx context.Context, channelID, userID primitive.ObjectID, now time.Time) (bool, error)
+	// Start reports false, writing nothing, if an active membership is
+	// in the way.
+	Start(ctx context.Context, m *models.Membership) (bool, error)
+	SetAutoRenew(ctx context.Context, id primitive.ObjectID, autoRenew bool, now time.Time) (*models.Membership, error)
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Membership, error)
+	ListChannelMembers(ctx context.Context, channelID primitive.ObjectID, now time.Time, after *TimeCursor, limit int) ([]models.Membership, error)
+	ListDue(ctx context.Context, now time.Time, limit int) ([]models.Membership, error)
+	// Renew and Expire report false if the membership is no longer
+	// active with the expiry it was loaded with.
+	Renew(ctx context.Context, id primitive.ObjectID, prevExpiry, expiresAt time.Time, tierName string) (bool, error)
+	Expire(ctx context.Context, id primitive.ObjectID, prevExpiry time.Time) (bool, error)
+}
+
+// PollRepository stores polls and one vote per user and poll.
+type PollRepository interface {
+	Create(ctx context.Context, p *models.Poll) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Poll, error)
+	FindOpen(ctx context.Context, channelID primitive.ObjectID, now time.Time) (*models.Poll, error)
+	// Close returns nil if the poll was closed already.
+	Close(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.Poll, error)
+	ListOpenIDs(ctx context.Context, channelID primitive.ObjectID) ([]primitive.ObjectID, error)
+	// InsertVote reports false if the user voted already.
+	InsertVote(ctx context.Context, v *models.PollVote) (bool, error)
+	CountVote(ctx context.Context, id primitive.ObjectID, option int) error
+	ListByBroadcast(ctx context.Context, broadcastID primitive.ObjectID) ([]models.Poll, error)
+}
+
+// PredictionRepository stores predictions and one stake per user and
+// prediction.
+type PredictionRepository interface {
+	Create(ctx context.Context, p *models.Prediction) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Prediction, error)
+	FindActive(ctx context.Context, channelID primitive.ObjectID) (*models.Prediction, error)
+	// Lock and End return nil if the prediction had moved on already.
+	Lock(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.Prediction, error)
+	End(ctx context.Context, id primitive.ObjectID, winning *int, by primitive.ObjectID, now time.Time) (*models.Prediction, error)
+	MarkSettled(ctx context.Context, id primitive.ObjectID) error
+	ListUnsettled(ctx context.Context, channelID primitive.ObjectID) ([]models.Prediction, error)
+	ListByBroadcast(ctx context.Context, broadcastID primitive.ObjectID) ([]models.Prediction, error)
+	// PlaceStake records the stake and pays for it with t, all or
+	// nothing. It fails with ErrPredictionNotOpen or ErrInsufficientFunds,
+	// and reports false if the user staked already.
+	PlaceStake(ctx context.Context, s *models.PredictionStake, t *models.LedgerTransfer, now time.Time) (bool, error)
+	ListStakes(ctx context.Context, predictionID primitive.ObjectID) ([]models.PredictionStake, error)
+	SetStakePayout(ctx context.Context, id primitive.ObjectID, payout int64) error
+}
+
+// RaidRepository stores raids, at most one pending per channel, and raid
+// settings.
+type RaidRepository interface {
+	SetPending(ctx context.Context, r *models.Raid) (*models.Raid, error)
+	FindPending(ctx context.Context, fromID primitive.ObjectID) (*models.Raid, error)
+	CancelPending(ctx context.Context, fromID primitive.ObjectID) (bool, error)
+	// Launch reports false if the raid is no longer pending.
+	Launch(ctx context.Context, id primitive.ObjectID, viewers int, now time.Time) (bool, error)
+	GetSettings(ctx context.Context, channelID primitive.ObjectID) (*models.RaidSettings, error)
+	SaveSettings(ctx context.Context, s *models.RaidSettings) error
+}
+
+// SearchRepository searches streams and channels. Only listed streams
+// are found.
+type SearchRepository interface {
+	Streams(ctx context.Context, q StreamSearch) ([]StreamHit, error)
+	Channels(ctx context.Context, text string, after *SearchCursor, limit int) ([]ChannelHit, error)
+	SuggestTags(ctx context.Context, prefix string, limit int) ([]TagCount, error)
+	SuggestChannels(ctx context.Context, prefix string, limit int) ([]ChannelHit, error)
+}
+
+// AccountRepository removes accounts with everything that belongs to them.
+type AccountRepository interface {
+	Delete(ctx context.Context, userID primitive.ObjectID) (*DeletedAccount, error)
+}
+
+// Store groups one implementation of every repository.
+type Store struct {
+	Users         UserRepository
+	Sessions      SessionRepository
+	StreamKeys    StreamKeyRepository
+	Streams       StreamRepository
+	Categories    CategoryRepository
+	Follows       FollowRepository
+	Notifications NotificationRepository
+	Chat          ChatRepository
+	Moderation    ModerationRepository
+	Videos        VideoRepository
+	Clips         ClipRepository
+	StreamInvites StreamInviteRepository
+	Restreams     RestreamRepository
+	Analytics     AnalyticsRepository
+	Broadcasts    BroadcastRepository
+	Ledger        LedgerRepository
+	Memberships   MembershipRepository
+	Polls         PollRepository
+	Predictions   PredictionRepository
+	Raids         RaidRepository
+	Search        SearchRepository
+	Accounts      AccountRepository
+}
+
+// NewMongoStore returns the repositories backed by the collections of d.
+func NewMongoStore(d *mongo.Database) *Store {
+	return &Store{
+		Users:         MongoUserRepository{d},
+		Sessions:      MongoSessionRepository{d},
+		StreamKeys:    MongoStreamKeyRepository{d},
+		Streams:       MongoStreamRepository{d},
+		Categories:    MongoCategoryRepository{d},
+		Follows:       MongoFollowRepository{d},
+		Notifications: MongoNotificationRepository{d},
+		Chat:          MongoChatRepository{d},
+		Moderation:    MongoModerationRepository{d},
+		Videos:        MongoVideoRepository{d},
+		Clips:         MongoClipRepository{d},
+		StreamInvites: MongoStreamInviteRepository{d},
+		Restreams:     MongoRestreamRepository{d},
+		Analytics:     MongoAnalyticsRepository{d},
+		Broadcasts:    MongoBroadcastRepository{d},
+		Ledger:        MongoLedgerRepository{d},
+		Memberships:   MongoMembershipRepository{d},
+		Polls:         MongoPollRepository{d},
+		Predictions:   MongoPredictionRepository{d},
+		Raids:         MongoRaidRepository{d},
+		Search:        MongoSearchRepository{d},
+		Accounts:      MongoAccountRepository{d},
+	}
+}
+
+// NewMemoryStore returns empty in-memory repositories. They share one
+// lock, so writes that MongoDB runs in a transaction, or that touch
+// several collections, are atomic here as well.
+func NewMemoryStore() *Store {
+	d := newMemoryDB()
+	return &Store{
+		Users:         &MemoryUserRepository{d},
+		Sessions:      &MemorySessionRepository{d},
+		StreamKeys:    &MemoryStreamKeyRepository{d},
+		Streams:       &MemoryStreamRepository{d},
+		Categories:    &MemoryCategoryRepository{d},
+		Follows:       &MemoryFollowRepository{d},
+		Notifications: &MemoryNotificationRepository{d},
+		Chat:          &MemoryChatRepository{d},
+		Moderation:    &MemoryModerationRepository{d},
+		Videos:        &MemoryVideoRepository{d},
+		Clips:         &MemoryClipRepository{d},
+		StreamInvites: &MemoryStreamInviteRepository{d},
+		Restreams:     &MemoryRestreamRepository{d},
+		Analytics:     &MemoryAnalyticsRepository{d},
+		Broadcasts:    &MemoryBroadcastRepository{d},
+		Ledger:        &MemoryLedgerRepository{d},
+		Memberships:   &MemoryMembershipRepository{d},
+		Polls:         &MemoryPollRepository{d},
+		Predictions:   &MemoryPredictionRepository{d},
+		Raids:         &MemoryRaidRepository{d},
+		Search:        &MemorySearchRepository{d},
+		Accounts:      &MemoryAccountRepository{d},
+	}
+}
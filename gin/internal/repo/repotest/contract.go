@@ -0,0 +1,386 @@
+// Package repotest holds the contract every implementation of the
+// repository interfaces in package repo must satisfy.
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Run runs the whole contract against stores made by newStore. Every
+// subtest gets a fresh, empty store.
+func Run(t *testing.T, newStore func(t *testing.T) *repo.Store) {
+	t.Run("users", func(t *testing.T) {
+		UserRepository(t, func(t *testing.T) repo.UserRepository { return newStore(t).Users })
+	})
+	t.Run("sessions", func(t *testing.T) {
+		SessionRepository(t, func(t *testing.T) repo.SessionRepository { return newStore(t).Sessions })
+	})
+	t.Run("stream_keys", func(t *testing.T) {
+		StreamKeyRepository(t, func(t *testing.T) repo.StreamKeyRepository { return newStore(t).StreamKeys })
+	})
+	t.Run("streams", func(t *testing.T) {
+		StreamRepository(t, func(t *testing.T) repo.StreamRepository { return newStore(t).Streams })
+	})
+	t.Run("categories", func(t *testing.T) { CategoryRepository(t, newStore) })
+	t.Run("stream_invites", func(t *testing.T) {
+		StreamInviteRepository(t, func(t *testing.T) repo.StreamInviteRepository { return newStore(t).StreamInvites })
+	})
+	t.Run("restreams", func(t *testing.T) {
+		RestreamRepository(t, func(t *testing.T) repo.RestreamRepository { return newStore(t).Restreams })
+	})
+	t.Run("follows", func(t *testing.T) { FollowRepository(t, newStore) })
+	t.Run("notifications", func(t *testing.T) {
+		NotificationRepository(t, func(t *testing.T) repo.NotificationRepository { return newStore(t).Notifications })
+	})
+	t.Run("chat", func(t *testing.T) {
+		ChatRepository(t, func(t *testing.T) repo.ChatRepository { return newStore(t).Chat })
+	})
+	t.Run("moderation", func(t *testing.T) {
+		ModerationRepository(t, func(t *testing.T) repo.ModerationRepository { return newStore(t).Moderation })
+	})
+	t.Run("videos", func(t *testing.T) {
+		VideoRepository(t, func(t *testing.T) repo.VideoRepository { return newStore(t).Videos })
+	})
+	t.Run("clips", func(t *testing.T) {
+		ClipRepository(t, func(t *testing.T) repo.ClipRepository { return newStore(t).Clips })
+	})
+	t.Run("broadcasts", func(t *testing.T) { BroadcastRepository(t, newStore) })
+	t.Run("analytics", func(t *testing.T) {
+		AnalyticsRepository(t, func(t *testing.T) repo.AnalyticsRepository { return newStore(t).Analytics })
+	})
+	t.Run("ledger", func(t *testing.T) {
+		LedgerRepository(t, func(t *testing.T) repo.LedgerRepository { return newStore(t).Ledger })
+	})
+	t.Run("memberships", func(t *testing.T) {
+		MembershipRepository(t, func(t *testing.T) repo.MembershipRepository { return newStore(t).Memberships })
+	})
+	t.Run("polls", func(t *testing.T) {
+		PollRepository(t, func(t *testing.T) repo.PollRepository { return newStore(t).Polls })
+	})
+	t.Run("predictions", func(t *testing.T) { PredictionRepository(t, newStore) })
+	t.Run("raids", func(t *testing.T) {
+		RaidRepository(t, func(t *testing.T) repo.RaidRepository { return newStore(t).Raids })
+	})
+	t.Run("search", func(t *testing.T) { SearchRepository(t, newStore) })
+	t.Run("accounts", func(t *testing.T) { AccountRepository(t, newStore) })
+}
+
+// base is the time the checks count from. It has no sub-millisecond
+// part, so it survives a round trip through BSON unchanged.
+var base = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// at returns the time the given number of minutes after base.
+func at(minutes int) time.Time {
+	return base.Add(time.Duration(minutes) * time.Minute)
+}
+
+// check fails the test if err is not nil.
+func check(t *testing.T, what string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", what, err)
+	}
+}
+
+func newUser(name string) *models.User {
+	return &models.User{Username: name, DisplayName: name, Email: name + "@example.com"}
+}
+
+// UserRepository checks a UserRepository.
+func UserRepository(t *testing.T, newRepo func(t *testing.T) repo.UserRepository) {
+	ctx := context.Background()
+
+	t.Run("create assigns an ID and finds the user", func(t *testing.T) {
+		r := newRepo(t)
+		u := newUser("alice")
+		if err := r.Create(ctx, u); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if u.ID.IsZero() {
+			t.Fatal("Create left the ID zero")
+		}
+		for name, find := range map[string]func() (*models.User, error){
+			"FindByID":              func() (*models.User, error) { return r.FindByID(ctx, u.ID) },
+			"FindByUsername":        func() (*models.User, error) { return r.FindByUsername(ctx, "alice") },
+			"FindByEmailOrUsername": func() (*models.User, error) { return r.FindByEmailOrUsername(ctx, "alice@example.com", "") },
+		} {
+			got, err := find()
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if got == nil || got.ID != u.ID || got.Username != "alice" || got.Email != "alice@example.com" {
+				t.Fatalf("%s = %+v, want alice", name, got)
+			}
+		}
+	})
+
+	t.Run("keeps a given ID", func(t *testing.T) {
+		r := newRepo(t)
+		u := newUser("bob")
+		u.ID = primitive.NewObjectID()
+		want := u.ID
+		if err := r.Create(ctx, u); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if u.ID != want {
+			t.Fatalf("ID = %s, want %s", u.ID.Hex(), want.Hex())
+		}
+	})
+
+	t.Run("unknown users are nil", func(t *testing.T) {
+		r := newRepo(t)
+		if err := r.Create(ctx, newUser("carol")); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if got, err := r.FindByID(ctx, primitive.NewObjectID()); err != nil || got != nil {
+			t.Fatalf("FindByID = %v, %v; want nil, nil", got, err)
+		}
+		if got, err := r.FindByUsername(ctx, "nobody"); err != nil || got != nil {
+			t.Fatalf("FindByUsername = %v, %v; want nil, nil", got, err)
+		}
+		if got, err := r.FindByUsername(ctx, ""); err != nil || got != nil {
+			t.Fatalf("FindByUsername(\"\") = %v, %v; want nil, nil", got, err)
+		}
+	})
+
+	t.Run("email and username must both match", func(t *testing.T) {
+		r := newRepo(t)
+		if err := r.Create(ctx, newUser("dave")); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if got, err := r.FindByEmailOrUsername(ctx, "dave@example.com", "dave"); err != nil || got == nil {
+			t.Fatalf("both matching = %v, %v; want dave", got, err)
+		}
+		if got, err := r.FindByEmailOrUsername(ctx, "other@example.com", "dave"); err != nil || got != nil {
+			t.Fatalf("email mismatch = %v, %v; want nil, nil", got, err)
+		}
+	})
+
+	t.Run("usernames and emails are unique", func(t *testing.T) {
+		r := newRepo(t)
+		if err := r.Create(ctx, newUser("erin")); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		sameName := newUser("erin")
+		sameName.Email = "erin2@example.com"
+		if err := r.Create(ctx, sameName); !mongo.IsDuplicateKeyError(err) {
+			t.Fatalf("duplicate username: err = %v, want a duplicate key error", err)
+		}
+		sameEmail := newUser("erin2")
+		sameEmail.Email = "erin@example.com"
+		if err := r.Create(ctx, sameEmail); !mongo.IsDuplicateKeyError(err) {
+			t.Fatalf("duplicate email: err = %v, want a duplicate key error", err)
+		}
+	})
+
+	t.Run("public profiles are ordered by username", func(t *testing.T) {
+		r := newRepo(t)
+		var ids []primitive.ObjectID
+		for _, name := range []string{"zed", "amy", "mel"} {
+			u := newUser(name)
+			if err := r.Create(ctx, u); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			ids = append(ids, u.ID)
+		}
+		got, err := r.ListPublicProfiles(ctx, append(ids, primitive.NewObjectID()))
+		if err != nil {
+			t.Fatalf("ListPublicProfiles: %v", err)
+		}
+		var names []string
+		for _, p := range got {
+			names = append(names, p.Username)
+		}
+		if fmt.Sprint(names) != "[amy mel zed]" {
+			t.Fatalf("profiles = %v, want [amy mel zed]", names)
+		}
+		empty, err := r.ListPublicProfiles(ctx, nil)
+		if err != nil || empty == nil || len(empty) != 0 {
+			t.Fatalf("no IDs = %v, %v; want an empty list", empty, err)
+		}
+	})
+
+	t.Run("stream suspension is set and lifted", func(t *testing.T) {
+		r := newRepo(t)
+		u := newUser("finn")
+		if err := r.Create(ctx, u); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		until := time.Now().UTC().Add(time.Hour).Truncate(time.Millisecond)
+		if err := r.SetStreamSuspension(ctx, u.ID, &until, "spam"); err != nil {
+			t.Fatalf("SetStreamSuspension: %v", err)
+		}
+		got, err := r.FindByID(ctx, u.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if got.StreamSuspendedUntil == nil || !got.StreamSuspendedUntil.Equal(until) || got.StreamSuspendReason != "spam" {
+			t.Fatalf("suspension = %v %q, want %v spam", got.StreamSuspendedUntil, got.StreamSuspendReason, until)
+		}
+		if err := r.SetStreamSuspension(ctx, u.ID, nil, ""); err != nil {
+			t.Fatalf("SetStreamSuspension(nil): %v", err)
+		}
+		if got, _ = r.FindByID(ctx, u.ID); got.StreamSuspendedUntil != nil || got.StreamSuspendReason != "" {
+			t.Fatalf("suspension not lifted: %v %q", got.StreamSuspendedUntil, got.StreamSuspendReason)
+		}
+	})
+
+	t.Run("returned users are copies", func(t *testing.T) {
+		r := newRepo(t)
+		u := newUser("gus")
+		if err := r.Create(ctx, u); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		u.DisplayName = "changed"
+		got, _ := r.FindByID(ctx, u.ID)
+		got.Username = "changed"
+		again, _ := r.FindByID(ctx, u.ID)
+		if again.DisplayName != "gus" || again.Username != "gus" {
+			t.Fatalf("stored user changed through a pointer: %+v", again)
+		}
+	})
+
+	t.Run("concurrent creates keep usernames unique", func(t *testing.T) {
+		r := newRepo(t)
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		created := 0
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				u := newUser("hal")
+				u.Email = fmt.Sprintf("hal%d@example.com", i)
+				if err := r.Create(ctx, u); err == nil {
+					mu.Lock()
+					created++
+					mu.Unlock()
+				} else if !mongo.IsDuplicateKeyError(err) {
+					t.Errorf("Create: %v", err)
+				}
+			}(i)
+		}
+		wg.Wait()
+		if created != 1 {
+			t.Fatalf("%d users named hal were created, want 1", created)
+		}
+	})
+}
+
+// SessionRepository checks a SessionRepository.
+func SessionRepository(t *testing.T, newRepo func(t *testing.T) repo.SessionRepository) {
+	ctx := context.Background()
+
+	t.Run("create and get", func(t *testing.T) {
+		r := newRepo(t)
+		userID := primitive.NewObjectID()
+		if err := r.Create(ctx, &models.Session{ID: "s-1", UserID: userID}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		got, err := r.Get(ctx, "s-1")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got == nil || got.ID != "s-1" || got.UserID != userID {
+			t.Fatalf("Get = %+v, want s-1 of %s", got, userID.Hex())
+		}
+	})
+
+	t.Run("unknown sessions are nil", func(t *testing.T) {
+		r := newRepo(t)
+		if got, err := r.Get(ctx, "missing"); err != nil || got != nil {
+			t.Fatalf("Get = %v, %v; want nil, nil", got, err)
+		}
+	})
+
+	t.Run("session IDs are unique", func(t *testing.T) {
+		r := newRepo(t)
+		if err := r.Create(ctx, &models.Session{ID: "s-2", UserID: primitive.NewObjectID()}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		err := r.Create(ctx, &models.Session{ID: "s-2", UserID: primitive.NewObjectID()})
+		if !mongo.IsDuplicateKeyError(err) {
+			t.Fatalf("err = %v, want a duplicate key error", err)
+		}
+	})
+}
+
+// StreamKeyRepository checks a StreamKeyRepository.
+func StreamKeyRepository(t *testing.T, newRepo func(t *testing.T) repo.StreamKeyRepository) {
+	ctx := context.Background()
+
+	t.Run("create sets the ID and finds the key", func(t *testing.T) {
+		r := newRepo(t)
+		userID := primitive.NewObjectID()
+		key := &models.StreamKey{UserID: userID}
+		if err := r.Create(ctx, key); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if key.ID.IsZero() {
+			t.Fatal("Create left the ID zero")
+		}
+		byUser, err := r.FindByUserID(ctx, userID)
+		if err != nil || byUser == nil || byUser.ID != key.ID {
+			t.Fatalf("FindByUserID = %v, %v; want %s", byUser, err, key.ID.Hex())
+		}
+		byID, err := r.FindByID(ctx, key.ID)
+		if err != nil || byID == nil || byID.UserID != userID {
+			t.Fatalf("FindByID = %v, %v; want the key of %s", byID, err, userID.Hex())
+		}
+	})
+
+	t.Run("unknown keys are nil", func(t *testing.T) {
+		r := newRepo(t)
+		if got, err := r.FindByUserID(ctx, primitive.NewObjectID()); err != nil || got != nil {
+			t.Fatalf("FindByUserID = %v, %v; want nil, nil", got, err)
+		}
+		if got, err := r.FindByID(ctx, primitive.NewObjectID()); err != nil || got != nil {
+			t.Fatalf("FindByID = %v, %v; want nil, nil", got, err)
+		}
+	})
+
+	t.Run("one key per user", func(t *testing.T) {
+		r := newRepo(t)
+		userID := primitive.NewObjectID()
+		if err := r.Create(ctx, &models.StreamKey{UserID: userID}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if err := r.Create(ctx, &models.StreamKey{UserID: userID}); !mongo.IsDuplicateKeyError(err) {
+			t.Fatalf("second key: err = %v, want a duplicate key error", err)
+		}
+	})
+
+	t.Run("delete frees the user for a new key", func(t *testing.T) {
+		r := newRepo(t)
+		userID := primitive.NewObjectID()
+		old := &models.StreamKey{UserID: userID}
+		if err := r.Create(ctx, old); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if err := r.DeleteByUserID(ctx, userID); err != nil {
+			t.Fatalf("DeleteByUserID: %v", err)
+		}
+		if got, err := r.FindByID(ctx, old.ID); err != nil || got != nil {
+			t.Fatalf("deleted key = %v, %v; want nil, nil", got, err)
+		}
+		if err := r.DeleteByUserID(ctx, userID); err != nil {
+			t.Fatalf("DeleteByUserID without a key: %v", err)
+		}
+		fresh := &models.StreamKey{UserID: userID}
+		if err := r.Create(ctx, fresh); err != nil {
+			t.Fatalf("Create after delete: %v", err)
+		}
+		if fresh.ID == old.ID {
+			t.Fatal("the new key reuses the old ID")
+		}
+	})
+}
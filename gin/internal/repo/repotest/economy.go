@@ -0,0 +1,548 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// grant pays amount into account from system issuance.
+func grant(t *testing.T, r repo.LedgerRepository, account string, amount int64, key string) {
+	t.Helper()
+	_, err := r.CreateTransfer(context.Background(), &models.LedgerTransfer{
+		IdempotencyKey: key, Kind: models.TransferGrant, From: models.SystemIssuance, To: account,
+		Amount: amount, CreatedAt: base,
+	})
+	check(t, "grant", err)
+}
+
+// balance returns the balance of account.
+func balance(t *testing.T, r repo.LedgerRepository, account string) int64 {
+	t.Helper()
+	b, err := r.Balance(context.Background(), account)
+	check(t, "Balance", err)
+	return b
+}
+
+// LedgerRepository checks a LedgerRepository.
+func LedgerRepository(t *testing.T, newRepo func(t *testing.T) repo.LedgerRepository) {
+	ctx := context.Background()
+	alice, bob := models.UserAccountID(primitive.NewObjectID()), models.UserAccountID(primitive.NewObjectID())
+
+	t.Run("transfers move honey once per key", func(t *testing.T) {
+		r := newRepo(t)
+		grant(t, r, alice, 100, "grant-1")
+		tip := &models.LedgerTransfer{IdempotencyKey: "tip-1", Kind: models.TransferTip, From: alice, To: bob, Amount: 30, CreatedAt: at(1)}
+		existing, err := r.CreateTransfer(ctx, tip)
+		if err != nil || existing != nil || tip.ID.IsZero() {
+			t.Fatalf("CreateTransfer = %v, %v (id %s); want a new transfer", existing, err, tip.ID.Hex())
+		}
+		existing, err = r.CreateTransfer(ctx, &models.LedgerTransfer{IdempotencyKey: "tip-1", Kind: models.TransferTip, From: alice, To: bob, Amount: 30, CreatedAt: at(2)})
+		if err != nil || existing == nil || existing.ID != tip.ID {
+			t.Fatalf("repeated CreateTransfer = %v, %v; want the first transfer", existing, err)
+		}
+		if a, b := balance(t, r, alice), balance(t, r, bob); a != 70 || b != 30 {
+			t.Fatalf("balances = %d, %d; want 70, 30", a, b)
+		}
+		if s := balance(t, r, models.SystemIssuance); s != -100 {
+			t.Fatalf("issuance balance = %d, want -100", s)
+		}
+	})
+
+	t.Run("accounts cannot be overdrawn", func(t *testing.T) {
+		r := newRepo(t)
+		grant(t, r, alice, 10, "grant-1")
+		_, err := r.CreateTransfer(ctx, &models.LedgerTransfer{IdempotencyKey: "tip-1", Kind: models.TransferTip, From: alice, To: bob, Amount: 11, CreatedAt: at(1)})
+		if !errors.Is(err, repo.ErrInsufficientFunds) {
+			t.Fatalf("overdraft: err = %v, want ErrInsufficientFunds", err)
+		}
+		if a, b := balance(t, r, alice), balance(t, r, bob); a != 10 || b != 0 {
+			t.Fatalf("balances after overdraft = %d, %d; want 10, 0", a, b)
+		}
+		// The key of a failed transfer is still free.
+		if _, err := r.CreateTransfer(ctx, &models.LedgerTransfer{IdempotencyKey: "tip-1", Kind: models.TransferTip, From: alice, To: bob, Amount: 10, CreatedAt: at(2)}); err != nil {
+			t.Fatalf("retry: %v", err)
+		}
+		if a := balance(t, r, alice); a != 0 {
+			t.Fatalf("balance after retry = %d, want 0", a)
+		}
+	})
+
+	t.Run("entries page newest first", func(t *testing.T) {
+		r := newRepo(t)
+		for i := 1; i <= 3; i++ {
+			_, err := r.CreateTransfer(ctx, &models.LedgerTransfer{
+				IdempotencyKey: fmt.Sprint("grant-", i), Kind: models.TransferGrant, From: models.SystemIssuance, To: alice,
+				Amount: int64(i), CreatedAt: at(i),
+			})
+			check(t, "CreateTransfer", err)
+		}
+		amounts := func(es []models.LedgerEntry) string {
+			var out []int64
+			for _, e := range es {
+				out = append(out, e.Amount)
+			}
+			return fmt.Sprint(out)
+		}
+		page, err := r.ListEntries(ctx, alice, nil, 2)
+		check(t, "ListEntries", err)
+		if amounts(page) != "[3 2]" {
+			t.Fatalf("first page = %s, want [3 2]", amounts(page))
+		}
+		if page[0].Counterparty != models.SystemIssuance || page[0].Kind != models.TransferGrant {
+			t.Fatalf("entry = %+v", page[0])
+		}
+		rest, err := r.ListEntries(ctx, alice, &repo.TimeCursor{At: page[1].CreatedAt, ID: page[1].ID}, 2)
+		check(t, "ListEntries after", err)
+		if amounts(rest) != "[1]" {
+			t.Fatalf("second page = %s, want [1]", amounts(rest))
+		}
+		debits, err := r.ListEntries(ctx, models.SystemIssuance, nil, 10)
+		check(t, "ListEntries of issuance", err)
+		if amounts(debits) != "[-3 -2 -1]" {
+			t.Fatalf("issuance entries = %s, want [-3 -2 -1]", amounts(debits))
+		}
+	})
+}
+
+// MembershipRepository checks a MembershipRepository.
+func MembershipRepository(t *testing.T, newRepo func(t *testing.T) repo.MembershipRepository) {
+	ctx := context.Background()
+
+	t.Run("tiers", func(t *testing.T) {
+		r := newRepo(t)
+		channelID := primitive.NewObjectID()
+		var tiers []*models.MembershipTier
+		for _, price := range []int64{50, 10, 30} {
+			tier := &models.MembershipTier{ChannelID: channelID, Name: fmt.Sprint("tier ", price), Perks: []string{}, Price: price, DurationDays: 30, CreatedAt: base, UpdatedAt: base}
+			check(t, "CreateTier", r.CreateTier(ctx, tier))
+			tiers = append(tiers, tier)
+		}
+		check(t, "CreateTier elsewhere", r.CreateTier(ctx, &models.MembershipTier{ChannelID: primitive.NewObjectID(), Price: 1}))
+
+		archived := true
+		name := "gold"
+		if got, err := r.UpdateTier(ctx, primitive.NewObjectID(), tiers[0].ID, repo.MembershipTierUpdate{Name: &name}); err != nil || got != nil {
+			t.Fatalf("UpdateTier of another channel = %v, %v; want nil, nil", got, err)
+		}
+		got, err := r.UpdateTier(ctx, channelID, tiers[0].ID, repo.MembershipTierUpdate{Name: &name, Archived: &archived})
+		check(t, "UpdateTier", err)
+		if got == nil || got.Name != "gold" || !got.Archived || got.Price != 50 {
+			t.Fatalf("UpdateTier = %+v", got)
+		}
+		names := func(ts []models.MembershipTier) string {
+			var out []string
+			for _, t := range ts {
+				out = append(out, t.Name)
+			}
+			return fmt.Sprint(out)
+		}
+		current, err := r.ListTiers(ctx, channelID, false)
+		check(t, "ListTiers", err)
+		if names(current) != "[tier 10 tier 30]" {
+			t.Fatalf("ListTiers = %s, want cheapest first without the archived one", names(current))
+		}
+		all, err := r.ListTiers(ctx, channelID, true)
+		check(t, "ListTiers with archived", err)
+		if names(all) != "[tier 10 tier 30 gold]" {
+			t.Fatalf("ListTiers with archived = %s", names(all))
+		}
+		if n, err := r.CountTiers(ctx, channelID); err != nil || n != 2 {
+			t.Fatalf("CountTiers = %d, %v; want 2", n, err)
+		}
+		if found, err := r.FindTier(ctx, tiers[1].ID); err != nil || found == nil || found.Price != 10 {
+			t.Fatalf("FindTier = %+v, %v", found, err)
+		}
+	})
+
+	t.Run("one membership per user and channel", func(t *testing.T) {
+		r := newRepo(t)
+		channelID, userID := primitive.NewObjectID(), primitive.NewObjectID()
+		start := func(started, expires int) (*models.Membership, bool) {
+			t.Helper()
+			mb := &models.Membership{
+				ChannelID: channelID, UserID: userID, Username: "viewer", TierName: "basic",
+				Status: models.MembershipActive, AutoRenew: true,
+				StartedAt: at(started), ExpiresAt: at(expires), UpdatedAt: at(started),
+			}
+			ok, err := r.Start(ctx, mb)
+			check(t, "Start", err)
+			return mb, ok
+		}
+		first, ok := start(0, 60)
+		if !ok || first.ID.IsZero() {
+			t.Fatalf("Start = %v (id %s), want a new membership", ok, first.ID.Hex())
+		}
+		if _, ok := start(30, 90); ok {
+			t.Fatal("Start over an active membership succeeded")
+		}
+		if member, err := r.IsMember(ctx, channelID, userID, at(59)); err != nil || !member {
+			t.Fatalf("IsMember before expiry = %v, %v; want true", member, err)
+		}
+		if member, err := r.IsMember(ctx, channelID, userID, at(60)); err != nil || member {
+			t.Fatalf("IsMember at expiry = %v, %v; want false", member, err)
+		}
+
+		canceled, err := r.SetAutoRenew(ctx, first.ID, false, at(10))
+		check(t, "SetAutoRenew", err)
+		if canceled == nil || canceled.AutoRenew || canceled.CanceledAt == nil {
+			t.Fatalf("SetAutoRenew(false) = %+v", canceled)
+		}
+
+		again, ok := start(60, 120)
+		if !ok || again.ID != first.ID || !again.AutoRenew || again.CanceledAt != nil {
+			t.Fatalf("Start after expiry = %v, %+v; want the same membership, renewed", ok, again)
+		}
+		got, err := r.Find(ctx, channelID, userID)
+		check(t, "Find", err)
+		if got == nil || !got.ExpiresAt.Equal(at(120)) {
+			t.Fatalf("Find = %+v", got)
+		}
+		list, err := r.ListByUser(ctx, userID)
+		check(t, "ListByUser", err)
+		if len(list) != 1 {
+			t.Fatalf("ListByUser = %d memberships, want 1", len(list))
+		}
+	})
+
+	t.Run("renewals", func(t *testing.T) {
+		r := newRepo(t)
+		channelID := primitive.NewObjectID()
+		var mbs []*models.Membership
+		for i, expires := range []int{20, 10, 90} {
+			mb := &models.Membership{
+				ChannelID: channelID, UserID: primitive.NewObjectID(), Username: fmt.Sprint("u", i),
+				Status: models.MembershipActive, StartedAt: at(i), ExpiresAt: at(expires),
+			}
+			if ok, err := r.Start(ctx, mb); err != nil || !ok {
+				t.Fatalf("Start = %v, %v", ok, err)
+			}
+			mbs = append(mbs, mb)
+		}
+		usernames := func(list []models.Membership) string {
+			var out []string
+			for _, mb := range list {
+				out = append(out, mb.Username)
+			}
+			return fmt.Sprint(out)
+		}
+
+		due, err := r.ListDue(ctx, at(30), 10)
+		check(t, "ListDue", err)
+		if usernames(due) != "[u1 u0]" {
+			t.Fatalf("ListDue = %s, want [u1 u0]", usernames(due))
+		}
+		members, err := r.ListChannelMembers(ctx, channelID, at(15), nil, 10)
+		check(t, "ListChannelMembers", err)
+		if usernames(members) != "[u2 u0]" {
+			t.Fatalf("ListChannelMembers = %s, want [u2 u0]", usernames(members))
+		}
+
+		if ok, err := r.Renew(ctx, mbs[0].ID, at(19), at(50), "plus"); err != nil || ok {
+			t.Fatalf("Renew with a stale expiry = %v, %v; want false", ok, err)
+		}
+		if ok, err := r.Renew(ctx, mbs[0].ID, at(20), at(50), "plus"); err != nil || !ok {
+			t.Fatalf("Renew = %v, %v; want true", ok, err)
+		}
+		if ok, err := r.Expire(ctx, mbs[0].ID, at(20)); err != nil || ok {
+			t.Fatalf("Expire after a renewal = %v, %v; want false", ok, err)
+		}
+		if ok, err := r.Expire(ctx, mbs[1].ID, at(10)); err != nil || !ok {
+			t.Fatalf("Expire = %v, %v; want true", ok, err)
+		}
+		if ok, err := r.Expire(ctx, mbs[1].ID, at(10)); err != nil || ok {
+			t.Fatalf("second Expire = %v, %v; want false", ok, err)
+		}
+		renewed, _ := r.Find(ctx, channelID, mbs[0].UserID)
+		expired, _ := r.Find(ctx, channelID, mbs[1].UserID)
+		if !renewed.ExpiresAt.Equal(at(50)) || renewed.TierName != "plus" || expired.Status != models.MembershipExpired || expired.AutoRenew {
+			t.Fatalf("renewed %+v, expired %+v", renewed, expired)
+		}
+		if due, _ := r.ListDue(ctx, at(30), 10); len(due) != 0 {
+			t.Fatalf("ListDue after renewals = %s, want none", usernames(due))
+		}
+	})
+}
+
+// PollRepository checks a PollRepository.
+func PollRepository(t *testing.T, newRepo func(t *testing.T) repo.PollRepository) {
+	ctx := context.Background()
+
+	t.Run("votes are counted once per user", func(t *testing.T) {
+		r := newRepo(t)
+		channelID, broadcastID := primitive.NewObjectID(), primitive.NewObjectID()
+		p := &models.Poll{
+			ChannelID: channelID, BroadcastID: broadcastID, Question: "?",
+			Options: []models.PollOption{{Text: "yes"}, {Text: "no"}},
+			Status:  models.PollOpen, CreatedAt: at(0), EndsAt: at(5),
+		}
+		check(t, "Create", r.Create(ctx, p))
+		if p.ID.IsZero() {
+			t.Fatal("Create left the ID zero")
+		}
+
+		if open, err := r.FindOpen(ctx, channelID, at(4)); err != nil || open == nil || open.ID != p.ID {
+			t.Fatalf("FindOpen = %+v, %v; want the poll", open, err)
+		}
+		if open, err := r.FindOpen(ctx, channelID, at(5)); err != nil || open != nil {
+			t.Fatalf("FindOpen after it ends = %+v, %v; want nil", open, err)
+		}
+
+		userID := primitive.NewObjectID()
+		if ok, err := r.InsertVote(ctx, &models.PollVote{PollID: p.ID, ChannelID: channelID, UserID: userID, Option: 1}); err != nil || !ok {
+			t.Fatalf("InsertVote = %v, %v; want true", ok, err)
+		}
+		if ok, err := r.InsertVote(ctx, &models.PollVote{PollID: p.ID, ChannelID: channelID, UserID: userID, Option: 0}); err != nil || ok {
+			t.Fatalf("second InsertVote = %v, %v; want false", ok, err)
+		}
+		check(t, "CountVote", r.CountVote(ctx, p.ID, 1))
+		check(t, "CountVote", r.CountVote(ctx, p.ID, 1))
+		got, err := r.FindByID(ctx, p.ID)
+		check(t, "FindByID", err)
+		if got.TotalVotes != 2 || got.Options[0].Votes != 0 || got.Options[1].Votes != 2 {
+			t.Fatalf("tally = %d, %+v", got.TotalVotes, got.Options)
+		}
+	})
+
+	t.Run("closing", func(t *testing.T) {
+		r := newRepo(t)
+		channelID, broadcastID := primitive.NewObjectID(), primitive.NewObjectID()
+		var polls []*models.Poll
+		for i := 0; i < 3; i++ {
+			p := &models.Poll{ChannelID: channelID, BroadcastID: broadcastID, Question: fmt.Sprint(i), Status: models.PollOpen, CreatedAt: at(10 - i), EndsAt: at(20)}
+			check(t, "Create", r.Create(ctx, p))
+			polls = append(polls, p)
+		}
+		closed, err := r.Close(ctx, polls[0].ID, at(12))
+		check(t, "Close", err)
+		if closed == nil || closed.Status != models.PollClosed || closed.ClosedAt == nil {
+			t.Fatalf("Close = %+v", closed)
+		}
+		if again, err := r.Close(ctx, polls[0].ID, at(13)); err != nil || again != nil {
+			t.Fatalf("second Close = %+v, %v; want nil", again, err)
+		}
+		open, err := r.ListOpenIDs(ctx, channelID)
+		check(t, "ListOpenIDs", err)
+		if len(open) != 2 || !(open[0] == polls[1].ID && open[1] == polls[2].ID || open[0] == polls[2].ID && open[1] == polls[1].ID) {
+			t.Fatalf("ListOpenIDs = %v", open)
+		}
+		list, err := r.ListByBroadcast(ctx, broadcastID)
+		check(t, "ListByBroadcast", err)
+		var questions []string
+		for _, p := range list {
+			questions = append(questions, p.Question)
+		}
+		if fmt.Sprint(questions) != "[2 1 0]" {
+			t.Fatalf("ListByBroadcast = %v, want the oldest first", questions)
+		}
+	})
+}
+
+// PredictionRepository checks a PredictionRepository together with the
+// ledger its stakes are paid through.
+func PredictionRepository(t *testing.T, newStore func(t *testing.T) *repo.Store) {
+	ctx := context.Background()
+
+	create := func(t *testing.T, r repo.PredictionRepository, channelID primitive.ObjectID, created int) *models.Prediction {
+		t.Helper()
+		p := &models.Prediction{
+			ChannelID: channelID, BroadcastID: primitive.NewObjectID(), Title: fmt.Sprint(created),
+			Outcomes: []models.PredictionOutcome{{Text: "win"}, {Text: "lose"}},
+			Status:   models.PredictionOpen, CreatedAt: at(created), LocksAt: at(created + 10),
+		}
+		check(t, "Create", r.Create(ctx, p))
+		if p.ID.IsZero() {
+			t.Fatal("Create left the ID zero")
+		}
+		return p
+	}
+	stake := func(p *models.Prediction, userID primitive.ObjectID, outcome int, amount int64, key string) (*models.PredictionStake, *models.LedgerTransfer) {
+		return &models.PredictionStake{PredictionID: p.ID, ChannelID: p.ChannelID, UserID: userID, Outcome: outcome, Amount: amount, CreatedAt: at(1)},
+			&models.LedgerTransfer{
+				IdempotencyKey: key, Kind: models.TransferPredictionStake,
+				From: models.UserAccountID(userID), To: models.PredictionEscrowAccount(p.ID), Amount: amount, CreatedAt: at(1),
+			}
+	}
+
+	t.Run("stakes are paid all or nothing", func(t *testing.T) {
+		store := newStore(t)
+		p := create(t, store.Predictions, primitive.NewObjectID(), 0)
+		rich, poor := primitive.NewObjectID(), primitive.NewObjectID()
+		grant(t, store.Ledger, models.UserAccountID(rich), 100, "grant-rich")
+		grant(t, store.Ledger, models.UserAccountID(poor), 5, "grant-poor")
+
+		s, tr := stake(p, rich, 0, 40, "stake-rich")
+		if ok, err := store.Predictions.PlaceStake(ctx, s, tr, at(1)); err != nil || !ok {
+			t.Fatalf("PlaceStake = %v, %v; want true", ok, err)
+		}
+		if ok, err := store.Predictions.PlaceStake(ctx, &models.PredictionStake{PredictionID: p.ID, UserID: rich, Outcome: 1, Amount: 10}, &models.LedgerTransfer{
+			IdempotencyKey: "stake-rich-2", From: models.UserAccountID(rich), To: models.PredictionEscrowAccount(p.ID), Amount: 10,
+		}, at(2)); err != nil || ok {
+			t.Fatalf("second stake = %v, %v; want false", ok, err)
+		}
+		s, tr = stake(p, poor, 1, 6, "stake-poor")
+		if ok, err := store.Predictions.PlaceStake(ctx, s, tr, at(2)); !errors.Is(err, repo.ErrInsufficientFunds) || ok {
+			t.Fatalf("stake beyond the balance = %v, %v; want ErrInsufficientFunds", ok, err)
+		}
+
+		if b := balance(t, store.Ledger, models.UserAccountID(rich)); b != 60 {
+			t.Fatalf("staker balance = %d, want 60", b)
+		}
+		if b := balance(t, store.Ledger, models.UserAccountID(poor)); b != 5 {
+			t.Fatalf("failed staker balance = %d, want 5", b)
+		}
+		if b := balance(t, store.Ledger, models.PredictionEscrowAccount(p.ID)); b != 40 {
+			t.Fatalf("escrow balance = %d, want 40", b)
+		}
+		got, err := store.Predictions.FindByID(ctx, p.ID)
+		check(t, "FindByID", err)
+		if got.Outcomes[0].Users != 1 || got.Outcomes[0].Points != 40 || got.Outcomes[1].Users != 0 {
+			t.Fatalf("outcomes = %+v", got.Outcomes)
+		}
+		stakes, err := store.Predictions.ListStakes(ctx, p.ID)
+		check(t, "ListStakes", err)
+		if len(stakes) != 1 || stakes[0].UserID != rich {
+			t.Fatalf("ListStakes = %+v, want the one stake", stakes)
+		}
+		check(t, "SetStakePayout", store.Predictions.SetStakePayout(ctx, stakes[0].ID, 40))
+		if stakes, _ := store.Predictions.ListStakes(ctx, p.ID); stakes[0].Payout != 40 {
+			t.Fatalf("payout = %d, want 40", stakes[0].Payout)
+		}
+	})
+
+	t.Run("locked predictions take no stakes", func(t *testing.T) {
+		store := newStore(t)
+		p := create(t, store.Predictions, primitive.NewObjectID(), 0)
+		userID := primitive.NewObjectID()
+		grant(t, store.Ledger, models.UserAccountID(userID), 100, "grant")
+
+		s, tr := stake(p, userID, 0, 10, "late")
+		if ok, err := store.Predictions.PlaceStake(ctx, s, tr, at(10)); !errors.Is(err, repo.ErrPredictionNotOpen) || ok {
+			t.Fatalf("stake at LocksAt = %v, %v; want ErrPredictionNotOpen", ok, err)
+		}
+		locked, err := store.Predictions.Lock(ctx, p.ID, at(3))
+		check(t, "Lock", err)
+		if locked == nil || locked.Status != models.PredictionLocked {
+			t.Fatalf("Lock = %+v", locked)
+		}
+		if again, err := store.Predictions.Lock(ctx, p.ID, at(4)); err != nil || again != nil {
+			t.Fatalf("second Lock = %+v, %v; want nil", again, err)
+		}
+		s, tr = stake(p, userID, 0, 10, "after-lock")
+		if ok, err := store.Predictions.PlaceStake(ctx, s, tr, at(4)); !errors.Is(err, repo.ErrPredictionNotOpen) || ok {
+			t.Fatalf("stake after Lock = %v, %v; want ErrPredictionNotOpen", ok, err)
+		}
+		if b := balance(t, store.Ledger, models.UserAccountID(userID)); b != 100 {
+			t.Fatalf("balance = %d, want 100", b)
+		}
+		if stakes, _ := store.Predictions.ListStakes(ctx, p.ID); len(stakes) != 0 {
+			t.Fatalf("ListStakes = %+v, want none", stakes)
+		}
+	})
+
+	t.Run("ending and settling", func(t *testing.T) {
+		r := newStore(t).Predictions
+		channelID := primitive.NewObjectID()
+		resolved, canceled, open := create(t, r, channelID, 0), create(t, r, channelID, 1), create(t, r, channelID, 2)
+
+		if active, err := r.FindActive(ctx, channelID); err != nil || active == nil {
+			t.Fatalf("FindActive = %+v, %v", active, err)
+		}
+		winning, by := 1, primitive.NewObjectID()
+		got, err := r.End(ctx, resolved.ID, &winning, by, at(5))
+		check(t, "End", err)
+		if got == nil || got.Status != models.PredictionResolved || got.WinningOutcome == nil || *got.WinningOutcome != 1 || got.EndedBy != by {
+			t.Fatalf("End with a winner = %+v", got)
+		}
+		if again, err := r.End(ctx, resolved.ID, nil, by, at(6)); err != nil || again != nil {
+			t.Fatalf("second End = %+v, %v; want nil", again, err)
+		}
+		if got, err := r.End(ctx, canceled.ID, nil, by, at(6)); err != nil || got == nil || got.Status != models.PredictionCanceled {
+			t.Fatalf("End without a winner = %+v, %v", got, err)
+		}
+		if active, err := r.FindActive(ctx, channelID); err != nil || active == nil || active.ID != open.ID {
+			t.Fatalf("FindActive after ending = %+v, %v; want the open one", active, err)
+		}
+
+		unsettled, err := r.ListUnsettled(ctx, channelID)
+		check(t, "ListUnsettled", err)
+		if len(unsettled) != 2 {
+			t.Fatalf("ListUnsettled = %d predictions, want 2", len(unsettled))
+		}
+		check(t, "MarkSettled", r.MarkSettled(ctx, resolved.ID))
+		unsettled, err = r.ListUnsettled(ctx, channelID)
+		check(t, "ListUnsettled", err)
+		if len(unsettled) != 1 || unsettled[0].ID != canceled.ID {
+			t.Fatalf("ListUnsettled after settling = %+v", unsettled)
+		}
+		byBroadcast, err := r.ListByBroadcast(ctx, open.BroadcastID)
+		check(t, "ListByBroadcast", err)
+		if len(byBroadcast) != 1 || byBroadcast[0].ID != open.ID {
+			t.Fatalf("ListByBroadcast = %+v", byBroadcast)
+		}
+	})
+}
+
+// RaidRepository checks a RaidRepository.
+func RaidRepository(t *testing.T, newRepo func(t *testing.T) repo.RaidRepository) {
+	ctx := context.Background()
+
+	t.Run("one pending raid per channel", func(t *testing.T) {
+		r := newRepo(t)
+		fromID := primitive.NewObjectID()
+		first, err := r.SetPending(ctx, &models.Raid{FromID: fromID, ToID: primitive.NewObjectID(), ToUsername: "a", CreatedAt: at(0)})
+		check(t, "SetPending", err)
+		target := primitive.NewObjectID()
+		second, err := r.SetPending(ctx, &models.Raid{FromID: fromID, ToID: target, ToUsername: "b", CreatedAt: at(1)})
+		check(t, "SetPending again", err)
+		if first == nil || second == nil || second.ID != first.ID || second.ToID != target || second.Status != models.RaidPending {
+			t.Fatalf("SetPending = %+v then %+v; want one raid retargeted", first, second)
+		}
+		if got, err := r.FindPending(ctx, fromID); err != nil || got == nil || got.ToUsername != "b" {
+			t.Fatalf("FindPending = %+v, %v", got, err)
+		}
+
+		if ok, err := r.Launch(ctx, second.ID, 42, at(5)); err != nil || !ok {
+			t.Fatalf("Launch = %v, %v; want true", ok, err)
+		}
+		if ok, err := r.Launch(ctx, second.ID, 42, at(6)); err != nil || ok {
+			t.Fatalf("second Launch = %v, %v; want false", ok, err)
+		}
+		if got, err := r.FindPending(ctx, fromID); err != nil || got != nil {
+			t.Fatalf("FindPending after launch = %+v, %v; want nil", got, err)
+		}
+
+		next, err := r.SetPending(ctx, &models.Raid{FromID: fromID, ToID: target, CreatedAt: at(10)})
+		check(t, "SetPending after launch", err)
+		if next.ID == second.ID {
+			t.Fatal("SetPending reused a launched raid")
+		}
+		if ok, err := r.CancelPending(ctx, fromID); err != nil || !ok {
+			t.Fatalf("CancelPending = %v, %v; want true", ok, err)
+		}
+		if ok, err := r.CancelPending(ctx, fromID); err != nil || ok {
+			t.Fatalf("second CancelPending = %v, %v; want false", ok, err)
+		}
+	})
+
+	t.Run("settings", func(t *testing.T) {
+		r := newRepo(t)
+		channelID := primitive.NewObjectID()
+		if got, err := r.GetSettings(ctx, channelID); err != nil || got != nil {
+			t.Fatalf("GetSettings before saving = %+v, %v; want nil", got, err)
+		}
+		blocked := primitive.NewObjectID()
+		check(t, "SaveSettings", r.SaveSettings(ctx, &models.RaidSettings{ChannelID: channelID, FollowersOnly: true, Blocked: []models.RaidBlock{{ChannelID: blocked}}, UpdatedAt: at(0)}))
+		check(t, "SaveSettings again", r.SaveSettings(ctx, &models.RaidSettings{ChannelID: channelID, Blocked: []models.RaidBlock{{ChannelID: blocked, Username: "pest"}}, UpdatedAt: at(1)}))
+		got, err := r.GetSettings(ctx, channelID)
+		check(t, "GetSettings", err)
+		if got == nil || got.FollowersOnly || !got.Blocks(blocked) || got.Blocked[0].Username != "pest" {
+			t.Fatalf("GetSettings = %+v, want the second save whole", got)
+		}
+	})
+}
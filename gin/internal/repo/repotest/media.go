@@ -0,0 +1,381 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// VideoRepository checks a VideoRepository.
+func VideoRepository(t *testing.T, newRepo func(t *testing.T) repo.VideoRepository) {
+	ctx := context.Background()
+
+	create := func(t *testing.T, r repo.VideoRepository, userID primitive.ObjectID, title, visibility string, started int) *models.Video {
+		t.Helper()
+		v := &models.Video{
+			UserID: userID, BroadcastID: primitive.NewObjectID(), Title: title, Visibility: visibility,
+			Status: models.VideoRecording, StartedAt: at(started), UpdatedAt: at(started),
+		}
+		check(t, "Create", r.Create(ctx, v))
+		if v.ID.IsZero() {
+			t.Fatal("Create left the ID zero")
+		}
+		return v
+	}
+	titles := func(vs []models.Video) string {
+		var out []string
+		for _, v := range vs {
+			out = append(out, v.Title)
+		}
+		return fmt.Sprint(out)
+	}
+
+	t.Run("one video per broadcast", func(t *testing.T) {
+		r := newRepo(t)
+		v := create(t, r, primitive.NewObjectID(), "a", "", 0)
+		err := r.Create(ctx, &models.Video{UserID: v.UserID, BroadcastID: v.BroadcastID})
+		if !mongo.IsDuplicateKeyError(err) {
+			t.Fatalf("second video: err = %v, want a duplicate key error", err)
+		}
+		got, err := r.FindByBroadcastID(ctx, v.BroadcastID)
+		check(t, "FindByBroadcastID", err)
+		if got == nil || got.ID != v.ID {
+			t.Fatalf("FindByBroadcastID = %+v, want %s", got, v.ID.Hex())
+		}
+		if got, err := r.FindByBroadcastID(ctx, primitive.NewObjectID()); err != nil || got != nil {
+			t.Fatalf("FindByBroadcastID of another broadcast = %v, %v; want nil, nil", got, err)
+		}
+	})
+
+	t.Run("finish and edit", func(t *testing.T) {
+		r := newRepo(t)
+		v := create(t, r, primitive.NewObjectID(), "raw", "", 0)
+		check(t, "Finish", r.Finish(ctx, v.ID, 61.5, 31, at(2)))
+		got, err := r.FindByID(ctx, v.ID)
+		check(t, "FindByID", err)
+		if got.Status != models.VideoReady || got.DurationSeconds != 61.5 || got.SegmentCount != 31 ||
+			got.EndedAt == nil || !got.EndedAt.Equal(at(2)) || !got.UpdatedAt.After(v.UpdatedAt) {
+			t.Fatalf("finished video = %+v", got)
+		}
+		renamed, err := r.UpdateTitle(ctx, v.ID, "cut")
+		check(t, "UpdateTitle", err)
+		if renamed == nil || renamed.Title != "cut" || renamed.Status != models.VideoReady {
+			t.Fatalf("UpdateTitle = %+v", renamed)
+		}
+		locked, err := r.SetSubscribersOnly(ctx, v.ID, true)
+		check(t, "SetSubscribersOnly", err)
+		if locked == nil || !locked.SubscribersOnly {
+			t.Fatalf("SetSubscribersOnly = %+v", locked)
+		}
+		if got, err := r.UpdateTitle(ctx, primitive.NewObjectID(), "x"); err != nil || got != nil {
+			t.Fatalf("UpdateTitle of an unknown video = %v, %v; want nil, nil", got, err)
+		}
+		check(t, "Delete", r.Delete(ctx, v.ID))
+		if got, err := r.FindByID(ctx, v.ID); err != nil || got != nil {
+			t.Fatalf("FindByID after delete = %v, %v; want nil, nil", got, err)
+		}
+	})
+
+	t.Run("listings", func(t *testing.T) {
+		r := newRepo(t)
+		userID := primitive.NewObjectID()
+		a := create(t, r, userID, "a", "", 0)
+		b := create(t, r, userID, "b", models.VisibilityPrivate, 1)
+		c := create(t, r, userID, "c", models.VisibilityPublic, 2)
+		create(t, r, primitive.NewObjectID(), "other", "", 3)
+		check(t, "Finish", r.Finish(ctx, b.ID, 1, 1, at(5)))
+
+		page, err := r.ListByUser(ctx, userID, nil, nil, 2)
+		check(t, "ListByUser", err)
+		if titles(page) != "[c b]" {
+			t.Fatalf("first page = %s, want [c b]", titles(page))
+		}
+		rest, err := r.ListByUser(ctx, userID, nil, &repo.TimeCursor{At: page[1].StartedAt, ID: page[1].ID}, 2)
+		check(t, "ListByUser after", err)
+		if titles(rest) != "[a]" {
+			t.Fatalf("second page = %s, want [a]", titles(rest))
+		}
+		visible, err := r.ListByUser(ctx, userID, []string{models.VisibilityPrivate}, nil, 10)
+		check(t, "ListByUser hiding private", err)
+		if titles(visible) != "[c a]" {
+			t.Fatalf("without private = %s, want [c a]", titles(visible))
+		}
+
+		recording, err := r.ListByStatus(ctx, models.VideoRecording)
+		check(t, "ListByStatus", err)
+		if len(recording) != 3 {
+			t.Fatalf("ListByStatus(recording) = %s, want 3 videos", titles(recording))
+		}
+		byBroadcast, err := r.ListByBroadcastIDs(ctx, []primitive.ObjectID{a.BroadcastID, c.BroadcastID, primitive.NewObjectID()})
+		check(t, "ListByBroadcastIDs", err)
+		sort.Slice(byBroadcast, func(i, j int) bool { return byBroadcast[i].Title < byBroadcast[j].Title })
+		if titles(byBroadcast) != "[a c]" {
+			t.Fatalf("ListByBroadcastIDs = %s, want [a c]", titles(byBroadcast))
+		}
+	})
+}
+
+// ClipRepository checks a ClipRepository.
+func ClipRepository(t *testing.T, newRepo func(t *testing.T) repo.ClipRepository) {
+	ctx := context.Background()
+
+	t.Run("clips page newest first and count views", func(t *testing.T) {
+		r := newRepo(t)
+		channelID := primitive.NewObjectID()
+		var clips []*models.Clip
+		for i, visibility := range []string{"", models.VisibilityUnlisted, models.VisibilityPublic} {
+			c := &models.Clip{ChannelID: channelID, Title: fmt.Sprint(i), Visibility: visibility, CreatedAt: at(i)}
+			check(t, "Create", r.Create(ctx, c))
+			if c.ID.IsZero() {
+				t.Fatal("Create left the ID zero")
+			}
+			clips = append(clips, c)
+		}
+		check(t, "Create elsewhere", r.Create(ctx, &models.Clip{ChannelID: primitive.NewObjectID(), CreatedAt: at(9)}))
+		titles := func(cs []models.Clip) string {
+			var out []string
+			for _, c := range cs {
+				out = append(out, c.Title)
+			}
+			return fmt.Sprint(out)
+		}
+
+		page, err := r.ListByChannel(ctx, channelID, nil, nil, 2)
+		check(t, "ListByChannel", err)
+		if titles(page) != "[2 1]" {
+			t.Fatalf("first page = %s, want [2 1]", titles(page))
+		}
+		rest, err := r.ListByChannel(ctx, channelID, nil, &repo.TimeCursor{At: page[1].CreatedAt, ID: page[1].ID}, 2)
+		check(t, "ListByChannel after", err)
+		if titles(rest) != "[0]" {
+			t.Fatalf("second page = %s, want [0]", titles(rest))
+		}
+		listed, err := r.ListByChannel(ctx, channelID, []string{models.VisibilityUnlisted, models.VisibilityPrivate}, nil, 10)
+		check(t, "ListByChannel hiding", err)
+		if titles(listed) != "[2 0]" {
+			t.Fatalf("listed clips = %s, want [2 0]", titles(listed))
+		}
+
+		check(t, "IncViews", r.IncViews(ctx, clips[0].ID))
+		check(t, "IncViews", r.IncViews(ctx, clips[0].ID))
+		got, err := r.FindByID(ctx, clips[0].ID)
+		check(t, "FindByID", err)
+		if got == nil || got.ViewCount != 2 {
+			t.Fatalf("FindByID = %+v, want 2 views", got)
+		}
+		check(t, "Delete", r.Delete(ctx, clips[0].ID))
+		if got, err := r.FindByID(ctx, clips[0].ID); err != nil || got != nil {
+			t.Fatalf("FindByID after delete = %v, %v; want nil, nil", got, err)
+		}
+	})
+}
+
+// BroadcastRepository checks a BroadcastRepository together with the
+// video store its visibility changes reach.
+func BroadcastRepository(t *testing.T, newStore func(t *testing.T) *repo.Store) {
+	ctx := context.Background()
+
+	t.Run("create is idempotent and counters only grow", func(t *testing.T) {
+		r := newStore(t).Broadcasts
+		b := &models.Broadcast{ID: primitive.NewObjectID(), ChannelID: primitive.NewObjectID(), Title: "first", StartedAt: at(0)}
+		check(t, "Create", r.Create(ctx, b))
+		check(t, "Create again", r.Create(ctx, &models.Broadcast{ID: b.ID, ChannelID: b.ChannelID, Title: "second", StartedAt: at(1)}))
+
+		check(t, "End", r.End(ctx, b.ID, at(30), 1800, 12))
+		check(t, "End with a smaller peak", r.End(ctx, b.ID, at(31), 1860, 5))
+		check(t, "IncStat", r.IncStat(ctx, b.ID, repo.StatChatMessages))
+		check(t, "IncStat", r.IncStat(ctx, b.ID, repo.StatChatMessages))
+		check(t, "IncStat", r.IncStat(ctx, b.ID, repo.StatNewFollowers))
+		check(t, "AddEvents", r.AddEvents(ctx, b.ID, []models.BroadcastEvent{{Type: models.BroadcastTitleChanged, From: "first", To: "new", At: at(2)}}))
+		check(t, "AddEvents none", r.AddEvents(ctx, b.ID, nil))
+
+		got, err := r.FindByID(ctx, b.ID)
+		check(t, "FindByID", err)
+		if got == nil || got.Title != "first" || got.EndedAt == nil || !got.EndedAt.Equal(at(31)) ||
+			got.DurationSeconds != 1860 || got.PeakViewers != 12 || got.ChatMessages != 2 || got.NewFollowers != 1 {
+			t.Fatalf("FindByID = %+v", got)
+		}
+		if got.Tags == nil || len(got.Events) != 1 || got.Events[0].To != "new" {
+			t.Fatalf("tags %v, events %+v", got.Tags, got.Events)
+		}
+		if got, err := r.FindByID(ctx, primitive.NewObjectID()); err != nil || got != nil {
+			t.Fatalf("FindByID of an unknown broadcast = %v, %v; want nil, nil", got, err)
+		}
+	})
+
+	t.Run("visibility reaches the recording only while it records", func(t *testing.T) {
+		store := newStore(t)
+		channelID := primitive.NewObjectID()
+		live := &models.Broadcast{ID: primitive.NewObjectID(), ChannelID: channelID, StartedAt: at(0)}
+		done := &models.Broadcast{ID: primitive.NewObjectID(), ChannelID: channelID, StartedAt: at(1)}
+		for _, b := range []*models.Broadcast{live, done} {
+			check(t, "Create", store.Broadcasts.Create(ctx, b))
+		}
+		recording := &models.Video{UserID: channelID, BroadcastID: live.ID, Status: models.VideoRecording}
+		ready := &models.Video{UserID: channelID, BroadcastID: done.ID, Status: models.VideoReady}
+		for _, v := range []*models.Video{recording, ready} {
+			check(t, "Create video", store.Videos.Create(ctx, v))
+		}
+		for _, b := range []*models.Broadcast{live, done} {
+			check(t, "SetVisibility", store.Broadcasts.SetVisibility(ctx, b.ID, models.VisibilityPrivate))
+			got, err := store.Broadcasts.FindByID(ctx, b.ID)
+			check(t, "FindByID", err)
+			if got.Visibility != models.VisibilityPrivate {
+				t.Fatalf("broadcast visibility = %q, want private", got.Visibility)
+			}
+		}
+		if v, _ := store.Videos.FindByID(ctx, recording.ID); v.Visibility != models.VisibilityPrivate {
+			t.Fatalf("recording visibility = %q, want private", v.Visibility)
+		}
+		if v, _ := store.Videos.FindByID(ctx, ready.ID); v.Visibility != "" {
+			t.Fatalf("finished video visibility = %q, want it unchanged", v.Visibility)
+		}
+	})
+
+	t.Run("listings", func(t *testing.T) {
+		r := newStore(t).Broadcasts
+		channelID := primitive.NewObjectID()
+		var all []primitive.ObjectID
+		for i, visibility := range []string{"", models.VisibilityPrivate, models.VisibilityPublic, ""} {
+			b := &models.Broadcast{ID: primitive.NewObjectID(), ChannelID: channelID, Title: fmt.Sprint(i), Visibility: visibility, StartedAt: at(i * 10)}
+			check(t, "Create", r.Create(ctx, b))
+			all = append(all, b.ID)
+		}
+		check(t, "Create elsewhere", r.Create(ctx, &models.Broadcast{ID: primitive.NewObjectID(), ChannelID: primitive.NewObjectID(), StartedAt: at(15)}))
+		titles := func(bs []models.Broadcast) string {
+			var out []string
+			for _, b := range bs {
+				out = append(out, b.Title)
+			}
+			return fmt.Sprint(out)
+		}
+
+		page, err := r.ListByChannel(ctx, channelID, []string{models.VisibilityPrivate}, nil, 2)
+		check(t, "ListByChannel", err)
+		if titles(page) != "[3 2]" {
+			t.Fatalf("first page = %s, want [3 2]", titles(page))
+		}
+		rest, err := r.ListByChannel(ctx, channelID, []string{models.VisibilityPrivate}, &repo.TimeCursor{At: page[1].StartedAt, ID: page[1].ID}, 2)
+		check(t, "ListByChannel after", err)
+		if titles(rest) != "[0]" {
+			t.Fatalf("second page = %s, want [0]", titles(rest))
+		}
+		between, err := r.ListStartedBetween(ctx, channelID, at(10), at(30))
+		check(t, "ListStartedBetween", err)
+		if titles(between) != "[2 1]" {
+			t.Fatalf("ListStartedBetween = %s, want [2 1]", titles(between))
+		}
+		byIDs, err := r.ListByIDs(ctx, []primitive.ObjectID{all[0], all[3], primitive.NewObjectID()})
+		check(t, "ListByIDs", err)
+		sort.Slice(byIDs, func(i, j int) bool { return byIDs[i].Title < byIDs[j].Title })
+		if titles(byIDs) != "[0 3]" {
+			t.Fatalf("ListByIDs = %s, want [0 3]", titles(byIDs))
+		}
+	})
+}
+
+// AnalyticsRepository checks an AnalyticsRepository.
+func AnalyticsRepository(t *testing.T, newRepo func(t *testing.T) repo.AnalyticsRepository) {
+	ctx := context.Background()
+
+	viewers := func(ss []models.ViewerSession) string {
+		var out []string
+		for _, s := range ss {
+			out = append(out, s.Viewer)
+		}
+		sort.Strings(out)
+		return fmt.Sprint(out)
+	}
+
+	t.Run("sessions by time range, broadcast and viewer", func(t *testing.T) {
+		r := newRepo(t)
+		channelID, broadcastID := primitive.NewObjectID(), primitive.NewObjectID()
+		check(t, "InsertViewerSessions", r.InsertViewerSessions(ctx, []models.ViewerSession{
+			{ChannelID: channelID, BroadcastID: broadcastID, Viewer: "early", JoinedAt: at(0), LeftAt: at(9)},
+			{ChannelID: channelID, BroadcastID: broadcastID, Viewer: "edge", JoinedAt: at(0), LeftAt: at(10)},
+			{ChannelID: channelID, BroadcastID: broadcastID, Viewer: "mid", JoinedAt: at(15), LeftAt: at(25)},
+			{ChannelID: channelID, Viewer: "late", JoinedAt: at(20), LeftAt: at(40)},
+			{ChannelID: primitive.NewObjectID(), Viewer: "mid", JoinedAt: at(30), LeftAt: at(35)},
+		}))
+		check(t, "InsertViewerSessions none", r.InsertViewerSessions(ctx, nil))
+
+		overlapping, err := r.ListViewerSessions(ctx, channelID, at(10), at(20))
+		check(t, "ListViewerSessions", err)
+		if viewers(overlapping) != "[edge mid]" {
+			t.Fatalf("ListViewerSessions = %s, want [edge mid]", viewers(overlapping))
+		}
+		for _, s := range overlapping {
+			if s.ID.IsZero() {
+				t.Fatal("a stored session has no ID")
+			}
+		}
+		byBroadcast, err := r.ListBroadcastViewerSessions(ctx, []primitive.ObjectID{broadcastID})
+		check(t, "ListBroadcastViewerSessions", err)
+		if viewers(byBroadcast) != "[early edge mid]" {
+			t.Fatalf("ListBroadcastViewerSessions = %s, want [early edge mid]", viewers(byBroadcast))
+		}
+		if none, err := r.ListBroadcastViewerSessions(ctx, nil); err != nil || none == nil || len(none) != 0 {
+			t.Fatalf("ListBroadcastViewerSessions(nil) = %v, %v; want an empty list", none, err)
+		}
+		history, err := r.ListViewerHistory(ctx, "mid", at(15), 10)
+		check(t, "ListViewerHistory", err)
+		if len(history) != 2 || !history[0].JoinedAt.Equal(at(30)) || !history[1].JoinedAt.Equal(at(15)) {
+			t.Fatalf("ListViewerHistory = %+v, want the latest first", history)
+		}
+	})
+
+	t.Run("co-watched channels are counted by viewer", func(t *testing.T) {
+		r := newRepo(t)
+		home, popular, niche := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+		var sessions []models.ViewerSession
+		watch := func(channelID primitive.ObjectID, viewer string, minute int) {
+			sessions = append(sessions, models.ViewerSession{ChannelID: channelID, Viewer: viewer, JoinedAt: at(minute), LeftAt: at(minute + 1)})
+		}
+		for _, v := range []string{"a", "b", "c", "me"} {
+			watch(home, v, 10)
+			watch(popular, v, 10)
+		}
+		watch(popular, "a", 11) // counted once per viewer
+		watch(niche, "c", 10)
+		watch(niche, "old", 0)
+		watch(home, "old", 0)
+		check(t, "InsertViewerSessions", r.InsertViewerSessions(ctx, sessions))
+
+		got, err := r.CoWatchedChannels(ctx, home, "me", at(5), 100, 10)
+		check(t, "CoWatchedChannels", err)
+		if len(got) != 2 || got[0].ChannelID != popular || got[0].Viewers != 3 || got[1].ChannelID != niche || got[1].Viewers != 1 {
+			t.Fatalf("CoWatchedChannels = %+v, want popular 3, niche 1", got)
+		}
+		top, err := r.CoWatchedChannels(ctx, home, "me", at(5), 100, 1)
+		check(t, "CoWatchedChannels limited", err)
+		if len(top) != 1 || top[0].ChannelID != popular {
+			t.Fatalf("CoWatchedChannels(limit 1) = %+v, want popular", top)
+		}
+	})
+
+	t.Run("daily stats count per day", func(t *testing.T) {
+		r := newRepo(t)
+		channelID := primitive.NewObjectID()
+		for _, inc := range []struct{ day, field string }{
+			{"2025-03-02", repo.StatChatMessages},
+			{"2025-03-01", repo.StatChatMessages},
+			{"2025-03-01", repo.StatChatMessages},
+			{"2025-03-01", repo.StatNewFollowers},
+			{"2025-03-05", repo.StatNewFollowers},
+		} {
+			check(t, "IncDailyStat", r.IncDailyStat(ctx, channelID, inc.day, inc.field))
+		}
+		stats, err := r.ListDailyStats(ctx, channelID, "2025-03-01", "2025-03-02")
+		check(t, "ListDailyStats", err)
+		if len(stats) != 2 || stats[0].Day != "2025-03-01" || stats[0].ChatMessages != 2 || stats[0].NewFollowers != 1 ||
+			stats[1].Day != "2025-03-02" || stats[1].ChatMessages != 1 {
+			t.Fatalf("ListDailyStats = %+v", stats)
+		}
+	})
+}
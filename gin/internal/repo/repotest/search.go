@@ -0,0 +1,256 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SearchRepository checks a SearchRepository over streams and users
+// written through the other stores.
+func SearchRepository(t *testing.T, newStore func(t *testing.T) *repo.Store) {
+	ctx := context.Background()
+
+	// stream saves a stream and pins its updated_at to at(minute).
+	stream := func(t *testing.T, store *repo.Store, name string, d repo.StreamDetails, live bool, minute int) {
+		t.Helper()
+		userID := primitive.NewObjectID()
+		_, err := store.Streams.UpsertDetails(ctx, userID, name, d)
+		check(t, "UpsertDetails", err)
+		_, err = store.Streams.SetLive(ctx, userID, name, live, at(minute))
+		check(t, "SetLive", err)
+	}
+	owners := func(hits []repo.StreamHit) string {
+		var out []string
+		for _, h := range hits {
+			out = append(out, h.Username)
+		}
+		return fmt.Sprint(out)
+	}
+	seed := func(t *testing.T) *repo.Store {
+		store := newStore(t)
+		stream(t, store, "titled", repo.StreamDetails{Title: "chess night", Category: "games", Tags: []string{}}, true, 1)
+		stream(t, store, "tagged", repo.StreamDetails{Title: "music", Category: "music", Tags: []string{"chess"}}, false, 2)
+		stream(t, store, "described", repo.StreamDetails{Title: "variety", Description: "we sometimes play chess", Category: "games", Tags: []string{"cozy"}}, true, 3)
+		stream(t, store, "unrelated", repo.StreamDetails{Title: "cooking", Category: "food", Tags: []string{"cozy"}}, true, 4)
+		stream(t, store, "hidden", repo.StreamDetails{Title: "chess", Category: "games", Tags: []string{"chess"}}, true, 5)
+		hidden, err := store.Streams.FindByUsername(ctx, "hidden")
+		check(t, "FindByUsername", err)
+		_, err = store.Streams.SetVisibility(ctx, hidden.UserID, "hidden", models.VisibilityPrivate)
+		check(t, "SetVisibility", err)
+		return store
+	}
+
+	t.Run("streams by relevance", func(t *testing.T) {
+		r := seed(t).Search
+		hits, err := r.Streams(ctx, repo.StreamSearch{Text: "chess", Limit: 10})
+		check(t, "Streams", err)
+		if owners(hits) != "[titled tagged described]" {
+			t.Fatalf("Streams = %s, want title, then tag, then description matches", owners(hits))
+		}
+		if !(hits[0].Score > hits[1].Score && hits[1].Score > hits[2].Score && hits[2].Score > 0) {
+			t.Fatalf("scores = %v, %v, %v; want them falling", hits[0].Score, hits[1].Score, hits[2].Score)
+		}
+
+		page, err := r.Streams(ctx, repo.StreamSearch{Text: "chess", Limit: 2})
+		check(t, "Streams", err)
+		last := page[len(page)-1]
+		rest, err := r.Streams(ctx, repo.StreamSearch{Text: "chess", Limit: 2, After: &repo.SearchCursor{Score: last.Score, UpdatedAt: last.UpdatedAt, ID: last.ID}})
+		check(t, "Streams after", err)
+		if owners(page)+owners(rest) != "[titled tagged][described]" {
+			t.Fatalf("pages = %s %s", owners(page), owners(rest))
+		}
+
+		recent, err := r.Streams(ctx, repo.StreamSearch{Text: "chess", SortRecent: true, Limit: 10})
+		check(t, "Streams by recency", err)
+		if owners(recent) != "[described tagged titled]" {
+			t.Fatalf("Streams by recency = %s", owners(recent))
+		}
+	})
+
+	t.Run("stream filters", func(t *testing.T) {
+		r := seed(t).Search
+		for _, tc := range []struct {
+			q    repo.StreamSearch
+			want string
+		}{
+			{repo.StreamSearch{Limit: 10}, "[unrelated described tagged titled]"},
+			{repo.StreamSearch{LiveOnly: true, Limit: 10}, "[unrelated described titled]"},
+			{repo.StreamSearch{Category: "games", Limit: 10}, "[described titled]"},
+			{repo.StreamSearch{Tag: "cozy", Limit: 10}, "[unrelated described]"},
+			{repo.StreamSearch{Text: "chess", LiveOnly: true, Category: "games", Limit: 10}, "[titled described]"},
+			{repo.StreamSearch{Limit: 2}, "[unrelated described]"},
+			{repo.StreamSearch{Text: "knitting", Limit: 10}, "[]"},
+		} {
+			hits, err := r.Streams(ctx, tc.q)
+			check(t, "Streams", err)
+			if owners(hits) != tc.want {
+				t.Errorf("Streams(%+v) = %s, want %s", tc.q, owners(hits), tc.want)
+			}
+		}
+		all, err := r.Streams(ctx, repo.StreamSearch{Limit: 2})
+		check(t, "Streams", err)
+		last := all[1]
+		rest, err := r.Streams(ctx, repo.StreamSearch{Limit: 10, After: &repo.SearchCursor{UpdatedAt: last.UpdatedAt, ID: last.ID}})
+		check(t, "Streams after", err)
+		if owners(rest) != "[tagged titled]" {
+			t.Fatalf("listing after %s = %s, want [tagged titled]", last.Username, owners(rest))
+		}
+	})
+
+	t.Run("tag suggestions skip hidden streams", func(t *testing.T) {
+		r := seed(t).Search
+		tags, err := r.SuggestTags(ctx, "c", 10)
+		check(t, "SuggestTags", err)
+		if fmt.Sprint(tags) != "[{cozy 2} {chess 1}]" {
+			t.Fatalf("SuggestTags = %v, want [{cozy 2} {chess 1}]", tags)
+		}
+	})
+
+	t.Run("channels", func(t *testing.T) {
+		store := newStore(t)
+		for _, u := range []*models.User{
+			{Username: "chessbrah", DisplayName: "Chess Bro"},
+			{Username: "chesspro", DisplayName: "Daily chess"},
+			{Username: "painter", DisplayName: "Chess"},
+			{Username: "baker", DisplayName: "Baker"},
+		} {
+			u.Email = u.Username + "@example.com"
+			u.SetSearchKeys()
+			check(t, "Create user", store.Users.Create(ctx, u))
+		}
+		names := func(hits []repo.ChannelHit) string {
+			var out []string
+			for _, h := range hits {
+				out = append(out, h.Username)
+			}
+			return fmt.Sprint(out)
+		}
+
+		hits, err := store.Search.Channels(ctx, "painter", nil, 10)
+		check(t, "Channels", err)
+		if names(hits) != "[painter]" || hits[0].DisplayName != "Chess" || hits[0].Score <= 0 {
+			t.Fatalf("Channels(painter) = %+v", hits)
+		}
+		hits, err = store.Search.Channels(ctx, "chess", nil, 10)
+		check(t, "Channels", err)
+		if len(hits) != 3 || hits[0].Username != "painter" {
+			t.Fatalf("Channels(chess) = %s, want the whole display name match first", names(hits))
+		}
+		page, err := store.Search.Channels(ctx, "chess", nil, 2)
+		check(t, "Channels", err)
+		last := page[1]
+		rest, err := store.Search.Channels(ctx, "chess", &repo.SearchCursor{Score: last.Score, ID: last.ID}, 2)
+		check(t, "Channels after", err)
+		if names(page)+names(rest) != names(hits[:2])+names(hits[2:]) {
+			t.Fatalf("pages = %s %s, want %s", names(page), names(rest), names(hits))
+		}
+
+		suggested, err := store.Search.SuggestChannels(ctx, "CHESS", 10)
+		check(t, "SuggestChannels", err)
+		if names(suggested) != "[chessbrah chesspro painter]" {
+			t.Fatalf("SuggestChannels(CHESS) = %s", names(suggested))
+		}
+		suggested, err = store.Search.SuggestChannels(ctx, "b", 1)
+		check(t, "SuggestChannels", err)
+		if names(suggested) != "[baker]" {
+			t.Fatalf("SuggestChannels(b, 1) = %s, want [baker]", names(suggested))
+		}
+	})
+}
+
+// AccountRepository checks that deleting an account reaches every store.
+func AccountRepository(t *testing.T, newStore func(t *testing.T) *repo.Store) {
+	ctx := context.Background()
+
+	t.Run("delete removes the account and what it owns", func(t *testing.T) {
+		store := newStore(t)
+		u := createUsers(t, store.Users, "gone", "fan", "idol")
+		gone, fan, idol := u[0], u[1], u[2]
+
+		check(t, "Create follow", store.Follows.Create(ctx, &models.Follow{FollowerID: fan.ID, ChannelID: gone.ID, CreatedAt: at(0)}))
+		check(t, "Create follow", store.Follows.Create(ctx, &models.Follow{FollowerID: gone.ID, ChannelID: idol.ID, CreatedAt: at(0)}))
+		check(t, "Create follow", store.Follows.Create(ctx, &models.Follow{FollowerID: fan.ID, ChannelID: idol.ID, CreatedAt: at(0)}))
+
+		check(t, "Create session", store.Sessions.Create(ctx, &models.Session{ID: "gone-session", UserID: gone.ID}))
+		check(t, "Create stream key", store.StreamKeys.Create(ctx, &models.StreamKey{UserID: gone.ID}))
+		_, err := store.Streams.UpsertDetails(ctx, gone.ID, "gone", repo.StreamDetails{Title: "bye", Tags: []string{}})
+		check(t, "UpsertDetails", err)
+		_, err = store.Streams.AddViewer(ctx, idol.ID, "idol", gone.ID)
+		check(t, "AddViewer", err)
+		_, err = store.Streams.AddViewer(ctx, idol.ID, "idol", fan.ID)
+		check(t, "AddViewer", err)
+		check(t, "SaveSettings", store.Raids.SaveSettings(ctx, &models.RaidSettings{
+			ChannelID: idol.ID, Blocked: []models.RaidBlock{{ChannelID: gone.ID, Username: "gone"}, {ChannelID: fan.ID, Username: "fan"}},
+		}))
+
+		video := &models.Video{UserID: gone.ID, BroadcastID: primitive.NewObjectID(), Status: models.VideoReady, StartedAt: base}
+		check(t, "Create video", store.Videos.Create(ctx, video))
+		ownClip := &models.Clip{ChannelID: gone.ID, CreatorID: fan.ID, CreatedAt: base}
+		madeClip := &models.Clip{ChannelID: idol.ID, CreatorID: gone.ID, CreatedAt: base}
+		otherClip := &models.Clip{ChannelID: idol.ID, CreatorID: fan.ID, CreatedAt: base}
+		for _, c := range []*models.Clip{ownClip, madeClip, otherClip} {
+			check(t, "Create clip", store.Clips.Create(ctx, c))
+		}
+		grant(t, store.Ledger, models.UserAccountID(gone.ID), 50, "grant")
+
+		deleted, err := store.Accounts.Delete(ctx, gone.ID)
+		check(t, "Delete", err)
+		if len(deleted.VideoIDs) != 1 || deleted.VideoIDs[0] != video.ID {
+			t.Fatalf("deleted videos = %v, want [%s]", deleted.VideoIDs, video.ID.Hex())
+		}
+		if len(deleted.ClipIDs) != 2 {
+			t.Fatalf("deleted clips = %v, want the channel's and the one they made", deleted.ClipIDs)
+		}
+
+		if got, err := store.Users.FindByID(ctx, gone.ID); err != nil || got != nil {
+			t.Fatalf("user after delete = %v, %v; want nil", got, err)
+		}
+		if got, err := store.Sessions.Get(ctx, "gone-session"); err != nil || got != nil {
+			t.Fatalf("session after delete = %v, %v; want nil", got, err)
+		}
+		if got, err := store.StreamKeys.FindByUserID(ctx, gone.ID); err != nil || got != nil {
+			t.Fatalf("stream key after delete = %v, %v; want nil", got, err)
+		}
+		if got, err := store.Streams.FindByUserID(ctx, gone.ID); err != nil || got != nil {
+			t.Fatalf("stream after delete = %v, %v; want nil", got, err)
+		}
+		if got, err := store.Videos.FindByID(ctx, video.ID); err != nil || got != nil {
+			t.Fatalf("video after delete = %v, %v; want nil", got, err)
+		}
+		for _, c := range []*models.Clip{ownClip, madeClip} {
+			if got, err := store.Clips.FindByID(ctx, c.ID); err != nil || got != nil {
+				t.Fatalf("clip after delete = %v, %v; want nil", got, err)
+			}
+		}
+		if got, err := store.Clips.FindByID(ctx, otherClip.ID); err != nil || got == nil {
+			t.Fatalf("someone else's clip = %v, %v; want it kept", got, err)
+		}
+
+		f, _ := store.Users.FindByID(ctx, fan.ID)
+		i, _ := store.Users.FindByID(ctx, idol.ID)
+		if f.FollowingCount != 1 || i.FollowerCount != 1 {
+			t.Fatalf("counters = fan following %d, idol followers %d; want 1, 1", f.FollowingCount, i.FollowerCount)
+		}
+		if following, _ := store.Follows.IsFollowing(ctx, fan.ID, gone.ID); following {
+			t.Fatal("a follow edge to the deleted user survived")
+		}
+		s, err := store.Streams.FindByUserID(ctx, idol.ID)
+		check(t, "FindByUserID", err)
+		if len(s.AllowedUserIDs) != 1 || s.AllowedUserIDs[0] != fan.ID {
+			t.Fatalf("allowlist = %v, want only the fan", s.AllowedUserIDs)
+		}
+		settings, err := store.Raids.GetSettings(ctx, idol.ID)
+		check(t, "GetSettings", err)
+		if settings.Blocks(gone.ID) || !settings.Blocks(fan.ID) {
+			t.Fatalf("raid blocks = %+v, want only the fan", settings.Blocked)
+		}
+		if b := balance(t, store.Ledger, models.SystemIssuance); b != -50 {
+			t.Fatalf("issuance balance = %d, want -50: ledger entries are kept", b)
+		}
+	})
+}
@@ -0,0 +1,420 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// createUsers creates users with the given names and returns them.
+func createUsers(t *testing.T, r repo.UserRepository, names ...string) []*models.User {
+	t.Helper()
+	users := make([]*models.User, len(names))
+	for i, name := range names {
+		users[i] = newUser(name)
+		check(t, "Create user", r.Create(context.Background(), users[i]))
+	}
+	return users
+}
+
+// FollowRepository checks a FollowRepository together with the user
+// store whose counters it keeps.
+func FollowRepository(t *testing.T, newStore func(t *testing.T) *repo.Store) {
+	ctx := context.Background()
+
+	counts := func(t *testing.T, store *repo.Store, u *models.User) string {
+		t.Helper()
+		got, err := store.Users.FindByID(ctx, u.ID)
+		check(t, "FindByID", err)
+		return fmt.Sprintf("%d/%d", got.FollowerCount, got.FollowingCount)
+	}
+
+	t.Run("edges are unique and keep the counters", func(t *testing.T) {
+		store := newStore(t)
+		u := createUsers(t, store.Users, "fan", "star")
+		fan, star := u[0], u[1]
+		f := &models.Follow{FollowerID: fan.ID, ChannelID: star.ID, CreatedAt: base}
+		check(t, "Create", store.Follows.Create(ctx, f))
+		if f.ID.IsZero() {
+			t.Fatal("Create left the ID zero")
+		}
+		err := store.Follows.Create(ctx, &models.Follow{FollowerID: fan.ID, ChannelID: star.ID, CreatedAt: at(1)})
+		if !mongo.IsDuplicateKeyError(err) {
+			t.Fatalf("second edge: err = %v, want a duplicate key error", err)
+		}
+		if got := counts(t, store, fan) + " " + counts(t, store, star); got != "0/1 1/0" {
+			t.Fatalf("followers/following = %s, want 0/1 1/0", got)
+		}
+		if ok, err := store.Follows.IsFollowing(ctx, fan.ID, star.ID); err != nil || !ok {
+			t.Fatalf("IsFollowing = %v, %v; want true", ok, err)
+		}
+		if ok, err := store.Follows.IsFollowing(ctx, star.ID, fan.ID); err != nil || ok {
+			t.Fatalf("IsFollowing backwards = %v, %v; want false", ok, err)
+		}
+		found, err := store.Follows.Find(ctx, fan.ID, star.ID)
+		check(t, "Find", err)
+		if found == nil || found.ID != f.ID || !found.CreatedAt.Equal(base) {
+			t.Fatalf("Find = %+v, want the first edge", found)
+		}
+
+		if ok, err := store.Follows.Delete(ctx, fan.ID, star.ID); err != nil || !ok {
+			t.Fatalf("Delete = %v, %v; want true", ok, err)
+		}
+		if ok, err := store.Follows.Delete(ctx, fan.ID, star.ID); err != nil || ok {
+			t.Fatalf("second Delete = %v, %v; want false", ok, err)
+		}
+		if got := counts(t, store, fan) + " " + counts(t, store, star); got != "0/0 0/0" {
+			t.Fatalf("followers/following after delete = %s, want 0/0 0/0", got)
+		}
+		if found, err := store.Follows.Find(ctx, fan.ID, star.ID); err != nil || found != nil {
+			t.Fatalf("Find after delete = %v, %v; want nil, nil", found, err)
+		}
+	})
+
+	t.Run("lists page newest first and join the other user", func(t *testing.T) {
+		store := newStore(t)
+		u := createUsers(t, store.Users, "star", "a", "b", "c")
+		star := u[0]
+		for i, fan := range u[1:] {
+			check(t, "Create", store.Follows.Create(ctx, &models.Follow{FollowerID: fan.ID, ChannelID: star.ID, CreatedAt: at(i)}))
+		}
+		page, err := store.Follows.ListFollowers(ctx, star.ID, nil, 2)
+		check(t, "ListFollowers", err)
+		if len(page) != 2 || page[0].User.Username != "c" || page[1].User.Username != "b" || !page[0].FollowedAt.Equal(at(2)) {
+			t.Fatalf("first page = %+v, want c, b", page)
+		}
+		last := page[1]
+		rest, err := store.Follows.ListFollowers(ctx, star.ID, &repo.TimeCursor{At: last.FollowedAt, ID: last.FollowID}, 2)
+		check(t, "ListFollowers after", err)
+		if len(rest) != 1 || rest[0].User.Username != "a" {
+			t.Fatalf("second page = %+v, want a", rest)
+		}
+		following, err := store.Follows.ListFollowing(ctx, u[1].ID, nil, 10)
+		check(t, "ListFollowing", err)
+		if len(following) != 1 || following[0].User.Username != "star" || following[0].User.FollowerCount != 3 {
+			t.Fatalf("ListFollowing = %+v, want star with 3 followers", following)
+		}
+		ids, err := store.Follows.FollowedChannelIDs(ctx, u[1].ID, 10)
+		check(t, "FollowedChannelIDs", err)
+		if len(ids) != 1 || ids[0] != star.ID {
+			t.Fatalf("FollowedChannelIDs = %v, want [%s]", ids, star.ID.Hex())
+		}
+	})
+
+	t.Run("follower IDs are walked in edge order", func(t *testing.T) {
+		store := newStore(t)
+		channelID := primitive.NewObjectID()
+		var want []primitive.ObjectID
+		for i := 0; i < 5; i++ {
+			f := &models.Follow{FollowerID: primitive.NewObjectID(), ChannelID: channelID, CreatedAt: at(-i)}
+			check(t, "Create", store.Follows.Create(ctx, f))
+			want = append(want, f.FollowerID)
+		}
+		var got []primitive.ObjectID
+		after := primitive.NilObjectID
+		for {
+			ids, last, err := store.Follows.FollowerIDsAfter(ctx, channelID, after, 2)
+			check(t, "FollowerIDsAfter", err)
+			if len(ids) == 0 {
+				if last != after {
+					t.Fatalf("an empty batch moved the position to %s", last.Hex())
+				}
+				break
+			}
+			got, after = append(got, ids...), last
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("followers = %v, want %v", got, want)
+		}
+	})
+}
+
+// NotificationRepository checks a NotificationRepository.
+func NotificationRepository(t *testing.T, newRepo func(t *testing.T) repo.NotificationRepository) {
+	ctx := context.Background()
+
+	insert := func(t *testing.T, r repo.NotificationRepository, userID primitive.ObjectID, n int) []*models.Notification {
+		t.Helper()
+		ns := make([]*models.Notification, n)
+		for i := range ns {
+			ns[i] = &models.Notification{UserID: userID, Type: models.NotificationMention, Message: fmt.Sprint(i), CreatedAt: at(i)}
+		}
+		check(t, "Insert", r.Insert(ctx, ns))
+		for _, x := range ns {
+			if x.ID.IsZero() {
+				t.Fatal("Insert left an ID zero")
+			}
+		}
+		return ns
+	}
+	messages := func(ns []models.Notification) string {
+		var out []string
+		for _, n := range ns {
+			out = append(out, n.Message)
+		}
+		return fmt.Sprint(out)
+	}
+
+	t.Run("the inbox pages newest first", func(t *testing.T) {
+		r := newRepo(t)
+		userID := primitive.NewObjectID()
+		insert(t, r, userID, 3)
+		insert(t, r, primitive.NewObjectID(), 1)
+		page, err := r.List(ctx, userID, false, nil, 2)
+		check(t, "List", err)
+		if messages(page) != "[2 1]" {
+			t.Fatalf("first page = %s, want [2 1]", messages(page))
+		}
+		rest, err := r.List(ctx, userID, false, &repo.TimeCursor{At: page[1].CreatedAt, ID: page[1].ID}, 2)
+		check(t, "List after", err)
+		if messages(rest) != "[0]" {
+			t.Fatalf("second page = %s, want [0]", messages(rest))
+		}
+		if err := r.Insert(ctx, nil); err != nil {
+			t.Fatalf("Insert(nil): %v", err)
+		}
+	})
+
+	t.Run("mark read by ID or all", func(t *testing.T) {
+		r := newRepo(t)
+		userID := primitive.NewObjectID()
+		ns := insert(t, r, userID, 3)
+		if n, err := r.MarkRead(ctx, primitive.NewObjectID(), []primitive.ObjectID{ns[0].ID}); err != nil || n != 0 {
+			t.Fatalf("MarkRead by a stranger = %d, %v; want 0", n, err)
+		}
+		if n, err := r.MarkRead(ctx, userID, []primitive.ObjectID{ns[0].ID}); err != nil || n != 1 {
+			t.Fatalf("MarkRead one = %d, %v; want 1", n, err)
+		}
+		if n, err := r.CountUnread(ctx, userID); err != nil || n != 2 {
+			t.Fatalf("CountUnread = %d, %v; want 2", n, err)
+		}
+		unread, err := r.List(ctx, userID, true, nil, 10)
+		check(t, "List unread", err)
+		if messages(unread) != "[2 1]" {
+			t.Fatalf("unread = %s, want [2 1]", messages(unread))
+		}
+		if n, err := r.MarkRead(ctx, userID, nil); err != nil || n != 2 {
+			t.Fatalf("MarkRead all = %d, %v; want 2", n, err)
+		}
+		all, err := r.List(ctx, userID, false, nil, 10)
+		check(t, "List", err)
+		for _, n := range all {
+			if !n.Read || n.ReadAt == nil {
+				t.Fatalf("notification %s not marked read: %+v", n.Message, n)
+			}
+		}
+	})
+}
+
+// ChatRepository checks a ChatRepository.
+func ChatRepository(t *testing.T, newRepo func(t *testing.T) repo.ChatRepository) {
+	ctx := context.Background()
+
+	post := func(t *testing.T, r repo.ChatRepository, channel string, userID primitive.ObjectID, text string, when time.Time) *models.ChatMessage {
+		t.Helper()
+		m := &models.ChatMessage{Channel: channel, UserID: userID, Text: text, CreatedAt: when}
+		check(t, "Insert", r.Insert(ctx, m))
+		if m.ID.IsZero() {
+			t.Fatal("Insert left the ID zero")
+		}
+		return m
+	}
+	texts := func(ms []models.ChatMessage) string {
+		var out []string
+		for _, m := range ms {
+			out = append(out, m.Text)
+		}
+		return fmt.Sprint(out)
+	}
+
+	t.Run("history pages newest first without deleted messages", func(t *testing.T) {
+		r := newRepo(t)
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		post(t, r, "room", alice, "one", at(0))
+		two := post(t, r, "room", bob, "two", at(1))
+		post(t, r, "room", alice, "three", at(2))
+		post(t, r, "room", bob, "four", at(3))
+		post(t, r, "other", alice, "elsewhere", at(4))
+
+		page, err := r.List(ctx, "room", nil, 2)
+		check(t, "List", err)
+		if texts(page) != "[four three]" {
+			t.Fatalf("first page = %s, want [four three]", texts(page))
+		}
+		rest, err := r.List(ctx, "room", &repo.TimeCursor{At: page[1].CreatedAt, ID: page[1].ID}, 10)
+		check(t, "List after", err)
+		if texts(rest) != "[two one]" {
+			t.Fatalf("second page = %s, want [two one]", texts(rest))
+		}
+
+		mod := primitive.NewObjectID()
+		if got, err := r.MarkDeleted(ctx, "other", two.ID, mod); err != nil || got != nil {
+			t.Fatalf("MarkDeleted in the wrong room = %v, %v; want nil, nil", got, err)
+		}
+		got, err := r.MarkDeleted(ctx, "room", two.ID, mod)
+		check(t, "MarkDeleted", err)
+		if got == nil || !got.Deleted || got.DeletedBy != mod {
+			t.Fatalf("MarkDeleted = %+v", got)
+		}
+		check(t, "MarkUserMessagesDeleted", r.MarkUserMessagesDeleted(ctx, "room", alice, mod))
+		left, err := r.List(ctx, "room", nil, 10)
+		check(t, "List", err)
+		if texts(left) != "[four]" {
+			t.Fatalf("after deleting = %s, want [four]", texts(left))
+		}
+		if other, _ := r.List(ctx, "other", nil, 10); texts(other) != "[elsewhere]" {
+			t.Fatalf("the other room = %s, want [elsewhere]", texts(other))
+		}
+	})
+
+	t.Run("last message time per user and room", func(t *testing.T) {
+		r := newRepo(t)
+		userID := primitive.NewObjectID()
+		if last, err := r.LastMessageAt(ctx, "room", userID); err != nil || !last.IsZero() {
+			t.Fatalf("LastMessageAt before posting = %v, %v; want zero", last, err)
+		}
+		post(t, r, "room", userID, "late", at(5))
+		post(t, r, "room", userID, "early", at(1))
+		post(t, r, "other", userID, "later", at(9))
+		last, err := r.LastMessageAt(ctx, "room", userID)
+		check(t, "LastMessageAt", err)
+		if !last.Equal(at(5)) {
+			t.Fatalf("LastMessageAt = %v, want %v", last, at(5))
+		}
+	})
+}
+
+// ModerationRepository checks a ModerationRepository.
+func ModerationRepository(t *testing.T, newRepo func(t *testing.T) repo.ModerationRepository) {
+	ctx := context.Background()
+
+	t.Run("moderators are added once and listed oldest first", func(t *testing.T) {
+		r := newRepo(t)
+		channelID := primitive.NewObjectID()
+		first, second := primitive.NewObjectID(), primitive.NewObjectID()
+		for i, userID := range []primitive.ObjectID{second, first} {
+			ok, err := r.AddModerator(ctx, &models.ChannelModerator{ChannelID: channelID, UserID: userID, Username: fmt.Sprint(i), CreatedAt: at(i)})
+			if err != nil || !ok {
+				t.Fatalf("AddModerator = %v, %v; want true", ok, err)
+			}
+		}
+		if ok, err := r.AddModerator(ctx, &models.ChannelModerator{ChannelID: channelID, UserID: first, CreatedAt: at(5)}); err != nil || ok {
+			t.Fatalf("AddModerator again = %v, %v; want false", ok, err)
+		}
+		mods, err := r.ListModerators(ctx, channelID)
+		check(t, "ListModerators", err)
+		if len(mods) != 2 || mods[0].UserID != second || mods[1].UserID != first || !mods[1].CreatedAt.Equal(at(1)) {
+			t.Fatalf("ListModerators = %+v", mods)
+		}
+		if ok, err := r.IsModerator(ctx, channelID, first); err != nil || !ok {
+			t.Fatalf("IsModerator = %v, %v; want true", ok, err)
+		}
+		if ok, err := r.RemoveModerator(ctx, channelID, first); err != nil || !ok {
+			t.Fatalf("RemoveModerator = %v, %v; want true", ok, err)
+		}
+		if ok, err := r.RemoveModerator(ctx, channelID, first); err != nil || ok {
+			t.Fatalf("RemoveModerator again = %v, %v; want false", ok, err)
+		}
+		if ok, err := r.IsModerator(ctx, channelID, first); err != nil || ok {
+			t.Fatalf("IsModerator after removal = %v, %v; want false", ok, err)
+		}
+	})
+
+	t.Run("bans replace each other and timeouts run out", func(t *testing.T) {
+		r := newRepo(t)
+		channelID, userID, other := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+		until := at(10)
+		check(t, "UpsertBan", r.UpsertBan(ctx, &models.ChannelBan{ChannelID: channelID, UserID: userID, Reason: "spam", ExpiresAt: &until, CreatedAt: at(0)}))
+		check(t, "UpsertBan", r.UpsertBan(ctx, &models.ChannelBan{ChannelID: channelID, UserID: other, Reason: "rude", CreatedAt: at(1)}))
+
+		ban, err := r.FindActiveBan(ctx, channelID, userID, at(9))
+		check(t, "FindActiveBan", err)
+		if ban == nil || ban.Reason != "spam" {
+			t.Fatalf("FindActiveBan during the timeout = %+v", ban)
+		}
+		if ban, err := r.FindActiveBan(ctx, channelID, userID, at(10)); err != nil || ban != nil {
+			t.Fatalf("FindActiveBan after the timeout = %v, %v; want nil, nil", ban, err)
+		}
+		bans, err := r.ListActiveBans(ctx, channelID, at(9))
+		check(t, "ListActiveBans", err)
+		if len(bans) != 2 || bans[0].UserID != other || bans[1].UserID != userID {
+			t.Fatalf("ListActiveBans = %+v, want the newest first", bans)
+		}
+		if bans, _ := r.ListActiveBans(ctx, channelID, at(11)); len(bans) != 1 || bans[0].UserID != other {
+			t.Fatalf("ListActiveBans after the timeout = %+v, want only the ban", bans)
+		}
+
+		check(t, "UpsertBan", r.UpsertBan(ctx, &models.ChannelBan{ChannelID: channelID, UserID: userID, Reason: "again", CreatedAt: at(20)}))
+		ban, err = r.FindActiveBan(ctx, channelID, userID, at(30))
+		check(t, "FindActiveBan", err)
+		if ban == nil || ban.Reason != "again" || ban.ExpiresAt != nil {
+			t.Fatalf("replaced ban = %+v, want a permanent ban", ban)
+		}
+		if bans, _ := r.ListActiveBans(ctx, channelID, at(30)); len(bans) != 2 {
+			t.Fatalf("replacing a ban changed the count to %d", len(bans))
+		}
+		if ok, err := r.DeleteBan(ctx, channelID, userID); err != nil || !ok {
+			t.Fatalf("DeleteBan = %v, %v; want true", ok, err)
+		}
+		if ok, err := r.DeleteBan(ctx, channelID, userID); err != nil || ok {
+			t.Fatalf("DeleteBan again = %v, %v; want false", ok, err)
+		}
+	})
+
+	t.Run("chat settings are saved whole", func(t *testing.T) {
+		r := newRepo(t)
+		channelID := primitive.NewObjectID()
+		if s, err := r.GetChatSettings(ctx, channelID); err != nil || s != nil {
+			t.Fatalf("GetChatSettings before saving = %v, %v; want nil, nil", s, err)
+		}
+		check(t, "SaveChatSettings", r.SaveChatSettings(ctx, &models.ChatSettings{
+			ChannelID: channelID, SlowModeSeconds: 5, BlockedTerms: []models.BlockedTerm{{Pattern: "x"}},
+		}))
+		check(t, "SaveChatSettings", r.SaveChatSettings(ctx, &models.ChatSettings{ChannelID: channelID, EmoteOnly: true}))
+		s, err := r.GetChatSettings(ctx, channelID)
+		check(t, "GetChatSettings", err)
+		if s == nil || !s.EmoteOnly || s.SlowModeSeconds != 0 || len(s.BlockedTerms) != 0 {
+			t.Fatalf("GetChatSettings = %+v, want only emote mode", s)
+		}
+	})
+
+	t.Run("the log pages newest first and filters by action", func(t *testing.T) {
+		r := newRepo(t)
+		channelID := primitive.NewObjectID()
+		for i, action := range []string{"ban", "timeout", "ban", "delete"} {
+			a := &models.ModerationAction{ChannelID: channelID, Action: action, Reason: fmt.Sprint(i), CreatedAt: at(i)}
+			check(t, "InsertAction", r.InsertAction(ctx, a))
+			if a.ID.IsZero() {
+				t.Fatal("InsertAction left the ID zero")
+			}
+		}
+		reasons := func(as []models.ModerationAction) string {
+			var out []string
+			for _, a := range as {
+				out = append(out, a.Reason)
+			}
+			return fmt.Sprint(out)
+		}
+		page, err := r.ListActions(ctx, channelID, "", nil, 3)
+		check(t, "ListActions", err)
+		if reasons(page) != "[3 2 1]" {
+			t.Fatalf("first page = %s, want [3 2 1]", reasons(page))
+		}
+		rest, err := r.ListActions(ctx, channelID, "", &repo.TimeCursor{At: page[2].CreatedAt, ID: page[2].ID}, 3)
+		check(t, "ListActions after", err)
+		if reasons(rest) != "[0]" {
+			t.Fatalf("second page = %s, want [0]", reasons(rest))
+		}
+		bans, err := r.ListActions(ctx, channelID, "ban", nil, 10)
+		check(t, "ListActions ban", err)
+		if reasons(bans) != "[2 0]" {
+			t.Fatalf("bans = %s, want [2 0]", reasons(bans))
+		}
+	})
+}
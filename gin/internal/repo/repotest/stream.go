@@ -0,0 +1,401 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// streamOwners returns the usernames of streams, in order.
+func streamOwners(streams []models.Stream) string {
+	var names []string
+	for _, s := range streams {
+		names = append(names, s.Username)
+	}
+	return fmt.Sprint(names)
+}
+
+// StreamRepository checks a StreamRepository.
+func StreamRepository(t *testing.T, newRepo func(t *testing.T) repo.StreamRepository) {
+	ctx := context.Background()
+
+	t.Run("setters create the stream with empty details", func(t *testing.T) {
+		r := newRepo(t)
+		userID := primitive.NewObjectID()
+		s, err := r.SetVisibility(ctx, userID, "ann", models.VisibilityUnlisted)
+		check(t, "SetVisibility", err)
+		if s == nil || s.UserID != userID || s.Username != "ann" || s.Visibility != models.VisibilityUnlisted ||
+			s.Live || s.Title != "" || s.Tags == nil || len(s.Tags) != 0 {
+			t.Fatalf("created stream = %+v", s)
+		}
+		byName, err := r.FindByUsername(ctx, "ann")
+		check(t, "FindByUsername", err)
+		if byName == nil || byName.ID != s.ID {
+			t.Fatalf("FindByUsername = %+v, want %s", byName, s.ID.Hex())
+		}
+		if got, err := r.FindByUserID(ctx, primitive.NewObjectID()); err != nil || got != nil {
+			t.Fatalf("FindByUserID of a stranger = %v, %v; want nil, nil", got, err)
+		}
+	})
+
+	t.Run("details are kept across updates", func(t *testing.T) {
+		r := newRepo(t)
+		userID := primitive.NewObjectID()
+		first, err := r.UpsertDetails(ctx, userID, "bea", repo.StreamDetails{
+			Title: "Speedrun", Description: "any%", Category: "games", Tags: []string{"speedrun", "retro"},
+		})
+		check(t, "UpsertDetails", err)
+		live, err := r.SetLive(ctx, userID, "bea", true, at(1))
+		check(t, "SetLive", err)
+		if live.ID != first.ID || !live.Live || live.Title != "Speedrun" || fmt.Sprint(live.Tags) != "[speedrun retro]" {
+			t.Fatalf("after going live = %+v", live)
+		}
+		if live.StartedAt == nil || !live.StartedAt.Equal(at(1)) || live.BroadcastID.IsZero() {
+			t.Fatalf("going live recorded %v and broadcast %s", live.StartedAt, live.BroadcastID.Hex())
+		}
+		again, err := r.SetLive(ctx, userID, "bea", true, at(2))
+		check(t, "SetLive again", err)
+		if again.BroadcastID == live.BroadcastID {
+			t.Fatal("going live again kept the broadcast ID")
+		}
+		ended, err := r.SetLive(ctx, userID, "bea", false, at(3))
+		check(t, "SetLive(false)", err)
+		if ended.Live || ended.EndedAt == nil || !ended.EndedAt.Equal(at(3)) || ended.BroadcastID != again.BroadcastID {
+			t.Fatalf("after ending = %+v", ended)
+		}
+	})
+
+	t.Run("the allowlist holds each viewer once", func(t *testing.T) {
+		r := newRepo(t)
+		userID, viewer := primitive.NewObjectID(), primitive.NewObjectID()
+		_, err := r.AddViewer(ctx, userID, "cal", viewer)
+		check(t, "AddViewer", err)
+		s, err := r.AddViewer(ctx, userID, "cal", viewer)
+		check(t, "AddViewer again", err)
+		if len(s.AllowedUserIDs) != 1 || s.AllowedUserIDs[0] != viewer {
+			t.Fatalf("allowlist = %v, want [%s]", s.AllowedUserIDs, viewer.Hex())
+		}
+		s, err = r.RemoveViewer(ctx, userID, "cal", viewer)
+		check(t, "RemoveViewer", err)
+		if len(s.AllowedUserIDs) != 0 {
+			t.Fatalf("allowlist after removal = %v", s.AllowedUserIDs)
+		}
+		s, err = r.SetSubscribersOnly(ctx, userID, "cal", true)
+		check(t, "SetSubscribersOnly", err)
+		if !s.SubscribersOnly {
+			t.Fatal("SetSubscribersOnly did not stick")
+		}
+	})
+
+	t.Run("usernames are unique", func(t *testing.T) {
+		r := newRepo(t)
+		_, err := r.SetVisibility(ctx, primitive.NewObjectID(), "dot", models.VisibilityPublic)
+		check(t, "SetVisibility", err)
+		if _, err := r.SetVisibility(ctx, primitive.NewObjectID(), "dot", models.VisibilityPublic); !mongo.IsDuplicateKeyError(err) {
+			t.Fatalf("second stream named dot: err = %v, want a duplicate key error", err)
+		}
+	})
+
+	t.Run("listings skip hidden streams and put the latest first", func(t *testing.T) {
+		r := newRepo(t)
+		var ids []primitive.ObjectID
+		for i, c := range []struct {
+			name, visibility string
+		}{
+			{"eve", models.VisibilityPublic},
+			{"fay", ""},
+			{"gil", models.VisibilityUnlisted},
+			{"hoa", models.VisibilityPrivate},
+			{"ida", models.VisibilityPublic},
+		} {
+			id := primitive.NewObjectID()
+			ids = append(ids, id)
+			if c.visibility != "" {
+				_, err := r.SetVisibility(ctx, id, c.name, c.visibility)
+				check(t, "SetVisibility", err)
+			}
+			_, err := r.UpsertDetails(ctx, id, c.name, repo.StreamDetails{Category: "music", Tags: []string{"live", c.name}})
+			check(t, "UpsertDetails", err)
+			_, err = r.SetLive(ctx, id, c.name, true, at(i))
+			check(t, "SetLive", err)
+		}
+		_, err := r.SetLive(ctx, ids[4], "ida", false, at(10))
+		check(t, "SetLive(false)", err)
+
+		live, err := r.ListLive(ctx, 10)
+		check(t, "ListLive", err)
+		if got := streamOwners(live); got != "[fay eve]" {
+			t.Fatalf("ListLive = %s, want [fay eve]", got)
+		}
+		if first, _ := r.ListLive(ctx, 1); streamOwners(first) != "[fay]" {
+			t.Fatalf("ListLive(1) = %s, want [fay]", streamOwners(first))
+		}
+		byUsers, err := r.ListLiveByUserIDs(ctx, []primitive.ObjectID{ids[0], ids[2], ids[4]}, 10)
+		check(t, "ListLiveByUserIDs", err)
+		if got := streamOwners(byUsers); got != "[eve]" {
+			t.Fatalf("ListLiveByUserIDs = %s, want [eve]", got)
+		}
+		ended, err := r.ListEndedByUserIDs(ctx, ids, at(5), 10)
+		check(t, "ListEndedByUserIDs", err)
+		if got := streamOwners(ended); got != "[ida]" {
+			t.Fatalf("ListEndedByUserIDs = %s, want [ida]", got)
+		}
+		if ended, _ := r.ListEndedByUserIDs(ctx, ids, at(11), 10); len(ended) != 0 {
+			t.Fatalf("ListEndedByUserIDs since after the end = %s, want none", streamOwners(ended))
+		}
+
+		n, err := r.CountInCategory(ctx, "music")
+		check(t, "CountInCategory", err)
+		if n != 5 {
+			t.Fatalf("CountInCategory = %d, want every stream, 5", n)
+		}
+		tags, err := r.TrendingTags(ctx, at(5), 2)
+		check(t, "TrendingTags", err)
+		if fmt.Sprint(tags) != "[{live 3} {eve 1}]" {
+			t.Fatalf("TrendingTags = %v, want [{live 3} {eve 1}]", tags)
+		}
+	})
+}
+
+// CategoryRepository checks a CategoryRepository. It needs the stream
+// store to count live streams.
+func CategoryRepository(t *testing.T, newStore func(t *testing.T) *repo.Store) {
+	ctx := context.Background()
+
+	create := func(t *testing.T, r repo.CategoryRepository, slug, name string, order int) *models.Category {
+		t.Helper()
+		c := &models.Category{Slug: slug, Name: name, SortOrder: order, CreatedAt: base, UpdatedAt: base}
+		check(t, "Create", r.Create(ctx, c))
+		if c.ID.IsZero() {
+			t.Fatal("Create left the ID zero")
+		}
+		return c
+	}
+
+	t.Run("the catalog is ordered by sort order, then name", func(t *testing.T) {
+		r := newStore(t).Categories
+		create(t, r, "music", "Music", 2)
+		create(t, r, "art", "Art", 2)
+		create(t, r, "games", "Games", 1)
+		cats, err := r.List(ctx)
+		check(t, "List", err)
+		var slugs []string
+		for _, c := range cats {
+			slugs = append(slugs, c.Slug)
+		}
+		if fmt.Sprint(slugs) != "[games art music]" {
+			t.Fatalf("List = %v, want [games art music]", slugs)
+		}
+		if n, err := r.Count(ctx); err != nil || n != 3 {
+			t.Fatalf("Count = %d, %v; want 3", n, err)
+		}
+	})
+
+	t.Run("slugs are unique", func(t *testing.T) {
+		r := newStore(t).Categories
+		create(t, r, "chat", "Chat", 0)
+		if err := r.Create(ctx, &models.Category{Slug: "chat", Name: "Talk"}); !mongo.IsDuplicateKeyError(err) {
+			t.Fatalf("err = %v, want a duplicate key error", err)
+		}
+	})
+
+	t.Run("update, find and delete by slug", func(t *testing.T) {
+		r := newStore(t).Categories
+		c := create(t, r, "irl", "IRL", 0)
+		name := "In real life"
+		got, err := r.Update(ctx, "irl", repo.CategoryUpdate{Name: &name})
+		check(t, "Update", err)
+		if got == nil || got.ID != c.ID || got.Name != name || got.SortOrder != 0 {
+			t.Fatalf("Update = %+v", got)
+		}
+		if got, err := r.Update(ctx, "nope", repo.CategoryUpdate{Name: &name}); err != nil || got != nil {
+			t.Fatalf("Update of an unknown slug = %v, %v; want nil, nil", got, err)
+		}
+		found, err := r.FindBySlug(ctx, "irl")
+		check(t, "FindBySlug", err)
+		if found == nil || found.Name != name {
+			t.Fatalf("FindBySlug = %+v", found)
+		}
+		if ok, err := r.Delete(ctx, "irl"); err != nil || !ok {
+			t.Fatalf("Delete = %v, %v; want true", ok, err)
+		}
+		if ok, err := r.Delete(ctx, "irl"); err != nil || ok {
+			t.Fatalf("second Delete = %v, %v; want false", ok, err)
+		}
+		if found, err := r.FindBySlug(ctx, "irl"); err != nil || found != nil {
+			t.Fatalf("FindBySlug after delete = %v, %v; want nil, nil", found, err)
+		}
+	})
+
+	t.Run("live counts include only listed live streams", func(t *testing.T) {
+		store := newStore(t)
+		create(t, store.Categories, "games", "Games", 0)
+		create(t, store.Categories, "music", "Music", 1)
+		for _, s := range []struct {
+			name, visibility string
+			live             bool
+		}{
+			{"a", models.VisibilityPublic, true},
+			{"b", models.VisibilityUnlisted, true},
+			{"c", models.VisibilityPublic, false},
+			{"d", "", true},
+		} {
+			id := primitive.NewObjectID()
+			if s.visibility != "" {
+				_, err := store.Streams.SetVisibility(ctx, id, s.name, s.visibility)
+				check(t, "SetVisibility", err)
+			}
+			_, err := store.Streams.UpsertDetails(ctx, id, s.name, repo.StreamDetails{Category: "games"})
+			check(t, "UpsertDetails", err)
+			_, err = store.Streams.SetLive(ctx, id, s.name, s.live, base)
+			check(t, "SetLive", err)
+		}
+		counts, err := store.Categories.ListWithLiveCounts(ctx)
+		check(t, "ListWithLiveCounts", err)
+		if len(counts) != 2 || counts[0].Slug != "games" || counts[0].LiveStreams != 2 ||
+			counts[1].Slug != "music" || counts[1].LiveStreams != 0 {
+			t.Fatalf("ListWithLiveCounts = %+v, want games 2, music 0", counts)
+		}
+	})
+}
+
+// StreamInviteRepository checks a StreamInviteRepository.
+func StreamInviteRepository(t *testing.T, newRepo func(t *testing.T) repo.StreamInviteRepository) {
+	ctx := context.Background()
+
+	t.Run("invites are listed newest first and codes are unique", func(t *testing.T) {
+		r := newRepo(t)
+		channelID := primitive.NewObjectID()
+		for i, code := range []string{"old", "new"} {
+			check(t, "Create", r.Create(ctx, &models.StreamInvite{ChannelID: channelID, Code: code, CreatedAt: at(i)}))
+		}
+		check(t, "Create elsewhere", r.Create(ctx, &models.StreamInvite{ChannelID: primitive.NewObjectID(), Code: "other"}))
+		if err := r.Create(ctx, &models.StreamInvite{ChannelID: channelID, Code: "old"}); !mongo.IsDuplicateKeyError(err) {
+			t.Fatalf("duplicate code: err = %v, want a duplicate key error", err)
+		}
+		invites, err := r.List(ctx, channelID)
+		check(t, "List", err)
+		if len(invites) != 2 || invites[0].Code != "new" || invites[1].Code != "old" {
+			t.Fatalf("List = %+v, want new, old", invites)
+		}
+	})
+
+	t.Run("use counts valid codes only", func(t *testing.T) {
+		r := newRepo(t)
+		channelID := primitive.NewObjectID()
+		expires := at(10)
+		limited := &models.StreamInvite{ChannelID: channelID, Code: "twice", MaxUses: 2}
+		check(t, "Create", r.Create(ctx, limited))
+		check(t, "Create", r.Create(ctx, &models.StreamInvite{ChannelID: channelID, Code: "soon", ExpiresAt: &expires}))
+
+		for want := 1; want <= 2; want++ {
+			inv, err := r.Use(ctx, channelID, "twice", base)
+			check(t, "Use", err)
+			if inv == nil || inv.Uses != want {
+				t.Fatalf("use %d = %+v", want, inv)
+			}
+		}
+		if inv, err := r.Use(ctx, channelID, "twice", base); err != nil || inv != nil {
+			t.Fatalf("used up = %v, %v; want nil, nil", inv, err)
+		}
+		if inv, err := r.Use(ctx, primitive.NewObjectID(), "soon", base); err != nil || inv != nil {
+			t.Fatalf("another channel's code = %v, %v; want nil, nil", inv, err)
+		}
+		if inv, err := r.Use(ctx, channelID, "soon", at(9)); err != nil || inv == nil {
+			t.Fatalf("before expiry = %v, %v; want the invite", inv, err)
+		}
+		if inv, err := r.Use(ctx, channelID, "soon", at(10)); err != nil || inv != nil {
+			t.Fatalf("at expiry = %v, %v; want nil, nil", inv, err)
+		}
+		got, err := r.FindByID(ctx, limited.ID)
+		check(t, "FindByID", err)
+		if got == nil || got.Uses != 2 {
+			t.Fatalf("FindByID = %+v, want 2 uses", got)
+		}
+	})
+
+	t.Run("delete is scoped to the channel", func(t *testing.T) {
+		r := newRepo(t)
+		channelID := primitive.NewObjectID()
+		inv := &models.StreamInvite{ChannelID: channelID, Code: "x"}
+		check(t, "Create", r.Create(ctx, inv))
+		if ok, err := r.Delete(ctx, primitive.NewObjectID(), inv.ID); err != nil || ok {
+			t.Fatalf("Delete by another channel = %v, %v; want false", ok, err)
+		}
+		if ok, err := r.Delete(ctx, channelID, inv.ID); err != nil || !ok {
+			t.Fatalf("Delete = %v, %v; want true", ok, err)
+		}
+		if got, err := r.FindByID(ctx, inv.ID); err != nil || got != nil {
+			t.Fatalf("FindByID after delete = %v, %v; want nil, nil", got, err)
+		}
+	})
+}
+
+// RestreamRepository checks a RestreamRepository.
+func RestreamRepository(t *testing.T, newRepo func(t *testing.T) repo.RestreamRepository) {
+	ctx := context.Background()
+
+	t.Run("list oldest first, optionally enabled only", func(t *testing.T) {
+		r := newRepo(t)
+		userID := primitive.NewObjectID()
+		for i, name := range []string{"b", "a", "c"} {
+			d := &models.RestreamDestination{UserID: userID, Name: name, Enabled: name != "a", CreatedAt: at(i)}
+			check(t, "Create", r.Create(ctx, d))
+			if d.ID.IsZero() {
+				t.Fatal("Create left the ID zero")
+			}
+		}
+		check(t, "Create elsewhere", r.Create(ctx, &models.RestreamDestination{UserID: primitive.NewObjectID(), Name: "x"}))
+		names := func(ds []models.RestreamDestination) string {
+			var out []string
+			for _, d := range ds {
+				out = append(out, d.Name)
+			}
+			return fmt.Sprint(out)
+		}
+		all, err := r.List(ctx, userID, false)
+		check(t, "List", err)
+		if names(all) != "[b a c]" {
+			t.Fatalf("List = %s, want [b a c]", names(all))
+		}
+		enabled, err := r.List(ctx, userID, true)
+		check(t, "List enabled", err)
+		if names(enabled) != "[b c]" {
+			t.Fatalf("List enabled = %s, want [b c]", names(enabled))
+		}
+		if n, err := r.Count(ctx, userID); err != nil || n != 3 {
+			t.Fatalf("Count = %d, %v; want 3", n, err)
+		}
+	})
+
+	t.Run("update and delete are scoped to the owner", func(t *testing.T) {
+		r := newRepo(t)
+		userID := primitive.NewObjectID()
+		d := &models.RestreamDestination{UserID: userID, Name: "yt", URL: "rtmp://a", KeyCipher: "k1", Enabled: true}
+		check(t, "Create", r.Create(ctx, d))
+
+		off, url := false, "rtmp://b"
+		if got, err := r.Update(ctx, primitive.NewObjectID(), d.ID, repo.RestreamDestinationUpdate{Enabled: &off}); err != nil || got != nil {
+			t.Fatalf("Update by a stranger = %v, %v; want nil, nil", got, err)
+		}
+		got, err := r.Update(ctx, userID, d.ID, repo.RestreamDestinationUpdate{Enabled: &off, URL: &url})
+		check(t, "Update", err)
+		if got == nil || got.Enabled || got.URL != url || got.Name != "yt" || got.KeyCipher != "k1" {
+			t.Fatalf("Update = %+v", got)
+		}
+		if ok, err := r.Delete(ctx, primitive.NewObjectID(), d.ID); err != nil || ok {
+			t.Fatalf("Delete by a stranger = %v, %v; want false", ok, err)
+		}
+		if ok, err := r.Delete(ctx, userID, d.ID); err != nil || !ok {
+			t.Fatalf("Delete = %v, %v; want true", ok, err)
+		}
+		if n, err := r.Count(ctx, userID); err != nil || n != 0 {
+			t.Fatalf("Count after delete = %d, %v; want 0", n, err)
+		}
+	})
+}
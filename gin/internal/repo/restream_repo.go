@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoRestreamRepository is the RestreamRepository backed by the
+// "restream_destinations" collection.
+type MongoRestreamRepository struct {
+	db *mongo.Database
+}
+
+// Create inserts a new destination and sets its ID.
+func (r MongoRestreamRepository) Create(ctx context.Context, d *models.RestreamDestination) error {
+	coll := r.db.Collection("restream_destinations")
+	res, err := coll.InsertOne(ctx, d)
+	if err != nil {
+		return err
+	}
+	d.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// List returns the user's destinations, oldest first.
+// With enabledOnly, disabled ones are left out.
+func (r MongoRestreamRepository) List(ctx context.Context, userID primitive.ObjectID, enabledOnly bool) ([]models.RestreamDestination, error) {
+	coll := r.db.Collection("restream_destinations")
+	filter := bson.M{"user_id": userID}
+	if enabledOnly {
+		filter["enabled"] = true
+	}
+	cur, err := coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	dests := []models.RestreamDestination{}
+	if err := cur.All(ctx, &dests); err != nil {
+		return nil, err
+	}
+	return dests, nil
+}
+
+// Count returns how many destinations the user has.
+func (r MongoRestreamRepository) Count(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.db.Collection("restream_destinations").CountDocuments(ctx, bson.M{"user_id": userID})
+}
+
+// RestreamDestinationUpdate holds the editable fields of a destination;
+// nil means unchanged.
+type RestreamDestinationUpdate struct {
+	Name      *string
+	URL       *string
+	KeyCipher *string
+	KeyHint   *string
+	Enabled   *bool
+}
+
+// Update applies the non-nil fields of u to one of the
+// user's destinations and returns the result, or nil if it does not exist.
+func (r MongoRestreamRepository) Update(ctx context.Context, userID, id primitive.ObjectID, u RestreamDestinationUpdate) (*models.RestreamDestination, error) {
+	coll := r.db.Collection("restream_destinations")
+	set := bson.M{"updated_at": time.Now().UTC()}
+	if u.Name != nil {
+		set["name"] = *u.Name
+	}
+	if u.URL != nil {
+		set["url"] = *u.URL
+	}
+	if u.KeyCipher != nil {
+		set["key_cipher"] = *u.KeyCipher
+	}
+	if u.KeyHint != nil {
+		set["key_hint"] = *u.KeyHint
+	}
+	if u.Enabled != nil {
+		set["enabled"] = *u.Enabled
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var d models.RestreamDestination
+	err := coll.FindOneAndUpdate(ctx, bson.M{"_id": id, "user_id": userID}, bson.M{"$set": set}, opts).Decode(&d)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Delete removes one of the user's destinations.
+func (r MongoRestreamRepository) Delete(ctx context.Context, userID, id primitive.ObjectID) (bool, error) {
+	coll := r.db.Collection("restream_destinations")
+	res, err := coll.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
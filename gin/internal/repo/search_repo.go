@@ -0,0 +1,209 @@
+package repo
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoSearchRepository is the SearchRepository backed by the text
+// indexes of the "streams" and "users" collections.
+type MongoSearchRepository struct {
+	db *mongo.Database
+}
+
+// SearchCursor marks the last result of a page. The next page starts
+// strictly after it in the ordering the search was run with.
+type SearchCursor struct {
+	Score     float64            `json:"s"`
+	UpdatedAt time.Time          `json:"t"`
+	ID        primitive.ObjectID `json:"id"`
+}
+
+// StreamSearch describes a query over the "streams" collection.
+// An empty Text lists every stream matching the filters by recency.
+type StreamSearch struct {
+	Text       string
+	LiveOnly   bool
+	Category   string
+	Tag        string
+	SortRecent bool // ignore relevance and order by updated_at only
+	After      *SearchCursor
+	Limit      int
+}
+
+// StreamHit is a stream together with its text relevance score.
+type StreamHit struct {
+	models.Stream `bson:",inline"`
+	Score         float64 `json:"score" bson:"score"`
+}
+
+// ChannelHit is the public part of a user matched by a channel search.
+type ChannelHit struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Username    string             `json:"username" bson:"username"`
+	DisplayName string             `json:"display_name" bson:"display_name"`
+	Score       float64            `json:"score" bson:"score"`
+}
+
+// TagCount is a tag and the number of streams carrying it.
+type TagCount struct {
+	Tag   string `json:"tag" bson:"_id"`
+	Count int    `json:"count" bson:"count"`
+}
+
+// Streams runs a text search (or a plain filtered listing) over streams.
+func (r MongoSearchRepository) Streams(ctx context.Context, q StreamSearch) ([]StreamHit, error) {
+	coll := r.db.Collection("streams")
+
+	match := bson.M{"visibility": listedVisibilities()}
+	if q.Text != "" {
+		match["$text"] = bson.M{"$search": q.Text}
+	}
+	if q.LiveOnly {
+		match["live"] = true
+	}
+	if q.Category != "" {
+		match["category"] = q.Category
+	}
+	if q.Tag != "" {
+		match["tags"] = q.Tag
+	}
+
+	pipeline := mongo.Pipeline{bson.D{{Key: "$match", Value: match}}}
+	if q.Text != "" {
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.M{"score": bson.M{"$meta": "textScore"}}}})
+	} else {
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.M{"score": 0.0}}})
+	}
+
+	byRelevance := q.Text != "" && !q.SortRecent
+	if q.After != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: afterCursor(q.After, byRelevance)}})
+	}
+	if byRelevance {
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: bson.D{
+			{Key: "score", Value: -1}, {Key: "updated_at", Value: -1}, {Key: "_id", Value: -1},
+		}}})
+	} else {
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: bson.D{
+			{Key: "updated_at", Value: -1}, {Key: "_id", Value: -1},
+		}}})
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$limit", Value: q.Limit}})
+
+	cur, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	hits := []StreamHit{}
+	if err := cur.All(ctx, &hits); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// Channels runs a text search over usernames and display names.
+func (r MongoSearchRepository) Channels(ctx context.Context, text string, after *SearchCursor, limit int) ([]ChannelHit, error) {
+	coll := r.db.Collection("users")
+
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{"$text": bson.M{"$search": text}}}},
+		bson.D{{Key: "$project", Value: bson.M{
+			"username":     1,
+			"display_name": 1,
+			"score":        bson.M{"$meta": "textScore"},
+		}}},
+	}
+	if after != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{"score": bson.M{"$lt": after.Score}},
+			bson.M{"score": after.Score, "_id": bson.M{"$lt": after.ID}},
+		}}}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "score", Value: -1}, {Key: "_id", Value: -1}}}},
+		bson.D{{Key: "$limit", Value: limit}},
+	)
+
+	cur, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	hits := []ChannelHit{}
+	if err := cur.All(ctx, &hits); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// SuggestTags returns the most used tags that start with prefix.
+func (r MongoSearchRepository) SuggestTags(ctx context.Context, prefix string, limit int) ([]TagCount, error) {
+	coll := r.db.Collection("streams")
+	re := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix)}
+
+	cur, err := coll.Aggregate(ctx, mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{"tags": re, "visibility": listedVisibilities()}}},
+		bson.D{{Key: "$unwind", Value: "$tags"}},
+		bson.D{{Key: "$match", Value: bson.M{"tags": re}}},
+		bson.D{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		bson.D{{Key: "$limit", Value: limit}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	tags := []TagCount{}
+	if err := cur.All(ctx, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// SuggestChannels returns users whose username or display name starts
+// with prefix, ignoring case. It matches the lower-cased copies of the
+// names, so that the anchored regex can use their indexes.
+func (r MongoSearchRepository) SuggestChannels(ctx context.Context, prefix string, limit int) ([]ChannelHit, error) {
+	coll := r.db.Collection("users")
+	re := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(strings.ToLower(prefix))}
+
+	opts := options.Find().
+		SetProjection(bson.M{"username": 1, "display_name": 1}).
+		SetSort(bson.D{{Key: "username", Value: 1}}).
+		SetLimit(int64(limit))
+	cur, err := coll.Find(ctx, bson.M{"$or": bson.A{
+		bson.M{"username_lower": re},
+		bson.M{"display_name_lower": re},
+	}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	hits := []ChannelHit{}
+	if err := cur.All(ctx, &hits); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// afterCursor builds the $match stage that skips everything up to and
+// including the cursor position.
+func afterCursor(c *SearchCursor, byRelevance bool) bson.M {
+	if byRelevance {
+		return bson.M{"$or": bson.A{
+			bson.M{"score": bson.M{"$lt": c.Score}},
+			bson.M{"score": c.Score, "updated_at": bson.M{"$lt": c.UpdatedAt}},
+			bson.M{"score": c.Score, "updated_at": c.UpdatedAt, "_id": bson.M{"$lt": c.ID}},
+		}}
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{"updated_at": bson.M{"$lt": c.UpdatedAt}},
+		bson.M{"updated_at": c.UpdatedAt, "_id": bson.M{"$lt": c.ID}},
+	}}
+}
@@ -3,22 +3,27 @@ package repo
 import (
 	"context"
 
-	"github.com/CSBOWMA/bigredhacks2025/gin/internal/db"
 	"github.com/CSBOWMA/bigredhacks2025/gin/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// CreateSession inserts a new session document.
-func CreateSession(ctx context.Context, session *models.Session) error {
-	collection := db.DB().Collection("sessions")
+// MongoSessionRepository is the SessionRepository backed by the
+// "sessions" collection.
+type MongoSessionRepository struct {
+	db *mongo.Database
+}
+
+// Create inserts a new session document.
+func (r MongoSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	collection := r.db.Collection("sessions")
 	_, err := collection.InsertOne(ctx, session)
 	return err
 }
 
-// GetSession retrieves a session by its UUID (the _id field).
-func GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
-	collection := db.DB().Collection("sessions")
+// Get retrieves a session by its UUID (the _id field).
+func (r MongoSessionRepository) Get(ctx context.Context, sessionID string) (*models.Session, error) {
+	collection := r.db.Collection("sessions")
 	var sess models.Session
 	err := collection.FindOne(ctx, bson.M{"_id": sessionID}).Decode(&sess)
 	if err != nil {
@@ -0,0 +1,99 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStreamInviteRepository is the StreamInviteRepository backed by the
+// "stream_invites" collection.
+type MongoStreamInviteRepository struct {
+	db *mongo.Database
+}
+
+// Create inserts a new invite and sets its ID.
+func (r MongoStreamInviteRepository) Create(ctx context.Context, inv *models.StreamInvite) error {
+	coll := r.db.Collection("stream_invites")
+	res, err := coll.InsertOne(ctx, inv)
+	if err != nil {
+		return err
+	}
+	inv.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// List returns the channel's invites, newest first.
+func (r MongoStreamInviteRepository) List(ctx context.Context, channelID primitive.ObjectID) ([]models.StreamInvite, error) {
+	coll := r.db.Collection("stream_invites")
+	cur, err := coll.Find(ctx, bson.M{"channel_id": channelID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	invites := []models.StreamInvite{}
+	if err := cur.All(ctx, &invites); err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// FindByID returns the invite (or nil).
+func (r MongoStreamInviteRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.StreamInvite, error) {
+	coll := r.db.Collection("stream_invites")
+	var inv models.StreamInvite
+	err := coll.FindOne(ctx, bson.M{"_id": id}).Decode(&inv)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// Delete revokes one of the channel's invites.
+func (r MongoStreamInviteRepository) Delete(ctx context.Context, channelID, id primitive.ObjectID) (bool, error) {
+	coll := r.db.Collection("stream_invites")
+	res, err := coll.DeleteOne(ctx, bson.M{"_id": id, "channel_id": channelID})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// Use atomically counts one use of the channel's invite code
+// and returns it, or nil if the code is unknown, expired or used up.
+func (r MongoStreamInviteRepository) Use(ctx context.Context, channelID primitive.ObjectID, code string, now time.Time) (*models.StreamInvite, error) {
+	coll := r.db.Collection("stream_invites")
+	filter := bson.M{
+		"channel_id": channelID,
+		"code":       code,
+		"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"expires_at": bson.M{"$exists": false}},
+				bson.M{"expires_at": bson.M{"$gt": now}},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{"max_uses": 0},
+				bson.M{"$expr": bson.M{"$lt": bson.A{"$uses", "$max_uses"}}},
+			}},
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var inv models.StreamInvite
+	err := coll.FindOneAndUpdate(ctx, filter, bson.M{"$inc": bson.M{"uses": 1}}, opts).Decode(&inv)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &inv, nil
+}
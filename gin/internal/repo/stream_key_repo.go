@@ -3,16 +3,21 @@ package repo
 import (
 	"context"
  
-	"github.com/CSBOWMA/bigredhacks2025/gin/internal/db"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"github.com/CSBOWMA/bigredhacks2025/gin/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// FindStreamKeyByUserID returns the active stream key for a user (or nil).
-func FindStreamKeyByUserID(ctx context.Context, userID primitive.ObjectID) (*models.StreamKey, error) {
-	coll := db.DB().Collection("stream_keys")
+// MongoStreamKeyRepository is the StreamKeyRepository backed by the
+// "stream_keys" collection.
+type MongoStreamKeyRepository struct {
+	db *mongo.Database
+}
+
+// FindByUserID returns the active stream key for a user (or nil).
+func (r MongoStreamKeyRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) (*models.StreamKey, error) {
+	coll := r.db.Collection("stream_keys")
 	filter := bson.M{"user_id": userID}
 
 	var key models.StreamKey
@@ -26,9 +31,9 @@ func FindStreamKeyByUserID(ctx context.Context, userID primitive.ObjectID) (*mod
 	return &key, nil
 }
 
-// CreateStreamKey inserts a new document **and fills the ID field**.
-func CreateStreamKey(ctx context.Context, key *models.StreamKey) error {
-	coll := db.DB().Collection("stream_keys")
+// Create inserts a new document **and fills the ID field**.
+func (r MongoStreamKeyRepository) Create(ctx context.Context, key *models.StreamKey) error {
+	coll := r.db.Collection("stream_keys")
 	res, err := coll.InsertOne(ctx, key)
 	if err != nil {
 		return err
@@ -39,10 +44,25 @@ func CreateStreamKey(ctx context.Context, key *models.StreamKey) error {
 	return nil
 }
 
-// DeleteStreamKeyByUserID removes any existing key for the user.
-func DeleteStreamKeyByUserID(ctx context.Context, userID primitive.ObjectID) error {
-	coll := db.DB().Collection("stream_keys")
+// DeleteByUserID removes any existing key for the user.
+func (r MongoStreamKeyRepository) DeleteByUserID(ctx context.Context, userID primitive.ObjectID) error {
+	coll := r.db.Collection("stream_keys")
 	filter := bson.M{"user_id": userID}
 	_, err := coll.DeleteOne(ctx, filter)
 	return err
 }
+
+// FindByID looks a key up by its value (the hex ObjectID handed to the broadcaster).
+func (r MongoStreamKeyRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.StreamKey, error) {
+	coll := r.db.Collection("stream_keys")
+
+	var key models.StreamKey
+	err := coll.FindOne(ctx, bson.M{"_id": id}).Decode(&key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
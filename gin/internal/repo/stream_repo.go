@@ -0,0 +1,222 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStreamRepository is the StreamRepository backed by the "streams"
+// collection.
+type MongoStreamRepository struct {
+	db *mongo.Database
+}
+
+// StreamDetails are the user-editable fields of a stream.
+type StreamDetails struct {
+	Title       string
+	Description string
+	Category    string
+	Tags        []string
+}
+
+// listedVisibilities matches the visibility of streams that may appear
+// in discovery. Streams saved before visibility existed have none and are public.
+func listedVisibilities() bson.M {
+	return bson.M{"$nin": bson.A{models.VisibilityUnlisted, models.VisibilityPrivate}}
+}
+
+// FindByUserID returns the stream owned by the user (or nil).
+func (r MongoStreamRepository) FindByUserID(ctx context.Context, userID primitive.ObjectID) (*models.Stream, error) {
+	return r.findStream(ctx, bson.M{"user_id": userID})
+}
+
+// FindByUsername returns the stream of the channel with the given username (or nil).
+func (r MongoStreamRepository) FindByUsername(ctx context.Context, username string) (*models.Stream, error) {
+	return r.findStream(ctx, bson.M{"username": username})
+}
+
+func (r MongoStreamRepository) findStream(ctx context.Context, filter bson.M) (*models.Stream, error) {
+	coll := r.db.Collection("streams")
+	var s models.Stream
+	err := coll.FindOne(ctx, filter).Decode(&s)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// UpsertDetails writes the editable fields of the user's stream,
+// creating the document if the user has never had one, and returns the result.
+func (r MongoStreamRepository) UpsertDetails(ctx context.Context, userID primitive.ObjectID, username string, d StreamDetails) (*models.Stream, error) {
+	coll := r.db.Collection("streams")
+	update := bson.M{
+		"$set": bson.M{
+			"username":    username,
+			"title":       d.Title,
+			"description": d.Description,
+			"category":    d.Category,
+			"tags":        d.Tags,
+			"updated_at":  time.Now().UTC(),
+		},
+		"$setOnInsert": bson.M{"live": false},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var s models.Stream
+	if err := coll.FindOneAndUpdate(ctx, bson.M{"user_id": userID}, update, opts).Decode(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SetLive flips the live flag of the user's stream and records the
+// start or end time. Going live starts a new broadcast ID. The document is
+// created if it does not exist yet.
+func (r MongoStreamRepository) SetLive(ctx context.Context, userID primitive.ObjectID, username string, live bool, at time.Time) (*models.Stream, error) {
+	coll := r.db.Collection("streams")
+	set := bson.M{
+		"username":   username,
+		"live":       live,
+		"updated_at": at,
+	}
+	if live {
+		set["started_at"] = at
+		set["broadcast_id"] = primitive.NewObjectID()
+	} else {
+		set["ended_at"] = at
+	}
+	update := bson.M{
+		"$set":         set,
+		"$setOnInsert": bson.M{"title": "", "description": "", "category": "", "tags": []string{}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var s models.Stream
+	if err := coll.FindOneAndUpdate(ctx, bson.M{"user_id": userID}, update, opts).Decode(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SetVisibility changes who may find and watch the user's stream,
+// creating the document if needed.
+func (r MongoStreamRepository) SetVisibility(ctx context.Context, userID primitive.ObjectID, username, visibility string) (*models.Stream, error) {
+	return r.updateStream(ctx, userID, bson.M{
+		"$set": bson.M{"username": username, "visibility": visibility, "updated_at": time.Now().UTC()},
+	})
+}
+
+// SetSubscribersOnly restricts playback of the user's stream to
+// members, or lifts the restriction.
+func (r MongoStreamRepository) SetSubscribersOnly(ctx context.Context, userID primitive.ObjectID, username string, on bool) (*models.Stream, error) {
+	return r.updateStream(ctx, userID, bson.M{
+		"$set": bson.M{"username": username, "subscribers_only": on, "updated_at": time.Now().UTC()},
+	})
+}
+
+// AddViewer puts viewerID on the stream's allowlist.
+func (r MongoStreamRepository) AddViewer(ctx context.Context, userID primitive.ObjectID, username string, viewerID primitive.ObjectID) (*models.Stream, error) {
+	return r.updateStream(ctx, userID, bson.M{
+		"$set":      bson.M{"username": username},
+		"$addToSet": bson.M{"allowed_user_ids": viewerID},
+	})
+}
+
+// RemoveViewer takes viewerID off the stream's allowlist.
+func (r MongoStreamRepository) RemoveViewer(ctx context.Context, userID primitive.ObjectID, username string, viewerID primitive.ObjectID) (*models.Stream, error) {
+	return r.updateStream(ctx, userID, bson.M{
+		"$set":  bson.M{"username": username},
+		"$pull": bson.M{"allowed_user_ids": viewerID},
+	})
+}
+
+// updateStream applies update to the user's stream, creating it with
+// empty details if needed, and returns the result.
+func (r MongoStreamRepository) updateStream(ctx context.Context, userID primitive.ObjectID, update bson.M) (*models.Stream, error) {
+	coll := r.db.Collection("streams")
+	update["$setOnInsert"] = bson.M{"title": "", "description": "", "category": "", "tags": []string{}, "live": false}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var s models.Stream
+	if err := coll.FindOneAndUpdate(ctx, bson.M{"user_id": userID}, update, opts).Decode(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CountInCategory returns how many streams reference the category slug.
+func (r MongoStreamRepository) CountInCategory(ctx context.Context, slug string) (int64, error) {
+	return r.db.Collection("streams").CountDocuments(ctx, bson.M{"category": slug})
+}
+
+// TrendingTags counts, per tag, the listed channels that were live at some point
+// since the given time, most popular first.
+func (r MongoStreamRepository) TrendingTags(ctx context.Context, since time.Time, limit int) ([]TagCount, error) {
+	coll := r.db.Collection("streams")
+	cur, err := coll.Aggregate(ctx, mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{
+			"visibility": listedVisibilities(),
+			"$or": bson.A{
+				bson.M{"live": true},
+				bson.M{"ended_at": bson.M{"$gte": since}},
+			},
+		}}},
+		bson.D{{Key: "$unwind", Value: "$tags"}},
+		bson.D{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		bson.D{{Key: "$limit", Value: limit}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	tags := []TagCount{}
+	if err := cur.All(ctx, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// ListLiveByUserIDs returns the listed live streams among the given owners,
+// most recently started first.
+func (r MongoStreamRepository) ListLiveByUserIDs(ctx context.Context, userIDs []primitive.ObjectID, limit int) ([]models.Stream, error) {
+	return r.findStreams(ctx,
+		bson.M{"user_id": bson.M{"$in": userIDs}, "live": true, "visibility": listedVisibilities()},
+		bson.D{{Key: "started_at", Value: -1}}, limit)
+}
+
+// ListEndedByUserIDs returns the listed offline streams among the given
+// owners that ended after since, most recently ended first.
+func (r MongoStreamRepository) ListEndedByUserIDs(ctx context.Context, userIDs []primitive.ObjectID, since time.Time, limit int) ([]models.Stream, error) {
+	return r.findStreams(ctx,
+		bson.M{"user_id": bson.M{"$in": userIDs}, "live": false, "ended_at": bson.M{"$gte": since}, "visibility": listedVisibilities()},
+		bson.D{{Key: "ended_at", Value: -1}}, limit)
+}
+
+// ListLive returns listed live streams, most recently started first.
+func (r MongoStreamRepository) ListLive(ctx context.Context, limit int) ([]models.Stream, error) {
+	return r.findStreams(ctx,
+		bson.M{"live": true, "visibility": listedVisibilities()},
+		bson.D{{Key: "started_at", Value: -1}}, limit)
+}
+
+func (r MongoStreamRepository) findStreams(ctx context.Context, filter bson.M, sort bson.D, limit int) ([]models.Stream, error) {
+	coll := r.db.Collection("streams")
+	cur, err := coll.Find(ctx, filter, options.Find().SetSort(sort).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	streams := []models.Stream{}
+	if err := cur.All(ctx, &streams); err != nil {
+		return nil, err
+	}
+	return streams, nil
+}
@@ -0,0 +1,21 @@
+package repo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// inTransaction runs fn in a transaction, retrying it on transient errors.
+// Transactions need a replica set, such as Atlas.
+func inTransaction(ctx context.Context, d *mongo.Database, fn func(sc mongo.SessionContext) error) error {
+	sess, err := d.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer sess.EndSession(ctx)
+	_, err = sess.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
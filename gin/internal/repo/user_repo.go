@@ -2,31 +2,42 @@ package repo
 
 import (
 	"context"
+	"time"
 
-	"github.com/CSBOWMA/bigredhacks2025/gin/internal/db"
 	"github.com/CSBOWMA/bigredhacks2025/gin/models"
     "go.mongodb.org/mongo-driver/bson/primitive"
     "go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// MongoUserRepository is the UserRepository backed by the "users" collection.
+type MongoUserRepository struct {
+	db *mongo.Database
+}
+
 func HexToObjectID(hex string) (primitive.ObjectID, error) {
 	return primitive.ObjectIDFromHex(hex)
 }
 
-// CreateUser inserts a new user document into the "users" collection.
-func CreateUser(ctx context.Context, user *models.User) error {
-	collection := db.DB().Collection("users")
-	_, err := collection.InsertOne(ctx, user)
+// Create inserts a new user document into the "users" collection
+// and fills the ID field.
+func (r MongoUserRepository) Create(ctx context.Context, user *models.User) error {
+	collection := r.db.Collection("users")
+	user.SetSearchKeys()
+	res, err := collection.InsertOne(ctx, user)
 	if err != nil {
 		// If you want more granular errors you can inspect the mongo.WriteError
 		// but for a hackathon a simple wrap is enough.
 		return err
 	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		user.ID = id
+	}
 	return nil
 }
-func FindUserByEmailOrUsername(ctx context.Context, email, username string) (*models.User, error) {
-	collection := db.DB().Collection("users")
+func (r MongoUserRepository) FindByEmailOrUsername(ctx context.Context, email, username string) (*models.User, error) {
+	collection := r.db.Collection("users")
 
 	filter := bson.M{}
 	if email != "" {
@@ -46,3 +57,55 @@ func FindUserByEmailOrUsername(ctx context.Context, email, username string) (*mo
 	}
 	return &user, nil
 }
+
+// FindByID returns the user with the given ID (or nil).
+func (r MongoUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	collection := r.db.Collection("users")
+	var user models.User
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByUsername returns the user with the given username (or nil).
+func (r MongoUserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	if username == "" {
+		return nil, nil
+	}
+	return r.FindByEmailOrUsername(ctx, "", username)
+}
+
+// ListPublicProfiles returns the public profiles of the given users,
+// ordered by username. Unknown IDs are skipped.
+func (r MongoUserRepository) ListPublicProfiles(ctx context.Context, ids []primitive.ObjectID) ([]models.PublicProfile, error) {
+	profiles := []models.PublicProfile{}
+	if len(ids) == 0 {
+		return profiles, nil
+	}
+	coll := r.db.Collection("users")
+	cur, err := coll.Find(ctx, bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetSort(bson.D{{Key: "username", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	if err := cur.All(ctx, &profiles); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// SetStreamSuspension bars the user from going live until the given time,
+// or lifts the suspension when until is nil.
+func (r MongoUserRepository) SetStreamSuspension(ctx context.Context, id primitive.ObjectID, until *time.Time, reason string) error {
+	update := bson.M{"$unset": bson.M{"stream_suspended_until": "", "stream_suspend_reason": ""}}
+	if until != nil {
+		update = bson.M{"$set": bson.M{"stream_suspended_until": *until, "stream_suspend_reason": reason}}
+	}
+	_, err := r.db.Collection("users").UpdateByID(ctx, id, update)
+	return err
+}
@@ -0,0 +1,144 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoVideoRepository is the VideoRepository backed by the "videos"
+// collection.
+type MongoVideoRepository struct {
+	db *mongo.Database
+}
+
+// Create inserts a new video and sets its ID.
+func (r MongoVideoRepository) Create(ctx context.Context, v *models.Video) error {
+	coll := r.db.Collection("videos")
+	res, err := coll.InsertOne(ctx, v)
+	if err != nil {
+		return err
+	}
+	v.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindByID returns the video (or nil).
+func (r MongoVideoRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Video, error) {
+	return r.findVideo(ctx, bson.M{"_id": id})
+}
+
+// FindByBroadcastID returns the recording of a broadcast (or nil).
+func (r MongoVideoRepository) FindByBroadcastID(ctx context.Context, broadcastID primitive.ObjectID) (*models.Video, error) {
+	return r.findVideo(ctx, bson.M{"broadcast_id": broadcastID})
+}
+
+func (r MongoVideoRepository) findVideo(ctx context.Context, filter bson.M) (*models.Video, error) {
+	coll := r.db.Collection("videos")
+	var v models.Video
+	err := coll.FindOne(ctx, filter).Decode(&v)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Finish marks a recording ready with its final length.
+func (r MongoVideoRepository) Finish(ctx context.Context, id primitive.ObjectID, duration float64, segments int, endedAt time.Time) error {
+	coll := r.db.Collection("videos")
+	_, err := coll.UpdateByID(ctx, id, bson.M{"$set": bson.M{
+		"status":           models.VideoReady,
+		"duration_seconds": duration,
+		"segment_count":    segments,
+		"ended_at":         endedAt,
+		"updated_at":       time.Now().UTC(),
+	}})
+	return err
+}
+
+// UpdateTitle renames a video and returns the result (nil if it does not exist).
+func (r MongoVideoRepository) UpdateTitle(ctx context.Context, id primitive.ObjectID, title string) (*models.Video, error) {
+	coll := r.db.Collection("videos")
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var v models.Video
+	err := coll.FindOneAndUpdate(ctx, bson.M{"_id": id},
+		bson.M{"$set": bson.M{"title": title, "updated_at": time.Now().UTC()}}, opts).Decode(&v)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// SetSubscribersOnly restricts playback of a video to members, or
+// lifts the restriction, and returns the result (or nil).
+func (r MongoVideoRepository) SetSubscribersOnly(ctx context.Context, id primitive.ObjectID, on bool) (*models.Video, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var v models.Video
+	err := r.db.Collection("videos").FindOneAndUpdate(ctx, bson.M{"_id": id},
+		bson.M{"$set": bson.M{"subscribers_only": on, "updated_at": time.Now().UTC()}}, opts).Decode(&v)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Delete removes a video document.
+func (r MongoVideoRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.db.Collection("videos").DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// ListByUser returns a page of the user's videos, newest first,
+// leaving out those recorded with one of the hidden visibilities.
+func (r MongoVideoRepository) ListByUser(ctx context.Context, userID primitive.ObjectID, hidden []string, after *TimeCursor, limit int) ([]models.Video, error) {
+	filter := bson.M{"user_id": userID}
+	if len(hidden) > 0 {
+		filter["visibility"] = bson.M{"$nin": hidden}
+	}
+	if after != nil {
+		filter = bson.M{"$and": bson.A{filter, after.olderThan("started_at", "_id")}}
+	}
+	return r.findVideos(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit)))
+}
+
+// ListByStatus returns every video in the given status.
+func (r MongoVideoRepository) ListByStatus(ctx context.Context, status string) ([]models.Video, error) {
+	return r.findVideos(ctx, bson.M{"status": status}, options.Find())
+}
+
+func (r MongoVideoRepository) findVideos(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.Video, error) {
+	coll := r.db.Collection("videos")
+	cur, err := coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	videos := []models.Video{}
+	if err := cur.All(ctx, &videos); err != nil {
+		return nil, err
+	}
+	return videos, nil
+}
+
+// ListByBroadcastIDs returns the videos recorded of the given broadcasts.
+func (r MongoVideoRepository) ListByBroadcastIDs(ctx context.Context, broadcastIDs []primitive.ObjectID) ([]models.Video, error) {
+	if len(broadcastIDs) == 0 {
+		return []models.Video{}, nil
+	}
+	return r.findVideos(ctx, bson.M{"broadcast_id": bson.M{"$in": broadcastIDs}}, options.Find())
+}
@@ -0,0 +1,210 @@
+package rtmp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// AMF0 type markers.
+const (
+	amfNumber      = 0x00
+	amfBoolean     = 0x01
+	amfString      = 0x02
+	amfObject      = 0x03
+	amfNull        = 0x05
+	amfUndefined   = 0x06
+	amfECMAArray   = 0x08
+	amfObjectEnd   = 0x09
+	amfStrictArray = 0x0a
+	amfDate        = 0x0b
+	amfLongString  = 0x0c
+)
+
+var errAMF = errors.New("rtmp: malformed AMF0")
+
+// amfObj is a decoded AMF0 object or ECMA array.
+type amfObj map[string]any
+
+// decodeAMF decodes every AMF0 value in b.
+func decodeAMF(b []byte) ([]any, error) {
+	r := bytes.NewReader(b)
+	var vals []any
+	for r.Len() > 0 {
+		v, err := readAMF(r)
+		if err != nil {
+			return vals, err
+		}
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+func readAMF(r *bytes.Reader) (any, error) {
+	marker, err := r.ReadByte()
+	if err != nil {
+		return nil, errAMF
+	}
+	switch marker {
+	case amfNumber:
+		var n uint64
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			return nil, errAMF
+		}
+		return math.Float64frombits(n), nil
+	case amfBoolean:
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, errAMF
+		}
+		return b != 0, nil
+	case amfString:
+		return readAMFString(r, 2)
+	case amfLongString:
+		return readAMFString(r, 4)
+	case amfObject:
+		return readAMFProps(r)
+	case amfECMAArray:
+		if err := skipAMF(r, 4); err != nil { // count; unreliable
+			return nil, err
+		}
+		return readAMFProps(r)
+	case amfStrictArray:
+		var n uint32
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil || int(n) > r.Len() {
+			return nil, errAMF
+		}
+		arr := make([]any, 0, n)
+		for i := uint32(0); i < n; i++ {
+			v, err := readAMF(r)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case amfDate:
+		if err := skipAMF(r, 10); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	case amfNull, amfUndefined:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported type %#x", errAMF, marker)
+}
+
+// skipAMF skips n bytes, which must all be there: seeking a bytes.Reader
+// past its end does not fail.
+func skipAMF(r *bytes.Reader, n int) error {
+	if r.Len() < n {
+		return errAMF
+	}
+	_, err := r.Seek(int64(n), io.SeekCurrent)
+	return err
+}
+
+func readAMFString(r *bytes.Reader, lenSize int) (string, error) {
+	var n uint32
+	if lenSize == 2 {
+		var n16 uint16
+		if err := binary.Read(r, binary.BigEndian, &n16); err != nil {
+			return "", errAMF
+		}
+		n = uint32(n16)
+	} else if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return "", errAMF
+	}
+	if int(n) > r.Len() {
+		return "", errAMF
+	}
+	b := make([]byte, n)
+	_, _ = io.ReadFull(r, b)
+	return string(b), nil
+}
+
+func readAMFProps(r *bytes.Reader) (amfObj, error) {
+	obj := amfObj{}
+	for {
+		key, err := readAMFString(r, 2)
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			if m, err := r.ReadByte(); err != nil || m != amfObjectEnd {
+				return nil, errAMF
+			}
+			return obj, nil
+		}
+		v, err := readAMF(r)
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = v
+	}
+}
+
+// encodeAMF appends the AMF0 encoding of vals to a new buffer. Supported
+// values are nil, bool, string, numbers, amfObj and []any.
+func encodeAMF(vals ...any) []byte {
+	var buf bytes.Buffer
+	for _, v := range vals {
+		writeAMF(&buf, v)
+	}
+	return buf.Bytes()
+}
+
+func writeAMF(buf *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(amfNull)
+	case bool:
+		buf.WriteByte(amfBoolean)
+		if v {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case string:
+		if len(v) > math.MaxUint16 {
+			buf.WriteByte(amfLongString)
+			_ = binary.Write(buf, binary.BigEndian, uint32(len(v)))
+		} else {
+			buf.WriteByte(amfString)
+			_ = binary.Write(buf, binary.BigEndian, uint16(len(v)))
+		}
+		buf.WriteString(v)
+	case float64:
+		buf.WriteByte(amfNumber)
+		_ = binary.Write(buf, binary.BigEndian, math.Float64bits(v))
+	case int:
+		writeAMF(buf, float64(v))
+	case uint32:
+		writeAMF(buf, float64(v))
+	case amfObj:
+		buf.WriteByte(amfObject)
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			_ = binary.Write(buf, binary.BigEndian, uint16(len(k)))
+			buf.WriteString(k)
+			writeAMF(buf, v[k])
+		}
+		buf.Write([]byte{0, 0, amfObjectEnd})
+	case []any:
+		buf.WriteByte(amfStrictArray)
+		_ = binary.Write(buf, binary.BigEndian, uint32(len(v)))
+		for _, e := range v {
+			writeAMF(buf, e)
+		}
+	default:
+		buf.WriteByte(amfUndefined)
+	}
+}
@@ -0,0 +1,102 @@
+package rtmp
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAMFRoundTrip(t *testing.T) {
+	long := strings.Repeat("x", 70000)
+	tests := []struct {
+		name string
+		in   []any
+		want []any // nil when the values decode as they were
+	}{
+		{name: "null", in: []any{nil}},
+		{name: "booleans", in: []any{true, false}},
+		{name: "number", in: []any{1.5}},
+		{name: "integers become numbers", in: []any{3, uint32(7)}, want: []any{3.0, 7.0}},
+		{name: "string", in: []any{"publish"}},
+		{name: "long string", in: []any{long}},
+		{name: "object", in: []any{amfObj{"app": "live", "tcUrl": "rtmp://x/live", "n": 2.0, "nested": amfObj{}}}},
+		{name: "strict array", in: []any{[]any{1.0, "a", nil}}},
+		{name: "command", in: []any{"connect", 1.0, amfObj{"app": "live"}, nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeAMF(encodeAMF(tt.in...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.want
+			if want == nil {
+				want = tt.in
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %#v, want %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestAMFDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want []any
+	}{
+		{
+			name: "ECMA array",
+			in:   []byte{amfECMAArray, 0, 0, 0, 9, 0, 1, 'w', amfNumber, 0x40, 0x94, 0, 0, 0, 0, 0, 0, 0, 0, amfObjectEnd},
+			want: []any{amfObj{"w": 1280.0}},
+		},
+		{
+			name: "date",
+			in:   []byte{amfDate, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			want: []any{nil},
+		},
+		{
+			name: "undefined",
+			in:   []byte{amfUndefined},
+			want: []any{nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeAMF(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAMFMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+	}{
+		{"truncated number", []byte{amfNumber, 0, 0}},
+		{"missing boolean", []byte{amfBoolean}},
+		{"string longer than input", []byte{amfString, 0, 10, 'a'}},
+		{"truncated long string length", []byte{amfLongString, 0, 0}},
+		{"object without end marker", []byte{amfObject, 0, 1, 'a', amfNull}},
+		{"object with a bad end marker", []byte{amfObject, 0, 0, amfNull}},
+		{"strict array count beyond input", []byte{amfStrictArray, 0xff, 0xff, 0xff, 0xff}},
+		{"truncated date", []byte{amfDate, 0, 0}},
+		{"truncated ECMA array count", []byte{amfECMAArray, 0, 0}},
+		{"unsupported marker", []byte{0x11}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeAMF(tt.in); !errors.Is(err, errAMF) {
+				t.Errorf("got %v, want %v", err, errAMF)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package rtmp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Message type IDs.
+const (
+	msgSetChunkSize     = 1
+	msgAbort            = 2
+	msgAck              = 3
+	msgUserControl      = 4
+	msgWindowAckSize    = 5
+	msgSetPeerBandwidth = 6
+	msgAudio            = 8
+	msgVideo            = 9
+	msgDataAMF3         = 15
+	msgCommandAMF3      = 17
+	msgDataAMF0         = 18
+	msgCommandAMF0      = 20
+)
+
+// Chunk stream IDs used for what the server sends.
+const (
+	csidControl = 2
+	csidCommand = 3
+	csidAudio   = 4
+	csidVideo   = 6
+	csidData    = 5
+)
+
+const (
+	defaultChunkSize = 128
+	// serverChunkSize is what the server announces and sends with.
+	serverChunkSize = 4096
+	// maxMessageSize bounds a single message; a keyframe of a high-bitrate
+	// stream is a few megabytes at most.
+	maxMessageSize = 16 << 20
+	// maxChunkStreams and maxBufferedBytes bound what one connection can
+	// make the reader hold: encoders use a handful of chunk streams and
+	// interleave at most an audio and a video message.
+	maxChunkStreams  = 64
+	maxBufferedBytes = 2 * maxMessageSize
+	extendedTS       = 0xffffff
+)
+
+var errChunk = errors.New("rtmp: malformed chunk stream")
+
+// Message is one RTMP message, reassembled from its chunks.
+type Message struct {
+	Type      uint8
+	StreamID  uint32
+	Timestamp uint32 // milliseconds
+	Payload   []byte
+}
+
+// chunkState is what the reader remembers per chunk stream, since later
+// chunk headers only carry what changed.
+type chunkState struct {
+	timestamp uint32
+	delta     uint32
+	length    uint32
+	typ       uint8
+	streamID  uint32
+	extended  bool
+	buf       []byte // payload of the message being reassembled
+}
+
+// chunkReader splits the incoming byte stream into messages.
+type chunkReader struct {
+	r         *bufio.Reader
+	chunkSize uint32
+	streams   map[uint32]*chunkState
+	buffered  int    // payload bytes of messages not yet complete
+	read      uint64 // bytes consumed, for acknowledgements
+}
+
+func newChunkReader(r *bufio.Reader) *chunkReader {
+	return &chunkReader{r: r, chunkSize: defaultChunkSize, streams: make(map[uint32]*chunkState)}
+}
+
+func (cr *chunkReader) full(b []byte) error {
+	n, err := io.ReadFull(cr.r, b)
+	cr.read += uint64(n)
+	return err
+}
+
+func (cr *chunkReader) u24(b []byte) uint32 {
+	return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+}
+
+// ReadMessage returns the next complete message.
+func (cr *chunkReader) ReadMessage() (*Message, error) {
+	var hdr [11]byte
+	for {
+		if err := cr.full(hdr[:1]); err != nil {
+			return nil, err
+		}
+		format := hdr[0] >> 6
+		csid := uint32(hdr[0] & 0x3f)
+		switch csid {
+		case 0:
+			if err := cr.full(hdr[:1]); err != nil {
+				return nil, err
+			}
+			csid = 64 + uint32(hdr[0])
+		case 1:
+			if err := cr.full(hdr[:2]); err != nil {
+				return nil, err
+			}
+			csid = 64 + uint32(hdr[0]) + uint32(hdr[1])<<8
+		}
+
+		cs := cr.streams[csid]
+		if cs == nil {
+			if format != 0 {
+				return nil, fmt.Errorf("%w: chunk stream %d starts without a full header", errChunk, csid)
+			}
+			if len(cr.streams) >= maxChunkStreams {
+				return nil, fmt.Errorf("%w: more than %d chunk streams", errChunk, maxChunkStreams)
+			}
+			cs = &chunkState{}
+			cr.streams[csid] = cs
+		}
+		starting := len(cs.buf) == 0
+
+		var ts uint32
+		switch format {
+		case 0:
+			if err := cr.full(hdr[:11]); err != nil {
+				return nil, err
+			}
+			ts = cr.u24(hdr[0:3])
+			cs.length = cr.u24(hdr[3:6])
+			cs.typ = hdr[6]
+			cs.streamID = binary.LittleEndian.Uint32(hdr[7:11])
+		case 1:
+			if err := cr.full(hdr[:7]); err != nil {
+				return nil, err
+			}
+			ts = cr.u24(hdr[0:3])
+			cs.length = cr.u24(hdr[3:6])
+			cs.typ = hdr[6]
+		case 2:
+			if err := cr.full(hdr[:3]); err != nil {
+				return nil, err
+			}
+			ts = cr.u24(hdr[0:3])
+		}
+		if format < 3 {
+			cs.extended = ts == extendedTS
+		}
+		if cs.extended {
+			// Type 3 chunks repeat the extended timestamp of their header.
+			if err := cr.full(hdr[:4]); err != nil {
+				return nil, err
+			}
+			if format < 3 {
+				ts = binary.BigEndian.Uint32(hdr[:4])
+			}
+		}
+
+		if starting {
+			switch format {
+			case 0:
+				// A type 3 header after this one repeats its timestamp as delta.
+				cs.timestamp, cs.delta = ts, ts
+			case 1, 2:
+				cs.timestamp += ts
+				cs.delta = ts
+			case 3:
+				cs.timestamp += cs.delta
+			}
+			if cs.length > maxMessageSize {
+				return nil, fmt.Errorf("%w: message of %d bytes", errChunk, cs.length)
+			}
+		}
+
+		// The buffer grows with the chunks that actually arrive, so a
+		// header announcing a large message costs nothing by itself.
+		n := int(min(cs.length-uint32(len(cs.buf)), cr.chunkSize))
+		if cr.buffered+n > maxBufferedBytes {
+			return nil, fmt.Errorf("%w: more than %d bytes of incomplete messages", errChunk, maxBufferedBytes)
+		}
+		start := len(cs.buf)
+		cs.buf = slices.Grow(cs.buf, n)[:start+n]
+		if err := cr.full(cs.buf[start:]); err != nil {
+			return nil, err
+		}
+		cr.buffered += n
+		if uint32(len(cs.buf)) < cs.length {
+			continue
+		}
+
+		msg := &Message{Type: cs.typ, StreamID: cs.streamID, Timestamp: cs.timestamp, Payload: cs.buf}
+		cr.buffered -= len(cs.buf)
+		cs.buf = nil
+		return msg, nil
+	}
+}
+
+// Abort drops the partly received message of chunk stream csid.
+func (cr *chunkReader) Abort(csid uint32) {
+	if cs := cr.streams[csid]; cs != nil {
+		cr.buffered -= len(cs.buf)
+		cs.buf = nil
+	}
+}
+
+// chunkWriter splits outgoing messages into chunks. Every message starts
+// with a full header, which all peers accept.
+type chunkWriter struct {
+	w         *bufio.Writer
+	chunkSize uint32
+}
+
+func (cw *chunkWriter) WriteMessage(csid uint32, m *Message) error {
+	var hdr [16]byte
+	hdr[0] = byte(csid & 0x3f)
+	ts := m.Timestamp
+	if ts >= extendedTS {
+		ts = extendedTS
+	}
+	hdr[1], hdr[2], hdr[3] = byte(ts>>16), byte(ts>>8), byte(ts)
+	n := len(m.Payload)
+	hdr[4], hdr[5], hdr[6] = byte(n>>16), byte(n>>8), byte(n)
+	hdr[7] = m.Type
+	binary.LittleEndian.PutUint32(hdr[8:12], m.StreamID)
+	hlen := 12
+	if ts == extendedTS {
+		binary.BigEndian.PutUint32(hdr[12:16], m.Timestamp)
+		hlen = 16
+	}
+	if _, err := cw.w.Write(hdr[:hlen]); err != nil {
+		return err
+	}
+
+	payload := m.Payload
+	for {
+		k := min(uint32(len(payload)), cw.chunkSize)
+		if _, err := cw.w.Write(payload[:k]); err != nil {
+			return err
+		}
+		payload = payload[k:]
+		if len(payload) == 0 {
+			return nil
+		}
+		cont := []byte{0xc0 | byte(csid&0x3f)}
+		if ts == extendedTS {
+			cont = binary.BigEndian.AppendUint32(cont, m.Timestamp)
+		}
+		if _, err := cw.w.Write(cont); err != nil {
+			return err
+		}
+	}
+}
+
+func (cw *chunkWriter) Flush() error { return cw.w.Flush() }
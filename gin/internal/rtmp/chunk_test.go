@@ -0,0 +1,248 @@
+package rtmp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// header0 is a type 0 chunk header on a one-byte chunk stream ID.
+func header0(csid byte, ts, length uint32, typ byte, streamID uint32) []byte {
+	b := []byte{csid, byte(ts >> 16), byte(ts >> 8), byte(ts), byte(length >> 16), byte(length >> 8), byte(length), typ}
+	return binary.LittleEndian.AppendUint32(b, streamID)
+}
+
+// header1 is a type 1 chunk header: delta, length and type.
+func header1(csid byte, delta, length uint32, typ byte) []byte {
+	return []byte{0x40 | csid, byte(delta >> 16), byte(delta >> 8), byte(delta), byte(length >> 16), byte(length >> 8), byte(length), typ}
+}
+
+// header2 is a type 2 chunk header: delta only.
+func header2(csid byte, delta uint32) []byte {
+	return []byte{0x80 | csid, byte(delta >> 16), byte(delta >> 8), byte(delta)}
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// written chunks the messages the way the server sends them.
+func written(t *testing.T, chunkSize uint32, msgs ...*Message) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	cw := &chunkWriter{w: bufio.NewWriter(&buf), chunkSize: chunkSize}
+	for _, m := range msgs {
+		if err := cw.WriteMessage(csidVideo, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func payload(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i)
+	}
+	return b
+}
+
+func TestChunkReassembly(t *testing.T) {
+	big := payload(300)
+	tests := []struct {
+		name string
+		in   func(t *testing.T) []byte
+		want []Message
+	}{
+		{
+			name: "single chunk",
+			in: func(t *testing.T) []byte {
+				return written(t, defaultChunkSize, &Message{Type: msgVideo, StreamID: 1, Timestamp: 40, Payload: []byte("abc")})
+			},
+			want: []Message{{Type: msgVideo, StreamID: 1, Timestamp: 40, Payload: []byte("abc")}},
+		},
+		{
+			name: "split across chunks",
+			in: func(t *testing.T) []byte {
+				return written(t, defaultChunkSize, &Message{Type: msgVideo, StreamID: 1, Timestamp: 80, Payload: big})
+			},
+			want: []Message{{Type: msgVideo, StreamID: 1, Timestamp: 80, Payload: big}},
+		},
+		{
+			name: "extended timestamp repeated in continuations",
+			in: func(t *testing.T) []byte {
+				return written(t, defaultChunkSize, &Message{Type: msgVideo, StreamID: 1, Timestamp: 0x01234567, Payload: big})
+			},
+			want: []Message{{Type: msgVideo, StreamID: 1, Timestamp: 0x01234567, Payload: big}},
+		},
+		{
+			name: "deltas from type 1, 2 and 3 headers",
+			in: func(*testing.T) []byte {
+				return concat(
+					header0(4, 1000, 3, msgAudio, 1), []byte("one"),
+					header1(4, 20, 2, msgAudio), []byte("tw"),
+					header2(4, 20), []byte("th"),
+					[]byte{0xc0 | 4}, []byte("fo"),
+				)
+			},
+			want: []Message{
+				{Type: msgAudio, StreamID: 1, Timestamp: 1000, Payload: []byte("one")},
+				{Type: msgAudio, StreamID: 1, Timestamp: 1020, Payload: []byte("tw")},
+				{Type: msgAudio, StreamID: 1, Timestamp: 1040, Payload: []byte("th")},
+				{Type: msgAudio, StreamID: 1, Timestamp: 1060, Payload: []byte("fo")},
+			},
+		},
+		{
+			name: "extended delta carried to the next message",
+			in: func(*testing.T) []byte {
+				return concat(
+					header0(4, extendedTS, 1, msgAudio, 1), []byte{0x01, 0, 0, 0}, []byte("a"),
+					[]byte{0xc0 | 4}, []byte{0x01, 0, 0, 0}, []byte("b"),
+				)
+			},
+			want: []Message{
+				{Type: msgAudio, StreamID: 1, Timestamp: 0x01000000, Payload: []byte("a")},
+				{Type: msgAudio, StreamID: 1, Timestamp: 0x02000000, Payload: []byte("b")},
+			},
+		},
+		{
+			name: "interleaved chunk streams",
+			in: func(*testing.T) []byte {
+				return concat(
+					header0(6, 0, 200, msgVideo, 1), big[:128],
+					header0(4, 10, 5, msgAudio, 1), []byte("audio"),
+					[]byte{0xc0 | 6}, big[128:200],
+				)
+			},
+			want: []Message{
+				{Type: msgAudio, StreamID: 1, Timestamp: 10, Payload: []byte("audio")},
+				{Type: msgVideo, StreamID: 1, Timestamp: 0, Payload: big[:200]},
+			},
+		},
+		{
+			name: "two-byte chunk stream ID",
+			in: func(*testing.T) []byte {
+				h := header0(0, 5, 1, msgDataAMF0, 1)
+				return concat(h[:1], []byte{100}, h[1:], []byte("x"))
+			},
+			want: []Message{{Type: msgDataAMF0, StreamID: 1, Timestamp: 5, Payload: []byte("x")}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := newChunkReader(bufio.NewReader(bytes.NewReader(tt.in(t))))
+			var got []Message
+			for {
+				m, err := cr.ReadMessage()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, *m)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+			if cr.buffered != 0 {
+				t.Errorf("%d bytes still counted as buffered", cr.buffered)
+			}
+		})
+	}
+}
+
+func TestChunkMalformed(t *testing.T) {
+	var manyStreams [][]byte
+	for csid := byte(2); csid < 2+maxChunkStreams+1; csid++ {
+		manyStreams = append(manyStreams, header0(csid, 0, 2, msgAudio, 1), []byte{0})
+	}
+	tests := []struct {
+		name      string
+		chunkSize uint32
+		in        []byte
+		want      error
+	}{
+		{"continuation without a header", 0, []byte{0xc0 | 4, 0}, errChunk},
+		{"delta without a header", 0, header2(4, 20), errChunk},
+		{"truncated header", 0, header0(4, 0, 10, msgAudio, 1)[:5], io.ErrUnexpectedEOF},
+		{"truncated payload", 0, concat(header0(4, 0, 10, msgAudio, 1), []byte("abc")), io.ErrUnexpectedEOF},
+		{"truncated extended timestamp", 0, concat(header0(4, extendedTS, 1, msgAudio, 1), []byte{1, 2}), io.ErrUnexpectedEOF},
+		{"too many chunk streams", 1, concat(manyStreams...), errChunk},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := newChunkReader(bufio.NewReader(bytes.NewReader(tt.in)))
+			if tt.chunkSize != 0 {
+				cr.chunkSize = tt.chunkSize
+			}
+			var err error
+			for err == nil {
+				_, err = cr.ReadMessage()
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// A header announcing a large message must not allocate it up front.
+func TestChunkBufferGrowsWithChunks(t *testing.T) {
+	in := concat(header0(4, 0, 0xffffff, msgVideo, 1), payload(defaultChunkSize))
+	cr := newChunkReader(bufio.NewReader(bytes.NewReader(in)))
+	if _, err := cr.ReadMessage(); err != io.EOF {
+		t.Fatalf("got %v, want EOF", err)
+	}
+	if c := cap(cr.streams[4].buf); c > 2*defaultChunkSize {
+		t.Errorf("buffer capacity %d after one chunk", c)
+	}
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestChunkBufferedLimit(t *testing.T) {
+	// Each chunk stream leaves one byte of its message missing.
+	const length = 0xffffff
+	var parts []io.Reader
+	for csid := byte(4); csid < 7; csid++ {
+		parts = append(parts, bytes.NewReader(header0(csid, 0, length, msgVideo, 1)), io.LimitReader(zeros{}, length-1))
+	}
+	cr := newChunkReader(bufio.NewReader(io.MultiReader(parts...)))
+	cr.chunkSize = length - 1
+	_, err := cr.ReadMessage()
+	if !errors.Is(err, errChunk) {
+		t.Fatalf("got %v, want %v", err, errChunk)
+	}
+	if cr.buffered > maxBufferedBytes {
+		t.Errorf("buffered %d bytes", cr.buffered)
+	}
+}
+
+func TestChunkAbort(t *testing.T) {
+	in := concat(
+		header0(4, 0, 10, msgAudio, 1), []byte("abc"),
+		header0(5, 0, 2, msgAudio, 1), []byte("ok"),
+	)
+	cr := newChunkReader(bufio.NewReader(bytes.NewReader(in)))
+	cr.chunkSize = 3
+	if _, err := cr.ReadMessage(); err != nil {
+		t.Fatal(err)
+	}
+	cr.Abort(4)
+	if cr.buffered != 0 || len(cr.streams[4].buf) != 0 {
+		t.Errorf("abort left %d bytes buffered", cr.buffered)
+	}
+}
@@ -0,0 +1,412 @@
+package rtmp
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	handshakeTimeout = 10 * time.Second
+	// publishTimeout drops publishers that stop sending.
+	publishTimeout = 30 * time.Second
+	authTimeout    = 10 * time.Second
+	windowAckSize  = 2500000
+	// mediaStreamID is the message stream every client gets from createStream.
+	mediaStreamID = 1
+)
+
+// errConnDone ends a connection on purpose, e.g. after a refused publish.
+var errConnDone = errors.New("rtmp: connection done")
+
+// User control event types.
+const (
+	eventStreamBegin = 0
+	eventPingRequest = 6
+	eventPingReply   = 7
+)
+
+type conn struct {
+	srv *Server
+	nc  net.Conn
+	br  *bufio.Reader
+	cr  *chunkReader
+
+	wmu sync.Mutex
+	cw  *chunkWriter
+
+	app       string
+	ackWindow uint32
+	acked     uint64
+
+	pub    *stream
+	pubKey string
+
+	play       *player
+	playStream *stream
+	finishOnce sync.Once
+}
+
+func newConn(s *Server, nc net.Conn) *conn {
+	br := bufio.NewReaderSize(nc, 64<<10)
+	return &conn{
+		srv: s,
+		nc:  nc,
+		br:  br,
+		cr:  newChunkReader(br),
+		cw:  &chunkWriter{w: bufio.NewWriterSize(nc, 64<<10), chunkSize: defaultChunkSize},
+	}
+}
+
+func (c *conn) serve() error {
+	defer c.nc.Close()
+	defer c.finish()
+
+	c.nc.SetDeadline(time.Now().Add(handshakeTimeout))
+	if err := handshake(c.br, c.cw.w); err != nil {
+		return fmt.Errorf("handshake: %w", err)
+	}
+	c.nc.SetDeadline(time.Time{})
+
+	for {
+		if c.play == nil {
+			// Players may stay silent; everyone else must keep talking.
+			c.nc.SetReadDeadline(time.Now().Add(publishTimeout))
+		}
+		msg, err := c.cr.ReadMessage()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return errConnDone
+			}
+			return err
+		}
+		if c.ackWindow > 0 && c.cr.read-c.acked >= uint64(c.ackWindow) {
+			c.acked = c.cr.read
+			if err := c.control(msgAck, uint32(c.cr.read)); err != nil {
+				return err
+			}
+		}
+		if err := c.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *conn) handle(msg *Message) error {
+	p := msg.Payload
+	switch msg.Type {
+	case msgSetChunkSize:
+		if len(p) < 4 {
+			return errChunk
+		}
+		size := binary.BigEndian.Uint32(p) & 0x7fffffff
+		if size == 0 || size > maxMessageSize {
+			return fmt.Errorf("%w: chunk size %d", errChunk, size)
+		}
+		c.cr.chunkSize = size
+	case msgAbort:
+		if len(p) >= 4 {
+			c.cr.Abort(binary.BigEndian.Uint32(p))
+		}
+	case msgWindowAckSize:
+		if len(p) >= 4 {
+			c.ackWindow = binary.BigEndian.Uint32(p)
+		}
+	case msgUserControl:
+		if len(p) >= 6 && binary.BigEndian.Uint16(p) == eventPingRequest {
+			return c.userControl(eventPingReply, binary.BigEndian.Uint32(p[2:]))
+		}
+	case msgCommandAMF3:
+		if len(p) > 0 {
+			return c.command(p[1:])
+		}
+	case msgCommandAMF0:
+		return c.command(p)
+	case msgDataAMF3:
+		if len(p) > 0 {
+			c.data(p[1:])
+		}
+	case msgDataAMF0:
+		c.data(p)
+	case msgAudio, msgVideo:
+		return c.media(msg)
+	}
+	return nil
+}
+
+func (c *conn) command(payload []byte) error {
+	vals, err := decodeAMF(payload)
+	if err != nil || len(vals) < 2 {
+		return fmt.Errorf("command: %w", errAMF)
+	}
+	name, _ := vals[0].(string)
+	txn, _ := vals[1].(float64)
+	arg := func(i int) string {
+		if i < len(vals) {
+			s, _ := vals[i].(string)
+			return s
+		}
+		return ""
+	}
+
+	switch name {
+	case "connect":
+		if len(vals) > 2 {
+			obj, _ := vals[2].(amfObj)
+			app, _ := obj["app"].(string)
+			c.app = strings.Trim(strings.SplitN(app, "?", 2)[0], "/")
+		}
+		if err := c.control(msgWindowAckSize, windowAckSize); err != nil {
+			return err
+		}
+		if err := c.write(csidControl, &Message{Type: msgSetPeerBandwidth, Payload: []byte{0, 0x26, 0x25, 0xa0, 2}}); err != nil {
+			return err
+		}
+		if err := c.control(msgSetChunkSize, serverChunkSize); err != nil {
+			return err
+		}
+		c.wmu.Lock()
+		c.cw.chunkSize = serverChunkSize
+		c.wmu.Unlock()
+		return c.send("_result", txn,
+			amfObj{"fmsVer": "FMS/3,0,1,123", "capabilities": 31.0},
+			amfObj{"level": "status", "code": "NetConnection.Connect.Success", "description": "Connection succeeded.", "objectEncoding": 0.0})
+	case "createStream":
+		return c.send("_result", txn, nil, mediaStreamID)
+	case "releaseStream", "FCPublish", "FCUnpublish", "getStreamLength":
+		return c.send("_result", txn, nil, nil)
+	case "publish":
+		return c.publish(arg(3))
+	case "play":
+		return c.startPlay(arg(3))
+	case "deleteStream", "closeStream":
+		c.finish()
+		return errConnDone
+	}
+	return nil
+}
+
+// publish authorizes and starts a publish of key.
+func (c *conn) publish(key string) error {
+	key = strings.SplitN(key, "?", 2)[0]
+	if c.pub != nil || key == "" {
+		return c.refuse("NetStream.Publish.BadName", "invalid publish")
+	}
+	if !c.srv.claimKey(key) {
+		return c.refuse("NetStream.Publish.BadName", ErrAlreadyPublishing.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), authTimeout)
+	name, err := c.srv.Handler.Publish(ctx, c.app, key)
+	cancel()
+	if err != nil {
+		c.srv.releaseKey(key)
+		return c.refuse("NetStream.Publish.Denied", err.Error())
+	}
+	st := &stream{name: name, publisher: c.nc}
+	if err := c.srv.addStream(st); err != nil {
+		// Someone else holds the name; their publish stays theirs.
+		c.srv.releaseKey(key)
+		return c.refuse("NetStream.Publish.BadName", err.Error())
+	}
+	if c.srv.NewSink != nil {
+		if st.sink, err = c.srv.NewSink(name); err != nil {
+			c.srv.removeStream(st)
+			c.srv.releaseKey(key)
+			c.srv.Handler.PublishDone(name)
+			return fmt.Errorf("opening output of %s: %w", name, err)
+		}
+	}
+	c.pub, c.pubKey = st, key
+
+	if err := c.userControl(eventStreamBegin, mediaStreamID); err != nil {
+		return err
+	}
+	return c.status("status", "NetStream.Publish.Start", name+" is now published.")
+}
+
+// startPlay subscribes the connection to a stream being published.
+func (c *conn) startPlay(name string) error {
+	name = strings.SplitN(name, "?", 2)[0]
+	if c.play != nil || c.srv.AllowPlay == nil || !c.srv.AllowPlay(c.nc.RemoteAddr(), c.app, name) {
+		return c.refuse("NetStream.Play.Failed", "playback not allowed")
+	}
+	st := c.srv.lookup(name)
+	if st == nil {
+		return c.refuse("NetStream.Play.StreamNotFound", "no such stream")
+	}
+
+	if err := c.userControl(eventStreamBegin, mediaStreamID); err != nil {
+		return err
+	}
+	if err := c.status("status", "NetStream.Play.Reset", "Playing and resetting "+name+"."); err != nil {
+		return err
+	}
+	if err := c.status("status", "NetStream.Play.Start", "Started playing "+name+"."); err != nil {
+		return err
+	}
+	if err := c.write(csidData, &Message{Type: msgDataAMF0, StreamID: mediaStreamID,
+		Payload: encodeAMF("|RtmpSampleAccess", true, true)}); err != nil {
+		return err
+	}
+
+	p := &player{queue: make(chan *Message, playerQueue), overflow: func() { c.nc.Close() }}
+	if !st.addPlayer(p) {
+		return c.refuse("NetStream.Play.StreamNotFound", "stream ended")
+	}
+	c.play, c.playStream = p, st
+	go c.playLoop(p)
+	return nil
+}
+
+// playLoop writes a player's queue until the stream ends or the
+// connection fails.
+func (c *conn) playLoop(p *player) {
+	for m := range p.queue {
+		csid := uint32(csidData)
+		switch m.Type {
+		case msgAudio:
+			csid = csidAudio
+		case msgVideo:
+			csid = csidVideo
+		}
+		c.wmu.Lock()
+		err := c.cw.WriteMessage(csid, m)
+		if err == nil && len(p.queue) == 0 {
+			err = c.cw.Flush()
+		}
+		c.wmu.Unlock()
+		if err != nil {
+			c.nc.Close()
+			return
+		}
+	}
+	// The publisher went away.
+	_ = c.status("status", "NetStream.Play.UnpublishNotify", "Stream ended.")
+	c.nc.Close()
+}
+
+// data keeps the publisher's metadata for players.
+func (c *conn) data(payload []byte) {
+	if c.pub == nil {
+		return
+	}
+	vals, err := decodeAMF(payload)
+	if err != nil || len(vals) == 0 {
+		return
+	}
+	if s, _ := vals[0].(string); s == "@setDataFrame" {
+		vals = vals[1:]
+	}
+	if len(vals) < 2 {
+		return
+	}
+	if s, _ := vals[0].(string); s != "onMetaData" {
+		return
+	}
+	m := &Message{Type: msgDataAMF0, StreamID: mediaStreamID, Payload: encodeAMF("onMetaData", vals[1])}
+	c.pub.mu.Lock()
+	c.pub.metadata = m
+	c.pub.mu.Unlock()
+	c.pub.broadcast(m, false)
+}
+
+// media passes a publisher's audio or video to the sink and players.
+func (c *conn) media(msg *Message) error {
+	st := c.pub
+	if st == nil || len(msg.Payload) < 2 {
+		return nil
+	}
+	m := &Message{Type: msg.Type, StreamID: mediaStreamID, Timestamp: msg.Timestamp, Payload: msg.Payload}
+	p := msg.Payload
+	keyframe := false
+	if msg.Type == msgVideo {
+		if st.sink != nil {
+			if err := st.sink.WriteVideo(msg.Timestamp, p); err != nil {
+				return fmt.Errorf("%s: video: %w", st.name, err)
+			}
+		}
+		if p[0]&0x0f == 7 && p[1] == 0 { // AVC sequence header
+			st.mu.Lock()
+			st.avcSeq = m
+			st.mu.Unlock()
+		}
+		keyframe = p[0]>>4 == 1
+	} else {
+		if st.sink != nil {
+			if err := st.sink.WriteAudio(msg.Timestamp, p); err != nil {
+				return fmt.Errorf("%s: audio: %w", st.name, err)
+			}
+		}
+		if p[0]>>4 == 10 && p[1] == 0 { // AAC sequence header
+			st.mu.Lock()
+			st.aacSeq = m
+			st.mu.Unlock()
+		}
+	}
+	st.broadcast(m, keyframe)
+	return nil
+}
+
+// finish ends the connection's publish or playback, once.
+func (c *conn) finish() {
+	c.finishOnce.Do(func() {
+		if st := c.playStream; st != nil {
+			st.removePlayer(c.play)
+		}
+		st := c.pub
+		if st == nil {
+			return
+		}
+		c.srv.removeStream(st)
+		st.end()
+		if st.sink != nil {
+			if err := st.sink.Close(); err != nil {
+				log.Printf("rtmp: closing output of %s: %v", st.name, err)
+			}
+		}
+		c.srv.Handler.PublishDone(st.name)
+		c.srv.releaseKey(c.pubKey)
+	})
+}
+
+// refuse tells the client why and ends the connection.
+func (c *conn) refuse(code, description string) error {
+	_ = c.status("error", code, description)
+	return errConnDone
+}
+
+func (c *conn) status(level, code, description string) error {
+	return c.write(csidCommand, &Message{Type: msgCommandAMF0, StreamID: mediaStreamID,
+		Payload: encodeAMF("onStatus", 0.0, nil, amfObj{"level": level, "code": code, "description": description})})
+}
+
+func (c *conn) send(vals ...any) error {
+	return c.write(csidCommand, &Message{Type: msgCommandAMF0, Payload: encodeAMF(vals...)})
+}
+
+func (c *conn) control(typ uint8, v uint32) error {
+	return c.write(csidControl, &Message{Type: typ, Payload: binary.BigEndian.AppendUint32(nil, v)})
+}
+
+func (c *conn) userControl(event uint16, v uint32) error {
+	p := binary.BigEndian.AppendUint16(nil, event)
+	return c.write(csidControl, &Message{Type: msgUserControl, Payload: binary.BigEndian.AppendUint32(p, v)})
+}
+
+func (c *conn) write(csid uint32, m *Message) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if err := c.cw.WriteMessage(csid, m); err != nil {
+		return err
+	}
+	return c.cw.Flush()
+}
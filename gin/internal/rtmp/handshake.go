@@ -0,0 +1,38 @@
+package rtmp
+
+import (
+	"bufio"
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+const handshakeSize = 1536
+
+// handshake performs the server side of the simple (unsigned) RTMP
+// handshake. Clients that try the digest handshake fall back to it when
+// S1 carries a zero version, as here.
+func handshake(r *bufio.Reader, w *bufio.Writer) error {
+	c0c1 := make([]byte, 1+handshakeSize)
+	if _, err := io.ReadFull(r, c0c1); err != nil {
+		return err
+	}
+	if c0c1[0] != 3 {
+		return fmt.Errorf("rtmp: unsupported version %d", c0c1[0])
+	}
+
+	s1 := make([]byte, handshakeSize) // time and zero version, then random
+	if _, err := rand.Read(s1[8:]); err != nil {
+		return err
+	}
+	w.WriteByte(3)
+	w.Write(s1)
+	w.Write(c0c1[1:]) // S2 echoes C1
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	c2 := make([]byte, handshakeSize)
+	_, err := io.ReadFull(r, c2)
+	return err
+}
@@ -0,0 +1,234 @@
+// Package rtmp is a small RTMP server for ingest: it accepts publishers
+// (OBS, ffmpeg, ...), hands their audio and video to a Sink such as an HLS
+// segmenter, and lets trusted clients play streams back, e.g. relays.
+package rtmp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"sync"
+)
+
+// ErrAlreadyPublishing is returned when a second publisher claims a stream.
+var ErrAlreadyPublishing = errors.New("rtmp: stream is already being published")
+
+// Handler decides who may publish and hears when publishes end.
+type Handler interface {
+	// Publish authorizes a publish to app with the stream name the client
+	// sent (the stream key) and returns the name the stream is known by
+	// from then on. An error refuses the publish.
+	Publish(ctx context.Context, app, key string) (name string, err error)
+	// PublishDone is called once when a publish that Publish accepted
+	// ends, after its sink is closed.
+	PublishDone(name string)
+}
+
+// Sink receives a published stream's FLV tag bodies.
+type Sink interface {
+	WriteAudio(ts uint32, body []byte) error
+	WriteVideo(ts uint32, body []byte) error
+	Close() error
+}
+
+// Server accepts RTMP connections.
+type Server struct {
+	Handler Handler
+	// NewSink, if set, opens the output of each accepted publish.
+	NewSink func(name string) (Sink, error)
+	// AllowPlay, if set, decides who may play a stream back. Without it
+	// playback is refused.
+	AllowPlay func(addr net.Addr, app, name string) bool
+
+	mu      sync.Mutex
+	streams map[string]*stream // by name
+	keys    map[string]bool    // keys with a publish in progress
+}
+
+// ListenAndServe listens on addr and serves connections until the
+// listener fails.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l.
+func (s *Server) Serve(l net.Listener) error {
+	defer l.Close()
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+		go func() {
+			if err := newConn(s, c).serve(); err != nil && !isClosed(err) {
+				log.Printf("rtmp: %s: %v", c.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// Publishing returns the names of the streams being published.
+func (s *Server) Publishing() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.streams))
+	for name := range s.streams {
+		names = append(names, name)
+	}
+	return names
+}
+
+// claimKey marks key as publishing, failing if it already is.
+func (s *Server) claimKey(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys == nil {
+		s.keys = make(map[string]bool)
+	}
+	if s.keys[key] {
+		return false
+	}
+	s.keys[key] = true
+	return true
+}
+
+func (s *Server) releaseKey(key string) {
+	s.mu.Lock()
+	delete(s.keys, key)
+	s.mu.Unlock()
+}
+
+// addStream registers a new publish under name.
+func (s *Server) addStream(st *stream) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.streams == nil {
+		s.streams = make(map[string]*stream)
+	}
+	if _, ok := s.streams[st.name]; ok {
+		return ErrAlreadyPublishing
+	}
+	s.streams[st.name] = st
+	return nil
+}
+
+func (s *Server) removeStream(st *stream) {
+	s.mu.Lock()
+	if s.streams[st.name] == st {
+		delete(s.streams, st.name)
+	}
+	s.mu.Unlock()
+}
+
+// Drop disconnects the publisher of name, which ends the publish as if
+// the client had left. It reports whether name was being published.
+func (s *Server) Drop(name string) bool {
+	st := s.lookup(name)
+	if st == nil {
+		return false
+	}
+	st.publisher.Close()
+	return true
+}
+
+func (s *Server) lookup(name string) *stream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.streams[name]
+}
+
+// stream is one publish in progress and its players.
+type stream struct {
+	name      string
+	sink      Sink
+	publisher io.Closer // the publishing connection
+
+	mu       sync.Mutex
+	metadata *Message // onMetaData
+	avcSeq   *Message // decoder configurations, sent to new players first
+	aacSeq   *Message
+	players  map[*player]struct{}
+	ended    bool
+}
+
+// player is a connection playing a stream. Messages are queued and
+// written by its connection; a player that falls behind is dropped.
+type player struct {
+	queue    chan *Message
+	gotKey   bool
+	overflow func()
+}
+
+const playerQueue = 512
+
+// addPlayer queues the stream's metadata and decoder configurations for
+// p and subscribes it. It reports false if the publish already ended.
+func (st *stream) addPlayer(p *player) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.ended {
+		return false
+	}
+	if st.players == nil {
+		st.players = make(map[*player]struct{})
+	}
+	for _, m := range []*Message{st.metadata, st.avcSeq, st.aacSeq} {
+		if m != nil {
+			p.queue <- m
+		}
+	}
+	st.players[p] = struct{}{}
+	return true
+}
+
+func (st *stream) removePlayer(p *player) {
+	st.mu.Lock()
+	delete(st.players, p)
+	st.mu.Unlock()
+}
+
+// broadcast queues m for every player. Video is held back from a player
+// until a keyframe, so it starts decodable.
+func (st *stream) broadcast(m *Message, keyframe bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for p := range st.players {
+		if m.Type == msgVideo && !p.gotKey {
+			if !keyframe {
+				continue
+			}
+			p.gotKey = true
+		}
+		select {
+		case p.queue <- m:
+		default:
+			delete(st.players, p)
+			p.overflow()
+		}
+	}
+}
+
+// end closes every player's queue.
+func (st *stream) end() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for p := range st.players {
+		close(p.queue)
+	}
+	st.players = nil
+	st.ended = true
+}
+
+func isClosed(err error) bool {
+	return errors.Is(err, net.ErrClosed) || errors.Is(err, errConnDone) || errors.Is(err, io.EOF)
+}
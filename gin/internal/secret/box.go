@@ -0,0 +1,61 @@
+// Package secret encrypts small secrets, such as third-party stream keys,
+// for storage at rest.
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// ErrCorrupt is returned for ciphertexts that do not decrypt, including
+// ones sealed with a different key.
+var ErrCorrupt = errors.New("secret: cannot decrypt")
+
+// Box seals and opens strings with AES-256-GCM.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox returns a Box keyed with the SHA-256 of key, so any passphrase
+// length works.
+func NewBox(key []byte) *Box {
+	sum := sha256.Sum256(key)
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		panic(err) // a 32-byte key is always valid
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return &Box{aead: aead}
+}
+
+// Seal encrypts plaintext under a random nonce and returns it as
+// base64url(nonce || ciphertext).
+func (b *Box) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a string produced by Seal.
+func (b *Box) Open(sealed string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < b.aead.NonceSize() {
+		return "", ErrCorrupt
+	}
+	n := b.aead.NonceSize()
+	plain, err := b.aead.Open(nil, raw[:n], raw[n:], nil)
+	if err != nil {
+		return "", ErrCorrupt
+	}
+	return string(plain), nil
+}
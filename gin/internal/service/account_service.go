@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrWrongPassword is returned when a sensitive action is confirmed with
+// the wrong password.
+var ErrWrongPassword = errors.New("wrong password")
+
+// DeleteAccount permanently deletes the user after checking their
+// password. A running broadcast is ended and its publisher dropped, and
+// the user's recordings and clips are removed from disk. If the ingest
+// server cannot be reached to drop the publisher, the account is deleted
+// all the same and the error wraps ErrIngestUnreachable.
+func (svc *Service) DeleteAccount(ctx context.Context, userID primitive.ObjectID, password string) error {
+	user, err := svc.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return ErrWrongPassword
+	}
+
+	// End the broadcast while the user still exists, so its restreams stop
+	// and its recording is finalized before it is deleted below.
+	s, err := svc.store.Streams.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	live := s != nil && s.Live
+	if live {
+		// Nobody is left to raid on the channel's behalf.
+		if _, err := svc.store.Raids.CancelPending(ctx, userID); err != nil {
+			return err
+		}
+		if _, err := svc.EndPublish(ctx, user.Username); err != nil {
+			return err
+		}
+	}
+
+	deleted, err := svc.store.Accounts.Delete(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, id := range deleted.VideoIDs {
+		if err := svc.archiver.Remove(id.Hex()); err != nil {
+			log.Printf("vod: failed to remove files of %s: %v", id.Hex(), err)
+		}
+	}
+	for _, id := range deleted.ClipIDs {
+		if err := svc.archiver.RemoveClip(id.Hex()); err != nil {
+			log.Printf("clips: failed to remove files of %s: %v", id.Hex(), err)
+		}
+	}
+
+	// The publish_done that follows finds no user and does nothing.
+	if live {
+		if _, err := svc.ingestControl().DropPublisher(ctx, ingestApp, user.Username); err != nil {
+			return fmt.Errorf("%w: %v", ErrIngestUnreachable, err)
+		}
+	}
+	return nil
+}
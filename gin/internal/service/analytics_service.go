@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/analytics"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/playback"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// viewerTimeout ends a viewer session once its player has not fetched
+	// a live playlist for this long.
+	viewerTimeout = 30 * time.Second
+	viewerSweep   = 10 * time.Second
+	// defaultAnalyticsDays and maxAnalyticsDays bound report date ranges.
+	defaultAnalyticsDays  = 30
+	maxAnalyticsDays      = 366
+	analyticsDay          = "2006-01-02"
+	analyticsWriteTimeout = 10 * time.Second
+)
+
+var (
+	// ErrInvalidRange is wrapped by malformed analytics date ranges.
+	ErrInvalidRange = errors.New("invalid date range")
+	// ErrBroadcastNotFound is returned for unknown broadcasts and those of
+	// other channels.
+	ErrBroadcastNotFound = errors.New("broadcast not found")
+)
+
+// AnalyticsRange is an inclusive range of UTC days.
+type AnalyticsRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	start, end time.Time // [start, end)
+}
+
+// AnalyticsCounters are the numbers counted as they happen.
+type AnalyticsCounters struct {
+	ChatMessages int64 `json:"chat_messages"`
+	NewFollowers int64 `json:"new_followers"`
+}
+
+// DailyAnalytics are a channel's numbers for one UTC day.
+type DailyAnalytics struct {
+	Day string `json:"day"`
+	analytics.Stats
+	AnalyticsCounters
+}
+
+// AnalyticsTotals are a channel's numbers over a whole range. Unique
+// viewers and peak are over the range, not sums of days.
+type AnalyticsTotals struct {
+	analytics.Stats
+	AnalyticsCounters
+}
+
+// DailyReport covers every day of a range, including quiet ones.
+type DailyReport struct {
+	AnalyticsRange
+	Totals AnalyticsTotals  `json:"totals"`
+	Days   []DailyAnalytics `json:"days"`
+}
+
+// BroadcastAnalytics are the numbers of one broadcast.
+type BroadcastAnalytics struct {
+	BroadcastID primitive.ObjectID `json:"broadcast_id"`
+	Title       string             `json:"title"`
+	Category    string             `json:"category"`
+	StartedAt   time.Time          `json:"started_at"`
+	EndedAt     *time.Time         `json:"ended_at,omitempty"`
+	analytics.Stats
+	AnalyticsCounters
+}
+
+// deriveViewerKey returns the key viewer IDs are anonymized with, derived
+// from the JWT secret.
+func deriveViewerKey(jwtSecret string) []byte {
+	sum := sha256.Sum256([]byte("viewer-analytics:" + jwtSecret))
+	return sum[:]
+}
+
+// newPlaybackSession returns the random ID that ties the tokens of one
+// playback together, so anonymous viewers can be told apart.
+func newPlaybackSession() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// recordView counts a live playlist fetch as a heartbeat of the viewer
+// the token was issued to.
+func (svc *Service) recordView(ctx context.Context, claims playback.Claims) {
+	t := svc.viewers
+	var viewer string
+	switch {
+	case claims.Viewer != "":
+		viewer = analytics.ViewerID(svc.viewerKey, "user", claims.Viewer)
+	case claims.Session != "":
+		viewer = analytics.ViewerID(svc.viewerKey, "session", claims.Session)
+	default:
+		return // issued before sessions were tracked
+	}
+	now := time.Now().UTC()
+	if t.Seen(claims.Stream, viewer, now) {
+		return
+	}
+	// A broadcast that started before a restart.
+	s, err := svc.store.Streams.FindByUsername(ctx, claims.Stream)
+	if err != nil || s == nil || !s.Live || s.StartedAt == nil {
+		return
+	}
+	t.Begin(s.Username, trackedBroadcast(s))
+	t.Seen(claims.Stream, viewer, now)
+}
+
+func trackedBroadcast(s *models.Stream) analytics.Broadcast {
+	return analytics.Broadcast{Channel: s.UserID.Hex(), ID: s.BroadcastID.Hex(), Started: *s.StartedAt}
+}
+
+// startAnalytics starts counting the viewers of a broadcast that just
+// started.
+func (svc *Service) startAnalytics(s *models.Stream) {
+	svc.viewers.Begin(s.Username, trackedBroadcast(s))
+}
+
+// finishAnalytics closes the viewer sessions of a broadcast that just
+// ended.
+func (svc *Service) finishAnalytics(s *models.Stream) {
+	end := time.Now().UTC()
+	if s.EndedAt != nil {
+		end = *s.EndedAt
+	}
+	svc.viewers.End(s.Username, end)
+}
+
+// peakViewers returns the most viewers the stream's current broadcast had
+// at once, as far as this process saw.
+func (svc *Service) peakViewers(stream string) int {
+	_, peak := svc.viewers.Viewers(stream)
+	return peak
+}
+
+// currentViewers returns how many viewers the stream has right now, as
+// far as this process sees.
+func (svc *Service) currentViewers(stream string) int {
+	current, _ := svc.viewers.Viewers(stream)
+	return current
+}
+
+// saveViewerSessions stores sessions the tracker ended.
+func (svc *Service) saveViewerSessions(sessions []analytics.Session) {
+	docs := make([]models.ViewerSession, 0, len(sessions))
+	for _, s := range sessions {
+		channelID, err1 := primitive.ObjectIDFromHex(s.Channel)
+		broadcastID, err2 := primitive.ObjectIDFromHex(s.ID)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		docs = append(docs, models.ViewerSession{
+			ChannelID: channelID, BroadcastID: broadcastID, Viewer: s.Viewer,
+			JoinedAt: s.Joined, LeftAt: s.Left,
+		})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), analyticsWriteTimeout)
+	defer cancel()
+	if err := svc.store.Analytics.InsertViewerSessions(ctx, docs); err != nil {
+		log.Printf("analytics: failed to store %d viewer sessions: %v", len(docs), err)
+	}
+}
+
+// countStat adds one to a counter of the channel's day and, while it is
+// live, of its broadcast. Failures are logged.
+func (svc *Service) countStat(ctx context.Context, channel *models.User, field string, at time.Time) {
+	if err := svc.store.Analytics.IncDailyStat(ctx, channel.ID, at.UTC().Format(analyticsDay), field); err != nil {
+		log.Printf("analytics: failed to count %s of %s: %v", field, channel.Username, err)
+	}
+	s, err := svc.store.Streams.FindByUserID(ctx, channel.ID)
+	if err != nil || s == nil || !s.Live || s.BroadcastID.IsZero() {
+		return
+	}
+	if err := svc.store.Broadcasts.IncStat(ctx, s.BroadcastID, field); err != nil {
+		log.Printf("analytics: failed to count %s of %s: %v", field, channel.Username, err)
+	}
+}
+
+// ParseAnalyticsRange validates an inclusive range of days given as
+// YYYY-MM-DD. Missing bounds default to the last 30 days up to today.
+func ParseAnalyticsRange(from, to string) (AnalyticsRange, error) {
+	var r AnalyticsRange
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	if to != "" {
+		t, err := time.Parse(analyticsDay, to)
+		if err != nil {
+			return r, fmt.Errorf("%w: to must be YYYY-MM-DD", ErrInvalidRange)
+		}
+		end = t
+	}
+	start := end.AddDate(0, 0, -(defaultAnalyticsDays - 1))
+	if from != "" {
+		t, err := time.Parse(analyticsDay, from)
+		if err != nil {
+			return r, fmt.Errorf("%w: from must be YYYY-MM-DD", ErrInvalidRange)
+		}
+		start = t
+	}
+	if end.Before(start) {
+		return r, fmt.Errorf("%w: from is after to", ErrInvalidRange)
+	}
+	if days := int(end.Sub(start).Hours()/24) + 1; days > maxAnalyticsDays {
+		return r, fmt.Errorf("%w: at most %d days", ErrInvalidRange, maxAnalyticsDays)
+	}
+	r.From, r.To = start.Format(analyticsDay), end.Format(analyticsDay)
+	r.start, r.end = start, end.AddDate(0, 0, 1)
+	return r, nil
+}
+
+// GetDailyAnalytics rolls the user's channel up per day of r.
+func (svc *Service) GetDailyAnalytics(ctx context.Context, userID primitive.ObjectID, r AnalyticsRange) (*DailyReport, error) {
+	docs, err := svc.store.Analytics.ListViewerSessions(ctx, userID, r.start, r.end)
+	if err != nil {
+		return nil, err
+	}
+	counters, err := svc.store.Analytics.ListDailyStats(ctx, userID, r.From, r.To)
+	if err != nil {
+		return nil, err
+	}
+	byDay := make(map[string]models.DailyStats, len(counters))
+	for _, c := range counters {
+		byDay[c.Day] = c
+	}
+	sessions := toSessions(docs)
+
+	report := &DailyReport{AnalyticsRange: r, Days: []DailyAnalytics{}}
+	for day := r.start; day.Before(r.end); day = day.AddDate(0, 0, 1) {
+		key := day.Format(analyticsDay)
+		c := byDay[key]
+		d := DailyAnalytics{
+			Day:               key,
+			Stats:             analytics.Rollup(sessions, day, day.AddDate(0, 0, 1)),
+			AnalyticsCounters: AnalyticsCounters{ChatMessages: c.ChatMessages, NewFollowers: c.NewFollowers},
+		}
+		report.Totals.ChatMessages += d.ChatMessages
+		report.Totals.NewFollowers += d.NewFollowers
+		report.Days = append(report.Days, d)
+	}
+	report.Totals.Stats = analytics.Rollup(sessions, r.start, r.end)
+	return report, nil
+}
+
+// ListBroadcastAnalytics rolls up each of the user's broadcasts that
+// started within r, newest first.
+func (svc *Service) ListBroadcastAnalytics(ctx context.Context, userID primitive.ObjectID, r AnalyticsRange) ([]BroadcastAnalytics, error) {
+	broadcasts, err := svc.store.Broadcasts.ListStartedBetween(ctx, userID, r.start, r.end)
+	if err != nil {
+		return nil, err
+	}
+	return svc.rollupBroadcasts(ctx, broadcasts)
+}
+
+// GetBroadcastAnalytics rolls up one of the user's broadcasts.
+func (svc *Service) GetBroadcastAnalytics(ctx context.Context, userID, broadcastID primitive.ObjectID) (*BroadcastAnalytics, error) {
+	b, err := svc.store.Broadcasts.FindByID(ctx, broadcastID)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil || b.ChannelID != userID {
+		return nil, ErrBroadcastNotFound
+	}
+	out, err := svc.rollupBroadcasts(ctx, []models.Broadcast{*b})
+	if err != nil {
+		return nil, err
+	}
+	return &out[0], nil
+}
+
+func (svc *Service) rollupBroadcasts(ctx context.Context, broadcasts []models.Broadcast) ([]BroadcastAnalytics, error) {
+	ids := make([]primitive.ObjectID, len(broadcasts))
+	for i, b := range broadcasts {
+		ids[i] = b.ID
+	}
+	docs, err := svc.store.Analytics.ListBroadcastViewerSessions(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byBroadcast := make(map[primitive.ObjectID][]models.ViewerSession)
+	for _, d := range docs {
+		byBroadcast[d.BroadcastID] = append(byBroadcast[d.BroadcastID], d)
+	}
+
+	out := make([]BroadcastAnalytics, 0, len(broadcasts))
+	for _, b := range broadcasts {
+		end := time.Now().UTC()
+		if b.EndedAt != nil {
+			end = *b.EndedAt
+		}
+		sessions := toSessions(byBroadcast[b.ID])
+		out = append(out, BroadcastAnalytics{
+			BroadcastID:       b.ID,
+			Title:             b.Title,
+			Category:          b.Category,
+			StartedAt:         b.StartedAt,
+			EndedAt:           b.EndedAt,
+			Stats:             analytics.Rollup(sessions, b.StartedAt, end),
+			AnalyticsCounters: AnalyticsCounters{ChatMessages: b.ChatMessages, NewFollowers: b.NewFollowers},
+		})
+	}
+	return out, nil
+}
+
+func toSessions(docs []models.ViewerSession) []analytics.Session {
+	sessions := make([]analytics.Session, len(docs))
+	for i, d := range docs {
+		sessions[i] = analytics.Session{Viewer: d.Viewer, Joined: d.JoinedAt, Left: d.LeftAt}
+	}
+	return sessions
+}
@@ -5,8 +5,8 @@ import (
 
 	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
 	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
-
 )
 
 type LoginRequest struct {
@@ -14,22 +14,32 @@ type LoginRequest struct {
 	Password        string
 }
 
+// AuthService registers users and checks their credentials.
+type AuthService struct {
+	users repo.UserRepository
+}
+
+// NewAuthService returns an AuthService over users.
+func NewAuthService(users repo.UserRepository) *AuthService {
+	return &AuthService{users: users}
+}
+
 // CreateUser creates a user record in the DB.
 // At the moment it simply forwards to the repo; you can add
 // validation, duplicate‑email checks, etc. here later.
-func CreateUser(ctx context.Context, user *models.User) error {
-	return repo.CreateUser(ctx, user)
+func (s *AuthService) CreateUser(ctx context.Context, user *models.User) error {
+	return s.users.Create(ctx, user)
 }
 
-func Authenticate(ctx context.Context, lr *LoginRequest) (*models.User, error) {
+func (s *AuthService) Authenticate(ctx context.Context, lr *LoginRequest) (*models.User, error) {
 	// Find user by email first; if not found try username.
-	user, err := repo.FindUserByEmailOrUsername(ctx, lr.EmailOrUsername, "")
+	user, err := s.users.FindByEmailOrUsername(ctx, lr.EmailOrUsername, "")
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
 		// If not found by email, try by username.
-		user, err = repo.FindUserByEmailOrUsername(ctx, "", lr.EmailOrUsername)
+		user, err = s.users.FindByEmailOrUsername(ctx, "", lr.EmailOrUsername)
 		if err != nil {
 			return nil, err
 		}
@@ -44,3 +54,8 @@ func Authenticate(ctx context.Context, lr *LoginRequest) (*models.User, error) {
 	}
 	return user, nil
 }
+
+// GetUser returns the user with the given ID (or nil).
+func (s *AuthService) GetUser(ctx context.Context, userID primitive.ObjectID) (*models.User, error) {
+	return s.users.FindByID(ctx, userID)
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BroadcastPage is a page of a channel's broadcast history.
+type BroadcastPage struct {
+	Broadcasts []models.Broadcast `json:"broadcasts"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// startBroadcast records a broadcast that just started with the stream's
+// details at that moment. Failures are logged; they never block the publish.
+func (svc *Service) startBroadcast(ctx context.Context, s *models.Stream) {
+	b := &models.Broadcast{
+		ID:         s.BroadcastID,
+		ChannelID:  s.UserID,
+		Username:   s.Username,
+		Title:      s.Title,
+		Category:   s.Category,
+		Tags:       s.Tags,
+		Visibility: s.Visibility,
+		StartedAt:  *s.StartedAt,
+	}
+	if err := svc.store.Broadcasts.Create(ctx, b); err != nil {
+		log.Printf("broadcasts: failed to record broadcast of %s: %v", s.Username, err)
+	}
+}
+
+// finishBroadcast records the end of the stream's broadcast. It must run
+// before finishAnalytics, which forgets the broadcast's peak.
+func (svc *Service) finishBroadcast(ctx context.Context, s *models.Stream) {
+	if s.BroadcastID.IsZero() || s.StartedAt == nil {
+		return
+	}
+	end := time.Now().UTC()
+	if s.EndedAt != nil {
+		end = *s.EndedAt
+	}
+	err := svc.store.Broadcasts.End(ctx, s.BroadcastID, end, end.Sub(*s.StartedAt).Seconds(), svc.peakViewers(s.Username))
+	if err != nil {
+		log.Printf("broadcasts: failed to end broadcast of %s: %v", s.Username, err)
+	}
+}
+
+// recordBroadcastChanges logs title and category changes made while live
+// as events of the current broadcast.
+func (svc *Service) recordBroadcastChanges(ctx context.Context, prev, s *models.Stream) {
+	if prev == nil || !prev.Live || prev.BroadcastID != s.BroadcastID || s.BroadcastID.IsZero() {
+		return
+	}
+	now := time.Now().UTC()
+	var events []models.BroadcastEvent
+	if prev.Title != s.Title {
+		events = append(events, models.BroadcastEvent{Type: models.BroadcastTitleChanged, From: prev.Title, To: s.Title, At: now})
+	}
+	if prev.Category != s.Category {
+		events = append(events, models.BroadcastEvent{Type: models.BroadcastCategoryChanged, From: prev.Category, To: s.Category, At: now})
+	}
+	if err := svc.store.Broadcasts.AddEvents(ctx, s.BroadcastID, events); err != nil {
+		log.Printf("broadcasts: failed to record changes of %s: %v", s.Username, err)
+	}
+}
+
+// ListBroadcasts returns a page of a channel's broadcasts, newest first,
+// as the viewer may see them (the same rules as recordings). Broadcasts
+// whose recording was kept link it.
+func (svc *Service) ListBroadcasts(ctx context.Context, username string, viewerID primitive.ObjectID, cursor string, limit int) (*BroadcastPage, error) {
+	user, err := svc.store.Users.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	after, err := decodeTimeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	hidden, err := svc.hiddenVisibilities(ctx, user, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	limit = svc.clampLimit(limit)
+	broadcasts, err := svc.store.Broadcasts.ListByChannel(ctx, user.ID, hidden, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, len(broadcasts))
+	for i, b := range broadcasts {
+		ids[i] = b.ID
+	}
+	videos, err := svc.store.Videos.ListByBroadcastIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	videoOf := make(map[primitive.ObjectID]primitive.ObjectID, len(videos))
+	for _, v := range videos {
+		videoOf[v.BroadcastID] = v.ID
+	}
+	for i := range broadcasts {
+		if id, ok := videoOf[broadcasts[i].ID]; ok {
+			broadcasts[i].VideoID = &id
+		}
+	}
+
+	page := &BroadcastPage{Broadcasts: broadcasts}
+	if len(broadcasts) == limit {
+		last := broadcasts[len(broadcasts)-1]
+		page.NextCursor = encodeCursor(repo.TimeCursor{At: last.StartedAt, ID: last.ID})
+	}
+	return page, nil
+}
+
+// findVisibleBroadcast returns a broadcast the viewer may see. Like
+// recordings, unlisted ones are reachable by ID and private ones only by
+// the owner and allowlist; the rest look missing.
+func (svc *Service) findVisibleBroadcast(ctx context.Context, id, viewerID primitive.ObjectID) (*models.Broadcast, error) {
+	b, err := svc.store.Broadcasts.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, ErrBroadcastNotFound
+	}
+	if b.Visibility == models.VisibilityPrivate {
+		ok, err := svc.canSeePrivate(ctx, b.ChannelID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrBroadcastNotFound
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	defaultTrendingHours = 24
+	maxTrendingHours     = 24 * 7
+)
+
+var (
+	// ErrUnknownCategory is returned when a stream references a slug that is not in the catalog.
+	ErrUnknownCategory = errors.New("unknown category")
+	// ErrInvalidCategory is returned for malformed admin input.
+	ErrInvalidCategory = errors.New("invalid category")
+	// ErrCategoryExists is returned when creating a duplicate slug.
+	ErrCategoryExists = errors.New("category already exists")
+	// ErrCategoryInUse is returned when deleting a category that streams still reference.
+	ErrCategoryInUse = errors.New("category is used by existing streams")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,31}$`)
+
+// defaultCategories seeds an empty catalog with what the create page used to hard-code.
+var defaultCategories = []models.Category{
+	{Slug: "gaming", Name: "Gaming", Icon: "🎮", SortOrder: 10},
+	{Slug: "music", Name: "Music", Icon: "🎵", SortOrder: 20},
+	{Slug: "art", Name: "Art & Creative", Icon: "🎨", SortOrder: 30},
+	{Slug: "cooking", Name: "Cooking", Icon: "🍳", SortOrder: 40},
+	{Slug: "sports", Name: "Sports", Icon: "⚽", SortOrder: 50},
+	{Slug: "tech", Name: "Technology", Icon: "💻", SortOrder: 60},
+	{Slug: "chatting", Name: "Just Chatting", Icon: "💬", SortOrder: 70},
+}
+
+// CategoryInput is the admin payload for creating or editing a category.
+// Nil fields are left unchanged on update.
+type CategoryInput struct {
+	Slug      string
+	Name      *string
+	Icon      *string
+	SortOrder *int
+}
+
+// SeedDefaultCategories fills the catalog with the default entries if it is empty.
+func (svc *Service) SeedDefaultCategories(ctx context.Context) error {
+	n, err := svc.store.Categories.Count(ctx)
+	if err != nil || n > 0 {
+		return err
+	}
+	now := time.Now().UTC()
+	for _, c := range defaultCategories {
+		c.CreatedAt, c.UpdatedAt = now, now
+		if err := svc.store.Categories.Create(ctx, &c); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	log.Printf("seeded %d default categories", len(defaultCategories))
+	return nil
+}
+
+// ListCategories returns the catalog with live stream counts.
+func (svc *Service) ListCategories(ctx context.Context) ([]repo.CategoryLiveCount, error) {
+	return svc.store.Categories.ListWithLiveCounts(ctx)
+}
+
+// CreateCategory validates and inserts a new catalog entry.
+func (svc *Service) CreateCategory(ctx context.Context, in CategoryInput) (*models.Category, error) {
+	slug := strings.ToLower(strings.TrimSpace(in.Slug))
+	if !slugPattern.MatchString(slug) {
+		return nil, fmt.Errorf("%w: slug must be 2-32 lowercase letters, digits or hyphens", ErrInvalidCategory)
+	}
+	if in.Name == nil || strings.TrimSpace(*in.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidCategory)
+	}
+
+	now := time.Now().UTC()
+	cat := &models.Category{
+		Slug:      slug,
+		Name:      strings.TrimSpace(*in.Name),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if in.Icon != nil {
+		cat.Icon = strings.TrimSpace(*in.Icon)
+	}
+	if in.SortOrder != nil {
+		cat.SortOrder = *in.SortOrder
+	}
+	if err := svc.store.Categories.Create(ctx, cat); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrCategoryExists
+		}
+		return nil, err
+	}
+	return cat, nil
+}
+
+// UpdateCategory edits the display fields of a category. The slug is
+// immutable because streams reference it. Returns nil if it does not exist.
+func (svc *Service) UpdateCategory(ctx context.Context, in CategoryInput) (*models.Category, error) {
+	if in.Name != nil {
+		name := strings.TrimSpace(*in.Name)
+		if name == "" {
+			return nil, fmt.Errorf("%w: name cannot be empty", ErrInvalidCategory)
+		}
+		in.Name = &name
+	}
+	return svc.store.Categories.Update(ctx, in.Slug, repo.CategoryUpdate{
+		Name:      in.Name,
+		Icon:      in.Icon,
+		SortOrder: in.SortOrder,
+	})
+}
+
+// DeleteCategory removes an unused category. It reports whether it existed.
+func (svc *Service) DeleteCategory(ctx context.Context, slug string) (bool, error) {
+	n, err := svc.store.Streams.CountInCategory(ctx, slug)
+	if err != nil {
+		return false, err
+	}
+	if n > 0 {
+		return false, ErrCategoryInUse
+	}
+	return svc.store.Categories.Delete(ctx, slug)
+}
+
+// BrowseCategory lists the live streams in a category, most recently
+// started first. It returns ErrUnknownCategory for slugs not in the catalog.
+func (svc *Service) BrowseCategory(ctx context.Context, slug, cursor string, limit int) (*SearchResult, error) {
+	cat, err := svc.store.Categories.FindBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if cat == nil {
+		return nil, ErrUnknownCategory
+	}
+	return svc.Search(ctx, SearchRequest{
+		LiveOnly: true,
+		Category: cat.Slug,
+		Sort:     "recent",
+		Cursor:   cursor,
+		Limit:    limit,
+	})
+}
+
+// TrendingTags returns the tags used by the most channels that were live
+// during the last `hours` hours.
+func (svc *Service) TrendingTags(ctx context.Context, hours, limit int) ([]repo.TagCount, error) {
+	if hours <= 0 {
+		hours = defaultTrendingHours
+	}
+	if hours > maxTrendingHours {
+		hours = maxTrendingHours
+	}
+	since := time.Now().UTC().Add(-time.Duration(hours) * time.Hour)
+	return svc.store.Streams.TrendingTags(ctx, since, svc.clampLimit(limit))
+}
+
+// validateCategory checks that slug is in the catalog.
+func (svc *Service) validateCategory(ctx context.Context, slug string) error {
+	cat, err := svc.store.Categories.FindBySlug(ctx, slug)
+	if err != nil {
+		return err
+	}
+	if cat == nil {
+		return ErrUnknownCategory
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/chat"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/playback"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxChatMessageLength = 500
+	// chatHistorySize is how many messages a client receives on join.
+	chatHistorySize = 50
+	// Each user may send chatRateBurst messages at once, then one per chatRateInterval.
+	chatRateInterval = 2 * time.Second
+	chatRateBurst    = 5
+	// maxMentionsPerMessage caps notifications generated by one message.
+	maxMentionsPerMessage = 5
+)
+
+var mentionPattern = regexp.MustCompile(`@([A-Za-z0-9]{3,20})\b`)
+
+// ChatPage is one page of a channel's chat history.
+type ChatPage struct {
+	Messages   []models.ChatMessage `json:"messages"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+// UseChatPubSub replaces the chat pub/sub backend, e.g. with a shared one
+// so that several gin instances serve the same rooms. It must be called
+// before the server accepts connections.
+func (svc *Service) UseChatPubSub(ps chat.PubSub) {
+	svc.chatHub = chat.NewHub(ps)
+}
+
+// OpenChatRoom resolves the channel whose room a client wants to join and
+// checks that the viewer (NilObjectID when anonymous) may watch it. The
+// room carries polls, predictions and tips too, so it is held to the
+// stream's rules: viewers a private stream does not admit are told the
+// channel does not exist, as on the channel page, and subscriber-only
+// streams need a membership. Anonymous invite holders prove their invite
+// with the playback token they were given.
+func (svc *Service) OpenChatRoom(ctx context.Context, channel string, viewerID primitive.ObjectID, token string) (*models.User, error) {
+	user, err := svc.store.Users.FindByUsername(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	s, err := svc.store.Streams.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	ok := streamAllows(s, user.ID, viewerID)
+	if !ok && token != "" {
+		if claims, err := svc.verifyPlaybackToken(user.Username, token, playback.ResourceLive); err == nil && claims.Invite != "" {
+			if ok, err = svc.inviteStillValid(ctx, user.ID, claims.Invite); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	if err := svc.requireMembership(ctx, s != nil && s.SubscribersOnly, user.ID, viewerID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// JoinChat sends the recent history, chat modes and any running poll or
+// prediction to the client and adds it to the room.
+func (svc *Service) JoinChat(ctx context.Context, channel *models.User, c *chat.Client) error {
+	recent, err := svc.store.Chat.List(ctx, channel.Username, nil, chatHistorySize)
+	if err != nil {
+		return err
+	}
+	// Stored newest first; clients want chronological order.
+	for i, j := 0, len(recent)-1; i < j; i, j = i+1, j-1 {
+		recent[i], recent[j] = recent[j], recent[i]
+	}
+	c.Send(chat.Event{Type: chat.EventHistory, Messages: recent})
+	if rules, err := svc.loadChatRules(ctx, channel.ID); err == nil {
+		c.Send(chat.Event{Type: chat.EventSettings, Data: publicChatModes(&rules.settings)})
+	}
+	svc.sendInteractions(ctx, channel, c)
+	svc.chatHub.Join(channel.Username, c)
+	return nil
+}
+
+// LeaveChat removes the client from the room.
+func (svc *Service) LeaveChat(channel *models.User, c *chat.Client) {
+	svc.chatHub.Leave(channel.Username, c)
+}
+
+// HandleChatFrame processes one frame from a client. Problems are
+// reported back to that client only.
+func (svc *Service) HandleChatFrame(ctx context.Context, channel *models.User, c *chat.Client, in chat.Inbound) {
+	if in.Type != chat.EventMessage {
+		c.Send(chat.Event{Type: chat.EventError, Error: "unsupported frame type"})
+		return
+	}
+	if c.Anonymous() {
+		c.Send(chat.Event{Type: chat.EventError, Error: "log in to chat"})
+		return
+	}
+
+	text := strings.TrimSpace(in.Text)
+	if text == "" {
+		return
+	}
+	if utf8.RuneCountInString(text) > maxChatMessageLength {
+		c.Send(chat.Event{Type: chat.EventError, Error: fmt.Sprintf("messages are limited to %d characters", maxChatMessageLength)})
+		return
+	}
+	if !svc.chatLimiter.Allow(c.UserID.Hex()) {
+		c.Send(chat.Event{Type: chat.EventError, Error: "you are sending messages too fast"})
+		return
+	}
+
+	role, err := svc.channelRole(ctx, channel, c.UserID)
+	if err != nil {
+		log.Printf("chat: failed to resolve role in %s: %v", channel.Username, err)
+		c.Send(chat.Event{Type: chat.EventError, Error: "message could not be sent"})
+		return
+	}
+	// The broadcaster and moderators bypass bans and chat modes.
+	if role == "" {
+		reason, err := svc.checkChatRestrictions(ctx, channel, c.UserID, text)
+		if err != nil {
+			log.Printf("chat: failed to check restrictions in %s: %v", channel.Username, err)
+			c.Send(chat.Event{Type: chat.EventError, Error: "message could not be sent"})
+			return
+		}
+		if reason != "" {
+			c.Send(chat.Event{Type: chat.EventError, Error: reason})
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	badges := chatBadges(role)
+	if member, err := svc.store.Memberships.IsMember(ctx, channel.ID, c.UserID, now); err != nil {
+		log.Printf("chat: failed to check membership in %s: %v", channel.Username, err)
+	} else if member {
+		badges = append(badges, models.BadgeSubscriber)
+	}
+
+	msg := &models.ChatMessage{
+		Channel:     channel.Username,
+		ChannelID:   channel.ID,
+		UserID:      c.UserID,
+		Username:    c.Username,
+		DisplayName: c.DisplayName,
+		Text:        text,
+		Badges:      badges,
+		CreatedAt:   now,
+	}
+	if err := svc.store.Chat.Insert(ctx, msg); err != nil {
+		log.Printf("chat: failed to store message in %s: %v", channel.Username, err)
+		c.Send(chat.Event{Type: chat.EventError, Error: "message could not be sent"})
+		return
+	}
+	svc.countStat(ctx, channel, repo.StatChatMessages, msg.CreatedAt)
+	if err := svc.chatHub.Broadcast(ctx, channel.Username, chat.Event{Type: chat.EventMessage, Message: msg}); err != nil {
+		log.Printf("chat: failed to broadcast in %s: %v", channel.Username, err)
+	}
+	go svc.notifyMentions(*msg)
+}
+
+// ListChatMessages returns a page of a channel's chat history, newest
+// first, to viewers who may open its room.
+func (svc *Service) ListChatMessages(ctx context.Context, channel string, viewerID primitive.ObjectID, token, cursor string, limit int) (*ChatPage, error) {
+	after, err := decodeTimeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	room, err := svc.OpenChatRoom(ctx, channel, viewerID, token)
+	if err != nil {
+		return nil, err
+	}
+	limit = svc.clampLimit(limit)
+	msgs, err := svc.store.Chat.List(ctx, room.Username, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	page := &ChatPage{Messages: msgs}
+	if len(msgs) == limit {
+		last := msgs[len(msgs)-1]
+		page.NextCursor = encodeCursor(repo.TimeCursor{At: last.CreatedAt, ID: last.ID})
+	}
+	return page, nil
+}
+
+// chatBadges returns the badges shown for a sender with the given role.
+func chatBadges(role string) []string {
+	if role == "" {
+		return nil
+	}
+	return []string{role}
+}
+
+// notifyMentions sends a mention notification to every @username in the
+// message, skipping the author and unknown names.
+func (svc *Service) notifyMentions(msg models.ChatMessage) {
+	matches := mentionPattern.FindAllStringSubmatch(msg.Text, -1)
+	if len(matches) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	seen := make(map[string]bool)
+	for _, m := range matches {
+		name := m[1]
+		if seen[name] || name == msg.Username || len(seen) >= maxMentionsPerMessage {
+			continue
+		}
+		seen[name] = true
+
+		user, err := svc.store.Users.FindByUsername(ctx, name)
+		if err != nil || user == nil {
+			continue
+		}
+		err = svc.Notify(ctx, &models.Notification{
+			UserID:        user.ID,
+			Type:          models.NotificationMention,
+			ActorID:       msg.UserID,
+			ActorUsername: msg.Username,
+			Message:       fmt.Sprintf("%s mentioned you in %s's chat", msg.Username, msg.Channel),
+			Data:          map[string]string{"channel": msg.Channel, "message_id": msg.ID.Hex()},
+		})
+		if err != nil {
+			log.Printf("chat: failed to notify mention of %s: %v", name, err)
+		}
+	}
+}
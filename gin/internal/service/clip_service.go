@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/playback"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/vod"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	minClipSeconds     = 10
+	maxClipSeconds     = 60
+	defaultClipSeconds = 30
+	maxClipTitleLength = 100
+)
+
+var (
+	// ErrClipNotFound is returned for unknown clips and clip files.
+	ErrClipNotFound = errors.New("clip not found")
+	// ErrStreamOffline is returned when clipping a channel that is not live.
+	ErrStreamOffline = errors.New("channel is not live")
+	// ErrInvalidClip is wrapped by malformed clip requests.
+	ErrInvalidClip = errors.New("invalid clip")
+	// ErrClipRateLimited is returned when a user makes clips too quickly.
+	ErrClipRateLimited = errors.New("you are making clips too fast")
+)
+
+// ClipInput describes a clip to cut from a live channel.
+type ClipInput struct {
+	Channel         string
+	Title           string
+	DurationSeconds int // 0 means the default length
+}
+
+// ClipPage is one page of a channel's clips.
+type ClipPage struct {
+	Clips      []models.Clip `json:"clips"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// CreateClip snapshots the last seconds of a live channel into a clip
+// owned by the user.
+func (svc *Service) CreateClip(ctx context.Context, userID primitive.ObjectID, in ClipInput) (*models.Clip, error) {
+	seconds := in.DurationSeconds
+	if seconds == 0 {
+		seconds = defaultClipSeconds
+	}
+	if seconds < minClipSeconds || seconds > maxClipSeconds {
+		return nil, fmt.Errorf("%w: clips are %d-%d seconds long", ErrInvalidClip, minClipSeconds, maxClipSeconds)
+	}
+	title := strings.TrimSpace(in.Title)
+	if utf8.RuneCountInString(title) > maxClipTitleLength {
+		return nil, fmt.Errorf("%w: title is limited to %d characters", ErrInvalidClip, maxClipTitleLength)
+	}
+
+	creator, err := svc.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if creator == nil {
+		return nil, ErrUserNotFound
+	}
+	channel, err := svc.store.Users.FindByUsername(ctx, in.Channel)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, ErrUserNotFound
+	}
+	s, err := svc.store.Streams.FindByUserID(ctx, channel.ID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil || !s.Live {
+		return nil, ErrStreamOffline
+	}
+	video, err := svc.store.Videos.FindByBroadcastID(ctx, s.BroadcastID)
+	if err != nil {
+		return nil, err
+	}
+	if video == nil {
+		return nil, ErrStreamOffline
+	}
+	ok, err := svc.canWatch(ctx, channel, creator.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	if err := svc.requireMembership(ctx, s.SubscribersOnly, channel.ID, creator.ID); err != nil {
+		return nil, err
+	}
+	if !svc.clipLimiter.Allow(userID.Hex()) {
+		return nil, ErrClipRateLimited
+	}
+
+	if title == "" {
+		title = s.Title
+	}
+	if title == "" {
+		title = fmt.Sprintf("Clip of %s", channel.Username)
+	}
+	clip := &models.Clip{
+		ID:              primitive.NewObjectID(),
+		ChannelID:       channel.ID,
+		ChannelUsername: channel.Username,
+		CreatorID:       creator.ID,
+		CreatorUsername: creator.Username,
+		Title:           title,
+		BroadcastID:     s.BroadcastID,
+		VideoID:         video.ID,
+		StreamTitle:     s.Title,
+		Category:        s.Category,
+		Visibility:      video.Visibility,
+		SubscribersOnly: video.SubscribersOnly,
+		CreatedAt:       time.Now().UTC(),
+	}
+
+	sum, err := svc.archiver.Clip(channel.Username, clip.ID.Hex(), float64(seconds))
+	if errors.Is(err, vod.ErrNotRecording) {
+		return nil, ErrStreamOffline
+	}
+	if err != nil {
+		return nil, err
+	}
+	clip.DurationSeconds = sum.Duration
+	clip.OffsetSeconds = sum.Offset
+
+	if err := svc.store.Clips.Create(ctx, clip); err != nil {
+		_ = svc.archiver.RemoveClip(clip.ID.Hex())
+		return nil, err
+	}
+	return clip, nil
+}
+
+// GetClip returns a clip's metadata. Private clips look missing to
+// viewers who may not see them.
+func (svc *Service) GetClip(ctx context.Context, id, viewerID primitive.ObjectID) (*models.Clip, error) {
+	c, err := svc.findClip(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Private() {
+		ok, err := svc.canSeePrivate(ctx, c.ChannelID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrClipNotFound
+		}
+	}
+	return c, nil
+}
+
+func (svc *Service) findClip(ctx context.Context, id primitive.ObjectID) (*models.Clip, error) {
+	c, err := svc.store.Clips.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrClipNotFound
+	}
+	return c, nil
+}
+
+// ClipFilePath resolves a playlist or segment of a clip to a path on disk.
+// Fetching the playlist counts as a view. Private clips need a playback
+// token for the channel; playlistToken is then the token the playlist's
+// URIs must carry, which is good for this clip alone.
+func (svc *Service) ClipFilePath(ctx context.Context, id primitive.ObjectID, file, token string) (path, playlistToken string, err error) {
+	path = svc.archiver.ClipPath(id.Hex(), file)
+	if path == "" {
+		return "", "", ErrClipNotFound
+	}
+	c, err := svc.findClip(ctx, id)
+	if err != nil {
+		return "", "", err
+	}
+	if access := (recordingAccess{private: c.Private(), subscribersOnly: c.SubscribersOnly}); access.restricted() {
+		ttl := recordingTokenTTL(c.DurationSeconds, false)
+		if playlistToken, err = svc.authorizeRecordingFile(ctx, c.ChannelID, c.ChannelUsername, playback.ClipResource(id.Hex()), file, token, access, ttl); err != nil {
+			return "", "", err
+		}
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return "", "", ErrClipNotFound
+		}
+		return "", "", err
+	}
+	if file == vod.PlaylistName {
+		if err := svc.store.Clips.IncViews(ctx, id); err != nil {
+			log.Printf("clips: failed to count view of %s: %v", id.Hex(), err)
+		}
+	}
+	return path, playlistToken, nil
+}
+
+// ListClips returns a page of a channel's clips, newest first, as the
+// viewer may see them (see ListVideos).
+func (svc *Service) ListClips(ctx context.Context, username string, viewerID primitive.ObjectID, cursor string, limit int) (*ClipPage, error) {
+	channel, err := svc.store.Users.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, ErrUserNotFound
+	}
+	after, err := decodeTimeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	hidden, err := svc.hiddenVisibilities(ctx, channel, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	limit = svc.clampLimit(limit)
+	clips, err := svc.store.Clips.ListByChannel(ctx, channel.ID, hidden, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	page := &ClipPage{Clips: clips}
+	if len(clips) == limit {
+		last := clips[len(clips)-1]
+		page.NextCursor = encodeCursor(repo.TimeCursor{At: last.CreatedAt, ID: last.ID})
+	}
+	return page, nil
+}
+
+// DeleteClip removes a clip. Its creator, the channel's broadcaster and
+// the channel's moderators may do so.
+func (svc *Service) DeleteClip(ctx context.Context, userID, id primitive.ObjectID) error {
+	c, err := svc.findClip(ctx, id)
+	if err != nil {
+		return err
+	}
+	if c.CreatorID != userID {
+		channel, err := svc.store.Users.FindByID(ctx, c.ChannelID)
+		if err != nil {
+			return err
+		}
+		if channel == nil {
+			return ErrForbidden
+		}
+		role, err := svc.channelRole(ctx, channel, userID)
+		if err != nil {
+			return err
+		}
+		if role == "" {
+			return ErrForbidden
+		}
+	}
+	if err := svc.store.Clips.Delete(ctx, id); err != nil {
+		return err
+	}
+	if err := svc.archiver.RemoveClip(id.Hex()); err != nil {
+		log.Printf("clips: failed to remove files of %s: %v", id.Hex(), err)
+	}
+	return nil
+}
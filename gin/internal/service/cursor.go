@@ -0,0 +1,41 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+const defaultPageLimit = 20
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursors are opaque to clients: base64url-encoded JSON of the last item's sort keys.
+func encodeCursor(v any) string {
+	b, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor fills v from s. It reports false if s is empty.
+func decodeCursor(s string, v any) (bool, error) {
+	if s == "" {
+		return false, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return false, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return false, ErrInvalidCursor
+	}
+	return true, nil
+}
+
+// clampLimit applies the default and maximum page size.
+func (svc *Service) clampLimit(n int) int {
+	if n <= 0 {
+		n = defaultPageLimit
+	}
+	return min(n, svc.cfg.Limits.MaxPageSize)
+}
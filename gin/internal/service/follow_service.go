@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// feedFollowLimit caps how many followed channels the feed considers.
+	feedFollowLimit = 1000
+	// feedRecentWindow is how far back "recently ended" broadcasts go.
+	feedRecentWindow = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrUserNotFound is returned when a username does not exist.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrCannotFollowSelf is returned when a user tries to follow themselves.
+	ErrCannotFollowSelf = errors.New("you cannot follow yourself")
+)
+
+// FollowPage is one page of a followers or following list.
+type FollowPage struct {
+	Users      []repo.FollowEntry `json:"users"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// FollowingFeed lists followed channels that are live now, then the ones
+// whose broadcast ended recently.
+type FollowingFeed struct {
+	Live   []models.Stream `json:"live"`
+	Recent []models.Stream `json:"recent"`
+}
+
+// GetProfile returns the public profile of a username.
+func (svc *Service) GetProfile(ctx context.Context, username string) (*models.PublicProfile, error) {
+	user, err := svc.store.Users.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	p := user.Public()
+	return &p, nil
+}
+
+// Follow makes userID follow the channel. Following twice is a no-op.
+// It reports whether a new edge was created.
+func (svc *Service) Follow(ctx context.Context, userID primitive.ObjectID, channel string) (bool, error) {
+	target, err := svc.resolveChannel(ctx, userID, channel)
+	if err != nil {
+		return false, err
+	}
+
+	f := &models.Follow{FollowerID: userID, ChannelID: target.ID, CreatedAt: time.Now().UTC()}
+	if err := svc.store.Follows.Create(ctx, f); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	// The unique index guarantees only one request gets here per edge,
+	// and the counters move in the same transaction as the edge.
+	svc.notifyNewFollower(ctx, userID, target.ID)
+	svc.countStat(ctx, target, repo.StatNewFollowers, f.CreatedAt)
+	return true, nil
+}
+
+// Unfollow removes the edge if it exists and reports whether it did.
+func (svc *Service) Unfollow(ctx context.Context, userID primitive.ObjectID, channel string) (bool, error) {
+	target, err := svc.resolveChannel(ctx, userID, channel)
+	if err != nil {
+		return false, err
+	}
+
+	return svc.store.Follows.Delete(ctx, userID, target.ID)
+}
+
+// IsFollowing reports whether userID follows the channel.
+func (svc *Service) IsFollowing(ctx context.Context, userID primitive.ObjectID, channel string) (bool, error) {
+	target, err := svc.store.Users.FindByUsername(ctx, channel)
+	if err != nil {
+		return false, err
+	}
+	if target == nil {
+		return false, ErrUserNotFound
+	}
+	return svc.store.Follows.IsFollowing(ctx, userID, target.ID)
+}
+
+// ListFollowers returns a page of the users following the channel.
+func (svc *Service) ListFollowers(ctx context.Context, channel, cursor string, limit int) (*FollowPage, error) {
+	return svc.listFollowPage(ctx, channel, cursor, limit, svc.store.Follows.ListFollowers)
+}
+
+// ListFollowing returns a page of the channels the user follows.
+func (svc *Service) ListFollowing(ctx context.Context, username, cursor string, limit int) (*FollowPage, error) {
+	return svc.listFollowPage(ctx, username, cursor, limit, svc.store.Follows.ListFollowing)
+}
+
+func (svc *Service) listFollowPage(
+	ctx context.Context, username, cursor string, limit int,
+	list func(context.Context, primitive.ObjectID, *repo.TimeCursor, int) ([]repo.FollowEntry, error),
+) (*FollowPage, error) {
+	user, err := svc.store.Users.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	after, err := decodeTimeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	limit = svc.clampLimit(limit)
+	entries, err := list(ctx, user.ID, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	page := &FollowPage{Users: entries}
+	if len(entries) == limit {
+		last := entries[len(entries)-1]
+		page.NextCursor = encodeCursor(repo.TimeCursor{At: last.FollowedAt, ID: last.FollowID})
+	}
+	return page, nil
+}
+
+// GetFollowingFeed builds the personalised "following" feed for userID.
+func (svc *Service) GetFollowingFeed(ctx context.Context, userID primitive.ObjectID, limit int) (*FollowingFeed, error) {
+	limit = svc.clampLimit(limit)
+	ids, err := svc.store.Follows.FollowedChannelIDs(ctx, userID, feedFollowLimit)
+	if err != nil {
+		return nil, err
+	}
+	feed := &FollowingFeed{Live: []models.Stream{}, Recent: []models.Stream{}}
+	if len(ids) == 0 {
+		return feed, nil
+	}
+
+	if feed.Live, err = svc.store.Streams.ListLiveByUserIDs(ctx, ids, limit); err != nil {
+		return nil, err
+	}
+	since := time.Now().UTC().Add(-feedRecentWindow)
+	if feed.Recent, err = svc.store.Streams.ListEndedByUserIDs(ctx, ids, since, limit); err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// resolveChannel looks up the target of a follow/unfollow and rejects self-follows.
+func (svc *Service) resolveChannel(ctx context.Context, userID primitive.ObjectID, channel string) (*models.User, error) {
+	target, err := svc.store.Users.FindByUsername(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, ErrUserNotFound
+	}
+	if target.ID == userID {
+		return nil, ErrCannotFollowSelf
+	}
+	return target, nil
+}
+
+func decodeTimeCursor(s string) (*repo.TimeCursor, error) {
+	var c repo.TimeCursor
+	ok, err := decodeCursor(s, &c)
+	if err != nil || !ok {
+		return nil, err
+	}
+	if c.ID.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
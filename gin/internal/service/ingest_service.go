@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/hls"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/ingest"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/rtmp"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// ingestApp is the RTMP application broadcasters publish to, as in
+	// rtmp://host/live with the stream key as stream name.
+	ingestApp = "live"
+	// hlsFragment and hlsPlaylistLength match hls_fragment and
+	// hls_playlist_length of the nginx-rtmp setup.
+	hlsFragment       = 3 * time.Second
+	hlsPlaylistLength = 10 * time.Second
+	ingestHookTimeout = 10 * time.Second
+	maxTakedownReason = 500
+)
+
+var (
+	// ErrInvalidStreamKey refuses publishes with unknown stream keys.
+	ErrInvalidStreamKey = errors.New("invalid stream key")
+	// ErrStreamSuspended refuses publishes of users under a takedown.
+	ErrStreamSuspended = errors.New("streaming is suspended for this account")
+	// ErrInvalidTakedown is wrapped by malformed takedown requests.
+	ErrInvalidTakedown = errors.New("invalid takedown")
+	// ErrIngestUnreachable is wrapped when the ingest server could not be
+	// told to drop a publisher, which may then still be broadcasting.
+	ErrIngestUnreachable = errors.New("ingest server unreachable")
+)
+
+// TakedownInput describes an admin takedown. A positive SuspendSeconds
+// also keeps the channel from going live again for that long.
+type TakedownInput struct {
+	Reason         string
+	SuspendSeconds int
+}
+
+// NewIngestServer returns the built-in RTMP ingest, which runs in place of
+// nginx-rtmp: publishes are authenticated with StartPublish and remuxed
+// to HLS in the live HLS directory with the layout nginx-rtmp writes, and
+// EndPublish runs when they stop. Only loopback clients, such as restream
+// relays, and those in ingest.play_from may play streams back.
+//
+// Stream key rotations and takedowns drop publishers through the returned
+// server from then on, so call it before serving HTTP.
+func (svc *Service) NewIngestServer() *rtmp.Server {
+	svc.rtmpServer = &rtmp.Server{
+		Handler: ingestHooks{svc},
+		NewSink: func(name string) (rtmp.Sink, error) {
+			return hls.NewSegmenter(svc.liveHLSDir(), name, hlsFragment, hlsPlaylistLength)
+		},
+		AllowPlay: func(addr net.Addr, app, _ string) bool {
+			tcp, ok := addr.(*net.TCPAddr)
+			return ok && app == ingestApp && svc.cfg.AllowPlay(tcp.AddrPort().Addr())
+		},
+	}
+	return svc.rtmpServer
+}
+
+// ingestHooks ties the RTMP server's publish lifecycle to the streams.
+type ingestHooks struct {
+	svc *Service
+}
+
+func (h ingestHooks) Publish(ctx context.Context, app, key string) (string, error) {
+	if app != ingestApp {
+		return "", ErrInvalidStreamKey
+	}
+	s, err := h.svc.StartPublish(ctx, key)
+	if err != nil {
+		if !errors.Is(err, ErrStreamSuspended) {
+			log.Printf("ingest: starting publish: %v", err)
+		}
+		return "", err
+	}
+	if s == nil {
+		return "", ErrInvalidStreamKey
+	}
+	return s.Username, nil
+}
+
+func (h ingestHooks) PublishDone(name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), ingestHookTimeout)
+	defer cancel()
+	if _, err := h.svc.EndPublish(ctx, name); err != nil {
+		log.Printf("ingest: ending publish of %s: %v", name, err)
+	}
+}
+
+// ingestControl returns the control client of the ingest in use: the
+// built-in server when it runs, otherwise nginx-rtmp's control module at
+// the configured control URL.
+func (svc *Service) ingestControl() ingest.Controller {
+	if svc.rtmpServer != nil {
+		return ingest.Local{Server: svc.rtmpServer}
+	}
+	return svc.nginxControl
+}
+
+// DropPublisher cuts off the user's broadcast if they are live. The
+// ingest server reports the end through the usual publish_done path; if
+// it no longer has the publisher, the stream is ended here so it does not
+// stay live. If it cannot be reached the stream stays live, as it may
+// well be, and the error wraps ErrIngestUnreachable.
+func (svc *Service) DropPublisher(ctx context.Context, userID primitive.ObjectID) error {
+	s, err := svc.store.Streams.FindByUserID(ctx, userID)
+	if err != nil || s == nil || !s.Live {
+		return err
+	}
+	dropped, err := svc.ingestControl().DropPublisher(ctx, ingestApp, s.Username)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrIngestUnreachable, err)
+	}
+	if !dropped {
+		_, err = svc.EndPublish(ctx, s.Username)
+		return err
+	}
+	return nil
+}
+
+// EndStream disconnects the user's encoder from the dashboard. It keeps
+// the stream key, so the encoder may reconnect.
+func (svc *Service) EndStream(ctx context.Context, userID primitive.ObjectID) error {
+	s, err := svc.store.Streams.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if s == nil || !s.Live {
+		return ErrStreamOffline
+	}
+	return svc.DropPublisher(ctx, userID)
+}
+
+// TakeDownStream ends a channel's broadcast on behalf of an admin and
+// optionally suspends it from going live. The owner is notified.
+func (svc *Service) TakeDownStream(ctx context.Context, adminID primitive.ObjectID, username string, in TakedownInput) (*models.User, error) {
+	reason := strings.TrimSpace(in.Reason)
+	if len(reason) > maxTakedownReason {
+		return nil, fmt.Errorf("%w: reason is limited to %d characters", ErrInvalidTakedown, maxTakedownReason)
+	}
+	if in.SuspendSeconds < 0 {
+		return nil, fmt.Errorf("%w: suspend_seconds must not be negative", ErrInvalidTakedown)
+	}
+	user, err := svc.store.Users.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	msg := "Your broadcast was taken down by an admin"
+	if in.SuspendSeconds > 0 {
+		until := time.Now().UTC().Add(time.Duration(in.SuspendSeconds) * time.Second)
+		if err := svc.store.Users.SetStreamSuspension(ctx, user.ID, &until, reason); err != nil {
+			return nil, err
+		}
+		user.StreamSuspendedUntil, user.StreamSuspendReason = &until, reason
+		msg = fmt.Sprintf("Your channel was suspended from streaming until %s", until.Format(time.RFC1123))
+	}
+	if reason != "" {
+		msg += ": " + reason
+	}
+	// A channel taken down does not get to hand its viewers on.
+	if _, err := svc.store.Raids.CancelPending(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	if err := svc.DropPublisher(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	log.Printf("ingest: %s took down %s (suspended %ds): %s", adminID.Hex(), user.Username, in.SuspendSeconds, reason)
+
+	err = svc.Notify(ctx, &models.Notification{
+		UserID:  user.ID,
+		Type:    models.NotificationModeration,
+		Message: msg,
+		Data:    map[string]string{"action": "stream_takedown"},
+	})
+	if err != nil {
+		log.Printf("ingest: failed to notify %s of takedown: %v", user.Username, err)
+	}
+	return user, nil
+}
+
+// LiftStreamSuspension lets a suspended channel go live again.
+func (svc *Service) LiftStreamSuspension(ctx context.Context, username string) error {
+	user, err := svc.store.Users.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	return svc.store.Users.SetStreamSuspension(ctx, user.ID, nil, "")
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/chat"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxTransferAmount   = 1_000_000
+	maxLedgerMemo       = 200
+	maxTipMessageLength = 200
+	maxIdempotencyKey   = 100
+)
+
+var (
+	// ErrInsufficientFunds is returned when the payer cannot afford a transfer.
+	ErrInsufficientFunds = repo.ErrInsufficientFunds
+	// ErrInvalidTransfer is wrapped by malformed grants, adjustments and tips.
+	ErrInvalidTransfer = errors.New("invalid transfer")
+	// ErrIdempotencyConflict is returned when an idempotency key is reused
+	// for a different transfer.
+	ErrIdempotencyConflict = errors.New("idempotency key was used for a different transfer")
+)
+
+// Wallet is a user's honey balance.
+type Wallet struct {
+	Account  string `json:"account"`
+	Currency string `json:"currency"`
+	Balance  int64  `json:"balance"`
+}
+
+// LedgerPage is one page of an account's statement.
+type LedgerPage struct {
+	Entries    []models.LedgerEntry `json:"entries"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+// TipInput is a tip to a channel.
+type TipInput struct {
+	Amount         int64
+	Message        string
+	IdempotencyKey string
+}
+
+// TipResult is the recorded tip. Replayed is set when the idempotency key
+// matched an earlier tip, which is returned instead of tipping again.
+type TipResult struct {
+	Transfer *models.LedgerTransfer `json:"transfer"`
+	Balance  int64                  `json:"balance"`
+	Replayed bool                   `json:"replayed"`
+}
+
+// GetWallet returns the user's balance.
+func (svc *Service) GetWallet(ctx context.Context, userID primitive.ObjectID) (*Wallet, error) {
+	account := models.UserAccountID(userID)
+	balance, err := svc.store.Ledger.Balance(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	return &Wallet{Account: account, Currency: models.Currency, Balance: balance}, nil
+}
+
+// ListLedgerEntries returns a page of the user's statement, newest first.
+func (svc *Service) ListLedgerEntries(ctx context.Context, userID primitive.ObjectID, cursor string, limit int) (*LedgerPage, error) {
+	after, err := decodeTimeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit = svc.clampLimit(limit)
+	entries, err := svc.store.Ledger.ListEntries(ctx, models.UserAccountID(userID), after, limit)
+	if err != nil {
+		return nil, err
+	}
+	page := &LedgerPage{Entries: entries}
+	if len(entries) == limit {
+		last := entries[len(entries)-1]
+		page.NextCursor = encodeCursor(repo.TimeCursor{At: last.CreatedAt, ID: last.ID})
+	}
+	return page, nil
+}
+
+// GrantHoney issues amount honey to a user on behalf of an admin.
+func (svc *Service) GrantHoney(ctx context.Context, adminID primitive.ObjectID, username string, amount int64, memo, key string) (*models.LedgerTransfer, error) {
+	if amount <= 0 || amount > maxTransferAmount {
+		return nil, fmt.Errorf("%w: amount must be between 1 and %d", ErrInvalidTransfer, maxTransferAmount)
+	}
+	user, err := svc.findLedgerUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	t, _, err := svc.transfer(ctx, adminID, key, models.LedgerTransfer{
+		Kind:   models.TransferGrant,
+		From:   models.SystemIssuance,
+		To:     models.UserAccountID(user.ID),
+		Amount: amount,
+		Memo:   memo,
+	})
+	return t, err
+}
+
+// AdjustHoney corrects a user's balance on behalf of an admin: a positive
+// amount credits the user, a negative one debits them back into issuance.
+// A debit cannot take the balance below zero. The memo is required.
+func (svc *Service) AdjustHoney(ctx context.Context, adminID primitive.ObjectID, username string, amount int64, memo, key string) (*models.LedgerTransfer, error) {
+	if amount == 0 || amount > maxTransferAmount || amount < -maxTransferAmount {
+		return nil, fmt.Errorf("%w: amount must be nonzero and at most %d either way", ErrInvalidTransfer, maxTransferAmount)
+	}
+	if strings.TrimSpace(memo) == "" {
+		return nil, fmt.Errorf("%w: adjustments need a memo", ErrInvalidTransfer)
+	}
+	user, err := svc.findLedgerUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	t := models.LedgerTransfer{Kind: models.TransferAdjustment, From: models.SystemIssuance, To: models.UserAccountID(user.ID), Amount: amount, Memo: memo}
+	if amount < 0 {
+		t.From, t.To, t.Amount = t.To, t.From, -amount
+	}
+	out, _, err := svc.transfer(ctx, adminID, key, t)
+	return out, err
+}
+
+// Tip moves honey from the user to a channel. A message, if any, is posted
+// in the channel's chat and shown with the on-stream alert. Users banned
+// or timed out in the chat cannot tip it.
+func (svc *Service) Tip(ctx context.Context, userID primitive.ObjectID, channelName string, in TipInput) (*TipResult, error) {
+	if in.Amount <= 0 || in.Amount > maxTransferAmount {
+		return nil, fmt.Errorf("%w: amount must be between 1 and %d", ErrInvalidTransfer, maxTransferAmount)
+	}
+	message := strings.TrimSpace(in.Message)
+	if utf8.RuneCountInString(message) > maxTipMessageLength {
+		return nil, fmt.Errorf("%w: message is limited to %d characters", ErrInvalidTransfer, maxTipMessageLength)
+	}
+	channel, err := svc.findLedgerUser(ctx, channelName)
+	if err != nil {
+		return nil, err
+	}
+	if channel.ID == userID {
+		return nil, fmt.Errorf("%w: you cannot tip yourself", ErrInvalidTransfer)
+	}
+	ban, err := svc.store.Moderation.FindActiveBan(ctx, channel.ID, userID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if ban != nil {
+		return nil, ErrForbidden
+	}
+	tipper, err := svc.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tipper == nil {
+		return nil, ErrUserNotFound
+	}
+
+	t, replayed, err := svc.transfer(ctx, userID, in.IdempotencyKey, models.LedgerTransfer{
+		Kind:   models.TransferTip,
+		From:   models.UserAccountID(userID),
+		To:     models.UserAccountID(channel.ID),
+		Amount: in.Amount,
+		Memo:   message,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !replayed {
+		svc.announceTip(ctx, tipper, channel, t)
+	}
+	balance, err := svc.store.Ledger.Balance(ctx, t.From)
+	if err != nil {
+		return nil, err
+	}
+	return &TipResult{Transfer: t, Balance: balance, Replayed: replayed}, nil
+}
+
+// transfer records t for actor. Idempotency keys are scoped to the actor;
+// without one the transfer is never deduplicated. It reports replayed when
+// the key matched an earlier, identical transfer.
+func (svc *Service) transfer(ctx context.Context, actor primitive.ObjectID, key string, t models.LedgerTransfer) (*models.LedgerTransfer, bool, error) {
+	if err := prepareTransfer(actor, key, &t); err != nil {
+		return nil, false, err
+	}
+	existing, err := svc.store.Ledger.CreateTransfer(ctx, &t)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing == nil {
+		return &t, false, nil
+	}
+	if existing.Kind != t.Kind || existing.From != t.From || existing.To != t.To || existing.Amount != t.Amount {
+		return nil, false, ErrIdempotencyConflict
+	}
+	return existing, true, nil
+}
+
+// prepareTransfer checks t's memo and fills in its idempotency key,
+// initiator and time.
+func prepareTransfer(actor primitive.ObjectID, key string, t *models.LedgerTransfer) error {
+	if len(key) > maxIdempotencyKey {
+		return fmt.Errorf("%w: idempotency key is limited to %d characters", ErrInvalidTransfer, maxIdempotencyKey)
+	}
+	t.Memo = strings.TrimSpace(t.Memo)
+	if utf8.RuneCountInString(t.Memo) > maxLedgerMemo {
+		return fmt.Errorf("%w: memo is limited to %d characters", ErrInvalidTransfer, maxLedgerMemo)
+	}
+	if key == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+		key = "auto:" + hex.EncodeToString(b)
+	}
+	t.IdempotencyKey = actor.Hex() + ":" + key
+	t.CreatedBy = actor
+	t.CreatedAt = time.Now().UTC()
+	return nil
+}
+
+// announceTip posts the tip in the channel's chat, raises the on-stream
+// alert and notifies the streamer. Failures are only logged: the honey has
+// moved either way.
+func (svc *Service) announceTip(ctx context.Context, tipper, channel *models.User, t *models.LedgerTransfer) {
+	msg := &models.ChatMessage{
+		Channel:     channel.Username,
+		ChannelID:   channel.ID,
+		UserID:      tipper.ID,
+		Username:    tipper.Username,
+		DisplayName: tipper.DisplayName,
+		Text:        t.Memo,
+		Tip:         t.Amount,
+		CreatedAt:   t.CreatedAt,
+	}
+	if err := svc.store.Chat.Insert(ctx, msg); err != nil {
+		log.Printf("ledger: failed to store tip message in %s: %v", channel.Username, err)
+	} else {
+		svc.countStat(ctx, channel, repo.StatChatMessages, msg.CreatedAt)
+		if err := svc.chatHub.Broadcast(ctx, channel.Username, chat.Event{Type: chat.EventMessage, Message: msg}); err != nil {
+			log.Printf("ledger: failed to broadcast tip in %s: %v", channel.Username, err)
+		}
+	}
+
+	alert := chat.Event{Type: chat.EventAlert, Data: map[string]interface{}{
+		"kind":         models.TransferTip,
+		"transfer_id":  t.ID.Hex(),
+		"username":     tipper.Username,
+		"display_name": tipper.DisplayName,
+		"amount":       t.Amount,
+		"currency":     models.Currency,
+		"message":      t.Memo,
+	}}
+	if err := svc.chatHub.Broadcast(ctx, channel.Username, alert); err != nil {
+		log.Printf("ledger: failed to send tip alert in %s: %v", channel.Username, err)
+	}
+
+	err := svc.Notify(ctx, &models.Notification{
+		UserID:        channel.ID,
+		Type:          models.NotificationTip,
+		ActorID:       tipper.ID,
+		ActorUsername: tipper.Username,
+		Message:       fmt.Sprintf("%s tipped you %d %s", tipper.Username, t.Amount, models.Currency),
+		Data:          map[string]string{"transfer_id": t.ID.Hex(), "amount": fmt.Sprint(t.Amount)},
+	})
+	if err != nil {
+		log.Printf("ledger: failed to notify %s of tip: %v", channel.Username, err)
+	}
+}
+
+func (svc *Service) findLedgerUser(ctx context.Context, username string) (*models.User, error) {
+	user, err := svc.store.Users.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
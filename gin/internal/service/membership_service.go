@@ -0,0 +1,536 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/chat"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxMembershipTiers      = 5
+	maxTierNameLength       = 40
+	maxTierPerks            = 10
+	maxTierPerkLength       = 100
+	defaultTierDurationDays = 30
+	maxTierDurationDays     = 365
+	// membershipRenewInterval is how often due memberships are renewed or
+	// expired; a membership may outlast its period by up to this long.
+	membershipRenewInterval = time.Minute
+	membershipRenewBatch    = 100
+)
+
+var (
+	// ErrTierNotFound is returned for unknown or archived tiers.
+	ErrTierNotFound = errors.New("membership tier not found")
+	// ErrInvalidTier is wrapped by malformed tier changes.
+	ErrInvalidTier = errors.New("invalid membership tier")
+	// ErrMembershipNotFound is returned when the user has no membership to change.
+	ErrMembershipNotFound = errors.New("membership not found")
+	// ErrAlreadyMember is returned when subscribing to another tier while a
+	// membership is still running.
+	ErrAlreadyMember = errors.New("already a member on another tier; cancel and subscribe again once it ends")
+	// ErrSubscriptionRequired is returned when playing subscriber-only
+	// content without an active membership.
+	ErrSubscriptionRequired = errors.New("an active membership of the channel is required")
+)
+
+// TierInput describes a new tier. DurationDays 0 means 30.
+type TierInput struct {
+	Name         string
+	Perks        []string
+	Price        int64
+	DurationDays int
+}
+
+// TierUpdate changes the non-nil fields of a tier. Price and duration
+// changes apply to members from their next renewal.
+type TierUpdate struct {
+	Name         *string
+	Perks        *[]string
+	Price        *int64
+	DurationDays *int
+}
+
+// MemberPage is one page of a channel's active members.
+type MemberPage struct {
+	Members    []models.Membership `json:"members"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+// ListMembershipTiers returns the tiers a channel offers, cheapest first.
+func (svc *Service) ListMembershipTiers(ctx context.Context, username string) ([]models.MembershipTier, error) {
+	channel, err := svc.findLedgerUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return svc.store.Memberships.ListTiers(ctx, channel.ID, false)
+}
+
+// ListOwnMembershipTiers returns the user's tiers, archived ones included.
+func (svc *Service) ListOwnMembershipTiers(ctx context.Context, userID primitive.ObjectID) ([]models.MembershipTier, error) {
+	return svc.store.Memberships.ListTiers(ctx, userID, true)
+}
+
+// CreateMembershipTier adds a tier to the user's channel.
+func (svc *Service) CreateMembershipTier(ctx context.Context, userID primitive.ObjectID, in TierInput) (*models.MembershipTier, error) {
+	if in.DurationDays == 0 {
+		in.DurationDays = defaultTierDurationDays
+	}
+	name, err := cleanTierName(in.Name)
+	if err != nil {
+		return nil, err
+	}
+	perks, err := cleanTierPerks(in.Perks)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTierTerms(in.Price, in.DurationDays); err != nil {
+		return nil, err
+	}
+	n, err := svc.store.Memberships.CountTiers(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if n >= maxMembershipTiers {
+		return nil, fmt.Errorf("%w: at most %d tiers", ErrInvalidTier, maxMembershipTiers)
+	}
+
+	now := time.Now().UTC()
+	t := &models.MembershipTier{
+		ChannelID:    userID,
+		Name:         name,
+		Perks:        perks,
+		Price:        in.Price,
+		DurationDays: in.DurationDays,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := svc.store.Memberships.CreateTier(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// UpdateMembershipTier edits one of the user's tiers.
+func (svc *Service) UpdateMembershipTier(ctx context.Context, userID, id primitive.ObjectID, in TierUpdate) (*models.MembershipTier, error) {
+	var u repo.MembershipTierUpdate
+	if in.Name != nil {
+		name, err := cleanTierName(*in.Name)
+		if err != nil {
+			return nil, err
+		}
+		u.Name = &name
+	}
+	if in.Perks != nil {
+		perks, err := cleanTierPerks(*in.Perks)
+		if err != nil {
+			return nil, err
+		}
+		u.Perks = &perks
+	}
+	if in.Price != nil {
+		if err := checkTierTerms(*in.Price, 1); err != nil {
+			return nil, err
+		}
+		u.Price = in.Price
+	}
+	if in.DurationDays != nil {
+		if err := checkTierTerms(1, *in.DurationDays); err != nil {
+			return nil, err
+		}
+		u.DurationDays = in.DurationDays
+	}
+	t, err := svc.store.Memberships.UpdateTier(ctx, userID, id, u)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrTierNotFound
+	}
+	return t, nil
+}
+
+// ArchiveMembershipTier stops offering one of the user's tiers. Its
+// members keep their perks until their period ends; it is not renewed.
+func (svc *Service) ArchiveMembershipTier(ctx context.Context, userID, id primitive.ObjectID) error {
+	archived := true
+	t, err := svc.store.Memberships.UpdateTier(ctx, userID, id, repo.MembershipTierUpdate{Archived: &archived})
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return ErrTierNotFound
+	}
+	return nil
+}
+
+// Subscribe makes the user a member of the channel on the tier, paying
+// its price for the first period; later periods renew automatically until
+// cancelled. Subscribing again to the tier of a running membership only
+// turns renewal back on. created reports whether a new period was paid.
+func (svc *Service) Subscribe(ctx context.Context, userID primitive.ObjectID, channelName string, tierID primitive.ObjectID) (m *models.Membership, created bool, err error) {
+	channel, err := svc.findLedgerUser(ctx, channelName)
+	if err != nil {
+		return nil, false, err
+	}
+	if channel.ID == userID {
+		return nil, false, fmt.Errorf("%w: you cannot subscribe to your own channel", ErrInvalidTier)
+	}
+	tier, err := svc.store.Memberships.FindTier(ctx, tierID)
+	if err != nil {
+		return nil, false, err
+	}
+	if tier == nil || tier.ChannelID != channel.ID || tier.Archived {
+		return nil, false, ErrTierNotFound
+	}
+	user, err := svc.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if user == nil {
+		return nil, false, ErrUserNotFound
+	}
+
+	now := time.Now().UTC()
+	existing, err := svc.store.Memberships.Find(ctx, channel.ID, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil && existing.Active(now) {
+		if existing.TierID != tier.ID {
+			return nil, false, ErrAlreadyMember
+		}
+		if existing.AutoRenew {
+			return existing, false, nil
+		}
+		m, err := svc.store.Memberships.SetAutoRenew(ctx, existing.ID, true, now)
+		return m, false, err
+	}
+
+	m = &models.Membership{
+		ChannelID:       channel.ID,
+		ChannelUsername: channel.Username,
+		UserID:          userID,
+		Username:        user.Username,
+		TierID:          tier.ID,
+		TierName:        tier.Name,
+		Status:          models.MembershipActive,
+		AutoRenew:       true,
+		StartedAt:       now,
+		ExpiresAt:       now.Add(tierPeriod(tier)),
+		UpdatedAt:       now,
+	}
+	ok, err := svc.store.Memberships.Start(ctx, m)
+	if err != nil {
+		return nil, false, err
+	}
+	if !ok {
+		return nil, false, ErrAlreadyMember // lost a race with another request
+	}
+	if _, _, err := svc.chargeMembership(ctx, m, tier, m.StartedAt); err != nil {
+		// Not paid: take the membership back.
+		if _, xerr := svc.store.Memberships.Expire(ctx, m.ID, m.ExpiresAt); xerr != nil {
+			log.Printf("membership: failed to revoke unpaid membership %s: %v", m.ID.Hex(), xerr)
+		}
+		return nil, false, err
+	}
+	svc.announceMembership(ctx, user, channel, m)
+	return m, true, nil
+}
+
+// CancelMembership turns off renewal of the user's membership of the
+// channel. It stays active until the end of the paid period.
+func (svc *Service) CancelMembership(ctx context.Context, userID primitive.ObjectID, channelName string) (*models.Membership, error) {
+	m, err := svc.GetMembership(ctx, userID, channelName)
+	if err != nil {
+		return nil, err
+	}
+	if !m.Active(time.Now()) {
+		return nil, ErrMembershipNotFound
+	}
+	if !m.AutoRenew {
+		return m, nil
+	}
+	return svc.store.Memberships.SetAutoRenew(ctx, m.ID, false, time.Now().UTC())
+}
+
+// GetMembership returns the user's membership of the channel, which may
+// have expired.
+func (svc *Service) GetMembership(ctx context.Context, userID primitive.ObjectID, channelName string) (*models.Membership, error) {
+	channel, err := svc.findLedgerUser(ctx, channelName)
+	if err != nil {
+		return nil, err
+	}
+	m, err := svc.store.Memberships.Find(ctx, channel.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrMembershipNotFound
+	}
+	return m, nil
+}
+
+// ListMyMemberships returns the user's memberships, active ones first.
+func (svc *Service) ListMyMemberships(ctx context.Context, userID primitive.ObjectID) ([]models.Membership, error) {
+	return svc.store.Memberships.ListByUser(ctx, userID)
+}
+
+// ListChannelMembers returns a page of the user's active members, newest
+// first.
+func (svc *Service) ListChannelMembers(ctx context.Context, userID primitive.ObjectID, cursor string, limit int) (*MemberPage, error) {
+	after, err := decodeTimeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit = svc.clampLimit(limit)
+	members, err := svc.store.Memberships.ListChannelMembers(ctx, userID, time.Now().UTC(), after, limit)
+	if err != nil {
+		return nil, err
+	}
+	page := &MemberPage{Members: members}
+	if len(members) == limit {
+		last := members[len(members)-1]
+		page.NextCursor = encodeCursor(repo.TimeCursor{At: last.StartedAt, ID: last.ID})
+	}
+	return page, nil
+}
+
+// SetStreamSubscribersOnly restricts playback of the user's stream to
+// members, or lifts the restriction. Recordings made from now on inherit it.
+func (svc *Service) SetStreamSubscribersOnly(ctx context.Context, userID primitive.ObjectID, on bool) (*models.Stream, error) {
+	user, err := svc.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrStreamNotFound
+	}
+	return svc.store.Streams.SetSubscribersOnly(ctx, userID, user.Username, on)
+}
+
+// SetVideoSubscribersOnly restricts playback of one of the user's videos
+// to members, or lifts the restriction.
+func (svc *Service) SetVideoSubscribersOnly(ctx context.Context, userID, id primitive.ObjectID, on bool) (*models.Video, error) {
+	if _, err := svc.ownVideo(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	v, err := svc.store.Videos.SetSubscribersOnly(ctx, id, on)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, ErrVideoNotFound
+	}
+	return v, nil
+}
+
+// isMember reports whether the viewer may play the channel's
+// subscriber-only content: its owner and active members may.
+func (svc *Service) isMember(ctx context.Context, channelID, viewerID primitive.ObjectID) (bool, error) {
+	if viewerID.IsZero() {
+		return false, nil
+	}
+	if viewerID == channelID {
+		return true, nil
+	}
+	return svc.store.Memberships.IsMember(ctx, channelID, viewerID, time.Now().UTC())
+}
+
+// requireMembership returns ErrSubscriptionRequired if content restricted
+// to the channel's members is played by somebody else.
+func (svc *Service) requireMembership(ctx context.Context, subscribersOnly bool, channelID, viewerID primitive.ObjectID) error {
+	if !subscribersOnly {
+		return nil
+	}
+	ok, err := svc.isMember(ctx, channelID, viewerID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSubscriptionRequired
+	}
+	return nil
+}
+
+// StartMembershipRenewals starts the background job that renews due
+// memberships, charging their members, and expires the rest, until Close.
+// Every instance may run it: renewals are idempotent.
+func (svc *Service) StartMembershipRenewals() {
+	svc.renewals.Do(func() {
+		go func() {
+			tick := time.NewTicker(membershipRenewInterval)
+			defer tick.Stop()
+			for {
+				select {
+				case <-svc.done:
+					return
+				case <-tick.C:
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), membershipRenewInterval)
+				svc.renewDueMemberships(ctx, time.Now().UTC())
+				cancel()
+			}
+		}()
+	})
+}
+
+func (svc *Service) renewDueMemberships(ctx context.Context, now time.Time) {
+	due, err := svc.store.Memberships.ListDue(ctx, now, membershipRenewBatch)
+	if err != nil {
+		log.Printf("membership: failed to list due memberships: %v", err)
+		return
+	}
+	for i := range due {
+		svc.renewMembership(ctx, &due[i], now)
+	}
+}
+
+// renewMembership charges the member for another period and extends the
+// membership, or expires it when it was cancelled, its tier is gone or
+// the member cannot pay.
+func (svc *Service) renewMembership(ctx context.Context, m *models.Membership, now time.Time) {
+	tier, err := svc.store.Memberships.FindTier(ctx, m.TierID)
+	if err != nil {
+		log.Printf("membership: failed to load tier of %s: %v", m.ID.Hex(), err)
+		return
+	}
+	switch {
+	case !m.AutoRenew:
+		svc.expireMembership(ctx, m, "")
+		return
+	case tier == nil || tier.Archived:
+		svc.expireMembership(ctx, m, "the tier is no longer offered")
+		return
+	}
+
+	// Renewals continue from the end of the last period, unless the job
+	// fell so far behind that a whole period has passed.
+	next := m.ExpiresAt.Add(tierPeriod(tier))
+	if !next.After(now) {
+		next = now.Add(tierPeriod(tier))
+	}
+	_, _, err = svc.chargeMembership(ctx, m, tier, m.ExpiresAt)
+	if errors.Is(err, ErrInsufficientFunds) {
+		svc.expireMembership(ctx, m, fmt.Sprintf("not enough %s to renew", models.Currency))
+		return
+	}
+	if err != nil {
+		log.Printf("membership: failed to charge renewal of %s: %v", m.ID.Hex(), err)
+		return // tried again on the next run
+	}
+	if _, err := svc.store.Memberships.Renew(ctx, m.ID, m.ExpiresAt, next, tier.Name); err != nil {
+		log.Printf("membership: failed to renew %s: %v", m.ID.Hex(), err)
+	}
+}
+
+// chargeMembership pays the tier's price for the period starting at
+// periodStart. The period is the idempotency key, so a renewal retried
+// after a crash or by another instance is charged once.
+func (svc *Service) chargeMembership(ctx context.Context, m *models.Membership, tier *models.MembershipTier, periodStart time.Time) (*models.LedgerTransfer, bool, error) {
+	return svc.transfer(ctx, m.UserID, fmt.Sprintf("membership:%s:%d", m.ID.Hex(), periodStart.UnixMilli()), models.LedgerTransfer{
+		Kind:   models.TransferMembership,
+		From:   models.UserAccountID(m.UserID),
+		To:     models.UserAccountID(m.ChannelID),
+		Amount: tier.Price,
+		Memo:   fmt.Sprintf("%s membership of %s", tier.Name, m.ChannelUsername),
+	})
+}
+
+// expireMembership ends a due membership and tells the member why, unless
+// they cancelled it themselves.
+func (svc *Service) expireMembership(ctx context.Context, m *models.Membership, reason string) {
+	ok, err := svc.store.Memberships.Expire(ctx, m.ID, m.ExpiresAt)
+	if err != nil {
+		log.Printf("membership: failed to expire %s: %v", m.ID.Hex(), err)
+		return
+	}
+	if !ok || reason == "" {
+		return
+	}
+	err = svc.Notify(ctx, &models.Notification{
+		UserID:        m.UserID,
+		Type:          models.NotificationMembership,
+		ActorID:       m.ChannelID,
+		ActorUsername: m.ChannelUsername,
+		Message:       fmt.Sprintf("Your %s membership of %s ended: %s", m.TierName, m.ChannelUsername, reason),
+		Data:          map[string]string{"channel": m.ChannelUsername, "membership_id": m.ID.Hex(), "status": models.MembershipExpired},
+	})
+	if err != nil {
+		log.Printf("membership: failed to notify %s of expiry: %v", m.Username, err)
+	}
+}
+
+// announceMembership raises the on-stream alert for a new member and
+// notifies the streamer.
+func (svc *Service) announceMembership(ctx context.Context, member, channel *models.User, m *models.Membership) {
+	alert := chat.Event{Type: chat.EventAlert, Data: map[string]interface{}{
+		"kind":         models.TransferMembership,
+		"username":     member.Username,
+		"display_name": member.DisplayName,
+		"tier":         m.TierName,
+	}}
+	if err := svc.chatHub.Broadcast(ctx, channel.Username, alert); err != nil {
+		log.Printf("membership: failed to send alert in %s: %v", channel.Username, err)
+	}
+	err := svc.Notify(ctx, &models.Notification{
+		UserID:        channel.ID,
+		Type:          models.NotificationMembership,
+		ActorID:       member.ID,
+		ActorUsername: member.Username,
+		Message:       fmt.Sprintf("%s became a %s member", member.Username, m.TierName),
+		Data:          map[string]string{"membership_id": m.ID.Hex(), "tier_id": m.TierID.Hex(), "status": models.MembershipActive},
+	})
+	if err != nil {
+		log.Printf("membership: failed to notify %s of new member: %v", channel.Username, err)
+	}
+}
+
+func tierPeriod(t *models.MembershipTier) time.Duration {
+	return time.Duration(t.DurationDays) * 24 * time.Hour
+}
+
+func cleanTierName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxTierNameLength {
+		return "", fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidTier, maxTierNameLength)
+	}
+	return name, nil
+}
+
+func cleanTierPerks(perks []string) ([]string, error) {
+	if len(perks) > maxTierPerks {
+		return nil, fmt.Errorf("%w: at most %d perks", ErrInvalidTier, maxTierPerks)
+	}
+	out := make([]string, 0, len(perks))
+	for _, p := range perks {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if utf8.RuneCountInString(p) > maxTierPerkLength {
+			return nil, fmt.Errorf("%w: perks are limited to %d characters", ErrInvalidTier, maxTierPerkLength)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func checkTierTerms(price int64, days int) error {
+	if price <= 0 || price > maxTransferAmount {
+		return fmt.Errorf("%w: price must be between 1 and %d", ErrInvalidTier, maxTransferAmount)
+	}
+	if days < 1 || days > maxTierDurationDays {
+		return fmt.Errorf("%w: duration_days must be between 1 and %d", ErrInvalidTier, maxTierDurationDays)
+	}
+	return nil
+}
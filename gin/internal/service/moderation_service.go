@@ -0,0 +1,611 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/chat"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxTimeout          = 14 * 24 * time.Hour
+	maxBanReasonLength  = 200
+	maxSlowModeSeconds  = 120
+	maxFollowersMinutes = 90 * 24 * 60
+	maxBlockedTerms     = 200
+	maxBlockedTermLen   = 200
+	// chatRulesTTL bounds how stale another instance's cached settings can be.
+	chatRulesTTL = 10 * time.Second
+)
+
+var (
+	// ErrForbidden is returned when the caller lacks the channel role required.
+	ErrForbidden = errors.New("not allowed")
+	// ErrInvalidModeration is wrapped by malformed moderation input.
+	ErrInvalidModeration = errors.New("invalid moderation request")
+	// ErrMessageNotFound is returned when deleting an unknown chat message.
+	ErrMessageNotFound = errors.New("message not found")
+)
+
+// Channel roles, from most to least privileged.
+const (
+	roleBroadcaster = models.BadgeBroadcaster
+	roleModerator   = models.BadgeModerator
+)
+
+// emoteCode matches a single :emote: token.
+var emoteCode = regexp.MustCompile(`^:[A-Za-z0-9_]{2,32}:$`)
+
+// BanInput describes a ban (DurationSeconds 0) or timeout.
+type BanInput struct {
+	Username        string
+	Reason          string
+	DurationSeconds int
+}
+
+// ChatSettingsInput is a partial update of a channel's chat settings.
+type ChatSettingsInput struct {
+	SlowModeSeconds      *int
+	FollowersOnly        *bool
+	FollowersOnlyMinutes *int
+	EmoteOnly            *bool
+	BlockedTerms         *[]models.BlockedTerm
+}
+
+// ModerationLogPage is one page of a channel's moderation log.
+type ModerationLogPage struct {
+	Actions    []models.ModerationAction `json:"actions"`
+	NextCursor string                    `json:"next_cursor,omitempty"`
+}
+
+// chatRules is a channel's settings with blocked terms compiled.
+type chatRules struct {
+	settings models.ChatSettings
+	blocked  []*regexp.Regexp
+	loadedAt time.Time
+}
+
+// ----- roles -----
+
+// channelRole returns the user's role in the channel: broadcaster,
+// moderator, or "" for everyone else.
+func (svc *Service) channelRole(ctx context.Context, channel *models.User, userID primitive.ObjectID) (string, error) {
+	if userID == channel.ID {
+		return roleBroadcaster, nil
+	}
+	isMod, err := svc.store.Moderation.IsModerator(ctx, channel.ID, userID)
+	if err != nil || !isMod {
+		return "", err
+	}
+	return roleModerator, nil
+}
+
+// resolveModerator loads the channel and checks the actor holds at least
+// the minimum role (roleModerator or roleBroadcaster).
+func (svc *Service) resolveModerator(ctx context.Context, actorID primitive.ObjectID, channelName, minimum string) (*models.User, string, error) {
+	channel, err := svc.store.Users.FindByUsername(ctx, channelName)
+	if err != nil {
+		return nil, "", err
+	}
+	if channel == nil {
+		return nil, "", ErrUserNotFound
+	}
+	role, err := svc.channelRole(ctx, channel, actorID)
+	if err != nil {
+		return nil, "", err
+	}
+	if role == "" || (minimum == roleBroadcaster && role != roleBroadcaster) {
+		return nil, "", ErrForbidden
+	}
+	return channel, role, nil
+}
+
+// ----- moderators -----
+
+// AddModerator appoints target as a moderator of the actor's channel.
+// Only the broadcaster may do this.
+func (svc *Service) AddModerator(ctx context.Context, actorID primitive.ObjectID, channelName, target string) (*models.ChannelModerator, error) {
+	channel, _, err := svc.resolveModerator(ctx, actorID, channelName, roleBroadcaster)
+	if err != nil {
+		return nil, err
+	}
+	user, err := svc.store.Users.FindByUsername(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.ID == channel.ID {
+		return nil, fmt.Errorf("%w: the broadcaster is already in charge", ErrInvalidModeration)
+	}
+
+	m := &models.ChannelModerator{
+		ChannelID: channel.ID,
+		UserID:    user.ID,
+		Username:  user.Username,
+		AddedBy:   actorID,
+		CreatedAt: time.Now().UTC(),
+	}
+	created, err := svc.store.Moderation.AddModerator(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		svc.recordModeration(ctx, channel, actorID, &models.ModerationAction{
+			Action: models.ModActionAddModerator, TargetUserID: user.ID, TargetUsername: user.Username,
+		})
+		svc.notifyModerated(ctx, channel, user.ID, fmt.Sprintf("You are now a moderator in %s's chat", channel.Username))
+	}
+	return m, nil
+}
+
+// RemoveModerator revokes target's moderator role. Broadcaster only.
+func (svc *Service) RemoveModerator(ctx context.Context, actorID primitive.ObjectID, channelName, target string) (bool, error) {
+	channel, _, err := svc.resolveModerator(ctx, actorID, channelName, roleBroadcaster)
+	if err != nil {
+		return false, err
+	}
+	user, err := svc.store.Users.FindByUsername(ctx, target)
+	if err != nil {
+		return false, err
+	}
+	if user == nil {
+		return false, ErrUserNotFound
+	}
+	removed, err := svc.store.Moderation.RemoveModerator(ctx, channel.ID, user.ID)
+	if err != nil || !removed {
+		return false, err
+	}
+	svc.recordModeration(ctx, channel, actorID, &models.ModerationAction{
+		Action: models.ModActionRemoveModerator, TargetUserID: user.ID, TargetUsername: user.Username,
+	})
+	svc.notifyModerated(ctx, channel, user.ID, fmt.Sprintf("You are no longer a moderator in %s's chat", channel.Username))
+	return true, nil
+}
+
+// ListModerators returns the channel's moderators. Broadcaster and moderators only.
+func (svc *Service) ListModerators(ctx context.Context, actorID primitive.ObjectID, channelName string) ([]models.ChannelModerator, error) {
+	channel, _, err := svc.resolveModerator(ctx, actorID, channelName, roleModerator)
+	if err != nil {
+		return nil, err
+	}
+	return svc.store.Moderation.ListModerators(ctx, channel.ID)
+}
+
+// ----- bans and timeouts -----
+
+// BanUser bans (DurationSeconds == 0) or times out a user in the channel
+// and clears their messages. Moderators cannot act on other moderators.
+func (svc *Service) BanUser(ctx context.Context, actorID primitive.ObjectID, channelName string, in BanInput) (*models.ChannelBan, error) {
+	channel, role, err := svc.resolveModerator(ctx, actorID, channelName, roleModerator)
+	if err != nil {
+		return nil, err
+	}
+	reason := strings.TrimSpace(in.Reason)
+	if len(reason) > maxBanReasonLength {
+		return nil, fmt.Errorf("%w: reason is limited to %d characters", ErrInvalidModeration, maxBanReasonLength)
+	}
+	duration := time.Duration(in.DurationSeconds) * time.Second
+	if duration < 0 || duration > maxTimeout {
+		return nil, fmt.Errorf("%w: timeouts last between 1 second and %d days", ErrInvalidModeration, int(maxTimeout.Hours()/24))
+	}
+
+	user, err := svc.store.Users.FindByUsername(ctx, in.Username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	targetRole, err := svc.channelRole(ctx, channel, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case targetRole == roleBroadcaster:
+		return nil, fmt.Errorf("%w: the broadcaster cannot be banned", ErrInvalidModeration)
+	case targetRole == roleModerator && role != roleBroadcaster:
+		return nil, ErrForbidden
+	case targetRole == roleModerator:
+		// The broadcaster banning a moderator demotes them first.
+		if _, err := svc.store.Moderation.RemoveModerator(ctx, channel.ID, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC()
+	ban := &models.ChannelBan{
+		ChannelID: channel.ID,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Reason:    reason,
+		CreatedBy: actorID,
+		CreatedAt: now,
+	}
+	action := models.ModActionBan
+	if duration > 0 {
+		expires := now.Add(duration)
+		ban.ExpiresAt = &expires
+		action = models.ModActionTimeout
+	}
+	if err := svc.store.Moderation.UpsertBan(ctx, ban); err != nil {
+		return nil, err
+	}
+
+	if err := svc.store.Chat.MarkUserMessagesDeleted(ctx, channel.Username, user.ID, actorID); err != nil {
+		log.Printf("moderation: failed to clear messages of %s in %s: %v", user.Username, channel.Username, err)
+	}
+	svc.broadcastChat(ctx, channel.Username, chat.Event{
+		Type: chat.EventClearUser,
+		Data: map[string]interface{}{"user_id": user.ID.Hex(), "username": user.Username},
+	})
+
+	svc.recordModeration(ctx, channel, actorID, &models.ModerationAction{
+		Action: action, TargetUserID: user.ID, TargetUsername: user.Username,
+		Reason: reason, DurationSecs: in.DurationSeconds,
+	})
+	msg := fmt.Sprintf("You were banned from %s's chat", channel.Username)
+	if duration > 0 {
+		msg = fmt.Sprintf("You were timed out in %s's chat for %s", channel.Username, duration)
+	}
+	if reason != "" {
+		msg += ": " + reason
+	}
+	svc.notifyModerated(ctx, channel, user.ID, msg)
+	return ban, nil
+}
+
+// UnbanUser lifts a ban or timeout.
+func (svc *Service) UnbanUser(ctx context.Context, actorID primitive.ObjectID, channelName, target string) (bool, error) {
+	channel, _, err := svc.resolveModerator(ctx, actorID, channelName, roleModerator)
+	if err != nil {
+		return false, err
+	}
+	user, err := svc.store.Users.FindByUsername(ctx, target)
+	if err != nil {
+		return false, err
+	}
+	if user == nil {
+		return false, ErrUserNotFound
+	}
+	lifted, err := svc.store.Moderation.DeleteBan(ctx, channel.ID, user.ID)
+	if err != nil || !lifted {
+		return false, err
+	}
+	svc.recordModeration(ctx, channel, actorID, &models.ModerationAction{
+		Action: models.ModActionUnban, TargetUserID: user.ID, TargetUsername: user.Username,
+	})
+	svc.notifyModerated(ctx, channel, user.ID, fmt.Sprintf("You were unbanned from %s's chat", channel.Username))
+	return true, nil
+}
+
+// ListBans returns the channel's active bans and timeouts.
+func (svc *Service) ListBans(ctx context.Context, actorID primitive.ObjectID, channelName string) ([]models.ChannelBan, error) {
+	channel, _, err := svc.resolveModerator(ctx, actorID, channelName, roleModerator)
+	if err != nil {
+		return nil, err
+	}
+	return svc.store.Moderation.ListActiveBans(ctx, channel.ID, time.Now().UTC())
+}
+
+// ----- messages -----
+
+// DeleteChatMessage removes a single message from the channel's chat.
+func (svc *Service) DeleteChatMessage(ctx context.Context, actorID primitive.ObjectID, channelName string, messageID primitive.ObjectID) error {
+	channel, _, err := svc.resolveModerator(ctx, actorID, channelName, roleModerator)
+	if err != nil {
+		return err
+	}
+	msg, err := svc.store.Chat.MarkDeleted(ctx, channel.Username, messageID, actorID)
+	if err != nil {
+		return err
+	}
+	if msg == nil {
+		return ErrMessageNotFound
+	}
+	svc.broadcastChat(ctx, channel.Username, chat.Event{
+		Type: chat.EventDelete,
+		Data: map[string]interface{}{"message_id": msg.ID.Hex()},
+	})
+	svc.recordModeration(ctx, channel, actorID, &models.ModerationAction{
+		Action: models.ModActionDeleteMessage, TargetUserID: msg.UserID, TargetUsername: msg.Username, MessageID: msg.ID,
+	})
+	return nil
+}
+
+// ----- settings -----
+
+// GetChatSettings returns the channel's full chat settings, including
+// blocked terms. Broadcaster and moderators only.
+func (svc *Service) GetChatSettings(ctx context.Context, actorID primitive.ObjectID, channelName string) (*models.ChatSettings, error) {
+	channel, _, err := svc.resolveModerator(ctx, actorID, channelName, roleModerator)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := svc.loadChatRules(ctx, channel.ID)
+	if err != nil {
+		return nil, err
+	}
+	s := rules.settings
+	return &s, nil
+}
+
+// UpdateChatSettings applies a partial update to the channel's chat settings.
+func (svc *Service) UpdateChatSettings(ctx context.Context, actorID primitive.ObjectID, channelName string, in ChatSettingsInput) (*models.ChatSettings, error) {
+	channel, _, err := svc.resolveModerator(ctx, actorID, channelName, roleModerator)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := svc.loadChatRules(ctx, channel.ID)
+	if err != nil {
+		return nil, err
+	}
+	s := rules.settings
+
+	if in.SlowModeSeconds != nil {
+		if *in.SlowModeSeconds < 0 || *in.SlowModeSeconds > maxSlowModeSeconds {
+			return nil, fmt.Errorf("%w: slow mode must be 0-%d seconds", ErrInvalidModeration, maxSlowModeSeconds)
+		}
+		s.SlowModeSeconds = *in.SlowModeSeconds
+	}
+	if in.FollowersOnly != nil {
+		s.FollowersOnly = *in.FollowersOnly
+	}
+	if in.FollowersOnlyMinutes != nil {
+		if *in.FollowersOnlyMinutes < 0 || *in.FollowersOnlyMinutes > maxFollowersMinutes {
+			return nil, fmt.Errorf("%w: follow age must be 0-%d minutes", ErrInvalidModeration, maxFollowersMinutes)
+		}
+		s.FollowersOnlyMinutes = *in.FollowersOnlyMinutes
+	}
+	if in.EmoteOnly != nil {
+		s.EmoteOnly = *in.EmoteOnly
+	}
+	if in.BlockedTerms != nil {
+		terms, err := cleanBlockedTerms(*in.BlockedTerms)
+		if err != nil {
+			return nil, err
+		}
+		s.BlockedTerms = terms
+	}
+	s.ChannelID = channel.ID
+	s.UpdatedAt = time.Now().UTC()
+
+	if err := svc.store.Moderation.SaveChatSettings(ctx, &s); err != nil {
+		return nil, err
+	}
+	svc.invalidateChatRules(channel.ID)
+
+	svc.broadcastChat(ctx, channel.Username, chat.Event{Type: chat.EventSettings, Data: publicChatModes(&s)})
+	svc.recordModeration(ctx, channel, actorID, &models.ModerationAction{Action: models.ModActionUpdateSettings})
+	return &s, nil
+}
+
+// ListModerationLog returns a page of the channel's moderation log.
+// Only the broadcaster may read it.
+func (svc *Service) ListModerationLog(ctx context.Context, actorID primitive.ObjectID, channelName, action, cursor string, limit int) (*ModerationLogPage, error) {
+	channel, _, err := svc.resolveModerator(ctx, actorID, channelName, roleBroadcaster)
+	if err != nil {
+		return nil, err
+	}
+	after, err := decodeTimeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit = svc.clampLimit(limit)
+	actions, err := svc.store.Moderation.ListActions(ctx, channel.ID, action, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	page := &ModerationLogPage{Actions: actions}
+	if len(actions) == limit {
+		last := actions[len(actions)-1]
+		page.NextCursor = encodeCursor(repo.TimeCursor{At: last.CreatedAt, ID: last.ID})
+	}
+	return page, nil
+}
+
+// ----- enforcement -----
+
+// checkChatRestrictions returns why userID may not post text in the
+// channel right now, or "" if they may. Broadcaster and moderators are
+// exempt and should not be passed here.
+func (svc *Service) checkChatRestrictions(ctx context.Context, channel *models.User, userID primitive.ObjectID, text string) (string, error) {
+	now := time.Now().UTC()
+
+	ban, err := svc.store.Moderation.FindActiveBan(ctx, channel.ID, userID, now)
+	if err != nil {
+		return "", err
+	}
+	if ban != nil {
+		if ban.ExpiresAt == nil {
+			return "you are banned from this chat", nil
+		}
+		left := ban.ExpiresAt.Sub(now).Round(time.Second)
+		return fmt.Sprintf("you are timed out for another %s", left), nil
+	}
+
+	rules, err := svc.loadChatRules(ctx, channel.ID)
+	if err != nil {
+		return "", err
+	}
+	s := rules.settings
+
+	if s.FollowersOnly {
+		f, err := svc.store.Follows.Find(ctx, userID, channel.ID)
+		if err != nil {
+			return "", err
+		}
+		minAge := time.Duration(s.FollowersOnlyMinutes) * time.Minute
+		if f == nil || now.Sub(f.CreatedAt) < minAge {
+			if minAge > 0 {
+				return fmt.Sprintf("chat is followers-only (followed for at least %s)", minAge), nil
+			}
+			return "chat is followers-only", nil
+		}
+	}
+
+	if s.SlowModeSeconds > 0 {
+		last, err := svc.store.Chat.LastMessageAt(ctx, channel.Username, userID)
+		if err != nil {
+			return "", err
+		}
+		gap := time.Duration(s.SlowModeSeconds) * time.Second
+		if wait := last.Add(gap).Sub(now); wait > 0 {
+			return fmt.Sprintf("slow mode is on, wait %s", wait.Round(time.Second)), nil
+		}
+	}
+
+	if s.EmoteOnly && !isEmoteOnly(text) {
+		return "chat is emote-only", nil
+	}
+
+	for _, re := range rules.blocked {
+		if re.MatchString(text) {
+			return "your message contains a blocked term", nil
+		}
+	}
+	return "", nil
+}
+
+// loadChatRules returns the channel's settings and compiled blocked terms,
+// from a short-lived cache.
+func (svc *Service) loadChatRules(ctx context.Context, channelID primitive.ObjectID) (*chatRules, error) {
+	svc.chatRulesMu.Lock()
+	cached := svc.chatRulesCache[channelID]
+	svc.chatRulesMu.Unlock()
+	if cached != nil && time.Since(cached.loadedAt) < chatRulesTTL {
+		return cached, nil
+	}
+
+	s, err := svc.store.Moderation.GetChatSettings(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		s = &models.ChatSettings{ChannelID: channelID, BlockedTerms: []models.BlockedTerm{}}
+	}
+	rules := &chatRules{settings: *s, loadedAt: time.Now()}
+	for _, t := range s.BlockedTerms {
+		// Terms were validated on save; skip anything that no longer compiles.
+		if re, err := compileBlockedTerm(t); err == nil {
+			rules.blocked = append(rules.blocked, re)
+		}
+	}
+
+	svc.chatRulesMu.Lock()
+	svc.chatRulesCache[channelID] = rules
+	svc.chatRulesMu.Unlock()
+	return rules, nil
+}
+
+func (svc *Service) invalidateChatRules(channelID primitive.ObjectID) {
+	svc.chatRulesMu.Lock()
+	delete(svc.chatRulesCache, channelID)
+	svc.chatRulesMu.Unlock()
+}
+
+func compileBlockedTerm(t models.BlockedTerm) (*regexp.Regexp, error) {
+	if t.Regex {
+		return regexp.Compile("(?i)" + t.Pattern)
+	}
+	return regexp.Compile("(?i)" + regexp.QuoteMeta(t.Pattern))
+}
+
+// cleanBlockedTerms trims, de-duplicates and validates blocked terms.
+// Go regexps are RE2, so user patterns cannot cause catastrophic backtracking.
+func cleanBlockedTerms(terms []models.BlockedTerm) ([]models.BlockedTerm, error) {
+	if len(terms) > maxBlockedTerms {
+		return nil, fmt.Errorf("%w: at most %d blocked terms", ErrInvalidModeration, maxBlockedTerms)
+	}
+	out := make([]models.BlockedTerm, 0, len(terms))
+	seen := make(map[models.BlockedTerm]bool)
+	for _, t := range terms {
+		t.Pattern = strings.TrimSpace(t.Pattern)
+		if t.Pattern == "" || seen[t] {
+			continue
+		}
+		if len(t.Pattern) > maxBlockedTermLen {
+			return nil, fmt.Errorf("%w: blocked terms are limited to %d characters", ErrInvalidModeration, maxBlockedTermLen)
+		}
+		if _, err := compileBlockedTerm(t); err != nil {
+			return nil, fmt.Errorf("%w: bad pattern %q: %v", ErrInvalidModeration, t.Pattern, err)
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// isEmoteOnly reports whether every word of text is an :emote: code or
+// made only of emoji.
+func isEmoteOnly(text string) bool {
+	for _, word := range strings.Fields(text) {
+		if emoteCode.MatchString(word) {
+			continue
+		}
+		for _, r := range word {
+			// Symbols plus the joiners and modifiers used inside emoji sequences.
+			if !unicode.In(r, unicode.So, unicode.Sk, unicode.Mn) && r != '\u200d' && r != '\ufe0f' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// publicChatModes is what every viewer is told about the room's settings.
+// Blocked terms stay private to the channel's moderators.
+func publicChatModes(s *models.ChatSettings) map[string]interface{} {
+	return map[string]interface{}{
+		"slow_mode_seconds":      s.SlowModeSeconds,
+		"followers_only":         s.FollowersOnly,
+		"followers_only_minutes": s.FollowersOnlyMinutes,
+		"emote_only":             s.EmoteOnly,
+	}
+}
+
+// ----- helpers -----
+
+func (svc *Service) broadcastChat(ctx context.Context, room string, ev chat.Event) {
+	if err := svc.chatHub.Broadcast(ctx, room, ev); err != nil {
+		log.Printf("chat: failed to broadcast %s in %s: %v", ev.Type, room, err)
+	}
+}
+
+// recordModeration appends to the moderation log; failures are logged so
+// the action itself still succeeds.
+func (svc *Service) recordModeration(ctx context.Context, channel *models.User, actorID primitive.ObjectID, a *models.ModerationAction) {
+	a.ChannelID = channel.ID
+	a.ActorID = actorID
+	if actor, err := svc.store.Users.FindByID(ctx, actorID); err == nil && actor != nil {
+		a.ActorUsername = actor.Username
+	}
+	a.CreatedAt = time.Now().UTC()
+	if err := svc.store.Moderation.InsertAction(ctx, a); err != nil {
+		log.Printf("moderation: failed to log %s in %s: %v", a.Action, channel.Username, err)
+	}
+}
+
+func (svc *Service) notifyModerated(ctx context.Context, channel *models.User, userID primitive.ObjectID, msg string) {
+	err := svc.Notify(ctx, &models.Notification{
+		UserID:        userID,
+		Type:          models.NotificationModeration,
+		ActorID:       channel.ID,
+		ActorUsername: channel.Username,
+		Message:       msg,
+		Data:          map[string]string{"channel": channel.Username},
+	})
+	if err != nil {
+		log.Printf("moderation: failed to notify: %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// fanOutBatch is how many followers are loaded and notified per round trip.
+	fanOutBatch = 500
+	// fanOutTimeout bounds a single went-live fan-out.
+	fanOutTimeout = 5 * time.Minute
+	// liveReconnectGrace suppresses a second "went live" notification when a
+	// broadcaster drops and reconnects shortly after.
+	liveReconnectGrace = 10 * time.Minute
+)
+
+// NotificationPage is one page of a user's inbox.
+type NotificationPage struct {
+	Notifications []models.Notification `json:"notifications"`
+	NextCursor    string                `json:"next_cursor,omitempty"`
+}
+
+// SubscribeNotifications registers a live connection for userID. Call the
+// returned func when the connection closes.
+func (svc *Service) SubscribeNotifications(userID primitive.ObjectID) (<-chan models.Notification, func()) {
+	return svc.notifications.Subscribe(userID)
+}
+
+// Notify stores a notification and pushes it to the recipient if connected.
+func (svc *Service) Notify(ctx context.Context, n *models.Notification) error {
+	return svc.notifyMany(ctx, []*models.Notification{n})
+}
+
+func (svc *Service) notifyMany(ctx context.Context, ns []*models.Notification) error {
+	now := time.Now().UTC()
+	for _, n := range ns {
+		if n.CreatedAt.IsZero() {
+			n.CreatedAt = now
+		}
+	}
+	if err := svc.store.Notifications.Insert(ctx, ns); err != nil {
+		return err
+	}
+	for _, n := range ns {
+		svc.notifications.Publish(*n)
+	}
+	return nil
+}
+
+// ListNotifications returns a page of the user's inbox, newest first.
+func (svc *Service) ListNotifications(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, cursor string, limit int) (*NotificationPage, error) {
+	after, err := decodeTimeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit = svc.clampLimit(limit)
+	ns, err := svc.store.Notifications.List(ctx, userID, unreadOnly, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	page := &NotificationPage{Notifications: ns}
+	if len(ns) == limit {
+		last := ns[len(ns)-1]
+		page.NextCursor = encodeCursor(repo.TimeCursor{At: last.CreatedAt, ID: last.ID})
+	}
+	return page, nil
+}
+
+// UnreadNotificationCount returns the number of unread notifications.
+func (svc *Service) UnreadNotificationCount(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return svc.store.Notifications.CountUnread(ctx, userID)
+}
+
+// MarkNotificationsRead marks the listed notifications (or all when ids is
+// empty) as read and returns how many changed.
+func (svc *Service) MarkNotificationsRead(ctx context.Context, userID primitive.ObjectID, ids []primitive.ObjectID) (int64, error) {
+	return svc.store.Notifications.MarkRead(ctx, userID, ids)
+}
+
+// announceWentLive notifies every follower of the channel in the
+// background, walking the follower set in batches.
+func (svc *Service) announceWentLive(s *models.Stream) {
+	go func() {
+		svc.fanOutSlots <- struct{}{}
+		defer func() { <-svc.fanOutSlots }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), fanOutTimeout)
+		defer cancel()
+
+		msg := fmt.Sprintf("%s is live", s.Username)
+		if s.Title != "" {
+			msg = fmt.Sprintf("%s is live: %s", s.Username, s.Title)
+		}
+
+		var after primitive.ObjectID
+		sent := 0
+		for {
+			ids, last, err := svc.store.Follows.FollowerIDsAfter(ctx, s.UserID, after, fanOutBatch)
+			if err != nil {
+				log.Printf("went-live fan-out for %s stopped after %d: %v", s.Username, sent, err)
+				return
+			}
+			if len(ids) == 0 {
+				return
+			}
+			batch := make([]*models.Notification, len(ids))
+			for i, id := range ids {
+				batch[i] = &models.Notification{
+					UserID:        id,
+					Type:          models.NotificationWentLive,
+					ActorID:       s.UserID,
+					ActorUsername: s.Username,
+					Message:       msg,
+					Data:          map[string]string{"channel": s.Username, "category": s.Category},
+				}
+			}
+			if err := svc.notifyMany(ctx, batch); err != nil {
+				log.Printf("went-live fan-out for %s stopped after %d: %v", s.Username, sent, err)
+				return
+			}
+			sent += len(ids)
+			after = last
+		}
+	}()
+}
+
+// notifyNewFollower tells a channel it gained a follower. Failures are
+// logged; they must not fail the follow itself.
+func (svc *Service) notifyNewFollower(ctx context.Context, followerID, channelID primitive.ObjectID) {
+	follower, err := svc.store.Users.FindByID(ctx, followerID)
+	if err != nil || follower == nil {
+		return
+	}
+	err = svc.Notify(ctx, &models.Notification{
+		UserID:        channelID,
+		Type:          models.NotificationNewFollower,
+		ActorID:       follower.ID,
+		ActorUsername: follower.Username,
+		Message:       fmt.Sprintf("%s followed you", follower.Username),
+	})
+	if err != nil {
+		log.Printf("failed to notify new follower: %v", err)
+	}
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/hls"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/playback"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// playbackTokenTTL is how long a playback token is valid. Players
+	// refetch live playlists every few seconds and get a renewed token in
+	// them, so only viewers who stop watching (or lose access) run out.
+	playbackTokenTTL = 5 * time.Minute
+	// playbackRenewAfter is the token age after which playlists are
+	// rewritten with a fresh one.
+	playbackRenewAfter = playbackTokenTTL / 2
+	// maxRecordingTokenTTL caps tokens handed out in VOD playlists.
+	maxRecordingTokenTTL = 12 * time.Hour
+)
+
+var (
+	// ErrPlaybackDenied is returned when a viewer may not watch a stream.
+	ErrPlaybackDenied = errors.New("playback not allowed")
+	// ErrHLSNotFound is returned for HLS files that do not exist.
+	ErrHLSNotFound = errors.New("stream file not found")
+)
+
+// PlaybackGrant is a signed playback token and where to use it.
+type PlaybackGrant struct {
+	Token       string    `json:"token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	PlaylistURL string    `json:"playlist_url"`
+}
+
+// IssuePlaybackToken grants viewerID (NilObjectID for anonymous viewers)
+// a short-lived token to watch the channel's live stream. invite is an
+// optional invite code admitting the viewer to a private stream; it does
+// not stand in for a membership of a subscriber-only one.
+func (svc *Service) IssuePlaybackToken(ctx context.Context, username string, viewerID primitive.ObjectID, invite string) (*PlaybackGrant, error) {
+	channel, err := svc.store.Users.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, ErrStreamNotFound
+	}
+
+	claims := playback.Claims{Stream: channel.Username, Resource: playback.ResourceLive, Session: newPlaybackSession()}
+	if !viewerID.IsZero() {
+		claims.Viewer = viewerID.Hex()
+	}
+	s, err := svc.store.Streams.FindByUserID(ctx, channel.ID)
+	if err != nil {
+		return nil, err
+	}
+	ok := streamAllows(s, channel.ID, viewerID)
+	if !ok && invite != "" {
+		inv, err := svc.redeemInvite(ctx, channel, viewerID, invite)
+		if err != nil {
+			return nil, err
+		}
+		if viewerID.IsZero() {
+			claims.Invite = inv.ID.Hex()
+		}
+		ok = true
+	}
+	if !ok {
+		return nil, ErrPlaybackDenied
+	}
+	if err := svc.requireMembership(ctx, s != nil && s.SubscribersOnly, channel.ID, viewerID); err != nil {
+		return nil, err
+	}
+
+	claims.Expires = time.Now().Add(playbackTokenTTL).Unix()
+	token := svc.signer.Sign(claims)
+	return &PlaybackGrant{
+		Token:       token,
+		ExpiresAt:   claims.ExpiresAt(),
+		PlaylistURL: "/api/v1/hls/" + channel.Username + ".m3u8?token=" + url.QueryEscape(token),
+	}, nil
+}
+
+// AuthorizePlayback checks that token grants access to the live HLS file.
+func (svc *Service) AuthorizePlayback(file, token string) (playback.Claims, error) {
+	stream := playback.StreamOfFile(file)
+	if stream == "" {
+		return playback.Claims{}, ErrHLSNotFound
+	}
+	return svc.verifyPlaybackToken(stream, token, playback.ResourceLive)
+}
+
+// verifyPlaybackToken checks that token is valid for the channel's stream
+// and grants one of resources.
+func (svc *Service) verifyPlaybackToken(stream, token string, resources ...string) (playback.Claims, error) {
+	claims, err := svc.signer.Verify(token, stream, time.Now())
+	if errors.Is(err, playback.ErrWrongStream) {
+		return claims, ErrPlaybackDenied
+	}
+	if err != nil {
+		return claims, err
+	}
+	if !slices.Contains(resources, claims.Resource) {
+		return claims, ErrPlaybackDenied
+	}
+	return claims, nil
+}
+
+// OpenLiveHLS authorizes and resolves a live HLS file. For playlists it
+// also returns the token their URIs must carry (see playlistToken). A
+// missing playlist is reported as ErrStreamNotFound or ErrStreamOffline so
+// players can tell a wrong link from a stream that is not up (yet).
+func (svc *Service) OpenLiveHLS(ctx context.Context, file, token string) (path, playlistToken string, err error) {
+	claims, err := svc.AuthorizePlayback(file, token)
+	if err != nil {
+		return "", "", err
+	}
+	path = filepath.Join(svc.liveHLSDir(), file)
+	if _, err := os.Stat(path); err != nil {
+		if !os.IsNotExist(err) {
+			return "", "", err
+		}
+		if !playback.IsPlaylist(file) {
+			return "", "", ErrHLSNotFound // rotated out of the live window
+		}
+		if err := svc.liveStatus(ctx, claims.Stream); err != nil {
+			return "", "", err
+		}
+		return "", "", ErrStreamOffline
+	}
+	if !playback.IsPlaylist(file) {
+		return path, "", nil
+	}
+	if playlistToken, err = svc.renewIfAging(ctx, claims, token); err != nil {
+		return "", "", err
+	}
+	svc.recordView(ctx, claims)
+	return path, playlistToken, nil
+}
+
+// OpenLiveMaster authorizes a request for the master playlist of a live
+// stream and returns its renditions, best first, and the token their URIs
+// must carry.
+func (svc *Service) OpenLiveMaster(ctx context.Context, stream, token string) ([]hls.Rendition, string, error) {
+	claims, err := svc.verifyPlaybackToken(stream, token, playback.ResourceLive)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := svc.liveStatus(ctx, stream); err != nil {
+		return nil, "", err
+	}
+	renditions, err := hls.Renditions(svc.liveHLSDir(), stream)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, "", err
+	}
+	if len(renditions) == 0 {
+		// Live, but the first segments are not written yet.
+		return nil, "", ErrStreamOffline
+	}
+	playlistToken, err := svc.renewIfAging(ctx, claims, token)
+	if err != nil {
+		return nil, "", err
+	}
+	svc.recordView(ctx, claims)
+	return renditions, playlistToken, nil
+}
+
+// liveStatus returns ErrStreamNotFound if there is no such channel and
+// ErrStreamOffline if it is not broadcasting.
+func (svc *Service) liveStatus(ctx context.Context, stream string) error {
+	channel, err := svc.store.Users.FindByUsername(ctx, stream)
+	if err != nil {
+		return err
+	}
+	if channel == nil {
+		return ErrStreamNotFound
+	}
+	s, err := svc.store.Streams.FindByUserID(ctx, channel.ID)
+	if err != nil {
+		return err
+	}
+	if s == nil || !s.Live {
+		return ErrStreamOffline
+	}
+	return nil
+}
+
+// renewIfAging returns the token a playlist's URIs should carry: the same
+// one, or a renewed one once it is half used up and the viewer still has
+// access.
+func (svc *Service) renewIfAging(ctx context.Context, claims playback.Claims, token string) (string, error) {
+	issued := claims.ExpiresAt().Add(-playbackTokenTTL)
+	if time.Since(issued) < playbackRenewAfter {
+		return token, nil
+	}
+	return svc.renewPlaybackToken(ctx, claims, playbackTokenTTL)
+}
+
+// renewPlaybackToken re-checks that the token's viewer still has access
+// to the stream, membership included, and signs the same grant for
+// another ttl.
+func (svc *Service) renewPlaybackToken(ctx context.Context, claims playback.Claims, ttl time.Duration) (string, error) {
+	channel, err := svc.store.Users.FindByUsername(ctx, claims.Stream)
+	if err != nil {
+		return "", err
+	}
+	if channel == nil {
+		return "", ErrStreamNotFound
+	}
+	var viewerID primitive.ObjectID
+	if claims.Viewer != "" {
+		if viewerID, err = primitive.ObjectIDFromHex(claims.Viewer); err != nil {
+			return "", playback.ErrInvalidToken
+		}
+	}
+	s, err := svc.store.Streams.FindByUserID(ctx, channel.ID)
+	if err != nil {
+		return "", err
+	}
+	ok := streamAllows(s, channel.ID, viewerID)
+	if !ok && claims.Invite != "" {
+		if ok, err = svc.inviteStillValid(ctx, channel.ID, claims.Invite); err != nil {
+			return "", err
+		}
+	}
+	if !ok {
+		return "", ErrPlaybackDenied
+	}
+	if err := svc.requireMembership(ctx, s != nil && s.SubscribersOnly, channel.ID, viewerID); err != nil {
+		return "", err
+	}
+	claims.Expires = time.Now().Add(ttl).Unix()
+	return svc.signer.Sign(claims), nil
+}
+
+// recordingAccess is what a recording is restricted to.
+type recordingAccess struct {
+	private         bool
+	subscribersOnly bool
+}
+
+func (a recordingAccess) restricted() bool {
+	return a.private || a.subscribersOnly
+}
+
+// authorizeRecording checks a playback token for a private or
+// subscriber-only recording of the channel, which is resource, and
+// returns the token its URIs should carry: good for resource alone, for
+// ttl. Viewers come with a token for the live stream, or for resource
+// when a player reloads a growing playlist.
+func (svc *Service) authorizeRecording(ctx context.Context, channelID primitive.ObjectID, username, resource, token string, access recordingAccess, ttl time.Duration) (string, error) {
+	claims, err := svc.verifyPlaybackToken(username, token, playback.ResourceLive, resource)
+	if err != nil {
+		return "", err
+	}
+	var viewerID primitive.ObjectID
+	if claims.Viewer != "" {
+		if viewerID, err = primitive.ObjectIDFromHex(claims.Viewer); err != nil {
+			return "", playback.ErrInvalidToken
+		}
+	}
+	if access.private {
+		ok, err := svc.canSeePrivate(ctx, channelID, viewerID)
+		if err != nil {
+			return "", err
+		}
+		if !ok && claims.Invite != "" {
+			if ok, err = svc.inviteStillValid(ctx, channelID, claims.Invite); err != nil {
+				return "", err
+			}
+		}
+		if !ok {
+			return "", ErrPlaybackDenied
+		}
+	}
+	if err := svc.requireMembership(ctx, access.subscribersOnly, channelID, viewerID); err != nil {
+		return "", err
+	}
+	claims.Resource = resource
+	claims.Expires = time.Now().Add(ttl).Unix()
+	return svc.signer.Sign(claims), nil
+}
+
+// authorizeRecordingFile checks the token for one file of a restricted
+// recording, which is resource. Segments need a valid token for resource;
+// playlists re-check access and return the token to rewrite them with.
+func (svc *Service) authorizeRecordingFile(ctx context.Context, channelID primitive.ObjectID, username, resource, file, token string, access recordingAccess, ttl time.Duration) (string, error) {
+	if !playback.IsPlaylist(file) {
+		_, err := svc.verifyPlaybackToken(username, token, resource)
+		return "", err
+	}
+	return svc.authorizeRecording(ctx, channelID, username, resource, token, access, ttl)
+}
+
+// recordingTokenTTL is how long the token in a recording's playlist must
+// last: long enough to watch it through.
+func recordingTokenTTL(durationSeconds float64, growing bool) time.Duration {
+	if growing {
+		return playbackTokenTTL
+	}
+	ttl := time.Duration(durationSeconds)*time.Second + time.Hour
+	if ttl > maxRecordingTokenTTL {
+		ttl = maxRecordingTokenTTL
+	}
+	return ttl
+}
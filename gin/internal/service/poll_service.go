@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/chat"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	minChoices          = 2
+	maxChoices          = 5
+	maxQuestionLength   = 100
+	maxChoiceLength     = 50
+	minInteractionTime  = 15 * time.Second
+	maxInteractionTime  = 30 * time.Minute
+	interactionDeadline = 10 * time.Second
+	// tallyPushInterval caps how often a busy poll or prediction sends
+	// its tallies to chat.
+	tallyPushInterval = time.Second
+)
+
+var (
+	// ErrPollNotFound is returned for unknown polls.
+	ErrPollNotFound = errors.New("poll not found")
+	// ErrInvalidPoll is wrapped by malformed polls and votes.
+	ErrInvalidPoll = errors.New("invalid poll")
+	// ErrPollClosed is returned when voting in or ending a poll that ended.
+	ErrPollClosed = errors.New("poll is closed")
+	// ErrPollRunning is returned when opening a poll while another runs.
+	ErrPollRunning = errors.New("a poll is already running")
+	// ErrAlreadyVoted is returned for a second vote in a poll.
+	ErrAlreadyVoted = errors.New("you already voted in this poll")
+)
+
+// PollInput describes a poll to open.
+type PollInput struct {
+	Question        string
+	Options         []string
+	DurationSeconds int
+}
+
+// OpenPoll puts a question to the live channel's chat on behalf of its
+// owner or a moderator. It closes by itself after the duration.
+func (svc *Service) OpenPoll(ctx context.Context, actorID primitive.ObjectID, channelName string, in PollInput) (*models.Poll, error) {
+	question, err := cleanChoiceText(in.Question, maxQuestionLength, "question")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPoll, err)
+	}
+	choices, err := cleanChoices(in.Options)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPoll, err)
+	}
+	duration, err := interactionDuration(in.DurationSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPoll, err)
+	}
+	channel, _, err := svc.resolveModerator(ctx, actorID, channelName, roleModerator)
+	if err != nil {
+		return nil, err
+	}
+	s, err := svc.liveStream(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	running, err := svc.store.Polls.FindOpen(ctx, channel.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if running != nil {
+		return nil, ErrPollRunning
+	}
+	p := &models.Poll{
+		ChannelID:   channel.ID,
+		BroadcastID: s.BroadcastID,
+		Question:    question,
+		Options:     make([]models.PollOption, len(choices)),
+		Status:      models.PollOpen,
+		CreatedBy:   actorID,
+		CreatedAt:   now,
+		EndsAt:      now.Add(duration),
+	}
+	for i, c := range choices {
+		p.Options[i].Text = c
+	}
+	if err := svc.store.Polls.Create(ctx, p); err != nil {
+		return nil, err
+	}
+	svc.sendPoll(ctx, channel.Username, p)
+
+	// Only this instance knows to announce the result; other instances'
+	// clients see it then too, since events go through the hub.
+	time.AfterFunc(duration, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), interactionDeadline)
+		defer cancel()
+		svc.closePoll(ctx, channel.Username, p.ID)
+	})
+	return p, nil
+}
+
+// EndPoll closes a running poll early.
+func (svc *Service) EndPoll(ctx context.Context, actorID primitive.ObjectID, channelName string, id primitive.ObjectID) (*models.Poll, error) {
+	channel, _, err := svc.resolveModerator(ctx, actorID, channelName, roleModerator)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := svc.channelPoll(ctx, channel, id); err != nil {
+		return nil, err
+	}
+	p, err := svc.closePoll(ctx, channel.Username, id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrPollClosed
+	}
+	return p, nil
+}
+
+// VotePoll casts the user's one vote for an option of a running poll.
+// Users banned from the channel's chat cannot vote.
+func (svc *Service) VotePoll(ctx context.Context, userID primitive.ObjectID, channelName string, id primitive.ObjectID, option int) error {
+	channel, err := svc.findLedgerUser(ctx, channelName)
+	if err != nil {
+		return err
+	}
+	p, err := svc.channelPoll(ctx, channel, id)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if !p.Open(now) {
+		return ErrPollClosed
+	}
+	if option < 0 || option >= len(p.Options) {
+		return fmt.Errorf("%w: option must be between 0 and %d", ErrInvalidPoll, len(p.Options)-1)
+	}
+	if err := svc.checkNotBanned(ctx, channel, userID, now); err != nil {
+		return err
+	}
+
+	ok, err := svc.store.Polls.InsertVote(ctx, &models.PollVote{
+		PollID:    p.ID,
+		ChannelID: channel.ID,
+		UserID:    userID,
+		Option:    option,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAlreadyVoted
+	}
+	if err := svc.store.Polls.CountVote(ctx, p.ID, option); err != nil {
+		return err
+	}
+	svc.tallies.push(p.ID, func(ctx context.Context) {
+		if p, err := svc.store.Polls.FindByID(ctx, id); err == nil && p != nil {
+			svc.sendPoll(ctx, channel.Username, p)
+		}
+	})
+	return nil
+}
+
+// ListBroadcastPolls returns the polls of a broadcast the viewer may see,
+// with their final tallies.
+func (svc *Service) ListBroadcastPolls(ctx context.Context, broadcastID, viewerID primitive.ObjectID) ([]models.Poll, error) {
+	if _, err := svc.findVisibleBroadcast(ctx, broadcastID, viewerID); err != nil {
+		return nil, err
+	}
+	return svc.store.Polls.ListByBroadcast(ctx, broadcastID)
+}
+
+// closePoll closes the poll if it is still open and announces its result.
+// It returns nil if the poll was closed already.
+func (svc *Service) closePoll(ctx context.Context, username string, id primitive.ObjectID) (*models.Poll, error) {
+	p, err := svc.store.Polls.Close(ctx, id, time.Now().UTC())
+	if err != nil {
+		log.Printf("polls: failed to close %s: %v", id.Hex(), err)
+		return nil, err
+	}
+	if p != nil {
+		svc.sendPoll(ctx, username, p)
+	}
+	return p, nil
+}
+
+func (svc *Service) channelPoll(ctx context.Context, channel *models.User, id primitive.ObjectID) (*models.Poll, error) {
+	p, err := svc.store.Polls.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil || p.ChannelID != channel.ID {
+		return nil, ErrPollNotFound
+	}
+	return p, nil
+}
+
+func (svc *Service) sendPoll(ctx context.Context, username string, p *models.Poll) {
+	if err := svc.chatHub.Broadcast(ctx, username, chat.Event{Type: chat.EventPoll, Poll: p}); err != nil {
+		log.Printf("polls: failed to broadcast in %s: %v", username, err)
+	}
+}
+
+// sendInteractions sends a client joining chat the running poll and
+// prediction, if any.
+func (svc *Service) sendInteractions(ctx context.Context, channel *models.User, c *chat.Client) {
+	if p, err := svc.store.Polls.FindOpen(ctx, channel.ID, time.Now().UTC()); err == nil && p != nil {
+		c.Send(chat.Event{Type: chat.EventPoll, Poll: p})
+	}
+	if p, err := svc.store.Predictions.FindActive(ctx, channel.ID); err == nil && p != nil {
+		c.Send(chat.Event{Type: chat.EventPrediction, Prediction: p})
+	}
+}
+
+// endInteractions closes the polls and locks the prediction of a
+// broadcast that ended. The prediction still waits for its outcome.
+func (svc *Service) endInteractions(ctx context.Context, s *models.Stream) {
+	ids, err := svc.store.Polls.ListOpenIDs(ctx, s.UserID)
+	if err != nil {
+		log.Printf("polls: failed to list open polls of %s: %v", s.Username, err)
+	}
+	for _, id := range ids {
+		_, _ = svc.closePoll(ctx, s.Username, id)
+	}
+	p, err := svc.store.Predictions.FindActive(ctx, s.UserID)
+	if err != nil {
+		log.Printf("predictions: failed to load prediction of %s: %v", s.Username, err)
+		return
+	}
+	if p != nil && p.Status == models.PredictionOpen {
+		svc.lockPrediction(ctx, s.Username, p.ID)
+	}
+}
+
+// liveStream returns the channel's stream, or ErrStreamOffline if it is
+// not live: polls and predictions belong to a broadcast.
+func (svc *Service) liveStream(ctx context.Context, channel *models.User) (*models.Stream, error) {
+	s, err := svc.store.Streams.FindByUserID(ctx, channel.ID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil || !s.Live {
+		return nil, ErrStreamOffline
+	}
+	return s, nil
+}
+
+// checkNotBanned returns ErrForbidden if the user is banned or timed out
+// in the channel's chat.
+func (svc *Service) checkNotBanned(ctx context.Context, channel *models.User, userID primitive.ObjectID, now time.Time) error {
+	ban, err := svc.store.Moderation.FindActiveBan(ctx, channel.ID, userID, now)
+	if err != nil {
+		return err
+	}
+	if ban != nil {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func interactionDuration(seconds int) (time.Duration, error) {
+	d := time.Duration(seconds) * time.Second
+	if d < minInteractionTime || d > maxInteractionTime {
+		return 0, fmt.Errorf("duration must be %d-%d seconds", int(minInteractionTime.Seconds()), int(maxInteractionTime.Seconds()))
+	}
+	return d, nil
+}
+
+func cleanChoiceText(s string, max int, what string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || utf8.RuneCountInString(s) > max {
+		return "", fmt.Errorf("%s must be 1-%d characters", what, max)
+	}
+	return s, nil
+}
+
+func cleanChoices(choices []string) ([]string, error) {
+	if len(choices) < minChoices || len(choices) > maxChoices {
+		return nil, fmt.Errorf("give %d-%d options", minChoices, maxChoices)
+	}
+	out := make([]string, len(choices))
+	for i, c := range choices {
+		var err error
+		if out[i], err = cleanChoiceText(c, maxChoiceLength, "options"); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// tallyPusher coalesces tally updates so that a busy poll or prediction
+// sends at most one event per interval, carrying its latest state.
+type tallyPusher struct {
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[primitive.ObjectID]bool
+}
+
+// push schedules send for id unless it is already scheduled.
+func (t *tallyPusher) push(id primitive.ObjectID, send func(context.Context)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pending[id] {
+		return
+	}
+	t.pending[id] = true
+	time.AfterFunc(t.interval, func() {
+		t.mu.Lock()
+		delete(t.pending, id)
+		t.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), interactionDeadline)
+		defer cancel()
+		send(ctx)
+	})
+}
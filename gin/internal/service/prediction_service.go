@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/bits"
+	"sort"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/chat"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrPredictionNotFound is returned for unknown predictions.
+	ErrPredictionNotFound = errors.New("prediction not found")
+	// ErrInvalidPrediction is wrapped by malformed predictions and stakes.
+	ErrInvalidPrediction = errors.New("invalid prediction")
+	// ErrPredictionClosed is returned when staking on a prediction that is
+	// locked, or changing one that ended.
+	ErrPredictionClosed = errors.New("prediction is closed")
+	// ErrPredictionRunning is returned when opening a prediction while the
+	// previous one still waits for its outcome.
+	ErrPredictionRunning = errors.New("a prediction is already running")
+	// ErrAlreadyStaked is returned for a second stake on a prediction.
+	ErrAlreadyStaked = errors.New("you already staked on this prediction")
+)
+
+// PredictionInput describes a prediction to open. Stakes are taken for
+// WindowSeconds.
+type PredictionInput struct {
+	Title         string
+	Outcomes      []string
+	WindowSeconds int
+}
+
+// OpenPrediction starts a prediction in the live channel on behalf of its
+// owner or a moderator. It locks by itself when the window closes.
+func (svc *Service) OpenPrediction(ctx context.Context, actorID primitive.ObjectID, channelName string, in PredictionInput) (*models.Prediction, error) {
+	title, err := cleanChoiceText(in.Title, maxQuestionLength, "title")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrediction, err)
+	}
+	outcomes, err := cleanChoices(in.Outcomes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrediction, err)
+	}
+	window, err := interactionDuration(in.WindowSeconds)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrediction, err)
+	}
+	channel, _, err := svc.resolveModerator(ctx, actorID, channelName, roleModerator)
+	if err != nil {
+		return nil, err
+	}
+	s, err := svc.liveStream(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+	running, err := svc.store.Predictions.FindActive(ctx, channel.ID)
+	if err != nil {
+		return nil, err
+	}
+	if running != nil {
+		return nil, ErrPredictionRunning
+	}
+
+	now := time.Now().UTC()
+	p := &models.Prediction{
+		ChannelID:   channel.ID,
+		BroadcastID: s.BroadcastID,
+		Title:       title,
+		Outcomes:    make([]models.PredictionOutcome, len(outcomes)),
+		Status:      models.PredictionOpen,
+		CreatedBy:   actorID,
+		CreatedAt:   now,
+		LocksAt:     now.Add(window),
+	}
+	for i, o := range outcomes {
+		p.Outcomes[i].Text = o
+	}
+	if err := svc.store.Predictions.Create(ctx, p); err != nil {
+		return nil, err
+	}
+	svc.sendPrediction(ctx, channel.Username, p)
+
+	time.AfterFunc(window, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), interactionDeadline)
+		defer cancel()
+		svc.lockPrediction(ctx, channel.Username, p.ID)
+	})
+	return p, nil
+}
+
+// LockPrediction stops a prediction from taking stakes before its window
+// closes.
+func (svc *Service) LockPrediction(ctx context.Context, actorID primitive.ObjectID, channelName string, id primitive.ObjectID) (*models.Prediction, error) {
+	channel, _, err := svc.resolveModerator(ctx, actorID, channelName, roleModerator)
+	if err != nil {
+		return nil, err
+	}
+	p, err := svc.channelPrediction(ctx, channel, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != models.PredictionOpen {
+		if p.Ended() {
+			return nil, ErrPredictionClosed
+		}
+		return p, nil
+	}
+	if locked := svc.lockPrediction(ctx, channel.Username, id); locked != nil {
+		return locked, nil
+	}
+	return svc.channelPrediction(ctx, channel, id)
+}
+
+// ResolvePrediction ends a prediction with the winning outcome and pays
+// the winners out of the pool. If nobody backed the winner, every stake
+// is refunded. Resolving again with the same outcome finishes payouts
+// that were interrupted.
+func (svc *Service) ResolvePrediction(ctx context.Context, actorID primitive.ObjectID, channelName string, id primitive.ObjectID, outcome int) (*models.Prediction, error) {
+	channel, _, err := svc.resolveModerator(ctx, actorID, channelName, roleModerator)
+	if err != nil {
+		return nil, err
+	}
+	p, err := svc.channelPrediction(ctx, channel, id)
+	if err != nil {
+		return nil, err
+	}
+	if outcome < 0 || outcome >= len(p.Outcomes) {
+		return nil, fmt.Errorf("%w: outcome must be between 0 and %d", ErrInvalidPrediction, len(p.Outcomes)-1)
+	}
+	if p.Ended() {
+		if p.Status != models.PredictionResolved || *p.WinningOutcome != outcome || p.Settled {
+			return nil, ErrPredictionClosed
+		}
+		return p, svc.settlePrediction(ctx, channel.Username, p)
+	}
+	return svc.endPrediction(ctx, channel, actorID, id, &outcome)
+}
+
+// CancelPrediction ends a prediction without an outcome and refunds every
+// stake.
+func (svc *Service) CancelPrediction(ctx context.Context, actorID primitive.ObjectID, channelName string, id primitive.ObjectID) (*models.Prediction, error) {
+	channel, _, err := svc.resolveModerator(ctx, actorID, channelName, roleModerator)
+	if err != nil {
+		return nil, err
+	}
+	p, err := svc.channelPrediction(ctx, channel, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Ended() {
+		if p.Status != models.PredictionCanceled || p.Settled {
+			return nil, ErrPredictionClosed
+		}
+		return p, svc.settlePrediction(ctx, channel.Username, p)
+	}
+	return svc.endPrediction(ctx, channel, actorID, id, nil)
+}
+
+// StakePrediction places the user's one stake of amount honey on an
+// outcome of an open prediction. The channel's owner and moderators, who
+// decide the outcome, cannot stake, nor can users banned from its chat.
+func (svc *Service) StakePrediction(ctx context.Context, userID primitive.ObjectID, channelName string, id primitive.ObjectID, outcome int, amount int64) (*models.PredictionStake, error) {
+	channel, err := svc.findLedgerUser(ctx, channelName)
+	if err != nil {
+		return nil, err
+	}
+	p, err := svc.channelPrediction(ctx, channel, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if !p.Open(now) {
+		return nil, ErrPredictionClosed
+	}
+	if outcome < 0 || outcome >= len(p.Outcomes) {
+		return nil, fmt.Errorf("%w: outcome must be between 0 and %d", ErrInvalidPrediction, len(p.Outcomes)-1)
+	}
+	if amount <= 0 || amount > maxTransferAmount {
+		return nil, fmt.Errorf("%w: amount must be between 1 and %d", ErrInvalidPrediction, maxTransferAmount)
+	}
+	role, err := svc.channelRole(ctx, channel, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role != "" {
+		return nil, ErrForbidden
+	}
+	if err := svc.checkNotBanned(ctx, channel, userID, now); err != nil {
+		return nil, err
+	}
+	user, err := svc.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	stake := &models.PredictionStake{
+		PredictionID: p.ID,
+		ChannelID:    channel.ID,
+		UserID:       userID,
+		Username:     user.Username,
+		Outcome:      outcome,
+		Amount:       amount,
+		CreatedAt:    now,
+	}
+	payment := models.LedgerTransfer{
+		Kind:   models.TransferPredictionStake,
+		From:   models.UserAccountID(userID),
+		To:     models.PredictionEscrowAccount(p.ID),
+		Amount: amount,
+		Memo:   p.Title,
+	}
+	if err := prepareTransfer(userID, "prediction:"+p.ID.Hex(), &payment); err != nil {
+		return nil, err
+	}
+	// The stake counts only if it is paid for while the prediction is open.
+	ok, err := svc.store.Predictions.PlaceStake(ctx, stake, &payment, now)
+	if errors.Is(err, repo.ErrPredictionNotOpen) {
+		return nil, ErrPredictionClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrAlreadyStaked
+	}
+	svc.tallies.push(p.ID, func(ctx context.Context) {
+		if p, err := svc.store.Predictions.FindByID(ctx, id); err == nil && p != nil {
+			svc.sendPrediction(ctx, channel.Username, p)
+		}
+	})
+	return stake, nil
+}
+
+// ListBroadcastPredictions returns the predictions of a broadcast the
+// viewer may see, with their outcomes and totals.
+func (svc *Service) ListBroadcastPredictions(ctx context.Context, broadcastID, viewerID primitive.ObjectID) ([]models.Prediction, error) {
+	if _, err := svc.findVisibleBroadcast(ctx, broadcastID, viewerID); err != nil {
+		return nil, err
+	}
+	return svc.store.Predictions.ListByBroadcast(ctx, broadcastID)
+}
+
+// lockPrediction locks the prediction if it is still open and announces
+// it. It returns nil if the prediction was not open.
+func (svc *Service) lockPrediction(ctx context.Context, username string, id primitive.ObjectID) *models.Prediction {
+	p, err := svc.store.Predictions.Lock(ctx, id, time.Now().UTC())
+	if err != nil {
+		log.Printf("predictions: failed to lock %s: %v", id.Hex(), err)
+		return nil
+	}
+	if p != nil {
+		svc.sendPrediction(ctx, username, p)
+	}
+	return p
+}
+
+// endPrediction resolves or cancels the prediction, announces it and
+// settles its stakes.
+func (svc *Service) endPrediction(ctx context.Context, channel *models.User, actorID, id primitive.ObjectID, winning *int) (*models.Prediction, error) {
+	p, err := svc.store.Predictions.End(ctx, id, winning, actorID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrPredictionClosed // ended by a concurrent request
+	}
+	if err := svc.settlePrediction(ctx, channel.Username, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// settlePrediction pays out (or refunds) every stake of an ended
+// prediction from its escrow and announces the result. Each payment has
+// an idempotency key of its own, so settling again after a failure pays
+// nobody twice.
+func (svc *Service) settlePrediction(ctx context.Context, username string, p *models.Prediction) error {
+	stakes, err := svc.store.Predictions.ListStakes(ctx, p.ID)
+	if err != nil {
+		return err
+	}
+	var winning *int
+	if p.Status == models.PredictionResolved {
+		winning = p.WinningOutcome
+	}
+	payouts, refunded := predictionPayouts(stakes, winning)
+	kind := models.TransferPredictionPayout
+	if refunded {
+		kind = models.TransferPredictionRefund
+	}
+	for i, s := range stakes {
+		if payouts[i] == 0 {
+			continue
+		}
+		_, _, err := svc.transfer(ctx, p.ChannelID, fmt.Sprintf("prediction:%s:%s", p.ID.Hex(), s.UserID.Hex()), models.LedgerTransfer{
+			Kind:   kind,
+			From:   models.PredictionEscrowAccount(p.ID),
+			To:     models.UserAccountID(s.UserID),
+			Amount: payouts[i],
+			Memo:   p.Title,
+		})
+		if err != nil {
+			return err
+		}
+		if err := svc.store.Predictions.SetStakePayout(ctx, s.ID, payouts[i]); err != nil {
+			return err
+		}
+	}
+	if err := svc.store.Predictions.MarkSettled(ctx, p.ID); err != nil {
+		return err
+	}
+	p.Settled = true
+	svc.sendPrediction(ctx, username, p)
+	return nil
+}
+
+// predictionPayouts splits the pool of all stakes between the stakes on
+// the winning outcome in proportion to their amounts, rounding down; the
+// honey rounding leaves over goes one each to the largest winning stakes.
+// Without a winner (canceled), or if nobody backed it, every stake is
+// refunded and refunded is true.
+func predictionPayouts(stakes []models.PredictionStake, winning *int) (payouts []int64, refunded bool) {
+	payouts = make([]int64, len(stakes))
+	var pool, backed uint64
+	var winners []int
+	for i, s := range stakes {
+		pool += uint64(s.Amount)
+		if winning != nil && s.Outcome == *winning {
+			backed += uint64(s.Amount)
+			winners = append(winners, i)
+		}
+	}
+	if len(winners) == 0 {
+		for i, s := range stakes {
+			payouts[i] = s.Amount
+		}
+		return payouts, true
+	}
+
+	left := pool
+	for _, i := range winners {
+		// stake*pool/backed without overflow; the quotient fits as stake <= backed.
+		hi, lo := bits.Mul64(uint64(stakes[i].Amount), pool)
+		q, _ := bits.Div64(hi, lo, backed)
+		payouts[i] = int64(q)
+		left -= q
+	}
+	sort.SliceStable(winners, func(a, b int) bool {
+		return stakes[winners[a]].Amount > stakes[winners[b]].Amount
+	})
+	for k := 0; left > 0; k = (k + 1) % len(winners) {
+		payouts[winners[k]]++
+		left--
+	}
+	return payouts, false
+}
+
+func (svc *Service) channelPrediction(ctx context.Context, channel *models.User, id primitive.ObjectID) (*models.Prediction, error) {
+	p, err := svc.store.Predictions.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil || p.ChannelID != channel.ID {
+		return nil, ErrPredictionNotFound
+	}
+	return p, nil
+}
+
+func (svc *Service) sendPrediction(ctx context.Context, username string, p *models.Prediction) {
+	if err := svc.chatHub.Broadcast(ctx, username, chat.Event{Type: chat.EventPrediction, Prediction: p}); err != nil {
+		log.Printf("predictions: failed to broadcast in %s: %v", username, err)
+	}
+}
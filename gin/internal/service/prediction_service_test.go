@@ -0,0 +1,86 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+)
+
+func TestPredictionPayouts(t *testing.T) {
+	first, second := 0, 1
+	stakes := func(pairs ...int64) []models.PredictionStake {
+		var out []models.PredictionStake
+		for i := 0; i < len(pairs); i += 2 {
+			out = append(out, models.PredictionStake{Outcome: int(pairs[i]), Amount: pairs[i+1]})
+		}
+		return out
+	}
+	tests := []struct {
+		name         string
+		stakes       []models.PredictionStake
+		winning      *int
+		want         []int64
+		wantRefunded bool
+	}{
+		{
+			name:         "canceled refunds everybody",
+			stakes:       stakes(0, 100, 1, 50),
+			want:         []int64{100, 50},
+			wantRefunded: true,
+		},
+		{
+			name:         "unbacked winner refunds everybody",
+			stakes:       stakes(1, 100, 1, 50),
+			winning:      &first,
+			want:         []int64{100, 50},
+			wantRefunded: true,
+		},
+		{
+			name:    "sole winner takes the pool",
+			stakes:  stakes(0, 10, 1, 30, 1, 60),
+			winning: &first,
+			want:    []int64{100, 0, 0},
+		},
+		{
+			name:    "split in proportion to stakes",
+			stakes:  stakes(0, 100, 0, 300, 1, 400),
+			winning: &first,
+			want:    []int64{200, 600, 0},
+		},
+		{
+			name:    "leftover goes to the largest stake",
+			stakes:  stakes(0, 100, 0, 50, 1, 100),
+			winning: &first,
+			want:    []int64{167, 83, 0},
+		},
+		{
+			name:    "leftover spread over equal stakes in order",
+			stakes:  stakes(1, 1, 1, 1, 1, 1, 0, 2),
+			winning: &second,
+			want:    []int64{2, 2, 1, 0},
+		},
+		{
+			name:    "large amounts do not overflow",
+			stakes:  stakes(0, 1<<40, 1, 1<<40, 1, 1<<40),
+			winning: &first,
+			want:    []int64{3 << 40, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, refunded := predictionPayouts(tt.stakes, tt.winning)
+			if !reflect.DeepEqual(got, tt.want) || refunded != tt.wantRefunded {
+				t.Errorf("got %v, %v; want %v, %v", got, refunded, tt.want, tt.wantRefunded)
+			}
+			var pool, paid int64
+			for i, s := range tt.stakes {
+				pool += s.Amount
+				paid += got[i]
+			}
+			if paid != pool {
+				t.Errorf("paid %d of a pool of %d", paid, pool)
+			}
+		})
+	}
+}
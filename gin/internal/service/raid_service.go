@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/chat"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxRaidBlocks = 100
+
+var (
+	// ErrRaidNotFound is returned when the channel has no pending raid.
+	ErrRaidNotFound = errors.New("no raid pending")
+	// ErrInvalidRaid is wrapped by malformed raids and raid settings.
+	ErrInvalidRaid = errors.New("invalid raid")
+	// ErrRaidNotAllowed is wrapped when the target does not accept raids
+	// from the channel, or its viewers could not watch it.
+	ErrRaidNotAllowed = errors.New("raid not allowed")
+)
+
+// RaidSettingsInput is a partial update of a channel's raid settings.
+// Blocked lists usernames and replaces the block list.
+type RaidSettingsInput struct {
+	FollowersOnly *bool
+	Blocked       *[]string
+}
+
+// StartRaid picks the live channel target to send the user's viewers to
+// when their broadcast ends. Picking again replaces the target.
+func (svc *Service) StartRaid(ctx context.Context, userID primitive.ObjectID, target string) (*models.Raid, error) {
+	from, err := svc.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if from == nil {
+		return nil, ErrUserNotFound
+	}
+	s, err := svc.liveStream(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	to, err := svc.store.Users.FindByUsername(ctx, strings.TrimSpace(target))
+	if err != nil {
+		return nil, err
+	}
+	if to == nil {
+		return nil, ErrUserNotFound
+	}
+	if to.ID == from.ID {
+		return nil, fmt.Errorf("%w: a channel cannot raid itself", ErrInvalidRaid)
+	}
+	if err := svc.checkRaidTarget(ctx, from, to); err != nil {
+		return nil, err
+	}
+
+	r, err := svc.store.Raids.SetPending(ctx, &models.Raid{
+		FromID:       from.ID,
+		FromUsername: from.Username,
+		ToID:         to.ID,
+		ToUsername:   to.Username,
+		BroadcastID:  s.BroadcastID,
+		CreatedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	svc.broadcastChat(ctx, from.Username, chat.Event{Type: chat.EventSystem,
+		Text: fmt.Sprintf("When the stream ends, everyone here will raid %s!", to.Username)})
+	return r, nil
+}
+
+// GetPendingRaid returns the user's pending raid.
+func (svc *Service) GetPendingRaid(ctx context.Context, userID primitive.ObjectID) (*models.Raid, error) {
+	r, err := svc.store.Raids.FindPending(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ErrRaidNotFound
+	}
+	return r, nil
+}
+
+// CancelRaid calls off the user's pending raid.
+func (svc *Service) CancelRaid(ctx context.Context, userID primitive.ObjectID) error {
+	ok, err := svc.store.Raids.CancelPending(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrRaidNotFound
+	}
+	return nil
+}
+
+// GetRaidSettings returns the user's raid settings.
+func (svc *Service) GetRaidSettings(ctx context.Context, userID primitive.ObjectID) (*models.RaidSettings, error) {
+	return svc.loadRaidSettings(ctx, userID)
+}
+
+// UpdateRaidSettings applies a partial update to the user's raid settings.
+func (svc *Service) UpdateRaidSettings(ctx context.Context, userID primitive.ObjectID, in RaidSettingsInput) (*models.RaidSettings, error) {
+	s, err := svc.loadRaidSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if in.FollowersOnly != nil {
+		s.FollowersOnly = *in.FollowersOnly
+	}
+	if in.Blocked != nil {
+		if len(*in.Blocked) > maxRaidBlocks {
+			return nil, fmt.Errorf("%w: at most %d blocked channels", ErrInvalidRaid, maxRaidBlocks)
+		}
+		blocked := []models.RaidBlock{}
+		seen := make(map[primitive.ObjectID]bool)
+		for _, name := range *in.Blocked {
+			u, err := svc.store.Users.FindByUsername(ctx, strings.TrimSpace(name))
+			if err != nil {
+				return nil, err
+			}
+			if u == nil {
+				return nil, fmt.Errorf("%w: unknown channel %q", ErrInvalidRaid, name)
+			}
+			if u.ID == userID || seen[u.ID] {
+				continue
+			}
+			seen[u.ID] = true
+			blocked = append(blocked, models.RaidBlock{ChannelID: u.ID, Username: u.Username})
+		}
+		s.Blocked = blocked
+	}
+	s.UpdatedAt = time.Now().UTC()
+	if err := svc.store.Raids.SaveSettings(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// launchRaid sends the viewers of a broadcast that just ended on to the
+// channel it raids, if any. The target is checked again: it may have gone
+// offline or changed its raid settings since it was picked.
+func (svc *Service) launchRaid(ctx context.Context, s *models.Stream) {
+	r, err := svc.store.Raids.FindPending(ctx, s.UserID)
+	if err != nil {
+		log.Printf("raids: failed to load raid of %s: %v", s.Username, err)
+		return
+	}
+	if r == nil {
+		return
+	}
+	from, err1 := svc.store.Users.FindByID(ctx, r.FromID)
+	to, err2 := svc.store.Users.FindByID(ctx, r.ToID)
+	if err1 != nil || err2 != nil {
+		log.Printf("raids: failed to load channels of raid %s: %v %v", r.ID.Hex(), err1, err2)
+		return
+	}
+	if from == nil || to == nil {
+		err = ErrUserNotFound
+	} else {
+		err = svc.checkRaidTarget(ctx, from, to)
+	}
+	if err != nil {
+		if _, cerr := svc.store.Raids.CancelPending(ctx, r.FromID); cerr != nil {
+			log.Printf("raids: failed to cancel raid %s: %v", r.ID.Hex(), cerr)
+		}
+		svc.broadcastChat(ctx, s.Username, chat.Event{Type: chat.EventSystem,
+			Text: fmt.Sprintf("The raid on %s was called off: %v", r.ToUsername, err)})
+		return
+	}
+
+	viewers := svc.currentViewers(s.Username)
+	ok, err := svc.store.Raids.Launch(ctx, r.ID, viewers, time.Now().UTC())
+	if err != nil || !ok {
+		if err != nil {
+			log.Printf("raids: failed to launch raid %s: %v", r.ID.Hex(), err)
+		}
+		return
+	}
+
+	svc.broadcastChat(ctx, from.Username, chat.Event{Type: chat.EventRaid, Data: map[string]interface{}{
+		"raid_id": r.ID.Hex(),
+		"target":  to.Username,
+		"viewers": viewers,
+	}})
+
+	svc.broadcastChat(ctx, to.Username, chat.Event{Type: chat.EventSystem,
+		Text: fmt.Sprintf("%s is raiding with %d viewers!", from.Username, viewers)})
+	svc.broadcastChat(ctx, to.Username, chat.Event{Type: chat.EventAlert, Data: map[string]interface{}{
+		"kind":         "raid",
+		"raid_id":      r.ID.Hex(),
+		"username":     from.Username,
+		"display_name": from.DisplayName,
+		"viewers":      viewers,
+	}})
+	err = svc.Notify(ctx, &models.Notification{
+		UserID:        to.ID,
+		Type:          models.NotificationRaid,
+		ActorID:       from.ID,
+		ActorUsername: from.Username,
+		Message:       fmt.Sprintf("%s raided you with %d viewers", from.Username, viewers),
+		Data:          map[string]string{"raid_id": r.ID.Hex(), "viewers": fmt.Sprint(viewers)},
+	})
+	if err != nil {
+		log.Printf("raids: failed to notify %s of raid: %v", to.Username, err)
+	}
+}
+
+// checkRaidTarget returns an error unless from may raid to now: to must
+// be live, watchable by everyone and accept raids from from.
+func (svc *Service) checkRaidTarget(ctx context.Context, from, to *models.User) error {
+	target, err := svc.liveStream(ctx, to)
+	if err != nil {
+		return err
+	}
+	if target.Visibility == models.VisibilityPrivate || target.SubscribersOnly {
+		return fmt.Errorf("%w: %s is not open to all viewers", ErrRaidNotAllowed, to.Username)
+	}
+	rs, err := svc.loadRaidSettings(ctx, to.ID)
+	if err != nil {
+		return err
+	}
+	if rs.Blocks(from.ID) {
+		return fmt.Errorf("%w: %s does not accept raids from you", ErrRaidNotAllowed, to.Username)
+	}
+	if rs.FollowersOnly {
+		following, err := svc.store.Follows.IsFollowing(ctx, from.ID, to.ID)
+		if err != nil {
+			return err
+		}
+		if !following {
+			return fmt.Errorf("%w: %s only accepts raids from channels that follow it", ErrRaidNotAllowed, to.Username)
+		}
+	}
+	return nil
+}
+
+// loadRaidSettings returns the channel's raid settings or the defaults:
+// raids from everyone.
+func (svc *Service) loadRaidSettings(ctx context.Context, channelID primitive.ObjectID) (*models.RaidSettings, error) {
+	s, err := svc.store.Raids.GetSettings(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		s = &models.RaidSettings{ChannelID: channelID}
+	}
+	if s.Blocked == nil {
+		s.Blocked = []models.RaidBlock{}
+	}
+	return s, nil
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/analytics"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// recommendHistoryWindow is how far back watch history counts.
+	recommendHistoryWindow = 30 * 24 * time.Hour
+	recommendHistorySize   = 500
+	// recommendCandidates caps the live streams scored per request.
+	recommendCandidates = 200
+	// Co-watching is looked up for the viewer's most watched channels, from
+	// a sample of the other viewers of each.
+	coWatchSeeds  = 3
+	coWatchSample = 500
+	coWatchLimit  = 50
+	// freshnessHalfLife halves a broadcast's freshness boost; broadcasts
+	// younger than justWentLive say so.
+	freshnessHalfLife = 2 * time.Hour
+	justWentLive      = 15 * time.Minute
+	// Signals weaker than minReasonScore are not given as reasons.
+	minReasonScore = 0.25
+	maxReasons     = 3
+)
+
+// Weights of the signals a live stream is scored by. Each signal is
+// scaled to at most 1 before it is weighted.
+const (
+	weightFollow     = 3.0
+	weightWatched    = 2.0
+	weightCategory   = 1.5
+	weightTag        = 1.0
+	weightCoWatch    = 1.5
+	weightFreshness  = 0.5
+	weightPopularity = 0.5
+)
+
+// Recommendation reason types.
+const (
+	ReasonFollow     = "follow"
+	ReasonWatched    = "watched"
+	ReasonCategory   = "category"
+	ReasonTag        = "tag"
+	ReasonCoWatch    = "co_watch"
+	ReasonFresh      = "fresh"
+	ReasonPopularity = "popular"
+)
+
+// RecommendationReason explains part of why a stream was recommended.
+type RecommendationReason struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Recommendation is a live stream scored for a viewer.
+type Recommendation struct {
+	Stream  models.Stream          `json:"stream"`
+	Viewers int                    `json:"viewers"`
+	Score   float64                `json:"score"`
+	Reasons []RecommendationReason `json:"reasons"`
+}
+
+// Recommendations lists live streams, best first. Personalized is false
+// when they are ranked by popularity and freshness alone, as they are for
+// anonymous viewers and viewers with no follows or history yet.
+type Recommendations struct {
+	Personalized bool             `json:"personalized"`
+	Streams      []Recommendation `json:"streams"`
+}
+
+// viewerTaste is what a viewer's follows and recent watch history say
+// they like. Every map holds scores in (0, 1].
+type viewerTaste struct {
+	follows    map[primitive.ObjectID]bool
+	channels   map[primitive.ObjectID]float64
+	categories map[string]float64
+	tags       map[string]float64
+	coWatch    map[primitive.ObjectID]coWatched
+}
+
+// coWatched is a channel watched by the other viewers of via.
+type coWatched struct {
+	score float64
+	via   string
+}
+
+func (t *viewerTaste) empty() bool {
+	return len(t.follows) == 0 && len(t.channels) == 0
+}
+
+// RecommendStreams ranks the live streams for viewerID, or by popularity
+// and freshness if the viewer is anonymous (zero).
+func (svc *Service) RecommendStreams(ctx context.Context, viewerID primitive.ObjectID, limit int) (*Recommendations, error) {
+	limit = svc.clampLimit(limit)
+	streams, err := svc.store.Streams.ListLive(ctx, recommendCandidates)
+	if err != nil {
+		return nil, err
+	}
+	taste := &viewerTaste{}
+	if !viewerID.IsZero() {
+		if taste, err = svc.loadViewerTaste(ctx, viewerID); err != nil {
+			return nil, err
+		}
+	}
+	names, err := svc.categoryNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	viewers := make([]int, len(streams))
+	most := 0
+	for i, s := range streams {
+		viewers[i] = svc.currentViewers(s.Username)
+		if viewers[i] > most {
+			most = viewers[i]
+		}
+	}
+	recs := make([]Recommendation, 0, len(streams))
+	for i, s := range streams {
+		if s.UserID == viewerID {
+			continue
+		}
+		recs = append(recs, scoreStream(taste, names, s, viewers[i], most, now))
+	}
+	sort.SliceStable(recs, func(a, b int) bool {
+		if recs[a].Score != recs[b].Score {
+			return recs[a].Score > recs[b].Score
+		}
+		return recs[a].Viewers > recs[b].Viewers
+	})
+	if len(recs) > limit {
+		recs = recs[:limit]
+	}
+	return &Recommendations{Personalized: !taste.empty(), Streams: recs}, nil
+}
+
+// scoreStream adds up the weighted signals for s and keeps the strongest
+// as reasons.
+func scoreStream(taste *viewerTaste, categories map[string]string, s models.Stream, viewers, most int, now time.Time) Recommendation {
+	type signal struct {
+		score  float64
+		reason RecommendationReason
+	}
+	var signals []signal
+	add := func(weight, v float64, kind, text string) {
+		if v > 0 {
+			signals = append(signals, signal{weight * v, RecommendationReason{Type: kind, Text: text}})
+		}
+	}
+
+	if taste.follows[s.UserID] {
+		add(weightFollow, 1, ReasonFollow, fmt.Sprintf("Because you follow %s", s.Username))
+	} else {
+		add(weightWatched, taste.channels[s.UserID], ReasonWatched, fmt.Sprintf("Because you watched %s", s.Username))
+	}
+	if s.Category != "" {
+		name := categories[s.Category]
+		if name == "" {
+			name = s.Category
+		}
+		add(weightCategory, taste.categories[s.Category], ReasonCategory, fmt.Sprintf("Because you watch %s", name))
+	}
+	var tagScore, bestTag float64
+	var best string
+	for _, tag := range s.Tags {
+		v := taste.tags[tag]
+		tagScore += v
+		if v > bestTag {
+			bestTag, best = v, tag
+		}
+	}
+	add(weightTag, math.Min(tagScore, 1), ReasonTag, fmt.Sprintf("Because you watch #%s streams", best))
+	if cw, ok := taste.coWatch[s.UserID]; ok {
+		add(weightCoWatch, cw.score, ReasonCoWatch, fmt.Sprintf("Viewers of %s also watch this", cw.via))
+	}
+
+	if s.StartedAt != nil {
+		age := now.Sub(*s.StartedAt)
+		fresh := math.Exp2(-age.Hours() / freshnessHalfLife.Hours())
+		text := ""
+		if age < justWentLive {
+			text = "Just went live"
+		}
+		add(weightFreshness, math.Min(fresh, 1), ReasonFresh, text)
+	}
+	if most > 0 {
+		add(weightPopularity, math.Log1p(float64(viewers))/math.Log1p(float64(most)), ReasonPopularity,
+			fmt.Sprintf("%d watching now", viewers))
+	}
+
+	sort.SliceStable(signals, func(a, b int) bool { return signals[a].score > signals[b].score })
+	rec := Recommendation{Stream: s, Viewers: viewers, Reasons: []RecommendationReason{}}
+	for _, sig := range signals {
+		rec.Score += sig.score
+		if sig.reason.Text != "" && sig.score >= minReasonScore && len(rec.Reasons) < maxReasons {
+			rec.Reasons = append(rec.Reasons, sig.reason)
+		}
+	}
+	rec.Score = math.Round(rec.Score*1000) / 1000
+	return rec
+}
+
+// loadViewerTaste gathers the viewer's follows, the channels, categories
+// and tags they watched recently, weighted by watch time, and the
+// channels co-watched with their favourites.
+func (svc *Service) loadViewerTaste(ctx context.Context, viewerID primitive.ObjectID) (*viewerTaste, error) {
+	taste := &viewerTaste{
+		follows:    make(map[primitive.ObjectID]bool),
+		channels:   make(map[primitive.ObjectID]float64),
+		categories: make(map[string]float64),
+		tags:       make(map[string]float64),
+		coWatch:    make(map[primitive.ObjectID]coWatched),
+	}
+	follows, err := svc.store.Follows.FollowedChannelIDs(ctx, viewerID, feedFollowLimit)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range follows {
+		taste.follows[id] = true
+	}
+
+	viewer := analytics.ViewerID(svc.viewerKey, "user", viewerID.Hex())
+	since := time.Now().UTC().Add(-recommendHistoryWindow)
+	sessions, err := svc.store.Analytics.ListViewerHistory(ctx, viewer, since, recommendHistorySize)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return taste, nil
+	}
+	watched := make(map[primitive.ObjectID]float64) // seconds per broadcast
+	var ids []primitive.ObjectID
+	for _, s := range sessions {
+		if s.ChannelID == viewerID {
+			continue // their own channel says nothing about their taste
+		}
+		if _, ok := watched[s.BroadcastID]; !ok {
+			ids = append(ids, s.BroadcastID)
+		}
+		secs := math.Max(s.LeftAt.Sub(s.JoinedAt).Seconds(), 1)
+		watched[s.BroadcastID] += secs
+		taste.channels[s.ChannelID] += secs
+	}
+	broadcasts, err := svc.store.Broadcasts.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	usernames := make(map[primitive.ObjectID]string)
+	for _, b := range broadcasts {
+		usernames[b.ChannelID] = b.Username
+		if b.Category != "" {
+			taste.categories[b.Category] += watched[b.ID]
+		}
+		for _, tag := range b.Tags {
+			taste.tags[tag] += watched[b.ID]
+		}
+	}
+	normalize(taste.channels)
+	normalize(taste.categories)
+	normalize(taste.tags)
+
+	if err := svc.loadCoWatched(ctx, taste, viewer, usernames, since); err != nil {
+		return nil, err
+	}
+	return taste, nil
+}
+
+// loadCoWatched fills taste.coWatch from the other viewers of the
+// viewer's most watched channels. A channel's score is the share of those
+// viewers who watched it, scaled by how much the viewer likes the channel
+// it was found through.
+func (svc *Service) loadCoWatched(ctx context.Context, taste *viewerTaste, viewer string, usernames map[primitive.ObjectID]string, since time.Time) error {
+	seeds := make([]primitive.ObjectID, 0, len(taste.channels))
+	for id := range taste.channels {
+		seeds = append(seeds, id)
+	}
+	sort.Slice(seeds, func(a, b int) bool { return taste.channels[seeds[a]] > taste.channels[seeds[b]] })
+	if len(seeds) > coWatchSeeds {
+		seeds = seeds[:coWatchSeeds]
+	}
+	for _, seed := range seeds {
+		channels, err := svc.store.Analytics.CoWatchedChannels(ctx, seed, viewer, since, coWatchSample, coWatchLimit)
+		if err != nil {
+			return err
+		}
+		if len(channels) == 0 || usernames[seed] == "" {
+			continue
+		}
+		top := float64(channels[0].Viewers)
+		for _, c := range channels {
+			if _, seen := taste.channels[c.ChannelID]; seen {
+				continue // already scored as watched
+			}
+			score := float64(c.Viewers) / top * taste.channels[seed]
+			if score > taste.coWatch[c.ChannelID].score {
+				taste.coWatch[c.ChannelID] = coWatched{score: score, via: usernames[seed]}
+			}
+		}
+	}
+	return nil
+}
+
+// categoryNames maps category slugs to their display names.
+func (svc *Service) categoryNames(ctx context.Context) (map[string]string, error) {
+	categories, err := svc.store.Categories.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(categories))
+	for _, c := range categories {
+		names[c.Slug] = c.Name
+	}
+	return names, nil
+}
+
+// normalize scales m so that its largest value is 1.
+func normalize[K comparable](m map[K]float64) {
+	var top float64
+	for _, v := range m {
+		top = math.Max(top, v)
+	}
+	if top == 0 {
+		return
+	}
+	for k, v := range m {
+		m[k] = v / top
+	}
+}
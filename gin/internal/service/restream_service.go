@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/config"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/relay"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/secret"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxRestreamDestinations = 5
+	maxRestreamNameLength   = 50
+	maxRestreamKeyLength    = 512
+	restreamLookupTimeout   = 5 * time.Second
+)
+
+var (
+	// ErrDestinationNotFound is returned for unknown restream destinations.
+	ErrDestinationNotFound = errors.New("restream destination not found")
+	// ErrInvalidDestination is wrapped by malformed destination changes.
+	ErrInvalidDestination = errors.New("invalid restream destination")
+)
+
+// RestreamDestinationStatus is a destination with the state of its relay
+// while the owner is live.
+type RestreamDestinationStatus struct {
+	models.RestreamDestination
+	Status *relay.Status `json:"status,omitempty"`
+}
+
+// RestreamInput describes a new destination. Enabled defaults to true.
+type RestreamInput struct {
+	Name    string
+	URL     string
+	Key     string
+	Enabled *bool
+}
+
+// RestreamUpdate changes the non-nil fields of a destination.
+type RestreamUpdate struct {
+	Name    *string
+	URL     *string
+	Key     *string
+	Enabled *bool
+}
+
+// newRestreamer returns the relay manager and the box destination keys
+// are sealed in. Relays pull the stream back from the configured restream
+// source (the RTMP application, default rtmp://rtmp:1935/live or the
+// built-in ingest) with ffmpeg. Keys are encrypted with the restream
+// secret, falling back to the JWT secret, which config.Validate requires.
+func newRestreamer(cfg *config.Config) (*relay.Manager, *secret.Box) {
+	source := cfg.Ingest.RestreamSource
+	if source == "" {
+		source = "rtmp://rtmp:1935/live"
+		if _, port, err := net.SplitHostPort(cfg.Ingest.RTMPAddr); err == nil {
+			source = "rtmp://127.0.0.1:" + port + "/" + ingestApp // built-in ingest
+		}
+	}
+	key := []byte(cfg.Auth.RestreamSecret)
+	if len(key) == 0 {
+		key = []byte(cfg.Auth.JWTSecret)
+	}
+	return relay.NewManager(source, cfg.Ingest.FFmpegPath), secret.NewBox(key)
+}
+
+// ListRestreamDestinations returns the user's destinations with the
+// status of any running relay.
+func (svc *Service) ListRestreamDestinations(ctx context.Context, userID primitive.ObjectID) ([]RestreamDestinationStatus, error) {
+	user, err := svc.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	dests, err := svc.store.Restreams.List(ctx, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	statuses := svc.relays.Status(user.Username)
+	out := make([]RestreamDestinationStatus, len(dests))
+	for i, d := range dests {
+		out[i] = RestreamDestinationStatus{RestreamDestination: d}
+		if st, ok := statuses[d.ID.Hex()]; ok {
+			out[i].Status = &st
+		}
+	}
+	return out, nil
+}
+
+// CreateRestreamDestination adds a destination. If the user is live and it
+// is enabled, forwarding starts right away.
+func (svc *Service) CreateRestreamDestination(ctx context.Context, userID primitive.ObjectID, in RestreamInput) (*models.RestreamDestination, error) {
+	name, err := cleanRestreamName(in.Name)
+	if err != nil {
+		return nil, err
+	}
+	u, err := cleanRestreamURL(ctx, in.URL)
+	if err != nil {
+		return nil, err
+	}
+	cipher, hint, err := svc.sealRestreamKey(in.Key)
+	if err != nil {
+		return nil, err
+	}
+	n, err := svc.store.Restreams.Count(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if n >= maxRestreamDestinations {
+		return nil, fmt.Errorf("%w: at most %d destinations", ErrInvalidDestination, maxRestreamDestinations)
+	}
+
+	now := time.Now().UTC()
+	d := &models.RestreamDestination{
+		UserID:    userID,
+		Name:      name,
+		URL:       u,
+		KeyCipher: cipher,
+		KeyHint:   hint,
+		Enabled:   in.Enabled == nil || *in.Enabled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := svc.store.Restreams.Create(ctx, d); err != nil {
+		return nil, err
+	}
+	svc.syncRestream(ctx, d)
+	return d, nil
+}
+
+// UpdateRestreamDestination edits a destination and restarts, starts or
+// stops its relay to match when the user is live.
+func (svc *Service) UpdateRestreamDestination(ctx context.Context, userID, id primitive.ObjectID, in RestreamUpdate) (*models.RestreamDestination, error) {
+	u := repo.RestreamDestinationUpdate{Enabled: in.Enabled}
+	if in.Name != nil {
+		name, err := cleanRestreamName(*in.Name)
+		if err != nil {
+			return nil, err
+		}
+		u.Name = &name
+	}
+	if in.URL != nil {
+		url, err := cleanRestreamURL(ctx, *in.URL)
+		if err != nil {
+			return nil, err
+		}
+		u.URL = &url
+	}
+	if in.Key != nil {
+		cipher, hint, err := svc.sealRestreamKey(*in.Key)
+		if err != nil {
+			return nil, err
+		}
+		u.KeyCipher, u.KeyHint = &cipher, &hint
+	}
+
+	d, err := svc.store.Restreams.Update(ctx, userID, id, u)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrDestinationNotFound
+	}
+	svc.syncRestream(ctx, d)
+	return d, nil
+}
+
+// DeleteRestreamDestination removes a destination, stopping its relay.
+func (svc *Service) DeleteRestreamDestination(ctx context.Context, userID, id primitive.ObjectID) error {
+	deleted, err := svc.store.Restreams.Delete(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrDestinationNotFound
+	}
+	user, err := svc.store.Users.FindByID(ctx, userID)
+	if err != nil || user == nil {
+		return err
+	}
+	svc.relays.Stop(user.Username, id.Hex())
+	return nil
+}
+
+// startRestreams forwards a broadcast that just started to the owner's
+// enabled destinations. Failures are logged; they never block the publish.
+func (svc *Service) startRestreams(ctx context.Context, s *models.Stream) {
+	dests, err := svc.store.Restreams.List(ctx, s.UserID, true)
+	if err != nil {
+		log.Printf("restream: listing destinations of %s: %v", s.Username, err)
+		return
+	}
+	for _, d := range dests {
+		t, err := svc.restreamTarget(ctx, d)
+		if err != nil {
+			log.Printf("restream: destination %s of %s: %v", d.ID.Hex(), s.Username, err)
+			continue
+		}
+		svc.relays.Start(s.Username, t)
+	}
+}
+
+// stopRestreams stops forwarding a broadcast that ended.
+func (svc *Service) stopRestreams(s *models.Stream) {
+	svc.relays.StopAll(s.Username)
+}
+
+// syncRestream makes the relay of a changed destination match it while
+// its owner is live.
+func (svc *Service) syncRestream(ctx context.Context, d *models.RestreamDestination) {
+	s, err := svc.store.Streams.FindByUserID(ctx, d.UserID)
+	if err != nil || s == nil || !s.Live {
+		return
+	}
+	if !d.Enabled {
+		svc.relays.Stop(s.Username, d.ID.Hex())
+		return
+	}
+	t, err := svc.restreamTarget(ctx, *d)
+	if err != nil {
+		log.Printf("restream: destination %s of %s: %v", d.ID.Hex(), s.Username, err)
+		return
+	}
+	svc.relays.Start(s.Username, t)
+}
+
+// restreamTarget decrypts a destination into what the relay pushes to.
+// Its host is checked again, as what a name resolves to can change.
+func (svc *Service) restreamTarget(ctx context.Context, d models.RestreamDestination) (relay.Target, error) {
+	u, err := url.Parse(d.URL)
+	if err != nil {
+		return relay.Target{}, err
+	}
+	if err := checkRestreamHost(ctx, u.Hostname()); err != nil {
+		return relay.Target{}, err
+	}
+	key, err := svc.keyBox.Open(d.KeyCipher)
+	if err != nil {
+		return relay.Target{}, err
+	}
+	return relay.Target{ID: d.ID.Hex(), URL: d.URL, Key: key}, nil
+}
+
+func cleanRestreamName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxRestreamNameLength {
+		return "", fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidDestination, maxRestreamNameLength)
+	}
+	return name, nil
+}
+
+// cleanRestreamURL accepts rtmp:// and rtmps:// URLs to public hosts
+// without the key, which is stored separately.
+func cleanRestreamURL(ctx context.Context, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "rtmp" && u.Scheme != "rtmps") || u.Host == "" {
+		return "", fmt.Errorf("%w: url must be rtmp:// or rtmps://", ErrInvalidDestination)
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("%w: put credentials in the stream key, not the url", ErrInvalidDestination)
+	}
+	if err := checkRestreamHost(ctx, u.Hostname()); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(u.String(), "/"), nil
+}
+
+// nonPublicPrefixes are the special-purpose IPv4 ranges netip does not
+// classify: "this network", carrier-grade NAT and benchmarking.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// checkRestreamHost refuses hosts the relay must not push to: this
+// server, private networks, and the services of the deployment (mongo,
+// rtmp, ...), whose single-label names only resolve inside it. Names are
+// resolved, so that a public-looking name cannot point inside either.
+func checkRestreamHost(ctx context.Context, host string) error {
+	refused := fmt.Errorf("%w: url must point to a public host", ErrInvalidDestination)
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !publicAddr(ip) {
+			return refused
+		}
+		return nil
+	}
+	name := strings.ToLower(strings.TrimSuffix(host, "."))
+	if !strings.Contains(name, ".") || name == "localhost" ||
+		strings.HasSuffix(name, ".localhost") || strings.HasSuffix(name, ".local") || strings.HasSuffix(name, ".internal") {
+		return refused
+	}
+	ctx, cancel := context.WithTimeout(ctx, restreamLookupTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", name)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("%w: cannot resolve %s", ErrInvalidDestination, name)
+	}
+	for _, ip := range addrs {
+		if !publicAddr(ip) {
+			return refused
+		}
+	}
+	return nil
+}
+
+// publicAddr reports whether ip is a unicast address on the internet.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// sealRestreamKey encrypts a stream key and returns it with a hint for
+// telling keys apart in the dashboard.
+func (svc *Service) sealRestreamKey(key string) (cipher, hint string, err error) {
+	key = strings.TrimSpace(key)
+	if key == "" || len(key) > maxRestreamKeyLength || strings.ContainsAny(key, " \t\r\n") {
+		return "", "", fmt.Errorf("%w: stream key must be 1-%d characters without spaces", ErrInvalidDestination, maxRestreamKeyLength)
+	}
+	if cipher, err = svc.keyBox.Seal(key); err != nil {
+		return "", "", err
+	}
+	hint = "****"
+	if len(key) > 8 {
+		hint += key[len(key)-4:]
+	}
+	return cipher, hint, nil
+}
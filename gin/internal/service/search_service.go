@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+)
+
+// SearchRequest holds the query-string parameters of GET /search.
+type SearchRequest struct {
+	Query    string
+	Type     string // "streams" (default) or "channels"
+	LiveOnly bool
+	Category string
+	Tag      string
+	Sort     string // "relevance" (default) or "recent"
+	Cursor   string
+	Limit    int
+}
+
+// SearchResult is one page of hits plus the cursor for the next page
+// (empty when there are no more results).
+type SearchResult struct {
+	Streams    []repo.StreamHit  `json:"streams,omitempty"`
+	Channels   []repo.ChannelHit `json:"channels,omitempty"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// Search runs a stream or channel search and returns one page of results.
+func (svc *Service) Search(ctx context.Context, req SearchRequest) (*SearchResult, error) {
+	after, err := decodeSearchCursor(req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit := svc.clampLimit(req.Limit)
+	text := strings.TrimSpace(req.Query)
+
+	if req.Type == "channels" {
+		if text == "" {
+			return &SearchResult{Channels: []repo.ChannelHit{}}, nil
+		}
+		hits, err := svc.store.Search.Channels(ctx, text, after, limit)
+		if err != nil {
+			return nil, err
+		}
+		res := &SearchResult{Channels: hits}
+		if len(hits) == limit {
+			last := hits[len(hits)-1]
+			res.NextCursor = encodeCursor(&repo.SearchCursor{Score: last.Score, ID: last.ID})
+		}
+		return res, nil
+	}
+
+	hits, err := svc.store.Search.Streams(ctx, repo.StreamSearch{
+		Text:       text,
+		LiveOnly:   req.LiveOnly,
+		Category:   req.Category,
+		Tag:        normalizeTag(req.Tag),
+		SortRecent: req.Sort == "recent",
+		After:      after,
+		Limit:      limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := &SearchResult{Streams: hits}
+	if len(hits) == limit {
+		last := hits[len(hits)-1]
+		res.NextCursor = encodeCursor(&repo.SearchCursor{Score: last.Score, UpdatedAt: last.UpdatedAt, ID: last.ID})
+	}
+	return res, nil
+}
+
+// SuggestTags returns tag completions for an autocomplete box.
+func (svc *Service) SuggestTags(ctx context.Context, prefix string, limit int) ([]repo.TagCount, error) {
+	prefix = normalizeTag(prefix)
+	if prefix == "" {
+		return []repo.TagCount{}, nil
+	}
+	return svc.store.Search.SuggestTags(ctx, prefix, svc.clampLimit(limit))
+}
+
+// SuggestChannels returns username/display-name completions.
+func (svc *Service) SuggestChannels(ctx context.Context, prefix string, limit int) ([]repo.ChannelHit, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return []repo.ChannelHit{}, nil
+	}
+	return svc.store.Search.SuggestChannels(ctx, prefix, svc.clampLimit(limit))
+}
+
+func decodeSearchCursor(s string) (*repo.SearchCursor, error) {
+	var c repo.SearchCursor
+	ok, err := decodeCursor(s, &c)
+	if err != nil || !ok {
+		return nil, err
+	}
+	if c.ID.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/analytics"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/chat"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/config"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/health"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/ingest"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/notify"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/playback"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/relay"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/rtmp"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/secret"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/vod"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Service runs every feature of the API but registration, sessions and
+// stream keys over one Store. Its chat rooms, caches, rate limits and
+// background jobs are its own, so several can share a process.
+type Service struct {
+	cfg   *config.Config
+	store *repo.Store
+
+	// chatHub relays room events; the pub/sub backend can be swapped with UseChatPubSub.
+	chatHub     *chat.Hub
+	chatLimiter *chat.RateLimiter
+	// clipLimiter lets each user make a few clips at once, then one per minute.
+	clipLimiter *chat.RateLimiter
+
+	chatRulesMu    sync.Mutex
+	chatRulesCache map[primitive.ObjectID]*chatRules
+
+	// notifications pushes freshly stored notifications to SSE subscribers.
+	notifications *notify.Hub
+	// fanOutSlots limits how many went-live fan-outs run at once so a burst of
+	// big channels going live cannot monopolise the database.
+	fanOutSlots chan struct{}
+
+	// tallies coalesces the tally updates polls and predictions send to chat.
+	tallies *tallyPusher
+
+	// viewers tracks live viewers, whose IDs are anonymized with viewerKey.
+	viewers   *analytics.Tracker
+	viewerKey []byte
+
+	signer     *playback.Signer
+	relays     *relay.Manager
+	keyBox     *secret.Box
+	health     *health.Monitor
+	archiver   *vod.Archiver
+	bannedTags map[string]bool
+
+	// rtmpServer is the built-in RTMP server, if running. It is set by
+	// NewIngestServer before the HTTP server starts and read-only after.
+	rtmpServer   *rtmp.Server
+	nginxControl *ingest.NginxControl
+
+	renewals  sync.Once
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New returns a Service over store, configured by cfg, and starts
+// tracking live viewers. Close stops its background jobs.
+func New(cfg *config.Config, store *repo.Store) *Service {
+	svc := &Service{
+		cfg:            cfg,
+		store:          store,
+		chatHub:        chat.NewHub(chat.NewMemoryPubSub()),
+		chatLimiter:    chat.NewRateLimiter(chatRateInterval, chatRateBurst),
+		clipLimiter:    chat.NewRateLimiter(time.Minute, 3),
+		chatRulesCache: make(map[primitive.ObjectID]*chatRules),
+		notifications:  notify.NewHub(),
+		fanOutSlots:    make(chan struct{}, 4),
+		tallies:        &tallyPusher{interval: tallyPushInterval, pending: make(map[primitive.ObjectID]bool)},
+		viewerKey:      deriveViewerKey(cfg.Auth.JWTSecret),
+		signer:         playback.NewSigner([]byte(cfg.Auth.JWTSecret)),
+		health:         health.NewMonitor(cfg.HLS.LiveDir, healthPollInterval, healthSamples),
+		archiver:       vod.NewArchiver(cfg.HLS.LiveDir, cfg.HLS.VODDir, vodPollInterval),
+		bannedTags:     bannedTagSet(cfg.Moderation.BannedTags),
+		nginxControl:   &ingest.NginxControl{URL: cfg.Ingest.ControlURL},
+		done:           make(chan struct{}),
+	}
+	svc.relays, svc.keyBox = newRestreamer(cfg)
+	svc.viewers = analytics.NewTracker(viewerTimeout, svc.saveViewerSessions)
+	go svc.viewers.Run(viewerSweep, svc.done)
+	return svc
+}
+
+// Close stops the viewer tracker and membership renewals. It may be
+// called more than once.
+func (svc *Service) Close() {
+	svc.closeOnce.Do(func() { close(svc.done) })
+}
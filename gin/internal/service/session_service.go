@@ -8,8 +8,18 @@ import (
 	"github.com/google/uuid"
 )
 
+// SessionService issues and validates login sessions.
+type SessionService struct {
+	sessions repo.SessionRepository
+}
+
+// NewSessionService returns a SessionService over sessions.
+func NewSessionService(sessions repo.SessionRepository) *SessionService {
+	return &SessionService{sessions: sessions}
+}
+
 // CreateSession creates a new session for the given user ID and returns the UUID.
-func CreateSession(ctx context.Context, userID string) (string, error) {
+func (s *SessionService) CreateSession(ctx context.Context, userID string) (string, error) {
 	// userID comes in as a hex string; we convert it back to ObjectID for storage.
 	objID, err := repo.HexToObjectID(userID) // helper added below
 	if err != nil {
@@ -23,7 +33,7 @@ func CreateSession(ctx context.Context, userID string) (string, error) {
 		UserID: objID,
 	}
 
-	if err := repo.CreateSession(ctx, &session); err != nil {
+	if err := s.sessions.Create(ctx, &session); err != nil {
 		return "", err
 	}
 	return sid, nil
@@ -31,6 +41,6 @@ func CreateSession(ctx context.Context, userID string) (string, error) {
 
 // ValidateSession checks that a session with the given UUID exists.
 // It returns the stored Session (or nil) and any error.
-func ValidateSession(ctx context.Context, sid string) (*models.Session, error) {
-	return repo.GetSession(ctx, sid)
+func (s *SessionService) ValidateSession(ctx context.Context, sid string) (*models.Session, error) {
+	return s.sessions.Get(ctx, sid)
 }
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxAllowedViewers  = 1000
+	maxInviteUses      = 10000
+	maxInviteLifetime  = 90 * 24 * time.Hour
+	inviteCodeByteSize = 16
+)
+
+var (
+	// ErrInvalidVisibility is returned for unknown visibility modes.
+	ErrInvalidVisibility = errors.New("visibility must be public, unlisted or private")
+	// ErrInvalidAccess is wrapped by malformed allowlist and invite changes.
+	ErrInvalidAccess = errors.New("invalid access change")
+	// ErrInvalidInvite is returned for unknown, expired or used-up invites.
+	ErrInvalidInvite = errors.New("invite is invalid or expired")
+)
+
+// StreamAccess is who may watch the owner's stream.
+type StreamAccess struct {
+	Visibility string                 `json:"visibility"`
+	Allowed    []models.PublicProfile `json:"allowed"`
+	Invites    []models.StreamInvite  `json:"invites"`
+}
+
+// InviteInput limits a new invite link. Zero values mean no limit.
+type InviteInput struct {
+	MaxUses        int
+	ExpiresInHours int
+}
+
+// canWatch reports whether the viewer (NilObjectID when anonymous) may
+// watch the channel's stream and its recordings.
+func (svc *Service) canWatch(ctx context.Context, channel *models.User, viewerID primitive.ObjectID) (bool, error) {
+	s, err := svc.store.Streams.FindByUserID(ctx, channel.ID)
+	if err != nil {
+		return false, err
+	}
+	return streamAllows(s, channel.ID, viewerID), nil
+}
+
+// streamAllows applies the stream's visibility to a viewer. Public and
+// unlisted streams admit everyone; private ones their owner and allowlist.
+func streamAllows(s *models.Stream, channelID, viewerID primitive.ObjectID) bool {
+	if s == nil || s.Visibility != models.VisibilityPrivate {
+		return true
+	}
+	if viewerID.IsZero() {
+		return false
+	}
+	if viewerID == channelID {
+		return true
+	}
+	for _, id := range s.AllowedUserIDs {
+		if id == viewerID {
+			return true
+		}
+	}
+	return false
+}
+
+// hiddenVisibilities lists the visibilities of a channel's recordings that
+// the viewer must not see listed: none for the owner, unlisted ones for
+// everybody else, and private ones for viewers not on the allowlist.
+func (svc *Service) hiddenVisibilities(ctx context.Context, channel *models.User, viewerID primitive.ObjectID) ([]string, error) {
+	if viewerID == channel.ID {
+		return nil, nil
+	}
+	hidden := []string{models.VisibilityUnlisted}
+	ok, err := svc.canSeePrivate(ctx, channel.ID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		hidden = append(hidden, models.VisibilityPrivate)
+	}
+	return hidden, nil
+}
+
+// canSeePrivate reports whether the viewer may see the channel's private
+// recordings: the owner and the allowlist may, whatever the stream's
+// current visibility, so recordings stay private when a channel goes public.
+func (svc *Service) canSeePrivate(ctx context.Context, channelID, viewerID primitive.ObjectID) (bool, error) {
+	if viewerID.IsZero() {
+		return false, nil
+	}
+	if viewerID == channelID {
+		return true, nil
+	}
+	s, err := svc.store.Streams.FindByUserID(ctx, channelID)
+	if err != nil || s == nil {
+		return false, err
+	}
+	probe := models.Stream{Visibility: models.VisibilityPrivate, AllowedUserIDs: s.AllowedUserIDs}
+	return streamAllows(&probe, channelID, viewerID), nil
+}
+
+// SetStreamVisibility changes the owner's stream visibility.
+func (svc *Service) SetStreamVisibility(ctx context.Context, userID primitive.ObjectID, visibility string) (*models.Stream, error) {
+	switch visibility {
+	case models.VisibilityPublic, models.VisibilityUnlisted, models.VisibilityPrivate:
+	default:
+		return nil, ErrInvalidVisibility
+	}
+	user, err := svc.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrStreamNotFound
+	}
+	s, err := svc.store.Streams.SetVisibility(ctx, userID, user.Username, visibility)
+	if err != nil {
+		return nil, err
+	}
+	// The broadcast under way, and its recording, follow the stream.
+	if s.Live && !s.BroadcastID.IsZero() {
+		if err := svc.store.Broadcasts.SetVisibility(ctx, s.BroadcastID, visibility); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// GetStreamAccess returns the owner's visibility, allowlist and invites.
+func (svc *Service) GetStreamAccess(ctx context.Context, userID primitive.ObjectID) (*StreamAccess, error) {
+	s, err := svc.store.Streams.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	access := &StreamAccess{Visibility: models.VisibilityPublic}
+	var allowed []primitive.ObjectID
+	if s != nil {
+		if s.Visibility != "" {
+			access.Visibility = s.Visibility
+		}
+		allowed = s.AllowedUserIDs
+	}
+	if access.Allowed, err = svc.store.Users.ListPublicProfiles(ctx, allowed); err != nil {
+		return nil, err
+	}
+	if access.Invites, err = svc.store.StreamInvites.List(ctx, userID); err != nil {
+		return nil, err
+	}
+	return access, nil
+}
+
+// AllowStreamViewer adds a user to the owner's allowlist.
+func (svc *Service) AllowStreamViewer(ctx context.Context, userID primitive.ObjectID, username string) error {
+	owner, viewer, err := svc.resolveAccessChange(ctx, userID, username)
+	if err != nil {
+		return err
+	}
+	s, err := svc.store.Streams.FindByUserID(ctx, owner.ID)
+	if err != nil {
+		return err
+	}
+	if s != nil && len(s.AllowedUserIDs) >= maxAllowedViewers {
+		return fmt.Errorf("%w: the allowlist holds at most %d users", ErrInvalidAccess, maxAllowedViewers)
+	}
+	_, err = svc.store.Streams.AddViewer(ctx, owner.ID, owner.Username, viewer.ID)
+	return err
+}
+
+// DisallowStreamViewer removes a user from the owner's allowlist. Tokens
+// already issued to them stop being renewed.
+func (svc *Service) DisallowStreamViewer(ctx context.Context, userID primitive.ObjectID, username string) error {
+	owner, viewer, err := svc.resolveAccessChange(ctx, userID, username)
+	if err != nil {
+		return err
+	}
+	_, err = svc.store.Streams.RemoveViewer(ctx, owner.ID, owner.Username, viewer.ID)
+	return err
+}
+
+func (svc *Service) resolveAccessChange(ctx context.Context, userID primitive.ObjectID, username string) (owner, viewer *models.User, err error) {
+	owner, err = svc.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if owner == nil {
+		return nil, nil, ErrStreamNotFound
+	}
+	viewer, err = svc.store.Users.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, nil, err
+	}
+	if viewer == nil {
+		return nil, nil, ErrUserNotFound
+	}
+	if viewer.ID == owner.ID {
+		return nil, nil, fmt.Errorf("%w: you can always watch your own stream", ErrInvalidAccess)
+	}
+	return owner, viewer, nil
+}
+
+// CreateStreamInvite creates an invite link to the owner's stream.
+func (svc *Service) CreateStreamInvite(ctx context.Context, userID primitive.ObjectID, in InviteInput) (*models.StreamInvite, error) {
+	if in.MaxUses < 0 || in.MaxUses > maxInviteUses {
+		return nil, fmt.Errorf("%w: max_uses must be 0-%d", ErrInvalidAccess, maxInviteUses)
+	}
+	lifetime := time.Duration(in.ExpiresInHours) * time.Hour
+	if lifetime < 0 || lifetime > maxInviteLifetime {
+		return nil, fmt.Errorf("%w: invites last at most %d days", ErrInvalidAccess, int(maxInviteLifetime.Hours()/24))
+	}
+	code, err := newInviteCode()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	inv := &models.StreamInvite{
+		ChannelID: userID,
+		Code:      code,
+		MaxUses:   in.MaxUses,
+		CreatedAt: now,
+	}
+	if lifetime > 0 {
+		expires := now.Add(lifetime)
+		inv.ExpiresAt = &expires
+	}
+	if err := svc.store.StreamInvites.Create(ctx, inv); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// RevokeStreamInvite deletes one of the owner's invites.
+func (svc *Service) RevokeStreamInvite(ctx context.Context, userID, inviteID primitive.ObjectID) (bool, error) {
+	return svc.store.StreamInvites.Delete(ctx, userID, inviteID)
+}
+
+// RedeemStreamInvite adds the viewer to the channel's allowlist using an
+// invite code, so they keep access after the link expires.
+func (svc *Service) RedeemStreamInvite(ctx context.Context, viewerID primitive.ObjectID, channelName, code string) error {
+	channel, err := svc.store.Users.FindByUsername(ctx, channelName)
+	if err != nil {
+		return err
+	}
+	if channel == nil {
+		return ErrStreamNotFound
+	}
+	if viewerID == channel.ID {
+		return nil
+	}
+	_, err = svc.redeemInvite(ctx, channel, viewerID, code)
+	return err
+}
+
+// redeemInvite uses up one use of the invite code. Logged-in viewers are
+// added to the allowlist; anonymous ones (NilObjectID) only get the invite
+// back, to be carried in their playback token.
+func (svc *Service) redeemInvite(ctx context.Context, channel *models.User, viewerID primitive.ObjectID, code string) (*models.StreamInvite, error) {
+	inv, err := svc.store.StreamInvites.Use(ctx, channel.ID, code, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if inv == nil {
+		return nil, ErrInvalidInvite
+	}
+	if !viewerID.IsZero() {
+		if _, err := svc.store.Streams.AddViewer(ctx, channel.ID, channel.Username, viewerID); err != nil {
+			return nil, err
+		}
+	}
+	return inv, nil
+}
+
+// inviteStillValid reports whether an invite an anonymous viewer was
+// admitted with has neither been revoked nor expired.
+func (svc *Service) inviteStillValid(ctx context.Context, channelID primitive.ObjectID, inviteHex string) (bool, error) {
+	id, err := primitive.ObjectIDFromHex(inviteHex)
+	if err != nil {
+		return false, nil
+	}
+	inv, err := svc.store.StreamInvites.FindByID(ctx, id)
+	if err != nil || inv == nil {
+		return false, err
+	}
+	if inv.ChannelID != channelID {
+		return false, nil
+	}
+	return inv.ExpiresAt == nil || time.Now().Before(*inv.ExpiresAt), nil
+}
+
+func newInviteCode() (string, error) {
+	b := make([]byte, inviteCodeByteSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
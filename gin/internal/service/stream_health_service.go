@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/health"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	healthPollInterval = 2 * time.Second
+	// healthSamples keeps about five minutes of 3s segments.
+	healthSamples = 100
+	// healthRecent is how many of the latest samples the summary averages.
+	healthRecent = 5
+
+	maxKeyframeInterval = 4.0 // seconds
+	maxIngestBitrate    = 8000
+	minIngestBitrate    = 300
+	minIngestFPS        = 20
+	maxIngestHeight     = 1080
+	// stalledAfter is how long without a new segment counts as stalled.
+	stalledAfter = 4 * hlsFragment
+)
+
+// StreamHealth is how the owner's live ingest is doing: a summary of the
+// last few segments, the recent time series and what looks wrong.
+type StreamHealth struct {
+	Live             bool            `json:"live"`
+	StartedAt        *time.Time      `json:"started_at,omitempty"`
+	UptimeSeconds    int64           `json:"uptime_seconds"`
+	VideoCodec       string          `json:"video_codec,omitempty"`
+	Profile          string          `json:"profile,omitempty"`
+	Width            int             `json:"width,omitempty"`
+	Height           int             `json:"height,omitempty"`
+	FPS              float64         `json:"fps"`
+	BitrateKbps      int             `json:"bitrate_kbps"`
+	KeyframeInterval float64         `json:"keyframe_interval"`
+	DroppedFrames    int             `json:"dropped_frames"`
+	AudioCodec       string          `json:"audio_codec,omitempty"`
+	AudioSampleRate  int             `json:"audio_sample_rate,omitempty"`
+	AudioChannels    int             `json:"audio_channels,omitempty"`
+	Series           []health.Sample `json:"series"`
+	Warnings         []HealthWarning `json:"warnings"`
+}
+
+// HealthWarning is a likely misconfiguration or connection problem.
+type HealthWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// startHealthMonitor begins measuring a broadcast that just started.
+func (svc *Service) startHealthMonitor(s *models.Stream) {
+	svc.health.Start(s.Username)
+}
+
+// stopHealthMonitor forgets the measurements of a broadcast that ended.
+func (svc *Service) stopHealthMonitor(s *models.Stream) {
+	svc.health.Stop(s.Username)
+}
+
+// GetStreamHealth reports on the user's live ingest. Streams that went
+// live before a restart are picked up here, so the series may start late.
+func (svc *Service) GetStreamHealth(ctx context.Context, userID primitive.ObjectID) (*StreamHealth, error) {
+	s, err := svc.store.Streams.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	h := &StreamHealth{Series: []health.Sample{}, Warnings: []HealthWarning{}}
+	if s == nil || !s.Live {
+		return h, nil
+	}
+	h.Live, h.StartedAt = true, s.StartedAt
+	if s.StartedAt != nil {
+		h.UptimeSeconds = int64(time.Since(*s.StartedAt).Seconds())
+	}
+
+	mon := svc.health
+	snap, ok := mon.Snapshot(s.Username)
+	if !ok {
+		mon.Start(s.Username)
+		return h, nil
+	}
+	h.VideoCodec, h.Profile, h.Width, h.Height = snap.VideoCodec, snap.Profile, snap.Width, snap.Height
+	h.AudioCodec, h.AudioSampleRate, h.AudioChannels = snap.AudioCodec, snap.SampleRate, snap.Channels
+	h.Series = snap.Samples
+	summarizeHealth(h)
+	h.Warnings = healthWarnings(h, time.Now())
+	return h, nil
+}
+
+// summarizeHealth averages the latest samples into the headline numbers.
+func summarizeHealth(h *StreamHealth) {
+	recent := h.Series[max(0, len(h.Series)-healthRecent):]
+	if len(recent) == 0 {
+		return
+	}
+	var bits, frames, seconds float64
+	for _, s := range recent {
+		bits += float64(s.BitrateKbps) * s.Duration
+		frames += s.FPS * s.Duration
+		seconds += s.Duration
+		h.KeyframeInterval = max(h.KeyframeInterval, s.KeyframeInterval)
+		h.DroppedFrames += s.DroppedFrames
+	}
+	h.BitrateKbps = int(bits / seconds)
+	h.FPS = math.Round(frames/seconds*100) / 100
+}
+
+func healthWarnings(h *StreamHealth, now time.Time) []HealthWarning {
+	warnings := []HealthWarning{}
+	add := func(code, format string, args ...any) {
+		warnings = append(warnings, HealthWarning{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+	if len(h.Series) == 0 {
+		if h.UptimeSeconds > int64(stalledAfter.Seconds()) {
+			add("no_segments", "No video has arrived yet. Check that your encoder sends H.264 with AAC audio.")
+		}
+		return warnings
+	}
+
+	if last := h.Series[len(h.Series)-1]; now.Sub(last.At) > stalledAfter {
+		add("stalled", "No new video for %.0f seconds. Your connection to the server may have dropped.", now.Sub(last.At).Seconds())
+	}
+	switch h.VideoCodec {
+	case "h264":
+	case "":
+		add("no_video", "The stream has no video track.")
+	default:
+		add("video_codec", "Video is %s; browsers can only play H.264. Switch your encoder to x264 or an H.264 hardware encoder.", h.VideoCodec)
+	}
+	if h.AudioCodec == "" {
+		add("no_audio", "The stream has no audio track. Viewers will hear nothing.")
+	}
+	if h.KeyframeInterval > maxKeyframeInterval {
+		add("keyframe_interval", "Keyframe interval is %.1fs. Set it to 2 seconds in your encoder for smooth playback and quick joins.", h.KeyframeInterval)
+	}
+	if h.VideoCodec != "" && h.BitrateKbps > maxIngestBitrate {
+		add("bitrate_high", "Bitrate is %d kbps. Many viewers cannot keep up above %d kbps.", h.BitrateKbps, maxIngestBitrate)
+	}
+	if h.VideoCodec != "" && h.BitrateKbps > 0 && h.BitrateKbps < minIngestBitrate {
+		add("bitrate_low", "Bitrate is only %d kbps, so the picture will look poor.", h.BitrateKbps)
+	}
+	if h.VideoCodec != "" && h.FPS > 0 && h.FPS < minIngestFPS {
+		add("fps_low", "Frame rate is %.1f fps. Your encoder may be overloaded.", h.FPS)
+	}
+	if h.Height > maxIngestHeight {
+		add("resolution_high", "Resolution is %dx%d. Send at most 1080p.", h.Width, h.Height)
+	}
+	if h.DroppedFrames > 0 {
+		add("dropped_frames", "%d frames went missing in the last few seconds. Your encoder or upload may be overloaded.", h.DroppedFrames)
+	}
+	return warnings
+}
@@ -8,9 +8,23 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// StreamKeyService hands out and rotates stream keys.
+type StreamKeyService struct {
+	keys repo.StreamKeyRepository
+	// endBroadcast cuts off a broadcast still running on a rotated key;
+	// nil when there is no ingest to talk to.
+	endBroadcast func(ctx context.Context, userID primitive.ObjectID) error
+}
+
+// NewStreamKeyService returns a StreamKeyService over keys. endBroadcast,
+// usually Service.DropPublisher, is called after a key is rotated and may be nil.
+func NewStreamKeyService(keys repo.StreamKeyRepository, endBroadcast func(context.Context, primitive.ObjectID) error) *StreamKeyService {
+	return &StreamKeyService{keys: keys, endBroadcast: endBroadcast}
+}
+
 // GetOrCreateStreamKey returns the current key, creating one if it does not exist.
-func GetOrCreateStreamKey(ctx context.Context, userID primitive.ObjectID) (*models.StreamKey, error) {
-	key, err := repo.FindStreamKeyByUserID(ctx, userID)
+func (s *StreamKeyService) GetOrCreateStreamKey(ctx context.Context, userID primitive.ObjectID) (*models.StreamKey, error) {
+	key, err := s.keys.FindByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -21,24 +35,33 @@ func GetOrCreateStreamKey(ctx context.Context, userID primitive.ObjectID) (*mode
 	newKey := &models.StreamKey{
 		UserID: userID,
 	}
-	if err := repo.CreateStreamKey(ctx, newKey); err != nil {
+	if err := s.keys.Create(ctx, newKey); err != nil {
 		return nil, err
 	}
 	return newKey, nil
 }
 
 // ReplaceStreamKey **rotates** the key: it deletes the old one (if any) and
-// creates a fresh key, returning the newly generated key.
-func ReplaceStreamKey(ctx context.Context, userID primitive.ObjectID) (*models.StreamKey, error) {
+// creates a fresh key, returning the newly generated key. A broadcast
+// running on the old key is cut off; if that fails the key stays rotated
+// and the error is returned.
+func (s *StreamKeyService) ReplaceStreamKey(ctx context.Context, userID primitive.ObjectID) (*models.StreamKey, error) {
 	// 1️⃣ Delete any existing key – ignore "not found" errors.
-	_ = repo.DeleteStreamKeyByUserID(ctx, userID)
+	_ = s.keys.DeleteByUserID(ctx, userID)
 
 	// 2️⃣ Create a brand‑new key.
 	newKey := &models.StreamKey{
 		UserID: userID,
 	}
-	if err := repo.CreateStreamKey(ctx, newKey); err != nil {
+	if err := s.keys.Create(ctx, newKey); err != nil {
 		return nil, err
 	}
+
+	// 3️⃣ Drop the publisher still using the old key.
+	if s.endBroadcast != nil {
+		if err := s.endBroadcast(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
 	return newKey, nil
 }
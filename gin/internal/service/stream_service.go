@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrStreamNotFound is returned when a stream or its owner does not exist.
+var ErrStreamNotFound = errors.New("stream not found")
+
+// StreamDetailsInput is what a broadcaster can edit about their stream.
+type StreamDetailsInput struct {
+	Title       string
+	Description string
+	Category    string
+	Tags        []string
+}
+
+// GetChannelStream returns the stream of a channel as seen by viewerID
+// (NilObjectID when anonymous), or nil if the channel has never set one up
+// or it is private and the viewer is not allowed in.
+func (svc *Service) GetChannelStream(ctx context.Context, username string, viewerID primitive.ObjectID) (*models.Stream, error) {
+	s, err := svc.store.Streams.FindByUsername(ctx, username)
+	if err != nil || s == nil {
+		return nil, err
+	}
+	if !streamAllows(s, s.UserID, viewerID) {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// GetStreamForUser returns the authenticated user's own stream (or nil).
+func (svc *Service) GetStreamForUser(ctx context.Context, userID primitive.ObjectID) (*models.Stream, error) {
+	return svc.store.Streams.FindByUserID(ctx, userID)
+}
+
+// UpdateStreamDetails stores new title/description/category/tags for the
+// user's stream, creating the stream document on first use. Title and
+// category changes while live are kept in the broadcast's history.
+func (svc *Service) UpdateStreamDetails(ctx context.Context, userID primitive.ObjectID, in StreamDetailsInput) (*models.Stream, error) {
+	user, err := svc.store.Users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrStreamNotFound
+	}
+
+	category := strings.TrimSpace(in.Category)
+	if err := svc.validateCategory(ctx, category); err != nil {
+		return nil, err
+	}
+	tags, err := svc.NormalizeTags(in.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	prev, err := svc.store.Streams.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s, err := svc.store.Streams.UpsertDetails(ctx, userID, user.Username, repo.StreamDetails{
+		Title:       strings.TrimSpace(in.Title),
+		Description: strings.TrimSpace(in.Description),
+		Category:    category,
+		Tags:        tags,
+	})
+	if err != nil {
+		return nil, err
+	}
+	svc.recordBroadcastChanges(ctx, prev, s)
+	return s, nil
+}
+
+// StartPublish is called when a broadcaster connects to the RTMP server.
+// It resolves the stream key to its owner, marks their stream live and
+// starts recording and restreaming the broadcast.
+// A nil stream means the key is unknown and the publish must be rejected,
+// as must ErrStreamSuspended.
+func (svc *Service) StartPublish(ctx context.Context, streamKey string) (*models.Stream, error) {
+	keyID, err := primitive.ObjectIDFromHex(streamKey)
+	if err != nil {
+		return nil, nil
+	}
+	key, err := svc.store.StreamKeys.FindByID(ctx, keyID)
+	if err != nil || key == nil {
+		return nil, err
+	}
+	user, err := svc.store.Users.FindByID(ctx, key.UserID)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if user.StreamSuspended(now) {
+		return nil, ErrStreamSuspended
+	}
+
+	prev, err := svc.store.Streams.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	s, err := svc.store.Streams.SetLive(ctx, user.ID, user.Username, true, now)
+	if err != nil {
+		return nil, err
+	}
+	// Unlisted and private broadcasts are not announced to followers.
+	if s.Listed() && shouldAnnounce(prev, now) {
+		svc.announceWentLive(s)
+	}
+	svc.startRecording(ctx, s)
+	svc.startRestreams(ctx, s)
+	svc.startHealthMonitor(s)
+	svc.startBroadcast(ctx, s)
+	svc.startAnalytics(s)
+	return s, nil
+}
+
+// shouldAnnounce reports whether going live now deserves a follower
+// notification: not when already live, nor when reconnecting shortly
+// after a dropped broadcast.
+func shouldAnnounce(prev *models.Stream, now time.Time) bool {
+	if prev == nil {
+		return true
+	}
+	if prev.Live {
+		return false
+	}
+	return prev.EndedAt == nil || now.Sub(*prev.EndedAt) > liveReconnectGrace
+}
+
+// EndPublish marks the channel's stream offline, stops its restreams,
+// finalizes its recording and launches its pending raid. name is the RTMP
+// stream name, which is the username once StartPublish has redirected the
+// publish.
+func (svc *Service) EndPublish(ctx context.Context, name string) (*models.Stream, error) {
+	user, err := svc.store.Users.FindByUsername(ctx, name)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	s, err := svc.store.Streams.SetLive(ctx, user.ID, user.Username, false, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	svc.stopRestreams(s)
+	svc.stopHealthMonitor(s)
+	svc.finishRecording(ctx, s)
+	svc.endInteractions(ctx, s)
+	svc.launchRaid(ctx, s)
+	svc.finishBroadcast(ctx, s)
+	svc.finishAnalytics(s)
+	return s, nil
+}
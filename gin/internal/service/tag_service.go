@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	maxTagsPerStream = 10
+	minTagLength     = 2
+	maxTagLength     = 25
+)
+
+// ErrInvalidTag is wrapped by every tag validation failure.
+var ErrInvalidTag = errors.New("invalid tag")
+
+// defaultBannedTags is extended with the configured moderation.banned_tags.
+var defaultBannedTags = []string{"nsfw", "porn", "xxx", "gore"}
+
+// bannedTagSet returns the default banned tags plus the normalized extra ones.
+func bannedTagSet(extra []string) map[string]bool {
+	banned := make(map[string]bool)
+	for _, t := range defaultBannedTags {
+		banned[t] = true
+	}
+	for _, t := range extra {
+		if t = normalizeTag(t); t != "" {
+			banned[t] = true
+		}
+	}
+	return banned
+}
+
+// normalizeTag lower-cases a tag, strips a leading '#', and turns runs of
+// whitespace or underscores into single hyphens. It does not validate.
+func normalizeTag(raw string) string {
+	t := strings.ToLower(strings.TrimSpace(raw))
+	t = strings.TrimLeft(t, "#")
+
+	var b strings.Builder
+	sep := false
+	for _, r := range t {
+		if unicode.IsSpace(r) || r == '_' || r == '-' {
+			sep = true
+			continue
+		}
+		if sep && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		sep = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// NormalizeTags normalises each tag, drops empties and duplicates, and
+// enforces the character set, length, count and banned-list rules.
+func (svc *Service) NormalizeTags(raw []string) ([]string, error) {
+	out := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, r := range raw {
+		t := normalizeTag(r)
+		if t == "" || seen[t] {
+			continue
+		}
+		if n := len([]rune(t)); n < minTagLength || n > maxTagLength {
+			return nil, fmt.Errorf("%w: %q must be %d-%d characters", ErrInvalidTag, t, minTagLength, maxTagLength)
+		}
+		for _, c := range t {
+			if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '-' {
+				return nil, fmt.Errorf("%w: %q may only contain letters, digits and hyphens", ErrInvalidTag, t)
+			}
+		}
+		if svc.bannedTags[t] {
+			return nil, fmt.Errorf("%w: %q is not allowed", ErrInvalidTag, t)
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	if len(out) > maxTagsPerStream {
+		return nil, fmt.Errorf("%w: at most %d tags are allowed", ErrInvalidTag, maxTagsPerStream)
+	}
+	return out, nil
+}
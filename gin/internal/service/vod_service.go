@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/playback"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/repo"
+	"github.com/CSBOWMA/bigredhacks2025/gin/internal/vod"
+	"github.com/CSBOWMA/bigredhacks2025/gin/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxVideoTitleLength = 140
+	// vodPollInterval must stay below the RTMP server's hls_playlist_length
+	// so no segment rotates out unseen.
+	vodPollInterval = 2 * time.Second
+)
+
+var (
+	// ErrVideoNotFound is returned for unknown videos and files.
+	ErrVideoNotFound = errors.New("video not found")
+	// ErrVideoRecording is returned when deleting a video that is still live.
+	ErrVideoRecording = errors.New("video is still recording")
+	// ErrInvalidVideo is wrapped by malformed video edits.
+	ErrInvalidVideo = errors.New("invalid video")
+)
+
+// VideoPage is one page of a channel's videos.
+type VideoPage struct {
+	Videos     []models.Video `json:"videos"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// liveHLSDir is where the RTMP server writes live HLS (its hls_path).
+func (svc *Service) liveHLSDir() string {
+	return svc.cfg.HLS.LiveDir
+}
+
+// startRecording creates the video of the broadcast that just started and
+// begins archiving it. Failures are logged; they never block the publish.
+func (svc *Service) startRecording(ctx context.Context, s *models.Stream) {
+	title := s.Title
+	if title == "" {
+		title = fmt.Sprintf("Broadcast of %s", s.StartedAt.Format("Jan 2, 2006"))
+	}
+	v := &models.Video{
+		UserID:      s.UserID,
+		Username:    s.Username,
+		BroadcastID: s.BroadcastID,
+		Title:       title,
+		Category:    s.Category,
+		Tags:        s.Tags,
+		Status:      models.VideoRecording,
+		Visibility:  s.Visibility,
+		// Subscriber-only broadcasts stay so when recorded.
+		SubscribersOnly: s.SubscribersOnly,
+		StartedAt:       *s.StartedAt,
+		UpdatedAt:       time.Now().UTC(),
+	}
+	if err := svc.store.Videos.Create(ctx, v); err != nil {
+		log.Printf("vod: failed to create video for %s: %v", s.Username, err)
+		return
+	}
+	if err := svc.archiver.Start(s.Username, v.ID.Hex()); err != nil {
+		log.Printf("vod: failed to start recording %s: %v", s.Username, err)
+	}
+}
+
+// finishRecording stops archiving the stream's last broadcast and
+// finalizes its video. Broadcasts that produced no segments are dropped.
+func (svc *Service) finishRecording(ctx context.Context, s *models.Stream) {
+	if s.BroadcastID.IsZero() {
+		return
+	}
+	v, err := svc.store.Videos.FindByBroadcastID(ctx, s.BroadcastID)
+	if err != nil || v == nil || v.Status != models.VideoRecording {
+		if err != nil {
+			log.Printf("vod: failed to load video of %s: %v", s.Username, err)
+		}
+		return
+	}
+
+	sum, err := svc.archiver.Stop(s.Username)
+	if errors.Is(err, vod.ErrNotRecording) {
+		sum, err = svc.archiver.Finalize(v.ID.Hex())
+	}
+	if err != nil {
+		log.Printf("vod: failed to finalize %s: %v", v.ID.Hex(), err)
+		return
+	}
+	svc.finishVideo(ctx, v, sum, s.EndedAt)
+}
+
+func (svc *Service) finishVideo(ctx context.Context, v *models.Video, sum vod.Summary, endedAt *time.Time) {
+	if sum.Segments == 0 {
+		if err := svc.store.Videos.Delete(ctx, v.ID); err != nil {
+			log.Printf("vod: failed to drop empty video %s: %v", v.ID.Hex(), err)
+		}
+		_ = svc.archiver.Remove(v.ID.Hex())
+		return
+	}
+	end := time.Now().UTC()
+	if endedAt != nil {
+		end = *endedAt
+	}
+	if err := svc.store.Videos.Finish(ctx, v.ID, sum.Duration, sum.Segments, end); err != nil {
+		log.Printf("vod: failed to mark %s ready: %v", v.ID.Hex(), err)
+	}
+}
+
+// RecoverRecordings picks up recordings interrupted by a restart: those
+// whose broadcast is still live resume archiving, the rest are finalized
+// from what reached the disk.
+func (svc *Service) RecoverRecordings(ctx context.Context) error {
+	videos, err := svc.store.Videos.ListByStatus(ctx, models.VideoRecording)
+	if err != nil {
+		return err
+	}
+	for i := range videos {
+		v := &videos[i]
+		s, err := svc.store.Streams.FindByUserID(ctx, v.UserID)
+		if err != nil {
+			return err
+		}
+		if s != nil && s.Live && s.BroadcastID == v.BroadcastID {
+			if err := svc.archiver.Start(s.Username, v.ID.Hex()); err != nil {
+				log.Printf("vod: failed to resume recording %s: %v", v.ID.Hex(), err)
+			}
+			continue
+		}
+		sum, err := svc.archiver.Finalize(v.ID.Hex())
+		if err != nil {
+			log.Printf("vod: failed to finalize %s: %v", v.ID.Hex(), err)
+			continue
+		}
+		var endedAt *time.Time
+		if s != nil {
+			endedAt = s.EndedAt
+		}
+		svc.finishVideo(ctx, v, sum, endedAt)
+	}
+	return nil
+}
+
+// ListVideos returns a page of a channel's recordings, newest first, as
+// the viewer may see them: unlisted ones only for the owner, private ones
+// for the owner and allowlist.
+func (svc *Service) ListVideos(ctx context.Context, username string, viewerID primitive.ObjectID, cursor string, limit int) (*VideoPage, error) {
+	user, err := svc.store.Users.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	after, err := decodeTimeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	hidden, err := svc.hiddenVisibilities(ctx, user, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	limit = svc.clampLimit(limit)
+	videos, err := svc.store.Videos.ListByUser(ctx, user.ID, hidden, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	page := &VideoPage{Videos: videos}
+	if len(videos) == limit {
+		last := videos[len(videos)-1]
+		page.NextCursor = encodeCursor(repo.TimeCursor{At: last.StartedAt, ID: last.ID})
+	}
+	return page, nil
+}
+
+// GetVideo returns a video's metadata. Private videos look missing to
+// viewers who may not see them.
+func (svc *Service) GetVideo(ctx context.Context, id, viewerID primitive.ObjectID) (*models.Video, error) {
+	v, err := svc.findVideo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if v.Private() {
+		ok, err := svc.canSeePrivate(ctx, v.UserID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrVideoNotFound
+		}
+	}
+	return v, nil
+}
+
+func (svc *Service) findVideo(ctx context.Context, id primitive.ObjectID) (*models.Video, error) {
+	v, err := svc.store.Videos.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, ErrVideoNotFound
+	}
+	return v, nil
+}
+
+// VideoFilePath resolves a playlist or segment of a video to a path on
+// disk. Videos still recording serve a growing EVENT playlist. Private and
+// subscriber-only videos need a playback token for the channel;
+// playlistToken is then the token the playlist's URIs must carry, which
+// is good for this video alone.
+func (svc *Service) VideoFilePath(ctx context.Context, id primitive.ObjectID, file, token string) (path, playlistToken string, v *models.Video, err error) {
+	path = svc.archiver.Path(id.Hex(), file)
+	if path == "" {
+		return "", "", nil, ErrVideoNotFound
+	}
+	if v, err = svc.findVideo(ctx, id); err != nil {
+		return "", "", nil, err
+	}
+	if access := (recordingAccess{private: v.Private(), subscribersOnly: v.SubscribersOnly}); access.restricted() {
+		ttl := recordingTokenTTL(v.DurationSeconds, v.Status == models.VideoRecording)
+		if playlistToken, err = svc.authorizeRecordingFile(ctx, v.UserID, v.Username, playback.VideoResource(id.Hex()), file, token, access, ttl); err != nil {
+			return "", "", nil, err
+		}
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return "", "", nil, ErrVideoNotFound
+		}
+		return "", "", nil, err
+	}
+	return path, playlistToken, v, nil
+}
+
+// UpdateVideoTitle retitles one of the user's videos.
+func (svc *Service) UpdateVideoTitle(ctx context.Context, userID, id primitive.ObjectID, title string) (*models.Video, error) {
+	title = strings.TrimSpace(title)
+	if title == "" || utf8.RuneCountInString(title) > maxVideoTitleLength {
+		return nil, fmt.Errorf("%w: title must be 1-%d characters", ErrInvalidVideo, maxVideoTitleLength)
+	}
+	if _, err := svc.ownVideo(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	v, err := svc.store.Videos.UpdateTitle(ctx, id, title)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, ErrVideoNotFound
+	}
+	return v, nil
+}
+
+// DeleteVideo removes one of the user's videos and its files. A broadcast
+// cannot be deleted while it is still being recorded.
+func (svc *Service) DeleteVideo(ctx context.Context, userID, id primitive.ObjectID) error {
+	v, err := svc.ownVideo(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if v.Status == models.VideoRecording {
+		return ErrVideoRecording
+	}
+	if err := svc.store.Videos.Delete(ctx, id); err != nil {
+		return err
+	}
+	if err := svc.archiver.Remove(id.Hex()); err != nil {
+		log.Printf("vod: failed to remove files of %s: %v", id.Hex(), err)
+	}
+	return nil
+}
+
+func (svc *Service) ownVideo(ctx context.Context, userID, id primitive.ObjectID) (*models.Video, error) {
+	v, err := svc.findVideo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if v.UserID != userID {
+		return nil, ErrForbidden
+	}
+	return v, nil
+}
@@ -0,0 +1,303 @@
+package vod
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// PlaylistName is the playlist file inside every recording directory.
+const PlaylistName = "index.m3u8"
+
+// ErrNotRecording is returned by Stop when the stream has no active recording.
+var ErrNotRecording = errors.New("vod: stream is not being recorded")
+
+// segmentName matches the files a recording directory may serve besides
+// its playlist.
+var segmentName = regexp.MustCompile(`^[0-9]{6}\.ts$`)
+
+// ValidFile reports whether name is a file a recording may contain, so
+// that it is safe to join onto the recording directory.
+func ValidFile(name string) bool {
+	return name == PlaylistName || segmentName.MatchString(name)
+}
+
+// Summary describes a finished recording.
+type Summary struct {
+	Segments int
+	Duration float64 // seconds
+}
+
+// Archiver copies the HLS segments that the RTMP server writes for a live
+// stream into a per-recording directory before they rotate out of the
+// live playlist, and keeps that directory's playlist up to date.
+type Archiver struct {
+	hlsDir   string
+	vodDir   string
+	interval time.Duration
+
+	mu     sync.Mutex
+	active map[string]*recording // by HLS stream name
+}
+
+// recording is the archive of one broadcast in progress.
+type recording struct {
+	name string // HLS stream name: <hlsDir>/<name>.m3u8
+	dir  string
+
+	mu   sync.Mutex // guards segs, which clips read while polling appends
+	segs []Segment
+	seen map[string]bool // source segment names already copied
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewArchiver archives streams found in hlsDir into subdirectories of
+// vodDir, polling the live playlists every interval. The interval must be
+// shorter than the live playlist window or segments will be missed.
+func NewArchiver(hlsDir, vodDir string, interval time.Duration) *Archiver {
+	return &Archiver{
+		hlsDir:   hlsDir,
+		vodDir:   vodDir,
+		interval: interval,
+		active:   make(map[string]*recording),
+	}
+}
+
+// Dir returns the directory of the recording with the given ID.
+func (a *Archiver) Dir(id string) string {
+	return filepath.Join(a.vodDir, id)
+}
+
+// Path returns the path of file inside the recording, or "" if file is
+// not a valid recording file name.
+func (a *Archiver) Path(id, file string) string {
+	if !ValidFile(file) {
+		return ""
+	}
+	return filepath.Join(a.Dir(id), file)
+}
+
+// Start begins archiving the live stream name into the recording id. If
+// the recording directory already holds segments (e.g. after a restart)
+// archiving continues after them. A recording already running for name
+// is stopped first.
+func (a *Archiver) Start(name, id string) error {
+	if _, err := a.Stop(name); err != nil && !errors.Is(err, ErrNotRecording) {
+		log.Printf("vod: failed to stop previous recording of %s: %v", name, err)
+	}
+
+	dir := a.Dir(id)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	segs, err := readPlaylist(dir)
+	if err != nil {
+		return err
+	}
+	rec := &recording{
+		name: name,
+		dir:  dir,
+		segs: segs,
+		seen: make(map[string]bool),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	if len(segs) > 0 {
+		// Resuming: we cannot tell which of the segments still in the live
+		// window were already copied, so skip them rather than repeat video.
+		if live, err := a.livePlaylist(name); err == nil {
+			for _, s := range live {
+				rec.seen[filepath.Base(s.URI)] = true
+			}
+		}
+	}
+
+	a.mu.Lock()
+	a.active[name] = rec
+	a.mu.Unlock()
+
+	go a.run(rec)
+	return nil
+}
+
+// Stop archives whatever the live playlist still lists, writes the final
+// VOD playlist and returns a summary of the recording.
+func (a *Archiver) Stop(name string) (Summary, error) {
+	a.mu.Lock()
+	rec := a.active[name]
+	delete(a.active, name)
+	a.mu.Unlock()
+	if rec == nil {
+		return Summary{}, ErrNotRecording
+	}
+
+	close(rec.stop)
+	<-rec.done
+	if err := a.poll(rec); err != nil {
+		log.Printf("vod: final poll of %s failed: %v", name, err)
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if err := writePlaylist(rec.dir, rec.segs, true); err != nil {
+		return Summary{}, err
+	}
+	return Summary{Segments: len(rec.segs), Duration: TotalDuration(rec.segs)}, nil
+}
+
+// Finalize closes the playlist of a recording that is not running, e.g.
+// one interrupted by a restart after its broadcast ended.
+func (a *Archiver) Finalize(id string) (Summary, error) {
+	dir := a.Dir(id)
+	segs, err := readPlaylist(dir)
+	if err != nil {
+		return Summary{}, err
+	}
+	if len(segs) > 0 {
+		if err := writePlaylist(dir, segs, true); err != nil {
+			return Summary{}, err
+		}
+	}
+	return Summary{Segments: len(segs), Duration: TotalDuration(segs)}, nil
+}
+
+// Remove deletes a recording's directory.
+func (a *Archiver) Remove(id string) error {
+	return os.RemoveAll(a.Dir(id))
+}
+
+func (a *Archiver) run(rec *recording) {
+	defer close(rec.done)
+	t := time.NewTicker(a.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-rec.stop:
+			return
+		case <-t.C:
+			if err := a.poll(rec); err != nil {
+				log.Printf("vod: polling %s: %v", rec.name, err)
+			}
+		}
+	}
+}
+
+// poll copies segments of the live playlist that have not been archived
+// yet and rewrites the recording's playlist if anything was added.
+func (a *Archiver) poll(rec *recording) error {
+	live, err := a.livePlaylist(rec.name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // the RTMP server has not written the first segment yet
+		}
+		return err
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	added := 0
+	for _, s := range live {
+		src := filepath.Base(s.URI)
+		if rec.seen[src] {
+			continue
+		}
+		dst := segmentFile(len(rec.segs))
+		if err := copyFile(filepath.Join(a.hlsDir, src), filepath.Join(rec.dir, dst)); err != nil {
+			if os.IsNotExist(err) {
+				// Rotated out before we got to it; nothing to do but skip it.
+				rec.seen[src] = true
+				continue
+			}
+			return err
+		}
+		rec.seen[src] = true
+		rec.segs = append(rec.segs, Segment{URI: dst, Duration: s.Duration})
+		added++
+	}
+	if added == 0 {
+		return nil
+	}
+	return writePlaylist(rec.dir, rec.segs, false)
+}
+
+// segmentFile names the i-th segment of a recording or clip.
+func segmentFile(i int) string {
+	return fmt.Sprintf("%06d.ts", i)
+}
+
+func (a *Archiver) livePlaylist(name string) ([]Segment, error) {
+	f, err := os.Open(filepath.Join(a.hlsDir, name+".m3u8"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseMediaPlaylist(f)
+}
+
+func readPlaylist(dir string) ([]Segment, error) {
+	f, err := os.Open(filepath.Join(dir, PlaylistName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return ParseMediaPlaylist(f)
+}
+
+// writePlaylist replaces the playlist atomically so players never read a
+// half-written file.
+func writePlaylist(dir string, segs []Segment, ended bool) error {
+	tmp, err := os.CreateTemp(dir, ".index-*.m3u8")
+	if err != nil {
+		return err
+	}
+	if err := WriteMediaPlaylist(tmp, segs, ended); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, PlaylistName))
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".part"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
@@ -0,0 +1,93 @@
+package vod
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ClipSummary describes a clip cut from a live recording.
+type ClipSummary struct {
+	Summary
+	// Offset is where the clip starts within its broadcast, in seconds.
+	Offset float64
+}
+
+// ClipDir returns the directory of the clip with the given ID.
+func (a *Archiver) ClipDir(id string) string {
+	return filepath.Join(a.vodDir, "clips", id)
+}
+
+// ClipPath returns the path of file inside the clip, or "" if file is not
+// a valid recording file name.
+func (a *Archiver) ClipPath(id, file string) string {
+	if !ValidFile(file) {
+		return ""
+	}
+	return filepath.Join(a.ClipDir(id), file)
+}
+
+// Clip snapshots the last seconds of the live stream name into a
+// standalone VOD playlist in the clip directory id. The clip may be a
+// little longer than asked since segments are never split.
+func (a *Archiver) Clip(name, id string, seconds float64) (ClipSummary, error) {
+	a.mu.Lock()
+	rec := a.active[name]
+	a.mu.Unlock()
+	if rec == nil {
+		return ClipSummary{}, ErrNotRecording
+	}
+
+	// Catch up with the live playlist so the clip ends at the live edge.
+	if err := a.poll(rec); err != nil {
+		return ClipSummary{}, err
+	}
+	rec.mu.Lock()
+	first := len(rec.segs)
+	var length float64
+	for first > 0 && length < seconds {
+		first--
+		length += rec.segs[first].Duration
+	}
+	src := append([]Segment(nil), rec.segs[first:]...)
+	offset := TotalDuration(rec.segs[:first])
+	rec.mu.Unlock()
+
+	if len(src) == 0 {
+		return ClipSummary{}, ErrNotRecording
+	}
+
+	dir := a.ClipDir(id)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return ClipSummary{}, err
+	}
+	segs := make([]Segment, len(src))
+	for i, s := range src {
+		segs[i] = Segment{URI: segmentFile(i), Duration: s.Duration}
+		if err := linkOrCopy(filepath.Join(rec.dir, s.URI), filepath.Join(dir, segs[i].URI)); err != nil {
+			os.RemoveAll(dir)
+			return ClipSummary{}, err
+		}
+	}
+	if err := writePlaylist(dir, segs, true); err != nil {
+		os.RemoveAll(dir)
+		return ClipSummary{}, err
+	}
+	return ClipSummary{
+		Summary: Summary{Segments: len(segs), Duration: TotalDuration(segs)},
+		Offset:  offset,
+	}, nil
+}
+
+// RemoveClip deletes a clip's directory.
+func (a *Archiver) RemoveClip(id string) error {
+	return os.RemoveAll(a.ClipDir(id))
+}
+
+// linkOrCopy hard-links src to dst so clips share storage with their
+// recording and survive its deletion, copying when linking is not possible.
+func linkOrCopy(src, dst string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	return copyFile(src, dst)
+}
@@ -0,0 +1,88 @@
+// Package vod archives live HLS output into per-broadcast recordings.
+package vod
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Segment is one media segment of an HLS playlist.
+type Segment struct {
+	URI      string
+	Duration float64 // seconds
+}
+
+// ParseMediaPlaylist reads the segments of an HLS media playlist. Tags
+// other than #EXTINF are ignored.
+func ParseMediaPlaylist(r io.Reader) ([]Segment, error) {
+	var (
+		segs     []Segment
+		duration float64
+		pending  bool
+	)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXTINF:"):
+			v := strings.TrimPrefix(line, "#EXTINF:")
+			if i := strings.IndexByte(v, ','); i >= 0 {
+				v = v[:i]
+			}
+			d, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("vod: bad EXTINF %q", line)
+			}
+			duration, pending = d, true
+		case strings.HasPrefix(line, "#"):
+		default:
+			if pending {
+				segs = append(segs, Segment{URI: line, Duration: duration})
+				pending = false
+			}
+		}
+	}
+	return segs, sc.Err()
+}
+
+// WriteMediaPlaylist writes segs as an HLS media playlist. An ended
+// playlist is a complete VOD; otherwise it is an EVENT playlist that
+// players keep polling while the broadcast is still being archived.
+func WriteMediaPlaylist(w io.Writer, segs []Segment, ended bool) error {
+	target := 1.0
+	for _, s := range segs {
+		target = math.Max(target, s.Duration)
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "#EXTM3U")
+	fmt.Fprintln(bw, "#EXT-X-VERSION:3")
+	if ended {
+		fmt.Fprintln(bw, "#EXT-X-PLAYLIST-TYPE:VOD")
+	} else {
+		fmt.Fprintln(bw, "#EXT-X-PLAYLIST-TYPE:EVENT")
+	}
+	fmt.Fprintf(bw, "#EXT-X-TARGETDURATION:%d\n", int(math.Ceil(target)))
+	fmt.Fprintln(bw, "#EXT-X-MEDIA-SEQUENCE:0")
+	for _, s := range segs {
+		fmt.Fprintf(bw, "#EXTINF:%.3f,\n%s\n", s.Duration, s.URI)
+	}
+	if ended {
+		fmt.Fprintln(bw, "#EXT-X-ENDLIST")
+	}
+	return bw.Flush()
+}
+
+// TotalDuration sums the durations of segs.
+func TotalDuration(segs []Segment) float64 {
+	var total float64
+	for _, s := range segs {
+		total += s.Duration
+	}
+	return total
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ViewerSession is one viewer watching one broadcast. Viewer is an
+// anonymized ID: stable per logged-in user, per playback session for
+// anonymous viewers, and not reversible to either.
+type ViewerSession struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ChannelID   primitive.ObjectID `json:"channel_id" bson:"channel_id"`
+	BroadcastID primitive.ObjectID `json:"broadcast_id" bson:"broadcast_id"`
+	Viewer      string             `json:"viewer" bson:"viewer"`
+	JoinedAt    time.Time          `json:"joined_at" bson:"joined_at"`
+	LeftAt      time.Time          `json:"left_at" bson:"left_at"`
+}
+
+// DailyStats holds a channel's counters for one UTC day (YYYY-MM-DD).
+// Chat history expires, so messages are counted as they are posted.
+type DailyStats struct {
+	ChannelID    primitive.ObjectID `json:"channel_id" bson:"channel_id"`
+	Day          string             `json:"day" bson:"day"`
+	ChatMessages int64              `json:"chat_messages" bson:"chat_messages"`
+	NewFollowers int64              `json:"new_followers" bson:"new_followers"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Broadcast event types.
+const (
+	BroadcastTitleChanged    = "title_changed"
+	BroadcastCategoryChanged = "category_changed"
+)
+
+// BroadcastEvent is a change the broadcaster made while live.
+type BroadcastEvent struct {
+	Type string    `json:"type" bson:"type"`
+	From string    `json:"from" bson:"from"`
+	To   string    `json:"to" bson:"to"`
+	At   time.Time `json:"at" bson:"at"`
+}
+
+// Broadcast is one time a channel went live, from publish start to end.
+// Its ID is the BroadcastID the stream carried; title, category, tags and
+// visibility are as they were when it started.
+type Broadcast struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id"`
+	ChannelID       primitive.ObjectID `json:"channel_id" bson:"channel_id"`
+	Username        string             `json:"username" bson:"username"`
+	Title           string             `json:"title" bson:"title"`
+	Category        string             `json:"category" bson:"category"`
+	Tags            []string           `json:"tags" bson:"tags"`
+	Visibility      string             `json:"visibility" bson:"visibility,omitempty"`
+	StartedAt       time.Time          `json:"started_at" bson:"started_at"`
+	EndedAt         *time.Time         `json:"ended_at,omitempty" bson:"ended_at,omitempty"`
+	DurationSeconds float64            `json:"duration_seconds" bson:"duration_seconds"`
+	PeakViewers     int                `json:"peak_viewers" bson:"peak_viewers"`
+	Events          []BroadcastEvent   `json:"events" bson:"events"`
+
+	// Counters for the owner's analytics.
+	ChatMessages int64 `json:"-" bson:"chat_messages"`
+	NewFollowers int64 `json:"-" bson:"new_followers"`
+
+	// VideoID links the recording, if it was kept; filled when listing.
+	VideoID *primitive.ObjectID `json:"video_id,omitempty" bson:"-"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Category is an admin-managed entry of the stream category catalog.
+// Streams reference categories by Slug.
+type Category struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Slug      string             `json:"slug" bson:"slug"`
+	Name      string             `json:"name" bson:"name"`
+	Icon      string             `json:"icon" bson:"icon"`
+	SortOrder int                `json:"sort_order" bson:"sort_order"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
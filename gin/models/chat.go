@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Chat badges shown next to a sender's name.
+const (
+	BadgeBroadcaster = "broadcaster"
+	BadgeModerator   = "moderator"
+	BadgeSubscriber  = "subscriber"
+)
+
+// ChatMessage is a message posted in a channel's chat room.
+type ChatMessage struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Channel     string             `json:"channel" bson:"channel"` // channel username (the room)
+	ChannelID   primitive.ObjectID `json:"channel_id" bson:"channel_id"`
+	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Username    string             `json:"username" bson:"username"`
+	DisplayName string             `json:"display_name" bson:"display_name"`
+	Badges      []string           `json:"badges,omitempty" bson:"badges,omitempty"`
+	Text        string             `json:"text" bson:"text"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+
+	// Set on the message attached to a tip: the honey tipped.
+	Tip int64 `json:"tip,omitempty" bson:"tip,omitempty"`
+
+	// Set when a moderator removes the message; deleted messages are
+	// hidden from history.
+	Deleted   bool               `json:"deleted,omitempty" bson:"deleted,omitempty"`
+	DeletedBy primitive.ObjectID `json:"-" bson:"deleted_by,omitempty"`
+}
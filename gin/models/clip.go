@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Clip is a short cut of a live broadcast, owned by the viewer who made it.
+type Clip struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ChannelID       primitive.ObjectID `json:"channel_id" bson:"channel_id"`
+	ChannelUsername string             `json:"channel_username" bson:"channel_username"`
+	CreatorID       primitive.ObjectID `json:"creator_id" bson:"creator_id"`
+	CreatorUsername string             `json:"creator_username" bson:"creator_username"`
+	Title           string             `json:"title" bson:"title"`
+
+	// Source broadcast, as it was when the clip was made.
+	BroadcastID primitive.ObjectID `json:"broadcast_id" bson:"broadcast_id"`
+	VideoID     primitive.ObjectID `json:"video_id" bson:"video_id"`
+	StreamTitle string             `json:"stream_title" bson:"stream_title"`
+	Category    string             `json:"category" bson:"category"`
+	// Visibility is the source video's; private clips follow the channel's allowlist.
+	Visibility string `json:"visibility" bson:"visibility,omitempty"`
+	// SubscribersOnly is the source video's when the clip was made.
+	SubscribersOnly bool `json:"subscribers_only" bson:"subscribers_only,omitempty"`
+	// OffsetSeconds is where the clip starts within the broadcast's video.
+	OffsetSeconds float64 `json:"offset_seconds" bson:"offset_seconds"`
+
+	DurationSeconds float64   `json:"duration_seconds" bson:"duration_seconds"`
+	ViewCount       int64     `json:"view_count" bson:"view_count"`
+	CreatedAt       time.Time `json:"created_at" bson:"created_at"`
+}
+
+// Private reports whether only allowed viewers may see the clip.
+func (c *Clip) Private() bool {
+	return c.Visibility == VisibilityPrivate
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Follow is an edge of the social graph: FollowerID follows ChannelID.
+// Both are user IDs; a channel is just a user who streams.
+type Follow struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	FollowerID primitive.ObjectID `json:"follower_id" bson:"follower_id"`
+	ChannelID  primitive.ObjectID `json:"channel_id" bson:"channel_id"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+}
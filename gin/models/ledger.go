@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Currency is the name of the in-app currency.
+const Currency = "honey"
+
+// Ledger account kinds.
+const (
+	LedgerAccountUser   = "user"
+	LedgerAccountEscrow = "escrow"
+	LedgerAccountSystem = "system"
+)
+
+// SystemIssuance is the system account honey is granted from and
+// adjusted back into. System accounts are not checked for overdraft;
+// issuance is the only one that goes negative.
+const SystemIssuance = "system:issuance"
+
+// Transfer kinds.
+const (
+	TransferGrant      = "grant"
+	TransferAdjustment = "adjustment"
+	TransferTip        = "tip"
+	TransferMembership = "membership"
+
+	TransferPredictionStake  = "prediction_stake"
+	TransferPredictionPayout = "prediction_payout"
+	TransferPredictionRefund = "prediction_refund"
+)
+
+// LedgerAccount is a holder of honey. Its balance is the sum of its
+// entries; Seq only serializes concurrent transfers touching it.
+type LedgerAccount struct {
+	ID        string             `json:"id" bson:"_id"` // "<kind>:<id>", e.g. "user:<hex>"
+	Kind      string             `json:"kind" bson:"kind"`
+	UserID    primitive.ObjectID `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	Seq       int64              `json:"-" bson:"seq"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// UserAccountID is the ledger account of a user.
+func UserAccountID(userID primitive.ObjectID) string {
+	return "user:" + userID.Hex()
+}
+
+// LedgerTransfer moves Amount (always positive) from one account to
+// another. It is recorded with one debit and one credit entry.
+type LedgerTransfer struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	IdempotencyKey string             `json:"-" bson:"idempotency_key"` // unique, scoped to the initiator
+	Kind           string             `json:"kind" bson:"kind"`
+	From           string             `json:"from" bson:"from"`
+	To             string             `json:"to" bson:"to"`
+	Amount         int64              `json:"amount" bson:"amount"`
+	Memo           string             `json:"memo,omitempty" bson:"memo,omitempty"`
+	CreatedBy      primitive.ObjectID `json:"created_by" bson:"created_by"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// LedgerEntry is one side of a transfer: negative for the account paying,
+// positive for the one paid. The entries of a transfer sum to zero.
+type LedgerEntry struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TransferID   primitive.ObjectID `json:"transfer_id" bson:"transfer_id"`
+	Account      string             `json:"account" bson:"account"`
+	Counterparty string             `json:"counterparty" bson:"counterparty"`
+	Kind         string             `json:"kind" bson:"kind"`
+	Amount       int64              `json:"amount" bson:"amount"`
+	Memo         string             `json:"memo,omitempty" bson:"memo,omitempty"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Membership statuses.
+const (
+	MembershipActive  = "active"
+	MembershipExpired = "expired"
+)
+
+// MembershipTier is a level of support a channel offers, paid in honey
+// for every period of DurationDays.
+type MembershipTier struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ChannelID    primitive.ObjectID `json:"channel_id" bson:"channel_id"`
+	Name         string             `json:"name" bson:"name"`
+	Perks        []string           `json:"perks" bson:"perks"`
+	Price        int64              `json:"price" bson:"price"`
+	DurationDays int                `json:"duration_days" bson:"duration_days"`
+	// Archived tiers take no new members and do not renew.
+	Archived  bool      `json:"archived,omitempty" bson:"archived,omitempty"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Membership is a user's subscription to a channel. There is at most one
+// per user and channel; it is reused when they subscribe again.
+type Membership struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ChannelID       primitive.ObjectID `json:"channel_id" bson:"channel_id"`
+	ChannelUsername string             `json:"channel_username" bson:"channel_username"`
+	UserID          primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Username        string             `json:"username" bson:"username"`
+	TierID          primitive.ObjectID `json:"tier_id" bson:"tier_id"`
+	TierName        string             `json:"tier_name" bson:"tier_name"`
+	Status          string             `json:"status" bson:"status"`
+	// AutoRenew is cleared by cancelling; the membership then lasts until
+	// ExpiresAt.
+	AutoRenew  bool       `json:"auto_renew" bson:"auto_renew"`
+	StartedAt  time.Time  `json:"started_at" bson:"started_at"`
+	ExpiresAt  time.Time  `json:"expires_at" bson:"expires_at"`
+	CanceledAt *time.Time `json:"canceled_at,omitempty" bson:"canceled_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+// Active reports whether the membership grants its perks at now.
+func (m *Membership) Active(now time.Time) bool {
+	return m.Status == MembershipActive && now.Before(m.ExpiresAt)
+}
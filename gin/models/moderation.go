@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Moderation log actions.
+const (
+	ModActionBan             = "ban"
+	ModActionTimeout         = "timeout"
+	ModActionUnban           = "unban"
+	ModActionDeleteMessage   = "delete_message"
+	ModActionAddModerator    = "add_moderator"
+	ModActionRemoveModerator = "remove_moderator"
+	ModActionUpdateSettings  = "update_settings"
+)
+
+// ChannelModerator appoints UserID as a chat moderator of ChannelID.
+type ChannelModerator struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ChannelID primitive.ObjectID `json:"channel_id" bson:"channel_id"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Username  string             `json:"username" bson:"username"`
+	AddedBy   primitive.ObjectID `json:"added_by" bson:"added_by"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// ChannelBan keeps UserID out of ChannelID's chat. A nil ExpiresAt is a
+// permanent ban; otherwise it is a timeout, removed by a TTL index.
+type ChannelBan struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ChannelID primitive.ObjectID `json:"channel_id" bson:"channel_id"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Username  string             `json:"username" bson:"username"`
+	Reason    string             `json:"reason" bson:"reason"`
+	ExpiresAt *time.Time         `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+	CreatedBy primitive.ObjectID `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// BlockedTerm is a word or regular expression rejected in chat.
+// Plain terms match case-insensitively anywhere in the message.
+type BlockedTerm struct {
+	Pattern string `json:"pattern" bson:"pattern"`
+	Regex   bool   `json:"regex" bson:"regex"`
+}
+
+// ChatSettings are a channel's chat modes. The document ID is the channel's user ID.
+type ChatSettings struct {
+	ChannelID primitive.ObjectID `json:"channel_id" bson:"_id"`
+	// SlowModeSeconds is the minimum gap between two messages of a user; 0 disables it.
+	SlowModeSeconds int `json:"slow_mode_seconds" bson:"slow_mode_seconds"`
+	// FollowersOnly restricts chat to followers of at least FollowersOnlyMinutes.
+	FollowersOnly        bool          `json:"followers_only" bson:"followers_only"`
+	FollowersOnlyMinutes int           `json:"followers_only_minutes" bson:"followers_only_minutes"`
+	EmoteOnly            bool          `json:"emote_only" bson:"emote_only"`
+	BlockedTerms         []BlockedTerm `json:"blocked_terms" bson:"blocked_terms"`
+	UpdatedAt            time.Time     `json:"updated_at" bson:"updated_at"`
+}
+
+// ModerationAction is an entry of a channel's moderation log.
+type ModerationAction struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ChannelID      primitive.ObjectID `json:"channel_id" bson:"channel_id"`
+	Action         string             `json:"action" bson:"action"`
+	ActorID        primitive.ObjectID `json:"actor_id" bson:"actor_id"`
+	ActorUsername  string             `json:"actor_username" bson:"actor_username"`
+	TargetUserID   primitive.ObjectID `json:"target_user_id,omitempty" bson:"target_user_id,omitempty"`
+	TargetUsername string             `json:"target_username,omitempty" bson:"target_username,omitempty"`
+	MessageID      primitive.ObjectID `json:"message_id,omitempty" bson:"message_id,omitempty"`
+	Reason         string             `json:"reason,omitempty" bson:"reason,omitempty"`
+	DurationSecs   int                `json:"duration_seconds,omitempty" bson:"duration_seconds,omitempty"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+}
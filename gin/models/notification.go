@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification types.
+const (
+	NotificationWentLive    = "went_live"
+	NotificationNewFollower = "new_follower"
+	NotificationMention     = "mention"
+	NotificationModeration  = "moderation_action"
+	NotificationTip         = "tip"
+	NotificationMembership  = "membership"
+	NotificationRaid        = "raid"
+)
+
+// Notification is an entry in a user's inbox.
+type Notification struct {
+	ID     primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID primitive.ObjectID `json:"user_id" bson:"user_id"` // recipient
+	Type   string             `json:"type" bson:"type"`
+
+	// Who or what triggered it; ActorUsername is denormalised for display.
+	ActorID       primitive.ObjectID `json:"actor_id,omitempty" bson:"actor_id,omitempty"`
+	ActorUsername string             `json:"actor_username,omitempty" bson:"actor_username,omitempty"`
+
+	Message string            `json:"message" bson:"message"`
+	Data    map[string]string `json:"data,omitempty" bson:"data,omitempty"`
+
+	Read      bool       `json:"read" bson:"read"`
+	ReadAt    *time.Time `json:"read_at,omitempty" bson:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Poll statuses.
+const (
+	PollOpen   = "open"
+	PollClosed = "closed"
+)
+
+// PollOption is one answer of a poll with its running tally.
+type PollOption struct {
+	Text  string `json:"text" bson:"text"`
+	Votes int64  `json:"votes" bson:"votes"`
+}
+
+// Poll is a question put to a channel's chat during a broadcast.
+type Poll struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ChannelID   primitive.ObjectID `json:"channel_id" bson:"channel_id"`
+	BroadcastID primitive.ObjectID `json:"broadcast_id" bson:"broadcast_id"`
+	Question    string             `json:"question" bson:"question"`
+	Options     []PollOption       `json:"options" bson:"options"`
+	TotalVotes  int64              `json:"total_votes" bson:"total_votes"`
+	Status      string             `json:"status" bson:"status"`
+	CreatedBy   primitive.ObjectID `json:"created_by" bson:"created_by"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	EndsAt      time.Time          `json:"ends_at" bson:"ends_at"`
+	ClosedAt    *time.Time         `json:"closed_at,omitempty" bson:"closed_at,omitempty"`
+}
+
+// Open reports whether the poll takes votes at now.
+func (p *Poll) Open(now time.Time) bool {
+	return p.Status == PollOpen && now.Before(p.EndsAt)
+}
+
+// PollVote is a user's single vote in a poll.
+type PollVote struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	PollID    primitive.ObjectID `json:"poll_id" bson:"poll_id"`
+	ChannelID primitive.ObjectID `json:"channel_id" bson:"channel_id"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Option    int                `json:"option" bson:"option"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Prediction statuses. An open prediction takes stakes until LocksAt.
+const (
+	PredictionOpen     = "open"
+	PredictionLocked   = "locked"
+	PredictionResolved = "resolved"
+	PredictionCanceled = "canceled"
+)
+
+// PredictionOutcome is one possible result with the stakes placed on it.
+type PredictionOutcome struct {
+	Text   string `json:"text" bson:"text"`
+	Users  int64  `json:"users" bson:"users"`
+	Points int64  `json:"points" bson:"points"`
+}
+
+// Prediction lets viewers stake honey on how something during a
+// broadcast turns out. Stakes are held in the prediction's escrow account
+// until it is resolved, when the winners split the whole pool in
+// proportion to their stakes, or canceled, when everybody is refunded.
+type Prediction struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	ChannelID   primitive.ObjectID  `json:"channel_id" bson:"channel_id"`
+	BroadcastID primitive.ObjectID  `json:"broadcast_id" bson:"broadcast_id"`
+	Title       string              `json:"title" bson:"title"`
+	Outcomes    []PredictionOutcome `json:"outcomes" bson:"outcomes"`
+	Status      string              `json:"status" bson:"status"`
+	// WinningOutcome indexes Outcomes once resolved.
+	WinningOutcome *int               `json:"winning_outcome,omitempty" bson:"winning_outcome,omitempty"`
+	CreatedBy      primitive.ObjectID `json:"created_by" bson:"created_by"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	LocksAt        time.Time          `json:"locks_at" bson:"locks_at"`
+	EndedBy        primitive.ObjectID `json:"ended_by,omitempty" bson:"ended_by,omitempty"`
+	EndedAt        *time.Time         `json:"ended_at,omitempty" bson:"ended_at,omitempty"`
+	// Settled is set once every payout or refund has been made.
+	Settled bool `json:"settled" bson:"settled"`
+}
+
+// Open reports whether the prediction takes stakes at now.
+func (p *Prediction) Open(now time.Time) bool {
+	return p.Status == PredictionOpen && now.Before(p.LocksAt)
+}
+
+// Ended reports whether the prediction was resolved or canceled.
+func (p *Prediction) Ended() bool {
+	return p.Status == PredictionResolved || p.Status == PredictionCanceled
+}
+
+// PredictionEscrowAccount is the ledger account holding a prediction's
+// stakes. Escrow accounts are checked for overdraft like users' are, so
+// payouts can never exceed what was staked.
+func PredictionEscrowAccount(id primitive.ObjectID) string {
+	return LedgerAccountEscrow + ":prediction:" + id.Hex()
+}
+
+// PredictionStake is a user's single stake in a prediction.
+type PredictionStake struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	PredictionID primitive.ObjectID `json:"prediction_id" bson:"prediction_id"`
+	ChannelID    primitive.ObjectID `json:"channel_id" bson:"channel_id"`
+	UserID       primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Username     string             `json:"username" bson:"username"`
+	Outcome      int                `json:"outcome" bson:"outcome"`
+	Amount       int64              `json:"amount" bson:"amount"`
+	// Payout is what the stake returned: winnings, a refund, or 0.
+	Payout    int64     `json:"payout" bson:"payout"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
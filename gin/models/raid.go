@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Raid statuses.
+const (
+	RaidPending   = "pending"   // launches when the raider's broadcast ends
+	RaidCompleted = "completed" // viewers were sent to the target
+	RaidCanceled  = "canceled"  // called off, or the target could no longer be raided
+)
+
+// Raid hands the viewers of FromID's broadcast over to ToID's when it ends.
+// A channel has at most one pending raid.
+type Raid struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	FromID       primitive.ObjectID `json:"from_id" bson:"from_id"`
+	FromUsername string             `json:"from_username" bson:"from_username"`
+	ToID         primitive.ObjectID `json:"to_id" bson:"to_id"`
+	ToUsername   string             `json:"to_username" bson:"to_username"`
+	BroadcastID  primitive.ObjectID `json:"broadcast_id" bson:"broadcast_id"`
+	Status       string             `json:"status" bson:"status"`
+	// Viewers is the raid size: how many were watching when it launched.
+	Viewers    int        `json:"viewers" bson:"viewers"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	LaunchedAt *time.Time `json:"launched_at,omitempty" bson:"launched_at,omitempty"`
+}
+
+// RaidBlock keeps ChannelID from raiding the channel that blocked it.
+type RaidBlock struct {
+	ChannelID primitive.ObjectID `json:"channel_id" bson:"channel_id"`
+	Username  string             `json:"username" bson:"username"`
+}
+
+// RaidSettings restrict who may raid a channel. The document ID is the
+// channel's user ID.
+type RaidSettings struct {
+	ChannelID primitive.ObjectID `json:"channel_id" bson:"_id"`
+	// FollowersOnly accepts raids only from channels that follow this one.
+	FollowersOnly bool        `json:"followers_only" bson:"followers_only"`
+	Blocked       []RaidBlock `json:"blocked" bson:"blocked"`
+	UpdatedAt     time.Time   `json:"updated_at" bson:"updated_at"`
+}
+
+// Blocks reports whether the settings block channelID.
+func (s *RaidSettings) Blocks(channelID primitive.ObjectID) bool {
+	for _, b := range s.Blocked {
+		if b.ChannelID == channelID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RestreamDestination is an external RTMP endpoint a user's broadcasts are
+// forwarded to. The stream key is stored encrypted and never returned.
+type RestreamDestination struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Name      string             `json:"name" bson:"name"`
+	URL       string             `json:"url" bson:"url"`
+	KeyCipher string             `json:"-" bson:"key_cipher"`
+	KeyHint   string             `json:"key_hint" bson:"key_hint"` // last characters of the key
+	Enabled   bool               `json:"enabled" bson:"enabled"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
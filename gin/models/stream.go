@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Stream visibility modes.
+const (
+	VisibilityPublic   = "public"   // listed everywhere
+	VisibilityUnlisted = "unlisted" // watchable with the link, hidden from discovery and search
+	VisibilityPrivate  = "private"  // only the allowlist and invite holders may watch
+)
+
+// Stream holds a channel's broadcast metadata and live state.
+// Every user has at most one Stream document; it is created the first
+// time they edit their stream details or go live.
+type Stream struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Username    string             `json:"username" bson:"username"` // denormalised for search and display
+	Title       string             `json:"title" bson:"title"`
+	Description string             `json:"description" bson:"description"`
+	Category    string             `json:"category" bson:"category"`
+	Tags        []string           `json:"tags" bson:"tags"`
+	Live        bool               `json:"live" bson:"live"`
+	// BroadcastID identifies the current (or last) broadcast; a new one is
+	// issued every time the channel goes live.
+	BroadcastID primitive.ObjectID `json:"broadcast_id,omitempty" bson:"broadcast_id,omitempty"`
+	StartedAt   *time.Time         `json:"started_at,omitempty" bson:"started_at,omitempty"`
+	EndedAt     *time.Time         `json:"ended_at,omitempty" bson:"ended_at,omitempty"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+
+	// Visibility is one of the Visibility* modes; empty means public.
+	Visibility string `json:"visibility" bson:"visibility,omitempty"`
+	// AllowedUserIDs may watch a private stream besides its owner.
+	AllowedUserIDs []primitive.ObjectID `json:"-" bson:"allowed_user_ids,omitempty"`
+	// SubscribersOnly streams can be found as their visibility allows, but
+	// only the owner and active members may play them.
+	SubscribersOnly bool `json:"subscribers_only" bson:"subscribers_only,omitempty"`
+}
+
+// Listed reports whether the stream may appear in discovery and search.
+func (s *Stream) Listed() bool {
+	return s.Visibility == "" || s.Visibility == VisibilityPublic
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StreamInvite is a shareable link granting access to a private stream.
+type StreamInvite struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ChannelID primitive.ObjectID `json:"channel_id" bson:"channel_id"`
+	Code      string             `json:"code" bson:"code"`
+	MaxUses   int                `json:"max_uses" bson:"max_uses"` // 0 means unlimited
+	Uses      int                `json:"uses" bson:"uses"`
+	ExpiresAt *time.Time         `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
@@ -1,16 +1,69 @@
 package models
 
 import (
+	"strings"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// RoleAdmin marks a user allowed to use the /admin routes.
+// Roles are assigned directly in the database.
+const RoleAdmin = "admin"
+
 // User is the JSON representation we expose.
 // The BSON tag tells Mongo how to store the fields.
 type User struct {
 	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	Username     string             `json:"username" bson:"username"`
+	DisplayName  string             `json:"display_name" bson:"display_name"`
 	FirstName    string             `json:"first_name" bson:"first_name"`
 	LastName     string             `json:"last_name" bson:"last_name"`
 	Email        string             `json:"email" bson:"email"`
 	PasswordHash string             `json:"-" bson:"password_hash"` // omitted from JSON
+	Role         string             `json:"role,omitempty" bson:"role,omitempty"`
+
+	// Lower-cased copies of Username and DisplayName, so that
+	// case-insensitive prefix search can use an index. Set by SetSearchKeys.
+	UsernameLower    string `json:"-" bson:"username_lower"`
+	DisplayNameLower string `json:"-" bson:"display_name_lower"`
+
+	// Maintained with $inc whenever a follow edge is created or removed.
+	FollowerCount  int64 `json:"follower_count" bson:"follower_count"`
+	FollowingCount int64 `json:"following_count" bson:"following_count"`
+
+	// Set by an admin takedown: the user may not go live before then.
+	StreamSuspendedUntil *time.Time `json:"stream_suspended_until,omitempty" bson:"stream_suspended_until,omitempty"`
+	StreamSuspendReason  string     `json:"stream_suspend_reason,omitempty" bson:"stream_suspend_reason,omitempty"`
+}
+
+// SetSearchKeys fills the lower-cased copies of the names.
+func (u *User) SetSearchKeys() {
+	u.UsernameLower = strings.ToLower(u.Username)
+	u.DisplayNameLower = strings.ToLower(u.DisplayName)
+}
+
+// StreamSuspended reports whether the user is barred from going live at now.
+func (u *User) StreamSuspended(now time.Time) bool {
+	return u.StreamSuspendedUntil != nil && now.Before(*u.StreamSuspendedUntil)
+}
+
+// PublicProfile is the subset of a user that anyone may see.
+type PublicProfile struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id"`
+	Username       string             `json:"username" bson:"username"`
+	DisplayName    string             `json:"display_name" bson:"display_name"`
+	FollowerCount  int64              `json:"follower_count" bson:"follower_count"`
+	FollowingCount int64              `json:"following_count" bson:"following_count"`
+}
+
+// Public strips private fields (email, names, password hash, role).
+func (u *User) Public() PublicProfile {
+	return PublicProfile{
+		ID:             u.ID,
+		Username:       u.Username,
+		DisplayName:    u.DisplayName,
+		FollowerCount:  u.FollowerCount,
+		FollowingCount: u.FollowingCount,
+	}
 }
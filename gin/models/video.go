@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Video statuses.
+const (
+	VideoRecording = "recording" // the broadcast is still live and being archived
+	VideoReady     = "ready"     // the broadcast ended and the VOD playlist is final
+)
+
+// Video is the recording of one broadcast. Its files live in a directory
+// named after the video ID.
+type Video struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID          primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Username        string             `json:"username" bson:"username"`
+	BroadcastID     primitive.ObjectID `json:"broadcast_id" bson:"broadcast_id"`
+	Title           string             `json:"title" bson:"title"`
+	Category        string             `json:"category" bson:"category"`
+	Tags            []string           `json:"tags" bson:"tags"`
+	Status          string             `json:"status" bson:"status"`
+	Visibility      string             `json:"visibility" bson:"visibility,omitempty"` // the stream's, when recorded
+	SubscribersOnly bool               `json:"subscribers_only" bson:"subscribers_only,omitempty"`
+	DurationSeconds float64            `json:"duration_seconds" bson:"duration_seconds"`
+	SegmentCount    int                `json:"segment_count" bson:"segment_count"`
+	StartedAt       time.Time          `json:"started_at" bson:"started_at"`
+	EndedAt         *time.Time         `json:"ended_at,omitempty" bson:"ended_at,omitempty"`
+	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Private reports whether only allowed viewers may see the video.
+func (v *Video) Private() bool {
+	return v.Visibility == VisibilityPrivate
+}
@@ -8,4 +8,17 @@ else
   cp /etc/nginx/nginx.conf.default /etc/nginx/nginx.conf
 fi
 
+# The API refuses ingest callbacks without the shared secret.
+if [ -z "$INGEST_SECRET" ]; then
+  echo "INGEST_SECRET is not set" >&2
+  exit 1
+fi
+case "$INGEST_SECRET" in
+  *[!A-Za-z0-9._~-]*)
+    echo "INGEST_SECRET may only contain letters, digits and . _ ~ -" >&2
+    exit 1
+    ;;
+esac
+sed -i "s|__INGEST_SECRET__|$INGEST_SECRET|g" /etc/nginx/nginx.conf
+
 exec "$@"